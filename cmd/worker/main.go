@@ -1,75 +1,78 @@
 package main
 
-// import (
-// 	"context"
-// 	"os"
-// 	"os/signal"
-// 	"syscall"
-// 	"time"
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-// 	"server/internal/config"
-// 	"server/internal/infrastructure/database/postgres"
-// 	"server/internal/worker"
-// 	"server/pkg/logger"
-// )
+	"server/internal/config"
+	"server/internal/domain/role"
+	"server/internal/infrastructure/database/postgres"
+	"server/internal/worker"
+	"server/pkg/logger"
+)
 
-// func main() {
-// 	// Initialize logger
-// 	log := logger.NewLogger()
-// 	log.Info("Starting TNP RGPV Background Worker...")
+func main() {
+	// Initialize logger
+	log := logger.NewLogger()
+	log.Info("Starting TNP RGPV Background Worker...")
 
-// 	// Load configuration
-// 	cfg, err := config.Load()
-// 	if err != nil {
-// 		log.Fatal("Failed to load configuration", "error", err)
-// 	}
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration", "error", err)
+	}
 
-// 	// Connect to PostgreSQL
-// 	db, err := postgres.NewConnection(cfg.Database)
-// 	if err != nil {
-// 		log.Fatal("Failed to connect to PostgreSQL", "error", err)
-// 	}
+	// Connect to PostgreSQL
+	db, err := postgres.NewConnection(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to PostgreSQL", "error", err)
+	}
 
-// 	// Ensure database connection is closed when the application exits
-// 	defer func() {
-// 		db.Close()
-// 		log.Info("PostgreSQL connection pool closed")
-// 	}()
+	// Ensure database connection is closed when the application exits
+	defer func() {
+		db.Close()
+		log.Info("PostgreSQL connection pool closed")
+	}()
 
-// 	// Create context for worker coordination
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
+	// Create context for worker coordination. Workers act for the platform rather than for a
+	// caller, so they reach every student.
+	ctx, cancel := context.WithCancel(role.WithAccessScope(context.Background(), role.UnrestrictedAccess))
+	defer cancel()
 
-// 	// Initialize and start workers
-// 	workers := []worker.Worker{
-// 		worker.NewEmailWorker(db, cfg, log),
-// 		worker.NewNotificationWorker(db, cfg, log),
-// 		worker.NewAnalyticsWorker(db, cfg, log),
-// 		// Add additional workers as needed
-// 	}
+	// Initialize and start workers
+	workers := []worker.Worker{
+		worker.NewTokenCleanupWorker(db, cfg, log),
+		worker.NewRecoveryBinWorker(db, cfg, log),
+		worker.NewAttendanceAlertWorker(db, cfg, log),
+		worker.NewQuizAttemptWorker(db, cfg, log),
+		// Add additional workers as needed
+	}
 
-// 	// Start all workers
-// 	for _, w := range workers {
-// 		go func(worker worker.Worker) {
-// 			if err := worker.Start(ctx); err != nil {
-// 				log.Error("Worker failed", "worker", worker.Name(), "error", err)
-// 			}
-// 		}(w)
-// 		log.Info("Started worker", "worker", w.Name())
-// 	}
+	// Start all workers
+	for _, w := range workers {
+		go func(worker worker.Worker) {
+			if err := worker.Start(ctx); err != nil {
+				log.Error("Worker failed", "worker", worker.Name(), "error", err)
+			}
+		}(w)
+		log.Info("Started worker", "worker", w.Name())
+	}
 
-// 	// Set up signal handling for graceful shutdown
-// 	quit := make(chan os.Signal, 1)
-// 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-// 	<-quit
+	// Set up signal handling for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
 
-// 	log.Info("Shutdown signal received, stopping workers...")
+	log.Info("Shutdown signal received, stopping workers...")
 
-// 	// Cancel context to stop all workers
-// 	cancel()
+	// Cancel context to stop all workers
+	cancel()
 
-// 	// Allow workers time to clean up
-// 	time.Sleep(3 * time.Second)
+	// Allow workers time to clean up
+	time.Sleep(3 * time.Second)
 
-// 	log.Info("All workers stopped successfully")
-// }
\ No newline at end of file
+	log.Info("All workers stopped successfully")
+}
@@ -0,0 +1,69 @@
+package attendance
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests marking the attendance of students
+type Handler struct {
+	studentService *student.Service
+	logger         logger.Logger
+}
+
+// NewHandler creates a new attendance Handler instance
+func NewHandler(studentService *student.Service, logger logger.Logger) *Handler {
+	return &Handler{
+		studentService: studentService,
+		logger:         logger,
+	}
+}
+
+// MarkSection records one class for a whole section and returns the attendance statistics of its
+// students. The class is recorded by the authenticated coordinator.
+func (h *Handler) MarkSection(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var sheet student.SectionAttendance
+	if err := c.ShouldBindJSON(&sheet); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return
+	}
+	sheet.RecordedBy = principal.ProfileID
+
+	result, err := h.studentService.MarkSectionAttendance(c.Request.Context(), sheet)
+	if err != nil {
+		switch {
+		case stderrors.Is(err, student.ErrSectionNotFound):
+			errors.NotFound("Section").RespondWithError(c)
+		case stderrors.Is(err, student.ErrInvalidAttendance), stderrors.Is(err, student.ErrStudentNotInSection):
+			errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		default:
+			h.logger.Error("Failed to mark section attendance", "actor_id", principal.ProfileID, "error", err)
+			errors.FromDomainError(err).RespondWithError(c)
+		}
+		return
+	}
+
+	h.logger.Info(
+		"Section attendance marked",
+		"actor_id", principal.ProfileID,
+		"course_id", sheet.CourseID,
+		"branch", sheet.Branch,
+		"section", sheet.Section,
+		"marked", result.Marked,
+		"unchanged", result.Unchanged,
+	)
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,195 @@
+package contact
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/role"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handler handles HTTP requests for the emergency contacts of students and for messaging them
+type Handler struct {
+	studentService *student.Service
+	logger         logger.Logger
+}
+
+// NewHandler creates a new contact Handler instance
+func NewHandler(studentService *student.Service, logger logger.Logger) *Handler {
+	return &Handler{
+		studentService: studentService,
+		logger:         logger,
+	}
+}
+
+// List returns the contacts of the student, the primary emergency contact first
+func (h *Handler) List(c *gin.Context) {
+	studentID, ok := h.studentID(c)
+	if !ok {
+		return
+	}
+
+	contacts, err := h.studentService.GetContacts(c.Request.Context(), studentID)
+	if err != nil {
+		h.respondWithError(c, "Failed to get contacts", studentID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contacts)
+}
+
+// Add adds a contact to the student
+func (h *Handler) Add(c *gin.Context) {
+	studentID, ok := h.studentID(c)
+	if !ok {
+		return
+	}
+
+	var req student.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return
+	}
+
+	contact, err := h.studentService.AddContact(c.Request.Context(), studentID, req)
+	if err != nil {
+		h.respondWithError(c, "Failed to add contact", studentID, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, contact)
+}
+
+// Update replaces the details of a contact of the student
+func (h *Handler) Update(c *gin.Context) {
+	studentID, ok := h.studentID(c)
+	if !ok {
+		return
+	}
+
+	contactID, err := uuid.Parse(c.Param("contactId"))
+	if err != nil {
+		errors.BadRequest("Invalid contact ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req student.ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return
+	}
+
+	contact, err := h.studentService.UpdateContact(c.Request.Context(), studentID, contactID, req)
+	if err != nil {
+		h.respondWithError(c, "Failed to update contact", studentID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contact)
+}
+
+// Delete deletes a contact of the student
+func (h *Handler) Delete(c *gin.Context) {
+	studentID, ok := h.studentID(c)
+	if !ok {
+		return
+	}
+
+	contactID, err := uuid.Parse(c.Param("contactId"))
+	if err != nil {
+		errors.BadRequest("Invalid contact ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.studentService.DeleteContact(c.Request.Context(), studentID, contactID); err != nil {
+		h.respondWithError(c, "Failed to delete contact", studentID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
+
+// NotifyGuardians emails a message to the emergency contacts of the selected students and reports
+// the outcome for every student
+func (h *Handler) NotifyGuardians(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req student.GuardianNotification
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return
+	}
+
+	result, err := h.studentService.NotifyGuardians(c.Request.Context(), req)
+	if err != nil {
+		h.respondWithError(c, "Failed to notify guardians", principal.ProfileID, err)
+		return
+	}
+
+	h.logger.Info(
+		"Guardians notified",
+		"actor_id", principal.ProfileID,
+		"students", len(result.Students),
+		"sent", result.Sent,
+		"failed", result.Failed,
+	)
+	c.JSON(http.StatusOK, result)
+}
+
+// studentID returns the student of the "id" path parameter or, on the routes of the caller's own
+// contacts, the student linked to the caller's profile. Writes the error response when there is none.
+// The own routes skip the permission check, their request is given an unrestricted access scope since
+// the caller only ever reaches its own student.
+func (h *Handler) studentID(c *gin.Context) (uuid.UUID, bool) {
+	if param := c.Param("id"); param != "" {
+		studentID, err := uuid.Parse(param)
+		if err != nil {
+			errors.BadRequest("Invalid student ID", nil).RespondWithError(c)
+			return uuid.Nil, false
+		}
+		return studentID, true
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return uuid.Nil, false
+	}
+
+	c.Request = c.Request.WithContext(role.WithAccessScope(c.Request.Context(), role.UnrestrictedAccess))
+
+	own, err := h.studentService.GetByProfileID(c.Request.Context(), principal.ProfileID)
+	if err != nil {
+		errors.NotFound("Student").RespondWithError(c)
+		return uuid.Nil, false
+	}
+
+	return own.ID, true
+}
+
+// respondWithError maps the errors of the student service to API errors
+func (h *Handler) respondWithError(c *gin.Context, message string, id uuid.UUID, err error) {
+	switch {
+	case stderrors.Is(err, student.ErrStudentNotFound):
+		errors.NotFound("Student").RespondWithError(c)
+	case stderrors.Is(err, student.ErrContactNotFound):
+		errors.NotFound("Contact").RespondWithError(c)
+	case stderrors.Is(err, student.ErrInvalidContact), stderrors.Is(err, student.ErrInvalidGuardianNotification):
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+	case stderrors.Is(err, student.ErrPrimaryContactRequired):
+		errors.Conflict("Mark another emergency contact as primary to replace the primary contact", nil).RespondWithError(c)
+	default:
+		h.logger.Error(message, "id", id, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+	}
+}
@@ -0,0 +1,56 @@
+package dossier
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"server/internal/common/errors"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles HTTP requests for the dossiers of students
+type Handler struct {
+	studentService *student.Service
+	logger         logger.Logger
+}
+
+// NewHandler creates a new dossier Handler instance
+func NewHandler(studentService *student.Service, logger logger.Logger) *Handler {
+	return &Handler{
+		studentService: studentService,
+		logger:         logger,
+	}
+}
+
+// Get returns the dossier of the student with the enrollment number, limited to the sections of
+// the comma separated "sections" query parameter. Sensitive fields are redacted for non-admins.
+func (h *Handler) Get(c *gin.Context) {
+	enrollmentNo := c.Param("enrollmentNo")
+
+	var options student.DossierOptions
+	if sections := c.Query("sections"); sections != "" {
+		for _, section := range strings.Split(sections, ",") {
+			options.Sections = append(options.Sections, student.DossierSection(strings.TrimSpace(section)))
+		}
+	}
+
+	dossier, err := h.studentService.GetStudentDossier(c.Request.Context(), enrollmentNo, options)
+	if err != nil {
+		switch {
+		case stderrors.Is(err, student.ErrStudentNotFound):
+			errors.NotFound("Student").RespondWithError(c)
+		case stderrors.Is(err, student.ErrInvalidDossierSection):
+			errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		default:
+			h.logger.Error("Failed to get dossier", "enrollment_no", enrollmentNo, "error", err)
+			errors.FromDomainError(err).RespondWithError(c)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dossier)
+}
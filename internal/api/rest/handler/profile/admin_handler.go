@@ -0,0 +1,332 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminHandler handles HTTP requests for administering other platform profiles
+type AdminHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(profileService platform_profile.Service, logger logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// List returns a page of the profiles within the administrator's scope, optionally filtered
+func (h *AdminHandler) List(c *gin.Context) {
+	var req platform_profile.ListProfilesRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	list, err := h.profileService.ListProfiles(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to list profiles", "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// AssignRole assigns a role to a profile, optionally scoped to a branch and/or batch
+func (h *AdminHandler) AssignRole(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.AssignRoleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("Not authenticated").RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.AssignRole(c.Request.Context(), principal.ProfileID, profileID, req.RoleID, req.Scope); err != nil {
+		h.logger.Error("Failed to assign role", "profile_id", profileID, "role_id", req.RoleID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role assigned successfully"})
+}
+
+// RemoveRole removes a role from a profile in every scope it is assigned in
+func (h *AdminHandler) RemoveRole(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	roleID, err := uuid.Parse(c.Param("roleId"))
+	if err != nil {
+		errors.BadRequest("Invalid role ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.RemoveRole(c.Request.Context(), profileID, roleID); err != nil {
+		h.logger.Error("Failed to remove role", "profile_id", profileID, "role_id", roleID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role removed successfully"})
+}
+
+// UnlockAccount lifts the lock of a profile locked after failed logins. The unlock is recorded in the audit log.
+func (h *AdminHandler) UnlockAccount(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.AdminUnlockRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.AdminUnlockAccount(c.Request.Context(), principal.ProfileID, profileID, req); err != nil {
+		h.logger.Error("Failed to unlock profile", "profile_id", profileID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked successfully"})
+}
+
+// ForcePasswordChange requires a profile to change its password at the next login and ends its sessions
+func (h *AdminHandler) ForcePasswordChange(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.ForcePasswordChange(c.Request.Context(), profileID); err != nil {
+		h.logger.Error("Failed to force password change", "profile_id", profileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password change required at next login"})
+}
+
+// SendInvitation mails a new link to set the password of a profile created on the owner's behalf
+func (h *AdminHandler) SendInvitation(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.SendInvitation(c.Request.Context(), profileID); err != nil {
+		h.logger.Error("Failed to send invitation", "profile_id", profileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation sent successfully"})
+}
+
+// BulkCreate creates many profiles at once and reports the outcome of every row
+func (h *AdminHandler) BulkCreate(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.BulkCreateProfilesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.profileService.BulkCreateProfiles(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		h.logger.Error("Failed to bulk create profiles", "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkUpdateStatus activates, suspends or locks many profiles at once and reports the outcome of every row
+func (h *AdminHandler) BulkUpdateStatus(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.BulkStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.profileService.BulkUpdateStatus(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		h.logger.Error("Failed to bulk update profile status", "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkAssignRole assigns a role to many profiles at once and reports the outcome of every row
+func (h *AdminHandler) BulkAssignRole(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.BulkRoleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.profileService.BulkAssignRole(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		h.logger.Error("Failed to bulk assign role", "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkDelete deletes many profiles at once and reports the outcome of every row
+func (h *AdminHandler) BulkDelete(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.BulkDeleteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.profileService.BulkDeleteProfiles(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		h.logger.Error("Failed to bulk delete profiles", "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SoftDelete moves a profile and its student records to the recovery bin. The body with the reason is optional.
+func (h *AdminHandler) SoftDelete(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.SoftDeleteRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.SoftDeleteProfile(c.Request.Context(), principal.ProfileID, profileID, req); err != nil {
+		h.logger.Error("Failed to soft delete profile", "profile_id", profileID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile moved to the recovery bin"})
+}
+
+// ListDeleted returns a page of the recovery bin, optionally searched by username or email
+func (h *AdminHandler) ListDeleted(c *gin.Context) {
+	var req platform_profile.ListDeletedProfilesRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	list, err := h.profileService.ListDeletedProfiles(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to list deleted profiles", "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// LookupDeleted finds a profile in the recovery bin by its exact username or email
+func (h *AdminHandler) LookupDeleted(c *gin.Context) {
+	var req platform_profile.GetSoftDeletedProfileRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	if req.Username == nil && req.Email == nil {
+		errors.BadRequest("A username or an email is required", nil).RespondWithError(c)
+		return
+	}
+
+	profile, err := h.profileService.GetSoftDeletedProfile(c.Request.Context(), req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// RestoreDeleted moves a profile and its student records out of the recovery bin
+func (h *AdminHandler) RestoreDeleted(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.RestoreProfile(c.Request.Context(), profileID); err != nil {
+		h.logger.Error("Failed to restore profile", "profile_id", profileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Profile restored successfully"})
+}
@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler handles HTTP requests for administering the API keys of platform profiles
+type APIKeyHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler instance
+func NewAPIKeyHandler(profileService platform_profile.Service, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// Create issues an API key for a profile. The response holds the key, it can't be retrieved again.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.CreateAPIKeyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	key, err := h.profileService.CreateAPIKey(c.Request.Context(), principal.ProfileID, profileID, req)
+	if err != nil {
+		h.logger.Error("Failed to create API key", "profile_id", profileID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// List lists the API keys of a profile
+func (h *APIKeyHandler) List(c *gin.Context) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	keys, err := h.profileService.ListAPIKeys(c.Request.Context(), profileID)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// Rotate replaces an API key. The old key keeps working during the overlap window.
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, keyID, ok := parseAPIKeyParams(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional, without it the default overlap applies
+	var req platform_profile.RotateAPIKeyRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	key, err := h.profileService.RotateAPIKey(c.Request.Context(), principal.ProfileID, profileID, keyID, req)
+	if err != nil {
+		h.logger.Error("Failed to rotate API key", "profile_id", profileID, "api_key_id", keyID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// Revoke revokes an API key immediately
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, keyID, ok := parseAPIKeyParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.profileService.RevokeAPIKey(c.Request.Context(), principal.ProfileID, profileID, keyID); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// parseAPIKeyParams parses the profile and API key IDs of the route, writing the error response if invalid
+func parseAPIKeyParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	keyID, err := uuid.Parse(c.Param("keyId"))
+	if err != nil {
+		errors.BadRequest("Invalid API key ID", nil).RespondWithError(c)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return profileID, keyID, true
+}
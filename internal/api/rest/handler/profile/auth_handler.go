@@ -0,0 +1,249 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks request bodies against the `validate` tags of the domain request models
+var validate = validator.New()
+
+// AuthHandler handles HTTP requests related to platform profile authentication
+type AuthHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewAuthHandler creates a new AuthHandler instance
+func NewAuthHandler(profileService platform_profile.Service, logger logger.Logger) *AuthHandler {
+	return &AuthHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// Register creates a new platform profile
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req platform_profile.CreateProfileRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	profile, err := h.profileService.RegisterProfile(c.Request.Context(), req)
+	if err != nil {
+		h.logger.Error("Failed to register profile", "username", req.Username, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, platform_profile.RegistrationResponse{
+		PlatformProfile:  profile,
+		PasswordStrength: h.profileService.PasswordStrength(req.Password),
+	})
+}
+
+// PasswordStrength scores a candidate password for the strength meter of the UI
+func (h *AuthHandler) PasswordStrength(c *gin.Context) {
+	var req platform_profile.PasswordStrengthRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"password_strength": h.profileService.PasswordStrength(req.Password)})
+}
+
+// VerifyEmail verifies the email address using the token from a verification link
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		errors.BadRequest("Verification token is required", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.VerifyEmail(c.Request.Context(), token); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a new verification email
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req platform_profile.ResendVerificationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.ResendVerificationEmail(c.Request.Context(), req); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	// Don't reveal if the email exists or not
+	c.JSON(http.StatusOK, gin.H{"message": "If your email is registered and not yet verified, you will receive a verification link"})
+}
+
+// Login authenticates a profile and starts a new session for the calling device
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req platform_profile.LoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	resp, err := h.profileService.AuthenticateProfile(c.Request.Context(), req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req platform_profile.RefreshTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.profileService.RefreshTokens(c.Request.Context(), req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout ends the session the given refresh token belongs to
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req platform_profile.RefreshTokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// ForgotPassword initiates the password reset process
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req platform_profile.PasswordResetRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.RequestPasswordReset(c.Request.Context(), req); err != nil {
+		h.logger.Error("Failed to process forgot password", "email", req.Email, "error", err)
+	}
+
+	// Don't reveal if the email exists or not
+	c.JSON(http.StatusOK, gin.H{"message": "If your email is registered, you will receive a password reset link"})
+}
+
+// ValidateResetToken checks a reset token before the user enters a new password
+func (h *AuthHandler) ValidateResetToken(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		errors.BadRequest("Reset token is required", nil).RespondWithError(c)
+		return
+	}
+
+	resetToken, err := h.profileService.ValidatePasswordResetToken(c.Request.Context(), token)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "expires_at": resetToken.ExpiresAt})
+}
+
+// ResetPassword sets a new password using a reset token. All sessions of the profile are ended.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req platform_profile.PasswordResetConfirmation
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.ConfirmPasswordReset(c.Request.Context(), req); err != nil {
+		h.logger.Error("Failed to reset password", "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Password reset successfully",
+		"password_strength": h.profileService.PasswordStrength(req.NewPassword),
+	})
+}
+
+// ChangePassword changes the password of the authenticated profile. All sessions of the profile are ended.
+// Also accepts the restricted token issued to profiles that must change their password.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.PasswordChangeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.ChangePassword(c.Request.Context(), principal.ProfileID, req); err != nil {
+		h.logger.Error("Failed to change password", "profile_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Password changed successfully, please log in again",
+		"password_strength": h.profileService.PasswordStrength(req.NewPassword),
+	})
+}
+
+// bindJSON decodes and validates the request body, writing a 400 response on failure
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return false
+	}
+
+	return true
+}
+
+// bindQuery decodes and validates the query string, writing a 400 response on failure
+func bindQuery(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MFAHandler handles HTTP requests for multi-factor authentication
+type MFAHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewMFAHandler creates a new MFAHandler instance
+func NewMFAHandler(profileService platform_profile.Service, logger logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// Verify completes a login that returned an MFA challenge
+func (h *MFAHandler) Verify(c *gin.Context) {
+	var req platform_profile.MFAVerifyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.profileService.VerifyMFA(c.Request.Context(), req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Enroll starts MFA enrollment for the authenticated profile
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	enrollment, err := h.profileService.BeginMFAEnrollment(c.Request.Context(), principal.ProfileID)
+	if err != nil {
+		h.logger.Error("Failed to start MFA enrollment", "profile_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm enables MFA for the authenticated profile and returns its recovery codes
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.MFACodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.profileService.ConfirmMFAEnrollment(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable turns off MFA for the authenticated profile
+func (h *MFAHandler) Disable(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.MFACodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.DisableMFA(c.Request.Context(), principal.ProfileID, req); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Multi-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated profile
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.MFACodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.profileService.RegenerateRecoveryCodes(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// AdminReset removes the MFA of another profile. The reset is recorded in the audit log.
+func (h *MFAHandler) AdminReset(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.BadRequest("Invalid profile ID", nil).RespondWithError(c)
+		return
+	}
+
+	var req platform_profile.AdminMFAResetRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.profileService.AdminResetMFA(c.Request.Context(), principal.ProfileID, profileID, req); err != nil {
+		h.logger.Error("Failed to reset MFA", "profile_id", profileID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Multi-factor authentication reset successfully"})
+}
@@ -0,0 +1,99 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OAuthHandler handles HTTP requests for logging in with external identity providers
+// and managing the identities linked to a profile
+type OAuthHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewOAuthHandler creates a new OAuthHandler instance
+func NewOAuthHandler(profileService platform_profile.Service, logger logger.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// Authorize starts a login with an external provider and returns the URL to send the user to
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	authorization, err := h.profileService.BeginOAuthLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		h.logger.Error("Failed to start external login", "provider", c.Param("provider"), "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, authorization)
+}
+
+// Callback completes a login with the state and code the provider redirected back with
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	var req platform_profile.OAuthCallbackRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Provider = c.Param("provider")
+	req.UserAgent = c.Request.UserAgent()
+	req.IPAddress = c.ClientIP()
+
+	resp, err := h.profileService.CompleteOAuthLogin(c.Request.Context(), req)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListIdentities lists the external identities linked to the authenticated profile
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	identities, err := h.profileService.ListLinkedIdentities(c.Request.Context(), principal.ProfileID)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+// UnlinkIdentity removes an external identity from the authenticated profile
+func (h *OAuthHandler) UnlinkIdentity(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	identityID, err := uuid.Parse(c.Param("identityId"))
+	if err != nil {
+		errors.BadRequest("Invalid identity ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.UnlinkIdentity(c.Request.Context(), principal.ProfileID, identityID); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked successfully"})
+}
@@ -0,0 +1,85 @@
+package profile
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionHandler handles HTTP requests for listing and ending the sessions of a profile
+type SessionHandler struct {
+	profileService platform_profile.Service
+	logger         logger.Logger
+}
+
+// NewSessionHandler creates a new SessionHandler instance
+func NewSessionHandler(profileService platform_profile.Service, logger logger.Logger) *SessionHandler {
+	return &SessionHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// ListSessions lists the devices the authenticated profile is logged in on
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	sessions, err := h.profileService.ListSessions(c.Request.Context(), principal.ProfileID, principal.SessionID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "profile_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs the authenticated profile out of one device
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		errors.BadRequest("Invalid session ID", nil).RespondWithError(c)
+		return
+	}
+
+	if err := h.profileService.RevokeSession(c.Request.Context(), principal.ProfileID, sessionID); err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeOtherSessions signs the authenticated profile out of every device except the calling one
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	revoked, err := h.profileService.RevokeOtherSessions(c.Request.Context(), principal.ProfileID, principal.SessionID)
+	if err != nil {
+		h.logger.Error("Failed to revoke other sessions", "profile_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "revoked": revoked})
+}
@@ -2,158 +2,160 @@ package quiz
 
 import (
 	"net/http"
-	"strconv"
-	"time"
 
-	"server/internal/model"
-	"server/internal/service"
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/quiz"
 	"server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxSubmittedAnswers caps the answers of a submission, beyond any sensible quiz length
+const maxSubmittedAnswers = 500
+
 // AttemptHandler handles HTTP requests related to quiz attempts
 type AttemptHandler struct {
-	attemptService service.AttemptService
+	attemptService quiz.AttemptService
 	logger         logger.Logger
 }
 
 // NewAttemptHandler creates a new AttemptHandler instance
-func NewAttemptHandler(attemptService service.AttemptService, logger logger.Logger) *AttemptHandler {
+func NewAttemptHandler(attemptService quiz.AttemptService, logger logger.Logger) *AttemptHandler {
 	return &AttemptHandler{
 		attemptService: attemptService,
 		logger:         logger,
 	}
 }
 
-// StartQuizAttempt creates a new quiz attempt
+// StartQuizAttempt starts an attempt of a published quiz and returns its questions without the answers
 func (h *AttemptHandler) StartQuizAttempt(c *gin.Context) {
-	quizID, err := strconv.ParseInt(c.Param("quizId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	studentID, exists := c.Get("studentID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	attempt := &model.QuizAttempt{
-		QuizID:     quizID,
-		StudentID:  studentID.(int64),
-		StartTime:  time.Now(),
-		Status:     "in_progress",
-	}
-
-	attemptID, err := h.attemptService.StartQuizAttempt(c.Request.Context(), attempt)
+	details, err := h.attemptService.StartAttempt(c.Request.Context(), quizID, principal.ProfileID)
 	if err != nil {
-		h.logger.Error("Failed to start quiz attempt", "quizID", quizID, "studentID", studentID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start quiz attempt"})
+		respondWithError(c, h.logger, "Failed to start quiz attempt", quizID, err)
 		return
 	}
 
-	attempt.ID = attemptID
-	c.JSON(http.StatusCreated, attempt)
+	c.JSON(http.StatusCreated, details)
 }
 
-// SubmitQuizAttempt submits answers for a quiz attempt
-func (h *AttemptHandler) SubmitQuizAttempt(c *gin.Context) {
-	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+// SaveAttemptAnswer saves the answer to a question of the caller's attempt while it runs. Saving
+// the same question again replaces the answer.
+func (h *AttemptHandler) SaveAttemptAnswer(c *gin.Context) {
+	attemptID, ok := paramID(c, "attemptId", "attempt")
+	if !ok {
 		return
 	}
 
-	studentID, exists := c.Get("studentID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+	questionID, ok := paramID(c, "questionId", "question")
+	if !ok {
 		return
 	}
 
-	var answers []model.QuizAnswer
-	if err := c.ShouldBindJSON(&answers); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	// Verify that the attempt belongs to the student
-	attempt, err := h.attemptService.GetAttemptByID(c.Request.Context(), attemptID)
+	var req quiz.SaveAnswerRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	saved, err := h.attemptService.SaveAnswer(c.Request.Context(), attemptID, principal.ProfileID, questionID, req)
 	if err != nil {
-		h.logger.Error("Failed to retrieve attempt", "attemptID", attemptID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit quiz attempt"})
+		respondWithError(c, h.logger, "Failed to save attempt answer", attemptID, err)
 		return
 	}
 
-	if attempt == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
+	c.JSON(http.StatusOK, saved)
+}
+
+// SubmitQuizAttempt grades the saved answers of the caller's attempt along with the submitted ones
+func (h *AttemptHandler) SubmitQuizAttempt(c *gin.Context) {
+	attemptID, ok := paramID(c, "attemptId", "attempt")
+	if !ok {
 		return
 	}
 
-	if attempt.StudentID != studentID.(int64) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to submit this attempt"})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	result, err := h.attemptService.SubmitQuizAttempt(c.Request.Context(), attemptID, answers)
+	var answers []quiz.AttemptAnswer
+	if err := c.ShouldBindJSON(&answers); err != nil {
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return
+	}
+	if len(answers) > maxSubmittedAnswers {
+		errors.BadRequest("Too many answers", nil).RespondWithError(c)
+		return
+	}
+	for i := range answers {
+		if err := validate.Struct(&answers[i]); err != nil {
+			errors.HandleValidationErrors(err).RespondWithError(c)
+			return
+		}
+	}
+
+	details, err := h.attemptService.SubmitAttempt(c.Request.Context(), attemptID, principal.ProfileID, answers)
 	if err != nil {
-		h.logger.Error("Failed to submit quiz attempt", "attemptID", attemptID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit quiz attempt"})
+		respondWithError(c, h.logger, "Failed to submit quiz attempt", attemptID, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, details)
 }
 
-// GetStudentAttempts retrieves all attempts for a student
+// GetStudentAttempts retrieves the caller's attempts, the latest first
 func (h *AttemptHandler) GetStudentAttempts(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	attempts, err := h.attemptService.GetStudentAttempts(c.Request.Context(), studentID.(int64))
+	attempts, err := h.attemptService.ListAttempts(c.Request.Context(), principal.ProfileID)
 	if err != nil {
-		h.logger.Error("Failed to get student attempts", "studentID", studentID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attempts"})
+		respondWithError(c, h.logger, "Failed to get student attempts", 0, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, attempts)
 }
 
-// GetAttemptDetails retrieves details of a specific attempt
+// GetAttemptDetails retrieves one of the caller's attempts with its questions and answers
 func (h *AttemptHandler) GetAttemptDetails(c *gin.Context) {
-	attemptID, err := strconv.ParseInt(c.Param("attemptId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attempt ID"})
+	attemptID, ok := paramID(c, "attemptId", "attempt")
+	if !ok {
 		return
 	}
 
-	studentID, exists := c.Get("studentID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	details, err := h.attemptService.GetAttemptDetails(c.Request.Context(), attemptID)
+	details, err := h.attemptService.GetAttemptDetails(c.Request.Context(), attemptID, principal.ProfileID)
 	if err != nil {
-		h.logger.Error("Failed to get attempt details", "attemptID", attemptID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attempt details"})
-		return
-	}
-
-	if details == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attempt not found"})
-		return
-	}
-
-	if details.StudentID != studentID.(int64) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view this attempt"})
+		respondWithError(c, h.logger, "Failed to get attempt details", attemptID, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, details)
-}
\ No newline at end of file
+}
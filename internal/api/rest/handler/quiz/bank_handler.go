@@ -0,0 +1,199 @@
+package quiz
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/quiz"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BankHandler handles HTTP requests related to question banks and their questions
+type BankHandler struct {
+	bankService quiz.BankService
+	logger      logger.Logger
+}
+
+// NewBankHandler creates a new BankHandler instance
+func NewBankHandler(bankService quiz.BankService, logger logger.Logger) *BankHandler {
+	return &BankHandler{
+		bankService: bankService,
+		logger:      logger,
+	}
+}
+
+// GetAllBanks retrieves the question banks matching the filters
+func (h *BankHandler) GetAllBanks(c *gin.Context) {
+	var req quiz.ListQuestionBanksRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	banks, err := h.bankService.ListBanks(c.Request.Context(), req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to list question banks", 0, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, banks)
+}
+
+// GetBankByID retrieves a question bank by ID
+func (h *BankHandler) GetBankByID(c *gin.Context) {
+	id, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	bank, err := h.bankService.GetBank(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to get question bank", id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bank)
+}
+
+// CreateBank creates an empty question bank owned by the caller
+func (h *BankHandler) CreateBank(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	var req quiz.QuestionBankRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	bank, err := h.bankService.CreateBank(c.Request.Context(), principal.ProfileID, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to create question bank", 0, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, bank)
+}
+
+// UpdateBank replaces the details of a question bank
+func (h *BankHandler) UpdateBank(c *gin.Context) {
+	id, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	var req quiz.QuestionBankRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	bank, err := h.bankService.UpdateBank(c.Request.Context(), id, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to update question bank", id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bank)
+}
+
+// DeleteBank deletes a question bank and its questions
+func (h *BankHandler) DeleteBank(c *gin.Context) {
+	id, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	if err := h.bankService.DeleteBank(c.Request.Context(), id); err != nil {
+		respondWithError(c, h.logger, "Failed to delete question bank", id, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question bank deleted successfully"})
+}
+
+// GetBankQuestions retrieves the questions of a bank with their answers
+func (h *BankHandler) GetBankQuestions(c *gin.Context) {
+	bankID, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	questions, err := h.bankService.GetBankQuestions(c.Request.Context(), bankID)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to get bank questions", bankID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// AddBankQuestion adds a question to a bank
+func (h *BankHandler) AddBankQuestion(c *gin.Context) {
+	bankID, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	var req quiz.BankQuestionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	question, err := h.bankService.AddBankQuestion(c.Request.Context(), bankID, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to add bank question", bankID, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, question)
+}
+
+// UpdateBankQuestion replaces a question of a bank
+func (h *BankHandler) UpdateBankQuestion(c *gin.Context) {
+	bankID, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	questionID, ok := paramID(c, "questionId", "question")
+	if !ok {
+		return
+	}
+
+	var req quiz.BankQuestionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	question, err := h.bankService.UpdateBankQuestion(c.Request.Context(), bankID, questionID, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to update bank question", questionID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, question)
+}
+
+// DeleteBankQuestion deletes a question of a bank
+func (h *BankHandler) DeleteBankQuestion(c *gin.Context) {
+	bankID, ok := paramID(c, "id", "question bank")
+	if !ok {
+		return
+	}
+
+	questionID, ok := paramID(c, "questionId", "question")
+	if !ok {
+		return
+	}
+
+	if err := h.bankService.DeleteBankQuestion(c.Request.Context(), bankID, questionID); err != nil {
+		respondWithError(c, h.logger, "Failed to delete bank question", questionID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question deleted successfully"})
+}
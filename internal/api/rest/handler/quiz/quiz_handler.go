@@ -1,213 +1,314 @@
 package quiz
 
 import (
+	stderrors "errors"
 	"net/http"
 	"strconv"
 
-	"server/internal/model"
-	"server/internal/service"
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/quiz"
+	"server/internal/domain/role"
 	"server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
-// QuizHandler handles HTTP requests related to quizzes
+// validate checks request bodies against the `validate` tags of the domain request models
+var validate = validator.New()
+
+// QuizHandler handles HTTP requests related to quizzes and their questions
 type QuizHandler struct {
-	quizService service.QuizService
+	quizService quiz.Service
 	logger      logger.Logger
 }
 
 // NewQuizHandler creates a new QuizHandler instance
-func NewQuizHandler(quizService service.QuizService, logger logger.Logger) *QuizHandler {
+func NewQuizHandler(quizService quiz.Service, logger logger.Logger) *QuizHandler {
 	return &QuizHandler{
 		quizService: quizService,
 		logger:      logger,
 	}
 }
 
-// GetAllQuizzes retrieves all quizzes
+// GetAllQuizzes retrieves a page of quizzes. Only callers who can edit quizzes see drafts.
 func (h *QuizHandler) GetAllQuizzes(c *gin.Context) {
-	quizzes, err := h.quizService.GetAllQuizzes(c.Request.Context())
+	var req quiz.ListQuizzesRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	list := h.quizService.ListPublishedQuizzes
+	if middleware.HasPermission(c, "quiz", role.ActionUpdate) {
+		list = h.quizService.ListQuizzes
+	}
+
+	quizzes, err := list(c.Request.Context(), req)
 	if err != nil {
-		h.logger.Error("Failed to get quizzes", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quizzes"})
+		respondWithError(c, h.logger, "Failed to list quizzes", 0, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, quizzes)
 }
 
-// GetQuizByID retrieves a quiz by ID
+// GetQuizByID retrieves a quiz by ID. Only callers who can edit quizzes see drafts.
 func (h *QuizHandler) GetQuizByID(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	id, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	quiz, err := h.quizService.GetQuizByID(c.Request.Context(), id)
-	if err != nil {
-		h.logger.Error("Failed to get quiz", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz"})
-		return
+	get := h.quizService.GetPublishedQuiz
+	if middleware.HasPermission(c, "quiz", role.ActionUpdate) {
+		get = h.quizService.GetQuiz
 	}
 
-	if quiz == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Quiz not found"})
+	q, err := get(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to get quiz", id, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, quiz)
+	c.JSON(http.StatusOK, q)
 }
 
-// CreateQuiz creates a new quiz
+// CreateQuiz creates a draft quiz authored by the caller
 func (h *QuizHandler) CreateQuiz(c *gin.Context) {
-	var quiz model.Quiz
-	if err := c.ShouldBindJSON(&quiz); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
 		return
 	}
 
-	id, err := h.quizService.CreateQuiz(c.Request.Context(), &quiz)
+	var req quiz.QuizRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	q, err := h.quizService.CreateQuiz(c.Request.Context(), principal.ProfileID, req)
 	if err != nil {
-		h.logger.Error("Failed to create quiz", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz"})
+		respondWithError(c, h.logger, "Failed to create quiz", 0, err)
 		return
 	}
 
-	quiz.ID = id
-	c.JSON(http.StatusCreated, quiz)
+	c.JSON(http.StatusCreated, q)
 }
 
-// UpdateQuiz updates an existing quiz
+// UpdateQuiz replaces the details of a quiz and publishes or unpublishes it
 func (h *QuizHandler) UpdateQuiz(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	id, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	var quiz model.Quiz
-	if err := c.ShouldBindJSON(&quiz); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req quiz.QuizRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	quiz.ID = id
-	if err := h.quizService.UpdateQuiz(c.Request.Context(), &quiz); err != nil {
-		h.logger.Error("Failed to update quiz", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quiz"})
+	q, err := h.quizService.UpdateQuiz(c.Request.Context(), id, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to update quiz", id, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, quiz)
+	c.JSON(http.StatusOK, q)
 }
 
-// DeleteQuiz deletes a quiz
+// DeleteQuiz deletes a quiz that has never been attempted
 func (h *QuizHandler) DeleteQuiz(c *gin.Context) {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	id, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
 	if err := h.quizService.DeleteQuiz(c.Request.Context(), id); err != nil {
-		h.logger.Error("Failed to delete quiz", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quiz"})
+		respondWithError(c, h.logger, "Failed to delete quiz", id, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Quiz deleted successfully"})
 }
 
-// GetQuizQuestions retrieves all questions for a quiz
+// GetQuizQuestions retrieves the questions of a quiz, with their answers, in order
 func (h *QuizHandler) GetQuizQuestions(c *gin.Context) {
-	quizID, err := strconv.ParseInt(c.Param("quizId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	questions, err := h.quizService.GetQuizQuestions(c.Request.Context(), quizID)
+	questions, err := h.quizService.GetQuestions(c.Request.Context(), quizID)
 	if err != nil {
-		h.logger.Error("Failed to get quiz questions", "quizID", quizID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve questions"})
+		respondWithError(c, h.logger, "Failed to get quiz questions", quizID, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, questions)
 }
 
-// AddQuizQuestion adds a question to a quiz
+// AddQuizQuestion adds a question to a draft quiz
 func (h *QuizHandler) AddQuizQuestion(c *gin.Context) {
-	quizID, err := strconv.ParseInt(c.Param("quizId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	var question model.Question
-	if err := c.ShouldBindJSON(&question); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req quiz.QuestionRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	question.QuizID = quizID
-	id, err := h.quizService.AddQuizQuestion(c.Request.Context(), &question)
+	question, err := h.quizService.AddQuestion(c.Request.Context(), quizID, req)
 	if err != nil {
-		h.logger.Error("Failed to add question", "quizID", quizID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add question"})
+		respondWithError(c, h.logger, "Failed to add question", quizID, err)
 		return
 	}
 
-	question.ID = id
 	c.JSON(http.StatusCreated, question)
 }
 
-// UpdateQuizQuestion updates a question
+// UpdateQuizQuestion replaces a question of a draft quiz
 func (h *QuizHandler) UpdateQuizQuestion(c *gin.Context) {
-	quizID, err := strconv.ParseInt(c.Param("quizId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quiz ID"})
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	questionID, err := strconv.ParseInt(c.Param("questionId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+	questionID, ok := paramID(c, "questionId", "question")
+	if !ok {
 		return
 	}
 
-	var question model.Question
-	if err := c.ShouldBindJSON(&question); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req quiz.QuestionRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	question.ID = questionID
-	question.QuizID = quizID
-	if err := h.quizService.UpdateQuizQuestion(c.Request.Context(), &question); err != nil {
-		h.logger.Error("Failed to update question", "questionID", questionID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question"})
+	question, err := h.quizService.UpdateQuestion(c.Request.Context(), quizID, questionID, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to update question", questionID, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, question)
 }
 
-// DeleteQuizQuestion deletes a question
+// DeleteQuizQuestion deletes a question of a draft quiz
 func (h *QuizHandler) DeleteQuizQuestion(c *gin.Context) {
-	questionID, err := strconv.ParseInt(c.Param("questionId"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
 		return
 	}
 
-	if err := h.quizService.DeleteQuizQuestion(c.Request.Context(), questionID); err != nil {
-		h.logger.Error("Failed to delete question", "questionID", questionID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete question"})
+	questionID, ok := paramID(c, "questionId", "question")
+	if !ok {
+		return
+	}
+
+	if err := h.quizService.DeleteQuestion(c.Request.Context(), quizID, questionID); err != nil {
+		respondWithError(c, h.logger, "Failed to delete question", questionID, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Question deleted successfully"})
-}
\ No newline at end of file
+}
+
+// ReorderQuizQuestions sets the order of every question of a draft quiz
+func (h *QuizHandler) ReorderQuizQuestions(c *gin.Context) {
+	quizID, ok := paramID(c, "id", "quiz")
+	if !ok {
+		return
+	}
+
+	var req quiz.ReorderQuestionsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	questions, err := h.quizService.ReorderQuestions(c.Request.Context(), quizID, req)
+	if err != nil {
+		respondWithError(c, h.logger, "Failed to reorder questions", quizID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, questions)
+}
+
+// paramID parses the ID of the named path parameter, writing a 400 response on failure
+func paramID(c *gin.Context, name, entity string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id < 1 {
+		errors.BadRequest("Invalid "+entity+" ID", nil).RespondWithError(c)
+		return 0, false
+	}
+	return id, true
+}
+
+// bindJSON decodes and validates the request body, writing a 400 response on failure
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return false
+	}
+
+	return true
+}
+
+// bindQuery decodes and validates the query string, writing a 400 response on failure
+func bindQuery(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return false
+	}
+
+	if err := validate.Struct(req); err != nil {
+		errors.HandleValidationErrors(err).RespondWithError(c)
+		return false
+	}
+
+	return true
+}
+
+// respondWithError maps the errors of the quiz service to API errors
+func respondWithError(c *gin.Context, log logger.Logger, message string, id int64, err error) {
+	switch {
+	case stderrors.Is(err, quiz.ErrQuizNotFound):
+		errors.NotFound("Quiz").RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrQuestionNotFound):
+		errors.NotFound("Question").RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrAttemptNotFound):
+		errors.NotFound("Attempt").RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrBankNotFound):
+		errors.NotFound("Question bank").RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrNotAttemptOwner):
+		errors.Forbidden("Not authorized to access this attempt").RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrInvalidQuiz),
+		stderrors.Is(err, quiz.ErrInvalidQuestion),
+		stderrors.Is(err, quiz.ErrInvalidQuestionOrder),
+		stderrors.Is(err, quiz.ErrInvalidBank):
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrQuizPublished):
+		errors.Conflict("Unpublish the quiz to change its questions", nil).RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrAttemptLimitReached):
+		errors.Conflict("Finish the attempts in progress before starting another", nil).RespondWithError(c)
+	case stderrors.Is(err, quiz.ErrQuizNotPublished),
+		stderrors.Is(err, quiz.ErrQuizHasNoQuestions),
+		stderrors.Is(err, quiz.ErrQuizHasAttempts),
+		stderrors.Is(err, quiz.ErrQuizHasQuestions),
+		stderrors.Is(err, quiz.ErrQuizHasBlueprint),
+		stderrors.Is(err, quiz.ErrNotEnoughQuestions),
+		stderrors.Is(err, quiz.ErrAttemptSubmitted),
+		stderrors.Is(err, quiz.ErrAttemptExpired):
+		errors.Conflict(err.Error(), nil).RespondWithError(c)
+	default:
+		log.Error(message, "id", id, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+	}
+}
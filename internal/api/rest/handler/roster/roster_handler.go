@@ -0,0 +1,133 @@
+package roster
+
+import (
+	"net/http"
+
+	"server/internal/api/rest/middleware"
+	"server/internal/common/errors"
+	"server/internal/domain/student/roster"
+	"server/internal/infrastructure/spreadsheet"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MaxFileSize is the largest roster upload accepted, in bytes
+const MaxFileSize = 10 << 20
+
+// Handler handles HTTP requests for importing student rosters
+type Handler struct {
+	rosterService roster.Service
+	logger        logger.Logger
+}
+
+// NewHandler creates a new roster Handler instance
+func NewHandler(rosterService roster.Service, logger logger.Logger) *Handler {
+	return &Handler{
+		rosterService: rosterService,
+		logger:        logger,
+	}
+}
+
+// Preview uploads a CSV or XLSX roster in the "file" form field and returns every row with its
+// errors. Nothing is created until the preview is committed.
+func (h *Handler) Preview(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxFileSize+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		errors.BadRequest("A roster file is required in the \"file\" field", nil).RespondWithError(c)
+		return
+	}
+	if header.Size > MaxFileSize {
+		errors.BadRequest("The roster file exceeds the 10MB limit", nil).RespondWithError(c)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.logger.Error("Failed to open uploaded roster", "error", err)
+		errors.BadRequest("Failed to read the roster file", nil).RespondWithError(c)
+		return
+	}
+	defer file.Close()
+
+	// The header row comes on top of the students
+	rows, err := spreadsheet.Read(header.Filename, file, header.Size, roster.MaxRows+1)
+	if err != nil {
+		h.logger.Warn("Failed to parse roster", "file_name", header.Filename, "error", err)
+		errors.BadRequest(err.Error(), nil).RespondWithError(c)
+		return
+	}
+
+	sourceRows := make([]roster.SourceRow, len(rows))
+	for i, row := range rows {
+		sourceRows[i] = roster.SourceRow{Number: row.Number, Cells: row.Cells}
+	}
+
+	imp, err := h.rosterService.Preview(c.Request.Context(), principal.ProfileID, header.Filename, sourceRows)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, imp)
+}
+
+// Get returns a roster import with its rows and, once committed, the outcome of every row
+func (h *Handler) Get(c *gin.Context) {
+	importID, err := uuid.Parse(c.Param("importId"))
+	if err != nil {
+		errors.BadRequest("Invalid import ID", nil).RespondWithError(c)
+		return
+	}
+
+	imp, err := h.rosterService.GetImport(c.Request.Context(), importID)
+	if err != nil {
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, imp)
+}
+
+// Commit creates the students of the valid rows of a preview and sends their sign-in details
+func (h *Handler) Commit(c *gin.Context) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		errors.Unauthorized("").RespondWithError(c)
+		return
+	}
+
+	importID, err := uuid.Parse(c.Param("importId"))
+	if err != nil {
+		errors.BadRequest("Invalid import ID", nil).RespondWithError(c)
+		return
+	}
+
+	// The body is optional, without it invitations are sent
+	var req roster.CommitRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errors.BadRequest(err.Error(), nil).RespondWithError(c)
+			return
+		}
+	}
+
+	imp, err := h.rosterService.Commit(c.Request.Context(), principal.ProfileID, importID, req)
+	if err != nil {
+		h.logger.Error("Failed to commit roster", "import_id", importID, "actor_id", principal.ProfileID, "error", err)
+		errors.FromDomainError(err).RespondWithError(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, imp)
+}
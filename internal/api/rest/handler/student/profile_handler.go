@@ -3,6 +3,7 @@ package student
 import (
 	"net/http"
 
+	"server/internal/api/rest/middleware"
 	"server/internal/model"
 	"server/internal/service"
 	"server/pkg/logger"
@@ -26,13 +27,14 @@ func NewProfileHandler(profileService service.ProfileService, logger logger.Logg
 
 // GetProfile retrieves a student's profile
 func (h *ProfileHandler) GetProfile(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
-	profile, err := h.profileService.GetProfile(c.Request.Context(), studentID.(int64))
+	profile, err := h.profileService.GetProfile(c.Request.Context(), studentID)
 	if err != nil {
 		h.logger.Error("Failed to get profile", "studentID", studentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile"})
@@ -49,11 +51,12 @@ func (h *ProfileHandler) GetProfile(c *gin.Context) {
 
 // UpdateProfile updates a student's profile
 func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
 	var profile model.StudentProfile
 	if err := c.ShouldBindJSON(&profile); err != nil {
@@ -61,7 +64,7 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	profile.StudentID = studentID.(int64)
+	profile.StudentID = studentID
 	if err := h.profileService.UpdateProfile(c.Request.Context(), &profile); err != nil {
 		h.logger.Error("Failed to update profile", "studentID", studentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
@@ -73,11 +76,12 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 
 // ChangePassword changes a student's password
 func (h *ProfileHandler) ChangePassword(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
 	var request struct {
 		CurrentPassword string `json:"current_password" binding:"required"`
@@ -92,7 +96,7 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 
 	if err := h.profileService.ChangePassword(
 		c.Request.Context(),
-		studentID.(int64),
+		studentID,
 		request.CurrentPassword,
 		request.NewPassword,
 	); err != nil {
@@ -106,11 +110,12 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 
 // UploadProfilePicture uploads a profile picture
 func (h *ProfileHandler) UploadProfilePicture(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
 	file, err := c.FormFile("profile_picture")
 	if err != nil {
@@ -134,7 +139,7 @@ func (h *ProfileHandler) UploadProfilePicture(c *gin.Context) {
 	defer src.Close()
 
 	// Upload the file
-	picturePath, err := h.profileService.UploadProfilePicture(c.Request.Context(), studentID.(int64), src, file.Filename)
+	picturePath, err := h.profileService.UploadProfilePicture(c.Request.Context(), studentID, src, file.Filename)
 	if err != nil {
 		h.logger.Error("Failed to upload profile picture", "studentID", studentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload profile picture"})
@@ -149,13 +154,14 @@ func (h *ProfileHandler) UploadProfilePicture(c *gin.Context) {
 
 // GetAcademicRecords retrieves a student's academic records
 func (h *ProfileHandler) GetAcademicRecords(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
-	records, err := h.profileService.GetAcademicRecords(c.Request.Context(), studentID.(int64))
+	records, err := h.profileService.GetAcademicRecords(c.Request.Context(), studentID)
 	if err != nil {
 		h.logger.Error("Failed to get academic records", "studentID", studentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve academic records"})
@@ -167,13 +173,14 @@ func (h *ProfileHandler) GetAcademicRecords(c *gin.Context) {
 
 // GetQuizHistory retrieves a student's quiz history
 func (h *ProfileHandler) GetQuizHistory(c *gin.Context) {
-	studentID, exists := c.Get("studentID")
+	principal, exists := middleware.GetPrincipal(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
 		return
 	}
+	studentID := principal.ProfileID
 
-	history, err := h.profileService.GetQuizHistory(c.Request.Context(), studentID.(int64))
+	history, err := h.profileService.GetQuizHistory(c.Request.Context(), studentID)
 	if err != nil {
 		h.logger.Error("Failed to get quiz history", "studentID", studentID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve quiz history"})
@@ -181,4 +188,4 @@ func (h *ProfileHandler) GetQuizHistory(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, history)
-}
\ No newline at end of file
+}
@@ -0,0 +1,327 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"server/internal/common/errors"
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/role"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// principalKey is the Gin context key the authenticated principal is stored under
+const principalKey = "principal"
+
+// permissionKeyPrefix prefixes the Gin context keys the results of CheckPermission are stored under
+const permissionKeyPrefix = "permission:"
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ProfileID uuid.UUID
+	SessionID uuid.UUID
+	Username  string
+	Email     string
+	Status    platform_profile.Status
+	Roles     []string
+
+	// Scope is set when the principal authenticated with a restricted token
+	Scope string
+
+	// APIKey is set when the principal authenticated with an API key instead of a session
+	APIKey *platform_profile.APIKey
+}
+
+// HasRole reports whether the principal has been assigned the named role
+func (p *Principal) HasRole(name string) bool {
+	return slices.Contains(p.Roles, name)
+}
+
+// IsAPIKey reports whether the principal authenticated with an API key
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKey != nil
+}
+
+// PermissionChecker reports whether a profile may perform an action on a resource, and in which
+// scope. Satisfied by role.Service, which lets role.ActionManage grant every action.
+type PermissionChecker interface {
+	GetAccessScope(ctx context.Context, profileID uuid.UUID, resource, action string) (role.AccessScope, bool, error)
+}
+
+// AuthMiddleware authenticates requests and guards routes by permission
+type AuthMiddleware struct {
+	profileService platform_profile.Service
+	permissions    PermissionChecker
+	logger         logger.Logger
+}
+
+// NewAuthMiddleware creates a new AuthMiddleware instance
+func NewAuthMiddleware(profileService platform_profile.Service, permissions PermissionChecker, logger logger.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		profileService: profileService,
+		permissions:    permissions,
+		logger:         logger,
+	}
+}
+
+// Authenticate validates the bearer access token or the API key, loads the profile and its roles
+// and stores the resulting Principal in the context. Restricted tokens are rejected.
+func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
+	return m.authenticate("")
+}
+
+// AuthenticateAllowingScope is Authenticate that additionally accepts tokens restricted to the scope,
+// such as the token issued to a profile that must change its password. API keys are rejected.
+func (m *AuthMiddleware) AuthenticateAllowingScope(scope string) gin.HandlerFunc {
+	return m.authenticate(scope)
+}
+
+// authenticate builds the authentication handler, accepting restricted tokens of the allowed scope.
+// API keys are accepted when no scope is allowed.
+func (m *AuthMiddleware) authenticate(allowedScope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if (!found || token == "") && allowedScope == "" {
+			if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+				m.authenticateAPIKey(c, apiKey)
+				return
+			}
+		}
+		if !found || token == "" {
+			abortWithError(c, errors.Unauthorized(""))
+			return
+		}
+
+		claims, err := m.profileService.ValidateAccessToken(c.Request.Context(), token)
+		if err != nil {
+			abortWithError(c, errors.FromDomainError(err))
+			return
+		}
+
+		if claims.Scope != "" && claims.Scope != allowedScope {
+			m.logger.Warn("Restricted token used outside its scope", "profile_id", claims.ProfileID, "scope", claims.Scope)
+			abortWithError(c, errors.NewAPIError(http.StatusForbidden, "PASSWORD_CHANGE_REQUIRED", "Password must be changed before continuing", nil))
+			return
+		}
+
+		principal, ok := m.loadPrincipal(c, claims.ProfileID)
+		if !ok {
+			return
+		}
+		principal.SessionID = claims.SessionID
+		principal.Scope = claims.Scope
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey authenticates the request as the profile the API key belongs to
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, apiKey string) {
+	key, err := m.profileService.AuthenticateAPIKey(c.Request.Context(), apiKey, c.ClientIP())
+	if err != nil {
+		abortWithError(c, errors.FromDomainError(err))
+		return
+	}
+
+	principal, ok := m.loadPrincipal(c, key.ProfileID)
+	if !ok {
+		return
+	}
+	principal.APIKey = key
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// loadPrincipal loads the active profile and its roles, aborting the request if that fails
+func (m *AuthMiddleware) loadPrincipal(c *gin.Context, profileID uuid.UUID) (*Principal, bool) {
+	ctx := c.Request.Context()
+
+	profile, err := m.profileService.GetProfile(ctx, platform_profile.GetProfileRequest{ID: &profileID})
+	if err != nil {
+		m.logger.Warn("Profile of credentials could not be loaded", "profile_id", profileID, "error", err)
+		abortWithError(c, errors.Unauthorized("Invalid credentials"))
+		return nil, false
+	}
+
+	if profile.Status != platform_profile.StatusActivated && profile.Status != platform_profile.StatusPending {
+		m.logger.Warn("Credentials presented for inactive account", "profile_id", profile.ID, "status", profile.Status)
+		abortWithError(c, errors.Unauthorized("Account is not active"))
+		return nil, false
+	}
+
+	// Roles are loaded on every request so role changes apply before the token expires
+	roles, err := m.profileService.GetRoleNames(ctx, profile.ID)
+	if err != nil {
+		m.logger.Error("Failed to load roles of principal", "profile_id", profile.ID, "error", err)
+		abortWithError(c, errors.FromDomainError(err))
+		return nil, false
+	}
+	c.Request = c.Request.WithContext(role.WithRoleNames(ctx, roles))
+
+	return &Principal{
+		ProfileID: profile.ID,
+		Username:  profile.Username,
+		Email:     profile.Email,
+		Status:    profile.Status,
+		Roles:     roles,
+	}, true
+}
+
+// RequireUser rejects principals that authenticated with an API key, for routes acting on the
+// caller's own account or managing credentials. Must be used after Authenticate.
+func (m *AuthMiddleware) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortWithError(c, errors.Unauthorized(""))
+			return
+		}
+
+		if principal.IsAPIKey() {
+			m.logger.Warn("API key used on a user only route", "api_key_id", principal.APIKey.ID, "path", c.FullPath())
+			abortWithError(c, errors.Forbidden("This route is not available to API keys"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole allows the request only if the principal has been assigned one of the named roles,
+// for actions reserved to a role rather than granted by permission. Must be used after Authenticate.
+func (m *AuthMiddleware) RequireRole(names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortWithError(c, errors.Unauthorized(""))
+			return
+		}
+
+		if !slices.ContainsFunc(names, principal.HasRole) {
+			m.logger.Warn("Role required", "profile_id", principal.ProfileID, "roles", names, "path", c.FullPath())
+			abortWithError(c, errors.Forbidden(""))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission allows the request only if the principal may perform the action on the resource.
+// A role granted role.ActionManage on a resource may perform every action on it. API keys must
+// additionally have a scope covering the action, on top of the roles of their profile.
+// The scope the permission is granted in is stored in the request context, see role.AccessScopeFromContext,
+// so that list queries only return what the principal may see. Must be used after Authenticate.
+func (m *AuthMiddleware) RequirePermission(resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortWithError(c, errors.Unauthorized(""))
+			return
+		}
+
+		if principal.IsAPIKey() && !principal.APIKey.Allows(resource, action) {
+			m.logger.Warn(
+				"API key scope denied",
+				"api_key_id", principal.APIKey.ID,
+				"resource", resource,
+				"action", action,
+			)
+			abortWithError(c, errors.Forbidden(""))
+			return
+		}
+
+		access, allowed, err := m.permissions.GetAccessScope(c.Request.Context(), principal.ProfileID, resource, action)
+		if err != nil {
+			m.logger.Error(
+				"Failed to check permission",
+				"profile_id", principal.ProfileID,
+				"resource", resource,
+				"action", action,
+				"error", err,
+			)
+			abortWithError(c, errors.FromDomainError(errors.NewDatabaseError("checking permission", err)))
+			return
+		}
+
+		if !allowed {
+			m.logger.Warn(
+				"Permission denied",
+				"profile_id", principal.ProfileID,
+				"resource", resource,
+				"action", action,
+			)
+			abortWithError(c, errors.Forbidden(""))
+			return
+		}
+
+		c.Request = c.Request.WithContext(role.WithAccessScope(c.Request.Context(), access))
+		c.Next()
+	}
+}
+
+// CheckPermission records whether the principal may perform an action on a resource, for handlers
+// that show more to callers holding the permission. Unlike RequirePermission it never denies the
+// request, read the result with HasPermission.
+func (m *AuthMiddleware) CheckPermission(resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortWithError(c, errors.Unauthorized(""))
+			return
+		}
+
+		allowed := !principal.IsAPIKey() || principal.APIKey.Allows(resource, action)
+		if allowed {
+			var err error
+			_, allowed, err = m.permissions.GetAccessScope(c.Request.Context(), principal.ProfileID, resource, action)
+			if err != nil {
+				m.logger.Error(
+					"Failed to check permission",
+					"profile_id", principal.ProfileID,
+					"resource", resource,
+					"action", action,
+					"error", err,
+				)
+				abortWithError(c, errors.FromDomainError(errors.NewDatabaseError("checking permission", err)))
+				return
+			}
+		}
+
+		c.Set(permissionKeyPrefix+resource+":"+action, allowed)
+		c.Next()
+	}
+}
+
+// HasPermission reports whether CheckPermission found that the principal may perform the action
+// on the resource
+func HasPermission(c *gin.Context, resource, action string) bool {
+	return c.GetBool(permissionKeyPrefix + resource + ":" + action)
+}
+
+// GetPrincipal returns the principal stored by Authenticate
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+// abortWithError writes the error response and stops the handler chain
+func abortWithError(c *gin.Context, apiErr *errors.APIError) {
+	apiErr.RespondWithError(c)
+	c.Abort()
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"server/internal/api/rest/handler/attendance"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/role"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAttendanceRoutes sets up the routes marking the attendance of the sections within the caller's scope
+func RegisterAttendanceRoutes(r *gin.RouterGroup, studentService *student.Service, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	attendanceHandler := attendance.NewHandler(studentService, *log)
+
+	// Coordinators mark their sections, the class is recorded in their name
+	r.POST(
+		"/students/attendance/sections",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequireUser(),
+		authMiddleware.RequireRole(role.RoleCoordinator),
+		authMiddleware.RequirePermission("student", role.ActionUpdate),
+		attendanceHandler.MarkSection,
+	)
+}
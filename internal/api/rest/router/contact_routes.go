@@ -0,0 +1,46 @@
+package router
+
+import (
+	"server/internal/api/rest/handler/contact"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/role"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterContactRoutes sets up the routes managing the emergency contacts of students and messaging them
+func RegisterContactRoutes(r *gin.RouterGroup, studentService *student.Service, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	contactHandler := contact.NewHandler(studentService, *log)
+
+	// Contacts of the student linked to the authenticated profile
+	own := r.Group("/students/me/contacts")
+	own.Use(authMiddleware.Authenticate(), authMiddleware.RequireUser())
+	{
+		own.GET("", contactHandler.List)
+		own.POST("", contactHandler.Add)
+		own.PUT("/:contactId", contactHandler.Update)
+		own.DELETE("/:contactId", contactHandler.Delete)
+	}
+
+	// Contacts of the students within the caller's scope
+	contacts := r.Group("/students/:id/contacts")
+	contacts.Use(authMiddleware.Authenticate())
+	{
+		contacts.GET("", authMiddleware.RequirePermission("student", role.ActionRead), contactHandler.List)
+		contacts.POST("", authMiddleware.RequirePermission("student", role.ActionUpdate), contactHandler.Add)
+		contacts.PUT("/:contactId", authMiddleware.RequirePermission("student", role.ActionUpdate), contactHandler.Update)
+		contacts.DELETE("/:contactId", authMiddleware.RequirePermission("student", role.ActionUpdate), contactHandler.Delete)
+	}
+
+	// Only coordinators message guardians, and only those of the students within their scope
+	r.POST(
+		"/students/guardian-notifications",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequireUser(),
+		authMiddleware.RequireRole(role.RoleCoordinator),
+		authMiddleware.RequirePermission("student", role.ActionRead),
+		contactHandler.NotifyGuardians,
+	)
+}
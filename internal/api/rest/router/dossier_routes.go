@@ -0,0 +1,23 @@
+package router
+
+import (
+	"server/internal/api/rest/handler/dossier"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/role"
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDossierRoutes sets up the routes reading the dossiers of the students within the caller's scope
+func RegisterDossierRoutes(r *gin.RouterGroup, studentService *student.Service, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	dossierHandler := dossier.NewHandler(studentService, *log)
+
+	r.GET(
+		"/students/dossiers/:enrollmentNo",
+		authMiddleware.Authenticate(),
+		authMiddleware.RequirePermission("student", role.ActionRead),
+		dossierHandler.Get,
+	)
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"server/internal/api/rest/handler/profile"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/role"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterProfileRoutes sets up authentication, session, MFA, API key and platform profile administration routes
+func RegisterProfileRoutes(r *gin.RouterGroup, profileService platform_profile.Service, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	// Create handlers
+	authHandler := profile.NewAuthHandler(profileService, *log)
+	sessionHandler := profile.NewSessionHandler(profileService, *log)
+	mfaHandler := profile.NewMFAHandler(profileService, *log)
+	adminHandler := profile.NewAdminHandler(profileService, *log)
+	oauthHandler := profile.NewOAuthHandler(profileService, *log)
+	apiKeyHandler := profile.NewAPIKeyHandler(profileService, *log)
+
+	// Auth routes (no authentication required)
+	authRoutes := r.Group("/auth")
+	{
+		authRoutes.POST("/register", authHandler.Register)
+		authRoutes.POST("/password-strength", authHandler.PasswordStrength)
+		authRoutes.GET("/verify-email", authHandler.VerifyEmail)
+		authRoutes.POST("/resend-verification", authHandler.ResendVerification)
+		authRoutes.POST("/login", authHandler.Login)
+		authRoutes.POST("/refresh", authHandler.Refresh)
+		authRoutes.POST("/logout", authHandler.Logout)
+		authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+		authRoutes.GET("/reset-password/validate", authHandler.ValidateResetToken)
+		authRoutes.POST("/reset-password", authHandler.ResetPassword)
+		authRoutes.POST("/mfa/verify", mfaHandler.Verify)
+		authRoutes.GET("/oauth/:provider", oauthHandler.Authorize)
+		authRoutes.POST("/oauth/:provider/callback", oauthHandler.Callback)
+	}
+
+	// Changing the password is also allowed with the token issued when a password change is forced
+	r.POST(
+		"/profiles/me/change-password",
+		authMiddleware.AuthenticateAllowingScope(platform_profile.ScopePasswordChange),
+		authHandler.ChangePassword,
+	)
+
+	// Routes of the authenticated profile, not available to API keys
+	me := r.Group("/profiles/me")
+	me.Use(authMiddleware.Authenticate(), authMiddleware.RequireUser())
+	{
+		me.GET("/sessions", sessionHandler.ListSessions)
+		me.DELETE("/sessions", sessionHandler.RevokeOtherSessions)
+		me.DELETE("/sessions/:sessionId", sessionHandler.RevokeSession)
+
+		me.POST("/mfa/enroll", mfaHandler.Enroll)
+		me.POST("/mfa/confirm", mfaHandler.Confirm)
+		me.DELETE("/mfa", mfaHandler.Disable)
+		me.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+		me.GET("/identities", oauthHandler.ListIdentities)
+		me.DELETE("/identities/:identityId", oauthHandler.UnlinkIdentity)
+	}
+
+	// Administration of other profiles
+	admin := r.Group("/admin/profiles")
+	admin.Use(authMiddleware.Authenticate(), authMiddleware.RequirePermission("profile", role.ActionManage))
+	{
+		// Administrators whose roles are scoped to a branch or batch only reach those students' profiles
+		admin.GET("", adminHandler.List)
+		admin.POST("/:id/roles", adminHandler.AssignRole)
+		admin.DELETE("/:id/roles/:roleId", adminHandler.RemoveRole)
+		admin.POST("/:id/mfa/reset", mfaHandler.AdminReset)
+		admin.POST("/:id/unlock", adminHandler.UnlockAccount)
+		admin.POST("/:id/force-password-change", adminHandler.ForcePasswordChange)
+		admin.POST("/:id/invite", adminHandler.SendInvitation)
+		admin.DELETE("/:id", adminHandler.SoftDelete)
+
+		// Recovery bin of soft deleted profiles, purged after the retention period
+		admin.GET("/deleted", adminHandler.ListDeleted)
+		admin.GET("/deleted/lookup", adminHandler.LookupDeleted)
+		admin.POST("/deleted/:id/restore", adminHandler.RestoreDeleted)
+
+		// Bulk operations take a dry_run flag and report the outcome of every row
+		admin.POST("/bulk", adminHandler.BulkCreate)
+		admin.POST("/bulk/status", adminHandler.BulkUpdateStatus)
+		admin.POST("/bulk/roles", adminHandler.BulkAssignRole)
+		admin.POST("/bulk/delete", adminHandler.BulkDelete)
+
+		// API keys can't manage API keys
+		admin.GET("/:id/api-keys", authMiddleware.RequireUser(), apiKeyHandler.List)
+		admin.POST("/:id/api-keys", authMiddleware.RequireUser(), apiKeyHandler.Create)
+		admin.POST("/:id/api-keys/:keyId/rotate", authMiddleware.RequireUser(), apiKeyHandler.Rotate)
+		admin.DELETE("/:id/api-keys/:keyId", authMiddleware.RequireUser(), apiKeyHandler.Revoke)
+	}
+}
@@ -1,45 +1,70 @@
 package router
 
 import (
-	"server/internal/api/rest/handlers"
-	"server/internal/config"
-	"server/internal/repository"
-	"server/internal/service"
+	quizhandler "server/internal/api/rest/handler/quiz"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/quiz"
+	"server/internal/domain/role"
 	"server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
 )
 
 // RegisterQuizRoutes sets up all quiz-related routes
-func RegisterQuizRoutes(r *gin.RouterGroup, db *sqlx.DB, log *logger.Logger, cfg *config.Config) {
-	// Create repositories
-	quizRepo := repository.NewQuizRepository(db)
-	
-	// Create services
-	quizService := service.NewQuizService(quizRepo, log)
-	
-	// Create handlers
-	quizHandler := handlers.NewQuizHandler(quizService, log)
-	
-	// Define routes
+func RegisterQuizRoutes(r *gin.RouterGroup, quizService quiz.Service, bankService quiz.BankService, attemptService quiz.AttemptService, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	quizHandler := quizhandler.NewQuizHandler(quizService, *log)
+	bankHandler := quizhandler.NewBankHandler(bankService, *log)
+	attemptHandler := quizhandler.NewAttemptHandler(attemptService, *log)
+
 	quizzes := r.Group("/quizzes")
+	quizzes.Use(authMiddleware.Authenticate())
+	{
+		quizzes.GET("", authMiddleware.RequirePermission("quiz", role.ActionRead), authMiddleware.CheckPermission("quiz", role.ActionUpdate), quizHandler.GetAllQuizzes)
+		quizzes.GET("/:id", authMiddleware.RequirePermission("quiz", role.ActionRead), authMiddleware.CheckPermission("quiz", role.ActionUpdate), quizHandler.GetQuizByID)
+		quizzes.POST("", authMiddleware.RequirePermission("quiz", role.ActionCreate), quizHandler.CreateQuiz)
+		quizzes.PUT("/:id", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.UpdateQuiz)
+		quizzes.DELETE("/:id", authMiddleware.RequirePermission("quiz", role.ActionDelete), quizHandler.DeleteQuiz)
+
+		// Questions come with their answers, so only authors list them. Students see them through attempts.
+		questions := quizzes.Group("/:id/questions")
+		{
+			questions.GET("", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.GetQuizQuestions)
+			questions.POST("", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.AddQuizQuestion)
+			questions.PUT("/order", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.ReorderQuizQuestions)
+			questions.PUT("/:questionId", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.UpdateQuizQuestion)
+			questions.DELETE("/:questionId", authMiddleware.RequirePermission("quiz", role.ActionUpdate), quizHandler.DeleteQuizQuestion)
+		}
+
+		quizzes.POST("/:id/attempts", authMiddleware.RequirePermission("quiz", role.ActionRead), attemptHandler.StartQuizAttempt)
+	}
+
+	// Question banks hold answers as well, so only authors see them
+	banks := r.Group("/question-banks")
+	banks.Use(authMiddleware.Authenticate())
 	{
-		quizzes.GET("", quizHandler.GetAllQuizzes)
-		quizzes.GET("/:id", quizHandler.GetQuizByID)
-		quizzes.POST("", quizHandler.CreateQuiz)
-		quizzes.PUT("/:id", quizHandler.UpdateQuiz)
-		quizzes.DELETE("/:id", quizHandler.DeleteQuiz)
-		
-		// Questions routes
-		questions := quizzes.Group("/:quizId/questions")
+		banks.GET("", authMiddleware.RequirePermission("quiz", role.ActionUpdate), bankHandler.GetAllBanks)
+		banks.GET("/:id", authMiddleware.RequirePermission("quiz", role.ActionUpdate), bankHandler.GetBankByID)
+		banks.POST("", authMiddleware.RequirePermission("quiz", role.ActionCreate), bankHandler.CreateBank)
+		banks.PUT("/:id", authMiddleware.RequirePermission("quiz", role.ActionUpdate), bankHandler.UpdateBank)
+		banks.DELETE("/:id", authMiddleware.RequirePermission("quiz", role.ActionDelete), bankHandler.DeleteBank)
+
+		questions := banks.Group("/:id/questions")
+		questions.Use(authMiddleware.RequirePermission("quiz", role.ActionUpdate))
 		{
-			questions.GET("", quizHandler.GetQuizQuestions)
-			questions.POST("", quizHandler.AddQuizQuestion)
-			questions.PUT("/:questionId", quizHandler.UpdateQuizQuestion)
-			questions.DELETE("/:questionId", quizHandler.DeleteQuizQuestion)
+			questions.GET("", bankHandler.GetBankQuestions)
+			questions.POST("", bankHandler.AddBankQuestion)
+			questions.PUT("/:questionId", bankHandler.UpdateBankQuestion)
+			questions.DELETE("/:questionId", bankHandler.DeleteBankQuestion)
 		}
-		
-		// Add more quiz-related routes as needed
 	}
-}
\ No newline at end of file
+
+	// Attempts of the authenticated student
+	attempts := r.Group("/attempts")
+	attempts.Use(authMiddleware.Authenticate(), authMiddleware.RequirePermission("quiz", role.ActionRead))
+	{
+		attempts.GET("", attemptHandler.GetStudentAttempts)
+		attempts.GET("/:attemptId", attemptHandler.GetAttemptDetails)
+		attempts.PUT("/:attemptId/answers/:questionId", attemptHandler.SaveAttemptAnswer)
+		attempts.POST("/:attemptId/submit", attemptHandler.SubmitQuizAttempt)
+	}
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"server/internal/api/rest/handler/roster"
+	"server/internal/api/rest/middleware"
+	"server/internal/domain/role"
+	studentroster "server/internal/domain/student/roster"
+	"server/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRosterRoutes sets up the routes importing the student rosters sent by the university
+func RegisterRosterRoutes(r *gin.RouterGroup, rosterService studentroster.Service, authMiddleware *middleware.AuthMiddleware, log *logger.Logger) {
+	rosterHandler := roster.NewHandler(rosterService, *log)
+
+	imports := r.Group("/students/imports")
+	imports.Use(authMiddleware.Authenticate(), authMiddleware.RequirePermission("student", role.ActionCreate))
+	{
+		// Uploading only previews the roster, committing the preview creates the students
+		imports.POST("", rosterHandler.Preview)
+		imports.GET("/:importId", rosterHandler.Get)
+		imports.POST("/:importId/commit", rosterHandler.Commit)
+	}
+}
@@ -1,21 +1,166 @@
 package router
 
 import (
+	"server/internal/api/rest/middleware"
+	"server/internal/common/utils"
+	"server/internal/common/validator"
 	"server/internal/config"
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/quiz"
+	"server/internal/domain/role"
+	"server/internal/domain/student"
+	"server/internal/domain/student/roster"
+	"server/internal/infrastructure/auth"
+	"server/internal/infrastructure/database/postgres/repositories"
+	"server/internal/infrastructure/email"
+	"server/internal/infrastructure/integration/google"
+	"server/internal/infrastructure/sandbox"
 	"server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // RegisterRoutes sets up all API routes
-func RegisterRoutes(r *gin.Engine, db *sqlx.DB, log *logger.Logger, cfg *config.Config) {
+func RegisterRoutes(r *gin.Engine, db *pgxpool.Pool, log *logger.Logger, cfg *config.Config) {
 	// API versioning
 	v1 := r.Group("/api/v1")
 
+	// Create the services every route group authenticates against
+	profileRepo := repositories.NewPostgresProfileRepository(db, log)
+
+	tokenProvider, err := auth.NewJWTProvider(cfg.Credentials.JWTSecret, cfg.Auth)
+	if err != nil {
+		log.Fatal("Failed to create token provider", "error", err)
+	}
+
+	otpProvider := auth.NewTOTPProvider(cfg.Auth.MFAIssuer)
+
+	verificationTokens, err := auth.NewSignedTokenProvider(cfg.Credentials.JWTSecret, "email-verification")
+	if err != nil {
+		log.Fatal("Failed to create verification token provider", "error", err)
+	}
+
+	apiKeys, err := auth.NewAPIKeyManager(cfg.Credentials.JWTSecret)
+	if err != nil {
+		log.Fatal("Failed to create API key manager", "error", err)
+	}
+
+	mailer := email.NewSMTPProvider(cfg.Integration.Email, log)
+
+	passwordHasher := utils.NewPasswordHasher(&utils.ArgonParams{
+		Memory:      uint32(cfg.Auth.ArgonMemory),
+		Iterations:  uint32(cfg.Auth.ArgonIterations),
+		Parallelism: uint8(cfg.Auth.ArgonParallelism),
+		SaltLength:  utils.ArgonSaltLen,
+		KeyLength:   utils.ArgonKeyLen,
+	})
+
+	passwordPolicy := cfg.Auth.PasswordPolicy
+	if cfg.Auth.BreachedPasswordFile != "" {
+		breachedPasswords, err := validator.LoadBreachedPasswordFile(cfg.Auth.BreachedPasswordFile)
+		if err != nil {
+			log.Fatal("Failed to load breached password file", "path", cfg.Auth.BreachedPasswordFile, "error", err)
+		}
+		log.Info("Loaded breached password list", "hashes", breachedPasswords.Size())
+		passwordPolicy.BreachedPasswords = breachedPasswords
+	}
+
+	identityProviders := map[string]platform_profile.IdentityProvider{}
+	if cfg.Integration.GoogleOAuth.Enabled {
+		googleProvider, err := google.NewAuthProvider(cfg.Integration.GoogleOAuth, nil)
+		if err != nil {
+			log.Fatal("Failed to create Google login provider", "error", err)
+		}
+		identityProviders[platform_profile.IdentityProviderGoogle] = googleProvider
+	}
+
+	roleService := role.NewService(repositories.NewPostgresRoleRepository(db, log), cfg.Auth.PermissionCacheTTL, *log)
+
+	securitySettings := platform_profile.SecuritySettings{
+		MFAChallengeTTL:      cfg.Auth.MFAChallengeTTL,
+		MFAMaxAttempts:       cfg.Auth.MFAMaxAttempts,
+		MFARecoveryCodeCount: cfg.Auth.MFARecoveryCodeCount,
+
+		LockoutThreshold:    cfg.Auth.LockoutThreshold,
+		LockoutBaseDuration: cfg.Auth.LockoutBaseDuration,
+		LockoutMaxDuration:  cfg.Auth.LockoutMaxDuration,
+
+		IPFailureThreshold: cfg.Auth.IPFailureThreshold,
+		IPFailureWindow:    cfg.Auth.IPFailureWindow,
+		IPBlockDuration:    cfg.Auth.IPBlockDuration,
+
+		RequireEmailVerification: cfg.Auth.RequireEmailVerification,
+		EmailVerificationTTL:     cfg.Auth.EmailVerificationTTL,
+		VerificationResendLimit:  cfg.Auth.VerificationResendLimit,
+		VerificationResendWindow: cfg.Auth.VerificationResendWindow,
+
+		PasswordPolicy:      passwordPolicy,
+		PasswordHistorySize: cfg.Auth.PasswordHistorySize,
+
+		InvitationTTL: cfg.Auth.InvitationTTL,
+
+		DeletedProfileRetention: cfg.Auth.DeletedProfileRetention,
+
+		OAuthStateTTL: cfg.Integration.GoogleOAuth.StateTTL,
+
+		APIKeyMaxLifetime:            cfg.Auth.APIKeyMaxLifetime,
+		APIKeyDefaultRotationOverlap: cfg.Auth.APIKeyDefaultRotationOverlap,
+		APIKeyMaxRotationOverlap:     cfg.Auth.APIKeyMaxRotationOverlap,
+	}
+
+	profileService := platform_profile.NewService(
+		profileRepo,
+		roleService,
+		tokenProvider,
+		otpProvider,
+		verificationTokens,
+		mailer,
+		passwordHasher,
+		apiKeys,
+		identityProviders,
+		securitySettings,
+		*log,
+	)
+
+	rosterService := roster.NewService(
+		repositories.NewPostgresRosterRepository(db, log),
+		profileService,
+		mailer,
+		passwordHasher,
+		*log,
+	)
+
+	studentService := student.NewService(
+		repositories.NewPostgresStudentRepository(db, log),
+		passwordHasher,
+		mailer,
+		student.AttendancePolicy{
+			ShortageThreshold: cfg.Student.AttendanceShortageThreshold,
+			MinimumClasses:    cfg.Student.AttendanceMinimumClasses,
+		},
+	)
+
+	quizRepo := repositories.NewPostgresQuizRepository(db, log)
+	quizService := quiz.NewService(quizRepo, *log)
+	bankService := quiz.NewBankService(quizRepo, *log)
+	attemptService := quiz.NewAttemptService(
+		quizRepo,
+		sandbox.NewSubprocessRunner(cfg.Quiz.Sandbox, log),
+		quiz.AttemptPolicy{GracePeriod: cfg.Quiz.AttemptGracePeriod},
+		*log,
+	)
+
+	authMiddleware := middleware.NewAuthMiddleware(profileService, roleService, *log)
+
 	// Register all route groups
-	RegisterStudentRoutes(v1, db, log, cfg)
-	RegisterQuizRoutes(v1, db, log, cfg)
-	
+	RegisterProfileRoutes(v1, profileService, authMiddleware, log)
+	RegisterStudentRoutes(v1, db, log, cfg, authMiddleware)
+	RegisterRosterRoutes(v1, rosterService, authMiddleware, log)
+	RegisterContactRoutes(v1, studentService, authMiddleware, log)
+	RegisterDossierRoutes(v1, studentService, authMiddleware, log)
+	RegisterAttendanceRoutes(v1, studentService, authMiddleware, log)
+	RegisterQuizRoutes(v1, quizService, bankService, attemptService, authMiddleware, log)
+
 	// Add more route groups as needed
-}
\ No newline at end of file
+}
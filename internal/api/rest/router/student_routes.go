@@ -2,35 +2,38 @@ package router
 
 import (
 	"server/internal/api/rest/handlers"
+	"server/internal/api/rest/middleware"
 	"server/internal/config"
+	"server/internal/domain/role"
 	"server/internal/repository"
 	"server/internal/service"
 	"server/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // RegisterStudentRoutes sets up all student-related routes
-func RegisterStudentRoutes(r *gin.RouterGroup, db *sqlx.DB, log *logger.Logger, cfg *config.Config) {
+func RegisterStudentRoutes(r *gin.RouterGroup, db *pgxpool.Pool, log *logger.Logger, cfg *config.Config, authMiddleware *middleware.AuthMiddleware) {
 	// Create repositories
 	studentRepo := repository.NewStudentRepository(db)
-	
+
 	// Create services
 	studentService := service.NewStudentService(studentRepo, log)
-	
+
 	// Create handlers
 	studentHandler := handlers.NewStudentHandler(studentService, log)
-	
+
 	// Define routes
 	students := r.Group("/students")
+	students.Use(authMiddleware.Authenticate())
 	{
-		students.GET("", studentHandler.GetAllStudents)
-		students.GET("/:id", studentHandler.GetStudentByID)
-		students.POST("", studentHandler.CreateStudent)
-		students.PUT("/:id", studentHandler.UpdateStudent)
-		students.DELETE("/:id", studentHandler.DeleteStudent)
-		
+		students.GET("", authMiddleware.RequirePermission("student", role.ActionRead), studentHandler.GetAllStudents)
+		students.GET("/:id", authMiddleware.RequirePermission("student", role.ActionRead), studentHandler.GetStudentByID)
+		students.POST("", authMiddleware.RequirePermission("student", role.ActionCreate), studentHandler.CreateStudent)
+		students.PUT("/:id", authMiddleware.RequirePermission("student", role.ActionUpdate), studentHandler.UpdateStudent)
+		students.DELETE("/:id", authMiddleware.RequirePermission("student", role.ActionDelete), studentHandler.DeleteStudent)
+
 		// Add more student-related routes as needed
 	}
 }
@@ -54,15 +57,15 @@ func RegisterStudentRoutes(r *gin.RouterGroup, db *sqlx.DB, log *logger.Logger,
 // 	// Create repositories
 // 	studentRepo := repository.NewStudentRepository(db)
 // 	profileRepo := repository.NewProfileRepository(db)
-	
+
 // 	// Create services
 // 	authService := service.NewAuthService(studentRepo, log, cfg)
 // 	profileService := service.NewProfileService(profileRepo, studentRepo, log, cfg)
-	
+
 // 	// Create handlers
 // 	authHandler := student.NewAuthHandler(authService, log)
 // 	profileHandler := student.NewProfileHandler(profileService, log)
-	
+
 // 	// Auth routes (no authentication required)
 // 	auth := r.Group("/auth")
 // 	{
@@ -72,7 +75,7 @@ func RegisterStudentRoutes(r *gin.RouterGroup, db *sqlx.DB, log *logger.Logger,
 // 		auth.POST("/forgot-password", authHandler.ForgotPassword)
 // 		auth.POST("/reset-password", authHandler.ResetPassword)
 // 	}
-	
+
 // 	// Student profile routes (authentication required)
 // 	students := r.Group("/students")
 // 	students.Use(middleware.Authenticate(authService))
@@ -85,4 +88,4 @@ func RegisterStudentRoutes(r *gin.RouterGroup, db *sqlx.DB, log *logger.Logger,
 // 		students.GET("/quiz-history", profileHandler.GetQuizHistory)
 // 		students.POST("/logout", authHandler.Logout)
 // 	}
-// }
\ No newline at end of file
+// }
@@ -168,3 +168,35 @@ func RespondWithError(c *gin.Context, err error) {
 	// If it's not an APIError, create an internal server error
 	InternalServerError(err).RespondWithError(c)
 }
+
+// FromDomainError converts an error returned by a domain service into an API error.
+// Errors that are not domain errors are reported as internal server errors.
+func FromDomainError(err error) *APIError {
+	var domainErr *DomainError
+	if !errors.As(err, &domainErr) {
+		return InternalServerError(err)
+	}
+
+	status := http.StatusInternalServerError
+	switch domainErr.Type {
+	case NotFoundError:
+		status = http.StatusNotFound
+	case ValidationError, BadInputError:
+		status = http.StatusBadRequest
+	case UnauthorizedError:
+		status = http.StatusUnauthorized
+	case ForbiddenError:
+		status = http.StatusForbidden
+	case ConflictError:
+		status = http.StatusConflict
+	case BusinessError:
+		status = http.StatusUnprocessableEntity
+	}
+
+	// Never leak internal causes such as database errors to the client
+	if status == http.StatusInternalServerError {
+		return NewAPIError(status, domainErr.Code, "Internal server error", nil)
+	}
+
+	return NewAPIError(status, domainErr.Code, domainErr.Message, domainErr.Details)
+}
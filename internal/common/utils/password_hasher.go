@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes passwords with Argon2id and verifies both Argon2id and legacy bcrypt hashes.
+// Verify reports when a matching hash should be replaced: bcrypt hashes and Argon2id hashes made with
+// parameters other than the current ones. Re-hashing on the next successful login lets the parameters
+// be raised without forcing a password reset.
+type PasswordHasher struct {
+	params ArgonParams
+}
+
+// NewPasswordHasher creates a hasher for the given Argon2id parameters, nil selects the defaults
+func NewPasswordHasher(params *ArgonParams) *PasswordHasher {
+	if params == nil {
+		params = DefaultArgonParams()
+	}
+
+	return &PasswordHasher{params: *params}
+}
+
+// Hash hashes a password with the current Argon2id parameters
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return hashArgon2id(password, &h.params)
+}
+
+// Verify checks a password against an Argon2id or bcrypt hash.
+// needsRehash is only meaningful when the password matched.
+func (h *PasswordHasher) Verify(password, encodedHash string) (match bool, needsRehash bool, err error) {
+	if isBcryptHash(encodedHash) {
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	}
+
+	params, salt, hash, err := decodeHash(encodedHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	otherHash := argon2.IDKey(
+		[]byte(password),
+		salt,
+		params.Iterations,
+		params.Memory,
+		params.Parallelism,
+		params.KeyLength,
+	)
+	if subtle.ConstantTimeCompare(hash, otherHash) != 1 {
+		return false, false, nil
+	}
+
+	return true, *params != h.params, nil
+}
+
+// isBcryptHash reports whether a hash is in the modular crypt format of bcrypt
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
@@ -149,10 +149,13 @@ func CSRFMiddleware() gin.HandlerFunc {
 	}
 }
 
-// HashPassword hashes a password using Argon2id
+// HashPassword hashes a password using Argon2id with the default parameters
 func HashPassword(password string) (string, error) {
-	params := DefaultArgonParams()
+	return hashArgon2id(password, DefaultArgonParams())
+}
 
+// hashArgon2id hashes a password using Argon2id with the given parameters
+func hashArgon2id(password string, params *ArgonParams) (string, error) {
 	// Generate a random salt
 	salt, err := GenerateRandomBytes(int(params.SaltLength))
 	if err != nil {
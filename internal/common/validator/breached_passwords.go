@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	sha1HexLength     = 40
+	hashPrefixLength  = 5 // Same bucket size as the Have I Been Pwned range API
+	maxBreachLineSize = 1024
+)
+
+// BreachedPasswordList is an offline breached-password set indexed the k-anonymity way:
+// SHA-1 hashes are bucketed by their first five hex characters, so a lookup only
+// touches the suffixes sharing the candidate's prefix and the plain password is never stored.
+type BreachedPasswordList struct {
+	buckets map[string]map[string]struct{}
+	size    int
+}
+
+// LoadBreachedPasswordFile reads a file with one upper or lower case SHA-1 hex hash per line,
+// optionally followed by ":<count>" as in the Have I Been Pwned downloads.
+// Empty lines and lines starting with '#' are ignored.
+func LoadBreachedPasswordFile(path string) (*BreachedPasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedPasswordList{buckets: make(map[string]map[string]struct{})}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, maxBreachLineSize), maxBreachLineSize)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(strings.TrimSpace(hash))
+		if len(hash) != sha1HexLength {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of breached password file", lineNumber)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid SHA-1 hash on line %d of breached password file: %w", lineNumber, err)
+		}
+
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password file: %w", err)
+	}
+
+	return list, nil
+}
+
+func (l *BreachedPasswordList) add(hash string) {
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	bucket, ok := l.buckets[prefix]
+	if !ok {
+		bucket = make(map[string]struct{})
+		l.buckets[prefix] = bucket
+	}
+	if _, exists := bucket[suffix]; !exists {
+		bucket[suffix] = struct{}{}
+		l.size++
+	}
+}
+
+// Contains reports whether the password's SHA-1 hash is in the list
+func (l *BreachedPasswordList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	bucket, ok := l.buckets[hash[:hashPrefixLength]]
+	if !ok {
+		return false
+	}
+	_, found := bucket[hash[hashPrefixLength:]]
+	return found
+}
+
+// Size returns the number of distinct hashes in the list
+func (l *BreachedPasswordList) Size() int {
+	if l == nil {
+		return 0
+	}
+	return l.size
+}
@@ -271,6 +271,31 @@ func SanitizeEmail(email string) string {
 	return email
 }
 
+// SanitizePhone validates a phone number and strips its separators, keeping a leading '+'.
+// Spaces, dots, dashes and parentheses are accepted between the 10 to 15 digits.
+func SanitizePhone(phone string) string {
+	// Trim whitespace
+	phone = strings.TrimSpace(phone)
+
+	// Digits with common separators, optionally in international format
+	phoneRegex := regexp.MustCompile(`^\+?[0-9 ().\-]+$`)
+	if !phoneRegex.MatchString(phone) {
+		return "" // Invalid phone number
+	}
+
+	// Keep the digits and the leading plus sign only
+	separatorRegex := regexp.MustCompile(`[^0-9]`)
+	digits := separatorRegex.ReplaceAllString(phone, "")
+	if len(digits) < 10 || len(digits) > 15 {
+		return ""
+	}
+
+	if strings.HasPrefix(phone, "+") {
+		return "+" + digits
+	}
+	return digits
+}
+
 // SanitizeUsername removes potentially dangerous characters from usernames
 func SanitizeUsername(username string) string {
 	// Trim whitespace
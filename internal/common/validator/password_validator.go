@@ -29,6 +29,9 @@ type PasswordValidationOptions struct {
 	RequireSpecial bool
 	CheckCommon    bool
 	CheckBreaches  bool
+
+	// BreachedPasswords is consulted in addition to the built-in list when CheckBreaches is set
+	BreachedPasswords *BreachedPasswordList
 }
 
 // DefaultPasswordOptions returns the standard password validation options
@@ -88,8 +91,8 @@ func ValidatePassword(password string, options PasswordValidationOptions) error
 		return ErrPasswordCommonPattern
 	}
 
-	// Check against breach database (simplified - would require external API in production)
-	if options.CheckBreaches && isBreachedPassword(password) {
+	// Check against the built-in and offline breach lists
+	if options.CheckBreaches && isBreachedPassword(password, options.BreachedPasswords) {
 		return ErrPasswordFoundInBreaches
 	}
 
@@ -120,14 +123,10 @@ func hasCommonPattern(password string) bool {
 	return false
 }
 
-// isBreachedPassword checks if a password appears in known breaches
-// In a real system, this would connect to a service like HIBP
-func isBreachedPassword(password string) bool {
-	// This is a placeholder for real breach checking logic
-	// In production, you'd use a service like Have I Been Pwned via API
-	// or maintain your own breach database
-
-	// For testing/demo - list of top breached passwords
+// isBreachedPassword checks if a password appears in known breaches,
+// either in the built-in top list or in the offline list loaded at startup
+func isBreachedPassword(password string, breaches *BreachedPasswordList) bool {
+	// List of top breached passwords, always checked
 	topBreached := map[string]bool{
 		"123456": true, "password": true, "123456789": true,
 		"12345678": true, "12345": true, "qwerty": true,
@@ -135,11 +134,16 @@ func isBreachedPassword(password string) bool {
 		"monkey": true, "111111": true, "letmein": true,
 	}
 
-	return topBreached[strings.ToLower(password)]
+	if topBreached[strings.ToLower(password)] {
+		return true
+	}
+
+	return breaches.Contains(password)
 }
 
-// PasswordStrengthScore calculates a score from 0-100 representing password strength
-func PasswordStrengthScore(password string) int {
+// PasswordStrengthScore calculates a score from 0-100 representing password strength.
+// breaches may be nil, in which case only the built-in breach list is considered.
+func PasswordStrengthScore(password string, breaches *BreachedPasswordList) int {
 	// Base score starts at 0
 	score := 0
 
@@ -189,7 +193,7 @@ func PasswordStrengthScore(password string) int {
 	}
 
 	// Breach database deduction
-	if isBreachedPassword(password) {
+	if isBreachedPassword(password, breaches) {
 		score -= 40
 	}
 
@@ -17,6 +17,9 @@ type Config struct {
 	Credentials Credentials
 	Integration IntegrationConfig
 	Features    FeatureFlags
+	Auth        AuthConfig
+	Student     StudentConfig
+	Quiz        QuizConfig
 }
 
 // ServerConfig contains all HTTP server related settings
@@ -77,6 +80,24 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load feature flags: %w", err)
 	}
 
+	// Load authentication settings
+	auth, err := loadAuthConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load auth configuration: %w", err)
+	}
+
+	// Load student records settings
+	student, err := loadStudentConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load student configuration: %w", err)
+	}
+
+	// Load quiz settings
+	quiz, err := loadQuizConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load quiz configuration: %w", err)
+	}
+
 	// Configure server
 	serverConfig := ServerConfig{
 		Port:                   getEnv("SERVER_PORT", "8080"),
@@ -117,6 +138,9 @@ func Load() (*Config, error) {
 		Credentials: *creds,
 		Integration: *integration,
 		Features:    *features,
+		Auth:        *auth,
+		Student:     *student,
+		Quiz:        *quiz,
 	}, nil
 }
 
@@ -154,4 +178,4 @@ func getEnvAsSlice(key string, fallback []string, separator string) []string {
 		return strings.Split(value, separator)
 	}
 	return fallback
-}
\ No newline at end of file
+}
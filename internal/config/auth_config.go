@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"time"
+
+	"server/internal/common/validator"
+)
+
+// AuthConfig contains authentication and token related settings
+type AuthConfig struct {
+	TokenIssuer     string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Multi-factor authentication
+	MFAIssuer            string
+	MFAChallengeTTL      time.Duration
+	MFAMaxAttempts       int
+	MFARecoveryCodeCount int
+
+	// Account lockout after failed logins
+	LockoutThreshold    int
+	LockoutBaseDuration time.Duration
+	LockoutMaxDuration  time.Duration
+
+	// Failed login tracking per client IP
+	IPFailureThreshold int
+	IPFailureWindow    time.Duration
+	IPBlockDuration    time.Duration
+
+	// Email verification
+	RequireEmailVerification bool
+	EmailVerificationTTL     time.Duration
+	VerificationResendLimit  int
+	VerificationResendWindow time.Duration
+
+	// Background cleanup of expired password reset tokens
+	ResetTokenCleanupInterval time.Duration
+
+	// Invitation links of imported profiles set the first password and stay valid for InvitationTTL
+	InvitationTTL time.Duration
+
+	// Soft deleted profiles stay restorable for DeletedProfileRetention. The recovery bin
+	// is checked for expired profiles every DeletedProfilePurgeInterval.
+	DeletedProfileRetention     time.Duration
+	DeletedProfilePurgeInterval time.Duration
+
+	// The effective permissions of a profile are cached for PermissionCacheTTL, zero disables the cache
+	PermissionCacheTTL time.Duration
+
+	// Password policy, the last PasswordHistorySize passwords can't be reused.
+	// BreachedPasswordFile optionally points to a SHA-1 hash list loaded at startup.
+	PasswordPolicy       validator.PasswordValidationOptions
+	PasswordHistorySize  int
+	BreachedPasswordFile string
+
+	// Argon2id parameters for new password hashes. Raising them re-hashes
+	// existing passwords on their next successful login.
+	ArgonMemory      int // KiB
+	ArgonIterations  int
+	ArgonParallelism int
+
+	// API keys live at most APIKeyMaxLifetime. A rotated key keeps working for the requested
+	// overlap, APIKeyDefaultRotationOverlap when none is given, up to APIKeyMaxRotationOverlap.
+	APIKeyMaxLifetime            time.Duration
+	APIKeyDefaultRotationOverlap time.Duration
+	APIKeyMaxRotationOverlap     time.Duration
+}
+
+// loadAuthConfig initializes authentication settings from environment variables
+func loadAuthConfig() (*AuthConfig, error) {
+	auth := &AuthConfig{
+		TokenIssuer:     getEnv("AUTH_TOKEN_ISSUER", "tnp-rgpv"),
+		AccessTokenTTL:  time.Duration(getEnvAsInt("AUTH_ACCESS_TOKEN_TTL", 900)) * time.Second,     // 15 minutes
+		RefreshTokenTTL: time.Duration(getEnvAsInt("AUTH_REFRESH_TOKEN_TTL", 604800)) * time.Second, // 7 days
+
+		MFAIssuer:            getEnv("AUTH_MFA_ISSUER", "TNP RGPV"),
+		MFAChallengeTTL:      time.Duration(getEnvAsInt("AUTH_MFA_CHALLENGE_TTL", 300)) * time.Second, // 5 minutes
+		MFAMaxAttempts:       getEnvAsInt("AUTH_MFA_MAX_ATTEMPTS", 5),
+		MFARecoveryCodeCount: getEnvAsInt("AUTH_MFA_RECOVERY_CODE_COUNT", 10),
+
+		LockoutThreshold:    getEnvAsInt("AUTH_LOCKOUT_THRESHOLD", 5),
+		LockoutBaseDuration: time.Duration(getEnvAsInt("AUTH_LOCKOUT_BASE_DURATION", 900)) * time.Second,  // 15 minutes
+		LockoutMaxDuration:  time.Duration(getEnvAsInt("AUTH_LOCKOUT_MAX_DURATION", 86400)) * time.Second, // 24 hours
+
+		IPFailureThreshold: getEnvAsInt("AUTH_IP_FAILURE_THRESHOLD", 20),
+		IPFailureWindow:    time.Duration(getEnvAsInt("AUTH_IP_FAILURE_WINDOW", 900)) * time.Second,  // 15 minutes
+		IPBlockDuration:    time.Duration(getEnvAsInt("AUTH_IP_BLOCK_DURATION", 1800)) * time.Second, // 30 minutes
+
+		RequireEmailVerification: getEnvAsBool("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationTTL:     time.Duration(getEnvAsInt("AUTH_EMAIL_VERIFICATION_TTL", 86400)) * time.Second, // 24 hours
+		VerificationResendLimit:  getEnvAsInt("AUTH_VERIFICATION_RESEND_LIMIT", 3),
+		VerificationResendWindow: time.Duration(getEnvAsInt("AUTH_VERIFICATION_RESEND_WINDOW", 3600)) * time.Second, // 1 hour
+
+		ResetTokenCleanupInterval: time.Duration(getEnvAsInt("AUTH_RESET_TOKEN_CLEANUP_INTERVAL", 3600)) * time.Second, // 1 hour
+
+		InvitationTTL: time.Duration(getEnvAsInt("AUTH_INVITATION_TTL", 604800)) * time.Second, // 7 days
+
+		DeletedProfileRetention:     time.Duration(getEnvAsInt("AUTH_DELETED_PROFILE_RETENTION", 2592000)) * time.Second,    // 30 days
+		DeletedProfilePurgeInterval: time.Duration(getEnvAsInt("AUTH_DELETED_PROFILE_PURGE_INTERVAL", 21600)) * time.Second, // 6 hours
+
+		PermissionCacheTTL: time.Duration(getEnvAsInt("AUTH_PERMISSION_CACHE_TTL", 60)) * time.Second,
+
+		PasswordHistorySize:  getEnvAsInt("AUTH_PASSWORD_HISTORY_SIZE", 5),
+		BreachedPasswordFile: getEnv("AUTH_BREACHED_PASSWORD_FILE", ""),
+
+		ArgonMemory:      getEnvAsInt("AUTH_ARGON_MEMORY", 64*1024), // 64 MiB
+		ArgonIterations:  getEnvAsInt("AUTH_ARGON_ITERATIONS", 1),
+		ArgonParallelism: getEnvAsInt("AUTH_ARGON_PARALLELISM", 4),
+
+		APIKeyMaxLifetime:            time.Duration(getEnvAsInt("AUTH_API_KEY_MAX_LIFETIME", 31536000)) * time.Second,          // 365 days
+		APIKeyDefaultRotationOverlap: time.Duration(getEnvAsInt("AUTH_API_KEY_DEFAULT_ROTATION_OVERLAP", 86400)) * time.Second, // 24 hours
+		APIKeyMaxRotationOverlap:     time.Duration(getEnvAsInt("AUTH_API_KEY_MAX_ROTATION_OVERLAP", 604800)) * time.Second,    // 7 days
+	}
+
+	defaults := validator.DefaultPasswordOptions()
+	auth.PasswordPolicy = validator.PasswordValidationOptions{
+		MinLength:      getEnvAsInt("AUTH_PASSWORD_MIN_LENGTH", defaults.MinLength),
+		MaxLength:      getEnvAsInt("AUTH_PASSWORD_MAX_LENGTH", defaults.MaxLength),
+		RequireUpper:   getEnvAsBool("AUTH_PASSWORD_REQUIRE_UPPER", defaults.RequireUpper),
+		RequireLower:   getEnvAsBool("AUTH_PASSWORD_REQUIRE_LOWER", defaults.RequireLower),
+		RequireNumber:  getEnvAsBool("AUTH_PASSWORD_REQUIRE_NUMBER", defaults.RequireNumber),
+		RequireSpecial: getEnvAsBool("AUTH_PASSWORD_REQUIRE_SPECIAL", defaults.RequireSpecial),
+		CheckCommon:    getEnvAsBool("AUTH_PASSWORD_CHECK_COMMON", defaults.CheckCommon),
+		CheckBreaches:  getEnvAsBool("AUTH_PASSWORD_CHECK_BREACHES", defaults.CheckBreaches),
+	}
+
+	if auth.AccessTokenTTL <= 0 || auth.RefreshTokenTTL <= 0 {
+		return nil, errors.New("token lifetimes (AUTH_ACCESS_TOKEN_TTL, AUTH_REFRESH_TOKEN_TTL) must be positive")
+	}
+
+	if auth.AccessTokenTTL >= auth.RefreshTokenTTL {
+		return nil, errors.New("access token lifetime must be shorter than refresh token lifetime")
+	}
+
+	if auth.MFAChallengeTTL <= 0 || auth.MFAMaxAttempts <= 0 || auth.MFARecoveryCodeCount <= 0 {
+		return nil, errors.New("MFA settings (AUTH_MFA_CHALLENGE_TTL, AUTH_MFA_MAX_ATTEMPTS, AUTH_MFA_RECOVERY_CODE_COUNT) must be positive")
+	}
+
+	if auth.LockoutThreshold <= 0 || auth.LockoutBaseDuration <= 0 || auth.LockoutMaxDuration < auth.LockoutBaseDuration {
+		return nil, errors.New("lockout settings (AUTH_LOCKOUT_THRESHOLD, AUTH_LOCKOUT_BASE_DURATION) must be positive and AUTH_LOCKOUT_MAX_DURATION at least the base duration")
+	}
+
+	if auth.IPFailureThreshold <= 0 || auth.IPFailureWindow <= 0 || auth.IPBlockDuration <= 0 {
+		return nil, errors.New("IP failure settings (AUTH_IP_FAILURE_THRESHOLD, AUTH_IP_FAILURE_WINDOW, AUTH_IP_BLOCK_DURATION) must be positive")
+	}
+
+	if auth.EmailVerificationTTL <= 0 || auth.VerificationResendLimit <= 0 || auth.VerificationResendWindow <= 0 {
+		return nil, errors.New("email verification settings (AUTH_EMAIL_VERIFICATION_TTL, AUTH_VERIFICATION_RESEND_LIMIT, AUTH_VERIFICATION_RESEND_WINDOW) must be positive")
+	}
+
+	if auth.ResetTokenCleanupInterval <= 0 {
+		return nil, errors.New("AUTH_RESET_TOKEN_CLEANUP_INTERVAL must be positive")
+	}
+
+	if auth.InvitationTTL <= 0 {
+		return nil, errors.New("AUTH_INVITATION_TTL must be positive")
+	}
+
+	if auth.DeletedProfileRetention <= 0 || auth.DeletedProfilePurgeInterval <= 0 {
+		return nil, errors.New("recovery bin settings (AUTH_DELETED_PROFILE_RETENTION, AUTH_DELETED_PROFILE_PURGE_INTERVAL) must be positive")
+	}
+
+	if auth.PermissionCacheTTL < 0 {
+		return nil, errors.New("AUTH_PERMISSION_CACHE_TTL must not be negative")
+	}
+
+	if auth.PasswordPolicy.MinLength < 8 || auth.PasswordPolicy.MaxLength < auth.PasswordPolicy.MinLength {
+		return nil, errors.New("AUTH_PASSWORD_MIN_LENGTH must be at least 8 and AUTH_PASSWORD_MAX_LENGTH not below it")
+	}
+
+	if auth.PasswordHistorySize < 0 {
+		return nil, errors.New("AUTH_PASSWORD_HISTORY_SIZE must not be negative")
+	}
+
+	if auth.ArgonMemory < 8*auth.ArgonParallelism || auth.ArgonIterations <= 0 || auth.ArgonParallelism <= 0 || auth.ArgonParallelism > 255 {
+		return nil, errors.New("Argon2 settings (AUTH_ARGON_MEMORY, AUTH_ARGON_ITERATIONS, AUTH_ARGON_PARALLELISM) must be positive, parallelism at most 255 and memory at least 8 KiB per thread")
+	}
+
+	if auth.APIKeyMaxLifetime <= 0 || auth.APIKeyDefaultRotationOverlap < 0 || auth.APIKeyMaxRotationOverlap < auth.APIKeyDefaultRotationOverlap {
+		return nil, errors.New("AUTH_API_KEY_MAX_LIFETIME must be positive and AUTH_API_KEY_MAX_ROTATION_OVERLAP at least AUTH_API_KEY_DEFAULT_ROTATION_OVERLAP")
+	}
+
+	return auth, nil
+}
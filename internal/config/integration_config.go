@@ -12,6 +12,7 @@ type IntegrationConfig struct {
 	Storage     StorageConfig
 	Monitoring  MonitoringConfig
 	ExternalAPI ExternalAPIConfig
+	GoogleOAuth GoogleOAuthConfig
 }
 
 // EmailConfig contains email service configuration
@@ -24,6 +25,17 @@ type EmailConfig struct {
 	MaxRetries        int
 	RetryInterval     time.Duration
 	Enabled           bool
+
+	// SMTP server used to deliver mail
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// Links placed in account emails, the token is appended as a query parameter
+	VerificationURL  string
+	PasswordResetURL string
+	InvitationURL    string
 }
 
 // SMSConfig contains SMS service configuration
@@ -66,6 +78,18 @@ type ExternalAPIConfig struct {
 	Enabled      bool
 }
 
+// GoogleOAuthConfig contains the Google OpenID Connect login configuration
+type GoogleOAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	IssuerURL    string // Overridable to point at a local fake issuer
+	HostedDomain string // Restricts logins to one Google Workspace domain when set
+	JWKSCacheTTL time.Duration
+	StateTTL     time.Duration
+	Enabled      bool
+}
+
 // loadIntegrationConfig initializes integration configurations
 func loadIntegrationConfig() (*IntegrationConfig, error) {
 	// Load environment to check if in production
@@ -90,6 +114,13 @@ func loadIntegrationConfig() (*IntegrationConfig, error) {
 		MaxRetries:        getEnvAsInt("EMAIL_MAX_RETRIES", 3),
 		RetryInterval:     time.Duration(getEnvAsInt("EMAIL_RETRY_INTERVAL", 5)) * time.Second,
 		Enabled:           getEnvAsBool("EMAIL_ENABLED", true),
+		SMTPHost:          getEnv("EMAIL_SMTP_HOST", "localhost"),
+		SMTPPort:          getEnvAsInt("EMAIL_SMTP_PORT", 587),
+		SMTPUsername:      getEnv("EMAIL_SMTP_USERNAME", ""),
+		SMTPPassword:      getAPIKey(creds, "email_smtp", getEnv("EMAIL_SMTP_PASSWORD", "")),
+		VerificationURL:   getEnv("EMAIL_VERIFICATION_URL", "http://localhost:8080/api/v1/auth/verify-email"),
+		PasswordResetURL:  getEnv("EMAIL_PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		InvitationURL:     getEnv("EMAIL_INVITATION_URL", "http://localhost:3000/accept-invitation"),
 	}
 
 	// SMS configuration
@@ -136,12 +167,33 @@ func loadIntegrationConfig() (*IntegrationConfig, error) {
 		Enabled:      getEnvAsBool("EXTERNAL_API_ENABLED", false),
 	}
 
+	// Google OpenID Connect login configuration
+	googleOAuthConfig := GoogleOAuthConfig{
+		ClientID:     getEnv("GOOGLE_OAUTH_CLIENT_ID", ""),
+		ClientSecret: getAPIKey(creds, "google_oauth", getEnv("GOOGLE_OAUTH_CLIENT_SECRET", "")),
+		RedirectURL:  getEnv("GOOGLE_OAUTH_REDIRECT_URL", "http://localhost:3000/auth/google/callback"),
+		IssuerURL:    getEnv("GOOGLE_OIDC_ISSUER_URL", "https://accounts.google.com"),
+		HostedDomain: getEnv("GOOGLE_OAUTH_HOSTED_DOMAIN", ""),
+		JWKSCacheTTL: time.Duration(getEnvAsInt("GOOGLE_OIDC_JWKS_CACHE_TTL", 3600)) * time.Second,
+		StateTTL:     time.Duration(getEnvAsInt("GOOGLE_OAUTH_STATE_TTL", 600)) * time.Second,
+		Enabled:      getEnvAsBool("GOOGLE_OAUTH_ENABLED", false),
+	}
+
+	if googleOAuthConfig.Enabled && (googleOAuthConfig.ClientID == "" || googleOAuthConfig.ClientSecret == "") {
+		return nil, fmt.Errorf("GOOGLE_OAUTH_CLIENT_ID and GOOGLE_OAUTH_CLIENT_SECRET are required when Google login is enabled")
+	}
+
+	if googleOAuthConfig.StateTTL <= 0 {
+		return nil, fmt.Errorf("GOOGLE_OAUTH_STATE_TTL must be positive")
+	}
+
 	return &IntegrationConfig{
 		Email:       emailConfig,
 		SMS:         smsConfig,
 		Storage:     storageConfig,
 		Monitoring:  monitoringConfig,
 		ExternalAPI: externalAPIConfig,
+		GoogleOAuth: googleOAuthConfig,
 	}, nil
 }
 
@@ -153,4 +205,4 @@ func getAPIKey(creds *Credentials, keyName string, defaultValue string) string {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
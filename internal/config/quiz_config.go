@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// QuizConfig contains the quiz settings
+type QuizConfig struct {
+	// Answers are accepted up to AttemptGracePeriod past the deadline of an attempt. Every
+	// AttemptSweepInterval, up to AttemptSweepBatchSize attempts left running after that are
+	// submitted automatically.
+	AttemptGracePeriod    time.Duration
+	AttemptSweepInterval  time.Duration
+	AttemptSweepBatchSize int
+
+	Sandbox SandboxConfig
+}
+
+// SandboxConfig contains the settings of the sandbox running the answers to code questions
+type SandboxConfig struct {
+	// Every submission gets a scratch directory under WorkDir, at most MaxConcurrent submissions
+	// run at a time
+	WorkDir       string
+	MaxConcurrent int
+
+	// Compilation gets CompileTimeout and CompileMemoryBytes, each run its question's own time and
+	// memory limits. Output beyond OutputLimitBytes is discarded.
+	CompileTimeout     time.Duration
+	CompileMemoryBytes int64
+	OutputLimitBytes   int
+
+	// Programs run as the host user and group UID and GID, which must be reserved for the sandbox,
+	// inside RootFS: a read-only directory with the compilers and runtimes and empty /proc and
+	// /sandbox directories. Each run gets a cgroup of its own under CgroupDir, a cgroup v2
+	// directory delegated to the server with the memory and pids controllers enabled, and may
+	// start up to MaxProcesses processes. Handing programs and their scratch directories over to
+	// that user takes CAP_SETUID, CAP_SETGID and CAP_CHOWN. Code questions are not graded until
+	// these are set.
+	UID          int
+	GID          int
+	RootFS       string
+	CgroupDir    string
+	MaxProcesses int
+}
+
+// Configured reports whether the sandbox has a user, a root and a cgroup to run programs in
+func (c SandboxConfig) Configured() bool {
+	return c.UID > 0 && c.GID > 0 && c.RootFS != "" && c.CgroupDir != ""
+}
+
+// loadQuizConfig loads the quiz settings from the environment
+func loadQuizConfig() (*QuizConfig, error) {
+	quiz := &QuizConfig{
+		AttemptGracePeriod:    time.Duration(getEnvAsInt("QUIZ_ATTEMPT_GRACE_PERIOD", 30)) * time.Second,
+		AttemptSweepInterval:  time.Duration(getEnvAsInt("QUIZ_ATTEMPT_SWEEP_INTERVAL", 30)) * time.Second,
+		AttemptSweepBatchSize: getEnvAsInt("QUIZ_ATTEMPT_SWEEP_BATCH_SIZE", 100),
+		Sandbox: SandboxConfig{
+			WorkDir:            getEnv("QUIZ_SANDBOX_WORK_DIR", filepath.Join(os.TempDir(), "quiz-sandbox")),
+			MaxConcurrent:      getEnvAsInt("QUIZ_SANDBOX_MAX_CONCURRENT", 4),
+			CompileTimeout:     time.Duration(getEnvAsInt("QUIZ_SANDBOX_COMPILE_TIMEOUT", 15)) * time.Second,
+			CompileMemoryBytes: int64(getEnvAsInt("QUIZ_SANDBOX_COMPILE_MEMORY_MB", 512)) * 1024 * 1024,
+			OutputLimitBytes:   getEnvAsInt("QUIZ_SANDBOX_OUTPUT_LIMIT", 64*1024),
+			UID:                getEnvAsInt("QUIZ_SANDBOX_UID", 0),
+			GID:                getEnvAsInt("QUIZ_SANDBOX_GID", 0),
+			RootFS:             getEnv("QUIZ_SANDBOX_ROOTFS", ""),
+			CgroupDir:          getEnv("QUIZ_SANDBOX_CGROUP_DIR", ""),
+			MaxProcesses:       getEnvAsInt("QUIZ_SANDBOX_MAX_PROCESSES", 64),
+		},
+	}
+
+	if quiz.AttemptGracePeriod < 0 {
+		return nil, errors.New("QUIZ_ATTEMPT_GRACE_PERIOD can't be negative")
+	}
+
+	if quiz.AttemptSweepInterval <= 0 || quiz.AttemptSweepBatchSize <= 0 {
+		return nil, errors.New("attempt sweep settings (QUIZ_ATTEMPT_SWEEP_INTERVAL, QUIZ_ATTEMPT_SWEEP_BATCH_SIZE) must be positive")
+	}
+
+	if !filepath.IsAbs(quiz.Sandbox.WorkDir) {
+		return nil, errors.New("QUIZ_SANDBOX_WORK_DIR must be an absolute path")
+	}
+
+	if quiz.Sandbox.MaxConcurrent <= 0 || quiz.Sandbox.CompileTimeout <= 0 || quiz.Sandbox.CompileMemoryBytes <= 0 ||
+		quiz.Sandbox.OutputLimitBytes <= 0 || quiz.Sandbox.MaxProcesses <= 0 {
+		return nil, errors.New("sandbox settings (QUIZ_SANDBOX_MAX_CONCURRENT, QUIZ_SANDBOX_COMPILE_TIMEOUT, QUIZ_SANDBOX_COMPILE_MEMORY_MB, QUIZ_SANDBOX_OUTPUT_LIMIT, QUIZ_SANDBOX_MAX_PROCESSES) must be positive")
+	}
+
+	if quiz.Sandbox.UID < 0 || quiz.Sandbox.GID < 0 {
+		return nil, errors.New("QUIZ_SANDBOX_UID and QUIZ_SANDBOX_GID can't be negative")
+	}
+
+	// Programs running as the server could read its secrets and signal it
+	if quiz.Sandbox.UID != 0 && quiz.Sandbox.UID == os.Getuid() {
+		return nil, errors.New("QUIZ_SANDBOX_UID must be a user reserved for the sandbox, not the server's own")
+	}
+
+	if (quiz.Sandbox.RootFS != "" && !filepath.IsAbs(quiz.Sandbox.RootFS)) ||
+		(quiz.Sandbox.CgroupDir != "" && !filepath.IsAbs(quiz.Sandbox.CgroupDir)) {
+		return nil, errors.New("QUIZ_SANDBOX_ROOTFS and QUIZ_SANDBOX_CGROUP_DIR must be absolute paths")
+	}
+
+	return quiz, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// StudentConfig contains the student records settings
+type StudentConfig struct {
+	// Students whose attendance rate in a course falls below AttendanceShortageThreshold percent are
+	// flagged and notified, once AttendanceMinimumClasses classes have been held
+	AttendanceShortageThreshold float64
+	AttendanceMinimumClasses    int
+
+	// Queued shortage alerts are sent every AttendanceAlertInterval, a failed alert is retried
+	// until it has been attempted AttendanceAlertMaxAttempts times
+	AttendanceAlertInterval    time.Duration
+	AttendanceAlertMaxAttempts int
+}
+
+// loadStudentConfig loads the student records settings from the environment
+func loadStudentConfig() (*StudentConfig, error) {
+	student := &StudentConfig{
+		AttendanceShortageThreshold: float64(getEnvAsInt("STUDENT_ATTENDANCE_SHORTAGE_THRESHOLD", 75)),
+		AttendanceMinimumClasses:    getEnvAsInt("STUDENT_ATTENDANCE_MINIMUM_CLASSES", 5),
+		AttendanceAlertInterval:     time.Duration(getEnvAsInt("STUDENT_ATTENDANCE_ALERT_INTERVAL", 300)) * time.Second, // 5 minutes
+		AttendanceAlertMaxAttempts:  getEnvAsInt("STUDENT_ATTENDANCE_ALERT_MAX_ATTEMPTS", 5),
+	}
+
+	if student.AttendanceShortageThreshold <= 0 || student.AttendanceShortageThreshold > 100 {
+		return nil, errors.New("STUDENT_ATTENDANCE_SHORTAGE_THRESHOLD must be a percentage between 1 and 100")
+	}
+
+	if student.AttendanceMinimumClasses <= 0 {
+		return nil, errors.New("STUDENT_ATTENDANCE_MINIMUM_CLASSES must be positive")
+	}
+
+	if student.AttendanceAlertInterval <= 0 || student.AttendanceAlertMaxAttempts <= 0 {
+		return nil, errors.New("attendance alert settings (STUDENT_ATTENDANCE_ALERT_INTERVAL, STUDENT_ATTENDANCE_ALERT_MAX_ATTEMPTS) must be positive")
+	}
+
+	return student, nil
+}
@@ -1,6 +1,5 @@
 // 1. TODO: Include gradually - line: 42
-// 2. TODO: Include OAuth
-// 3. TODO: Add models for soft delete recovery.
+// 2. TODO: Add models for soft delete recovery.
 
 // Rest models are final.
 // No changes needed.
@@ -11,6 +10,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"server/internal/domain/role"
 )
 
 // Status represents the current state of a platform profile
@@ -38,6 +39,9 @@ type PlatformProfile struct {
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedByUserAt     time.Time  `json:"updated_by_user_at"`
 	UpdatedBySystemAt   time.Time  `json:"updated_by_system_at"`
+
+	// MustChangePassword is set by an administrator. Logins then only get a token for changing the password.
+	MustChangePassword bool `json:"must_change_password"`
 }
 
 // CreateProfileRequest represents data needed to create a new profile
@@ -70,12 +74,70 @@ type LoginRequest struct {
 	Username string `json:"username,omitempty"`
 	Email    string `json:"email,omitempty"`
 	Password string `json:"password" validate:"required"`
+
+	// Client details recorded on the session created by this login. Set by the handler.
+	UserAgent string `json:"-"`
+	IPAddress string `json:"-"`
 }
 
 // GetProfileRequest represents data needed to get an existing profile
 type GetSoftDeletedProfileRequest struct {
-	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=30"`
-	Email    *string `json:"email,omitempty" validate:"omitempty,email"`
+	Username *string `json:"username,omitempty" form:"username" validate:"omitempty,min=3,max=30"`
+	Email    *string `json:"email,omitempty" form:"email" validate:"omitempty,email"`
+}
+
+// DeletedProfile is a soft deleted profile waiting in the recovery bin. It can be restored
+// until PurgeAt, when the retention worker deletes it for good.
+type DeletedProfile struct {
+	PlatformProfile
+	DeletedAt time.Time  `json:"deleted_at"`
+	DeletedBy *uuid.UUID `json:"deleted_by,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	PurgeAt   time.Time  `json:"purge_at"`
+}
+
+// SoftDeleteRequest represents the data needed to move a profile to the recovery bin
+type SoftDeleteRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// ListProfilesRequest represents a page of profiles. Search matches part of the username or email.
+type ListProfilesRequest struct {
+	Search   string     `form:"search" validate:"omitempty,max=100"`
+	Status   Status     `form:"status" validate:"omitempty,oneof=activated deactivated suspended pending locked"`
+	RoleID   *uuid.UUID `form:"role_id"`
+	Page     int        `form:"page" validate:"omitempty,min=1"`
+	PageSize int        `form:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// ProfileList is a page of profiles
+type ProfileList struct {
+	Profiles []*PlatformProfile `json:"profiles"`
+	Total    int                `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// AssignRoleRequest represents the data needed to assign a role to a profile. A scoped assignment
+// only covers the students of the branch and/or admission year batch.
+type AssignRoleRequest struct {
+	RoleID uuid.UUID  `json:"role_id" validate:"required"`
+	Scope  role.Scope `json:"scope"`
+}
+
+// ListDeletedProfilesRequest represents a page of the recovery bin. Search matches part of the username or email.
+type ListDeletedProfilesRequest struct {
+	Search   string `form:"search" validate:"omitempty,max=100"`
+	Page     int    `form:"page" validate:"omitempty,min=1"`
+	PageSize int    `form:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// DeletedProfileList is a page of the recovery bin
+type DeletedProfileList struct {
+	Profiles []*DeletedProfile `json:"profiles"`
+	Total    int               `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
 }
 
 // PasswordResetRequest represents data needed to request password reset
@@ -89,21 +151,48 @@ type PasswordChangeRequest struct {
 	NewPassword     string `json:"new_password" validate:"required,min=8,nefield=CurrentPassword"`
 }
 
-// PasswordResetToken stores information for password reset functionality
+// PasswordResetToken stores information for password reset functionality. Only the hash of the
+// token sent in the reset link is kept.
 type PasswordResetToken struct {
 	ProfileID uuid.UUID `json:"-"`
-	Token     string    `json:"-"`
+	TokenHash string    `json:"-"`
 	ExpiresAt time.Time `json:"-"` // Kept redundantly for admin tasks like auditing, lifetime extension, etc. and adding security.
 	IsUsed    bool      `json:"-"`
 	CreatedAt time.Time `json:"-"`
 }
 
+// EmailVerificationToken stores a sent email verification link. Only the hash of the signed token is kept.
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `json:"-"`
+	ProfileID uuid.UUID  `json:"-"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"-"`
+	UsedAt    *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"-"`
+}
+
+// ResendVerificationRequest represents data needed to send a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 // PasswordResetConfirmation represents data needed to confirm password reset
 type PasswordResetConfirmation struct {
 	Token       string `json:"token" validate:"required"`
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
+// PasswordStrengthRequest asks for the strength score of a password before it is submitted
+type PasswordStrengthRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// RegistrationResponse is the created profile along with the strength score of its password
+type RegistrationResponse struct {
+	*PlatformProfile
+	PasswordStrength int `json:"password_strength"`
+}
+
 // ProfilePreference stores user preferences for the platform
 type ProfilePreference struct {
 	ID                 uuid.UUID `json:"id"`
@@ -128,3 +217,365 @@ type ProfileRole struct {
 	RoleID     uuid.UUID `json:"role_id"`
 	AssignedAt time.Time `json:"assigned_at"`
 }
+
+// TokenPair is the set of tokens handed to a client after a successful login or refresh
+type TokenPair struct {
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	TokenType             string    `json:"token_type"`
+}
+
+// AuthResponse is returned by a successful authentication.
+// When the profile has MFA enabled, only MFARequired and MFAChallengeToken are set and
+// the challenge token has to be exchanged for tokens with a second factor.
+// When the profile must change its password, only PasswordChangeRequired and PasswordChangeToken
+// are set and the token is accepted for nothing but changing the password.
+type AuthResponse struct {
+	Profile *PlatformProfile `json:"profile,omitempty"`
+	Tokens  *TokenPair       `json:"tokens,omitempty"`
+
+	MFARequired       bool   `json:"mfa_required,omitempty"`
+	MFAChallengeToken string `json:"mfa_challenge_token,omitempty"`
+
+	PasswordChangeRequired bool   `json:"password_change_required,omitempty"`
+	PasswordChangeToken    string `json:"password_change_token,omitempty"`
+}
+
+// RefreshToken stores an issued refresh token. Only the hash of the opaque token is persisted.
+// All tokens rotated out of the same login share a FamilyID, so reuse of an old token
+// can revoke every token descended from that login.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"-"`
+	ProfileID uuid.UUID  `json:"-"`
+	FamilyID  uuid.UUID  `json:"-"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"-"`
+	UsedAt    *time.Time `json:"-"` // Set when the token is rotated
+	RevokedAt *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"-"`
+}
+
+// RefreshTokenRequest represents data needed to rotate a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// AccessClaims holds the verified contents of an access token
+type AccessClaims struct {
+	ProfileID uuid.UUID
+	SessionID uuid.UUID
+	Roles     []string
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Scope is set on restricted tokens, which belong to no session and are only accepted
+	// by routes allowing that scope
+	Scope string
+}
+
+// ScopePasswordChange restricts a token to changing the password of its profile
+const ScopePasswordChange = "password_change"
+
+// Session is a login on a single device. A session lives as long as the refresh token
+// family created by the login, and its ID is the family ID.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	ProfileID  uuid.UUID  `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	DeviceInfo string     `json:"device_info,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	// Current is set when listing sessions to mark the session of the caller
+	Current bool `json:"current"`
+}
+
+// ProfileMFA stores the TOTP second factor of a profile.
+// Enabled is only set once the profile confirmed enrollment with a first valid code.
+type ProfileMFA struct {
+	ProfileID    uuid.UUID  `json:"-"`
+	Secret       string     `json:"-"`
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"` // TOTP time step of the last accepted code, prevents replays
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// MFARecoveryCode is a one-time code that can replace a TOTP code. Only its Argon2 hash is stored.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"-"`
+	ProfileID uuid.UUID  `json:"-"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"-"`
+}
+
+// MFAChallenge is issued after a correct password for a profile with MFA enabled.
+// Only the hash of the challenge token is stored.
+type MFAChallenge struct {
+	ID         uuid.UUID  `json:"-"`
+	ProfileID  uuid.UUID  `json:"-"`
+	TokenHash  string     `json:"-"`
+	UserAgent  string     `json:"-"`
+	IPAddress  string     `json:"-"`
+	Attempts   int        `json:"-"`
+	ExpiresAt  time.Time  `json:"-"`
+	ConsumedAt *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"-"`
+}
+
+// MFAEnrollment is returned when a profile starts TOTP enrollment
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFARecoveryCodes holds freshly generated recovery codes. They are shown to the user only once.
+type MFARecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFACodeRequest represents a TOTP code or a recovery code entered by the user
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,min=6,max=32"`
+}
+
+// MFAVerifyRequest represents data needed to complete a login that requires MFA
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,min=6,max=32"`
+}
+
+// AdminMFAResetRequest represents data needed for an administrator to remove the MFA of a profile
+type AdminMFAResetRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500"`
+}
+
+// AccountLockout is the lockout state of a profile. LockCount is the number of locks since the
+// last successful login and grows the lock duration exponentially.
+type AccountLockout struct {
+	ProfileID    uuid.UUID  `json:"profile_id"`
+	LockCount    int        `json:"lock_count"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	LastLockedAt *time.Time `json:"last_locked_at,omitempty"`
+}
+
+// AdminUnlockRequest represents the data needed for an administrator to unlock a profile
+type AdminUnlockRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500"`
+}
+
+// AuditLog records an administrative action taken on a profile
+type AuditLog struct {
+	ID              uuid.UUID              `json:"id"`
+	ActorProfileID  uuid.UUID              `json:"actor_profile_id"`
+	TargetProfileID uuid.UUID              `json:"target_profile_id"`
+	Action          string                 `json:"action"`
+	Details         map[string]interface{} `json:"details,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+}
+
+// Audited administrative actions
+const (
+	AuditActionMFAReset      = "mfa_reset"
+	AuditActionAccountUnlock = "account_unlock"
+	AuditActionAPIKeyCreate  = "api_key_create"
+	AuditActionAPIKeyRotate  = "api_key_rotate"
+	AuditActionAPIKeyRevoke  = "api_key_revoke"
+	AuditActionProfilePurge  = "profile_purge"
+)
+
+// SystemActorID is the actor of audited actions taken by background workers
+var SystemActorID = uuid.Nil
+
+// Supported external identity providers
+const (
+	IdentityProviderGoogle = "google"
+)
+
+// ExternalIdentity is a user identity asserted by an external OpenID Connect provider
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LinkedIdentity links an external provider account to a profile. A profile can have
+// one identity per provider account, across several providers.
+type LinkedIdentity struct {
+	ID          uuid.UUID  `json:"id"`
+	ProfileID   uuid.UUID  `json:"profile_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OAuthState is a pending external login. Only the hash of the state parameter is stored,
+// along with the PKCE code verifier and the nonce expected in the ID token.
+type OAuthState struct {
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// OAuthAuthorization is where the client sends the user to log in with an external provider
+type OAuthAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OAuthCallbackRequest represents the data the provider redirected back with
+type OAuthCallbackRequest struct {
+	Provider  string `json:"-"`
+	State     string `json:"state" validate:"required"`
+	Code      string `json:"code" validate:"required"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// APIKeyScope allows an API key an action on a resource, with the resources and actions of the role model.
+// A key is never allowed more than the roles of the profile it belongs to.
+type APIKeyScope struct {
+	Resource string `json:"resource" validate:"required,max=100"`
+	Action   string `json:"action" validate:"required,oneof=create read update delete manage"`
+}
+
+// APIKey authenticates a script or partner system as the profile it belongs to. Only the hash
+// of the key is stored, the prefix identifies the key without revealing it.
+type APIKey struct {
+	ID          uuid.UUID     `json:"id"`
+	ProfileID   uuid.UUID     `json:"profile_id"`
+	Name        string        `json:"name"`
+	Prefix      string        `json:"prefix"`
+	KeyHash     string        `json:"-"`
+	Scopes      []APIKeyScope `json:"scopes"`
+	AllowedIPs  []string      `json:"allowed_ips,omitempty"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	LastUsedAt  *time.Time    `json:"last_used_at,omitempty"`
+	LastUsedIP  *string       `json:"last_used_ip,omitempty"`
+	RevokedAt   *time.Time    `json:"revoked_at,omitempty"`
+	RotatedFrom *uuid.UUID    `json:"rotated_from,omitempty"`
+	CreatedBy   uuid.UUID     `json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// Allows reports whether the scopes of the key cover the action on the resource.
+// A scope with the manage action covers every action on its resource.
+func (k *APIKey) Allows(resource, action string) bool {
+	for _, scope := range k.Scopes {
+		if scope.Resource == resource && (scope.Action == action || scope.Action == role.ActionManage) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest represents the data needed to create an API key.
+// AllowedIPs holds addresses or CIDR ranges, an empty list allows every address.
+type CreateAPIKeyRequest struct {
+	Name       string        `json:"name" validate:"required,min=3,max=100"`
+	Scopes     []APIKeyScope `json:"scopes" validate:"required,min=1,dive"`
+	ExpiresAt  time.Time     `json:"expires_at" validate:"required"`
+	AllowedIPs []string      `json:"allowed_ips" validate:"omitempty,dive,cidr|ip"`
+}
+
+// RotateAPIKeyRequest represents the data needed to rotate an API key. The old key keeps
+// working for OverlapSeconds so callers can switch without downtime.
+type RotateAPIKeyRequest struct {
+	OverlapSeconds *int `json:"overlap_seconds" validate:"omitempty,min=0"`
+}
+
+// CreatedAPIKey holds a new API key. The key is shown to the caller only once.
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// MaxBulkItems is the largest number of rows accepted by one bulk operation
+const MaxBulkItems = 5000
+
+// BulkItemStatus is the outcome of one row of a bulk operation
+type BulkItemStatus string
+
+const (
+	BulkItemCreated BulkItemStatus = "created"
+	BulkItemUpdated BulkItemStatus = "updated"
+	BulkItemDeleted BulkItemStatus = "deleted"
+	BulkItemSkipped BulkItemStatus = "skipped"
+	BulkItemFailed  BulkItemStatus = "failed"
+)
+
+// BulkItemResult reports the outcome of one row of a bulk operation. Index is the position of the
+// row in the request, Reason explains skipped and failed rows.
+type BulkItemResult struct {
+	Index     int            `json:"index"`
+	ProfileID *uuid.UUID     `json:"profile_id,omitempty"`
+	Username  string         `json:"username,omitempty"`
+	Status    BulkItemStatus `json:"status"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+// BulkResult is the per row report of a bulk operation. In a dry run every row is processed
+// and reported the same way, but nothing is saved.
+type BulkResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Skipped   int              `json:"skipped"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// BulkProfileInput is one profile of a bulk creation. Profiles created without a password
+// must set one with a password reset before they can log in.
+type BulkProfileInput struct {
+	Username string `json:"username" validate:"required,min=3,max=30"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"omitempty,min=8"`
+}
+
+// BulkCreateProfilesRequest represents the data needed to create many profiles at once.
+// Activate creates the profiles as activated and verified instead of pending.
+type BulkCreateProfilesRequest struct {
+	Profiles []BulkProfileInput `json:"profiles" validate:"required,min=1,max=5000,dive"`
+	Activate bool               `json:"activate"`
+	DryRun   bool               `json:"dry_run"`
+}
+
+// BulkStatusRequest represents the data needed to change the status of many profiles at once
+type BulkStatusRequest struct {
+	ProfileIDs []uuid.UUID `json:"profile_ids" validate:"required,min=1,max=5000"`
+	Status     Status      `json:"status" validate:"required,oneof=activated suspended locked"`
+	DryRun     bool        `json:"dry_run"`
+}
+
+// BulkRoleRequest represents the data needed to assign a role to many profiles at once
+type BulkRoleRequest struct {
+	ProfileIDs []uuid.UUID `json:"profile_ids" validate:"required,min=1,max=5000"`
+	RoleID     uuid.UUID   `json:"role_id" validate:"required"`
+	Scope      role.Scope  `json:"scope"`
+	DryRun     bool        `json:"dry_run"`
+}
+
+// BulkDeleteRequest represents the data needed to delete many profiles at once.
+// Soft deleted profiles can be restored, hard deleted ones can't.
+type BulkDeleteRequest struct {
+	ProfileIDs []uuid.UUID `json:"profile_ids" validate:"required,min=1,max=5000"`
+	HardDelete bool        `json:"hard_delete"`
+	Reason     string      `json:"reason" validate:"omitempty,max=500"`
+	DryRun     bool        `json:"dry_run"`
+}
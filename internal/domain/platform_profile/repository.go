@@ -2,6 +2,9 @@ package platform_profile
 
 import (
 	"context"
+	"time"
+
+	"server/internal/domain/role"
 
 	"github.com/google/uuid"
 )
@@ -21,30 +24,88 @@ type Repository interface {
 
 	UpdateProfile(ctx context.Context, profile *PlatformProfile) error
 
-	SoftDeleteProfile(ctx context.Context, id uuid.UUID) error
+	// SoftDeleteProfile moves a profile to the recovery bin and soft deletes its student records
+	SoftDeleteProfile(ctx context.Context, id uuid.UUID, deletedBy uuid.UUID, reason string) error
 
 	// Status management
 	VerifyProfile(ctx context.Context, id uuid.UUID) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status Status) error
 
+	// Email verification tokens
+	CreateEmailVerificationToken(ctx context.Context, token *EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (*EmailVerificationToken, error)
+	ConsumeEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error)
+	CountEmailVerificationTokensSince(ctx context.Context, profileID uuid.UUID, since time.Time) (int, error)
+	DeleteEmailVerificationTokens(ctx context.Context, profileID uuid.UUID) error
+
 	// Password / Authentication  operations
 	CreatePasswordResetToken(ctx context.Context, resetToken *PasswordResetToken) error
-	GetPasswordResetToken(ctx context.Context, token string) (*PasswordResetToken, error)
-	MarkPasswordResetTokenUsed(ctx context.Context, token string) error
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) error
 	DeleteOtherPasswordResetTokens(ctx context.Context, profileID uuid.UUID) error
+	ExpirePasswordResetTokens(ctx context.Context, profileID uuid.UUID) error
+	DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error)
 	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	ReplacePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error
+	SetMustChangePassword(ctx context.Context, id uuid.UUID, mustChange bool) error
+	AddPasswordHistory(ctx context.Context, profileID uuid.UUID, passwordHash string, keep int) error
+	GetPasswordHistory(ctx context.Context, profileID uuid.UUID, limit int) ([]string, error)
+
+	// Refresh token operations
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	ConsumeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllRefreshTokens(ctx context.Context, profileID uuid.UUID) error
+
+	// Session operations
+	CreateSession(ctx context.Context, session *Session, token *RefreshToken) error
+	GetSession(ctx context.Context, id uuid.UUID) (*Session, error)
+	ListActiveSessions(ctx context.Context, profileID uuid.UUID) ([]*Session, error)
+	TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, profileID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, profileID, keepSessionID uuid.UUID) (int64, error)
+	RevokeAllSessions(ctx context.Context, profileID uuid.UUID) error
+
+	// MFA operations
+	IsMFAEnabled(ctx context.Context, profileID uuid.UUID) (bool, error)
+	GetMFA(ctx context.Context, profileID uuid.UUID) (*ProfileMFA, error)
+	SaveMFA(ctx context.Context, mfa *ProfileMFA) error
+	EnableMFA(ctx context.Context, profileID uuid.UUID, recoveryCodes []*MFARecoveryCode) error
+	UpdateMFALastUsedStep(ctx context.Context, profileID uuid.UUID, step int64) (bool, error)
+	DeleteMFA(ctx context.Context, profileID uuid.UUID) error
+	ResetMFA(ctx context.Context, profileID uuid.UUID, audit *AuditLog) error
+	ReplaceRecoveryCodes(ctx context.Context, profileID uuid.UUID, recoveryCodes []*MFARecoveryCode) error
+	GetUnusedRecoveryCodes(ctx context.Context, profileID uuid.UUID) ([]*MFARecoveryCode, error)
+	MarkRecoveryCodeUsed(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// MFA login challenges
+	CreateMFAChallenge(ctx context.Context, challenge *MFAChallenge) error
+	GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*MFAChallenge, error)
+	IncrementMFAChallengeAttempts(ctx context.Context, id uuid.UUID) error
+	ConsumeMFAChallenge(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// Audit log
+	CreateAuditLog(ctx context.Context, audit *AuditLog) error
 
 	// Recovery operations
-	GetSoftDeletedProfileByUsername(ctx context.Context, username string) (*PlatformProfile, error)
-	GetSoftDeletedProfileByEmail(ctx context.Context, email string) (*PlatformProfile, error)
+	GetSoftDeletedProfileByUsername(ctx context.Context, username string) (*DeletedProfile, error)
+	GetSoftDeletedProfileByEmail(ctx context.Context, email string) (*DeletedProfile, error)
+	GetSoftDeletedProfile(ctx context.Context, id uuid.UUID) (*DeletedProfile, error)
+	// ListSoftDeletedProfiles lists the recovery bin, limited to the profiles of students within the access scope
+	ListSoftDeletedProfiles(ctx context.Context, search string, access role.AccessScope, offset, limit int) ([]*DeletedProfile, int, error)
 	RestoreSoftDeletedProfile(ctx context.Context, id uuid.UUID) error
 
 	// Hard / Permanaent delete operations
 	HardDeleteProfile(ctx context.Context, id uuid.UUID) error
+	// PurgeSoftDeletedProfiles deletes up to limit profiles soft deleted before deletedBefore for good,
+	// recording each in the audit log. It returns how many were purged.
+	PurgeSoftDeletedProfiles(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
 
 	// Role associations
 	AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID) error
 	GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]uuid.UUID, error)
+	GetProfileRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error)
 	RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error
 
 	// Preferences
@@ -52,15 +113,58 @@ type Repository interface {
 	GetPreferences(ctx context.Context, profileID uuid.UUID) (*ProfilePreference, error)
 
 	// Bulk/query operations (only for admin)
+	// GetProfiles filters by "status", "verified", "search", "role_id", "created_after", "created_before"
+	// and "scope", a role.AccessScope limiting the profiles to those of the students within it
 	GetProfiles(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*PlatformProfile, int, error)
 	GetProfilesByRole(ctx context.Context, roleID uuid.UUID, page, pageSize int) ([]*PlatformProfile, int, error)
+	// ProfilesInScope returns the profiles among profileIDs, including soft deleted ones, that belong
+	// to students within the access scope
+	ProfilesInScope(ctx context.Context, profileIDs []uuid.UUID, access role.AccessScope) ([]uuid.UUID, error)
 	GetProfilesByPreferences(ctx context.Context, preferences map[string]interface{}, page, pageSize int) ([]*PlatformProfile, int, error)
 	DeleteProfiles(ctx context.Context, profileIDs []uuid.UUID, hardDelete bool) error
 
+	// Bulk operations (only for admin), run in batches and reporting the outcome of every row
+	BulkCreateProfiles(ctx context.Context, profiles []*PlatformProfile, dryRun bool) ([]BulkItemResult, error)
+	BulkUpdateStatus(ctx context.Context, profileIDs []uuid.UUID, status Status, dryRun bool) ([]BulkItemResult, error)
+	BulkAssignRole(ctx context.Context, profileIDs []uuid.UUID, roleID uuid.UUID, scope role.Scope, dryRun bool) ([]BulkItemResult, error)
+	BulkDeleteProfiles(ctx context.Context, profileIDs []uuid.UUID, hardDelete bool, deletedBy uuid.UUID, reason string, dryRun bool) ([]BulkItemResult, error)
+
 	// Login management
 	RecordLogin(ctx context.Context, id uuid.UUID) error
 
 	// Failed Login Attempt management
-	IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) error
+	IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) (int, error)
 	ResetFailedLoginAttempts(ctx context.Context, id uuid.UUID) error
+
+	// Account lockout
+	GetAccountLockout(ctx context.Context, profileID uuid.UUID) (*AccountLockout, error)
+	LockProfile(ctx context.Context, profileID uuid.UUID, lockedUntil time.Time) error
+	UnlockProfile(ctx context.Context, profileID uuid.UUID, clearHistory bool, audit *AuditLog) (bool, error)
+	ClearAccountLockout(ctx context.Context, profileID uuid.UUID) error
+
+	// Per IP failed login tracking
+	RecordIPLoginFailure(ctx context.Context, ipAddress string, window time.Duration) (int, error)
+	BlockIP(ctx context.Context, ipAddress string, blockedUntil time.Time) error
+	GetIPBlockedUntil(ctx context.Context, ipAddress string) (*time.Time, error)
+
+	// External identity login
+	CreateOAuthState(ctx context.Context, state *OAuthState) error
+	ConsumeOAuthState(ctx context.Context, stateHash string) (*OAuthState, error)
+	DeleteExpiredOAuthStates(ctx context.Context) (int64, error)
+
+	// Linked external identities
+	CreateLinkedIdentity(ctx context.Context, identity *LinkedIdentity) error
+	GetLinkedIdentity(ctx context.Context, provider string, subject string) (*LinkedIdentity, error)
+	ListLinkedIdentities(ctx context.Context, profileID uuid.UUID) ([]*LinkedIdentity, error)
+	DeleteLinkedIdentity(ctx context.Context, profileID uuid.UUID, id uuid.UUID) (bool, error)
+	RecordIdentityLogin(ctx context.Context, id uuid.UUID) error
+
+	// API keys
+	CreateAPIKey(ctx context.Context, key *APIKey, audit *AuditLog) error
+	GetAPIKey(ctx context.Context, profileID uuid.UUID, id uuid.UUID) (*APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, profileID uuid.UUID) ([]*APIKey, error)
+	RotateAPIKey(ctx context.Context, oldKeyID uuid.UUID, oldKeyExpiresAt time.Time, newKey *APIKey, audit *AuditLog) (bool, error)
+	RevokeAPIKey(ctx context.Context, profileID uuid.UUID, id uuid.UUID, audit *AuditLog) (bool, error)
+	RecordAPIKeyUse(ctx context.Context, id uuid.UUID, ipAddress string) error
 }
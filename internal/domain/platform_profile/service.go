@@ -1,11 +1,9 @@
 // 1. TODO: Implement VerifyProfile
 // 2. TODO: Implement ActivateProfile
-// 3. TODO: Reset Preferences Function.
 // 4. TODO: Save Preferences Function.
 // 5. TODO: List All Profiles Function.
 // 6. TODO: Add Lock and Unlock mechanisms for multiple failed logins.
 // 7. TODO: Implement ValidatePasswordResetToken
-// 8. TODO: Implement SendPasswordResetEmail
 // 9. TODO: Implement ExpireOldResetTokens
 // 10. TODO: Implement ForcePasswordChange
 // 11. TODO: Implement DeleteExpiredResetTokens
@@ -14,13 +12,19 @@ package platform_profile
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"server/pkg/logger"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 
 	"server/internal/common/errors"
+	"server/internal/common/utils"
+	"server/internal/common/validator"
 	"server/internal/domain/role"
 )
 
@@ -30,43 +34,203 @@ type Service interface {
 	RegisterProfile(ctx context.Context, req CreateProfileRequest) (*PlatformProfile, error)
 	GetProfile(ctx context.Context, req GetProfileRequest) (*PlatformProfile, error)
 	UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest) (*PlatformProfile, error)
-	SoftDeleteProfile(ctx context.Context, id uuid.UUID) error
+	SoftDeleteProfile(ctx context.Context, actorID uuid.UUID, id uuid.UUID, req SoftDeleteRequest) error
 
 	// Account Verification and Status Management
-	VerifyProfile(ctx context.Context, id uuid.UUID) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, req ResendVerificationRequest) error
 	ActivateProfile(ctx context.Context, id uuid.UUID) error
 	SuspendProfile(ctx context.Context, id uuid.UUID) error
 	DeactivateProfile(ctx context.Context, id uuid.UUID) error
 
 	// Account Restoration
-	GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedProfileRequest) (*PlatformProfile, error)
+	GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedProfileRequest) (*DeletedProfile, error)
+	ListDeletedProfiles(ctx context.Context, req ListDeletedProfilesRequest) (*DeletedProfileList, error)
+	RestoreProfile(ctx context.Context, id uuid.UUID) error
 
 	// Authentication
-	AuthenticateProfile(ctx context.Context, req LoginRequest) (*PlatformProfile, error)
+	AuthenticateProfile(ctx context.Context, req LoginRequest) (*AuthResponse, error)
+	RefreshTokens(ctx context.Context, req RefreshTokenRequest) (*TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	ValidateAccessToken(ctx context.Context, accessToken string) (*AccessClaims, error)
 	ChangePassword(ctx context.Context, id uuid.UUID, req PasswordChangeRequest) error
 	RequestPasswordReset(ctx context.Context, req PasswordResetRequest) error
 	ConfirmPasswordReset(ctx context.Context, req PasswordResetConfirmation) error
+	SendInvitation(ctx context.Context, profileID uuid.UUID) error
 	LockAccountAfterFailedAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) error
 	UnlockAccount(ctx context.Context, id uuid.UUID) error
+	AdminUnlockAccount(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req AdminUnlockRequest) error
 	ValidatePasswordResetToken(ctx context.Context, token string) (*PasswordResetToken, error)
-	SendPasswordResetEmail(ctx context.Context, email string, resetLink string) error
 	ExpireOldResetTokens(ctx context.Context, profileID uuid.UUID) error
 	ForcePasswordChange(ctx context.Context, id uuid.UUID) error
 	DeleteExpiredResetTokens(ctx context.Context) error
+	PasswordStrength(password string) int
+
+	// Multi-factor authentication
+	BeginMFAEnrollment(ctx context.Context, profileID uuid.UUID) (*MFAEnrollment, error)
+	ConfirmMFAEnrollment(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) (*MFARecoveryCodes, error)
+	VerifyMFA(ctx context.Context, req MFAVerifyRequest) (*AuthResponse, error)
+	DisableMFA(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) (*MFARecoveryCodes, error)
+	AdminResetMFA(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req AdminMFAResetRequest) error
+
+	// Sessions
+	ListSessions(ctx context.Context, profileID uuid.UUID, currentSessionID uuid.UUID) ([]*Session, error)
+	RevokeSession(ctx context.Context, profileID uuid.UUID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, profileID uuid.UUID, currentSessionID uuid.UUID) (int64, error)
+
+	// External identity login
+	BeginOAuthLogin(ctx context.Context, provider string) (*OAuthAuthorization, error)
+	CompleteOAuthLogin(ctx context.Context, req OAuthCallbackRequest) (*AuthResponse, error)
+	ListLinkedIdentities(ctx context.Context, profileID uuid.UUID) ([]*LinkedIdentity, error)
+	UnlinkIdentity(ctx context.Context, profileID uuid.UUID, identityID uuid.UUID) error
+
+	// API keys
+	CreateAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, profileID uuid.UUID) ([]*APIKey, error)
+	RotateAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, keyID uuid.UUID, req RotateAPIKeyRequest) (*CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, keyID uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, key string, ipAddress string) (*APIKey, error)
 
 	// Role Management
-	AssignRole(ctx context.Context, profileID uuid.UUID, roleID uuid.UUID) error
+	AssignRole(ctx context.Context, actorID, profileID uuid.UUID, roleID uuid.UUID, scope role.Scope) error
 	RemoveRole(ctx context.Context, profileID uuid.UUID, roleID uuid.UUID) error
 	HasRole(ctx context.Context, profileID uuid.UUID, roleName string) (bool, error)
+	GetRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error)
 
 	// Preferences
 	UpdatePreferences(ctx context.Context, profileID uuid.UUID, prefs ProfilePreference) error
 	GetPreferences(ctx context.Context, profileID uuid.UUID) (*ProfilePreference, error)
 
-	// Administrative functions
+	// Administrative functions, limited to the caller's access scope
+	ListProfiles(ctx context.Context, req ListProfilesRequest) (*ProfileList, error)
 	SearchProfiles(ctx context.Context, query string, page, pageSize int) ([]*PlatformProfile, int, error)
 	ListProfilesByRole(ctx context.Context, roleID uuid.UUID, page, pageSize int) ([]*PlatformProfile, int, error)
 	ResetPreferencesToDefault(ctx context.Context, profileID uuid.UUID) (*ProfilePreference, error)
+
+	// Bulk administration, reporting the outcome of every row
+	BulkCreateProfiles(ctx context.Context, actorID uuid.UUID, req BulkCreateProfilesRequest) (*BulkResult, error)
+	BulkUpdateStatus(ctx context.Context, actorID uuid.UUID, req BulkStatusRequest) (*BulkResult, error)
+	BulkAssignRole(ctx context.Context, actorID uuid.UUID, req BulkRoleRequest) (*BulkResult, error)
+	BulkDeleteProfiles(ctx context.Context, actorID uuid.UUID, req BulkDeleteRequest) (*BulkResult, error)
+}
+
+// TokenProvider issues and verifies the tokens handed out after authentication.
+// Implemented by infrastructure/auth.JWTProvider.
+type TokenProvider interface {
+	// GenerateAccessToken creates a signed, short-lived access token for the profile's session
+	GenerateAccessToken(profileID uuid.UUID, sessionID uuid.UUID, roles []string) (string, time.Time, error)
+	// ParseAccessToken verifies an access token and returns its claims
+	ParseAccessToken(token string) (*AccessClaims, error)
+	// GenerateRefreshToken creates an opaque refresh token along with the hash to be stored
+	GenerateRefreshToken() (token string, tokenHash string, expiresAt time.Time, err error)
+	// HashRefreshToken returns the stored representation of a refresh token
+	HashRefreshToken(token string) string
+	// GenerateRestrictedToken creates a short-lived token without a session that is limited to the scope
+	GenerateRestrictedToken(profileID uuid.UUID, scope string) (string, time.Time, error)
+}
+
+// OTPProvider generates and validates time-based one-time passwords.
+// Implemented by infrastructure/auth.TOTPProvider.
+type OTPProvider interface {
+	// GenerateSecret creates a new shared secret
+	GenerateSecret() (string, error)
+	// ProvisioningURI returns the otpauth:// URI used to add the secret to an authenticator app
+	ProvisioningURI(secret, accountName string) string
+	// Validate checks a code and returns the time step it belongs to
+	Validate(secret, code string) (step int64, ok bool)
+}
+
+// SignedTokenProvider issues and verifies signed, expiring tokens bound to a profile.
+// Implemented by infrastructure/auth.SignedTokenProvider.
+type SignedTokenProvider interface {
+	// Sign creates a token for the profile that is valid until expiresAt
+	Sign(profileID uuid.UUID, expiresAt time.Time) (string, error)
+	// Verify checks the signature and expiry of a token and returns its profile ID
+	Verify(token string) (uuid.UUID, error)
+}
+
+// Mailer sends the account emails of the authentication flows.
+// Implemented by infrastructure/email.SMTPProvider.
+type Mailer interface {
+	SendVerificationEmail(to, token string) error
+	SendPasswordResetEmail(to, token string) error
+	// SendInvitationEmail sends the username and the link to set the first password of an account created on the owner's behalf
+	SendInvitationEmail(to, username, token string) error
+}
+
+// IdentityProvider runs the OpenID Connect authorization code flow with PKCE for one external provider.
+// Implemented by infrastructure/integration/google.AuthProvider.
+type IdentityProvider interface {
+	// AuthCodeURL returns the provider login URL, the PKCE challenge is derived from codeVerifier
+	AuthCodeURL(ctx context.Context, state, codeVerifier, nonce string) (string, error)
+	// Exchange redeems an authorization code and returns the identity from the verified ID token
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
+
+// PasswordHasher hashes and verifies passwords and recovery codes.
+// Implemented by utils.PasswordHasher, which also verifies legacy bcrypt hashes.
+type PasswordHasher interface {
+	// Hash hashes a secret with the current parameters
+	Hash(password string) (string, error)
+	// Verify checks a secret against a hash, needsRehash asks for the hash to be replaced by one from Hash
+	Verify(password, encodedHash string) (match bool, needsRehash bool, err error)
+}
+
+// APIKeyManager generates API keys and hashes them for storage.
+// Implemented by infrastructure/auth.APIKeyManager.
+type APIKeyManager interface {
+	// Generate creates a new key along with its public lookup prefix and the hash to store
+	Generate() (key string, prefix string, keyHash string, err error)
+	// Parse returns the lookup prefix of a presented key and whether the key is well formed
+	Parse(key string) (prefix string, ok bool)
+	// Verify checks a presented key against a stored hash
+	Verify(key, keyHash string) bool
+}
+
+// SecuritySettings holds the tunable parameters of the authentication flows
+type SecuritySettings struct {
+	MFAChallengeTTL      time.Duration
+	MFAMaxAttempts       int
+	MFARecoveryCodeCount int
+
+	// LockoutThreshold failed logins lock a profile for LockoutBaseDuration,
+	// doubling with every further lock up to LockoutMaxDuration
+	LockoutThreshold    int
+	LockoutBaseDuration time.Duration
+	LockoutMaxDuration  time.Duration
+
+	// IPFailureThreshold failed logins from one IP within IPFailureWindow block it for IPBlockDuration
+	IPFailureThreshold int
+	IPFailureWindow    time.Duration
+	IPBlockDuration    time.Duration
+
+	// RequireEmailVerification blocks logins of profiles that have not verified their email.
+	// At most VerificationResendLimit verification emails are sent per VerificationResendWindow.
+	RequireEmailVerification bool
+	EmailVerificationTTL     time.Duration
+	VerificationResendLimit  int
+	VerificationResendWindow time.Duration
+
+	// PasswordPolicy is enforced whenever a password is set.
+	// The last PasswordHistorySize passwords of a profile can't be reused.
+	PasswordPolicy      validator.PasswordValidationOptions
+	PasswordHistorySize int
+
+	// InvitationTTL is how long the link to set the first password of an imported profile stays valid
+	InvitationTTL time.Duration
+
+	// DeletedProfileRetention is how long soft deleted profiles can be restored before they are purged
+	DeletedProfileRetention time.Duration
+
+	// OAuthStateTTL is how long a user has to complete a login with an external provider
+	OAuthStateTTL time.Duration
+
+	// APIKeyMaxLifetime caps the expiry of API keys. A rotated key keeps working for the
+	// requested overlap, APIKeyDefaultRotationOverlap if none, up to APIKeyMaxRotationOverlap.
+	APIKeyMaxLifetime            time.Duration
+	APIKeyDefaultRotationOverlap time.Duration
+	APIKeyMaxRotationOverlap     time.Duration
 }
 
 // The "service" struct is the concrete implementation of the "Service" interface.
@@ -74,22 +238,43 @@ type service struct {
 	repo        Repository
 	roleService role.Service
 
-	// tokenGenerator utils.TokenGenerator
-	logger logger.Logger
+	tokenProvider      TokenProvider
+	otpProvider        OTPProvider
+	verificationTokens SignedTokenProvider
+	mailer             Mailer
+	passwordHasher     PasswordHasher
+	apiKeys            APIKeyManager
+	identityProviders  map[string]IdentityProvider
+	settings           SecuritySettings
+	logger             logger.Logger
 }
 
 // NewService creates a new platform profile service
 func NewService(
 	repo Repository,
 	roleService role.Service,
-	// tokenGen utils.TokenGenerator,
+	tokenProvider TokenProvider,
+	otpProvider OTPProvider,
+	verificationTokens SignedTokenProvider,
+	mailer Mailer,
+	passwordHasher PasswordHasher,
+	apiKeys APIKeyManager,
+	identityProviders map[string]IdentityProvider,
+	settings SecuritySettings,
 	logger logger.Logger,
 ) Service {
 	return &service{
-		repo:        repo,
-		roleService: roleService,
-		// tokenGenerator: tokenGen,
-		logger: logger,
+		repo:               repo,
+		roleService:        roleService,
+		tokenProvider:      tokenProvider,
+		otpProvider:        otpProvider,
+		verificationTokens: verificationTokens,
+		mailer:             mailer,
+		passwordHasher:     passwordHasher,
+		apiKeys:            apiKeys,
+		identityProviders:  identityProviders,
+		settings:           settings,
+		logger:             logger,
 	}
 }
 
@@ -119,8 +304,13 @@ func (s *service) RegisterProfile(ctx context.Context, req CreateProfileRequest)
 		return nil, errors.NewConflictError("email", map[string]interface{}{"email": req.Email})
 	}
 
+	// Enforce the password policy, there is no history yet
+	if err := s.validateNewPassword(ctx, nil, req.Password, "password"); err != nil {
+		return nil, err
+	}
+
 	// Hash password securely
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	passwordHash, err := s.passwordHasher.Hash(req.Password)
 	if err != nil {
 		s.logger.Error("Failed to hash password", "username", req.Username, "error", err)
 		return nil, errors.NewBusinessError("PASSWORD_HASHING_FAILED", "password hashing failed", nil)
@@ -131,7 +321,7 @@ func (s *service) RegisterProfile(ctx context.Context, req CreateProfileRequest)
 		ID:                  uuid.New(),
 		Username:            req.Username,
 		Email:               req.Email,
-		PasswordHash:        string(passwordHash),
+		PasswordHash:        passwordHash,
 		Status:              StatusPending, // Default to 'pending' for new profiles
 		FailedLoginAttempts: 0,
 		CreatedAt:           now,
@@ -145,15 +335,10 @@ func (s *service) RegisterProfile(ctx context.Context, req CreateProfileRequest)
 		return nil, errors.NewBusinessError("PROFILE_CREATION_FAILED", "failed to create profile", nil)
 	}
 
+	s.recordPasswordHistory(ctx, profile.ID, profile.PasswordHash)
+
 	// Create default preferences (not a blocker if it fails)
-	prefs := &ProfilePreference{
-		ID:                 uuid.New(),
-		ProfileID:          profile.ID,
-		NotificationsEmail: true,
-		CreatedAt:          now,
-		UpdatedAt:          now,
-	}
-	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+	if err := s.repo.SavePreferences(ctx, defaultPreferences(profile.ID, now)); err != nil {
 		s.logger.Warn("Failed to create default preferences", "profile_id", profile.ID, "error", err)
 	}
 
@@ -175,6 +360,12 @@ func (s *service) RegisterProfile(ctx context.Context, req CreateProfileRequest)
 
 	s.logger.Info("Profile registered successfully", "username", req.Username, "profile_id", profile.ID)
 
+	// The profile stays pending until the email address is verified. A failed send is not a blocker,
+	// a new verification email can be requested.
+	if err := s.sendVerificationEmail(ctx, profile); err != nil {
+		s.logger.Warn("Failed to send verification email", "profile_id", profile.ID, "error", err)
+	}
+
 	// Remove password hash before returning response
 	profile.PasswordHash = ""
 	return profile, nil
@@ -269,7 +460,8 @@ func (s *service) GetProfile(ctx context.Context, req GetProfileRequest) (*Platf
 	return profile, nil
 }
 
-// UpdateProfile updates a profile's information
+// UpdateProfile updates a profile's information. A new email address is unverified until the
+// profile confirms it through the verification email sent to it.
 func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdateProfileRequest) (*PlatformProfile, error) {
 
 	s.logger.Info(
@@ -327,6 +519,7 @@ func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdatePro
 		}
 	}
 
+	emailChanged := false
 	if req.Email != nil {
 		// Check if new email is available
 		if *req.Email != profile.Email {
@@ -355,6 +548,9 @@ func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdatePro
 
 			}
 			profile.Email = *req.Email
+			// The new address has to be verified again, links sent to the old one must not verify it
+			profile.VerifiedAt = nil
+			emailChanged = true
 			s.logger.Debug(
 				"Email updated",
 				"profileID", id,
@@ -380,48 +576,159 @@ func (s *service) UpdateProfile(ctx context.Context, id uuid.UUID, req UpdatePro
 
 	s.logger.Info("Profile updated successfully", "profileID", id)
 
+	if emailChanged {
+		if err := s.repo.DeleteEmailVerificationTokens(ctx, id); err != nil {
+			s.logger.Warn("Failed to delete email verification tokens", "profile_id", id, "error", err)
+		}
+		// A failed send is not a blocker, a new verification email can be requested
+		if err := s.sendVerificationEmail(ctx, profile); err != nil {
+			s.logger.Warn("Failed to send verification email", "profile_id", id, "error", err)
+		}
+	}
+
 	// Don't expose password hash
 	profile.PasswordHash = ""
 	return profile, nil
 }
 
-// DeleteProfile soft-deletes a profile
-func (s *service) SoftDeleteProfile(ctx context.Context, id uuid.UUID) error {
-	s.logger.Debug("Attempting to soft-delete profile", "id", id)
+// SoftDeleteProfile moves a profile to the recovery bin along with its student records.
+// It can be restored until the retention period has passed.
+func (s *service) SoftDeleteProfile(ctx context.Context, actorID uuid.UUID, id uuid.UUID, req SoftDeleteRequest) error {
+	s.logger.Debug("Attempting to soft-delete profile", "id", id, "actor_id", actorID)
+
+	if id == actorID {
+		return errors.NewBusinessError("CANNOT_DELETE_OWN_PROFILE", "administrators can't delete their own profile", nil)
+	}
+
+	if err := s.ensureInScope(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := s.repo.GetProfileByID(ctx, id); err != nil {
+		s.logger.Warn("Profile for soft delete not found", "id", id, "error", err)
+		return errors.NewNotFoundError("profile", id)
+	}
 
-	err := s.repo.SoftDeleteProfile(ctx, id)
+	err := s.repo.SoftDeleteProfile(ctx, id, actorID, strings.TrimSpace(req.Reason))
 	if err != nil {
 		s.logger.Error("Failed to soft-delete profile", "id", id, "error", err)
 		return errors.NewDatabaseError("soft delete profile", err)
 	}
 
-	s.logger.Info("Successfully soft-deleted profile", "id", id)
+	s.logger.Info("Successfully soft-deleted profile", "id", id, "actor_id", actorID)
 	return nil
 }
 
-// VerifyProfile verifies the profile and updates the status to activated
-func (s *service) VerifyProfile(ctx context.Context, id uuid.UUID) error {
-	s.logger.Debug("Starting profile verification", "profile_id", id)
+// VerifyEmail verifies the email address of a profile using the token from a verification email.
+// Verifying activates a pending profile.
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	s.logger.Debug("Starting email verification")
 
-	// Mark the profile as verified
-	err := s.repo.VerifyProfile(ctx, id)
+	profileID, err := s.verificationTokens.Verify(token)
+	if err != nil {
+		s.logger.Warn("Invalid email verification token presented", "error", err)
+		return errors.NewBusinessError("INVALID_VERIFICATION_TOKEN", "invalid or expired verification link", nil)
+	}
+
+	// The signature proves the token was issued, the stored record makes it single use
+	record, err := s.repo.GetEmailVerificationTokenByHash(ctx, hashOpaqueToken(token))
+	if err != nil || record.ProfileID != profileID || record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		s.logger.Warn("Email verification token not usable", "profile_id", profileID, "error", err)
+		return errors.NewBusinessError("INVALID_VERIFICATION_TOKEN", "invalid or expired verification link", nil)
+	}
+
+	consumed, err := s.repo.ConsumeEmailVerificationToken(ctx, record.ID)
 	if err != nil {
+		s.logger.Error("Failed to consume email verification token", "profile_id", profileID, "error", err)
+		return errors.NewDatabaseError("consuming verification token", err)
+	}
+	if !consumed {
+		s.logger.Warn("Email verification token already used", "profile_id", profileID)
+		return errors.NewBusinessError("INVALID_VERIFICATION_TOKEN", "invalid or expired verification link", nil)
+	}
+
+	// Mark the profile as verified
+	if err := s.repo.VerifyProfile(ctx, profileID); err != nil {
 		if errors.IsNotFoundErrorDomain(err) {
-			s.logger.Warn("Profile not found for verification", "profile_id", id)
-			return errors.NewNotFoundError("profile", map[string]interface{}{"id": id})
+			s.logger.Warn("Profile not found for verification", "profile_id", profileID)
+			return errors.NewNotFoundError("profile", map[string]interface{}{"id": profileID})
 		}
-		s.logger.Error("Failed to verify profile", "profile_id", id, "error", err)
+		s.logger.Error("Failed to verify profile", "profile_id", profileID, "error", err)
 		return errors.NewDatabaseError("profile verification", err)
 	}
 
-	// Update profile status to 'activated'
-	err = s.repo.UpdateStatus(ctx, id, StatusActivated)
+	// Older links are no longer needed
+	if err := s.repo.DeleteEmailVerificationTokens(ctx, profileID); err != nil {
+		s.logger.Warn("Failed to delete email verification tokens", "profile_id", profileID, "error", err)
+	}
+
+	s.logger.Info("Profile email verified successfully", "profile_id", profileID)
+	return nil
+}
+
+// ResendVerificationEmail sends a new verification email. Unknown and already verified addresses
+// are ignored so the response does not reveal which emails are registered.
+func (s *service) ResendVerificationEmail(ctx context.Context, req ResendVerificationRequest) error {
+	profile, err := s.repo.GetProfileByEmail(ctx, req.Email)
+	if err != nil {
+		s.logger.Info("Verification email requested for unknown email", "email", req.Email)
+		return nil
+	}
+
+	if profile.VerifiedAt != nil {
+		s.logger.Info("Verification email requested for verified profile", "profile_id", profile.ID)
+		return nil
+	}
+
+	sent, err := s.repo.CountEmailVerificationTokensSince(ctx, profile.ID, time.Now().Add(-s.settings.VerificationResendWindow))
 	if err != nil {
-		s.logger.Error("Failed to update profile status after verification", "profile_id", id, "error", err)
-		return errors.NewDatabaseError("status update", err)
+		s.logger.Error("Failed to count verification emails", "profile_id", profile.ID, "error", err)
+		return errors.NewDatabaseError("counting verification emails", err)
 	}
 
-	s.logger.Info("Profile verified and activated successfully", "profile_id", id)
+	if sent >= s.settings.VerificationResendLimit {
+		s.logger.Warn("Verification email rate limit reached", "profile_id", profile.ID, "sent", sent)
+		return errors.NewBusinessError(
+			"VERIFICATION_RATE_LIMITED",
+			"too many verification emails requested, please try again later",
+			map[string]any{"retry_after_seconds": int(s.settings.VerificationResendWindow.Seconds())},
+		)
+	}
+
+	if err := s.sendVerificationEmail(ctx, profile); err != nil {
+		s.logger.Error("Failed to send verification email", "profile_id", profile.ID, "error", err)
+		return errors.NewBusinessError("EMAIL_SEND_FAILED", "failed to send verification email", nil)
+	}
+
+	return nil
+}
+
+// sendVerificationEmail issues a new verification token for the profile and mails it
+func (s *service) sendVerificationEmail(ctx context.Context, profile *PlatformProfile) error {
+	now := time.Now()
+	expiresAt := now.Add(s.settings.EmailVerificationTTL)
+
+	token, err := s.verificationTokens.Sign(profile.ID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	record := &EmailVerificationToken{
+		ID:        uuid.New(),
+		ProfileID: profile.ID,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateEmailVerificationToken(ctx, record); err != nil {
+		return err
+	}
+
+	if err := s.mailer.SendVerificationEmail(profile.Email, token); err != nil {
+		return err
+	}
+
+	s.logger.Info("Verification email sent", "profile_id", profile.ID)
 	return nil
 }
 
@@ -479,13 +786,19 @@ func (s *service) DeactivateProfile(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// AuthenticateProfile verifies login credentials and returns the profile if valid
-func (s *service) AuthenticateProfile(ctx context.Context, req LoginRequest) (*PlatformProfile, error) {
+// AuthenticateProfile verifies login credentials and returns the profile along with a new token pair
+func (s *service) AuthenticateProfile(ctx context.Context, req LoginRequest) (*AuthResponse, error) {
 	var profile *PlatformProfile
 	var err error
 
 	s.logger.Debug("Authenticating profile", "username", req.Username, "email", req.Email)
 
+	// Clients that failed too often across accounts are turned away before any account is touched,
+	// so a single attacker cannot lock out many profiles
+	if err := s.checkIPBlocked(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+
 	// Lookup by username or email
 	switch {
 	case req.Username != "":
@@ -500,6 +813,7 @@ func (s *service) AuthenticateProfile(ctx context.Context, req LoginRequest) (*P
 	// Handle profile lookup errors
 	if err != nil {
 		if errors.IsNotFoundErrorDomain(err) {
+			s.recordIPFailure(ctx, req.IPAddress)
 			s.logger.Warn("Invalid credentials provided", "username", req.Username, "email", req.Email)
 			return nil, errors.NewUnauthorizedError("invalid credentials")
 		}
@@ -513,52 +827,353 @@ func (s *service) AuthenticateProfile(ctx context.Context, req LoginRequest) (*P
 		return nil, errors.NewUnauthorizedError("account is not active")
 	}
 
+	// Locked profiles are unlocked on the first attempt after the lock expired
+	wasLocked := profile.Status == StatusLocked
+	if wasLocked {
+		profile, err = s.unlockIfExpired(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(profile.PasswordHash), []byte(req.Password))
+	match, needsRehash, err := s.passwordHasher.Verify(req.Password, profile.PasswordHash)
 	if err != nil {
-		// Increment failed login attempts
-		if errInc := s.repo.IncrementFailedLoginAttempts(ctx, profile.ID); errInc != nil {
-			s.logger.Error("Failed to increment failed login attempts", "error", errInc)
-		}
+		s.logger.Error("Failed to verify password hash", "profile_id", profile.ID, "error", err)
+	}
+	if !match {
+		s.recordIPFailure(ctx, req.IPAddress)
+		s.logger.Warn("Invalid credentials provided", "profile_id", profile.ID)
+		return nil, s.recordFailedLogin(ctx, profile, errors.NewUnauthorizedError("invalid credentials"))
+	}
 
-		// Check if account should be suspended due to too many failed attempts
-		if profile.FailedLoginAttempts+1 >= 5 {
-			if errSusp := s.repo.UpdateStatus(ctx, profile.ID, StatusSuspended); errSusp != nil {
-				s.logger.Error("Failed to suspend profile after max failed attempts", "error", errSusp)
-			}
-			s.logger.Warn("Account suspended due to excessive failed login attempts", "profile_id", profile.ID)
-			return nil, errors.NewUnauthorizedError("account suspended due to too many failed login attempts")
-		}
+	// Legacy bcrypt hashes and outdated Argon2 parameters are upgraded while the password is at hand
+	if needsRehash {
+		s.rehashPassword(ctx, profile.ID, req.Password)
+	}
 
-		s.logger.Warn("Invalid credentials provided", "profile_id", profile.ID)
-		return nil, errors.NewUnauthorizedError("invalid credentials")
+	// Checked after the password so the response does not reveal whether an unverified account exists
+	if s.settings.RequireEmailVerification && profile.VerifiedAt == nil {
+		s.logger.Warn("Login attempt before email verification", "profile_id", profile.ID)
+		return nil, errors.NewDomainError("email address is not verified", errors.ForbiddenError, "EMAIL_NOT_VERIFIED", nil, nil)
+	}
+
+	// Profiles with MFA enabled have to present a second factor before tokens are issued
+	mfaEnabled, err := s.repo.IsMFAEnabled(ctx, profile.ID)
+	if err != nil {
+		s.logger.Error("Failed to check MFA status", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("checking MFA status", err)
+	}
+	// Failed attempts are kept until the second factor was presented too, so that wrong codes
+	// count against the same lockout as wrong passwords
+	if mfaEnabled {
+		return s.createMFAChallenge(ctx, profile, req.UserAgent, req.IPAddress)
+	}
+
+	s.clearFailedLogins(ctx, profile, wasLocked)
+	return s.completeLogin(ctx, profile, req.UserAgent, req.IPAddress)
+}
+
+// recordFailedLogin counts a wrong password or second factor against a profile and locks the
+// profile once too many attempts have failed. It returns the error to answer the attempt with.
+func (s *service) recordFailedLogin(ctx context.Context, profile *PlatformProfile, failure error) error {
+	attempts, err := s.repo.IncrementFailedLoginAttempts(ctx, profile.ID)
+	if err != nil {
+		s.logger.Error("Failed to increment failed login attempts", "error", err)
+		attempts = profile.FailedLoginAttempts + 1
 	}
 
-	// Reset failed attempts on successful login
+	// Lock the account once too many failed attempts have been made
+	if attempts >= s.settings.LockoutThreshold {
+		lockedUntil, err := s.lockAccount(ctx, profile.ID)
+		if err != nil {
+			s.logger.Error("Failed to lock profile after max failed attempts", "error", err)
+			return failure
+		}
+		return accountLockedError(&lockedUntil)
+	}
+
+	return failure
+}
+
+// clearFailedLogins resets the failed attempts and the lock backoff of a profile that logged in
+func (s *service) clearFailedLogins(ctx context.Context, profile *PlatformProfile, wasLocked bool) {
 	if profile.FailedLoginAttempts > 0 {
 		if err := s.repo.ResetFailedLoginAttempts(ctx, profile.ID); err != nil {
 			s.logger.Warn("Failed to reset failed attempts", "profile_id", profile.ID, "error", err)
 		}
 	}
+	if profile.FailedLoginAttempts > 0 || wasLocked {
+		if err := s.repo.ClearAccountLockout(ctx, profile.ID); err != nil {
+			s.logger.Warn("Failed to clear account lockout", "profile_id", profile.ID, "error", err)
+		}
+	}
+}
 
+// completeLogin records a successful login, starts a new session and issues its tokens
+func (s *service) completeLogin(ctx context.Context, profile *PlatformProfile, userAgent, ipAddress string) (*AuthResponse, error) {
 	// Update last login timestamp
 	if err := s.repo.RecordLogin(ctx, profile.ID); err != nil {
 		s.logger.Warn("Failed to record login", "profile_id", profile.ID, "error", err)
 	}
 
-	// Activate account if pending on first login
-	if profile.Status == StatusPending {
-		if err := s.repo.UpdateStatus(ctx, profile.ID, StatusActivated); err != nil {
-			s.logger.Warn("Failed to activate pending account", "profile_id", profile.ID, "error", err)
-		}
-		profile.Status = StatusActivated
-		s.logger.Info("Pending account activated upon first login", "profile_id", profile.ID)
+	// A forced password change has to happen before the profile gets a session
+	if profile.MustChangePassword {
+		return s.issuePasswordChangeToken(profile)
+	}
+
+	// Every login starts a new session, whose ID doubles as the refresh token family
+	sessionID := uuid.New()
+	tokens, refreshToken, err := s.newTokens(ctx, profile.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         sessionID,
+		ProfileID:  profile.ID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		DeviceInfo: describeDevice(userAgent),
+		ExpiresAt:  tokens.RefreshTokenExpiresAt,
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+	if err := s.repo.CreateSession(ctx, session, refreshToken); err != nil {
+		s.logger.Error("Failed to create session", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("creating session", err)
 	}
 
 	// Remove password hash before returning profile
 	profile.PasswordHash = ""
 	s.logger.Info("Profile authenticated successfully", "profile_id", profile.ID)
-	return profile, nil
+	return &AuthResponse{Profile: profile, Tokens: tokens}, nil
+}
+
+// RefreshTokens rotates a refresh token and returns a new token pair.
+// Presenting a refresh token that was already rotated is treated as theft and
+// revokes every token in its family.
+func (s *service) RefreshTokens(ctx context.Context, req RefreshTokenRequest) (*TokenPair, error) {
+	tokenHash := s.tokenProvider.HashRefreshToken(req.RefreshToken)
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		s.logger.Warn("Refresh token lookup failed", "error", err)
+		return nil, errors.NewUnauthorizedError("invalid refresh token")
+	}
+
+	if stored.RevokedAt != nil {
+		s.logger.Warn("Revoked refresh token presented", "profile_id", stored.ProfileID, "family_id", stored.FamilyID)
+		return nil, errors.NewUnauthorizedError("refresh token has been revoked")
+	}
+
+	if stored.UsedAt != nil {
+		s.revokeTokenFamilyOnReuse(ctx, stored)
+		return nil, errors.NewUnauthorizedError("refresh token has already been used")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		s.logger.Debug("Expired refresh token presented", "profile_id", stored.ProfileID)
+		return nil, errors.NewUnauthorizedError("refresh token has expired")
+	}
+
+	// Mark the token as used; losing this race means another request rotated it first
+	consumed, err := s.repo.ConsumeRefreshToken(ctx, stored.ID)
+	if err != nil {
+		s.logger.Error("Failed to consume refresh token", "profile_id", stored.ProfileID, "error", err)
+		return nil, errors.NewDatabaseError("consuming refresh token", err)
+	}
+	if !consumed {
+		s.revokeTokenFamilyOnReuse(ctx, stored)
+		return nil, errors.NewUnauthorizedError("refresh token has already been used")
+	}
+
+	// The session may have been revoked from another device
+	session, err := s.repo.GetSession(ctx, stored.FamilyID)
+	if err != nil || session.RevokedAt != nil {
+		if errRevoke := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); errRevoke != nil {
+			s.logger.Error("Failed to revoke tokens of ended session", "family_id", stored.FamilyID, "error", errRevoke)
+		}
+		s.logger.Warn("Refresh attempted for ended session", "profile_id", stored.ProfileID, "session_id", stored.FamilyID)
+		return nil, errors.NewUnauthorizedError("session has ended")
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, stored.ProfileID)
+	if err != nil {
+		s.logger.Warn("Profile for refresh token not found", "profile_id", stored.ProfileID, "error", err)
+		return nil, errors.NewUnauthorizedError("invalid refresh token")
+	}
+
+	if profile.Status == StatusDeactivated || profile.Status == StatusSuspended {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			s.logger.Error("Failed to revoke tokens of inactive profile", "profile_id", profile.ID, "error", err)
+		}
+		s.logger.Warn("Refresh attempted for inactive account", "profile_id", profile.ID, "status", profile.Status)
+		return nil, errors.NewUnauthorizedError("account is not active")
+	}
+
+	tokens, err := s.issueTokens(ctx, profile.ID, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the session alive for as long as its newest refresh token
+	if err := s.repo.TouchSession(ctx, session.ID, tokens.RefreshTokenExpiresAt); err != nil {
+		s.logger.Warn("Failed to touch session", "session_id", session.ID, "error", err)
+	}
+
+	s.logger.Info("Refresh token rotated", "profile_id", profile.ID, "family_id", stored.FamilyID)
+	return tokens, nil
+}
+
+// Logout ends the session the given refresh token belongs to
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	tokenHash := s.tokenProvider.HashRefreshToken(refreshToken)
+
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		s.logger.Warn("Logout with unknown refresh token", "error", err)
+		return errors.NewUnauthorizedError("invalid refresh token")
+	}
+
+	// Revoking the session also revokes its refresh tokens. Fall back to the token family
+	// when the session has already ended.
+	session, err := s.repo.GetSession(ctx, stored.FamilyID)
+	if err == nil && session.RevokedAt == nil {
+		err = s.repo.RevokeSession(ctx, stored.ProfileID, session.ID)
+	} else {
+		err = s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+	}
+	if err != nil {
+		s.logger.Error("Failed to revoke session on logout", "profile_id", stored.ProfileID, "error", err)
+		return errors.NewDatabaseError("revoking session", err)
+	}
+
+	s.logger.Info("Profile logged out", "profile_id", stored.ProfileID, "family_id", stored.FamilyID)
+	return nil
+}
+
+// ValidateAccessToken verifies an access token and checks that its session has not been revoked,
+// so signing a device out takes effect before its access token expires
+func (s *service) ValidateAccessToken(ctx context.Context, accessToken string) (*AccessClaims, error) {
+	claims, err := s.tokenProvider.ParseAccessToken(accessToken)
+	if err != nil {
+		s.logger.Debug("Invalid access token presented", "error", err)
+		return nil, errors.NewUnauthorizedError("invalid or expired access token")
+	}
+
+	// Restricted tokens have no session, they are only valid while their reason persists
+	if claims.Scope != "" {
+		return s.validateRestrictedToken(ctx, claims)
+	}
+
+	session, err := s.repo.GetSession(ctx, claims.SessionID)
+	if err != nil {
+		s.logger.Warn("Session of access token not found", "session_id", claims.SessionID, "error", err)
+		return nil, errors.NewUnauthorizedError("session has ended")
+	}
+
+	if session.ProfileID != claims.ProfileID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		s.logger.Debug("Access token presented for ended session", "profile_id", claims.ProfileID, "session_id", claims.SessionID)
+		return nil, errors.NewUnauthorizedError("session has ended")
+	}
+
+	return claims, nil
+}
+
+// issuePasswordChangeToken answers a login of a profile that must change its password
+// with a token that is only accepted for changing the password
+func (s *service) issuePasswordChangeToken(profile *PlatformProfile) (*AuthResponse, error) {
+	token, _, err := s.tokenProvider.GenerateRestrictedToken(profile.ID, ScopePasswordChange)
+	if err != nil {
+		s.logger.Error("Failed to generate password change token", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewBusinessError("TOKEN_GENERATION_FAILED", "failed to generate access token", nil)
+	}
+
+	s.logger.Info("Password verified, password change required", "profile_id", profile.ID)
+	return &AuthResponse{PasswordChangeRequired: true, PasswordChangeToken: token}, nil
+}
+
+// validateRestrictedToken checks that the reason a restricted token was issued for still applies
+func (s *service) validateRestrictedToken(ctx context.Context, claims *AccessClaims) (*AccessClaims, error) {
+	if claims.Scope != ScopePasswordChange {
+		s.logger.Warn("Access token with unknown scope presented", "profile_id", claims.ProfileID, "scope", claims.Scope)
+		return nil, errors.NewUnauthorizedError("invalid or expired access token")
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, claims.ProfileID)
+	if err != nil || !profile.MustChangePassword {
+		s.logger.Debug("Password change token presented after the password was changed", "profile_id", claims.ProfileID)
+		return nil, errors.NewUnauthorizedError("invalid or expired access token")
+	}
+
+	return claims, nil
+}
+
+// issueTokens creates an access token and a refresh token belonging to the given family
+func (s *service) issueTokens(ctx context.Context, profileID uuid.UUID, familyID uuid.UUID) (*TokenPair, error) {
+	tokens, stored, err := s.newTokens(ctx, profileID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		s.logger.Error("Failed to store refresh token", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("storing refresh token", err)
+	}
+
+	return tokens, nil
+}
+
+// newTokens generates a token pair of the given family along with the refresh token to store for it
+func (s *service) newTokens(ctx context.Context, profileID uuid.UUID, familyID uuid.UUID) (*TokenPair, *RefreshToken, error) {
+	roles, err := s.repo.GetProfileRoleNames(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to load roles for token", "profile_id", profileID, "error", err)
+		return nil, nil, errors.NewDatabaseError("fetching profile roles", err)
+	}
+
+	accessToken, accessExpiresAt, err := s.tokenProvider.GenerateAccessToken(profileID, familyID, roles)
+	if err != nil {
+		s.logger.Error("Failed to generate access token", "profile_id", profileID, "error", err)
+		return nil, nil, errors.NewBusinessError("TOKEN_GENERATION_FAILED", "failed to generate access token", nil)
+	}
+
+	refreshToken, refreshHash, refreshExpiresAt, err := s.tokenProvider.GenerateRefreshToken()
+	if err != nil {
+		s.logger.Error("Failed to generate refresh token", "profile_id", profileID, "error", err)
+		return nil, nil, errors.NewBusinessError("TOKEN_GENERATION_FAILED", "failed to generate refresh token", nil)
+	}
+
+	stored := &RefreshToken{
+		ID:        uuid.New(),
+		ProfileID: profileID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		ExpiresAt: refreshExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+		TokenType:             "Bearer",
+	}, stored, nil
+}
+
+// revokeTokenFamilyOnReuse revokes every token issued from the same login as a reused token
+func (s *service) revokeTokenFamilyOnReuse(ctx context.Context, stored *RefreshToken) {
+	s.logger.Warn(
+		"Refresh token reuse detected, revoking token family",
+		"profile_id", stored.ProfileID,
+		"family_id", stored.FamilyID,
+	)
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		s.logger.Error("Failed to revoke refresh token family", "family_id", stored.FamilyID, "error", err)
+	}
 }
 
 // ChangePassword changes a user's password when previous password is provided/known
@@ -569,8 +1184,8 @@ func (s *service) ChangePassword(ctx context.Context, id uuid.UUID, req Password
 	}
 
 	// Verify current password
-	err = bcrypt.CompareHashAndPassword([]byte(profile.PasswordHash), []byte(req.CurrentPassword))
-	if err != nil {
+	match, _, err := s.passwordHasher.Verify(req.CurrentPassword, profile.PasswordHash)
+	if err != nil || !match {
 		return errors.NewUnauthorizedError("current password is incorrect")
 	}
 
@@ -582,20 +1197,33 @@ func (s *service) ChangePassword(ctx context.Context, id uuid.UUID, req Password
 		)
 	}
 
+	if err := s.validateNewPassword(ctx, profile, req.NewPassword, "new_password"); err != nil {
+		return err
+	}
+
 	// Hash new password
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	passwordHash, err := s.passwordHasher.Hash(req.NewPassword)
 	if err != nil {
 		s.logger.Error("failed to hash password", "error", err)
 		return errors.NewBusinessError("PASSWORD_HASHING_FAILED", "failed to update password", nil)
 	}
 
 	// Update password in repository
-	err = s.repo.UpdatePassword(ctx, id, string(passwordHash))
+	err = s.repo.UpdatePassword(ctx, id, passwordHash)
 	if err != nil {
 		s.logger.Error("failed to update password in database", "error", err)
 		return errors.NewDatabaseError("updating password", err)
 	}
 
+	s.recordPasswordHistory(ctx, id, passwordHash)
+
+	// Sign out every device that knew the old password
+	if err := s.repo.RevokeAllSessions(ctx, id); err != nil {
+		s.logger.Error("failed to revoke sessions after password change", "profileID", id, "error", err)
+		return errors.NewDatabaseError("revoking sessions", err)
+	}
+
+	s.logger.Info("password changed and sessions revoked", "profileID", id)
 	return nil
 }
 
@@ -609,27 +1237,9 @@ func (s *service) RequestPasswordReset(ctx context.Context, req PasswordResetReq
 		return nil
 	}
 
-	// Generate reset token
-	token, err := s.tokenGenerator.GenerateToken()
+	token, err := s.issuePasswordResetToken(ctx, profile.ID, time.Hour)
 	if err != nil {
-		s.logger.Error("failed to generate reset token", "error", err)
-		return errors.NewBusinessError("RESET_TOKEN_GENERATION_FAILED", "failed to initiate password reset", nil)
-	}
-
-	// Store reset token with expiration (1 hours)
-	expires := time.Now().Add(1 * time.Hour)
-
-	passwordResetToken := PasswordResetToken{
-		ProfileID: profile.ID,
-		Token:     token,
-		ExpiresAt: expires,
-		IsUsed:    false,
-		CreatedAt: time.Now(),
-	}
-
-	if err := s.repo.CreatePasswordResetToken(ctx, &passwordResetToken); err != nil {
-		s.logger.Error("failed to save reset token", "error", err)
-		return errors.NewBusinessError("RESET_TOKEN_SAVE_FAILED", "failed to initiate password reset", nil)
+		return err
 	}
 
 	// Send reset link via email
@@ -644,60 +1254,1698 @@ func (s *service) RequestPasswordReset(ctx context.Context, req PasswordResetReq
 	return nil
 }
 
-// ConfirmPasswordReset validates the reset token and updates the password
-func (s *service) ConfirmPasswordReset(ctx context.Context, req PasswordResetConfirmation) error {
-	// Validate token
-	profile, err := s.repo.GetPasswordResetToken(ctx, req.Token)
+// SendInvitation mails the username and a link to set the first password to a profile created on
+// the owner's behalf, such as an imported student. Setting the password also verifies the email,
+// since the link was delivered to it. A new invitation replaces earlier ones.
+func (s *service) SendInvitation(ctx context.Context, profileID uuid.UUID) error {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return err
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, profileID)
 	if err != nil {
-		return errors.NewDatabaseError("fetching reset token", err)
+		if errors.IsNotFoundErrorDomain(err) {
+			return errors.NewNotFoundError("profile", map[string]interface{}{"id": profileID})
+		}
+		s.logger.Error("Failed to load profile for invitation", "profile_id", profileID, "error", err)
+		return errors.NewDatabaseError("fetching profile", err)
 	}
 
-	// Hash new password
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	token, err := s.issuePasswordResetToken(ctx, profile.ID, s.settings.InvitationTTL)
 	if err != nil {
-		s.logger.Error("failed to hash password", "error", err)
-		return errors.NewBusinessError(
+		return err
+	}
+
+	if err := s.mailer.SendInvitationEmail(profile.Email, profile.Username, token); err != nil {
+		s.logger.Error("Failed to send invitation email", "profile_id", profile.ID, "error", err)
+		return errors.NewBusinessError("EMAIL_SEND_FAILED", "failed to send invitation email", nil)
+	}
+
+	s.logger.Info("Invitation email sent", "profile_id", profile.ID)
+	return nil
+}
+
+// issuePasswordResetToken expires the earlier reset links of a profile and stores a new one
+func (s *service) issuePasswordResetToken(ctx context.Context, profileID uuid.UUID, ttl time.Duration) (string, error) {
+	// Only the newest link stays usable
+	if err := s.ExpireOldResetTokens(ctx, profileID); err != nil {
+		s.logger.Warn("failed to expire old reset tokens", "profileID", profileID, "error", err)
+	}
+
+	token, err := utils.GenerateToken(resetTokenLength)
+	if err != nil {
+		s.logger.Error("failed to generate reset token", "error", err)
+		return "", errors.NewBusinessError("RESET_TOKEN_GENERATION_FAILED", "failed to initiate password reset", nil)
+	}
+
+	now := time.Now()
+	passwordResetToken := PasswordResetToken{
+		ProfileID: profileID,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: now.Add(ttl),
+		IsUsed:    false,
+		CreatedAt: now,
+	}
+
+	if err := s.repo.CreatePasswordResetToken(ctx, &passwordResetToken); err != nil {
+		s.logger.Error("failed to save reset token", "error", err)
+		return "", errors.NewBusinessError("RESET_TOKEN_SAVE_FAILED", "failed to initiate password reset", nil)
+	}
+
+	return token, nil
+}
+
+// ConfirmPasswordReset validates the reset token and updates the password
+func (s *service) ConfirmPasswordReset(ctx context.Context, req PasswordResetConfirmation) error {
+	// Validate token
+	profile, err := s.ValidatePasswordResetToken(ctx, req.Token)
+	if err != nil {
+		return err
+	}
+
+	// Check the policy before the token is spent, so a rejected password can be retried with the same link
+	target, err := s.repo.GetProfileByID(ctx, profile.ProfileID)
+	if err != nil {
+		s.logger.Error("failed to load profile for password reset", "profileID", profile.ProfileID, "error", err)
+		return errors.NewDatabaseError("fetching profile", err)
+	}
+	if err := s.validateNewPassword(ctx, target, req.NewPassword, "new_password"); err != nil {
+		return err
+	}
+
+	// Mark token as used before the password changes, so a token can't be used twice concurrently
+	if err := s.repo.MarkPasswordResetTokenUsed(ctx, hashOpaqueToken(req.Token)); err != nil {
+		s.logger.Warn("reset token used concurrently", "profileID", profile.ProfileID, "error", err)
+		return errors.NewBusinessError("INVALID_RESET_TOKEN", "invalid or expired reset token", nil)
+	}
+
+	// Hash new password
+	passwordHash, err := s.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		s.logger.Error("failed to hash password", "error", err)
+		return errors.NewBusinessError(
 			"PASSWORD_HASH_FAILED",
 			"failed to update password",
 			nil,
 		)
 	}
 
-	// Update password
-	if err := s.repo.UpdatePassword(ctx, profile.ProfileID, string(passwordHash)); err != nil {
-		return errors.NewBusinessError(
-			"PASSWORD_UPDATE_FAILED",
-			"failed to update password",
-			nil,
-		)
+	// Update password
+	if err := s.repo.UpdatePassword(ctx, profile.ProfileID, passwordHash); err != nil {
+		return errors.NewBusinessError(
+			"PASSWORD_UPDATE_FAILED",
+			"failed to update password",
+			nil,
+		)
+	}
+
+	s.recordPasswordHistory(ctx, profile.ProfileID, passwordHash)
+
+	// Only the verification flow verifies an address, invited profiles get its link once they have a password
+	if target.VerifiedAt == nil {
+		if err := s.sendVerificationEmail(ctx, target); err != nil {
+			s.logger.Warn("failed to send verification email after password reset", "profileID", profile.ProfileID, "error", err)
+		}
+	}
+
+	// Invalidate / Delete all other tokens
+	if err := s.repo.DeleteOtherPasswordResetTokens(ctx, profile.ProfileID); err != nil {
+		s.logger.Warn("failed to invalidate/delete other reset tokens", "error", err)
+	}
+
+	// Sign out every device, the password may have been reset because the account was compromised
+	if err := s.repo.RevokeAllSessions(ctx, profile.ProfileID); err != nil {
+		s.logger.Error("failed to revoke sessions after password reset", "profileID", profile.ProfileID, "error", err)
+		return errors.NewDatabaseError("revoking sessions", err)
+	}
+
+	s.logger.Info("password successfully reset and token invalidated", "profileID", profile.ProfileID)
+	return nil
+
+}
+
+// ValidatePasswordResetToken checks that a reset token exists, is unused and has not expired
+func (s *service) ValidatePasswordResetToken(ctx context.Context, token string) (*PasswordResetToken, error) {
+	resetToken, err := s.repo.GetPasswordResetTokenByHash(ctx, hashOpaqueToken(token))
+	if err != nil {
+		s.logger.Warn("password reset token lookup failed", "error", err)
+		return nil, errors.NewBusinessError("INVALID_RESET_TOKEN", "invalid or expired reset token", nil)
+	}
+
+	if resetToken.IsUsed || !time.Now().Before(resetToken.ExpiresAt) {
+		s.logger.Warn("unusable password reset token presented", "profileID", resetToken.ProfileID, "is_used", resetToken.IsUsed)
+		return nil, errors.NewBusinessError("INVALID_RESET_TOKEN", "invalid or expired reset token", nil)
+	}
+
+	return resetToken, nil
+}
+
+// PasswordStrength scores a password from 0 to 100 for the strength meter of the UI
+func (s *service) PasswordStrength(password string) int {
+	return validator.PasswordStrengthScore(password, s.settings.PasswordPolicy.BreachedPasswords)
+}
+
+// validateNewPassword enforces the password policy and, for existing profiles, rejects
+// the current password and the ones kept in the password history
+func (s *service) validateNewPassword(ctx context.Context, profile *PlatformProfile, password string, field string) error {
+	if err := validator.ValidatePassword(password, s.settings.PasswordPolicy); err != nil {
+		return errors.NewValidationError(err.Error(), map[string]interface{}{"field": field})
+	}
+
+	if profile == nil || s.settings.PasswordHistorySize <= 0 {
+		return nil
+	}
+
+	history, err := s.repo.GetPasswordHistory(ctx, profile.ID, s.settings.PasswordHistorySize)
+	if err != nil {
+		s.logger.Error("failed to load password history", "profileID", profile.ID, "error", err)
+		return errors.NewDatabaseError("fetching password history", err)
+	}
+
+	// Profiles created before the history existed only have their current hash
+	for _, hash := range append([]string{profile.PasswordHash}, history...) {
+		if match, _, err := s.passwordHasher.Verify(password, hash); err == nil && match {
+			return errors.NewValidationError(
+				fmt.Sprintf("password must differ from the last %d passwords", s.settings.PasswordHistorySize),
+				map[string]interface{}{"field": field},
+			)
+		}
+	}
+
+	return nil
+}
+
+// rehashPassword replaces the stored hash of a verified password with one made by the current hasher.
+// Failures keep the old hash, which still verifies.
+func (s *service) rehashPassword(ctx context.Context, profileID uuid.UUID, password string) {
+	passwordHash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		s.logger.Warn("failed to rehash password", "profileID", profileID, "error", err)
+		return
+	}
+
+	if err := s.repo.ReplacePasswordHash(ctx, profileID, passwordHash); err != nil {
+		s.logger.Warn("failed to store rehashed password", "profileID", profileID, "error", err)
+		return
+	}
+
+	s.logger.Info("password rehashed with current parameters", "profileID", profileID)
+}
+
+// recordPasswordHistory keeps a newly set password hash for reuse checks, failures only lose history
+func (s *service) recordPasswordHistory(ctx context.Context, profileID uuid.UUID, passwordHash string) {
+	if s.settings.PasswordHistorySize <= 0 {
+		return
+	}
+
+	if err := s.repo.AddPasswordHistory(ctx, profileID, passwordHash, s.settings.PasswordHistorySize); err != nil {
+		s.logger.Warn("failed to record password history", "profileID", profileID, "error", err)
+	}
+}
+
+// ExpireOldResetTokens expires every unused reset token of a profile
+func (s *service) ExpireOldResetTokens(ctx context.Context, profileID uuid.UUID) error {
+	if err := s.repo.ExpirePasswordResetTokens(ctx, profileID); err != nil {
+		s.logger.Error("failed to expire reset tokens", "profileID", profileID, "error", err)
+		return errors.NewDatabaseError("expiring reset tokens", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredResetTokens removes reset tokens that are expired or used
+func (s *service) DeleteExpiredResetTokens(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredPasswordResetTokens(ctx)
+	if err != nil {
+		s.logger.Error("failed to delete expired reset tokens", "error", err)
+		return errors.NewDatabaseError("deleting expired reset tokens", err)
+	}
+
+	s.logger.Info("expired reset tokens deleted", "deleted", deleted)
+	return nil
+}
+
+// ForcePasswordChange requires a profile to change its password at the next login.
+// All sessions are ended so the change can't be avoided by staying logged in.
+func (s *service) ForcePasswordChange(ctx context.Context, id uuid.UUID) error {
+	if err := s.ensureInScope(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.SetMustChangePassword(ctx, id, true); err != nil {
+		if errors.IsNotFoundErrorDomain(err) {
+			return errors.NewNotFoundError("profile", map[string]interface{}{"id": id})
+		}
+		s.logger.Error("failed to force password change", "profileID", id, "error", err)
+		return errors.NewDatabaseError("forcing password change", err)
+	}
+
+	if err := s.repo.RevokeAllSessions(ctx, id); err != nil {
+		s.logger.Error("failed to revoke sessions after forcing password change", "profileID", id, "error", err)
+		return errors.NewDatabaseError("revoking sessions", err)
+	}
+
+	s.logger.Info("password change forced and sessions revoked", "profileID", id)
+	return nil
+}
+
+// LockAccountAfterFailedAttempts locks a profile if it has reached the given number of failed logins.
+// The lock lasts longer with every lock since the last successful login.
+func (s *service) LockAccountAfterFailedAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	profile, err := s.repo.GetProfileByID(ctx, id)
+	if err != nil {
+		s.logger.Warn("Profile not found for lockout", "profile_id", id, "error", err)
+		return errors.NewNotFoundError("profile", id)
+	}
+
+	if profile.Status == StatusLocked || profile.FailedLoginAttempts < maxAttempts {
+		return nil
+	}
+
+	if _, err := s.lockAccount(ctx, id); err != nil {
+		return errors.NewDatabaseError("locking profile", err)
+	}
+
+	return nil
+}
+
+// UnlockAccount lifts the lock of a profile and resets its lock backoff
+func (s *service) UnlockAccount(ctx context.Context, id uuid.UUID) error {
+	unlocked, err := s.repo.UnlockProfile(ctx, id, true, nil)
+	if err != nil {
+		s.logger.Error("Failed to unlock profile", "profile_id", id, "error", err)
+		return errors.NewDatabaseError("unlocking profile", err)
+	}
+
+	if !unlocked {
+		s.logger.Warn("Unlock requested for profile that is not locked", "profile_id", id)
+		return errors.NewBusinessError("ACCOUNT_NOT_LOCKED", "account is not locked", nil)
+	}
+
+	s.logger.Info("Profile unlocked", "profile_id", id)
+	return nil
+}
+
+// AdminUnlockAccount lifts the lock of a profile before it expires.
+// The unlock and the administrator who performed it are recorded in the audit log.
+func (s *service) AdminUnlockAccount(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req AdminUnlockRequest) error {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return err
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, profileID)
+	if err != nil {
+		s.logger.Warn("Profile for unlock not found", "profile_id", profileID, "error", err)
+		return errors.NewNotFoundError("profile", profileID)
+	}
+
+	audit := &AuditLog{
+		ID:              uuid.New(),
+		ActorProfileID:  actorID,
+		TargetProfileID: profileID,
+		Action:          AuditActionAccountUnlock,
+		Details: map[string]interface{}{
+			"reason":   req.Reason,
+			"username": profile.Username,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	unlocked, err := s.repo.UnlockProfile(ctx, profileID, true, audit)
+	if err != nil {
+		s.logger.Error("Failed to unlock profile", "profile_id", profileID, "actor_id", actorID, "error", err)
+		return errors.NewDatabaseError("unlocking profile", err)
+	}
+
+	if !unlocked {
+		s.logger.Warn("Unlock requested for profile that is not locked", "profile_id", profileID, "actor_id", actorID)
+		return errors.NewBusinessError("ACCOUNT_NOT_LOCKED", "account is not locked", nil)
+	}
+
+	s.logger.Info("Profile unlocked by administrator", "profile_id", profileID, "actor_id", actorID)
+	return nil
+}
+
+// lockAccount locks a profile for a duration that doubles with every earlier lock
+func (s *service) lockAccount(ctx context.Context, profileID uuid.UUID) (time.Time, error) {
+	lockout, err := s.repo.GetAccountLockout(ctx, profileID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lockedUntil := time.Now().Add(s.lockoutDuration(lockout.LockCount))
+	if err := s.repo.LockProfile(ctx, profileID, lockedUntil); err != nil {
+		return time.Time{}, err
+	}
+
+	s.logger.Warn("Account locked due to excessive failed login attempts",
+		"profile_id", profileID,
+		"lock_count", lockout.LockCount+1,
+		"locked_until", lockedUntil,
+	)
+	return lockedUntil, nil
+}
+
+// lockoutDuration returns the base lock duration doubled for every previous lock, capped at the maximum
+func (s *service) lockoutDuration(previousLocks int) time.Duration {
+	duration := s.settings.LockoutBaseDuration
+	for i := 0; i < previousLocks && duration < s.settings.LockoutMaxDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, s.settings.LockoutMaxDuration)
+}
+
+// unlockIfExpired unlocks a locked profile whose lock has expired and returns its current state.
+// A profile that is still locked results in an ACCOUNT_LOCKED error.
+func (s *service) unlockIfExpired(ctx context.Context, profile *PlatformProfile) (*PlatformProfile, error) {
+	lockout, err := s.repo.GetAccountLockout(ctx, profile.ID)
+	if err != nil {
+		s.logger.Error("Failed to fetch account lockout", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("fetching account lockout", err)
+	}
+
+	// A lock without expiry is kept until an administrator lifts it
+	if lockout.LockedUntil == nil || time.Now().Before(*lockout.LockedUntil) {
+		s.logger.Warn("Login attempt on locked account", "profile_id", profile.ID, "locked_until", lockout.LockedUntil)
+		return nil, accountLockedError(lockout.LockedUntil)
+	}
+
+	if _, err := s.repo.UnlockProfile(ctx, profile.ID, false, nil); err != nil {
+		s.logger.Error("Failed to unlock profile after lock expiry", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("unlocking profile", err)
+	}
+	s.logger.Info("Profile unlocked after lock expiry", "profile_id", profile.ID)
+
+	unlocked, err := s.repo.GetProfileByID(ctx, profile.ID)
+	if err != nil {
+		s.logger.Error("Failed to fetch unlocked profile", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("error fetching profile", err)
+	}
+
+	return unlocked, nil
+}
+
+// checkIPBlocked rejects logins from an IP address that is blocked for too many failed logins
+func (s *service) checkIPBlocked(ctx context.Context, ipAddress string) error {
+	if ipAddress == "" {
+		return nil
+	}
+
+	blockedUntil, err := s.repo.GetIPBlockedUntil(ctx, ipAddress)
+	if err != nil {
+		// Failing open keeps logins working, the account lockout still applies
+		s.logger.Error("Failed to check IP block", "ip_address", ipAddress, "error", err)
+		return nil
+	}
+
+	if blockedUntil != nil {
+		s.logger.Warn("Login attempt from blocked IP", "ip_address", ipAddress, "blocked_until", *blockedUntil)
+		return errors.NewDomainError(
+			"too many failed login attempts, try again later",
+			errors.UnauthorizedError,
+			"TOO_MANY_ATTEMPTS",
+			map[string]interface{}{"retry_after": *blockedUntil},
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// recordIPFailure counts a failed login for the IP address and blocks it once the threshold is reached
+func (s *service) recordIPFailure(ctx context.Context, ipAddress string) {
+	if ipAddress == "" {
+		return
+	}
+
+	failures, err := s.repo.RecordIPLoginFailure(ctx, ipAddress, s.settings.IPFailureWindow)
+	if err != nil {
+		s.logger.Error("Failed to record failed login for IP", "ip_address", ipAddress, "error", err)
+		return
+	}
+
+	if failures >= s.settings.IPFailureThreshold {
+		if err := s.repo.BlockIP(ctx, ipAddress, time.Now().Add(s.settings.IPBlockDuration)); err != nil {
+			s.logger.Error("Failed to block IP", "ip_address", ipAddress, "error", err)
+			return
+		}
+		s.logger.Warn("IP blocked due to excessive failed login attempts", "ip_address", ipAddress, "failures", failures)
+	}
+}
+
+// accountLockedError reports a locked account along with the time the lock expires
+func accountLockedError(lockedUntil *time.Time) error {
+	details := map[string]interface{}{}
+	if lockedUntil != nil {
+		details["locked_until"] = *lockedUntil
+	}
+
+	return errors.NewDomainError("account is temporarily locked", errors.UnauthorizedError, "ACCOUNT_LOCKED", details, nil)
+}
+
+// BeginMFAEnrollment generates a new TOTP secret for the profile. MFA stays disabled
+// until the enrollment is confirmed with a code from the authenticator app.
+func (s *service) BeginMFAEnrollment(ctx context.Context, profileID uuid.UUID) (*MFAEnrollment, error) {
+	profile, err := s.repo.GetProfileByID(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to fetch profile for MFA enrollment", "profile_id", profileID, "error", err)
+		return nil, errors.NewNotFoundError("profile", profileID)
+	}
+
+	enabled, err := s.repo.IsMFAEnabled(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to check MFA status", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("checking MFA status", err)
+	}
+	if enabled {
+		s.logger.Warn("MFA enrollment requested while MFA is enabled", "profile_id", profileID)
+		return nil, errors.NewBusinessError("MFA_ALREADY_ENABLED", "multi-factor authentication is already enabled", nil)
+	}
+
+	secret, err := s.otpProvider.GenerateSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate MFA secret", "profile_id", profileID, "error", err)
+		return nil, errors.NewBusinessError("MFA_SECRET_GENERATION_FAILED", "failed to start MFA enrollment", nil)
+	}
+
+	// Starting over replaces any enrollment that was never confirmed
+	now := time.Now()
+	mfa := &ProfileMFA{
+		ProfileID: profileID,
+		Secret:    secret,
+		Enabled:   false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.SaveMFA(ctx, mfa); err != nil {
+		s.logger.Error("Failed to save MFA enrollment", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("saving MFA enrollment", err)
+	}
+
+	s.logger.Info("MFA enrollment started", "profile_id", profileID)
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: s.otpProvider.ProvisioningURI(secret, profile.Email),
+	}, nil
+}
+
+// ConfirmMFAEnrollment enables MFA once the profile proves its authenticator app works
+// and returns the recovery codes, which are shown only this once
+func (s *service) ConfirmMFAEnrollment(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) (*MFARecoveryCodes, error) {
+	mfa, err := s.repo.GetMFA(ctx, profileID)
+	if err != nil || mfa.Enabled {
+		s.logger.Warn("MFA confirmation without pending enrollment", "profile_id", profileID, "error", err)
+		return nil, errors.NewBusinessError("MFA_ENROLLMENT_NOT_STARTED", "no pending MFA enrollment found", nil)
+	}
+
+	step, ok := s.otpProvider.Validate(mfa.Secret, req.Code)
+	if !ok {
+		s.logger.Warn("Invalid code for MFA confirmation", "profile_id", profileID)
+		return nil, errors.NewValidationError("invalid verification code", map[string]any{"field": "code"})
+	}
+
+	codes, recoveryCodes, err := s.generateRecoveryCodes(profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableMFA(ctx, profileID, recoveryCodes); err != nil {
+		s.logger.Error("Failed to enable MFA", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("enabling MFA", err)
+	}
+
+	// The confirmation code must not be usable for a login
+	if _, err := s.repo.UpdateMFALastUsedStep(ctx, profileID, step); err != nil {
+		s.logger.Warn("Failed to record MFA code use", "profile_id", profileID, "error", err)
+	}
+
+	s.logger.Info("MFA enabled", "profile_id", profileID)
+	return &MFARecoveryCodes{Codes: codes}, nil
+}
+
+// VerifyMFA completes a login by exchanging an MFA challenge token and a TOTP or recovery code for tokens
+func (s *service) VerifyMFA(ctx context.Context, req MFAVerifyRequest) (*AuthResponse, error) {
+	challenge, err := s.repo.GetMFAChallengeByHash(ctx, hashOpaqueToken(req.ChallengeToken))
+	if err != nil {
+		s.logger.Warn("MFA challenge lookup failed", "error", err)
+		return nil, errors.NewUnauthorizedError("invalid or expired MFA challenge")
+	}
+
+	if challenge.ConsumedAt != nil || time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= s.settings.MFAMaxAttempts {
+		s.logger.Warn("Unusable MFA challenge presented", "profile_id", challenge.ProfileID, "attempts", challenge.Attempts)
+		return nil, errors.NewUnauthorizedError("invalid or expired MFA challenge")
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, challenge.ProfileID)
+	if err != nil {
+		s.logger.Warn("Profile for MFA challenge not found", "profile_id", challenge.ProfileID, "error", err)
+		return nil, errors.NewUnauthorizedError("invalid or expired MFA challenge")
+	}
+
+	if profile.Status == StatusDeactivated || profile.Status == StatusSuspended {
+		s.logger.Warn("Account is not active", "profile_id", profile.ID, "status", profile.Status)
+		return nil, errors.NewUnauthorizedError("account is not active")
+	}
+
+	// A lock caused by wrong codes also holds for the challenges still pending
+	if profile.Status == StatusLocked {
+		profile, err = s.unlockIfExpired(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	verified, err := s.verifySecondFactor(ctx, profile.ID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		if err := s.repo.IncrementMFAChallengeAttempts(ctx, challenge.ID); err != nil {
+			s.logger.Error("Failed to record failed MFA attempt", "profile_id", profile.ID, "error", err)
+		}
+		// New challenges are issued on every login, so wrong codes count against the profile too
+		s.logger.Warn("Invalid MFA code provided", "profile_id", profile.ID)
+		return nil, s.recordFailedLogin(ctx, profile, errors.NewUnauthorizedError("invalid verification code"))
+	}
+
+	consumed, err := s.repo.ConsumeMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		s.logger.Error("Failed to consume MFA challenge", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("consuming MFA challenge", err)
+	}
+	if !consumed {
+		s.logger.Warn("MFA challenge already used", "profile_id", profile.ID)
+		return nil, errors.NewUnauthorizedError("invalid or expired MFA challenge")
+	}
+
+	// The password step may have lifted an expired lock, so the backoff is always cleared
+	s.clearFailedLogins(ctx, profile, true)
+	return s.completeLogin(ctx, profile, challenge.UserAgent, challenge.IPAddress)
+}
+
+// DisableMFA turns off MFA for a profile after verifying a current TOTP or recovery code
+func (s *service) DisableMFA(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) error {
+	verified, err := s.verifySecondFactor(ctx, profileID, req.Code)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		s.logger.Warn("Invalid code for disabling MFA", "profile_id", profileID)
+		return errors.NewValidationError("invalid verification code", map[string]any{"field": "code"})
+	}
+
+	if err := s.repo.DeleteMFA(ctx, profileID); err != nil {
+		s.logger.Error("Failed to disable MFA", "profile_id", profileID, "error", err)
+		return errors.NewDatabaseError("disabling MFA", err)
+	}
+
+	s.logger.Info("MFA disabled", "profile_id", profileID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of a profile after verifying a current TOTP code
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, profileID uuid.UUID, req MFACodeRequest) (*MFARecoveryCodes, error) {
+	mfa, err := s.repo.GetMFA(ctx, profileID)
+	if err != nil || !mfa.Enabled {
+		s.logger.Warn("Recovery code regeneration without MFA", "profile_id", profileID, "error", err)
+		return nil, errors.NewBusinessError("MFA_NOT_ENABLED", "multi-factor authentication is not enabled", nil)
+	}
+
+	// Only an authenticator code is accepted, a recovery code could be the one that leaked
+	if !s.acceptTOTP(ctx, mfa, req.Code) {
+		s.logger.Warn("Invalid code for recovery code regeneration", "profile_id", profileID)
+		return nil, errors.NewValidationError("invalid verification code", map[string]any{"field": "code"})
+	}
+
+	codes, recoveryCodes, err := s.generateRecoveryCodes(profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, profileID, recoveryCodes); err != nil {
+		s.logger.Error("Failed to replace recovery codes", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("replacing recovery codes", err)
+	}
+
+	s.logger.Info("Recovery codes regenerated", "profile_id", profileID)
+	return &MFARecoveryCodes{Codes: codes}, nil
+}
+
+// AdminResetMFA removes the MFA of a profile that lost access to its second factor.
+// The reset and the administrator who performed it are recorded in the audit log.
+func (s *service) AdminResetMFA(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req AdminMFAResetRequest) error {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return err
+	}
+
+	profile, err := s.repo.GetProfileByID(ctx, profileID)
+	if err != nil {
+		s.logger.Warn("Profile for MFA reset not found", "profile_id", profileID, "error", err)
+		return errors.NewNotFoundError("profile", profileID)
+	}
+
+	if _, err := s.repo.GetMFA(ctx, profileID); err != nil {
+		s.logger.Warn("MFA reset requested for profile without MFA", "profile_id", profileID, "error", err)
+		return errors.NewBusinessError("MFA_NOT_ENABLED", "multi-factor authentication is not configured for this profile", nil)
+	}
+
+	audit := &AuditLog{
+		ID:              uuid.New(),
+		ActorProfileID:  actorID,
+		TargetProfileID: profileID,
+		Action:          AuditActionMFAReset,
+		Details: map[string]interface{}{
+			"reason":   req.Reason,
+			"username": profile.Username,
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.ResetMFA(ctx, profileID, audit); err != nil {
+		s.logger.Error("Failed to reset MFA", "profile_id", profileID, "actor_id", actorID, "error", err)
+		return errors.NewDatabaseError("resetting MFA", err)
+	}
+
+	s.logger.Info("MFA reset by administrator", "profile_id", profileID, "actor_id", actorID)
+	return nil
+}
+
+// createMFAChallenge starts the second step of a login for a profile with MFA enabled
+func (s *service) createMFAChallenge(ctx context.Context, profile *PlatformProfile, userAgent, ipAddress string) (*AuthResponse, error) {
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate MFA challenge token", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewBusinessError("TOKEN_GENERATION_FAILED", "failed to start MFA verification", nil)
+	}
+
+	now := time.Now()
+	challenge := &MFAChallenge{
+		ID:        uuid.New(),
+		ProfileID: profile.ID,
+		TokenHash: hashOpaqueToken(token),
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: now.Add(s.settings.MFAChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateMFAChallenge(ctx, challenge); err != nil {
+		s.logger.Error("Failed to store MFA challenge", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("storing MFA challenge", err)
+	}
+
+	s.logger.Info("Password verified, MFA required", "profile_id", profile.ID)
+	return &AuthResponse{MFARequired: true, MFAChallengeToken: token}, nil
+}
+
+// verifySecondFactor checks a TOTP code or, failing that, an unused recovery code.
+// A matching recovery code is consumed.
+func (s *service) verifySecondFactor(ctx context.Context, profileID uuid.UUID, code string) (bool, error) {
+	mfa, err := s.repo.GetMFA(ctx, profileID)
+	if err != nil || !mfa.Enabled {
+		s.logger.Warn("Second factor checked for profile without MFA", "profile_id", profileID, "error", err)
+		return false, errors.NewBusinessError("MFA_NOT_ENABLED", "multi-factor authentication is not enabled", nil)
+	}
+
+	if s.acceptTOTP(ctx, mfa, code) {
+		return true, nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false, nil
+	}
+
+	recoveryCodes, err := s.repo.GetUnusedRecoveryCodes(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to fetch recovery codes", "profile_id", profileID, "error", err)
+		return false, errors.NewDatabaseError("fetching recovery codes", err)
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		match, _, err := s.passwordHasher.Verify(normalized, recoveryCode.CodeHash)
+		if err != nil || !match {
+			continue
+		}
+
+		// Losing this race means the same code was used concurrently
+		used, err := s.repo.MarkRecoveryCodeUsed(ctx, recoveryCode.ID)
+		if err != nil {
+			s.logger.Error("Failed to mark recovery code as used", "profile_id", profileID, "error", err)
+			return false, errors.NewDatabaseError("using recovery code", err)
+		}
+
+		s.logger.Info("Recovery code used", "profile_id", profileID, "remaining", len(recoveryCodes)-1)
+		return used, nil
+	}
+
+	return false, nil
+}
+
+// acceptTOTP validates a TOTP code and records its time step so the same code cannot be replayed
+func (s *service) acceptTOTP(ctx context.Context, mfa *ProfileMFA, code string) bool {
+	step, ok := s.otpProvider.Validate(mfa.Secret, code)
+	if !ok {
+		return false
+	}
+
+	fresh, err := s.repo.UpdateMFALastUsedStep(ctx, mfa.ProfileID, step)
+	if err != nil || !fresh {
+		s.logger.Warn("TOTP code rejected as replay", "profile_id", mfa.ProfileID, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// generateRecoveryCodes creates a new set of recovery codes, returning the plain codes
+// for the user and their Argon2 hashes for storage
+func (s *service) generateRecoveryCodes(profileID uuid.UUID) ([]string, []*MFARecoveryCode, error) {
+	now := time.Now()
+	codes := make([]string, 0, s.settings.MFARecoveryCodeCount)
+	recoveryCodes := make([]*MFARecoveryCode, 0, s.settings.MFARecoveryCodeCount)
+
+	for i := 0; i < s.settings.MFARecoveryCodeCount; i++ {
+		raw, err := utils.GenerateToken(recoveryCodeLength / 2)
+		if err != nil {
+			s.logger.Error("Failed to generate recovery code", "profile_id", profileID, "error", err)
+			return nil, nil, errors.NewBusinessError("RECOVERY_CODE_GENERATION_FAILED", "failed to generate recovery codes", nil)
+		}
+
+		hash, err := s.passwordHasher.Hash(raw)
+		if err != nil {
+			s.logger.Error("Failed to hash recovery code", "profile_id", profileID, "error", err)
+			return nil, nil, errors.NewBusinessError("RECOVERY_CODE_GENERATION_FAILED", "failed to generate recovery codes", nil)
+		}
+
+		// Shown as xxxxx-xxxxx for readability, the dash is ignored on input
+		codes = append(codes, raw[:recoveryCodeLength/2]+"-"+raw[recoveryCodeLength/2:])
+		recoveryCodes = append(recoveryCodes, &MFARecoveryCode{
+			ID:        uuid.New(),
+			ProfileID: profileID,
+			CodeHash:  hash,
+			CreatedAt: now,
+		})
+	}
+
+	return codes, recoveryCodes, nil
+}
+
+// ListSessions returns the active sessions of a profile, marking the caller's own session
+func (s *service) ListSessions(ctx context.Context, profileID uuid.UUID, currentSessionID uuid.UUID) ([]*Session, error) {
+	sessions, err := s.repo.ListActiveSessions(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to list sessions", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("listing sessions", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one session of a profile, signing that device out
+func (s *service) RevokeSession(ctx context.Context, profileID uuid.UUID, sessionID uuid.UUID) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil || session.ProfileID != profileID || session.RevokedAt != nil {
+		// Sessions of other profiles are reported as missing rather than forbidden
+		s.logger.Warn("Session to revoke not found", "profile_id", profileID, "session_id", sessionID)
+		return errors.NewNotFoundError("session", sessionID)
+	}
+
+	if err := s.repo.RevokeSession(ctx, profileID, sessionID); err != nil {
+		s.logger.Error("Failed to revoke session", "profile_id", profileID, "session_id", sessionID, "error", err)
+		return errors.NewDatabaseError("revoking session", err)
+	}
+
+	s.logger.Info("Session revoked", "profile_id", profileID, "session_id", sessionID)
+	return nil
+}
+
+// RevokeOtherSessions ends every session of a profile except the caller's own
+func (s *service) RevokeOtherSessions(ctx context.Context, profileID uuid.UUID, currentSessionID uuid.UUID) (int64, error) {
+	revoked, err := s.repo.RevokeOtherSessions(ctx, profileID, currentSessionID)
+	if err != nil {
+		s.logger.Error("Failed to revoke other sessions", "profile_id", profileID, "error", err)
+		return 0, errors.NewDatabaseError("revoking sessions", err)
+	}
+
+	s.logger.Info("Other sessions revoked", "profile_id", profileID, "sessions_revoked", revoked)
+	return revoked, nil
+}
+
+// BeginOAuthLogin starts a login with an external identity provider. The state, PKCE verifier
+// and nonce are kept server side until the provider redirects back.
+func (s *service) BeginOAuthLogin(ctx context.Context, providerName string) (*OAuthAuthorization, error) {
+	provider, ok := s.identityProviders[providerName]
+	if !ok {
+		return nil, errors.NewNotFoundError("identity provider", map[string]interface{}{"provider": providerName})
+	}
+
+	state, err := utils.GenerateToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate OAuth state", "provider", providerName, "error", err)
+		return nil, errors.NewBusinessError("OAUTH_INIT_FAILED", "failed to start external login", nil)
+	}
+	codeVerifier, err := utils.GenerateToken(32)
+	if err != nil {
+		s.logger.Error("Failed to generate PKCE verifier", "provider", providerName, "error", err)
+		return nil, errors.NewBusinessError("OAUTH_INIT_FAILED", "failed to start external login", nil)
+	}
+	nonce, err := utils.GenerateToken(16)
+	if err != nil {
+		s.logger.Error("Failed to generate OAuth nonce", "provider", providerName, "error", err)
+		return nil, errors.NewBusinessError("OAUTH_INIT_FAILED", "failed to start external login", nil)
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, codeVerifier, nonce)
+	if err != nil {
+		s.logger.Error("Failed to build authorization URL", "provider", providerName, "error", err)
+		return nil, errors.NewIntegrationError(providerName, "building authorization URL", err)
+	}
+
+	now := time.Now()
+	pending := &OAuthState{
+		StateHash:    hashOpaqueToken(state),
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(s.settings.OAuthStateTTL),
+		CreatedAt:    now,
+	}
+	if err := s.repo.CreateOAuthState(ctx, pending); err != nil {
+		s.logger.Error("Failed to store OAuth state", "provider", providerName, "error", err)
+		return nil, errors.NewDatabaseError("storing OAuth state", err)
+	}
+
+	return &OAuthAuthorization{AuthorizationURL: authorizationURL, State: state}, nil
+}
+
+// CompleteOAuthLogin exchanges the authorization code and logs in the profile the external identity
+// belongs to. Identities that are not linked yet are linked to the profile with the same email,
+// provided both the provider and the profile verified that email. MFA still applies.
+func (s *service) CompleteOAuthLogin(ctx context.Context, req OAuthCallbackRequest) (*AuthResponse, error) {
+	provider, ok := s.identityProviders[req.Provider]
+	if !ok {
+		return nil, errors.NewNotFoundError("identity provider", map[string]interface{}{"provider": req.Provider})
+	}
+
+	if err := s.checkIPBlocked(ctx, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	// The state is single use, whatever the outcome
+	pending, err := s.repo.ConsumeOAuthState(ctx, hashOpaqueToken(req.State))
+	if err != nil {
+		s.logger.Error("Failed to consume OAuth state", "provider", req.Provider, "error", err)
+		return nil, errors.NewDatabaseError("consuming OAuth state", err)
+	}
+	if pending == nil || pending.Provider != req.Provider || time.Now().After(pending.ExpiresAt) {
+		s.logger.Warn("Unknown or expired OAuth state presented", "provider", req.Provider)
+		return nil, errors.NewBusinessError("INVALID_OAUTH_STATE", "login request is invalid or has expired, please try again", nil)
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		s.recordIPFailure(ctx, req.IPAddress)
+		s.logger.Warn("External login rejected", "provider", req.Provider, "error", err)
+		return nil, errors.NewUnauthorizedError("external login failed")
+	}
+
+	linked, err := s.repo.GetLinkedIdentity(ctx, req.Provider, identity.Subject)
+	if err != nil {
+		s.logger.Error("Failed to fetch linked identity", "provider", req.Provider, "error", err)
+		return nil, errors.NewDatabaseError("fetching linked identity", err)
+	}
+
+	var profile *PlatformProfile
+	if linked != nil {
+		profile, err = s.repo.GetProfileByID(ctx, linked.ProfileID)
+		if err != nil {
+			s.logger.Error("Failed to fetch profile of linked identity", "profile_id", linked.ProfileID, "error", err)
+			return nil, errors.NewDatabaseError("fetching profile", err)
+		}
+	} else {
+		profile, linked, err = s.linkIdentityByEmail(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if profile.Status == StatusDeactivated || profile.Status == StatusSuspended {
+		s.logger.Warn("Account is not active", "profile_id", profile.ID, "status", profile.Status)
+		return nil, errors.NewUnauthorizedError("account is not active")
+	}
+
+	if profile.Status == StatusLocked {
+		profile, err = s.unlockIfExpired(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.ClearAccountLockout(ctx, profile.ID); err != nil {
+			s.logger.Warn("Failed to clear account lockout", "profile_id", profile.ID, "error", err)
+		}
+	}
+
+	if s.settings.RequireEmailVerification && profile.VerifiedAt == nil {
+		s.logger.Warn("Login attempt before email verification", "profile_id", profile.ID)
+		return nil, errors.NewDomainError("email address is not verified", errors.ForbiddenError, "EMAIL_NOT_VERIFIED", nil, nil)
+	}
+
+	if err := s.repo.RecordIdentityLogin(ctx, linked.ID); err != nil {
+		s.logger.Warn("Failed to record identity login", "identity_id", linked.ID, "error", err)
+	}
+
+	mfaEnabled, err := s.repo.IsMFAEnabled(ctx, profile.ID)
+	if err != nil {
+		s.logger.Error("Failed to check MFA status", "profile_id", profile.ID, "error", err)
+		return nil, errors.NewDatabaseError("checking MFA status", err)
+	}
+	if mfaEnabled {
+		return s.createMFAChallenge(ctx, profile, req.UserAgent, req.IPAddress)
+	}
+
+	return s.completeLogin(ctx, profile, req.UserAgent, req.IPAddress)
+}
+
+// linkIdentityByEmail links a new external identity to the profile registered with its verified email.
+// Profiles that never verified their email are not linked: anyone could have registered them with
+// the address, and the identity's owner would share the account with whoever set its password.
+func (s *service) linkIdentityByEmail(ctx context.Context, identity *ExternalIdentity) (*PlatformProfile, *LinkedIdentity, error) {
+	if !identity.EmailVerified || identity.Email == "" {
+		s.logger.Warn("External identity without verified email", "provider", identity.Provider)
+		return nil, nil, errors.NewDomainError("the provider has not verified this email address", errors.ForbiddenError, "OAUTH_EMAIL_NOT_VERIFIED", nil, nil)
+	}
+
+	exists, err := s.repo.EmailExists(ctx, identity.Email)
+	if err != nil {
+		s.logger.Error("Failed to check email existence", "email", identity.Email, "error", err)
+		return nil, nil, errors.NewDatabaseError("fetching email", err)
+	}
+	if !exists {
+		s.logger.Info("No profile for external identity", "provider", identity.Provider, "email", identity.Email)
+		return nil, nil, errors.NewNotFoundError("profile", map[string]interface{}{"email": identity.Email})
+	}
+
+	profile, err := s.repo.GetProfileByEmail(ctx, identity.Email)
+	if err != nil {
+		s.logger.Error("Failed to fetch profile by email", "email", identity.Email, "error", err)
+		return nil, nil, errors.NewDatabaseError("fetching profile", err)
+	}
+	if profile.VerifiedAt == nil {
+		s.logger.Warn("External identity matches unverified profile", "profile_id", profile.ID, "provider", identity.Provider)
+		return nil, nil, errors.NewDomainError(
+			"verify the email address of your account before logging in with this provider",
+			errors.ForbiddenError,
+			"PROFILE_EMAIL_NOT_VERIFIED",
+			nil,
+			nil,
+		)
+	}
+
+	linked := &LinkedIdentity{
+		ID:        uuid.New(),
+		ProfileID: profile.ID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateLinkedIdentity(ctx, linked); err != nil {
+		s.logger.Error("Failed to link external identity", "profile_id", profile.ID, "provider", identity.Provider, "error", err)
+		return nil, nil, errors.NewDatabaseError("linking external identity", err)
+	}
+
+	s.logger.Info("External identity linked by email", "profile_id", profile.ID, "provider", identity.Provider)
+	return profile, linked, nil
+}
+
+// ListLinkedIdentities returns the external identities linked to a profile
+func (s *service) ListLinkedIdentities(ctx context.Context, profileID uuid.UUID) ([]*LinkedIdentity, error) {
+	identities, err := s.repo.ListLinkedIdentities(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to list linked identities", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("listing linked identities", err)
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity removes an external identity from a profile. The profile can still log in with
+// its password, or link the identity again through its verified email.
+func (s *service) UnlinkIdentity(ctx context.Context, profileID uuid.UUID, identityID uuid.UUID) error {
+	deleted, err := s.repo.DeleteLinkedIdentity(ctx, profileID, identityID)
+	if err != nil {
+		s.logger.Error("Failed to unlink identity", "profile_id", profileID, "identity_id", identityID, "error", err)
+		return errors.NewDatabaseError("unlinking identity", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("linked identity", map[string]interface{}{"id": identityID})
+	}
+
+	s.logger.Info("External identity unlinked", "profile_id", profileID, "identity_id", identityID)
+	return nil
+}
+
+// CreateAPIKey creates an API key for a profile. The key is returned only this once,
+// afterwards only its prefix is shown.
+func (s *service) CreateAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, req CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetProfileByID(ctx, profileID); err != nil {
+		s.logger.Warn("Profile for API key not found", "profile_id", profileID, "error", err)
+		return nil, errors.NewNotFoundError("profile", profileID)
+	}
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.After(now.Add(s.settings.APIKeyMaxLifetime)) {
+		return nil, errors.NewValidationError(
+			"expiry must be in the future and within the maximum API key lifetime",
+			map[string]any{"field": "expires_at", "max_lifetime": s.settings.APIKeyMaxLifetime.String()},
+		)
+	}
+
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &APIKey{
+		ID:         uuid.New(),
+		ProfileID:  profileID,
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		AllowedIPs: allowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  actorID,
+		CreatedAt:  now,
+	}
+
+	return s.issueAPIKey(ctx, key, nil, &AuditLog{
+		ID:              uuid.New(),
+		ActorProfileID:  actorID,
+		TargetProfileID: profileID,
+		Action:          AuditActionAPIKeyCreate,
+		Details:         map[string]interface{}{"api_key_id": key.ID, "name": key.Name, "scopes": key.Scopes},
+		CreatedAt:       now,
+	})
+}
+
+// ListAPIKeys returns the API keys of a profile, without their secrets
+func (s *service) ListAPIKeys(ctx context.Context, profileID uuid.UUID) ([]*APIKey, error) {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.repo.ListAPIKeys(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to list API keys", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("listing API keys", err)
+	}
+
+	return keys, nil
+}
+
+// RotateAPIKey replaces an API key with a new one of the same name, scopes, allowed IPs and lifetime.
+// The old key keeps working until the end of the overlap window so callers can switch over.
+func (s *service) RotateAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, keyID uuid.UUID, req RotateAPIKeyRequest) (*CreatedAPIKey, error) {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return nil, err
+	}
+
+	overlap := s.settings.APIKeyDefaultRotationOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+	if overlap < 0 || overlap > s.settings.APIKeyMaxRotationOverlap {
+		return nil, errors.NewValidationError(
+			"overlap exceeds the maximum rotation overlap",
+			map[string]any{"field": "overlap_seconds", "max_overlap": s.settings.APIKeyMaxRotationOverlap.String()},
+		)
+	}
+
+	old, err := s.repo.GetAPIKey(ctx, profileID, keyID)
+	if err != nil {
+		s.logger.Error("Failed to fetch API key", "profile_id", profileID, "api_key_id", keyID, "error", err)
+		return nil, errors.NewDatabaseError("fetching API key", err)
+	}
+	if old == nil {
+		return nil, errors.NewNotFoundError("API key", keyID)
+	}
+
+	now := time.Now()
+	if old.RevokedAt != nil || !old.ExpiresAt.After(now) {
+		s.logger.Warn("Rotation requested for inactive API key", "profile_id", profileID, "api_key_id", keyID)
+		return nil, errors.NewBusinessError("API_KEY_INACTIVE", "revoked or expired API keys can't be rotated, create a new key instead", nil)
+	}
+
+	lifetime := old.ExpiresAt.Sub(old.CreatedAt)
+	if lifetime > s.settings.APIKeyMaxLifetime {
+		lifetime = s.settings.APIKeyMaxLifetime
+	}
+
+	key := &APIKey{
+		ID:          uuid.New(),
+		ProfileID:   profileID,
+		Name:        old.Name,
+		Scopes:      old.Scopes,
+		AllowedIPs:  old.AllowedIPs,
+		ExpiresAt:   now.Add(lifetime),
+		RotatedFrom: &old.ID,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+	}
+	oldExpiresAt := now.Add(overlap)
+
+	return s.issueAPIKey(ctx, key, &oldExpiresAt, &AuditLog{
+		ID:              uuid.New(),
+		ActorProfileID:  actorID,
+		TargetProfileID: profileID,
+		Action:          AuditActionAPIKeyRotate,
+		Details: map[string]interface{}{
+			"api_key_id":         key.ID,
+			"rotated_from":       old.ID,
+			"old_key_expires_at": oldExpiresAt,
+		},
+		CreatedAt: now,
+	})
+}
+
+// issueAPIKey generates the secret of a new key and stores it. When rotating, oldKeyExpiresAt is the
+// end of the overlap window of the key named by key.RotatedFrom.
+func (s *service) issueAPIKey(ctx context.Context, key *APIKey, oldKeyExpiresAt *time.Time, audit *AuditLog) (*CreatedAPIKey, error) {
+	rawKey, prefix, keyHash, err := s.apiKeys.Generate()
+	if err != nil {
+		s.logger.Error("Failed to generate API key", "profile_id", key.ProfileID, "error", err)
+		return nil, errors.NewBusinessError("API_KEY_GENERATION_FAILED", "failed to generate API key", nil)
+	}
+	key.Prefix = prefix
+	key.KeyHash = keyHash
+
+	if oldKeyExpiresAt == nil {
+		if err := s.repo.CreateAPIKey(ctx, key, audit); err != nil {
+			s.logger.Error("Failed to create API key", "profile_id", key.ProfileID, "error", err)
+			return nil, errors.NewDatabaseError("creating API key", err)
+		}
+		s.logger.Info("API key created", "profile_id", key.ProfileID, "api_key_id", key.ID, "actor_id", audit.ActorProfileID)
+		return &CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+	}
+
+	rotated, err := s.repo.RotateAPIKey(ctx, *key.RotatedFrom, *oldKeyExpiresAt, key, audit)
+	if err != nil {
+		s.logger.Error("Failed to rotate API key", "profile_id", key.ProfileID, "api_key_id", *key.RotatedFrom, "error", err)
+		return nil, errors.NewDatabaseError("rotating API key", err)
+	}
+	if !rotated {
+		return nil, errors.NewBusinessError("API_KEY_ALREADY_ROTATED", "API key was revoked or has already been rotated", nil)
+	}
+
+	s.logger.Info("API key rotated", "profile_id", key.ProfileID, "api_key_id", key.ID, "rotated_from", *key.RotatedFrom, "actor_id", audit.ActorProfileID)
+	return &CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+// RevokeAPIKey revokes an API key immediately
+func (s *service) RevokeAPIKey(ctx context.Context, actorID uuid.UUID, profileID uuid.UUID, keyID uuid.UUID) error {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
+		return err
+	}
+
+	audit := &AuditLog{
+		ID:              uuid.New(),
+		ActorProfileID:  actorID,
+		TargetProfileID: profileID,
+		Action:          AuditActionAPIKeyRevoke,
+		Details:         map[string]interface{}{"api_key_id": keyID},
+		CreatedAt:       time.Now(),
+	}
+
+	revoked, err := s.repo.RevokeAPIKey(ctx, profileID, keyID, audit)
+	if err != nil {
+		s.logger.Error("Failed to revoke API key", "profile_id", profileID, "api_key_id", keyID, "error", err)
+		return errors.NewDatabaseError("revoking API key", err)
+	}
+	if !revoked {
+		return errors.NewNotFoundError("API key", keyID)
+	}
+
+	s.logger.Info("API key revoked", "profile_id", profileID, "api_key_id", keyID, "actor_id", actorID)
+	return nil
+}
+
+// AuthenticateAPIKey verifies a presented API key and the address it is used from, and returns the key.
+// Invalid keys count as failed logins of the client IP.
+func (s *service) AuthenticateAPIKey(ctx context.Context, rawKey string, ipAddress string) (*APIKey, error) {
+	if err := s.checkIPBlocked(ctx, ipAddress); err != nil {
+		return nil, err
+	}
+
+	prefix, ok := s.apiKeys.Parse(rawKey)
+	if !ok {
+		s.recordIPFailure(ctx, ipAddress)
+		return nil, errors.NewUnauthorizedError("invalid API key")
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		s.logger.Error("Failed to fetch API key", "prefix", prefix, "error", err)
+		return nil, errors.NewDatabaseError("fetching API key", err)
+	}
+	if key == nil || !s.apiKeys.Verify(rawKey, key.KeyHash) {
+		s.logger.Warn("Invalid API key presented", "prefix", prefix, "ip_address", ipAddress)
+		s.recordIPFailure(ctx, ipAddress)
+		return nil, errors.NewUnauthorizedError("invalid API key")
+	}
+
+	if key.RevokedAt != nil {
+		s.logger.Warn("Revoked API key presented", "api_key_id", key.ID, "ip_address", ipAddress)
+		return nil, errors.NewUnauthorizedError("API key has been revoked")
+	}
+	if !key.ExpiresAt.After(time.Now()) {
+		s.logger.Warn("Expired API key presented", "api_key_id", key.ID, "ip_address", ipAddress)
+		return nil, errors.NewUnauthorizedError("API key has expired")
+	}
+
+	if !ipAllowed(key.AllowedIPs, ipAddress) {
+		s.logger.Warn("API key used from address outside its allowlist", "api_key_id", key.ID, "ip_address", ipAddress)
+		return nil, errors.NewDomainError("API key is not allowed from this address", errors.ForbiddenError, "API_KEY_IP_NOT_ALLOWED", nil, nil)
+	}
+
+	if err := s.repo.RecordAPIKeyUse(ctx, key.ID, ipAddress); err != nil {
+		s.logger.Warn("Failed to record API key use", "api_key_id", key.ID, "error", err)
+	}
+
+	return key, nil
+}
+
+// normalizeAllowedIPs validates an IP allowlist and stores single addresses as host ranges
+func normalizeAllowedIPs(entries []string) ([]string, error) {
+	normalized := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			normalized = append(normalized, network.String())
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, errors.NewValidationError("invalid IP address or CIDR range", map[string]any{"field": "allowed_ips", "value": entry})
+		}
+		if ip.To4() != nil {
+			normalized = append(normalized, ip.String()+"/32")
+		} else {
+			normalized = append(normalized, ip.String()+"/128")
+		}
+	}
+
+	return normalized, nil
+}
+
+// ipAllowed reports whether an address is within an allowlist of CIDR ranges. An empty allowlist allows every address.
+func ipAllowed(allowlist []string, ipAddress string) bool {
+	if len(allowlist) == 0 {
+		return true
 	}
 
-	// Mark token as used
-	if err := s.repo.MarkPasswordResetTokenUsed(ctx, req.Token); err != nil {
-		s.logger.Warn("failed to mark reset token as used", "error", err)
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
 	}
 
-	// Invalidate / Delete all other tokens
-	if err := s.repo.DeleteOtherPasswordResetTokens(ctx, profile.ProfileID); err != nil {
-		s.logger.Warn("failed to invalidate/delete other reset tokens", "error", err)
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BulkCreateProfiles creates many profiles at once, such as the students of a new academic year.
+// Rows with an invalid password or a username or email repeated within the request are reported
+// without reaching the database, rows whose username or email is taken are skipped as duplicates.
+// Profiles without a password get a random one and have to reset it before logging in.
+// Passwords are not hashed in a dry run.
+func (s *service) BulkCreateProfiles(ctx context.Context, actorID uuid.UUID, req BulkCreateProfilesRequest) (*BulkResult, error) {
+	if len(req.Profiles) == 0 || len(req.Profiles) > MaxBulkItems {
+		return nil, errors.NewValidationError(fmt.Sprintf("between 1 and %d profiles are required", MaxBulkItems), map[string]any{"field": "profiles"})
+	}
+
+	s.logger.Info("Starting bulk profile creation", "actor_id", actorID, "rows", len(req.Profiles), "dry_run", req.DryRun)
+
+	results := make([]BulkItemResult, len(req.Profiles))
+	profiles := make([]*PlatformProfile, 0, len(req.Profiles))
+	positions := make([]int, 0, len(req.Profiles))
+	seenUsernames := make(map[string]bool, len(req.Profiles))
+	seenEmails := make(map[string]bool, len(req.Profiles))
+
+	now := time.Now()
+	for i, input := range req.Profiles {
+		username := strings.TrimSpace(input.Username)
+		email := strings.TrimSpace(input.Email)
+		results[i] = BulkItemResult{Index: i, Username: username}
+
+		usernameKey, emailKey := strings.ToLower(username), strings.ToLower(email)
+		if seenUsernames[usernameKey] || seenEmails[emailKey] {
+			results[i].Status = BulkItemSkipped
+			results[i].Reason = "duplicate of an earlier row"
+			continue
+		}
+		seenUsernames[usernameKey] = true
+		seenEmails[emailKey] = true
+
+		password := input.Password
+		if password != "" {
+			if err := validator.ValidatePassword(password, s.settings.PasswordPolicy); err != nil {
+				results[i].Status = BulkItemFailed
+				results[i].Reason = err.Error()
+				continue
+			}
+		}
+
+		passwordHash := "dry-run"
+		if !req.DryRun {
+			if password == "" {
+				random, err := utils.GenerateToken(32)
+				if err != nil {
+					s.logger.Error("Failed to generate password", "username", username, "error", err)
+					return nil, errors.NewBusinessError("PASSWORD_HASHING_FAILED", "password hashing failed", nil)
+				}
+				password = random
+			}
+
+			hash, err := s.passwordHasher.Hash(password)
+			if err != nil {
+				s.logger.Error("Failed to hash password", "username", username, "error", err)
+				return nil, errors.NewBusinessError("PASSWORD_HASHING_FAILED", "password hashing failed", nil)
+			}
+			passwordHash = hash
+		}
+
+		profile := &PlatformProfile{
+			ID:                uuid.New(),
+			Username:          username,
+			Email:             email,
+			PasswordHash:      passwordHash,
+			Status:            StatusPending,
+			CreatedAt:         now,
+			UpdatedByUserAt:   now,
+			UpdatedBySystemAt: now,
+		}
+		if req.Activate {
+			profile.Status = StatusActivated
+			profile.VerifiedAt = &now
+		}
+
+		profiles = append(profiles, profile)
+		positions = append(positions, i)
+	}
+
+	if len(profiles) > 0 {
+		created, err := s.repo.BulkCreateProfiles(ctx, profiles, req.DryRun)
+		if err != nil {
+			s.logger.Error("Bulk profile creation failed", "actor_id", actorID, "error", err)
+			return nil, errors.NewDatabaseError("bulk creating profiles", err)
+		}
+		mergeBulkResults(results, created, positions)
+	}
+
+	return s.summarizeBulk("create_profiles", actorID, req.DryRun, results), nil
+}
+
+// BulkUpdateStatus activates, suspends or locks many profiles at once
+func (s *service) BulkUpdateStatus(ctx context.Context, actorID uuid.UUID, req BulkStatusRequest) (*BulkResult, error) {
+	if req.Status != StatusActivated && req.Status != StatusSuspended && req.Status != StatusLocked {
+		return nil, errors.NewValidationError("status must be activated, suspended or locked", map[string]any{"field": "status"})
+	}
+
+	results, ids, positions, err := prepareBulkIDs(req.ProfileIDs, actorID, req.Status != StatusActivated)
+	if err != nil {
+		return nil, err
+	}
+	if ids, positions, err = s.scopeBulkIDs(ctx, results, ids, positions); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Starting bulk status change", "actor_id", actorID, "rows", len(req.ProfileIDs), "status", req.Status, "dry_run", req.DryRun)
+
+	if len(ids) > 0 {
+		updated, err := s.repo.BulkUpdateStatus(ctx, ids, req.Status, req.DryRun)
+		if err != nil {
+			s.logger.Error("Bulk status change failed", "actor_id", actorID, "error", err)
+			return nil, errors.NewDatabaseError("bulk updating profile status", err)
+		}
+		mergeBulkResults(results, updated, positions)
+	}
+
+	return s.summarizeBulk("update_status", actorID, req.DryRun, results), nil
+}
+
+// BulkAssignRole assigns a role to many profiles at once
+func (s *service) BulkAssignRole(ctx context.Context, actorID uuid.UUID, req BulkRoleRequest) (*BulkResult, error) {
+	if req.RoleID == uuid.Nil {
+		return nil, errors.NewValidationError("role is required", map[string]any{"field": "role_id"})
+	}
+
+	scope, err := req.Scope.Normalize()
+	if err != nil {
+		return nil, errors.NewValidationError("branch must have at most 7 characters and batch must be an admission year", map[string]any{"field": "scope"})
+	}
+	if err := s.ensureGrantable(ctx, actorID, req.RoleID, scope); err != nil {
+		return nil, err
+	}
+
+	results, ids, positions, err := prepareBulkIDs(req.ProfileIDs, actorID, false)
+	if err != nil {
+		return nil, err
+	}
+	if ids, positions, err = s.scopeBulkIDs(ctx, results, ids, positions); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Starting bulk role assignment", "actor_id", actorID, "rows", len(req.ProfileIDs), "role_id", req.RoleID, "dry_run", req.DryRun)
+
+	if len(ids) > 0 {
+		assigned, err := s.repo.BulkAssignRole(ctx, ids, req.RoleID, scope, req.DryRun)
+		if err != nil {
+			s.logger.Error("Bulk role assignment failed", "actor_id", actorID, "error", err)
+			return nil, errors.NewDatabaseError("bulk assigning role", err)
+		}
+		mergeBulkResults(results, assigned, positions)
+
+		if !req.DryRun {
+			s.roleService.InvalidateProfiles(ids...)
+		}
+	}
+
+	return s.summarizeBulk("assign_role", actorID, req.DryRun, results), nil
+}
+
+// BulkDeleteProfiles soft or hard deletes many profiles at once
+func (s *service) BulkDeleteProfiles(ctx context.Context, actorID uuid.UUID, req BulkDeleteRequest) (*BulkResult, error) {
+	results, ids, positions, err := prepareBulkIDs(req.ProfileIDs, actorID, true)
+	if err != nil {
+		return nil, err
+	}
+	if ids, positions, err = s.scopeBulkIDs(ctx, results, ids, positions); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Starting bulk profile deletion", "actor_id", actorID, "rows", len(req.ProfileIDs), "hard_delete", req.HardDelete, "dry_run", req.DryRun)
+
+	if len(ids) > 0 {
+		deleted, err := s.repo.BulkDeleteProfiles(ctx, ids, req.HardDelete, actorID, strings.TrimSpace(req.Reason), req.DryRun)
+		if err != nil {
+			s.logger.Error("Bulk profile deletion failed", "actor_id", actorID, "error", err)
+			return nil, errors.NewDatabaseError("bulk deleting profiles", err)
+		}
+		mergeBulkResults(results, deleted, positions)
+	}
+
+	return s.summarizeBulk("delete_profiles", actorID, req.DryRun, results), nil
+}
+
+// prepareBulkIDs reports repeated IDs, and the actor's own profile when protectActor is set, and returns
+// the remaining IDs along with their positions in the request
+func prepareBulkIDs(profileIDs []uuid.UUID, actorID uuid.UUID, protectActor bool) ([]BulkItemResult, []uuid.UUID, []int, error) {
+	if len(profileIDs) == 0 || len(profileIDs) > MaxBulkItems {
+		return nil, nil, nil, errors.NewValidationError(fmt.Sprintf("between 1 and %d profile IDs are required", MaxBulkItems), map[string]any{"field": "profile_ids"})
+	}
+
+	results := make([]BulkItemResult, len(profileIDs))
+	ids := make([]uuid.UUID, 0, len(profileIDs))
+	positions := make([]int, 0, len(profileIDs))
+	seen := make(map[uuid.UUID]bool, len(profileIDs))
+
+	for i, id := range profileIDs {
+		results[i] = BulkItemResult{Index: i, ProfileID: &profileIDs[i]}
+
+		switch {
+		case seen[id]:
+			results[i].Status = BulkItemSkipped
+			results[i].Reason = "duplicate of an earlier row"
+		case protectActor && id == actorID:
+			results[i].Status = BulkItemFailed
+			results[i].Reason = "administrators can't apply this to their own profile"
+		default:
+			seen[id] = true
+			ids = append(ids, id)
+			positions = append(positions, i)
+		}
+	}
+
+	return results, ids, positions, nil
+}
+
+// scopeBulkIDs fails the rows of profiles outside the caller's access scope the same way as missing
+// profiles, and returns the remaining IDs along with their positions in the request
+func (s *service) scopeBulkIDs(ctx context.Context, results []BulkItemResult, ids []uuid.UUID, positions []int) ([]uuid.UUID, []int, error) {
+	access := role.AccessScopeFromContext(ctx)
+	if access.Unrestricted || len(ids) == 0 {
+		return ids, positions, nil
+	}
+
+	inScope, err := s.repo.ProfilesInScope(ctx, ids, access)
+	if err != nil {
+		s.logger.Error("Failed to check profile scope", "error", err)
+		return nil, nil, errors.NewDatabaseError("checking profile scope", err)
+	}
+
+	allowed := make(map[uuid.UUID]bool, len(inScope))
+	for _, id := range inScope {
+		allowed[id] = true
+	}
+
+	scopedIDs := make([]uuid.UUID, 0, len(inScope))
+	scopedPositions := make([]int, 0, len(inScope))
+	for j, id := range ids {
+		if !allowed[id] {
+			results[positions[j]].Status = BulkItemFailed
+			results[positions[j]].Reason = "profile not found"
+			continue
+		}
+		scopedIDs = append(scopedIDs, id)
+		scopedPositions = append(scopedPositions, positions[j])
+	}
+
+	return scopedIDs, scopedPositions, nil
+}
+
+// ensureInScope checks that the profile belongs to a student within the caller's access scope, see
+// role.AccessScopeFromContext. Profiles outside of it are reported as not found.
+func (s *service) ensureInScope(ctx context.Context, profileID uuid.UUID) error {
+	access := role.AccessScopeFromContext(ctx)
+	if access.Unrestricted {
+		return nil
+	}
+
+	inScope, err := s.repo.ProfilesInScope(ctx, []uuid.UUID{profileID}, access)
+	if err != nil {
+		s.logger.Error("Failed to check profile scope", "profile_id", profileID, "error", err)
+		return errors.NewDatabaseError("checking profile scope", err)
+	}
+	if len(inScope) == 0 {
+		s.logger.Warn("Profile outside of the caller's scope", "profile_id", profileID)
+		return errors.NewNotFoundError("profile", profileID)
+	}
+
+	return nil
+}
+
+// ensureGrantable checks that the caller holds every permission of the role, including those it
+// inherits, in a scope covering the assignment. Callers can only hand out their own or inherited
+// roles, or roles granting less, and only within their access scope.
+func (s *service) ensureGrantable(ctx context.Context, actorID, roleID uuid.UUID, scope role.Scope) error {
+	if !role.AccessScopeFromContext(ctx).Covers(scope) {
+		s.logger.Warn("Role assignment beyond the caller's scope", "actor_id", actorID, "role_id", roleID, "scope", scope)
+		return errors.NewForbiddenError("the role can't be assigned beyond your access scope")
+	}
+
+	held, err := s.roleService.GetProfilePermissions(ctx, actorID)
+	if err != nil {
+		s.logger.Error("Failed to get caller permissions", "actor_id", actorID, "error", err)
+		return errors.NewDatabaseError("fetching permissions", err)
+	}
+
+	visited := make(map[uuid.UUID]bool)
+	for id := &roleID; id != nil && !visited[*id]; {
+		visited[*id] = true
+
+		rl, err := s.roleService.GetRoleByID(ctx, *id)
+		if err != nil {
+			s.logger.Error("Failed to get role", "role_id", *id, "error", err)
+			return errors.NewDatabaseError("fetching role", err)
+		}
+		if rl == nil {
+			return errors.NewNotFoundError("role", *id)
+		}
+
+		for _, permission := range rl.Permissions {
+			access, granted := held.AccessScope(permission.Resource, permission.Action)
+			if !granted || !access.Covers(scope) {
+				s.logger.Warn("Role assignment beyond the caller's permissions", "actor_id", actorID, "role_id", roleID, "permission", permission.Resource+":"+permission.Action)
+				return errors.NewForbiddenError("you can only assign roles whose permissions you hold")
+			}
+		}
+		id = rl.InheritsFrom
 	}
 
-	s.logger.Info("password successfully reset and token invalidated", "profileID", profile.ProfileID)
 	return nil
+}
+
+// mergeBulkResults places the repository results of the submitted rows at their positions in the request
+func mergeBulkResults(results []BulkItemResult, submitted []BulkItemResult, positions []int) {
+	for j, result := range submitted {
+		result.Index = positions[j]
+		if result.Username == "" {
+			result.Username = results[positions[j]].Username
+		}
+		results[positions[j]] = result
+	}
+}
+
+// summarizeBulk counts the outcomes of a bulk operation
+func (s *service) summarizeBulk(operation string, actorID uuid.UUID, dryRun bool, items []BulkItemResult) *BulkResult {
+	result := &BulkResult{DryRun: dryRun, Total: len(items), Items: items}
+	for _, item := range items {
+		switch item.Status {
+		case BulkItemSkipped:
+			result.Skipped++
+		case BulkItemFailed:
+			result.Failed++
+		default:
+			result.Succeeded++
+		}
+	}
 
+	s.logger.Info(
+		"Bulk operation completed",
+		"operation", operation,
+		"actor_id", actorID,
+		"dry_run", dryRun,
+		"succeeded", result.Succeeded,
+		"skipped", result.Skipped,
+		"failed", result.Failed,
+	)
+	return result
 }
 
-func (s *service) GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedProfileRequest) (*PlatformProfile, error) {
-	var profile *PlatformProfile
+// GetSoftDeletedProfile looks up a profile in the recovery bin by email or username
+func (s *service) GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedProfileRequest) (*DeletedProfile, error) {
+	var profile *DeletedProfile
 	var err error
 
 	// Check if request contains an email
 	if req.Email != nil {
 		s.logger.Debug("Fetching soft-deleted profile by email", "email", *req.Email)
 		profile, err = s.repo.GetSoftDeletedProfileByEmail(ctx, *req.Email)
-		if err == nil && profile != nil {
+		if err == nil && profile != nil && s.ensureInScope(ctx, profile.ID) == nil {
 			s.logger.Info("Soft-deleted profile found by email", "email", *req.Email)
-			return profile, nil
+			return s.binEntry(profile), nil
 		}
 		if err != nil {
 			s.logger.Warn("Error retrieving soft-deleted profile by email", "email", *req.Email, "error", err)
@@ -708,9 +2956,9 @@ func (s *service) GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedP
 	if req.Username != nil {
 		s.logger.Debug("Fetching soft-deleted profile by username", "username", *req.Username)
 		profile, err = s.repo.GetSoftDeletedProfileByUsername(ctx, *req.Username)
-		if err == nil && profile != nil {
+		if err == nil && profile != nil && s.ensureInScope(ctx, profile.ID) == nil {
 			s.logger.Info("Soft-deleted profile found by username", "username", *req.Username)
-			return profile, nil
+			return s.binEntry(profile), nil
 		}
 		if err != nil {
 			s.logger.Warn("Error retrieving soft-deleted profile by username", "username", *req.Username, "error", err)
@@ -725,22 +2973,82 @@ func (s *service) GetSoftDeletedProfile(ctx context.Context, req GetSoftDeletedP
 	})
 }
 
-// RestoreProfile restores a soft-deleted profile based on the given identifier (ID, username, or email)
-func (s *service) RestoreProfile(ctx context.Context, id uuid.UUID) error {
-	var profile *PlatformProfile
-	var err error
+// ListDeletedProfiles returns a page of the recovery bin, most recently deleted first
+func (s *service) ListDeletedProfiles(ctx context.Context, req ListDeletedProfilesRequest) (*DeletedProfileList, error) {
+	// Validate pagination parameters
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20 // Default page size
+	}
+
+	access := role.AccessScopeFromContext(ctx)
+	profiles, total, err := s.repo.ListSoftDeletedProfiles(ctx, strings.TrimSpace(req.Search), access, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		s.logger.Error("Failed to list soft-deleted profiles", "error", err)
+		return nil, errors.NewDatabaseError("listing soft-deleted profiles", err)
+	}
+
+	for _, profile := range profiles {
+		s.binEntry(profile)
+	}
+
+	return &DeletedProfileList{
+		Profiles: profiles,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// binEntry prepares a soft deleted profile for display: the password hash is removed and the
+// time it will be purged is filled in
+func (s *service) binEntry(profile *DeletedProfile) *DeletedProfile {
+	profile.PasswordHash = ""
+	profile.PurgeAt = profile.DeletedAt.Add(s.settings.DeletedProfileRetention)
+	return profile
+}
 
+// RestoreProfile moves a soft-deleted profile out of the recovery bin along with its student records.
+// It fails if its username or email has been taken in the meantime.
+func (s *service) RestoreProfile(ctx context.Context, id uuid.UUID) error {
 	s.logger.Debug("Restoring soft-deleted profile by ID", "id", id)
 
-	err = s.repo.RestoreSoftDeletedProfile(ctx, id)
+	if err := s.ensureInScope(ctx, id); err != nil {
+		return err
+	}
 
-	// Handle errors if the profile is not found or another issue occurs
+	deleted, err := s.repo.GetSoftDeletedProfile(ctx, id)
 	if err != nil {
-		if errors.IsNotFoundErrorDomain(err) {
-			s.logger.Warn("Profile not found in deleted_profiles", "error", err)
-			return errors.NewNotFoundError("profile", map[string]interface{}{"id": id})
-		}
-		s.logger.Error("Failed to get soft-deleted profile", "error", err)
+		s.logger.Error("Failed to get soft-deleted profile", "id", id, "error", err)
+		return errors.NewDatabaseError("fetching soft-deleted profile", err)
+	}
+	if deleted == nil {
+		s.logger.Warn("Profile not found in deleted_profiles", "id", id)
+		return errors.NewNotFoundError("SoftDeletedProfile", id)
+	}
+
+	// A new profile may have taken the username or email since the deletion
+	usernameTaken, err := s.repo.UsernameExists(ctx, deleted.Username)
+	if err != nil {
+		return errors.NewDatabaseError("checking username", err)
+	}
+	emailTaken, err := s.repo.EmailExists(ctx, deleted.Email)
+	if err != nil {
+		return errors.NewDatabaseError("checking email", err)
+	}
+	if usernameTaken || emailTaken {
+		s.logger.Warn("Soft-deleted profile conflicts with an existing profile", "id", id, "username_taken", usernameTaken, "email_taken", emailTaken)
+		return errors.NewBusinessError(
+			"PROFILE_RESTORE_CONFLICT",
+			"the username or email of the profile now belongs to another profile",
+			map[string]interface{}{"username_taken": usernameTaken, "email_taken": emailTaken},
+		)
+	}
+
+	if err := s.repo.RestoreSoftDeletedProfile(ctx, id); err != nil {
+		s.logger.Error("Failed to restore soft-deleted profile", "id", id, "error", err)
 		return errors.NewBusinessError(
 			"PROFILE_RESTORE_FAILED",
 			"failed to restore profile",
@@ -748,7 +3056,7 @@ func (s *service) RestoreProfile(ctx context.Context, id uuid.UUID) error {
 		)
 	}
 
-	s.logger.Info("Profile restored successfully", "id", profile.ID)
+	s.logger.Info("Profile restored successfully", "id", id, "username", deleted.Username)
 	return nil
 }
 
@@ -770,57 +3078,109 @@ func (s *service) HardDeleteProfile(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// AssignRole assigns a role to a profile
-func (s *service) AssignRole(ctx context.Context, profileID uuid.UUID, roleID uuid.UUID) error {
-	// Verify profile exists
-	_, err := s.repo.GetProfileByID(ctx, profileID)
-	if err != nil {
+// AssignRole assigns a role to a profile, limited to the scope unless it is the zero Scope
+func (s *service) AssignRole(ctx context.Context, actorID, profileID uuid.UUID, roleID uuid.UUID, scope role.Scope) error {
+	if _, err := s.repo.GetProfileByID(ctx, profileID); err != nil {
+		return errors.NewNotFoundError("profile", profileID)
+	}
+	if err := s.ensureInScope(ctx, profileID); err != nil {
 		return err
 	}
 
-	// Verify role exists
-	_, err = s.roleRepo.GetRoleByID(ctx, roleID)
+	scope, err := scope.Normalize()
 	if err != nil {
+		return errors.NewValidationError("branch must have at most 7 characters and batch must be an admission year", map[string]any{"field": "scope"})
+	}
+
+	if err := s.ensureGrantable(ctx, actorID, roleID, scope); err != nil {
 		return err
 	}
 
-	// Check if already assigned
-	hasRole, err := s.repo.HasRoleAssignment(ctx, profileID, roleID)
+	assigned, err := s.roleService.GetProfileRoles(ctx, profileID)
 	if err != nil {
-		return err
+		s.logger.Error("Failed to get profile roles", "profile_id", profileID, "error", err)
+		return errors.NewDatabaseError("fetching profile roles", err)
+	}
+	for _, existing := range assigned {
+		if existing.ID == roleID && existing.Scope == scope {
+			return errors.NewBusinessError("ROLE_ALREADY_ASSIGNED", "role already assigned to profile", map[string]interface{}{"profile_id": profileID, "role_id": roleID})
+		}
 	}
 
-	if hasRole {
-		return errors.NewDomainError("role already assigned to profile", errors.ValidationError)
+	// The role service drops the cached permissions of the profile
+	if err := s.roleService.AssignRoleToProfile(ctx, profileID, roleID, scope); err != nil {
+		s.logger.Error("Failed to assign role", "profile_id", profileID, "role_id", roleID, "error", err)
+		return errors.NewDatabaseError("assigning role", err)
 	}
 
-	return s.repo.AssignRole(ctx, profileID, roleID)
+	return nil
 }
 
-// RemoveRole removes a role from a profile
+// RemoveRole removes a role from a profile, in every scope it is assigned in
 func (s *service) RemoveRole(ctx context.Context, profileID uuid.UUID, roleID uuid.UUID) error {
-	// Verify assignment exists
-	hasRole, err := s.repo.HasRoleAssignment(ctx, profileID, roleID)
-	if err != nil {
+	if err := s.ensureInScope(ctx, profileID); err != nil {
 		return err
 	}
 
+	hasRole, err := s.hasRoleAssignment(ctx, profileID, roleID)
+	if err != nil {
+		return err
+	}
 	if !hasRole {
-		return errors.NewDomainError("role not assigned to profile", errors.ValidationError)
+		return errors.NewBusinessError("ROLE_NOT_ASSIGNED", "role not assigned to profile", map[string]interface{}{"profile_id": profileID, "role_id": roleID})
+	}
+
+	if err := s.roleService.RemoveRoleFromProfile(ctx, profileID, roleID); err != nil {
+		s.logger.Error("Failed to remove role", "profile_id", profileID, "role_id", roleID, "error", err)
+		return errors.NewDatabaseError("removing role", err)
 	}
 
-	return s.repo.RemoveRole(ctx, profileID, roleID)
+	return nil
 }
 
 // HasRole checks if a profile has a specific role by name
 func (s *service) HasRole(ctx context.Context, profileID uuid.UUID, roleName string) (bool, error) {
-	// Get role ID from name
-	role, err := s.roleRepo.GetRoleByName(ctx, roleName)
+	roles, err := s.repo.GetProfileRoleNames(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to get profile role names", "profile_id", profileID, "error", err)
+		return false, errors.NewDatabaseError("fetching profile roles", err)
+	}
+
+	for _, name := range roles {
+		if name == roleName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// hasRoleAssignment checks if a role is assigned to a profile
+func (s *service) hasRoleAssignment(ctx context.Context, profileID, roleID uuid.UUID) (bool, error) {
+	roleIDs, err := s.repo.GetProfileRoles(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to get profile roles", "profile_id", profileID, "error", err)
+		return false, errors.NewDatabaseError("fetching profile roles", err)
+	}
+
+	for _, id := range roleIDs {
+		if id == roleID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetRoleNames retrieves the names of the roles assigned to a profile
+func (s *service) GetRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error) {
+	roles, err := s.repo.GetProfileRoleNames(ctx, profileID)
 	if err != nil {
-		return false, err
+		s.logger.Error("Failed to get profile role names", "profile_id", profileID, "error", err)
+		return nil, errors.NewDatabaseError("fetching profile roles", err)
 	}
 
-	return s.repo.HasRoleAssignment(ctx, profileID, role.ID)
+	return roles, nil
 }
 
 // GetPreferences retrieves a profile's preferences
@@ -837,6 +3197,29 @@ func (s *service) GetPreferences(ctx context.Context, profileID uuid.UUID) (*Pro
 	return prefs, nil
 }
 
+// ResetPreferencesToDefault replaces a profile's preferences with the defaults of new profiles
+func (s *service) ResetPreferencesToDefault(ctx context.Context, profileID uuid.UUID) (*ProfilePreference, error) {
+	prefs := defaultPreferences(profileID, time.Now())
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		s.logger.Error("failed to reset preferences", "profileID", profileID, "error", err)
+		return nil, errors.NewDatabaseError("resetting preferences", err)
+	}
+
+	s.logger.Info("preferences reset to default", "profileID", profileID)
+	return prefs, nil
+}
+
+// defaultPreferences returns the preferences every profile starts with
+func defaultPreferences(profileID uuid.UUID, now time.Time) *ProfilePreference {
+	return &ProfilePreference{
+		ID:                 uuid.New(),
+		ProfileID:          profileID,
+		NotificationsEmail: true,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
+
 // UpdatePreferences updates a profile's preferences
 func (s *service) UpdatePreferences(ctx context.Context, profileID uuid.UUID, prefs ProfilePreference) error {
 
@@ -855,6 +3238,46 @@ func (s *service) UpdatePreferences(ctx context.Context, profileID uuid.UUID, pr
 	return nil
 }
 
+// ListProfiles lists the profiles within the caller's access scope. Coordinators whose roles are
+// scoped to a branch or batch only see the profiles of those students.
+func (s *service) ListProfiles(ctx context.Context, req ListProfilesRequest) (*ProfileList, error) {
+	// Validate pagination parameters
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20 // Default page size
+	}
+
+	filters := map[string]interface{}{"scope": role.AccessScopeFromContext(ctx)}
+	if search := strings.TrimSpace(req.Search); search != "" {
+		filters["search"] = search
+	}
+	if req.Status != "" {
+		filters["status"] = req.Status
+	}
+	if req.RoleID != nil {
+		filters["role_id"] = *req.RoleID
+	}
+
+	profiles, total, err := s.repo.GetProfiles(ctx, (req.Page-1)*req.PageSize, req.PageSize, filters)
+	if err != nil {
+		s.logger.Error("Failed to list profiles", "error", err)
+		return nil, errors.NewDatabaseError("listing profiles", err)
+	}
+
+	for _, profile := range profiles {
+		profile.PasswordHash = ""
+	}
+
+	return &ProfileList{
+		Profiles: profiles,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
 // SearchProfiles searches for profiles matching a query string
 func (s *service) SearchProfiles(ctx context.Context, query string, page, pageSize int) ([]*PlatformProfile, int, error) {
 	// Validate pagination parameters
@@ -865,10 +3288,15 @@ func (s *service) SearchProfiles(ctx context.Context, query string, page, pageSi
 		pageSize = 20 // Default page size
 	}
 
-	// Search profiles
-	profiles, total, err := s.repo.SearchProfiles(ctx, query, page, pageSize)
+	// Search profiles by name, username or email
+	filters := map[string]interface{}{
+		"search": query,
+		"scope":  role.AccessScopeFromContext(ctx),
+	}
+	profiles, total, err := s.repo.GetProfiles(ctx, (page-1)*pageSize, pageSize, filters)
 	if err != nil {
-		return nil, 0, err
+		s.logger.Error("Failed to search profiles", "query", query, "error", err)
+		return nil, 0, errors.NewDatabaseError("searching profiles", err)
 	}
 
 	// Remove password hashes from results
@@ -882,9 +3310,13 @@ func (s *service) SearchProfiles(ctx context.Context, query string, page, pageSi
 // ListProfilesByRole retrieves profiles that have a specific role
 func (s *service) ListProfilesByRole(ctx context.Context, roleID uuid.UUID, page, pageSize int) ([]*PlatformProfile, int, error) {
 	// Verify role exists
-	_, err := s.roleRepo.GetRoleByID(ctx, roleID)
+	rl, err := s.roleService.GetRoleByID(ctx, roleID)
 	if err != nil {
-		return nil, 0, err
+		s.logger.Error("Failed to get role", "role_id", roleID, "error", err)
+		return nil, 0, errors.NewDatabaseError("fetching role", err)
+	}
+	if rl == nil {
+		return nil, 0, errors.NewNotFoundError("role", roleID)
 	}
 
 	// Validate pagination parameters
@@ -895,10 +3327,15 @@ func (s *service) ListProfilesByRole(ctx context.Context, roleID uuid.UUID, page
 		pageSize = 20 // Default page size
 	}
 
-	// Get profiles by role
-	profiles, total, err := s.repo.GetProfilesByRole(ctx, roleID, page, pageSize)
+	// Get profiles by role within the caller's scope
+	filters := map[string]interface{}{
+		"role_id": roleID,
+		"scope":   role.AccessScopeFromContext(ctx),
+	}
+	profiles, total, err := s.repo.GetProfiles(ctx, (page-1)*pageSize, pageSize, filters)
 	if err != nil {
-		return nil, 0, err
+		s.logger.Error("Failed to list profiles by role", "role_id", roleID, "error", err)
+		return nil, 0, errors.NewDatabaseError("listing profiles by role", err)
 	}
 
 	// Remove password hashes from results
@@ -908,3 +3345,63 @@ func (s *service) ListProfilesByRole(ctx context.Context, roleID uuid.UUID, page
 
 	return profiles, total, nil
 }
+
+// describeDevice derives a short, human readable device description from a user agent,
+// such as "Chrome on Windows", for the session list
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "dart"):
+		browser = "Mobile app"
+	}
+
+	platform := "unknown device"
+	switch {
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	return browser + " on " + platform
+}
+
+// resetTokenLength is the number of random bytes in a password reset token
+const resetTokenLength = 32
+
+// recoveryCodeLength is the number of hex characters in a recovery code
+const recoveryCodeLength = 10
+
+// normalizeRecoveryCode strips the separator and whitespace users may type along with a recovery code
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// hashOpaqueToken hashes a random token for storage and lookup
+func hashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,481 @@
+package platform_profile
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"server/internal/common/errors"
+	"server/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository keeps the state the tested flows touch in memory. Every other repository
+// method panics through the embedded nil interface.
+type fakeRepository struct {
+	Repository
+
+	profiles      map[uuid.UUID]*PlatformProfile
+	sessions      map[uuid.UUID]*Session
+	refreshTokens map[string]*RefreshToken
+	mfaSteps      map[uuid.UUID]int64
+
+	failedLogins map[uuid.UUID]int
+	lockCounts   map[uuid.UUID]int
+	lockedUntil  map[uuid.UUID]time.Time
+
+	// loseConsumeRace makes ConsumeRefreshToken report that another request used the token first
+	loseConsumeRace bool
+
+	createdTokens   []*RefreshToken
+	revokedFamilies []uuid.UUID
+	touchedSessions []uuid.UUID
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		profiles:      make(map[uuid.UUID]*PlatformProfile),
+		sessions:      make(map[uuid.UUID]*Session),
+		refreshTokens: make(map[string]*RefreshToken),
+		mfaSteps:      make(map[uuid.UUID]int64),
+		failedLogins:  make(map[uuid.UUID]int),
+		lockCounts:    make(map[uuid.UUID]int),
+		lockedUntil:   make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (r *fakeRepository) GetProfileByID(ctx context.Context, id uuid.UUID) (*PlatformProfile, error) {
+	profile, ok := r.profiles[id]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found", id)
+	}
+
+	return profile, nil
+}
+
+func (r *fakeRepository) GetProfileRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error) {
+	return []string{"student"}, nil
+}
+
+func (r *fakeRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	r.refreshTokens[token.TokenHash] = token
+	r.createdTokens = append(r.createdTokens, token)
+	return nil
+}
+
+func (r *fakeRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return nil, fmt.Errorf("refresh token not found")
+	}
+	copied := *token
+
+	return &copied, nil
+}
+
+func (r *fakeRepository) ConsumeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	if r.loseConsumeRace {
+		return false, nil
+	}
+	for _, token := range r.refreshTokens {
+		if token.ID == id && token.UsedAt == nil {
+			now := time.Now()
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revokedFamilies = append(r.revokedFamilies, familyID)
+	return nil
+}
+
+func (r *fakeRepository) GetSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+
+	return session, nil
+}
+
+func (r *fakeRepository) TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	r.touchedSessions = append(r.touchedSessions, id)
+	return nil
+}
+
+func (r *fakeRepository) UpdateMFALastUsedStep(ctx context.Context, profileID uuid.UUID, step int64) (bool, error) {
+	if step <= r.mfaSteps[profileID] {
+		return false, nil
+	}
+	r.mfaSteps[profileID] = step
+
+	return true, nil
+}
+
+func (r *fakeRepository) IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	r.failedLogins[id]++
+	return r.failedLogins[id], nil
+}
+
+func (r *fakeRepository) GetAccountLockout(ctx context.Context, profileID uuid.UUID) (*AccountLockout, error) {
+	return &AccountLockout{ProfileID: profileID, LockCount: r.lockCounts[profileID]}, nil
+}
+
+func (r *fakeRepository) LockProfile(ctx context.Context, profileID uuid.UUID, lockedUntil time.Time) error {
+	r.lockCounts[profileID]++
+	r.lockedUntil[profileID] = lockedUntil
+	return nil
+}
+
+// fakeTokenProvider issues predictable tokens whose hash is the token with a prefix
+type fakeTokenProvider struct {
+	TokenProvider
+
+	issued int
+}
+
+func (p *fakeTokenProvider) GenerateAccessToken(profileID uuid.UUID, sessionID uuid.UUID, roles []string) (string, time.Time, error) {
+	return "access-" + sessionID.String(), time.Now().Add(time.Minute), nil
+}
+
+func (p *fakeTokenProvider) GenerateRefreshToken() (string, string, time.Time, error) {
+	p.issued++
+	token := fmt.Sprintf("refresh-%d", p.issued)
+
+	return token, p.HashRefreshToken(token), time.Now().Add(time.Hour), nil
+}
+
+func (p *fakeTokenProvider) HashRefreshToken(token string) string {
+	return "hash:" + token
+}
+
+// fakeOTPProvider accepts the codes it knows and reports the time step they belong to
+type fakeOTPProvider struct {
+	OTPProvider
+
+	steps map[string]int64
+}
+
+func (p *fakeOTPProvider) Validate(secret, code string) (int64, bool) {
+	step, ok := p.steps[code]
+	return step, ok
+}
+
+func newTestService(repo Repository, settings SecuritySettings) *service {
+	return &service{
+		repo:          repo,
+		tokenProvider: &fakeTokenProvider{},
+		settings:      settings,
+		logger:        *logger.NewLogger(),
+	}
+}
+
+func TestRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	for _, tc := range []struct {
+		name    string
+		setup   func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile)
+		present string
+		// wantRevoked is whether the whole token family must be revoked
+		wantRevoked bool
+		wantErr     bool
+	}{
+		{
+			name:    "rotates an unused token",
+			present: "presented",
+		},
+		{
+			name:    "rejects an unknown token",
+			present: "unknown",
+			wantErr: true,
+		},
+		{
+			name: "revokes the family when a used token is presented again",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				token.UsedAt = &past
+			},
+			present:     "presented",
+			wantRevoked: true,
+			wantErr:     true,
+		},
+		{
+			name: "revokes the family when another request consumed the token first",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				repo.loseConsumeRace = true
+			},
+			present:     "presented",
+			wantRevoked: true,
+			wantErr:     true,
+		},
+		{
+			name: "rejects a revoked token",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				token.RevokedAt = &past
+			},
+			present: "presented",
+			wantErr: true,
+		},
+		{
+			name: "rejects an expired token",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				token.ExpiresAt = past
+			},
+			present: "presented",
+			wantErr: true,
+		},
+		{
+			name: "revokes the family of an ended session",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				session.RevokedAt = &past
+			},
+			present:     "presented",
+			wantRevoked: true,
+			wantErr:     true,
+		},
+		{
+			name: "revokes the family of a suspended profile",
+			setup: func(repo *fakeRepository, token *RefreshToken, session *Session, profile *PlatformProfile) {
+				profile.Status = StatusSuspended
+			},
+			present:     "presented",
+			wantRevoked: true,
+			wantErr:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			profile := &PlatformProfile{ID: uuid.New(), Status: StatusActivated}
+			session := &Session{ID: uuid.New(), ProfileID: profile.ID, ExpiresAt: time.Now().Add(time.Hour)}
+			token := &RefreshToken{
+				ID:        uuid.New(),
+				ProfileID: profile.ID,
+				FamilyID:  session.ID,
+				TokenHash: "hash:presented",
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if tc.setup != nil {
+				tc.setup(repo, token, session, profile)
+			}
+			repo.profiles[profile.ID] = profile
+			repo.sessions[session.ID] = session
+			repo.refreshTokens[token.TokenHash] = token
+
+			tokens, err := newTestService(repo, SecuritySettings{}).RefreshTokens(ctx, RefreshTokenRequest{RefreshToken: tc.present})
+
+			revoked := len(repo.revokedFamilies) == 1 && repo.revokedFamilies[0] == session.ID
+			if revoked != tc.wantRevoked || len(repo.revokedFamilies) > 1 {
+				t.Fatalf("expected family revoked %v, got revoked families %v", tc.wantRevoked, repo.revokedFamilies)
+			}
+
+			if tc.wantErr {
+				var domainErr *errors.DomainError
+				if !stderrors.As(err, &domainErr) || domainErr.Type != errors.UnauthorizedError {
+					t.Fatalf("expected an unauthorized error, got %v", err)
+				}
+				if len(repo.createdTokens) != 0 {
+					t.Fatalf("expected no token to be issued, got %d", len(repo.createdTokens))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RefreshTokens failed: %v", err)
+			}
+
+			if token.UsedAt == nil {
+				t.Fatalf("expected the presented token to be marked as used")
+			}
+			if len(repo.createdTokens) != 1 {
+				t.Fatalf("expected one new refresh token, got %d", len(repo.createdTokens))
+			}
+			created := repo.createdTokens[0]
+			if created.FamilyID != session.ID || created.ProfileID != profile.ID {
+				t.Fatalf("expected the new token to continue family %s, got %+v", session.ID, created)
+			}
+			if tokens.RefreshToken == tc.present || created.TokenHash != "hash:"+tokens.RefreshToken {
+				t.Fatalf("expected a new refresh token matching the stored hash, got %q", tokens.RefreshToken)
+			}
+			if len(repo.touchedSessions) != 1 || repo.touchedSessions[0] != session.ID {
+				t.Fatalf("expected session %s to be touched, got %v", session.ID, repo.touchedSessions)
+			}
+
+			// The rotated token must not be accepted again
+			if _, err := newTestService(repo, SecuritySettings{}).RefreshTokens(ctx, RefreshTokenRequest{RefreshToken: tc.present}); err == nil {
+				t.Fatalf("expected the rotated token to be rejected")
+			}
+			if len(repo.revokedFamilies) != 1 {
+				t.Fatalf("expected reuse of the rotated token to revoke the family")
+			}
+		})
+	}
+}
+
+func TestAcceptTOTPRejectsReplays(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository()
+	service := newTestService(repo, SecuritySettings{})
+	service.otpProvider = &fakeOTPProvider{steps: map[string]int64{"100000": 10, "090000": 9, "110000": 11}}
+	mfa := &ProfileMFA{ProfileID: uuid.New(), Secret: "secret", Enabled: true}
+
+	// The cases run in order against the same profile
+	for _, tc := range []struct {
+		name     string
+		code     string
+		want     bool
+		wantStep int64
+	}{
+		{name: "accepts a fresh code", code: "100000", want: true, wantStep: 10},
+		{name: "rejects the same code again", code: "100000", want: false, wantStep: 10},
+		{name: "rejects a code of an earlier step", code: "090000", want: false, wantStep: 10},
+		{name: "rejects an invalid code", code: "123456", want: false, wantStep: 10},
+		{name: "accepts a code of a later step", code: "110000", want: true, wantStep: 11},
+	} {
+		if got := service.acceptTOTP(ctx, mfa, tc.code); got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+		if repo.mfaSteps[mfa.ProfileID] != tc.wantStep {
+			t.Fatalf("%s: expected last used step %d, got %d", tc.name, tc.wantStep, repo.mfaSteps[mfa.ProfileID])
+		}
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	service := newTestService(newFakeRepository(), SecuritySettings{
+		LockoutBaseDuration: 15 * time.Minute,
+		LockoutMaxDuration:  2 * time.Hour,
+	})
+
+	for _, tc := range []struct {
+		previousLocks int
+		want          time.Duration
+	}{
+		{previousLocks: 0, want: 15 * time.Minute},
+		{previousLocks: 1, want: 30 * time.Minute},
+		{previousLocks: 2, want: time.Hour},
+		{previousLocks: 3, want: 2 * time.Hour},
+		{previousLocks: 4, want: 2 * time.Hour},
+		{previousLocks: 1000, want: 2 * time.Hour},
+	} {
+		if got := service.lockoutDuration(tc.previousLocks); got != tc.want {
+			t.Fatalf("expected %s after %d locks, got %s", tc.want, tc.previousLocks, got)
+		}
+	}
+}
+
+func TestRecordFailedLogin(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.NewUnauthorizedError("invalid credentials")
+
+	for _, tc := range []struct {
+		name          string
+		failedLogins  int
+		previousLocks int
+		wantLockedFor time.Duration
+	}{
+		{name: "below the threshold", failedLogins: 1},
+		{name: "first lock", failedLogins: 2, wantLockedFor: 15 * time.Minute},
+		{name: "doubles for a repeated lock", failedLogins: 2, previousLocks: 2, wantLockedFor: time.Hour},
+		{name: "capped at the maximum", failedLogins: 2, previousLocks: 5, wantLockedFor: 2 * time.Hour},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			profile := &PlatformProfile{ID: uuid.New(), FailedLoginAttempts: tc.failedLogins}
+			repo.failedLogins[profile.ID] = tc.failedLogins
+			repo.lockCounts[profile.ID] = tc.previousLocks
+			service := newTestService(repo, SecuritySettings{
+				LockoutThreshold:    3,
+				LockoutBaseDuration: 15 * time.Minute,
+				LockoutMaxDuration:  2 * time.Hour,
+			})
+
+			before := time.Now()
+			err := service.recordFailedLogin(ctx, profile, failure)
+
+			lockedUntil, locked := repo.lockedUntil[profile.ID]
+			if tc.wantLockedFor == 0 {
+				if locked || err != failure {
+					t.Fatalf("expected the failure without a lock, got %v locked until %v", err, lockedUntil)
+				}
+				return
+			}
+
+			var domainErr *errors.DomainError
+			if !stderrors.As(err, &domainErr) || domainErr.Code != "ACCOUNT_LOCKED" {
+				t.Fatalf("expected an ACCOUNT_LOCKED error, got %v", err)
+			}
+			if !locked || lockedUntil.Before(before.Add(tc.wantLockedFor)) || lockedUntil.After(time.Now().Add(tc.wantLockedFor)) {
+				t.Fatalf("expected a lock of %s, got locked until %v", tc.wantLockedFor, lockedUntil)
+			}
+		})
+	}
+}
+
+func TestNormalizeAllowedIPs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", entries: nil, want: []string{}},
+		{name: "single IPv4 address", entries: []string{" 203.0.113.7 "}, want: []string{"203.0.113.7/32"}},
+		{name: "single IPv6 address", entries: []string{"2001:DB8::1"}, want: []string{"2001:db8::1/128"}},
+		{name: "masks the host bits of a range", entries: []string{"10.1.2.3/8"}, want: []string{"10.0.0.0/8"}},
+		{name: "IPv6 range", entries: []string{"2001:db8::/32"}, want: []string{"2001:db8::/32"}},
+		{name: "mixed", entries: []string{"192.168.0.0/16", "127.0.0.1"}, want: []string{"192.168.0.0/16", "127.0.0.1/32"}},
+		{name: "hostname", entries: []string{"example.com"}, wantErr: true},
+		{name: "invalid mask", entries: []string{"10.0.0.0/33"}, wantErr: true},
+		{name: "empty entry", entries: []string{"127.0.0.1", ""}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeAllowedIPs(tc.entries)
+			if tc.wantErr {
+				var domainErr *errors.DomainError
+				if !stderrors.As(err, &domainErr) || domainErr.Type != errors.ValidationError {
+					t.Fatalf("expected a validation error, got %v, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeAllowedIPs failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	allowlist := []string{"10.0.0.0/8", "203.0.113.7/32", "2001:db8::/32"}
+
+	for _, tc := range []struct {
+		name      string
+		allowlist []string
+		ip        string
+		want      bool
+	}{
+		{name: "empty allowlist", ip: "198.51.100.1", want: true},
+		{name: "inside a range", allowlist: allowlist, ip: "10.20.30.40", want: true},
+		{name: "single address", allowlist: allowlist, ip: "203.0.113.7", want: true},
+		{name: "next to a single address", allowlist: allowlist, ip: "203.0.113.8"},
+		{name: "inside an IPv6 range", allowlist: allowlist, ip: "2001:db8::42", want: true},
+		{name: "outside every range", allowlist: allowlist, ip: "198.51.100.1"},
+		{name: "unparsable address", allowlist: allowlist, ip: "unknown"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ipAllowed(tc.allowlist, tc.ip); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
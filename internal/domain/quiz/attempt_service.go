@@ -0,0 +1,579 @@
+// internal/domain/quiz/attempt_service.go
+
+package quiz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/quiz/question"
+	"server/pkg/logger"
+)
+
+// AttemptService runs timed attempts of quizzes. Every attempt gets its own paper and a deadline
+// set by the server, answers are saved one question at a time while it runs and attempts left in
+// progress past the deadline are submitted automatically.
+type AttemptService interface {
+	// StartAttempt starts an attempt of a published quiz and returns its paper without the answers
+	StartAttempt(ctx context.Context, quizID int64, studentID uuid.UUID) (*AttemptDetails, error)
+	// SaveAnswer saves the answer to a question of the student's attempt, replacing any earlier one
+	SaveAnswer(ctx context.Context, attemptID int64, studentID uuid.UUID, questionID int64, req SaveAnswerRequest) (*SavedAnswer, error)
+	// SubmitAttempt grades the saved answers of the student's attempt along with the submitted ones
+	SubmitAttempt(ctx context.Context, attemptID int64, studentID uuid.UUID, answers []AttemptAnswer) (*AttemptDetails, error)
+	// ListAttempts retrieves the attempts of a student, the latest first
+	ListAttempts(ctx context.Context, studentID uuid.UUID) ([]*Attempt, error)
+	// GetAttemptDetails retrieves an attempt of the student with its questions and answers
+	GetAttemptDetails(ctx context.Context, attemptID int64, studentID uuid.UUID) (*AttemptDetails, error)
+	// SubmitExpiredAttempts submits up to limit attempts left in progress past their deadline and
+	// returns how many were submitted
+	SubmitExpiredAttempts(ctx context.Context, limit int) (int, error)
+	// RegradeAttempts grades again the answers of up to limit submitted attempts that the runner
+	// failed to grade, and returns how many attempts are now fully graded
+	RegradeAttempts(ctx context.Context, limit int) (int, error)
+}
+
+// Expired attempts that fail to be submitted, and submitted attempts whose answers fail to be
+// graded again, are left out of the sweeps for sweepRetryDelay, doubled with every failure up to
+// maxSweepRetryDelay
+const (
+	sweepRetryDelay    = time.Minute
+	maxSweepRetryDelay = time.Hour
+)
+
+type attemptService struct {
+	repo   Repository
+	runner question.Runner
+	policy AttemptPolicy
+	logger logger.Logger
+}
+
+// NewAttemptService creates a new attempt service. The runner grades the answers to code questions.
+func NewAttemptService(repo Repository, runner question.Runner, policy AttemptPolicy, logger logger.Logger) AttemptService {
+	return &attemptService{
+		repo:   repo,
+		runner: runner,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// StartAttempt starts an attempt of a published quiz, due when the time limit of the quiz runs out
+func (s *attemptService) StartAttempt(ctx context.Context, quizID int64, studentID uuid.UUID) (*AttemptDetails, error) {
+	quiz, err := s.getQuiz(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	if !quiz.Published {
+		return nil, ErrQuizNotPublished
+	}
+
+	paper, seed, err := s.newPaper(ctx, quiz)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	attempt := &Attempt{
+		QuizID:    quizID,
+		StudentID: studentID,
+		Status:    AttemptInProgress,
+		StartTime: now,
+		Deadline:  now.Add(time.Duration(quiz.TimeLimitMinutes) * time.Minute),
+		MaxScore:  totalPoints(paper),
+		PaperSeed: seed,
+	}
+	if err := s.repo.CreateAttempt(ctx, attempt, paper, quiz.MaxConcurrentAttempts); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Quiz attempt started", "attempt_id", attempt.ID, "quiz_id", quizID, "student_id", studentID, "deadline", attempt.Deadline)
+	return newAttemptDetails(attempt, quiz, withoutAnswers(paper), []GradedAnswer{}, now), nil
+}
+
+// SaveAnswer saves an answer while the attempt is open. Saving the same question again replaces
+// the answer, so clients can retry saves freely.
+func (s *attemptService) SaveAnswer(ctx context.Context, attemptID int64, studentID uuid.UUID, questionID int64, req SaveAnswerRequest) (*SavedAnswer, error) {
+	attempt, err := s.getOwnAttempt(ctx, attemptID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Status != AttemptInProgress {
+		return nil, ErrAttemptSubmitted
+	}
+
+	now := time.Now()
+	if !s.isOpen(attempt, now) {
+		return nil, ErrAttemptExpired
+	}
+
+	saved, err := s.repo.SaveAnswer(ctx, attemptID, questionID, req.Answer, now, now.Add(-s.policy.GracePeriod))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save answer: %w", err)
+	}
+	if !saved {
+		// The attempt was submitted or expired in the meantime, or the question is not on the paper
+		attempt, err = s.getOwnAttempt(ctx, attemptID, studentID)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case attempt.Status != AttemptInProgress:
+			return nil, ErrAttemptSubmitted
+		case !s.isOpen(attempt, time.Now()):
+			return nil, ErrAttemptExpired
+		default:
+			return nil, ErrQuestionNotFound
+		}
+	}
+
+	return &SavedAnswer{
+		QuestionID:       questionID,
+		SavedAt:          now,
+		Deadline:         attempt.Deadline,
+		RemainingSeconds: remainingSeconds(attempt, now),
+	}, nil
+}
+
+// SubmitAttempt grades the attempt. Submitted answers replace the saved answers to the same
+// questions. Past the deadline and its grace period, the submitted answers are ignored and the
+// attempt is graded on its saved answers, as it would have been automatically.
+func (s *attemptService) SubmitAttempt(ctx context.Context, attemptID int64, studentID uuid.UUID, answers []AttemptAnswer) (*AttemptDetails, error) {
+	attempt, err := s.getOwnAttempt(ctx, attemptID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Status != AttemptInProgress {
+		return nil, ErrAttemptSubmitted
+	}
+
+	if !s.isOpen(attempt, time.Now()) {
+		return s.submit(ctx, attempt, nil, true)
+	}
+	return s.submit(ctx, attempt, answers, false)
+}
+
+// ListAttempts retrieves the attempts of a student
+func (s *attemptService) ListAttempts(ctx context.Context, studentID uuid.UUID) ([]*Attempt, error) {
+	attempts, err := s.repo.ListAttempts(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// GetAttemptDetails retrieves an attempt of the student. The answers to the questions are only
+// shown once the attempt is submitted. An expired attempt the sweeper has not reached yet is
+// submitted first.
+func (s *attemptService) GetAttemptDetails(ctx context.Context, attemptID int64, studentID uuid.UUID) (*AttemptDetails, error) {
+	attempt, err := s.getOwnAttempt(ctx, attemptID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == AttemptInProgress && !s.isOpen(attempt, time.Now()) {
+		details, err := s.submit(ctx, attempt, nil, true)
+		if !errors.Is(err, ErrAttemptSubmitted) {
+			return details, err
+		}
+
+		// Submitted concurrently, show the attempt as it was submitted
+		if attempt, err = s.getOwnAttempt(ctx, attemptID, studentID); err != nil {
+			return nil, err
+		}
+	}
+
+	quiz, err := s.getQuiz(ctx, attempt.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := s.repo.GetPaper(ctx, attempt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper: %w", err)
+	}
+
+	answers, err := s.repo.GetAttemptAnswers(ctx, attemptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt answers: %w", err)
+	}
+
+	if attempt.Status == AttemptInProgress {
+		paper = withoutAnswers(paper)
+	}
+
+	return newAttemptDetails(attempt, quiz, paper, answers, time.Now()), nil
+}
+
+// SubmitExpiredAttempts submits a batch of expired attempts on their saved answers. Attempts that
+// fail to be submitted are postponed, so that they don't come first in every batch.
+func (s *attemptService) SubmitExpiredAttempts(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	attempts, err := s.repo.ListExpiredAttempts(ctx, now.Add(-s.policy.GracePeriod), now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired attempts: %w", err)
+	}
+
+	submitted := 0
+	for _, attempt := range attempts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if _, err := s.submit(ctx, attempt, nil, true); err != nil {
+			if errors.Is(err, ErrAttemptSubmitted) || ctx.Err() != nil {
+				continue
+			}
+
+			s.logger.Error("Failed to submit expired attempt", "attempt_id", attempt.ID, "error", err)
+			if err := s.repo.PostponeSweep(ctx, attempt.ID, time.Now(), sweepRetryDelay, maxSweepRetryDelay); err != nil {
+				return submitted, fmt.Errorf("failed to postpone expired attempt: %w", err)
+			}
+			continue
+		}
+		submitted++
+	}
+
+	return submitted, nil
+}
+
+// submit grades the saved answers of an attempt in progress, overridden by the submitted ones,
+// and records the score. Automatic submissions end at the deadline.
+func (s *attemptService) submit(ctx context.Context, attempt *Attempt, submitted []AttemptAnswer, automatic bool) (*AttemptDetails, error) {
+	quiz, err := s.getQuiz(ctx, attempt.QuizID)
+	if err != nil {
+		return nil, err
+	}
+
+	paper, err := s.repo.GetPaper(ctx, attempt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper: %w", err)
+	}
+
+	saved, err := s.repo.GetAttemptAnswers(ctx, attempt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt answers: %w", err)
+	}
+
+	answers := submitted
+	answered := make(map[int64]bool, len(submitted))
+	for _, answer := range submitted {
+		answered[answer.QuestionID] = true
+	}
+	for _, answer := range saved {
+		if !answered[answer.QuestionID] {
+			answers = append(answers, AttemptAnswer{QuestionID: answer.QuestionID, Answer: answer.Answer})
+		}
+	}
+
+	graded, err := s.gradeAnswers(ctx, paper, answers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	end := now
+	if automatic && attempt.Deadline.Before(now) {
+		end = attempt.Deadline
+	}
+	attempt.Status = AttemptSubmitted
+	attempt.EndTime = &end
+	attempt.AutoSubmitted = automatic
+	score(attempt, quiz, graded)
+
+	ok, err := s.repo.SubmitAttempt(ctx, attempt, graded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit attempt: %w", err)
+	}
+	if !ok {
+		return nil, ErrAttemptSubmitted
+	}
+
+	s.logger.Info(
+		"Quiz attempt submitted",
+		"attempt_id", attempt.ID,
+		"quiz_id", attempt.QuizID,
+		"student_id", attempt.StudentID,
+		"automatic", automatic,
+		"score", attempt.Score,
+		"max_score", attempt.MaxScore,
+	)
+	return newAttemptDetails(attempt, quiz, paper, graded, now), nil
+}
+
+// RegradeAttempts grades again the answers flagged when the runner failed to grade them. Attempts
+// whose answers still fail to be graded are postponed like the expired attempts that fail to be
+// submitted.
+func (s *attemptService) RegradeAttempts(ctx context.Context, limit int) (int, error) {
+	attempts, err := s.repo.ListAttemptsToRegrade(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list attempts to regrade: %w", err)
+	}
+
+	regraded := 0
+	for _, attempt := range attempts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := s.regrade(ctx, attempt)
+		if err == nil && attempt.Passed != nil {
+			regraded++
+			continue
+		}
+		if ctx.Err() != nil {
+			continue
+		}
+
+		if err != nil {
+			s.logger.Error("Failed to regrade attempt", "attempt_id", attempt.ID, "error", err)
+		}
+		if err := s.repo.PostponeSweep(ctx, attempt.ID, time.Now(), sweepRetryDelay, maxSweepRetryDelay); err != nil {
+			return regraded, fmt.Errorf("failed to postpone attempt regrade: %w", err)
+		}
+	}
+
+	return regraded, nil
+}
+
+// regrade grades the flagged answers of a submitted attempt again and updates its score. The
+// attempt is passed or failed once none of its answers is left to grade.
+func (s *attemptService) regrade(ctx context.Context, attempt *Attempt) error {
+	quiz, err := s.getQuiz(ctx, attempt.QuizID)
+	if err != nil {
+		return err
+	}
+
+	paper, err := s.repo.GetPaper(ctx, attempt.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get paper: %w", err)
+	}
+
+	answers, err := s.repo.GetAttemptAnswers(ctx, attempt.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get attempt answers: %w", err)
+	}
+
+	var flagged []AttemptAnswer
+	for _, answer := range answers {
+		if answer.NeedsRegrade {
+			flagged = append(flagged, AttemptAnswer{QuestionID: answer.QuestionID, Answer: answer.Answer})
+		}
+	}
+
+	graded, err := s.gradeAnswers(ctx, paper, flagged)
+	if err != nil {
+		return err
+	}
+
+	byQuestion := make(map[int64]GradedAnswer, len(graded))
+	for _, answer := range graded {
+		byQuestion[answer.QuestionID] = answer
+	}
+	for i, answer := range answers {
+		if regraded, ok := byQuestion[answer.QuestionID]; ok {
+			answers[i] = regraded
+		}
+	}
+	score(attempt, quiz, answers)
+
+	if err := s.repo.RegradeAttempt(ctx, attempt, graded); err != nil {
+		return fmt.Errorf("failed to regrade attempt: %w", err)
+	}
+
+	s.logger.Info(
+		"Quiz attempt regraded",
+		"attempt_id", attempt.ID,
+		"quiz_id", attempt.QuizID,
+		"student_id", attempt.StudentID,
+		"score", attempt.Score,
+		"graded", attempt.Passed != nil,
+	)
+	return nil
+}
+
+// score adds up the points awarded to the answers of an attempt, rounded to hundredths. Whether
+// the attempt passed is left unset while answers wait to be graded again, their points are missing
+// from the score.
+func score(attempt *Attempt, quiz *Quiz, answers []GradedAnswer) {
+	attempt.Score = 0
+	pending := false
+	for _, answer := range answers {
+		attempt.Score += answer.PointsAwarded
+		pending = pending || answer.NeedsRegrade
+	}
+	attempt.Score = math.Round(attempt.Score*100) / 100
+
+	attempt.Passed = nil
+	if !pending {
+		passed := attempt.MaxScore > 0 && attempt.Score*100/attempt.MaxScore >= quiz.PassingScore
+		attempt.Passed = &passed
+	}
+}
+
+// gradeAnswers grades the answers against the questions of the paper, running the answers to code
+// questions. Partial credit is awarded as a fraction of the points of the question, rounded to
+// hundredths. Answers the runner fails to grade earn no points and are flagged to be graded again
+// by RegradeAttempts, rather than keeping the attempt from being submitted.
+func (s *attemptService) gradeAnswers(ctx context.Context, paper []PaperQuestion, answers []AttemptAnswer) ([]GradedAnswer, error) {
+	byID := make(map[int64]*PaperQuestion, len(paper))
+	for i := range paper {
+		byID[paper[i].ID] = &paper[i]
+	}
+
+	graded := make([]GradedAnswer, 0, len(answers))
+	seen := make(map[int64]bool, len(answers))
+	for _, answer := range answers {
+		q, ok := byID[answer.QuestionID]
+		if !ok {
+			return nil, fmt.Errorf("%w: question %d is not on the paper", ErrInvalidQuestion, answer.QuestionID)
+		}
+		if seen[answer.QuestionID] {
+			return nil, fmt.Errorf("%w: question %d is answered more than once", ErrInvalidQuestion, answer.QuestionID)
+		}
+		seen[answer.QuestionID] = true
+
+		if len(answer.Answer) == 0 {
+			answer.Answer = json.RawMessage("null")
+		}
+
+		result := q.Content.Grade(answer.Answer)
+		if executable, ok := q.Content.(question.Executable); ok {
+			var err error
+			result, err = executable.GradeRun(ctx, s.runner, answer.Answer)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+
+				s.logger.Error("Failed to grade answer", "question_id", answer.QuestionID, "error", err)
+				graded = append(graded, GradedAnswer{
+					QuestionID:   answer.QuestionID,
+					Answer:       answer.Answer,
+					NeedsRegrade: true,
+				})
+				continue
+			}
+		}
+
+		graded = append(graded, GradedAnswer{
+			QuestionID:    answer.QuestionID,
+			Answer:        answer.Answer,
+			IsCorrect:     result.Correct,
+			PointsAwarded: math.Round(result.Score*q.Points*100) / 100,
+			Tests:         result.Tests,
+		})
+	}
+
+	return graded, nil
+}
+
+// newPaper generates the paper of an attempt. A quiz without a blueprint puts its own questions
+// in order. A quiz with one draws a selection from the question banks, seeded so that the paper
+// can be drawn again from the same pools, with the questions and their options shuffled.
+func (s *attemptService) newPaper(ctx context.Context, quiz *Quiz) ([]PaperQuestion, *int64, error) {
+	if len(quiz.Blueprint) == 0 {
+		questions, err := s.repo.GetQuestions(ctx, quiz.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get questions: %w", err)
+		}
+		if len(questions) == 0 {
+			return nil, nil, ErrQuizHasNoQuestions
+		}
+
+		return fixedPaper(questions), nil, nil
+	}
+
+	pools := make([][]int64, len(quiz.Blueprint))
+	for i, rule := range quiz.Blueprint {
+		ids, err := s.repo.ListPoolQuestionIDs(ctx, rule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list pool questions: %w", err)
+		}
+		pools[i] = ids
+	}
+
+	seed := rand.Int64()
+	rng := paperRand(seed)
+	drawn, err := drawQuestions(rng, quiz.Blueprint, pools)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]int64, len(drawn))
+	for i, d := range drawn {
+		ids[i] = d.id
+	}
+	questions, err := s.repo.GetBankQuestionsByID(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bank questions: %w", err)
+	}
+
+	paper, err := assemblePaper(rng, drawn, questions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return paper, &seed, nil
+}
+
+// getQuiz retrieves the quiz of an attempt
+func (s *attemptService) getQuiz(ctx context.Context, id int64) (*Quiz, error) {
+	quiz, err := s.repo.GetQuiz(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+
+	return quiz, nil
+}
+
+// getOwnAttempt retrieves an attempt of the student
+func (s *attemptService) getOwnAttempt(ctx context.Context, attemptID int64, studentID uuid.UUID) (*Attempt, error) {
+	attempt, err := s.repo.GetAttempt(ctx, attemptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt: %w", err)
+	}
+	if attempt == nil {
+		return nil, ErrAttemptNotFound
+	}
+	if attempt.StudentID != studentID {
+		return nil, ErrNotAttemptOwner
+	}
+
+	return attempt, nil
+}
+
+// isOpen reports whether an attempt still accepts answers at the time
+func (s *attemptService) isOpen(attempt *Attempt, now time.Time) bool {
+	return now.Before(attempt.Deadline.Add(s.policy.GracePeriod))
+}
+
+// newAttemptDetails assembles the details of an attempt as of now
+func newAttemptDetails(attempt *Attempt, quiz *Quiz, questions []PaperQuestion, answers []GradedAnswer, now time.Time) *AttemptDetails {
+	return &AttemptDetails{
+		Attempt:          *attempt,
+		QuizTitle:        quiz.Title,
+		Questions:        questions,
+		Answers:          answers,
+		ServerTime:       now,
+		RemainingSeconds: remainingSeconds(attempt, now),
+	}
+}
+
+// remainingSeconds returns the whole seconds left before the deadline of an attempt in progress
+func remainingSeconds(attempt *Attempt, now time.Time) int64 {
+	if attempt.Status != AttemptInProgress || !now.Before(attempt.Deadline) {
+		return 0
+	}
+	return int64(attempt.Deadline.Sub(now) / time.Second)
+}
@@ -0,0 +1,208 @@
+// internal/domain/quiz/bank_service.go
+
+package quiz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/quiz/question"
+	"server/pkg/logger"
+)
+
+// BankService manages the question banks that quizzes with a blueprint draw their questions
+// from. Papers keep copies of the questions they drew, so banks can change at any time.
+type BankService interface {
+	ListBanks(ctx context.Context, req ListQuestionBanksRequest) ([]*QuestionBank, error)
+	GetBank(ctx context.Context, id int64) (*QuestionBank, error)
+	CreateBank(ctx context.Context, actorID uuid.UUID, req QuestionBankRequest) (*QuestionBank, error)
+	UpdateBank(ctx context.Context, id int64, req QuestionBankRequest) (*QuestionBank, error)
+	DeleteBank(ctx context.Context, id int64) error
+
+	// Questions of a bank, with their answers
+	GetBankQuestions(ctx context.Context, bankID int64) ([]BankQuestion, error)
+	AddBankQuestion(ctx context.Context, bankID int64, req BankQuestionRequest) (*BankQuestion, error)
+	UpdateBankQuestion(ctx context.Context, bankID, questionID int64, req BankQuestionRequest) (*BankQuestion, error)
+	DeleteBankQuestion(ctx context.Context, bankID, questionID int64) error
+}
+
+type bankService struct {
+	repo   Repository
+	logger logger.Logger
+}
+
+// NewBankService creates a new question bank service
+func NewBankService(repo Repository, logger logger.Logger) BankService {
+	return &bankService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListBanks retrieves the question banks matching the filters
+func (s *bankService) ListBanks(ctx context.Context, req ListQuestionBanksRequest) ([]*QuestionBank, error) {
+	req.Search = strings.TrimSpace(req.Search)
+
+	banks, err := s.repo.ListQuestionBanks(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list question banks: %w", err)
+	}
+
+	return banks, nil
+}
+
+// GetBank retrieves a question bank
+func (s *bankService) GetBank(ctx context.Context, id int64) (*QuestionBank, error) {
+	bank, err := s.repo.GetQuestionBank(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question bank: %w", err)
+	}
+	if bank == nil {
+		return nil, ErrBankNotFound
+	}
+
+	return bank, nil
+}
+
+// CreateBank creates an empty question bank
+func (s *bankService) CreateBank(ctx context.Context, actorID uuid.UUID, req QuestionBankRequest) (*QuestionBank, error) {
+	now := time.Now()
+	bank := &QuestionBank{CreatedBy: actorID, CreatedAt: now}
+	if err := applyBankRequest(bank, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateQuestionBank(ctx, bank); err != nil {
+		return nil, fmt.Errorf("failed to create question bank: %w", err)
+	}
+
+	s.logger.Info("Question bank created", "bank_id", bank.ID, "actor_id", actorID)
+	return bank, nil
+}
+
+// UpdateBank replaces the details of a question bank. Retagging a bank moves its questions to the
+// pools of other blueprint rules.
+func (s *bankService) UpdateBank(ctx context.Context, id int64, req QuestionBankRequest) (*QuestionBank, error) {
+	bank, err := s.GetBank(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyBankRequest(bank, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateQuestionBank(ctx, bank); err != nil {
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// DeleteBank deletes a question bank along with its questions
+func (s *bankService) DeleteBank(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteQuestionBank(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("Question bank deleted", "bank_id", id)
+	return nil
+}
+
+// GetBankQuestions retrieves the questions of a bank
+func (s *bankService) GetBankQuestions(ctx context.Context, bankID int64) ([]BankQuestion, error) {
+	if _, err := s.GetBank(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetBankQuestions(ctx, bankID)
+}
+
+// AddBankQuestion adds a question to a bank
+func (s *bankService) AddBankQuestion(ctx context.Context, bankID int64, req BankQuestionRequest) (*BankQuestion, error) {
+	if _, err := s.GetBank(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	question := &BankQuestion{BankID: bankID, CreatedAt: now}
+	if err := applyBankQuestionRequest(question, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddBankQuestion(ctx, question); err != nil {
+		return nil, fmt.Errorf("failed to add bank question: %w", err)
+	}
+
+	return question, nil
+}
+
+// UpdateBankQuestion replaces a question of a bank. Papers already drawn keep the old question.
+func (s *bankService) UpdateBankQuestion(ctx context.Context, bankID, questionID int64, req BankQuestionRequest) (*BankQuestion, error) {
+	if _, err := s.GetBank(ctx, bankID); err != nil {
+		return nil, err
+	}
+
+	question := &BankQuestion{ID: questionID, BankID: bankID}
+	if err := applyBankQuestionRequest(question, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateBankQuestion(ctx, question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// DeleteBankQuestion deletes a question of a bank
+func (s *bankService) DeleteBankQuestion(ctx context.Context, bankID, questionID int64) error {
+	if _, err := s.GetBank(ctx, bankID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteBankQuestion(ctx, bankID, questionID)
+}
+
+// applyBankRequest validates a question bank request and copies it onto the bank
+func applyBankRequest(bank *QuestionBank, req QuestionBankRequest, now time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 200 {
+		return fmt.Errorf("%w: name of up to 200 characters is required", ErrInvalidBank)
+	}
+	if req.DomainID == 0 || req.DifficultyLevelID == 0 {
+		return fmt.Errorf("%w: domain and difficulty level are required", ErrInvalidBank)
+	}
+
+	bank.Name = name
+	bank.Description = strings.TrimSpace(req.Description)
+	bank.DomainID = req.DomainID
+	bank.SubDomainID = req.SubDomainID
+	bank.DifficultyLevelID = req.DifficultyLevelID
+	bank.UpdatedAt = now
+	return nil
+}
+
+// applyBankQuestionRequest validates a bank question request and copies it onto the question
+func applyBankQuestionRequest(q *BankQuestion, req BankQuestionRequest, now time.Time) error {
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		return fmt.Errorf("%w: prompt is required", ErrInvalidQuestion)
+	}
+
+	content, err := question.Decode(req.Type, req.Content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+
+	q.Type = content.Type()
+	q.Prompt = prompt
+	q.Content = content
+	q.Explanation = strings.TrimSpace(req.Explanation)
+	q.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,278 @@
+// internal/domain/quiz/model.go
+
+package quiz
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/quiz/question"
+)
+
+// AttemptStatus is the state of a quiz attempt
+type AttemptStatus string
+
+// Attempt statuses
+const (
+	AttemptInProgress AttemptStatus = "in_progress"
+	AttemptSubmitted  AttemptStatus = "submitted"
+)
+
+// Quiz is a published or draft set of ordered questions. Domain, sub-domain and difficulty level
+// use the same IDs as practice sessions. A quiz with a blueprint has no questions of its own,
+// every attempt draws its questions from the question banks instead.
+type Quiz struct {
+	ID                    int64      `json:"id"`
+	Title                 string     `json:"title"`
+	Description           string     `json:"description,omitempty"`
+	DomainID              uint32     `json:"domain_id"`
+	SubDomainID           uint32     `json:"sub_domain_id"`
+	DifficultyLevelID     uint32     `json:"difficulty_level_id"`
+	TimeLimitMinutes      int        `json:"time_limit_minutes"`
+	PassingScore          float64    `json:"passing_score"`           // Percentage of the total points
+	MaxConcurrentAttempts int        `json:"max_concurrent_attempts"` // Attempts a student may have in progress at once
+	Blueprint             Blueprint  `json:"blueprint,omitempty"`
+	Published             bool       `json:"published"`
+	PublishedAt           *time.Time `json:"published_at,omitempty"`
+	QuestionCount         int        `json:"question_count"`
+	TotalPoints           float64    `json:"total_points"`
+	CreatedBy             uuid.UUID  `json:"created_by"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// Question is a question of a quiz. Questions are ordered by position, starting at 1. The content
+// depends on the type and holds the answers, which are stripped from the questions shown to students.
+type Question struct {
+	ID          int64             `json:"id"`
+	QuizID      int64             `json:"quiz_id"`
+	Position    int               `json:"position"`
+	Type        question.Type     `json:"type"`
+	Prompt      string            `json:"prompt"`
+	Content     question.Question `json:"content"`
+	Explanation string            `json:"explanation,omitempty"`
+	Points      float64           `json:"points"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// QuizRequest represents the data needed to create or replace a quiz
+type QuizRequest struct {
+	Title                 string          `json:"title" validate:"required,max=200"`
+	Description           string          `json:"description" validate:"max=2000"`
+	DomainID              uint32          `json:"domain_id" validate:"required"`
+	SubDomainID           uint32          `json:"sub_domain_id"`
+	DifficultyLevelID     uint32          `json:"difficulty_level_id" validate:"required"`
+	TimeLimitMinutes      int             `json:"time_limit_minutes" validate:"required,min=1,max=600"`
+	PassingScore          float64         `json:"passing_score" validate:"min=0,max=100"`
+	Published             bool            `json:"published"`
+	MaxConcurrentAttempts int             `json:"max_concurrent_attempts" validate:"omitempty,min=1,max=5"` // Defaults to 1
+	Blueprint             []BlueprintRule `json:"blueprint" validate:"omitempty,max=20,dive"`
+}
+
+// BlueprintRule draws a number of questions from the question banks of a domain and difficulty
+// level. Without a sub-domain the rule draws from every bank of the domain. Every question drawn
+// is worth the points of the rule, so that every paper has the same total.
+type BlueprintRule struct {
+	DomainID          uint32  `json:"domain_id" validate:"required"`
+	SubDomainID       uint32  `json:"sub_domain_id"`
+	DifficultyLevelID uint32  `json:"difficulty_level_id" validate:"required"`
+	Count             int     `json:"count" validate:"required,min=1,max=100"`
+	Points            float64 `json:"points" validate:"omitempty,gt=0,max=100"` // Defaults to 1
+}
+
+// Blueprint lists the rules drawing the questions of a quiz, the questions of a paper are
+// shuffled across the rules
+type Blueprint []BlueprintRule
+
+// QuestionCount returns the number of questions of every paper
+func (b Blueprint) QuestionCount() int {
+	count := 0
+	for _, rule := range b {
+		count += rule.Count
+	}
+	return count
+}
+
+// TotalPoints returns the points of every paper
+func (b Blueprint) TotalPoints() float64 {
+	var total float64
+	for _, rule := range b {
+		total += float64(rule.Count) * rule.Points
+	}
+	return total
+}
+
+// QuestionRequest represents the data needed to add or replace a question. Without a position a
+// new question is appended, with one it is inserted there and the following questions move down.
+// The content is decoded according to the type.
+type QuestionRequest struct {
+	Position    int             `json:"position" validate:"omitempty,min=1"`
+	Type        question.Type   `json:"type" validate:"required,oneof=mcq multi_select true_false fill_blank code"`
+	Prompt      string          `json:"prompt" validate:"required,max=5000"`
+	Content     json.RawMessage `json:"content" validate:"required,max=20000"`
+	Explanation string          `json:"explanation" validate:"max=2000"`
+	Points      float64         `json:"points" validate:"omitempty,gt=0,max=100"`
+}
+
+// ReorderQuestionsRequest lists every question of a quiz in its new order
+type ReorderQuestionsRequest struct {
+	QuestionIDs []int64 `json:"question_ids" validate:"required,min=1"`
+}
+
+// ListQuizzesRequest represents a page of quizzes, optionally filtered
+type ListQuizzesRequest struct {
+	Search            string  `form:"search" validate:"omitempty,max=100"`
+	DomainID          *uint32 `form:"domain_id"`
+	SubDomainID       *uint32 `form:"sub_domain_id"`
+	DifficultyLevelID *uint32 `form:"difficulty_level_id"`
+	Published         *bool   `form:"published"`
+	Page              int     `form:"page" validate:"omitempty,min=1"`
+	PageSize          int     `form:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// QuizList is a page of quizzes
+type QuizList struct {
+	Quizzes  []*Quiz `json:"quizzes"`
+	Total    int     `json:"total"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"page_size"`
+}
+
+// QuestionBank is a pool of questions on a domain, sub-domain and difficulty level, which use the
+// same IDs as practice sessions. The questions of a bank belong to no quiz, blueprints draw them.
+type QuestionBank struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description,omitempty"`
+	DomainID          uint32    `json:"domain_id"`
+	SubDomainID       uint32    `json:"sub_domain_id"`
+	DifficultyLevelID uint32    `json:"difficulty_level_id"`
+	QuestionCount     int       `json:"question_count"`
+	CreatedBy         uuid.UUID `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// BankQuestion is a question of a bank. It has no points of its own, the blueprint drawing it
+// sets them.
+type BankQuestion struct {
+	ID          int64             `json:"id"`
+	BankID      int64             `json:"bank_id"`
+	Type        question.Type     `json:"type"`
+	Prompt      string            `json:"prompt"`
+	Content     question.Question `json:"content"`
+	Explanation string            `json:"explanation,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// QuestionBankRequest represents the data needed to create or replace a question bank
+type QuestionBankRequest struct {
+	Name              string `json:"name" validate:"required,max=200"`
+	Description       string `json:"description" validate:"max=2000"`
+	DomainID          uint32 `json:"domain_id" validate:"required"`
+	SubDomainID       uint32 `json:"sub_domain_id"`
+	DifficultyLevelID uint32 `json:"difficulty_level_id" validate:"required"`
+}
+
+// BankQuestionRequest represents the data needed to add or replace a question of a bank. The
+// content is decoded according to the type.
+type BankQuestionRequest struct {
+	Type        question.Type   `json:"type" validate:"required,oneof=mcq multi_select true_false fill_blank code"`
+	Prompt      string          `json:"prompt" validate:"required,max=5000"`
+	Content     json.RawMessage `json:"content" validate:"required,max=20000"`
+	Explanation string          `json:"explanation" validate:"max=2000"`
+}
+
+// ListQuestionBanksRequest filters the question banks
+type ListQuestionBanksRequest struct {
+	Search            string  `form:"search" validate:"omitempty,max=100"`
+	DomainID          *uint32 `form:"domain_id"`
+	SubDomainID       *uint32 `form:"sub_domain_id"`
+	DifficultyLevelID *uint32 `form:"difficulty_level_id"`
+}
+
+// Attempt is a student's sitting of a quiz. The deadline is set by the server when the attempt
+// starts, attempts still in progress past it are submitted automatically.
+type Attempt struct {
+	ID            int64         `json:"id"`
+	QuizID        int64         `json:"quiz_id"`
+	StudentID     uuid.UUID     `json:"student_id"` // Platform profile of the student
+	Status        AttemptStatus `json:"status"`
+	StartTime     time.Time     `json:"start_time"`
+	Deadline      time.Time     `json:"deadline"`
+	EndTime       *time.Time    `json:"end_time,omitempty"`
+	AutoSubmitted bool          `json:"auto_submitted"`
+	Score         float64       `json:"score"`
+	MaxScore      float64       `json:"max_score"`
+	Passed        *bool         `json:"passed,omitempty"` // Set once submitted and every answer is graded
+	PaperSeed     *int64        `json:"-"`                // Seed of the questions drawn for a quiz with a blueprint
+}
+
+// PaperQuestion is a question as it was put to the student in an attempt. Papers are generated
+// when attempts start and kept with them, so that attempts are graded and reviewed on the
+// questions they were given even after the questions change.
+type PaperQuestion struct {
+	ID          int64             `json:"id"` // Question of the quiz, or of a bank for quizzes with a blueprint
+	Position    int               `json:"position"`
+	Type        question.Type     `json:"type"`
+	Prompt      string            `json:"prompt"`
+	Content     question.Question `json:"content"`
+	Explanation string            `json:"explanation,omitempty"`
+	Points      float64           `json:"points"`
+}
+
+// AttemptPolicy contains the rules of timed attempts
+type AttemptPolicy struct {
+	// Answers saved or submitted up to GracePeriod past the deadline are accepted, to make up for
+	// network delays. Attempts still in progress after that are submitted automatically.
+	GracePeriod time.Duration
+}
+
+// AttemptAnswer is a student's answer to a question. Its shape depends on the type of the
+// question: an option ID, a list of option IDs, a boolean, the fillings of the blanks or the
+// source of a program.
+type AttemptAnswer struct {
+	QuestionID int64           `json:"question_id" validate:"required"`
+	Answer     json.RawMessage `json:"answer" validate:"max=70000"`
+}
+
+// SaveAnswerRequest represents the answer to a question saved while the attempt is in progress
+type SaveAnswerRequest struct {
+	Answer json.RawMessage `json:"answer" validate:"required,max=70000"`
+}
+
+// SavedAnswer acknowledges a saved answer along with the time left, by the clock of the server
+type SavedAnswer struct {
+	QuestionID       int64     `json:"question_id"`
+	SavedAt          time.Time `json:"saved_at"`
+	Deadline         time.Time `json:"deadline"`
+	RemainingSeconds int64     `json:"remaining_seconds"`
+}
+
+// GradedAnswer is an answer after grading. Answers earning partial credit are not correct.
+// Answers that couldn't be graded, when the sandbox fails, earn no points until they are graded
+// again, and the attempt is neither passed nor failed until then.
+type GradedAnswer struct {
+	QuestionID    int64                 `json:"question_id"`
+	Answer        json.RawMessage       `json:"answer"`
+	IsCorrect     bool                  `json:"is_correct"`
+	PointsAwarded float64               `json:"points_awarded"`
+	Tests         []question.TestResult `json:"tests,omitempty"` // Test cases run for code questions
+	NeedsRegrade  bool                  `json:"needs_regrade,omitempty"`
+}
+
+// AttemptDetails is an attempt along with its questions and answers. Until the attempt is
+// submitted, the questions are shown without their answers and the saved answers are not graded.
+// Clients count down from the time of the server rather than their own clock.
+type AttemptDetails struct {
+	Attempt
+	QuizTitle        string          `json:"quiz_title"`
+	Questions        []PaperQuestion `json:"questions"`
+	Answers          []GradedAnswer  `json:"answers"`
+	ServerTime       time.Time       `json:"server_time"`
+	RemainingSeconds int64           `json:"remaining_seconds"`
+}
@@ -0,0 +1,121 @@
+// internal/domain/quiz/paper.go
+
+package quiz
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"server/internal/domain/quiz/question"
+)
+
+// paperStream tells the random streams of papers apart from any other use of the same seed
+const paperStream = 0x7061706572
+
+// drawnQuestion is a bank question drawn for a paper, worth the points of the rule drawing it
+type drawnQuestion struct {
+	id     int64
+	points float64
+}
+
+// paperRand returns the random source of the paper drawn with the seed. The same seed draws the
+// same paper from the same pools.
+func paperRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), paperStream))
+}
+
+// fixedPaper puts the questions of a quiz without a blueprint in their order
+func fixedPaper(questions []Question) []PaperQuestion {
+	paper := make([]PaperQuestion, len(questions))
+	for i, q := range questions {
+		paper[i] = PaperQuestion{
+			ID:          q.ID,
+			Position:    i + 1,
+			Type:        q.Type,
+			Prompt:      q.Prompt,
+			Content:     q.Content,
+			Explanation: q.Explanation,
+			Points:      q.Points,
+		}
+	}
+	return paper
+}
+
+// drawQuestions picks the questions of every rule of a blueprint from its pool, then shuffles them
+// across the rules. Pools hold the IDs of the bank questions of each rule in ascending order.
+func drawQuestions(rng *rand.Rand, blueprint Blueprint, pools [][]int64) ([]drawnQuestion, error) {
+	drawn := make([]drawnQuestion, 0, blueprint.QuestionCount())
+	for i, rule := range blueprint {
+		pool := append([]int64(nil), pools[i]...)
+		if len(pool) < rule.Count {
+			return nil, fmt.Errorf("%w: rule %d draws %d questions from %d", ErrNotEnoughQuestions, i+1, rule.Count, len(pool))
+		}
+
+		// The first Count steps of a Fisher-Yates shuffle pick the questions
+		for j := 0; j < rule.Count; j++ {
+			k := j + rng.IntN(len(pool)-j)
+			pool[j], pool[k] = pool[k], pool[j]
+			drawn = append(drawn, drawnQuestion{id: pool[j], points: rule.Points})
+		}
+	}
+
+	rng.Shuffle(len(drawn), func(i, j int) {
+		drawn[i], drawn[j] = drawn[j], drawn[i]
+	})
+	return drawn, nil
+}
+
+// assemblePaper numbers the drawn questions in the order they were drawn and shuffles the options
+// of the questions that have some
+func assemblePaper(rng *rand.Rand, drawn []drawnQuestion, questions []BankQuestion) ([]PaperQuestion, error) {
+	byID := make(map[int64]*BankQuestion, len(questions))
+	for i := range questions {
+		byID[questions[i].ID] = &questions[i]
+	}
+
+	paper := make([]PaperQuestion, len(drawn))
+	for i, d := range drawn {
+		q, ok := byID[d.id]
+		if !ok {
+			// Deleted from its bank while the paper was drawn
+			return nil, fmt.Errorf("%w: question %d is no longer in its bank", ErrNotEnoughQuestions, d.id)
+		}
+
+		content := q.Content
+		if shuffler, ok := content.(question.Shuffler); ok {
+			content = shuffler.Shuffled(rng.Shuffle)
+		}
+
+		paper[i] = PaperQuestion{
+			ID:          q.ID,
+			Position:    i + 1,
+			Type:        q.Type,
+			Prompt:      q.Prompt,
+			Content:     content,
+			Explanation: q.Explanation,
+			Points:      d.points,
+		}
+	}
+
+	return paper, nil
+}
+
+// withoutAnswers returns copies of the questions without their answers and explanations
+func withoutAnswers(paper []PaperQuestion) []PaperQuestion {
+	stripped := make([]PaperQuestion, len(paper))
+	for i, q := range paper {
+		q.Content = q.Content.StudentView()
+		q.Explanation = ""
+		stripped[i] = q
+	}
+	return stripped
+}
+
+// totalPoints sums the points of the questions
+func totalPoints(paper []PaperQuestion) float64 {
+	var total float64
+	for _, q := range paper {
+		total += q.Points
+	}
+	return total
+}
@@ -0,0 +1,202 @@
+package quiz
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"server/internal/domain/quiz/question"
+)
+
+// pool returns the IDs from first to last
+func pool(first, last int64) []int64 {
+	ids := []int64{}
+	for id := first; id <= last; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func drawnIDs(drawn []drawnQuestion) []int64 {
+	ids := make([]int64, len(drawn))
+	for i, d := range drawn {
+		ids[i] = d.id
+	}
+	return ids
+}
+
+func TestDrawQuestions(t *testing.T) {
+	blueprint := Blueprint{
+		{DomainID: 1, DifficultyLevelID: 1, Count: 3, Points: 1},
+		{DomainID: 1, DifficultyLevelID: 2, Count: 2, Points: 2.5},
+	}
+	pools := [][]int64{pool(1, 20), pool(101, 110)}
+
+	first, err := drawQuestions(paperRand(42), blueprint, pools)
+	if err != nil {
+		t.Fatalf("drawQuestions failed: %v", err)
+	}
+
+	if len(first) != blueprint.QuestionCount() {
+		t.Fatalf("expected %d questions, got %d", blueprint.QuestionCount(), len(first))
+	}
+	counts := make(map[float64]int)
+	seen := make(map[int64]bool)
+	for _, d := range first {
+		if seen[d.id] {
+			t.Fatalf("expected question %d to be drawn once, got %v", d.id, drawnIDs(first))
+		}
+		seen[d.id] = true
+
+		switch {
+		case d.id >= 1 && d.id <= 20 && d.points == 1:
+		case d.id >= 101 && d.id <= 110 && d.points == 2.5:
+		default:
+			t.Fatalf("expected question %d to come from its rule's pool with its points, got %v points", d.id, d.points)
+		}
+		counts[d.points]++
+	}
+	if counts[1] != 3 || counts[2.5] != 2 {
+		t.Fatalf("expected 3 and 2 questions of the rules, got %v", counts)
+	}
+	if !reflect.DeepEqual(pools, [][]int64{pool(1, 20), pool(101, 110)}) {
+		t.Fatalf("expected the pools to be left untouched, got %v", pools)
+	}
+
+	again, err := drawQuestions(paperRand(42), blueprint, pools)
+	if err != nil {
+		t.Fatalf("drawQuestions failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, again) {
+		t.Fatalf("expected the same seed to draw the same paper, got %v and %v", drawnIDs(first), drawnIDs(again))
+	}
+
+	other, err := drawQuestions(paperRand(43), blueprint, pools)
+	if err != nil {
+		t.Fatalf("drawQuestions failed: %v", err)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Fatalf("expected another seed to draw another paper, got %v twice", drawnIDs(first))
+	}
+}
+
+func TestDrawQuestionsShufflesAcrossRules(t *testing.T) {
+	blueprint := Blueprint{{Count: 5, Points: 1}, {Count: 5, Points: 1}}
+	pools := [][]int64{pool(1, 5), pool(6, 10)}
+
+	// With whole pools drawn only the order can differ, which must mix the rules for some seed
+	for seed := int64(0); seed < 10; seed++ {
+		drawn, err := drawQuestions(paperRand(seed), blueprint, pools)
+		if err != nil {
+			t.Fatalf("drawQuestions failed: %v", err)
+		}
+		ids := drawnIDs(drawn)
+		if slices.Max(ids[:5]) > 5 {
+			return
+		}
+	}
+	t.Fatalf("expected the questions of the rules to be shuffled together")
+}
+
+func TestDrawQuestionsRejectsSmallPools(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		pools [][]int64
+	}{
+		{name: "one question short", pools: [][]int64{pool(1, 3), pool(11, 11)}},
+		{name: "empty pool", pools: [][]int64{pool(1, 3), {}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := drawQuestions(paperRand(1), Blueprint{{Count: 2, Points: 1}, {Count: 2, Points: 1}}, tc.pools)
+			if !errors.Is(err, ErrNotEnoughQuestions) {
+				t.Fatalf("expected %v, got %v", ErrNotEnoughQuestions, err)
+			}
+		})
+	}
+}
+
+func TestAssemblePaper(t *testing.T) {
+	choice := &question.MultipleChoice{Options: []question.Option{
+		{ID: "a", Text: "one"}, {ID: "b", Text: "two"}, {ID: "c", Text: "three"},
+		{ID: "d", Text: "four"}, {ID: "e", Text: "five"}, {ID: "f", Text: "six"},
+	}, Answer: "c"}
+	original := slices.Clone(choice.Options)
+	answer := true
+	questions := []BankQuestion{
+		{ID: 7, Type: question.TypeMultipleChoice, Prompt: "Pick three", Content: choice, Explanation: "Three is c"},
+		{ID: 9, Type: question.TypeTrueFalse, Prompt: "True?", Content: &question.TrueFalse{Answer: &answer}},
+	}
+	drawn := []drawnQuestion{{id: 9, points: 2}, {id: 7, points: 1.5}}
+
+	paper, err := assemblePaper(paperRand(42), drawn, questions)
+	if err != nil {
+		t.Fatalf("assemblePaper failed: %v", err)
+	}
+
+	if len(paper) != 2 || paper[0].ID != 9 || paper[1].ID != 7 {
+		t.Fatalf("expected the questions in the drawn order, got %+v", paper)
+	}
+	for i, q := range paper {
+		if q.Position != i+1 || q.Points != drawn[i].points {
+			t.Fatalf("expected question %d at position %d worth %v, got %+v", q.ID, i+1, drawn[i].points, q)
+		}
+	}
+	if paper[1].Prompt != "Pick three" || paper[1].Explanation != "Three is c" {
+		t.Fatalf("expected the bank question's prompt and explanation, got %+v", paper[1])
+	}
+
+	shuffled := paper[1].Content.(*question.MultipleChoice)
+	if shuffled.Answer != "c" || !slices.Equal(choice.Options, original) {
+		t.Fatalf("expected a shuffled copy keeping the answer, got %+v", shuffled)
+	}
+	if slices.Equal(shuffled.Options, original) {
+		t.Fatalf("expected the options to be shuffled, got %v", shuffled.Options)
+	}
+	sorted := slices.Clone(shuffled.Options)
+	slices.SortFunc(sorted, func(a, b question.Option) int { return int(a.ID[0]) - int(b.ID[0]) })
+	if !slices.Equal(sorted, original) {
+		t.Fatalf("expected the same options in another order, got %v", shuffled.Options)
+	}
+
+	again, err := assemblePaper(paperRand(42), drawn, questions)
+	if err != nil {
+		t.Fatalf("assemblePaper failed: %v", err)
+	}
+	if !reflect.DeepEqual(paper, again) {
+		t.Fatalf("expected the same seed to assemble the same paper")
+	}
+}
+
+func TestAssemblePaperRejectsDeletedQuestions(t *testing.T) {
+	questions := []BankQuestion{{ID: 7, Type: question.TypeTrueFalse, Content: &question.TrueFalse{}}}
+
+	_, err := assemblePaper(paperRand(1), []drawnQuestion{{id: 7, points: 1}, {id: 8, points: 1}}, questions)
+	if !errors.Is(err, ErrNotEnoughQuestions) {
+		t.Fatalf("expected %v, got %v", ErrNotEnoughQuestions, err)
+	}
+}
+
+func TestFixedPaper(t *testing.T) {
+	answer := false
+	questions := []Question{
+		{ID: 3, Position: 1, Type: question.TypeTrueFalse, Prompt: "First", Content: &question.TrueFalse{Answer: &answer}, Explanation: "Because", Points: 2},
+		{ID: 1, Position: 2, Type: question.TypeTrueFalse, Prompt: "Second", Content: &question.TrueFalse{Answer: &answer}, Points: 0.5},
+	}
+
+	paper := fixedPaper(questions)
+	if len(paper) != 2 || paper[0].ID != 3 || paper[1].ID != 1 || paper[0].Position != 1 || paper[1].Position != 2 {
+		t.Fatalf("expected the questions in their order, got %+v", paper)
+	}
+	if totalPoints(paper) != 2.5 {
+		t.Fatalf("expected 2.5 points, got %v", totalPoints(paper))
+	}
+
+	stripped := withoutAnswers(paper)
+	if stripped[0].Explanation != "" || stripped[0].Content.(*question.TrueFalse).Answer != nil {
+		t.Fatalf("expected the answers to be stripped, got %+v", stripped[0])
+	}
+	if paper[0].Explanation != "Because" || paper[0].Content.(*question.TrueFalse).Answer == nil {
+		t.Fatalf("expected the paper itself to keep its answers, got %+v", paper[0])
+	}
+}
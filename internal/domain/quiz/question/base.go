@@ -0,0 +1,174 @@
+// internal/domain/quiz/question/base.go
+
+// Package question implements the question types of quizzes. Every type validates its own
+// content, grades answers with partial credit and hides its answers from students. Questions are
+// stored as a single JSON document whose "type" field tells the types apart. Code questions are
+// graded by running the answers through a Runner.
+package question
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Type identifies how a question is answered and graded
+type Type string
+
+// Question types
+const (
+	TypeMultipleChoice Type = "mcq"
+	TypeMultiSelect    Type = "multi_select"
+	TypeTrueFalse      Type = "true_false"
+	TypeFillBlank      Type = "fill_blank"
+	TypeCode           Type = "code"
+)
+
+// Common errors
+var (
+	ErrUnknownType = errors.New("unknown question type")
+	ErrInvalid     = errors.New("invalid question")
+)
+
+// Question is the content of a question of a given type, along with its answers
+type Question interface {
+	// Type returns the type stored alongside the content
+	Type() Type
+
+	// Validate normalizes the content and checks it is complete and consistent
+	Validate() error
+
+	// Grade scores an answer. Answers that can't be decoded score nothing.
+	Grade(answer json.RawMessage) Result
+
+	// StudentView returns a copy of the question without anything that gives its answers away
+	StudentView() Question
+}
+
+// Result is the grade of an answer
+type Result struct {
+	Score   float64 `json:"score"`   // Between 0 and 1, the fraction of the points of the question awarded
+	Correct bool    `json:"correct"` // Whether the answer earns full credit
+
+	Tests []TestResult `json:"tests,omitempty"` // Outcome of every test case of a code question
+}
+
+// newResult grades a fraction of an answer being right
+func newResult(right, total int) Result {
+	if total <= 0 || right <= 0 {
+		return Result{}
+	}
+	if right >= total {
+		return Result{Score: 1, Correct: true}
+	}
+	return Result{Score: float64(right) / float64(total)}
+}
+
+// New returns an empty question of the type
+func New(t Type) (Question, error) {
+	switch t {
+	case TypeMultipleChoice:
+		return &MultipleChoice{}, nil
+	case TypeMultiSelect:
+		return &MultiSelect{}, nil
+	case TypeTrueFalse:
+		return &TrueFalse{}, nil
+	case TypeFillBlank:
+		return &FillBlank{}, nil
+	case TypeCode:
+		return &Code{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, t)
+	}
+}
+
+// Decode decodes and validates the content of a question of the type, as sent by its authors.
+// Unknown fields are rejected so that misspelt settings don't go unnoticed.
+func Decode(t Type, content json.RawMessage) (Question, error) {
+	q, err := New(t)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(q); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Marshal encodes a question for storage, adding its type to the document
+func Marshal(q Question) (json.RawMessage, error) {
+	content, err := json.Marshal(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode question: %w", err)
+	}
+
+	document := map[string]json.RawMessage{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to encode question: %w", err)
+	}
+	document["type"], _ = json.Marshal(q.Type())
+
+	return json.Marshal(document)
+}
+
+// Unmarshal decodes a stored question, picking its type from the document
+func Unmarshal(document []byte) (Question, error) {
+	var header struct {
+		Type Type `json:"type"`
+	}
+	if err := json.Unmarshal(document, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode question: %w", err)
+	}
+
+	q, err := New(header.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(document, q); err != nil {
+		return nil, fmt.Errorf("failed to decode %s question: %w", header.Type, err)
+	}
+	return q, nil
+}
+
+// invalid returns an ErrInvalid error with the reason
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...))
+}
+
+// decodeAnswer decodes an answer, reporting false for missing or malformed answers
+func decodeAnswer(answer json.RawMessage, v any) bool {
+	if len(bytes.TrimSpace(answer)) == 0 {
+		return false
+	}
+	return json.Unmarshal(answer, v) == nil
+}
+
+// distinct reports whether the values are all different
+func distinct(values []string) bool {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if seen[value] {
+			return false
+		}
+		seen[value] = true
+	}
+	return true
+}
+
+// trimAll trims the spaces around every value
+func trimAll(values []string) []string {
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.TrimSpace(value)
+	}
+	return trimmed
+}
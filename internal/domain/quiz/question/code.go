@@ -0,0 +1,258 @@
+// internal/domain/quiz/question/code.go
+
+package question
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Languages in which code questions can be answered
+var Languages = []string{"c", "cpp", "java", "javascript", "python"}
+
+// Limits of code questions
+const (
+	MaxTestCases          = 50
+	MaxTestCaseLength     = 10000
+	MaxStarterCodeLength  = 20000
+	MaxSourceLength       = 64 * 1024
+	DefaultTimeLimitMs    = 2000
+	MaxTimeLimitMs        = 10000
+	DefaultMemoryLimitMB  = 256
+	MinMemoryLimitMB      = 32
+	MaxMemoryLimitMB      = 1024
+	maxReportedOutputSize = 1000
+)
+
+// ErrRunnerUnavailable is returned when a program must be run but no runner is configured
+var ErrRunnerUnavailable = errors.New("code runner unavailable")
+
+// Program is the source of a submitted program in a language
+type Program struct {
+	Language string
+	Source   string
+}
+
+// Limits bound the resources of every run of a program
+type Limits struct {
+	Timeout     time.Duration
+	MemoryBytes int64
+}
+
+// RunStatus is the outcome of a run of a program
+type RunStatus string
+
+// Run statuses
+const (
+	RunCompleted    RunStatus = "completed"     // The program exited normally, its output decides the test
+	RunRuntimeError RunStatus = "runtime_error" // The program crashed, exited with an error or ran out of memory
+	RunTimedOut     RunStatus = "timed_out"
+	RunCompileError RunStatus = "compile_error"
+)
+
+// Execution is a run of a program on one input
+type Execution struct {
+	Status  RunStatus
+	Stdout  string
+	Stderr  string // Or the compiler output when the program doesn't compile
+	Runtime time.Duration
+}
+
+// Runner executes programs in isolation. Implementations must enforce the limits, keep the
+// program off the network and away from the host, and return one execution per input, in order.
+// An error means the runner itself failed, not the program.
+type Runner interface {
+	Run(ctx context.Context, program Program, inputs []string, limits Limits) ([]Execution, error)
+}
+
+// Executable is implemented by questions whose answers are programs, which must be run to be graded
+type Executable interface {
+	Question
+
+	// GradeRun runs the answer with the runner and grades its results
+	GradeRun(ctx context.Context, runner Runner, answer json.RawMessage) (Result, error)
+}
+
+// TestCase is an input of a code question and the output expected for it. Hidden test cases are
+// only used for grading, students never see them.
+type TestCase struct {
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+	Hidden         bool   `json:"hidden,omitempty"`
+}
+
+// TestResult is the outcome of a test case. Hidden test cases only report whether they passed.
+type TestResult struct {
+	Test      int       `json:"test"` // Position of the test case, starting at 1
+	Hidden    bool      `json:"hidden,omitempty"`
+	Passed    bool      `json:"passed"`
+	Status    RunStatus `json:"status"`
+	RuntimeMs int64     `json:"runtime_ms"`
+	Output    string    `json:"output,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Code is a programming question. Answers are the source of a program in the language of the
+// question, which reads a test case on standard input and writes the expected output. Every test
+// case passed earns an equal share of the points.
+type Code struct {
+	Language        string     `json:"language"`
+	StarterCode     string     `json:"starter_code,omitempty"`
+	TestCases       []TestCase `json:"test_cases,omitempty"`
+	HiddenTestCount int        `json:"hidden_test_count,omitempty"` // Only set on the student view
+	TimeLimitMs     int        `json:"time_limit_ms,omitempty"`
+	MemoryLimitMB   int        `json:"memory_limit_mb,omitempty"`
+}
+
+// Type returns the code type
+func (q *Code) Type() Type {
+	return TypeCode
+}
+
+// Validate checks the language, the test cases and the resource limits, defaulting the limits
+func (q *Code) Validate() error {
+	q.Language = strings.ToLower(strings.TrimSpace(q.Language))
+	if !slices.Contains(Languages, q.Language) {
+		return invalid("language must be one of %s", strings.Join(Languages, ", "))
+	}
+	if len(q.StarterCode) > MaxStarterCodeLength {
+		return invalid("starter code can't exceed %d characters", MaxStarterCodeLength)
+	}
+
+	if len(q.TestCases) == 0 || len(q.TestCases) > MaxTestCases {
+		return invalid("between 1 and %d test cases are required", MaxTestCases)
+	}
+	for i, test := range q.TestCases {
+		if len(test.Input) > MaxTestCaseLength || len(test.ExpectedOutput) > MaxTestCaseLength {
+			return invalid("input and expected output of test case %d can't exceed %d characters", i+1, MaxTestCaseLength)
+		}
+		if strings.TrimSpace(test.ExpectedOutput) == "" {
+			return invalid("test case %d needs an expected output", i+1)
+		}
+	}
+
+	if q.TimeLimitMs == 0 {
+		q.TimeLimitMs = DefaultTimeLimitMs
+	}
+	if q.TimeLimitMs < 1 || q.TimeLimitMs > MaxTimeLimitMs {
+		return invalid("time limit must be between 1 and %d milliseconds", MaxTimeLimitMs)
+	}
+	if q.MemoryLimitMB == 0 {
+		q.MemoryLimitMB = DefaultMemoryLimitMB
+	}
+	if q.MemoryLimitMB < MinMemoryLimitMB || q.MemoryLimitMB > MaxMemoryLimitMB {
+		return invalid("memory limit must be between %d and %d MB", MinMemoryLimitMB, MaxMemoryLimitMB)
+	}
+
+	q.HiddenTestCount = 0
+	return nil
+}
+
+// Grade can't run the program, so it awards nothing. Code answers are graded with GradeRun.
+func (q *Code) Grade(answer json.RawMessage) Result {
+	return Result{}
+}
+
+// GradeRun runs the submitted program on every test case. Empty answers score nothing without
+// being run.
+func (q *Code) GradeRun(ctx context.Context, runner Runner, answer json.RawMessage) (Result, error) {
+	var source string
+	if !decodeAnswer(answer, &source) || strings.TrimSpace(source) == "" || len(source) > MaxSourceLength {
+		return Result{}, nil
+	}
+	if runner == nil {
+		return Result{}, ErrRunnerUnavailable
+	}
+
+	inputs := make([]string, len(q.TestCases))
+	for i, test := range q.TestCases {
+		inputs[i] = test.Input
+	}
+
+	executions, err := runner.Run(
+		ctx,
+		Program{Language: q.Language, Source: source},
+		inputs,
+		Limits{
+			Timeout:     time.Duration(q.TimeLimitMs) * time.Millisecond,
+			MemoryBytes: int64(q.MemoryLimitMB) * 1024 * 1024,
+		},
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run program: %w", err)
+	}
+	if len(executions) != len(q.TestCases) {
+		return Result{}, fmt.Errorf("runner returned %d executions for %d test cases", len(executions), len(q.TestCases))
+	}
+
+	passed := 0
+	tests := make([]TestResult, len(q.TestCases))
+	for i, test := range q.TestCases {
+		execution := executions[i]
+		tests[i] = TestResult{
+			Test:      i + 1,
+			Hidden:    test.Hidden,
+			Status:    execution.Status,
+			RuntimeMs: execution.Runtime.Milliseconds(),
+			Passed:    execution.Status == RunCompleted && sameOutput(execution.Stdout, test.ExpectedOutput),
+		}
+		if tests[i].Passed {
+			passed++
+		}
+		if !test.Hidden {
+			tests[i].Output = truncate(execution.Stdout)
+			if execution.Status != RunCompleted {
+				tests[i].Error = truncate(execution.Stderr)
+			}
+		}
+	}
+
+	result := newResult(passed, len(q.TestCases))
+	result.Tests = tests
+	return result, nil
+}
+
+// StudentView returns the question with its visible test cases and the number of hidden ones
+func (q *Code) StudentView() Question {
+	view := &Code{
+		Language:      q.Language,
+		StarterCode:   q.StarterCode,
+		TimeLimitMs:   q.TimeLimitMs,
+		MemoryLimitMB: q.MemoryLimitMB,
+	}
+	for _, test := range q.TestCases {
+		if test.Hidden {
+			view.HiddenTestCount++
+			continue
+		}
+		view.TestCases = append(view.TestCases, test)
+	}
+	return view
+}
+
+// sameOutput compares outputs ignoring trailing spaces on every line and trailing blank lines
+func sameOutput(actual, expected string) bool {
+	return normalizeOutput(actual) == normalizeOutput(expected)
+}
+
+// normalizeOutput trims the trailing spaces of every line and the trailing blank lines
+func normalizeOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// truncate shortens program output to what is worth reporting
+func truncate(output string) string {
+	if len(output) <= maxReportedOutputSize {
+		return output
+	}
+	return output[:maxReportedOutputSize] + "..."
+}
@@ -0,0 +1,121 @@
+// internal/domain/quiz/question/fill_blank.go
+
+package question
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Limits of fill-in-the-blank questions
+const (
+	MaxBlanks            = 20
+	MaxAlternates        = 10
+	MaxBlankAnswerLength = 200
+)
+
+// Blank is a gap of a fill-in-the-blank question and the answers accepted for it. The first
+// answer is the expected one, the others are alternates such as synonyms or other spellings.
+type Blank struct {
+	Answers []string `json:"answers,omitempty"`
+}
+
+// FillBlank is a prompt with one or more gaps, answered in order. By default answers are compared
+// ignoring case and runs of spaces, either can be made significant.
+type FillBlank struct {
+	Blanks          []Blank `json:"blanks"`
+	CaseSensitive   bool    `json:"case_sensitive,omitempty"`
+	ExactWhitespace bool    `json:"exact_whitespace,omitempty"`
+}
+
+// Type returns the fill-in-the-blank type
+func (q *FillBlank) Type() Type {
+	return TypeFillBlank
+}
+
+// Validate checks that every blank accepts at least one answer and that the accepted answers of a
+// blank stay distinct under the matching rules
+func (q *FillBlank) Validate() error {
+	if len(q.Blanks) == 0 || len(q.Blanks) > MaxBlanks {
+		return invalid("between 1 and %d blanks are required", MaxBlanks)
+	}
+
+	blanks := make([]Blank, len(q.Blanks))
+	for i, blank := range q.Blanks {
+		if len(blank.Answers) == 0 || len(blank.Answers) > MaxAlternates {
+			return invalid("blank %d needs between 1 and %d accepted answers", i+1, MaxAlternates)
+		}
+
+		normalized := make([]string, len(blank.Answers))
+		for j, answer := range blank.Answers {
+			if !q.ExactWhitespace {
+				answer = strings.TrimSpace(answer)
+			}
+			if strings.TrimSpace(answer) == "" || len(answer) > MaxBlankAnswerLength {
+				return invalid("accepted answers of blank %d must have up to %d characters", i+1, MaxBlankAnswerLength)
+			}
+			blanks[i].Answers = append(blanks[i].Answers, answer)
+			normalized[j] = q.normalize(answer)
+		}
+		if !distinct(normalized) {
+			return invalid("accepted answers of blank %d must be distinct", i+1)
+		}
+	}
+
+	q.Blanks = blanks
+	return nil
+}
+
+// Grade awards credit for every blank filled with one of its accepted answers. The answer is the
+// list of the fillings in order, a single string answers a question with one blank.
+func (q *FillBlank) Grade(answer json.RawMessage) Result {
+	var fillings []string
+	if !decodeAnswer(answer, &fillings) {
+		var filling string
+		if !decodeAnswer(answer, &filling) {
+			return Result{}
+		}
+		fillings = []string{filling}
+	}
+
+	right := 0
+	for i, blank := range q.Blanks {
+		if i >= len(fillings) {
+			break
+		}
+		if q.accepts(blank, fillings[i]) {
+			right++
+		}
+	}
+
+	return newResult(right, len(q.Blanks))
+}
+
+// StudentView returns the number of blanks and the matching rules without the accepted answers
+func (q *FillBlank) StudentView() Question {
+	return &FillBlank{
+		Blanks:          make([]Blank, len(q.Blanks)),
+		CaseSensitive:   q.CaseSensitive,
+		ExactWhitespace: q.ExactWhitespace,
+	}
+}
+
+// accepts reports whether a filling matches one of the accepted answers of the blank
+func (q *FillBlank) accepts(blank Blank, filling string) bool {
+	filling = q.normalize(filling)
+	return slices.ContainsFunc(blank.Answers, func(answer string) bool {
+		return q.normalize(answer) == filling
+	})
+}
+
+// normalize applies the matching rules of the question to a text
+func (q *FillBlank) normalize(text string) string {
+	if !q.ExactWhitespace {
+		text = strings.Join(strings.Fields(text), " ")
+	}
+	if !q.CaseSensitive {
+		text = strings.ToLower(text)
+	}
+	return text
+}
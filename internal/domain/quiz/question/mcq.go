@@ -0,0 +1,197 @@
+// internal/domain/quiz/question/mcq.go
+
+package question
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// Limits of the options of choice questions
+const (
+	MinOptions          = 2
+	MaxOptions          = 10
+	MaxOptionIDLength   = 20
+	MaxOptionTextLength = 500
+)
+
+// Option is a choice of a multiple choice or multi-select question. Answers refer to options by
+// ID, so that options can be shown in any order.
+type Option struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// Shuffler is implemented by questions whose options can be shown in any order
+type Shuffler interface {
+	Question
+
+	// Shuffled returns a copy of the question with its options reordered by shuffle, which works
+	// like rand.Shuffle
+	Shuffled(shuffle func(n int, swap func(i, j int))) Question
+}
+
+// MultipleChoice is a question with exactly one right option
+type MultipleChoice struct {
+	Options []Option `json:"options"`
+	Answer  string   `json:"answer,omitempty"` // ID of the right option
+}
+
+// Type returns the multiple choice type
+func (q *MultipleChoice) Type() Type {
+	return TypeMultipleChoice
+}
+
+// Validate checks the options and that the answer is one of them
+func (q *MultipleChoice) Validate() error {
+	options, err := validateOptions(q.Options)
+	if err != nil {
+		return err
+	}
+
+	answer := strings.TrimSpace(q.Answer)
+	if !hasOption(options, answer) {
+		return invalid("the answer must be the ID of one of the options")
+	}
+
+	q.Options = options
+	q.Answer = answer
+	return nil
+}
+
+// Grade awards full credit for the ID of the right option and nothing otherwise
+func (q *MultipleChoice) Grade(answer json.RawMessage) Result {
+	var chosen string
+	if !decodeAnswer(answer, &chosen) || strings.TrimSpace(chosen) != q.Answer {
+		return Result{}
+	}
+	return newResult(1, 1)
+}
+
+// StudentView returns the question without its answer
+func (q *MultipleChoice) StudentView() Question {
+	return &MultipleChoice{Options: slices.Clone(q.Options)}
+}
+
+// Shuffled returns a copy of the question with its options reordered
+func (q *MultipleChoice) Shuffled(shuffle func(n int, swap func(i, j int))) Question {
+	return &MultipleChoice{Options: shuffledOptions(q.Options, shuffle), Answer: q.Answer}
+}
+
+// MultiSelect is a question with one or more right options
+type MultiSelect struct {
+	Options []Option `json:"options"`
+	Answers []string `json:"answers,omitempty"` // IDs of the right options
+}
+
+// Type returns the multi-select type
+func (q *MultiSelect) Type() Type {
+	return TypeMultiSelect
+}
+
+// Validate checks the options and that the answers are distinct options
+func (q *MultiSelect) Validate() error {
+	options, err := validateOptions(q.Options)
+	if err != nil {
+		return err
+	}
+
+	answers := trimAll(q.Answers)
+	if len(answers) == 0 {
+		return invalid("at least one option must be right")
+	}
+	if !distinct(answers) {
+		return invalid("every right option must be listed once")
+	}
+	for _, answer := range answers {
+		if !hasOption(options, answer) {
+			return invalid("the answers must be IDs of the options")
+		}
+	}
+
+	q.Options = options
+	q.Answers = answers
+	return nil
+}
+
+// Grade awards credit for every right option selected, less every wrong one, as a fraction of the
+// right options. Selecting everything therefore earns no more than selecting nothing.
+func (q *MultiSelect) Grade(answer json.RawMessage) Result {
+	var chosen []string
+	if !decodeAnswer(answer, &chosen) {
+		return Result{}
+	}
+
+	right := 0
+	seen := make(map[string]bool, len(chosen))
+	for _, id := range trimAll(chosen) {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if slices.Contains(q.Answers, id) {
+			right++
+		} else {
+			right--
+		}
+	}
+
+	return newResult(right, len(q.Answers))
+}
+
+// StudentView returns the question without its answers
+func (q *MultiSelect) StudentView() Question {
+	return &MultiSelect{Options: slices.Clone(q.Options)}
+}
+
+// Shuffled returns a copy of the question with its options reordered
+func (q *MultiSelect) Shuffled(shuffle func(n int, swap func(i, j int))) Question {
+	return &MultiSelect{Options: shuffledOptions(q.Options, shuffle), Answers: slices.Clone(q.Answers)}
+}
+
+// shuffledOptions returns a copy of the options reordered by shuffle
+func shuffledOptions(options []Option, shuffle func(n int, swap func(i, j int))) []Option {
+	shuffled := slices.Clone(options)
+	shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// validateOptions trims the options and checks their number and that their IDs and texts are
+// distinct and not empty
+func validateOptions(options []Option) ([]Option, error) {
+	if len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, invalid("between %d and %d options are required", MinOptions, MaxOptions)
+	}
+
+	trimmed := make([]Option, len(options))
+	ids := make([]string, len(options))
+	texts := make([]string, len(options))
+	for i, option := range options {
+		trimmed[i] = Option{ID: strings.TrimSpace(option.ID), Text: strings.TrimSpace(option.Text)}
+		ids[i] = trimmed[i].ID
+		texts[i] = trimmed[i].Text
+
+		if trimmed[i].ID == "" || len(trimmed[i].ID) > MaxOptionIDLength {
+			return nil, invalid("option IDs of up to %d characters are required", MaxOptionIDLength)
+		}
+		if trimmed[i].Text == "" || len(trimmed[i].Text) > MaxOptionTextLength {
+			return nil, invalid("option texts of up to %d characters are required", MaxOptionTextLength)
+		}
+	}
+
+	if !distinct(ids) || !distinct(texts) {
+		return nil, invalid("options must be distinct")
+	}
+	return trimmed, nil
+}
+
+// hasOption reports whether an option has the ID
+func hasOption(options []Option, id string) bool {
+	return slices.ContainsFunc(options, func(option Option) bool {
+		return option.ID == id
+	})
+}
@@ -0,0 +1,223 @@
+package question
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// fakeRunner returns prepared executions instead of running programs
+type fakeRunner struct {
+	executions []Execution
+	err        error
+
+	runs int
+}
+
+func (r *fakeRunner) Run(ctx context.Context, program Program, inputs []string, limits Limits) ([]Execution, error) {
+	r.runs++
+	return r.executions, r.err
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+func options(ids ...string) []Option {
+	options := make([]Option, len(ids))
+	for i, id := range ids {
+		options[i] = Option{ID: id, Text: "option " + id}
+	}
+	return options
+}
+
+func assertResult(t *testing.T, got Result, wantScore float64) {
+	t.Helper()
+
+	if math.Abs(got.Score-wantScore) > 1e-9 || got.Correct != (wantScore == 1) {
+		t.Fatalf("expected a score of %v, got %+v", wantScore, got)
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	for _, tc := range []struct {
+		right, total int
+		want         float64
+	}{
+		{right: 0, total: 3, want: 0},
+		{right: -2, total: 3, want: 0},
+		{right: 1, total: 4, want: 0.25},
+		{right: 4, total: 4, want: 1},
+		{right: 5, total: 4, want: 1},
+		{right: 1, total: 0, want: 0},
+	} {
+		assertResult(t, newResult(tc.right, tc.total), tc.want)
+	}
+}
+
+func TestGrade(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		question Question
+		answer   string
+		want     float64
+	}{
+		{name: "multiple choice right", question: &MultipleChoice{Options: options("a", "b"), Answer: "b"}, answer: `" b "`, want: 1},
+		{name: "multiple choice wrong", question: &MultipleChoice{Options: options("a", "b"), Answer: "b"}, answer: `"a"`},
+		{name: "multiple choice malformed", question: &MultipleChoice{Options: options("a", "b"), Answer: "b"}, answer: `["b"]`},
+		{name: "multiple choice missing", question: &MultipleChoice{Options: options("a", "b"), Answer: "b"}, answer: ``},
+
+		{name: "multi-select all right", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["c","a"]`, want: 1},
+		{name: "multi-select half right", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["a"]`, want: 0.5},
+		{name: "multi-select wrong option cancels a right one", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["a","b"]`},
+		{name: "multi-select everything", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["a","b","c","d"]`},
+		{name: "multi-select repeated option counts once", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["a","a"]`, want: 0.5},
+		{name: "multi-select nothing", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `[]`},
+		{name: "multi-select more wrong than right", question: &MultiSelect{Options: options("a", "b", "c", "d"), Answers: []string{"a", "c"}}, answer: `["b","d","a"]`},
+
+		{name: "true or false right", question: &TrueFalse{Answer: boolPtr(false)}, answer: `false`, want: 1},
+		{name: "true or false wrong", question: &TrueFalse{Answer: boolPtr(false)}, answer: `true`},
+		{name: "true or false as a string", question: &TrueFalse{Answer: boolPtr(true)}, answer: `"true"`},
+		{name: "true or false without an answer key", question: &TrueFalse{}, answer: `false`},
+
+		{name: "fill blank single string", question: &FillBlank{Blanks: []Blank{{Answers: []string{"Paris"}}}}, answer: `"  paris "`, want: 1},
+		{name: "fill blank alternate", question: &FillBlank{Blanks: []Blank{{Answers: []string{"colour", "color"}}}}, answer: `["Color"]`, want: 1},
+		{name: "fill blank collapses spaces", question: &FillBlank{Blanks: []Blank{{Answers: []string{"New Delhi"}}}}, answer: `["new   delhi"]`, want: 1},
+		{name: "fill blank case sensitive", question: &FillBlank{Blanks: []Blank{{Answers: []string{"NaCl"}}}, CaseSensitive: true}, answer: `["nacl"]`},
+		{name: "fill blank exact whitespace", question: &FillBlank{Blanks: []Blank{{Answers: []string{"a b"}}}, ExactWhitespace: true}, answer: `["a  b"]`},
+		{name: "fill blank partial", question: &FillBlank{Blanks: []Blank{{Answers: []string{"1"}}, {Answers: []string{"2"}}, {Answers: []string{"3"}}, {Answers: []string{"4"}}}}, answer: `["1","x","3","4"]`, want: 0.75},
+		{name: "fill blank too few fillings", question: &FillBlank{Blanks: []Blank{{Answers: []string{"1"}}, {Answers: []string{"2"}}}}, answer: `["1"]`, want: 0.5},
+		{name: "fill blank extra fillings ignored", question: &FillBlank{Blanks: []Blank{{Answers: []string{"1"}}}}, answer: `["1","2"]`, want: 1},
+		{name: "fill blank malformed", question: &FillBlank{Blanks: []Blank{{Answers: []string{"1"}}}}, answer: `{"blank":"1"}`},
+
+		{name: "code without a runner", question: &Code{Language: "python", TestCases: []TestCase{{ExpectedOutput: "1"}}}, answer: `"print(1)"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assertResult(t, tc.question.Grade(json.RawMessage(tc.answer)), tc.want)
+		})
+	}
+}
+
+func TestCodeGradeRun(t *testing.T) {
+	question := &Code{
+		Language: "python",
+		TestCases: []TestCase{
+			{Input: "1 2", ExpectedOutput: "3\n"},
+			{Input: "2 2", ExpectedOutput: "4"},
+			{Input: "5 5", ExpectedOutput: "10", Hidden: true},
+			{Input: "0 0", ExpectedOutput: "0", Hidden: true},
+		},
+	}
+	if err := question.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		runner     *fakeRunner
+		answer     string
+		want       float64
+		wantPassed []bool
+		wantRuns   int
+		wantErr    bool
+	}{
+		{
+			name: "every test passes ignoring trailing spaces",
+			runner: &fakeRunner{executions: []Execution{
+				{Status: RunCompleted, Stdout: "3  \r\n"},
+				{Status: RunCompleted, Stdout: "4\n\n"},
+				{Status: RunCompleted, Stdout: "10"},
+				{Status: RunCompleted, Stdout: "0\n"},
+			}},
+			answer:     `"print(sum(map(int, input().split())))"`,
+			want:       1,
+			wantPassed: []bool{true, true, true, true},
+			wantRuns:   1,
+		},
+		{
+			name: "partial credit per passed test",
+			runner: &fakeRunner{executions: []Execution{
+				{Status: RunCompleted, Stdout: "3"},
+				{Status: RunCompleted, Stdout: "5"},
+				{Status: RunTimedOut},
+				{Status: RunRuntimeError, Stdout: "0", Stderr: "Traceback"},
+			}},
+			answer:     `"print(3)"`,
+			want:       0.25,
+			wantPassed: []bool{true, false, false, false},
+			wantRuns:   1,
+		},
+		{
+			name: "compile error passes nothing",
+			runner: &fakeRunner{executions: []Execution{
+				{Status: RunCompileError}, {Status: RunCompileError}, {Status: RunCompileError}, {Status: RunCompileError},
+			}},
+			answer:     `"print("`,
+			wantPassed: []bool{false, false, false, false},
+			wantRuns:   1,
+		},
+		{name: "empty answer is not run", runner: &fakeRunner{}, answer: `"  "`},
+		{name: "malformed answer is not run", runner: &fakeRunner{}, answer: `42`},
+		{name: "runner failure", runner: &fakeRunner{err: errors.New("sandbox down")}, answer: `"print(1)"`, wantRuns: 1, wantErr: true},
+		{name: "runner missing executions", runner: &fakeRunner{executions: []Execution{{Status: RunCompleted}}}, answer: `"print(1)"`, wantRuns: 1, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := question.GradeRun(context.Background(), tc.runner, json.RawMessage(tc.answer))
+			if tc.runner.runs != tc.wantRuns {
+				t.Fatalf("expected %d runs, got %d", tc.wantRuns, tc.runner.runs)
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GradeRun failed: %v", err)
+			}
+
+			assertResult(t, result, tc.want)
+			if len(result.Tests) != len(tc.wantPassed) {
+				t.Fatalf("expected %d test results, got %d", len(tc.wantPassed), len(result.Tests))
+			}
+			for i, test := range result.Tests {
+				if test.Passed != tc.wantPassed[i] {
+					t.Fatalf("expected test %d passed %v, got %+v", i+1, tc.wantPassed[i], test)
+				}
+				if test.Hidden && (test.Output != "" || test.Error != "") {
+					t.Fatalf("expected hidden test %d to report no output, got %+v", i+1, test)
+				}
+			}
+		})
+	}
+}
+
+func TestCodeGradeRunWithoutRunner(t *testing.T) {
+	question := &Code{Language: "python", TestCases: []TestCase{{ExpectedOutput: "1"}}}
+
+	if _, err := question.GradeRun(context.Background(), nil, json.RawMessage(`"print(1)"`)); !errors.Is(err, ErrRunnerUnavailable) {
+		t.Fatalf("expected %v, got %v", ErrRunnerUnavailable, err)
+	}
+}
+
+func TestStudentViewHidesAnswers(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		question Question
+		answer   string
+	}{
+		{name: "multiple choice", question: &MultipleChoice{Options: options("a", "b"), Answer: "a"}, answer: `"a"`},
+		{name: "multi-select", question: &MultiSelect{Options: options("a", "b"), Answers: []string{"a"}}, answer: `["a"]`},
+		{name: "true or false", question: &TrueFalse{Answer: boolPtr(true)}, answer: `true`},
+		{name: "fill blank", question: &FillBlank{Blanks: []Blank{{Answers: []string{"x"}}}}, answer: `"x"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			view := tc.question.StudentView()
+			if result := view.Grade(json.RawMessage(tc.answer)); result.Score != 0 {
+				t.Fatalf("expected the student view to hold no answer, got %+v from %+v", result, view)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// internal/domain/quiz/question/true_false.go
+
+package question
+
+import "encoding/json"
+
+// TrueFalse is a statement that is either true or false
+type TrueFalse struct {
+	Answer *bool `json:"answer,omitempty"`
+}
+
+// Type returns the true or false type
+func (q *TrueFalse) Type() Type {
+	return TypeTrueFalse
+}
+
+// Validate checks that the answer is set
+func (q *TrueFalse) Validate() error {
+	if q.Answer == nil {
+		return invalid("the answer must be true or false")
+	}
+	return nil
+}
+
+// Grade awards full credit for the right boolean and nothing otherwise
+func (q *TrueFalse) Grade(answer json.RawMessage) Result {
+	var chosen bool
+	if q.Answer == nil || !decodeAnswer(answer, &chosen) || chosen != *q.Answer {
+		return Result{}
+	}
+	return newResult(1, 1)
+}
+
+// StudentView returns the statement without its answer
+func (q *TrueFalse) StudentView() Question {
+	return &TrueFalse{}
+}
@@ -0,0 +1,123 @@
+// internal/domain/quiz/repository.go
+
+package quiz
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository defines the data access contract for quizzes, their questions, the question banks
+// and attempts
+type Repository interface {
+	// CreateQuiz creates a quiz and sets its ID
+	CreateQuiz(ctx context.Context, quiz *Quiz) error
+
+	// GetQuiz retrieves a quiz with its question count and total points, nil if it does not exist
+	GetQuiz(ctx context.Context, id int64) (*Quiz, error)
+
+	// ListQuizzes retrieves a page of quizzes and the total number matching the filters
+	ListQuizzes(ctx context.Context, req ListQuizzesRequest) ([]*Quiz, int, error)
+
+	// UpdateQuiz updates the details and published state of a quiz
+	UpdateQuiz(ctx context.Context, quiz *Quiz) error
+
+	// DeleteQuiz deletes a quiz and its questions. Quizzes that have been attempted can't be deleted.
+	DeleteQuiz(ctx context.Context, id int64) error
+
+	// GetQuestions retrieves the questions of a quiz in order
+	GetQuestions(ctx context.Context, quizID int64) ([]Question, error)
+
+	// AddQuestion inserts a question at its position, moving the following questions down.
+	// A position past the end appends the question.
+	AddQuestion(ctx context.Context, question *Question) error
+
+	// UpdateQuestion replaces a question of a quiz, moving it when its position changes
+	UpdateQuestion(ctx context.Context, question *Question) error
+
+	// DeleteQuestion deletes a question of a quiz and closes the gap it leaves
+	DeleteQuestion(ctx context.Context, quizID, questionID int64) error
+
+	// ReorderQuestions sets the order of the questions of a quiz. questionIDs must hold every question.
+	ReorderQuestions(ctx context.Context, quizID int64, questionIDs []int64) error
+
+	// CreateQuestionBank creates a question bank and sets its ID
+	CreateQuestionBank(ctx context.Context, bank *QuestionBank) error
+
+	// GetQuestionBank retrieves a question bank with its question count, nil if it does not exist
+	GetQuestionBank(ctx context.Context, id int64) (*QuestionBank, error)
+
+	// ListQuestionBanks retrieves the question banks matching the filters, by name
+	ListQuestionBanks(ctx context.Context, req ListQuestionBanksRequest) ([]*QuestionBank, error)
+
+	// UpdateQuestionBank updates the details of a question bank
+	UpdateQuestionBank(ctx context.Context, bank *QuestionBank) error
+
+	// DeleteQuestionBank deletes a question bank and its questions. Papers already drawn from them
+	// keep their copies.
+	DeleteQuestionBank(ctx context.Context, id int64) error
+
+	// GetBankQuestions retrieves the questions of a bank in the order they were added
+	GetBankQuestions(ctx context.Context, bankID int64) ([]BankQuestion, error)
+
+	// GetBankQuestionsByID retrieves bank questions by ID, in no particular order
+	GetBankQuestionsByID(ctx context.Context, ids []int64) ([]BankQuestion, error)
+
+	// AddBankQuestion adds a question to a bank and sets its ID
+	AddBankQuestion(ctx context.Context, question *BankQuestion) error
+
+	// UpdateBankQuestion replaces a question of a bank
+	UpdateBankQuestion(ctx context.Context, question *BankQuestion) error
+
+	// DeleteBankQuestion deletes a question of a bank
+	DeleteBankQuestion(ctx context.Context, bankID, questionID int64) error
+
+	// ListPoolQuestionIDs retrieves the IDs of the bank questions a blueprint rule draws from, in
+	// ascending order
+	ListPoolQuestionIDs(ctx context.Context, rule BlueprintRule) ([]int64, error)
+
+	// CreateAttempt starts an attempt with its paper and sets its ID. It fails with
+	// ErrAttemptLimitReached when the student already has maxConcurrent attempts of the quiz in
+	// progress and before their deadline.
+	CreateAttempt(ctx context.Context, attempt *Attempt, paper []PaperQuestion, maxConcurrent int) error
+
+	// GetAttempt retrieves an attempt, nil if it does not exist
+	GetAttempt(ctx context.Context, id int64) (*Attempt, error)
+
+	// GetPaper retrieves the questions of an attempt in order
+	GetPaper(ctx context.Context, attemptID int64) ([]PaperQuestion, error)
+
+	// ListAttempts retrieves the attempts of a student, the latest first
+	ListAttempts(ctx context.Context, studentID uuid.UUID) ([]*Attempt, error)
+
+	// ListExpiredAttempts retrieves attempts still in progress whose deadline is before the time,
+	// the longest overdue first. Attempts postponed past now are left out.
+	ListExpiredAttempts(ctx context.Context, before, now time.Time, limit int) ([]*Attempt, error)
+
+	// ListAttemptsToRegrade retrieves submitted attempts with answers flagged to be graded again,
+	// the earliest submitted first. Attempts postponed past now are left out.
+	ListAttemptsToRegrade(ctx context.Context, now time.Time, limit int) ([]*Attempt, error)
+
+	// PostponeSweep counts a failure to submit an expired attempt, or to regrade a submitted one,
+	// and leaves it out of the sweeps for delay, doubled with every earlier failure up to maxDelay
+	PostponeSweep(ctx context.Context, attemptID int64, now time.Time, delay, maxDelay time.Duration) error
+
+	// GetAttemptAnswers retrieves the answers of an attempt
+	GetAttemptAnswers(ctx context.Context, attemptID int64) ([]GradedAnswer, error)
+
+	// SaveAnswer stores or replaces the answer to a question of the attempt's paper. It returns
+	// false without saving when the attempt is no longer in progress, its deadline is before
+	// openAfter or the question is not part of the paper.
+	SaveAnswer(ctx context.Context, attemptID, questionID int64, answer json.RawMessage, savedAt, openAfter time.Time) (bool, error)
+
+	// SubmitAttempt stores the graded answers and the score of an attempt in progress. It returns
+	// false when the attempt was already submitted.
+	SubmitAttempt(ctx context.Context, attempt *Attempt, answers []GradedAnswer) (bool, error)
+
+	// RegradeAttempt stores the answers of a submitted attempt graded again along with its new
+	// score and outcome. Only answers still flagged to be graded again are replaced.
+	RegradeAttempt(ctx context.Context, attempt *Attempt, answers []GradedAnswer) error
+}
@@ -0,0 +1,430 @@
+// internal/domain/quiz/service.go
+
+package quiz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/quiz/question"
+	"server/pkg/logger"
+)
+
+// Common errors
+var (
+	ErrQuizNotFound         = errors.New("quiz not found")
+	ErrQuestionNotFound     = errors.New("question not found")
+	ErrAttemptNotFound      = errors.New("attempt not found")
+	ErrInvalidQuiz          = errors.New("invalid quiz")
+	ErrInvalidQuestion      = errors.New("invalid question")
+	ErrInvalidQuestionOrder = errors.New("invalid question order")
+	ErrQuizPublished        = errors.New("quiz is published")
+	ErrQuizNotPublished     = errors.New("quiz is not published")
+	ErrQuizHasNoQuestions   = errors.New("quiz has no questions")
+	ErrQuizHasAttempts      = errors.New("quiz has attempts")
+	ErrQuizHasQuestions     = errors.New("quiz has questions of its own")
+	ErrQuizHasBlueprint     = errors.New("quiz draws its questions from question banks")
+	ErrNotEnoughQuestions   = errors.New("not enough questions in the question banks")
+	ErrBankNotFound         = errors.New("question bank not found")
+	ErrInvalidBank          = errors.New("invalid question bank")
+	ErrAttemptSubmitted     = errors.New("attempt already submitted")
+	ErrAttemptExpired       = errors.New("attempt deadline has passed")
+	ErrAttemptLimitReached  = errors.New("too many attempts in progress")
+	ErrNotAttemptOwner      = errors.New("attempt belongs to another student")
+)
+
+// Defaults of quizzes and questions created without them
+const (
+	defaultQuestionPoints        = 1
+	defaultMaxConcurrentAttempts = 1
+)
+
+// Service manages quizzes and their ordered questions
+type Service interface {
+	ListQuizzes(ctx context.Context, req ListQuizzesRequest) (*QuizList, error)
+	GetQuiz(ctx context.Context, id int64) (*Quiz, error)
+	// ListPublishedQuizzes and GetPublishedQuiz hide drafts from the callers who take quizzes
+	ListPublishedQuizzes(ctx context.Context, req ListQuizzesRequest) (*QuizList, error)
+	GetPublishedQuiz(ctx context.Context, id int64) (*Quiz, error)
+	CreateQuiz(ctx context.Context, actorID uuid.UUID, req QuizRequest) (*Quiz, error)
+	// UpdateQuiz replaces the details of a quiz. Publishing requires at least one question, or a
+	// blueprint the question banks have enough questions for.
+	UpdateQuiz(ctx context.Context, id int64, req QuizRequest) (*Quiz, error)
+	// DeleteQuiz deletes a quiz that has never been attempted
+	DeleteQuiz(ctx context.Context, id int64) error
+
+	// Questions, with their answers, for the authors of a quiz. The questions of a published quiz
+	// can't be changed, and quizzes with a blueprint have none.
+	GetQuestions(ctx context.Context, quizID int64) ([]Question, error)
+	AddQuestion(ctx context.Context, quizID int64, req QuestionRequest) (*Question, error)
+	UpdateQuestion(ctx context.Context, quizID, questionID int64, req QuestionRequest) (*Question, error)
+	DeleteQuestion(ctx context.Context, quizID, questionID int64) error
+	ReorderQuestions(ctx context.Context, quizID int64, req ReorderQuestionsRequest) ([]Question, error)
+}
+
+type service struct {
+	repo   Repository
+	logger logger.Logger
+}
+
+// NewService creates a new quiz service
+func NewService(repo Repository, logger logger.Logger) Service {
+	return &service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ListQuizzes retrieves a page of quizzes
+func (s *service) ListQuizzes(ctx context.Context, req ListQuizzesRequest) (*QuizList, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	req.Search = strings.TrimSpace(req.Search)
+
+	quizzes, total, err := s.repo.ListQuizzes(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quizzes: %w", err)
+	}
+
+	return &QuizList{
+		Quizzes:  quizzes,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// GetQuiz retrieves a quiz
+func (s *service) GetQuiz(ctx context.Context, id int64) (*Quiz, error) {
+	quiz, err := s.repo.GetQuiz(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+	if quiz == nil {
+		return nil, ErrQuizNotFound
+	}
+
+	return quiz, nil
+}
+
+// ListPublishedQuizzes retrieves a page of published quizzes
+func (s *service) ListPublishedQuizzes(ctx context.Context, req ListQuizzesRequest) (*QuizList, error) {
+	published := true
+	req.Published = &published
+	return s.ListQuizzes(ctx, req)
+}
+
+// GetPublishedQuiz retrieves a published quiz, drafts are reported as not found
+func (s *service) GetPublishedQuiz(ctx context.Context, id int64) (*Quiz, error) {
+	quiz, err := s.GetQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !quiz.Published {
+		return nil, ErrQuizNotFound
+	}
+
+	return quiz, nil
+}
+
+// CreateQuiz creates a draft quiz, it can only be published once it has questions or a blueprint
+func (s *service) CreateQuiz(ctx context.Context, actorID uuid.UUID, req QuizRequest) (*Quiz, error) {
+	if req.Published {
+		return nil, ErrQuizHasNoQuestions
+	}
+
+	now := time.Now()
+	quiz := &Quiz{CreatedBy: actorID, CreatedAt: now}
+	if err := applyQuizRequest(quiz, req, now); err != nil {
+		return nil, err
+	}
+	quiz.QuestionCount = quiz.Blueprint.QuestionCount()
+	quiz.TotalPoints = quiz.Blueprint.TotalPoints()
+
+	if err := s.repo.CreateQuiz(ctx, quiz); err != nil {
+		return nil, fmt.Errorf("failed to create quiz: %w", err)
+	}
+
+	s.logger.Info("Quiz created", "quiz_id", quiz.ID, "actor_id", actorID)
+	return quiz, nil
+}
+
+// UpdateQuiz replaces the details of a quiz and publishes or unpublishes it. The blueprint of a
+// published quiz can't be changed.
+func (s *service) UpdateQuiz(ctx context.Context, id int64, req QuizRequest) (*Quiz, error) {
+	quiz, err := s.GetQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The question count of a quiz with a blueprint is that of its papers
+	ownQuestions := quiz.QuestionCount
+	if len(quiz.Blueprint) > 0 {
+		ownQuestions = 0
+	}
+
+	wasPublished := quiz.Published
+	previousBlueprint := quiz.Blueprint
+	if err := applyQuizRequest(quiz, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(quiz.Blueprint) > 0 && ownQuestions > 0:
+		return nil, ErrQuizHasQuestions
+	case wasPublished && quiz.Published && !slices.Equal(quiz.Blueprint, previousBlueprint):
+		return nil, ErrQuizPublished
+	case quiz.Published && len(quiz.Blueprint) == 0 && ownQuestions == 0:
+		return nil, ErrQuizHasNoQuestions
+	}
+
+	if quiz.Published && len(quiz.Blueprint) > 0 {
+		if err := s.checkBlueprint(ctx, quiz.Blueprint); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(quiz.Blueprint) > 0 || len(previousBlueprint) > 0 {
+		quiz.QuestionCount = quiz.Blueprint.QuestionCount()
+		quiz.TotalPoints = quiz.Blueprint.TotalPoints()
+	}
+
+	if err := s.repo.UpdateQuiz(ctx, quiz); err != nil {
+		return nil, err
+	}
+
+	if quiz.Published != wasPublished {
+		s.logger.Info("Quiz published state changed", "quiz_id", quiz.ID, "published", quiz.Published)
+	}
+	return quiz, nil
+}
+
+// DeleteQuiz deletes a quiz along with its questions
+func (s *service) DeleteQuiz(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteQuiz(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.Info("Quiz deleted", "quiz_id", id)
+	return nil
+}
+
+// GetQuestions retrieves the questions of a quiz in order
+func (s *service) GetQuestions(ctx context.Context, quizID int64) ([]Question, error) {
+	if _, err := s.GetQuiz(ctx, quizID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetQuestions(ctx, quizID)
+}
+
+// AddQuestion adds a question to a draft quiz
+func (s *service) AddQuestion(ctx context.Context, quizID int64, req QuestionRequest) (*Question, error) {
+	if _, err := s.getDraftQuiz(ctx, quizID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	question := &Question{QuizID: quizID, CreatedAt: now}
+	if err := applyQuestionRequest(question, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddQuestion(ctx, question); err != nil {
+		return nil, fmt.Errorf("failed to add question: %w", err)
+	}
+
+	return question, nil
+}
+
+// UpdateQuestion replaces a question of a draft quiz. Without a position the question keeps its place.
+func (s *service) UpdateQuestion(ctx context.Context, quizID, questionID int64, req QuestionRequest) (*Question, error) {
+	if _, err := s.getDraftQuiz(ctx, quizID); err != nil {
+		return nil, err
+	}
+
+	question := &Question{ID: questionID, QuizID: quizID}
+	if err := applyQuestionRequest(question, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateQuestion(ctx, question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// DeleteQuestion deletes a question of a draft quiz
+func (s *service) DeleteQuestion(ctx context.Context, quizID, questionID int64) error {
+	if _, err := s.getDraftQuiz(ctx, quizID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteQuestion(ctx, quizID, questionID)
+}
+
+// ReorderQuestions sets the order of every question of a draft quiz
+func (s *service) ReorderQuestions(ctx context.Context, quizID int64, req ReorderQuestionsRequest) ([]Question, error) {
+	quiz, err := s.getDraftQuiz(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := slices.Clone(req.QuestionIDs)
+	slices.Sort(unique)
+	if len(req.QuestionIDs) != quiz.QuestionCount || len(slices.Compact(unique)) != len(req.QuestionIDs) {
+		return nil, fmt.Errorf("%w: every question of the quiz must be listed once", ErrInvalidQuestionOrder)
+	}
+
+	if err := s.repo.ReorderQuestions(ctx, quizID, req.QuestionIDs); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetQuestions(ctx, quizID)
+}
+
+// getDraftQuiz retrieves a quiz whose questions may be changed
+func (s *service) getDraftQuiz(ctx context.Context, id int64) (*Quiz, error) {
+	quiz, err := s.GetQuiz(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if quiz.Published {
+		return nil, ErrQuizPublished
+	}
+	if len(quiz.Blueprint) > 0 {
+		return nil, ErrQuizHasBlueprint
+	}
+
+	return quiz, nil
+}
+
+// checkBlueprint checks that the question banks have enough questions for every rule of a
+// blueprint. Rules don't overlap, so every rule is checked against its own pool.
+func (s *service) checkBlueprint(ctx context.Context, blueprint Blueprint) error {
+	for i, rule := range blueprint {
+		ids, err := s.repo.ListPoolQuestionIDs(ctx, rule)
+		if err != nil {
+			return fmt.Errorf("failed to list pool questions: %w", err)
+		}
+		if len(ids) < rule.Count {
+			return fmt.Errorf("%w: rule %d draws %d questions from %d", ErrNotEnoughQuestions, i+1, rule.Count, len(ids))
+		}
+	}
+
+	return nil
+}
+
+// applyQuizRequest validates a quiz request and copies it onto the quiz
+func applyQuizRequest(quiz *Quiz, req QuizRequest, now time.Time) error {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > 200 {
+		return fmt.Errorf("%w: title of up to 200 characters is required", ErrInvalidQuiz)
+	}
+	if req.TimeLimitMinutes < 1 {
+		return fmt.Errorf("%w: time limit must be at least a minute", ErrInvalidQuiz)
+	}
+	if req.PassingScore < 0 || req.PassingScore > 100 {
+		return fmt.Errorf("%w: passing score must be a percentage", ErrInvalidQuiz)
+	}
+	blueprint, err := newBlueprint(req.Blueprint)
+	if err != nil {
+		return err
+	}
+
+	if req.Published && !quiz.Published {
+		quiz.PublishedAt = &now
+	}
+	if !req.Published {
+		quiz.PublishedAt = nil
+	}
+
+	quiz.Title = title
+	quiz.Description = strings.TrimSpace(req.Description)
+	quiz.DomainID = req.DomainID
+	quiz.SubDomainID = req.SubDomainID
+	quiz.DifficultyLevelID = req.DifficultyLevelID
+	quiz.TimeLimitMinutes = req.TimeLimitMinutes
+	quiz.PassingScore = req.PassingScore
+	quiz.MaxConcurrentAttempts = req.MaxConcurrentAttempts
+	if quiz.MaxConcurrentAttempts == 0 {
+		quiz.MaxConcurrentAttempts = defaultMaxConcurrentAttempts
+	}
+	quiz.Blueprint = blueprint
+	quiz.Published = req.Published
+	quiz.UpdatedAt = now
+	return nil
+}
+
+// newBlueprint validates the rules of a blueprint and sets their default points. Rules can't
+// overlap, so that no question could be drawn by two rules of the same paper.
+func newBlueprint(rules []BlueprintRule) (Blueprint, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	blueprint := make(Blueprint, 0, len(rules))
+	for _, rule := range rules {
+		if rule.DomainID == 0 || rule.DifficultyLevelID == 0 {
+			return nil, fmt.Errorf("%w: every blueprint rule needs a domain and a difficulty level", ErrInvalidQuiz)
+		}
+		if rule.Count < 1 {
+			return nil, fmt.Errorf("%w: every blueprint rule must draw at least one question", ErrInvalidQuiz)
+		}
+		if rule.Points < 0 {
+			return nil, fmt.Errorf("%w: points can't be negative", ErrInvalidQuiz)
+		}
+		if rule.Points == 0 {
+			rule.Points = defaultQuestionPoints
+		}
+
+		for _, other := range blueprint {
+			if other.DomainID == rule.DomainID && other.DifficultyLevelID == rule.DifficultyLevelID &&
+				(other.SubDomainID == rule.SubDomainID || other.SubDomainID == 0 || rule.SubDomainID == 0) {
+				return nil, fmt.Errorf("%w: blueprint rules can't draw from the same question banks", ErrInvalidQuiz)
+			}
+		}
+		blueprint = append(blueprint, rule)
+	}
+
+	return blueprint, nil
+}
+
+// applyQuestionRequest validates a question request and copies it onto the question
+func applyQuestionRequest(q *Question, req QuestionRequest, now time.Time) error {
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		return fmt.Errorf("%w: prompt is required", ErrInvalidQuestion)
+	}
+	if req.Points < 0 {
+		return fmt.Errorf("%w: points can't be negative", ErrInvalidQuestion)
+	}
+
+	content, err := question.Decode(req.Type, req.Content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+
+	q.Position = req.Position
+	q.Type = content.Type()
+	q.Prompt = prompt
+	q.Content = content
+	q.Explanation = strings.TrimSpace(req.Explanation)
+	q.Points = req.Points
+	if q.Points == 0 {
+		q.Points = defaultQuestionPoints
+	}
+	q.UpdatedAt = now
+	return nil
+}
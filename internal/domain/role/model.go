@@ -1,22 +1,29 @@
 package role
 
 import (
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Role represents a role in the system with associated permissions
 type Role struct {
-	ID          uint         `json:"id"`
+	ID          uuid.UUID    `json:"id"`
 	Name        string       `json:"name"`
 	Description string       `json:"description"`
 	Permissions []Permission `json:"permissions"`
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// InheritsFrom is the role whose permissions this role includes as well,
+	// e.g. admin inherits from coordinator, which inherits from volunteer
+	InheritsFrom *uuid.UUID `json:"inherits_from,omitempty"`
 }
 
 // Permission represents an action that can be performed in the system
 type Permission struct {
-	ID          uint      `json:"id"`
+	ID          uuid.UUID `json:"id"`
 	Resource    string    `json:"resource"` // e.g., "student", "quiz", "event"
 	Action      string    `json:"action"`   // e.g., "create", "read", "update", "delete"
 	Description string    `json:"description"`
@@ -24,33 +31,202 @@ type Permission struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// UserRole represents the relationship between users and roles
-type UserRole struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"user_id"`
-	RoleID    uint      `json:"role_id"`
+// ProfileRole represents the relationship between platform profiles and roles.
+// Roles are assigned to platform profiles, the same IDs as platform_profile.ProfileRole.
+// A profile may hold the same role in several scopes.
+type ProfileRole struct {
+	ProfileID uuid.UUID `json:"profile_id"`
+	RoleID    uuid.UUID `json:"role_id"`
+	Scope     Scope     `json:"scope"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AssignedRole is a role held by a profile along with the scope of the assignment
+type AssignedRole struct {
+	Role
+	Scope Scope `json:"scope"`
+}
+
+// Scope restricts a role assignment to the students of a branch and/or batch, and to their
+// platform profiles. Empty fields match everything, the zero Scope is unrestricted.
+type Scope struct {
+	// Branch is the short name of a branch, e.g. "CSE", as in StudentAcademicDetailsTable.Branch
+	Branch string `json:"branch,omitempty"`
+	// Batch is the admission year of a batch, e.g. "2023" for Student.Batch "2023-2027"
+	Batch string `json:"batch,omitempty"`
+}
+
+// IsZero reports whether the scope is unrestricted
+func (s Scope) IsZero() bool {
+	return s.Branch == "" && s.Batch == ""
+}
+
+// Normalize trims the scope, uppercases the branch and validates both fields
+func (s Scope) Normalize() (Scope, error) {
+	s.Branch = strings.ToUpper(strings.TrimSpace(s.Branch))
+	s.Batch = strings.TrimSpace(s.Batch)
+
+	if len(s.Branch) > MaxScopeBranchLength {
+		return s, ErrInvalidScope
+	}
+	if s.Batch != "" {
+		if len(s.Batch) != 4 || strings.Trim(s.Batch, "0123456789") != "" {
+			return s, ErrInvalidScope
+		}
+	}
+	return s, nil
+}
+
+// Matches reports whether a student of the branch and batch is within the scope.
+// The batch may be an admission year or a range starting with it, like "2023-2027".
+func (s Scope) Matches(branch, batch string) bool {
+	if s.Branch != "" && !strings.EqualFold(s.Branch, branch) {
+		return false
+	}
+	if s.Batch != "" && batch != s.Batch && !strings.HasPrefix(batch, s.Batch+"-") {
+		return false
+	}
+	return true
+}
+
+// MaxScopeBranchLength is the length of the branch column of the academic details
+const MaxScopeBranchLength = 7
+
+// ScopedPermission is a permission granted to a profile through a role assignment of the scope
+type ScopedPermission struct {
+	Permission
+	Scope Scope `json:"scope"`
+}
+
+// AccessScope is the part of the data a permission covers, the union of the scopes of every
+// assignment granting it. An unrestricted access scope covers everything, the zero value nothing.
+type AccessScope struct {
+	Unrestricted bool    `json:"unrestricted"`
+	Scopes       []Scope `json:"scopes,omitempty"`
+}
+
+// UnrestrictedAccess covers everything
+var UnrestrictedAccess = AccessScope{Unrestricted: true}
+
+// Allows reports whether a student of the branch and batch is within the access scope
+func (a AccessScope) Allows(branch, batch string) bool {
+	if a.Unrestricted {
+		return true
+	}
+	for _, scope := range a.Scopes {
+		if scope.Matches(branch, batch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether every student of the scope is within the access scope. Only an
+// unrestricted access scope covers the zero Scope.
+func (a AccessScope) Covers(scope Scope) bool {
+	if a.Unrestricted {
+		return true
+	}
+	if scope.IsZero() {
+		return false
+	}
+	for _, s := range a.Scopes {
+		if (s.Branch == "" || strings.EqualFold(s.Branch, scope.Branch)) && (s.Batch == "" || s.Batch == scope.Batch) {
+			return true
+		}
+	}
+	return false
+}
+
+// union adds the scope to the access scope. An unrestricted scope makes it unrestricted.
+func (a AccessScope) union(scopes ...Scope) AccessScope {
+	for _, scope := range scopes {
+		if a.Unrestricted {
+			break
+		}
+		if scope.IsZero() {
+			return UnrestrictedAccess
+		}
+
+		seen := false
+		for _, existing := range a.Scopes {
+			if existing == scope {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			a.Scopes = append(a.Scopes, scope)
+		}
+	}
+	return a
 }
 
 // RolePermission represents the relationship between roles and permissions
 type RolePermission struct {
-	ID           uint      `json:"id"`
-	RoleID       uint      `json:"role_id"`
-	PermissionID uint      `json:"permission_id"`
+	RoleID       uuid.UUID `json:"role_id"`
+	PermissionID uuid.UUID `json:"permission_id"`
 	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PermissionSet is the effective set of permissions of a profile, the union of its roles' permissions
+// and the permissions they inherit, along with the scope each is granted in
+type PermissionSet map[string]map[string]AccessScope
+
+// NewPermissionSet builds a permission set from a list of scoped permissions
+func NewPermissionSet(permissions []ScopedPermission) PermissionSet {
+	set := make(PermissionSet)
+	for _, permission := range permissions {
+		if set[permission.Resource] == nil {
+			set[permission.Resource] = make(map[string]AccessScope)
+		}
+		actions := set[permission.Resource]
+		actions[permission.Action] = actions[permission.Action].union(permission.Scope)
+	}
+	return set
+}
+
+// Allows reports whether the set grants the action on the resource, in any scope.
+// ActionManage on a resource grants every action on it.
+func (s PermissionSet) Allows(resource, action string) bool {
+	_, ok := s.AccessScope(resource, action)
+	return ok
+}
+
+// AccessScope returns the scope the action on the resource is granted in, if it is granted.
+// The scope of ActionManage on the resource is included.
+func (s PermissionSet) AccessScope(resource, action string) (AccessScope, bool) {
+	actions := s[resource]
+	exact, granted := actions[action]
+	manage, managed := actions[ActionManage]
+	if !granted && !managed {
+		return AccessScope{}, false
+	}
+
+	// The cached set is shared, the scopes are copied before adding to them
+	access := AccessScope{Unrestricted: exact.Unrestricted, Scopes: append([]Scope(nil), exact.Scopes...)}
+	if managed {
+		if manage.Unrestricted {
+			return UnrestrictedAccess, true
+		}
+		access = access.union(manage.Scopes...)
+	}
+	return access, true
 }
 
 // Common predefined roles
 const (
 	RoleAdmin       = "admin"
 	RoleCoordinator = "coordinator"
+	RoleVolunteer   = "volunteer"
 	RoleStudent     = "student"
 	RoleFaculty     = "faculty"
 	RoleGuest       = "guest"
 )
 
+// DefaultRole is assigned to newly registered profiles
+const DefaultRole = RoleGuest
+
 // Permission actions
 const (
 	ActionCreate = "create"
@@ -2,36 +2,50 @@ package role
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
-// Repository defines the interface for role data access
+// Repository defines the interface for role data access.
+// Getters return nil when the role or permission does not exist.
 type Repository interface {
 	// Role operations
 	CreateRole(ctx context.Context, role *Role) error
-	GetRoleByID(ctx context.Context, id uint) (*Role, error)
+	GetRoleByID(ctx context.Context, id uuid.UUID) (*Role, error)
 	GetRoleByName(ctx context.Context, name string) (*Role, error)
 	ListRoles(ctx context.Context) ([]Role, error)
 	UpdateRole(ctx context.Context, role *Role) error
-	DeleteRole(ctx context.Context, id uint) error
+	DeleteRole(ctx context.Context, id uuid.UUID) error
 
 	// Permission operations
 	CreatePermission(ctx context.Context, permission *Permission) error
-	GetPermissionByID(ctx context.Context, id uint) (*Permission, error)
+	GetPermissionByID(ctx context.Context, id uuid.UUID) (*Permission, error)
 	ListPermissions(ctx context.Context) ([]Permission, error)
 	UpdatePermission(ctx context.Context, permission *Permission) error
-	DeletePermission(ctx context.Context, id uint) error
+	DeletePermission(ctx context.Context, id uuid.UUID) error
 
 	// Role-Permission operations
-	AssignPermissionToRole(ctx context.Context, roleID, permissionID uint) error
-	RemovePermissionFromRole(ctx context.Context, roleID, permissionID uint) error
-	GetRolePermissions(ctx context.Context, roleID uint) ([]Permission, error)
+	AssignPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID) error
+	RemovePermissionFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error
+	GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]Permission, error)
+	// SyncRolePermissions replaces the permissions of a role
+	SyncRolePermissions(ctx context.Context, roleID uuid.UUID, permissionIDs []uuid.UUID) error
 
-	// User-Role operations
-	AssignRoleToUser(ctx context.Context, userID, roleID uint) error
-	RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error
-	GetUserRoles(ctx context.Context, userID uint) ([]Role, error)
-	GetUsersWithRole(ctx context.Context, roleID uint) ([]uint, error) // Returns user IDs
+	// Profile-Role operations
+	// AssignRoleToProfile assigns a role in a scope, the zero Scope being unrestricted
+	AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID, scope Scope) error
+	// RemoveRoleFromProfile removes the role in every scope it is assigned in
+	RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error
+	GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]AssignedRole, error)
+	GetProfilesWithRole(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) // Returns profile IDs
+	// SyncProfileRoles replaces the role assignments of a profile
+	SyncProfileRoles(ctx context.Context, profileID uuid.UUID, assignments []ProfileRole) error
 
 	// Special queries
-	HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error)
+	// GetProfilePermissions returns the permissions of every role assigned to the platform profile
+	// and of the roles they inherit from, each with the scope of the assignment granting it
+	GetProfilePermissions(ctx context.Context, profileID uuid.UUID) ([]ScopedPermission, error)
+	// HasPermission checks the permissions of every role assigned to the platform profile, in any
+	// scope, including inherited ones. ActionManage on the resource grants every action.
+	HasPermission(ctx context.Context, profileID uuid.UUID, resource, action string) (bool, error)
 }
@@ -0,0 +1,39 @@
+package role
+
+import "context"
+
+// accessScopeKey is the context key of the access scope of a request
+type accessScopeKey struct{}
+
+// roleNamesKey is the context key of the role names of the caller of a request
+type roleNamesKey struct{}
+
+// WithAccessScope returns a context carrying the scope the caller was authorized in.
+// The authentication middleware stores it for every permission it checks.
+func WithAccessScope(ctx context.Context, access AccessScope) context.Context {
+	return context.WithValue(ctx, accessScopeKey{}, access)
+}
+
+// AccessScopeFromContext returns the scope the caller was authorized in. Contexts that didn't go
+// through a permission check reach nothing; workers and profiles acting on themselves store
+// UnrestrictedAccess explicitly.
+func AccessScopeFromContext(ctx context.Context) AccessScope {
+	access, ok := ctx.Value(accessScopeKey{}).(AccessScope)
+	if !ok {
+		return AccessScope{}
+	}
+	return access
+}
+
+// WithRoleNames returns a context carrying the names of the roles assigned to the caller.
+// The authentication middleware stores them for every authenticated request.
+func WithRoleNames(ctx context.Context, names []string) context.Context {
+	return context.WithValue(ctx, roleNamesKey{}, names)
+}
+
+// RoleNamesFromContext returns the names of the roles assigned to the caller, none for contexts
+// that weren't authenticated
+func RoleNamesFromContext(ctx context.Context) []string {
+	names, _ := ctx.Value(roleNamesKey{}).([]string)
+	return names
+}
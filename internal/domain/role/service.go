@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+
+	"server/pkg/logger"
 )
 
 // Common errors
@@ -16,6 +21,8 @@ var (
 	ErrDuplicatePermission = errors.New("permission already exists")
 	ErrInvalidRole         = errors.New("invalid role data")
 	ErrInvalidPermission   = errors.New("invalid permission data")
+	ErrInvalidScope        = errors.New("invalid role scope")
+	ErrRoleCycle           = errors.New("role can't inherit from itself")
 	ErrUnauthorized        = errors.New("user does not have required permission")
 )
 
@@ -23,61 +30,73 @@ var (
 type Service interface {
 	// Role operations
 	CreateRole(ctx context.Context, role *Role) error
-	GetRoleByID(ctx context.Context, id uint) (*Role, error)
+	GetRoleByID(ctx context.Context, id uuid.UUID) (*Role, error)
 	GetRoleByName(ctx context.Context, name string) (*Role, error)
 	ListRoles(ctx context.Context) ([]Role, error)
 	UpdateRole(ctx context.Context, role *Role) error
-	DeleteRole(ctx context.Context, id uint) error
+	DeleteRole(ctx context.Context, id uuid.UUID) error
 
 	// Permission operations
 	CreatePermission(ctx context.Context, permission *Permission) error
-	GetPermissionByID(ctx context.Context, id uint) (*Permission, error)
+	GetPermissionByID(ctx context.Context, id uuid.UUID) (*Permission, error)
 	ListPermissions(ctx context.Context) ([]Permission, error)
 	UpdatePermission(ctx context.Context, permission *Permission) error
-	DeletePermission(ctx context.Context, id uint) error
+	DeletePermission(ctx context.Context, id uuid.UUID) error
 
 	// Role-Permission operations
-	AssignPermissionToRole(ctx context.Context, roleID, permissionID uint) error
-	RemovePermissionFromRole(ctx context.Context, roleID, permissionID uint) error
-	GetRolePermissions(ctx context.Context, roleID uint) ([]Permission, error)
+	AssignPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID) error
+	RemovePermissionFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error
+	GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]Permission, error)
 	GetDefaultRoleID(ctx context.Context) (uuid.UUID, error)
 
-	// User-Role operations
-	AssignRoleToUser(ctx context.Context, userID, roleID uint) error
-	RemoveRoleFromUser(ctx context.Context, userID, roleID uint) error
-	GetUserRoles(ctx context.Context, userID uint) ([]Role, error)
+	// Profile-Role operations. Assignments may be scoped to a branch and/or batch.
+	AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID, scope Scope) error
+	RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error
+	GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]AssignedRole, error)
+
+	// Permission checking, answered from the cached permission set of the profile.
+	// ActionManage on a resource grants every action on it.
+	HasPermission(ctx context.Context, profileID uuid.UUID, resource, action string) (bool, error)
+	Authorize(ctx context.Context, profileID uuid.UUID, resource, action string) error
+	GetProfilePermissions(ctx context.Context, profileID uuid.UUID) (PermissionSet, error)
+	// GetAccessScope returns the scope the action on the resource is granted in, and false if it isn't
+	GetAccessScope(ctx context.Context, profileID uuid.UUID, resource, action string) (AccessScope, bool, error)
 
-	// Permission checking
-	HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error)
-	Authorize(ctx context.Context, userID uint, resource, action string) error
+	// InvalidateProfiles drops the cached permissions of profiles whose roles were changed
+	// outside of this service, like the bulk role assignment of platform profiles
+	InvalidateProfiles(profileIDs ...uuid.UUID)
 
 	// Bulk operations
-	SyncUserRoles(ctx context.Context, userID uint, roleIDs []uint) error
-	SyncRolePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error
+	SyncProfileRoles(ctx context.Context, profileID uuid.UUID, assignments []ProfileRole) error
+	SyncRolePermissions(ctx context.Context, roleID uuid.UUID, permissionIDs []uuid.UUID) error
 }
 
 // service implements the Service interface
 type service struct {
-	repo Repository
+	repo   Repository
+	cache  *permissionCache
+	logger logger.Logger
 }
 
-// NewService creates a new role service
-func NewService(repo Repository) Service {
+// NewService creates a new role service. The effective permissions of a profile are cached
+// for cacheTTL; changes made through the service invalidate them right away.
+func NewService(repo Repository, cacheTTL time.Duration, logger logger.Logger) Service {
 	return &service{
-		repo: repo,
+		repo:   repo,
+		cache:  newPermissionCache(cacheTTL),
+		logger: logger,
 	}
 }
 
 // GetDefaultRoleID returns the ID of the default role for new profiles
-func (s *service) GetDefaultRoleID(ctx context.Context) (uint, error) {
-	// Get the role with the default role name (e.g., "guest")
-	defaultRole, err := s.repo.GetRoleByName(ctx, "guest") // Or whatever your default role is called
+func (s *service) GetDefaultRoleID(ctx context.Context) (uuid.UUID, error) {
+	defaultRole, err := s.repo.GetRoleByName(ctx, DefaultRole)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch default role: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to fetch default role: %w", err)
 	}
 
 	if defaultRole == nil {
-		return 0, errors.New("default role does not exist")
+		return uuid.Nil, errors.New("default role does not exist")
 	}
 
 	return defaultRole.ID, nil
@@ -87,20 +106,33 @@ func (s *service) GetDefaultRoleID(ctx context.Context) (uint, error) {
 
 func (s *service) CreateRole(ctx context.Context, role *Role) error {
 	// Validate role
+	role.Name = strings.TrimSpace(role.Name)
 	if role.Name == "" {
 		return ErrInvalidRole
 	}
 
 	// Check for duplicate
 	existing, err := s.repo.GetRoleByName(ctx, role.Name)
-	if err == nil && existing != nil {
+	if err != nil {
+		return err
+	}
+	if existing != nil {
 		return ErrDuplicateRole
 	}
 
+	role.ID = uuid.New()
+	if err := s.ensureInheritance(ctx, role); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+
 	return s.repo.CreateRole(ctx, role)
 }
 
-func (s *service) GetRoleByID(ctx context.Context, id uint) (*Role, error) {
+func (s *service) GetRoleByID(ctx context.Context, id uuid.UUID) (*Role, error) {
 	return s.repo.GetRoleByID(ctx, id)
 }
 
@@ -114,6 +146,7 @@ func (s *service) ListRoles(ctx context.Context) ([]Role, error) {
 
 func (s *service) UpdateRole(ctx context.Context, role *Role) error {
 	// Validate role
+	role.Name = strings.TrimSpace(role.Name)
 	if role.Name == "" {
 		return ErrInvalidRole
 	}
@@ -127,10 +160,34 @@ func (s *service) UpdateRole(ctx context.Context, role *Role) error {
 		return ErrRoleNotFound
 	}
 
-	return s.repo.UpdateRole(ctx, role)
+	// The new name must not belong to another role
+	if role.Name != existing.Name {
+		other, err := s.repo.GetRoleByName(ctx, role.Name)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return ErrDuplicateRole
+		}
+	}
+
+	if err := s.ensureInheritance(ctx, role); err != nil {
+		return err
+	}
+
+	role.CreatedAt = existing.CreatedAt
+	role.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return err
+	}
+
+	// Profiles holding the role or one inheriting from it may have gained or lost permissions
+	s.cache.clear()
+	return nil
 }
 
-func (s *service) DeleteRole(ctx context.Context, id uint) error {
+func (s *service) DeleteRole(ctx context.Context, id uuid.UUID) error {
 	// Ensure role exists
 	existing, err := s.repo.GetRoleByID(ctx, id)
 	if err != nil {
@@ -140,16 +197,187 @@ func (s *service) DeleteRole(ctx context.Context, id uint) error {
 		return ErrRoleNotFound
 	}
 
-	return s.repo.DeleteRole(ctx, id)
+	if err := s.repo.DeleteRole(ctx, id); err != nil {
+		return err
+	}
+
+	// Every profile holding the role loses its permissions
+	s.cache.clear()
+	s.logger.Info("Role deleted", "role_id", id, "name", existing.Name)
+	return nil
+}
+
+func (s *service) CreatePermission(ctx context.Context, permission *Permission) error {
+	if err := normalizePermission(permission); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	permission.ID = uuid.New()
+	permission.CreatedAt = now
+	permission.UpdatedAt = now
+
+	return s.repo.CreatePermission(ctx, permission)
+}
+
+func (s *service) GetPermissionByID(ctx context.Context, id uuid.UUID) (*Permission, error) {
+	return s.repo.GetPermissionByID(ctx, id)
+}
+
+func (s *service) ListPermissions(ctx context.Context) ([]Permission, error) {
+	return s.repo.ListPermissions(ctx)
+}
+
+func (s *service) UpdatePermission(ctx context.Context, permission *Permission) error {
+	if err := normalizePermission(permission); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetPermissionByID(ctx, permission.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrPermissionNotFound
+	}
+
+	permission.CreatedAt = existing.CreatedAt
+	permission.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdatePermission(ctx, permission); err != nil {
+		return err
+	}
+
+	// The resource or action may have changed for every role granting it
+	s.cache.clear()
+	return nil
+}
+
+func (s *service) DeletePermission(ctx context.Context, id uuid.UUID) error {
+	existing, err := s.repo.GetPermissionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrPermissionNotFound
+	}
+
+	if err := s.repo.DeletePermission(ctx, id); err != nil {
+		return err
+	}
+
+	s.cache.clear()
+	return nil
+}
+
+func (s *service) AssignPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
+	if err := s.ensureRoleAndPermissions(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+
+	if err := s.repo.AssignPermissionToRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+
+	s.cache.clear()
+	return nil
+}
+
+func (s *service) RemovePermissionFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
+	if err := s.repo.RemovePermissionFromRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+
+	s.cache.clear()
+	return nil
+}
+
+func (s *service) GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]Permission, error) {
+	return s.repo.GetRolePermissions(ctx, roleID)
+}
+
+func (s *service) AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID, scope Scope) error {
+	if err := s.ensureRoles(ctx, roleID); err != nil {
+		return err
+	}
+
+	scope, err := scope.Normalize()
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AssignRoleToProfile(ctx, profileID, roleID, scope); err != nil {
+		return err
+	}
+
+	s.cache.invalidate(profileID)
+	return nil
+}
+
+func (s *service) RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
+	if err := s.repo.RemoveRoleFromProfile(ctx, profileID, roleID); err != nil {
+		return err
+	}
+
+	s.cache.invalidate(profileID)
+	return nil
+}
+
+func (s *service) GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]AssignedRole, error) {
+	return s.repo.GetProfileRoles(ctx, profileID)
+}
+
+func (s *service) SyncProfileRoles(ctx context.Context, profileID uuid.UUID, assignments []ProfileRole) error {
+	for i := range assignments {
+		if err := s.ensureRoles(ctx, assignments[i].RoleID); err != nil {
+			return err
+		}
+
+		scope, err := assignments[i].Scope.Normalize()
+		if err != nil {
+			return err
+		}
+		assignments[i].ProfileID = profileID
+		assignments[i].Scope = scope
+	}
+
+	if err := s.repo.SyncProfileRoles(ctx, profileID, assignments); err != nil {
+		return err
+	}
+
+	s.cache.invalidate(profileID)
+	return nil
+}
+
+func (s *service) SyncRolePermissions(ctx context.Context, roleID uuid.UUID, permissionIDs []uuid.UUID) error {
+	if err := s.ensureRoleAndPermissions(ctx, roleID, permissionIDs...); err != nil {
+		return err
+	}
+
+	if err := s.repo.SyncRolePermissions(ctx, roleID, permissionIDs); err != nil {
+		return err
+	}
+
+	s.cache.clear()
+	return nil
+}
+
+func (s *service) InvalidateProfiles(profileIDs ...uuid.UUID) {
+	s.cache.invalidate(profileIDs...)
 }
 
 // Authorization logic
-func (s *service) HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error) {
-	return s.repo.HasPermission(ctx, userID, resource, action)
+func (s *service) HasPermission(ctx context.Context, profileID uuid.UUID, resource, action string) (bool, error) {
+	permissions, err := s.GetProfilePermissions(ctx, profileID)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Allows(resource, action), nil
 }
 
-func (s *service) Authorize(ctx context.Context, userID uint, resource, action string) error {
-	hasPermission, err := s.HasPermission(ctx, userID, resource, action)
+func (s *service) Authorize(ctx context.Context, profileID uuid.UUID, resource, action string) error {
+	hasPermission, err := s.HasPermission(ctx, profileID, resource, action)
 	if err != nil {
 		return err
 	}
@@ -159,4 +387,181 @@ func (s *service) Authorize(ctx context.Context, userID uint, resource, action s
 	return nil
 }
 
-// Implement other service methods...
+func (s *service) GetAccessScope(ctx context.Context, profileID uuid.UUID, resource, action string) (AccessScope, bool, error) {
+	permissions, err := s.GetProfilePermissions(ctx, profileID)
+	if err != nil {
+		return AccessScope{}, false, err
+	}
+
+	access, ok := permissions.AccessScope(resource, action)
+	return access, ok, nil
+}
+
+// GetProfilePermissions returns the effective permissions of a profile, loading them on a cache miss
+func (s *service) GetProfilePermissions(ctx context.Context, profileID uuid.UUID) (PermissionSet, error) {
+	if permissions, ok := s.cache.get(profileID); ok {
+		return permissions, nil
+	}
+
+	// Changes made while loading must not be overwritten by the stale result
+	generation := s.cache.currentGeneration()
+
+	list, err := s.repo.GetProfilePermissions(ctx, profileID)
+	if err != nil {
+		s.logger.Error("Failed to load profile permissions", "profile_id", profileID, "error", err)
+		return nil, err
+	}
+
+	permissions := NewPermissionSet(list)
+	s.cache.put(profileID, permissions, generation)
+	return permissions, nil
+}
+
+// ensureRoles checks that every role exists
+func (s *service) ensureRoles(ctx context.Context, roleIDs ...uuid.UUID) error {
+	for _, roleID := range roleIDs {
+		role, err := s.repo.GetRoleByID(ctx, roleID)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return ErrRoleNotFound
+		}
+	}
+	return nil
+}
+
+// ensureInheritance checks that the role a role inherits from exists and doesn't inherit from it in turn
+func (s *service) ensureInheritance(ctx context.Context, role *Role) error {
+	seen := map[uuid.UUID]bool{role.ID: true}
+	for parentID := role.InheritsFrom; parentID != nil; {
+		if seen[*parentID] {
+			return ErrRoleCycle
+		}
+		seen[*parentID] = true
+
+		parent, err := s.repo.GetRoleByID(ctx, *parentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return ErrRoleNotFound
+		}
+		parentID = parent.InheritsFrom
+	}
+	return nil
+}
+
+// ensureRoleAndPermissions checks that the role and every permission exist
+func (s *service) ensureRoleAndPermissions(ctx context.Context, roleID uuid.UUID, permissionIDs ...uuid.UUID) error {
+	if err := s.ensureRoles(ctx, roleID); err != nil {
+		return err
+	}
+
+	for _, permissionID := range permissionIDs {
+		permission, err := s.repo.GetPermissionByID(ctx, permissionID)
+		if err != nil {
+			return err
+		}
+		if permission == nil {
+			return ErrPermissionNotFound
+		}
+	}
+	return nil
+}
+
+// normalizePermission trims and lowercases the resource and action of a permission and validates them
+func normalizePermission(permission *Permission) error {
+	permission.Resource = strings.ToLower(strings.TrimSpace(permission.Resource))
+	permission.Action = strings.ToLower(strings.TrimSpace(permission.Action))
+	if permission.Resource == "" || permission.Action == "" {
+		return ErrInvalidPermission
+	}
+	return nil
+}
+
+// permissionCache keeps the effective permissions of profiles for a limited time.
+// Every invalidation bumps the generation, a load started before it is not stored.
+type permissionCache struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	generation uint64
+	entries    map[uuid.UUID]cachedPermissions
+	nextSweep  time.Time
+}
+
+// cachedPermissions is a cached permission set along with its expiry
+type cachedPermissions struct {
+	permissions PermissionSet
+	expiresAt   time.Time
+}
+
+// newPermissionCache creates a cache keeping entries for ttl. A ttl of zero disables caching.
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]cachedPermissions),
+	}
+}
+
+func (c *permissionCache) get(profileID uuid.UUID) (PermissionSet, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[profileID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.permissions, true
+}
+
+func (c *permissionCache) currentGeneration() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// put stores the permissions loaded at the given generation, unless the cache was invalidated since
+func (c *permissionCache) put(profileID uuid.UUID, permissions PermissionSet, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := time.Now()
+	c.entries[profileID] = cachedPermissions{permissions: permissions, expiresAt: now.Add(c.ttl)}
+
+	// Expired entries of profiles that stopped making requests are dropped once per ttl
+	if now.After(c.nextSweep) {
+		for id, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		c.nextSweep = now.Add(c.ttl)
+	}
+}
+
+func (c *permissionCache) invalidate(profileIDs ...uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, profileID := range profileIDs {
+		delete(c.entries, profileID)
+	}
+}
+
+func (c *permissionCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.entries = make(map[uuid.UUID]cachedPermissions)
+}
@@ -0,0 +1,106 @@
+// internal/domain/student/attendance.go
+
+package student
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttendancePolicy decides when a student is short of attendance in a course
+type AttendancePolicy struct {
+	// ShortageThreshold is the attendance rate, in percent, below which a student is flagged
+	ShortageThreshold float64
+	// MinimumClasses is the number of classes held before students are flagged, so that a single
+	// absence at the start of the semester is not reported
+	MinimumClasses int
+}
+
+// IsShort reports whether the statistics fall below the shortage threshold
+func (p AttendancePolicy) IsShort(stats AttendanceStatistics) bool {
+	return stats.TotalClasses >= p.MinimumClasses && stats.Rate() < p.ShortageThreshold
+}
+
+// SectionAttendance marks the attendance of every student of a section for one class.
+// Students without a mark get DefaultStatus, so only the exceptions need to be listed.
+type SectionAttendance struct {
+	CourseID      uuid.UUID        `json:"course_id" binding:"required"`
+	Semester      int              `json:"semester" binding:"required,min=1,max=10"`
+	Date          time.Time        `json:"date" binding:"required"`
+	Branch        string           `json:"branch" binding:"required"`
+	Batch         string           `json:"batch" binding:"required"` // Admission year or batch, e.g. "2023" or "2023-2027"
+	Section       string           `json:"section"`
+	DefaultStatus AttendanceType   `json:"default_status"`
+	Marks         []AttendanceMark `json:"marks"`
+	RecordedBy    uuid.UUID        `json:"-"` // Set from the authenticated coordinator
+}
+
+// AttendanceMark is the attendance of one student in a section
+type AttendanceMark struct {
+	StudentID uuid.UUID      `json:"student_id" binding:"required"`
+	Status    AttendanceType `json:"status" binding:"required"`
+	Remarks   string         `json:"remarks,omitempty"`
+}
+
+// SectionAttendanceResult reports the outcome of marking a section. Submitting the same marks again
+// changes nothing, the records are then counted as unchanged.
+type SectionAttendanceResult struct {
+	Marked    int                        `json:"marked"`
+	Unchanged int                        `json:"unchanged"`
+	Students  []StudentAttendanceSummary `json:"students"`
+}
+
+// StudentAttendanceSummary is the attendance of a student in a course after marking
+type StudentAttendanceSummary struct {
+	StudentID    uuid.UUID            `json:"student_id"`
+	AttendanceID uuid.UUID            `json:"attendance_id"`
+	Statistics   AttendanceStatistics `json:"statistics"`
+	// ShortageAlerted is set when the marking queued a shortage alert for the student
+	ShortageAlerted bool `json:"shortage_alerted"`
+}
+
+// AttendanceShortageAlert is queued when a student's attendance rate in a course falls below the
+// shortage threshold, and sent to the student by the attendance alert worker. It is resolved once
+// the attendance recovers.
+type AttendanceShortageAlert struct {
+	ID             uuid.UUID  `json:"id"`
+	StudentID      uuid.UUID  `json:"student_id"`
+	AttendanceID   uuid.UUID  `json:"attendance_id"`
+	CourseID       uuid.UUID  `json:"course_id"`
+	Semester       int        `json:"semester"`
+	AttendanceRate float64    `json:"attendance_rate"`
+	Threshold      float64    `json:"threshold"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+
+	// Recipient of the alert, loaded with pending alerts
+	StudentName  string `json:"student_name,omitempty"`
+	StudentEmail string `json:"student_email,omitempty"`
+}
+
+// ShortageAlertMailer sends attendance shortage alerts to students.
+// Implemented by infrastructure/email.SMTPProvider.
+type ShortageAlertMailer interface {
+	SendAttendanceShortageEmail(to, name string, semester int, rate, threshold float64) error
+}
+
+// Rate returns the share of classes attended as a percentage, zero before the first class
+func (s AttendanceStatistics) Rate() float64 {
+	if s.TotalClasses == 0 {
+		return 0
+	}
+	return float64(s.Present) / float64(s.TotalClasses) * 100
+}
+
+// IsValidAttendanceType reports whether status is one of the attendance types
+func IsValidAttendanceType(status AttendanceType) bool {
+	switch status {
+	case AttendancePresent, AttendanceAbsent, AttendanceLeave:
+		return true
+	}
+	return false
+}
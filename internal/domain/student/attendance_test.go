@@ -0,0 +1,176 @@
+package student
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"server/internal/domain/role"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository records the section passed on by the service. Every other repository method
+// panics through the embedded nil interface.
+type fakeRepository struct {
+	Repository
+
+	sheets []SectionAttendance
+}
+
+func (r *fakeRepository) MarkSectionAttendance(ctx context.Context, sheet SectionAttendance, policy AttendancePolicy) (*SectionAttendanceResult, error) {
+	r.sheets = append(r.sheets, sheet)
+	return &SectionAttendanceResult{Marked: len(sheet.Marks)}, nil
+}
+
+func TestAttendanceStatisticsRate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		stats AttendanceStatistics
+		want  float64
+	}{
+		{name: "no classes yet", stats: AttendanceStatistics{}, want: 0},
+		{name: "every class attended", stats: AttendanceStatistics{TotalClasses: 12, Present: 12}, want: 100},
+		{name: "leave counts as missed", stats: AttendanceStatistics{TotalClasses: 4, Present: 3, Leave: 1}, want: 75},
+		{name: "fraction", stats: AttendanceStatistics{TotalClasses: 3, Present: 2, Absent: 1}, want: 200.0 / 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.stats.Rate(); math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAttendancePolicyIsShort(t *testing.T) {
+	policy := AttendancePolicy{ShortageThreshold: 75, MinimumClasses: 5}
+
+	for _, tc := range []struct {
+		name  string
+		stats AttendanceStatistics
+		want  bool
+	}{
+		{name: "too few classes held", stats: AttendanceStatistics{TotalClasses: 4, Absent: 4}},
+		{name: "below the threshold", stats: AttendanceStatistics{TotalClasses: 5, Present: 3, Absent: 2}, want: true},
+		{name: "exactly at the threshold", stats: AttendanceStatistics{TotalClasses: 8, Present: 6, Absent: 2}},
+		{name: "above the threshold", stats: AttendanceStatistics{TotalClasses: 10, Present: 9, Leave: 1}},
+		{name: "just below the threshold", stats: AttendanceStatistics{TotalClasses: 100, Present: 74, Absent: 26}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.IsShort(tc.stats); got != tc.want {
+				t.Fatalf("expected %v for a rate of %v, got %v", tc.want, tc.stats.Rate(), got)
+			}
+		})
+	}
+}
+
+func TestStudentGetAttendanceRate(t *testing.T) {
+	student := &Student{ID: uuid.New()}
+
+	rate := student.GetAttendanceRate([]StudentAttendance{
+		{StudentID: student.ID, Statistics: AttendanceStatistics{TotalClasses: 10, Present: 9}},
+		{StudentID: student.ID, Statistics: AttendanceStatistics{TotalClasses: 10, Present: 6}},
+		{StudentID: uuid.New(), Statistics: AttendanceStatistics{TotalClasses: 10}},
+	})
+	if rate != 75 {
+		t.Fatalf("expected the rate over the student's courses to be 75, got %v", rate)
+	}
+}
+
+func TestMarkSectionAttendance(t *testing.T) {
+	studentID := uuid.New()
+	scoped := role.WithAccessScope(context.Background(), role.AccessScope{Scopes: []role.Scope{{Branch: "CSE", Batch: "2023"}}})
+
+	for _, tc := range []struct {
+		name        string
+		ctx         context.Context
+		sheet       func(sheet *SectionAttendance)
+		wantErr     error
+		wantDefault AttendanceType
+	}{
+		{name: "defaults to present", ctx: scoped, wantDefault: AttendancePresent},
+		{
+			name:        "keeps an explicit default",
+			ctx:         scoped,
+			sheet:       func(sheet *SectionAttendance) { sheet.DefaultStatus = AttendanceAbsent },
+			wantDefault: AttendanceAbsent,
+		},
+		{
+			name:    "rejects an unknown default",
+			ctx:     scoped,
+			sheet:   func(sheet *SectionAttendance) { sheet.DefaultStatus = "late" },
+			wantErr: ErrInvalidAttendance,
+		},
+		{
+			name:    "rejects an unknown mark",
+			ctx:     scoped,
+			sheet:   func(sheet *SectionAttendance) { sheet.Marks[0].Status = "late" },
+			wantErr: ErrInvalidAttendance,
+		},
+		{
+			name: "rejects a student marked twice",
+			ctx:  scoped,
+			sheet: func(sheet *SectionAttendance) {
+				sheet.Marks = append(sheet.Marks, AttendanceMark{StudentID: studentID, Status: AttendanceLeave})
+			},
+			wantErr: ErrInvalidAttendance,
+		},
+		{
+			name:    "rejects a semester out of range",
+			ctx:     scoped,
+			sheet:   func(sheet *SectionAttendance) { sheet.Semester = 11 },
+			wantErr: ErrInvalidAttendance,
+		},
+		{
+			name:    "hides sections outside the scope",
+			ctx:     scoped,
+			sheet:   func(sheet *SectionAttendance) { sheet.Branch = "ece" },
+			wantErr: ErrSectionNotFound,
+		},
+		{
+			name:    "hides sections without a scope",
+			ctx:     context.Background(),
+			wantErr: ErrSectionNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sheet := SectionAttendance{
+				CourseID: uuid.New(),
+				Semester: 3,
+				Date:     time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC),
+				Branch:   " cse ",
+				Batch:    "2023",
+				Marks:    []AttendanceMark{{StudentID: studentID, Status: AttendanceAbsent}},
+			}
+			if tc.sheet != nil {
+				tc.sheet(&sheet)
+			}
+			repo := &fakeRepository{}
+			service := NewService(repo, nil, nil, AttendancePolicy{ShortageThreshold: 75})
+
+			_, err := service.MarkSectionAttendance(tc.ctx, sheet)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				if len(repo.sheets) != 0 {
+					t.Fatalf("expected nothing to be recorded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MarkSectionAttendance failed: %v", err)
+			}
+
+			if len(repo.sheets) != 1 {
+				t.Fatalf("expected the section to be recorded once, got %d", len(repo.sheets))
+			}
+			recorded := repo.sheets[0]
+			if recorded.Branch != "CSE" || recorded.DefaultStatus != tc.wantDefault {
+				t.Fatalf("expected branch CSE with default %s, got %s with %s", tc.wantDefault, recorded.Branch, recorded.DefaultStatus)
+			}
+		})
+	}
+}
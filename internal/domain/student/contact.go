@@ -0,0 +1,70 @@
+// internal/domain/student/contact.go
+
+package student
+
+import (
+	"github.com/google/uuid"
+)
+
+// MaxGuardianNotificationStudents is the number of students whose guardians can be notified at once
+const MaxGuardianNotificationStudents = 200
+
+// ContactRequest is the input for adding or replacing a contact of a student. A student with
+// emergency contacts always has exactly one primary contact: the first emergency contact becomes
+// primary, and marking another contact primary demotes the previous one.
+type ContactRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Relation    string `json:"relation" binding:"required,max=50"`
+	Phone       string `json:"phone" binding:"required"`
+	Email       string `json:"email"`
+	IsEmergency bool   `json:"is_emergency"`
+	IsPrimary   bool   `json:"is_primary"` // Only emergency contacts can be primary
+}
+
+// GuardianNotification is a message sent by a coordinator to the emergency contacts of students
+type GuardianNotification struct {
+	StudentIDs []uuid.UUID `json:"student_ids" binding:"required,min=1"`
+	Subject    string      `json:"subject" binding:"required,max=150"`
+	Message    string      `json:"message" binding:"required,max=5000"`
+	// PrimaryOnly limits the message to the primary contact of each student
+	PrimaryOnly bool `json:"primary_only"`
+}
+
+// GuardianRecipients are the emergency contacts of a student
+type GuardianRecipients struct {
+	StudentID    uuid.UUID
+	EnrollmentNo string
+	StudentName  string
+	Branch       string
+	Batch        string
+	Contacts     []StudentContact
+}
+
+// Outcomes of notifying the guardians of a student
+const (
+	GuardianNotificationSent      = "sent"
+	GuardianNotificationFailed    = "failed"
+	GuardianNotificationNoContact = "no_contact"
+	GuardianNotificationNotFound  = "not_found"
+)
+
+// GuardianNotificationResult reports the outcome of a guardian notification for every student
+type GuardianNotificationResult struct {
+	Sent     int                           `json:"sent"`
+	Failed   int                           `json:"failed"`
+	Students []GuardianNotificationOutcome `json:"students"`
+}
+
+// GuardianNotificationOutcome is the outcome of notifying the guardians of one student
+type GuardianNotificationOutcome struct {
+	StudentID  uuid.UUID `json:"student_id"`
+	Status     string    `json:"status"`
+	Recipients int       `json:"recipients"` // Emails sent
+	Error      string    `json:"error,omitempty"`
+}
+
+// GuardianMailer sends coordinator messages to the emergency contacts of students.
+// Implemented by infrastructure/email.SMTPProvider.
+type GuardianMailer interface {
+	SendGuardianNotificationEmail(to, guardianName, studentName, subject, message string) error
+}
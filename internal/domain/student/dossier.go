@@ -0,0 +1,190 @@
+// internal/domain/student/dossier.go
+
+package student
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DossierSection is a part of a student dossier that can be requested on its own
+type DossierSection string
+
+// Sections of a dossier
+const (
+	DossierContact          DossierSection = "contact"
+	DossierAcademic         DossierSection = "academic"
+	DossierProfile          DossierSection = "profile"
+	DossierFamily           DossierSection = "family"
+	DossierScholarship      DossierSection = "scholarship"
+	DossierDocuments        DossierSection = "documents"
+	DossierCertifications   DossierSection = "certifications"
+	DossierLeaderboard      DossierSection = "leaderboard"
+	DossierPracticeSessions DossierSection = "practice_sessions"
+)
+
+// AllDossierSections are the sections of a dossier when none are requested
+var AllDossierSections = []DossierSection{
+	DossierContact,
+	DossierAcademic,
+	DossierProfile,
+	DossierFamily,
+	DossierScholarship,
+	DossierDocuments,
+	DossierCertifications,
+	DossierLeaderboard,
+	DossierPracticeSessions,
+}
+
+// Fields withheld from viewers who are not admins
+const (
+	RedactedFamilyIncome = "family.total_family_income"
+	RedactedCategory     = "profile.category"
+)
+
+// Dossier is the complete record of a student, assembled from the enrollment record and every table
+// it references. Sections that were not requested are left empty.
+type Dossier struct {
+	StudentID    uuid.UUID     `json:"student_id"`
+	EnrollmentNo string        `json:"enrollment_no"`
+	Status       StudentStatus `json:"status"`
+	Branch       string        `json:"branch"`
+	Batch        string        `json:"batch"`
+
+	Contact          *DossierContactDetails     `json:"contact,omitempty"`
+	Academic         *DossierAcademicSummary    `json:"academic,omitempty"`
+	Profile          *DossierProfileDetails     `json:"profile,omitempty"`
+	Family           *DossierFamilyDetails      `json:"family,omitempty"`
+	Scholarship      *DossierScholarshipDetails `json:"scholarship,omitempty"`
+	Documents        []DossierDocument          `json:"documents,omitempty"`
+	Certifications   []DossierCertification     `json:"certifications,omitempty"`
+	Leaderboard      []DossierLeaderboardRecord `json:"leaderboard,omitempty"`
+	PracticeSessions []DossierPracticeSession   `json:"practice_sessions,omitempty"`
+
+	// Redacted lists the fields withheld from the viewer, e.g. RedactedFamilyIncome
+	Redacted []string `json:"redacted,omitempty"`
+}
+
+// DossierContactDetails are the login details of a student, without the password
+type DossierContactDetails struct {
+	Email       string     `json:"email"`
+	Phone       string     `json:"phone"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// DossierAcademicSummary is the academic standing of a student
+type DossierAcademicSummary struct {
+	Program               string   `json:"program"`
+	Branch                string   `json:"branch"`
+	YearOfEnrollment      int      `json:"year_of_enrollment"`
+	YearOfGraduation      *int     `json:"year_of_graduation,omitempty"`
+	PresentSemester       int      `json:"semester"`
+	Section               string   `json:"section,omitempty"`
+	CGPA                  *float32 `json:"cgpa,omitempty"`
+	PreviousSemSGPA       *float32 `json:"previous_sem_sgpa,omitempty"`
+	SchoolForClassTen     string   `json:"school_for_class_ten"`
+	ClassTenPercentage    *float32 `json:"class_ten_percentage,omitempty"`
+	SchoolForClassTwelve  string   `json:"school_for_class_twelve"`
+	ClassTwelvePercentage *float32 `json:"class_twelve_percentage,omitempty"`
+}
+
+// DossierProfileDetails are the personal details of a student
+type DossierProfileDetails struct {
+	Name             string     `json:"name"`
+	Gender           string     `json:"gender"`
+	Category         string     `json:"category,omitempty"` // Hidden from viewers who are not admins
+	DateOfBirth      *time.Time `json:"date_of_birth,omitempty"`
+	PresentAddress   Address    `json:"present_address"`
+	PermanentAddress Address    `json:"permanent_address"`
+	PhotographURL    string     `json:"photograph_url,omitempty"`
+	ResumeURL        string     `json:"resume_url,omitempty"`
+}
+
+// DossierFamilyDetails are the family details of a student
+type DossierFamilyDetails struct {
+	FatherName          string `json:"father_name"`
+	FatherQualification string `json:"father_qualification"`
+	FatherProfession    string `json:"father_profession"`
+	MotherName          string `json:"mother_name"`
+	MotherQualification string `json:"mother_qualification"`
+	MotherProfession    string `json:"mother_profession"`
+	NoOfSiblings        int    `json:"no_of_siblings"`
+	TotalFamilyIncome   *int   `json:"total_family_income,omitempty"` // Hidden from viewers who are not admins
+}
+
+// DossierScholarshipDetails is the scholarship a student receives
+type DossierScholarshipDetails struct {
+	ScholarshipName string `json:"scholarship_name"`
+	ProvidedBy      string `json:"provided_by"`
+	AmountReceived  int    `json:"amount_received"`
+}
+
+// DossierDocument is a document of a student, such as a marksheet or the resume
+type DossierDocument struct {
+	DocumentType string    `json:"document_type"`
+	URL          string    `json:"url,omitempty"` // Empty until the student uploads the file
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DossierCertification is a certification or achievement of a student
+type DossierCertification struct {
+	CertificationName string `json:"certification_name"`
+	IssuingAuthority  string `json:"issuing_authority"`
+	IssuingDate       string `json:"issuing_date"`
+	DocumentURL       string `json:"document_url,omitempty"`
+}
+
+// DossierLeaderboardRecord is a leaderboard standing of a student
+type DossierLeaderboardRecord struct {
+	Rank        int       `json:"rank"`
+	Score       float64   `json:"score"`
+	Domain      string    `json:"domain"`
+	SubDomain   string    `json:"sub_domain"`
+	TimePeriod  string    `json:"time_period"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// DossierPracticeSession is a practice session of a student
+type DossierPracticeSession struct {
+	SessionID          uint32    `json:"session_id"`
+	DomainID           uint32    `json:"domain_id"`
+	SubDomainID        uint32    `json:"sub_domain_id"`
+	DifficultyLevelID  uint32    `json:"difficulty_level_id"`
+	QuestionsAttempted int       `json:"questions_attempted"`
+	QuestionsCorrect   int       `json:"questions_correct"`
+	ScoreEarned        float64   `json:"score_earned"`
+	StartTime          time.Time `json:"start_time"`
+	EndTime            time.Time `json:"end_time"`
+	Feedbacks          string    `json:"feedbacks,omitempty"`
+	Status             string    `json:"status"`
+}
+
+// DossierOptions select the sections of a dossier
+type DossierOptions struct {
+	// Sections to include, every section when empty
+	Sections []DossierSection
+}
+
+// Includes reports whether the section was requested
+func (o DossierOptions) Includes(section DossierSection) bool {
+	if len(o.Sections) == 0 {
+		return true
+	}
+	for _, s := range o.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidDossierSection reports whether section names a part of a dossier
+func IsValidDossierSection(section DossierSection) bool {
+	for _, s := range AllDossierSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
@@ -10,34 +10,36 @@ import (
 
 // Student represents the core student entity in the system
 type Student struct {
-	// ID            uuid.UUID     `json:"id"`
-	EnrollmentID  string        `json:"enrollment_id"` // University enrollment ID/number
-	FirstName     string        `json:"first_name"`
-	LastName      string        `json:"last_name"`
-	Email         string        `json:"email"`
-	PhoneNumber   string        `json:"phone_number"`
-	DateOfBirth   time.Time     `json:"date_of_birth"`
-	Gender        string        `json:"gender"`
-	PresentAddress       Address       `json:"present_address"`
-	PermanentAddress Address `json:"permanent_address"`
-
-	Program       string        `json:"program"` // Degree program (e.g., "B.Tech Computer Science")
-	Batch         string        `json:"batch"`   // Admission year/batch (e.g., "2023-2027")
-	
-	PresentSemester      int           `json:"semester"` // Set to 10 for pass outs
-	Section       string        `json:"section,omitempty"` // Can be empty
-	Status        StudentStatus `json:"status"`
-	ProfileImageUrl  string        `json:"profile_image_url"`
-	
+	ID               uuid.UUID `json:"id"`
+	EnrollmentID     string    `json:"enrollment_id"` // University enrollment ID/number
+	FirstName        string    `json:"first_name"`
+	LastName         string    `json:"last_name"`
+	Email            string    `json:"email"`
+	PhoneNumber      string    `json:"phone_number"`
+	DateOfBirth      time.Time `json:"date_of_birth"`
+	Gender           string    `json:"gender"`
+	Category         string    `json:"category"` // Admission category (e.g., "GEN", "OBC")
+	PresentAddress   Address   `json:"present_address"`
+	PermanentAddress Address   `json:"permanent_address"`
+
+	Program string `json:"program"` // Degree program (e.g., "B.Tech Computer Science")
+	Branch  string `json:"branch"`  // Short name of the branch (e.g., "CSE")
+	Batch   string `json:"batch"`   // Admission year/batch (e.g., "2023-2027")
+
+	PresentSemester int           `json:"semester"`          // Set to 10 for pass outs
+	Section         string        `json:"section,omitempty"` // Can be empty
+	Status          StudentStatus `json:"status"`
+	ProfileImageUrl string        `json:"profile_image_url"`
+
 	// Achievements  []Achievement `json:"achievements,omitempty"`
 	// Courses       []Course      `json:"courses,omitempty"`
 
-	RoleIDs       []uuid.UUID   `json:"role_ids,omitempty"` // Link to roles in role domain
-	PasswordHash  string        `json:"-"`                  // Never exposed in JSON
-	LastLoginAt   *time.Time    `json:"last_login_at,omitempty"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
-	DeactivatedAt *time.Time    `json:"deactivated_at,omitempty"`
+	RoleIDs       []uuid.UUID `json:"role_ids,omitempty"` // Link to roles in role domain
+	PasswordHash  string      `json:"-"`                  // Never exposed in JSON
+	LastLoginAt   *time.Time  `json:"last_login_at,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	DeactivatedAt *time.Time  `json:"deactivated_at,omitempty"`
 }
 
 // Address represents a student's address
@@ -79,12 +81,12 @@ type StudentStatus string
 
 // Possible student statuses
 const (
-	StatusActive       StudentStatus = "active"
-	StatusOnLeave      StudentStatus = "on_leave"
-	StatusGraduated    StudentStatus = "graduated"
-	StatusSuspended    StudentStatus = "suspended"
-	StatusDeactivated  StudentStatus = "deactivated"
-	StatusProvisional  StudentStatus = "provisional"
+	StatusActive      StudentStatus = "active"
+	StatusOnLeave     StudentStatus = "on_leave"
+	StatusGraduated   StudentStatus = "graduated"
+	StatusSuspended   StudentStatus = "suspended"
+	StatusDeactivated StudentStatus = "deactivated"
+	StatusProvisional StudentStatus = "provisional"
 )
 
 // StudentAttendance represents attendance records for a student
@@ -101,10 +103,10 @@ type StudentAttendance struct {
 
 // AttendanceRecord represents a single attendance entry
 type AttendanceRecord struct {
-	Date     time.Time      `json:"date"`
-	Status   AttendanceType `json:"status"`
-	Remarks  string         `json:"remarks,omitempty"`
-	RecordedBy uuid.UUID    `json:"recorded_by"` // ID of coordinator/faculty who marked attendance
+	Date       time.Time      `json:"date"`
+	Status     AttendanceType `json:"status"`
+	Remarks    string         `json:"remarks,omitempty"`
+	RecordedBy uuid.UUID      `json:"recorded_by"` // ID of coordinator/faculty who marked attendance
 }
 
 // AttendanceType represents the status of attendance for a class
@@ -128,11 +130,11 @@ type AttendanceStatistics struct {
 
 // StudentPreferences represents a student's preferences and settings
 type StudentPreferences struct {
-	ID                 uuid.UUID `json:"id"`
-	StudentID          uuid.UUID `json:"student_id"`
-	Calendar           Calendar  `json:"calendar"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+	StudentID uuid.UUID `json:"student_id"`
+	Calendar  Calendar  `json:"calendar"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Calendar represents calendar sync preferences
@@ -144,34 +146,33 @@ type Calendar struct {
 
 // StudentContact represents a student's emergency contact information
 type StudentContact struct {
-	ID         uuid.UUID `json:"id"`
-	StudentID  uuid.UUID `json:"student_id"`
-	Name       string    `json:"name"`
-	Relation   string    `json:"relation"`
-	Phone      string    `json:"phone"`
-	Email      string    `json:"email,omitempty"`
+	ID          uuid.UUID `json:"id"`
+	StudentID   uuid.UUID `json:"student_id"`
+	Name        string    `json:"name"`
+	Relation    string    `json:"relation"`
+	Phone       string    `json:"phone"`
+	Email       string    `json:"email,omitempty"`
 	IsEmergency bool      `json:"is_emergency"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	IsPrimary   bool      `json:"is_primary"` // The one emergency contact reached first
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-
-
 // NewStudent creates a new student instance with default values
 func NewStudent(enrollmentID, firstName, lastName, email, program, batch string, semester int) *Student {
 	now := time.Now()
 	return &Student{
-		// ID:           uuid.New(),
-		EnrollmentID: enrollmentID,
-		FirstName:    firstName,
-		LastName:     lastName,
-		Email:        email,
-		Program:      program,
-		Batch:        batch,
-		PresentSemester:     semester,
-		Status:       StatusActive,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:              uuid.New(),
+		EnrollmentID:    enrollmentID,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Email:           email,
+		Program:         program,
+		Batch:           batch,
+		PresentSemester: semester,
+		Status:          StatusActive,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 }
 
@@ -201,11 +202,18 @@ func (s *Student) HasRole(roleID uuid.UUID) bool {
 // 	s.UpdatedAt = time.Now()
 // }
 
-// GetAttendanceRate calculates the overall attendance rate across all courses
-func (s *Student) GetAttendanceRate() float64 {
-	// This would typically be calculated from attendance records
-	// This is a simplified placeholder
-	return 0.0
+// GetAttendanceRate calculates the overall attendance rate across all courses of the attendance
+// sheets, as a percentage. Sheets of other students are ignored.
+func (s *Student) GetAttendanceRate(attendance []StudentAttendance) float64 {
+	var total AttendanceStatistics
+	for _, sheet := range attendance {
+		if sheet.StudentID != s.ID {
+			continue
+		}
+		total.TotalClasses += sheet.Statistics.TotalClasses
+		total.Present += sheet.Statistics.Present
+	}
+	return total.Rate()
 }
 
 // Deactivate sets the student's status to deactivated
@@ -214,4 +222,4 @@ func (s *Student) Deactivate() {
 	s.Status = StatusDeactivated
 	s.DeactivatedAt = &now
 	s.UpdatedAt = now
-}
\ No newline at end of file
+}
@@ -4,8 +4,11 @@ package student
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+
+	"server/internal/domain/role"
 )
 
 // Repository defines the data access contract for students
@@ -19,6 +22,9 @@ type Repository interface {
 	// GetByEnrollmentID retrieves a student by enrollment ID
 	GetByEnrollmentID(ctx context.Context, enrollmentID string) (*Student, error)
 
+	// GetByProfileID retrieves the student linked to a platform profile
+	GetByProfileID(ctx context.Context, profileID uuid.UUID) (*Student, error)
+
 	// GetByEmail retrieves a student by email
 	GetByEmail(ctx context.Context, email string) (*Student, error)
 
@@ -31,20 +37,29 @@ type Repository interface {
 	// List retrieves students with pagination
 	List(ctx context.Context, filter StudentFilter, pagination Pagination) ([]*Student, int64, error)
 
-	// AddCourse adds a course to a student
-	// AddCourse(ctx context.Context, studentID uuid.UUID, course Course) error
+	// GetAttendance retrieves attendance for a student
+	GetAttendance(ctx context.Context, studentID uuid.UUID, courseID *uuid.UUID, semester *int) ([]StudentAttendance, error)
 
-	// RemoveCourse removes a course from a student
-	RemoveCourse(ctx context.Context, studentID uuid.UUID, courseID uuid.UUID) error
+	// RecordAttendance records attendance for a student, replacing the records of the same dates.
+	// The statistics are updated and a shortage alert is queued when the policy is breached.
+	RecordAttendance(ctx context.Context, attendance *StudentAttendance, policy AttendancePolicy) error
 
-	// UpdateCourseGrade updates a grade for a specific course
-	UpdateCourseGrade(ctx context.Context, studentID, courseID uuid.UUID, grade string) error
+	// MarkSectionAttendance records one class for every active student of a section in a single
+	// transaction. Re-submitting the same marks is a no-op.
+	MarkSectionAttendance(ctx context.Context, sheet SectionAttendance, policy AttendancePolicy) (*SectionAttendanceResult, error)
 
-	// GetAttendance retrieves attendance for a student
-	GetAttendance(ctx context.Context, studentID uuid.UUID, courseID *uuid.UUID, semester *int) ([]StudentAttendance, error)
+	// GetAttendanceStatistics sums the attendance of a student across courses, optionally for a semester
+	GetAttendanceStatistics(ctx context.Context, studentID uuid.UUID, semester *int) (AttendanceStatistics, error)
 
-	// RecordAttendance records attendance for a student
-	RecordAttendance(ctx context.Context, attendance *StudentAttendance) error
+	// ListPendingShortageAlerts retrieves the oldest unsent, unresolved shortage alerts attempted
+	// fewer than maxAttempts times, along with their recipients
+	ListPendingShortageAlerts(ctx context.Context, maxAttempts, limit int) ([]AttendanceShortageAlert, error)
+
+	// MarkShortageAlertSent records the delivery of a shortage alert
+	MarkShortageAlertSent(ctx context.Context, alertID uuid.UUID, sentAt time.Time) error
+
+	// RecordShortageAlertFailure counts a failed delivery attempt of a shortage alert
+	RecordShortageAlertFailure(ctx context.Context, alertID uuid.UUID, reason string) error
 
 	// GetPreferences retrieves preferences for a student
 	GetPreferences(ctx context.Context, studentID uuid.UUID) (*StudentPreferences, error)
@@ -55,29 +70,24 @@ type Repository interface {
 	// GetContacts retrieves contacts for a student
 	GetContacts(ctx context.Context, studentID uuid.UUID) ([]StudentContact, error)
 
-	// AddContact adds a contact for a student
+	// AddContact adds a contact for a student, keeping exactly one primary emergency contact
 	AddContact(ctx context.Context, contact *StudentContact) error
 
-	// UpdateContact updates a contact for a student
+	// UpdateContact updates a contact of a student, keeping exactly one primary emergency contact
 	UpdateContact(ctx context.Context, contact *StudentContact) error
 
-	// DeleteContact deletes a contact for a student
-	DeleteContact(ctx context.Context, contactID uuid.UUID) error
-
-	// CreateSession creates a new session for a student
-	// CreateSession(ctx context.Context, session *StudentSession) error
+	// DeleteContact deletes a contact of a student, promoting another emergency contact when it was primary
+	DeleteContact(ctx context.Context, studentID, contactID uuid.UUID) error
 
-	// GetActiveSession retrieves an active session by token
-	// GetActiveSession(ctx context.Context, token string) (*StudentSession, error)
+	// GetGuardianRecipients retrieves the emergency contacts of the existing students among studentIDs
+	GetGuardianRecipients(ctx context.Context, studentIDs []uuid.UUID) ([]GuardianRecipients, error)
 
-	// RevokeSession revokes a session
-	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	// GetDossier assembles the dossier of a student with the sections the options include,
+	// nil if the student does not exist
+	GetDossier(ctx context.Context, enrollmentNo string, options DossierOptions) (*Dossier, error)
 
-	// AddAchievement adds an achievement to a student
-	// AddAchievement(ctx context.Context, studentID uuid.UUID, achievement Achievement) error
-
-	// GetAchievements retrieves achievements for a student
-	// GetAchievements(ctx context.Context, studentID uuid.UUID) ([]Achievement, error)
+	// Login sessions are owned by the platform_profile domain,
+	// see platform_profile.Repository.CreateSession and friends.
 }
 
 // StudentFilter defines the filter options for listing students
@@ -86,7 +96,11 @@ type StudentFilter struct {
 	Program     *string
 	Batch       *string
 	Semester    *int
+	Branch      *string
 	SearchQuery *string // Will match against name, email, or enrollment ID
+
+	// Scope limits the list to the students within the caller's access scope, set by the service
+	Scope role.AccessScope
 }
 
 // Pagination defines the pagination options
@@ -95,4 +109,4 @@ type Pagination struct {
 	PageSize int
 	SortBy   string
 	SortDesc bool
-}
\ No newline at end of file
+}
@@ -0,0 +1,204 @@
+package roster
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"server/internal/domain/student/student"
+)
+
+// column is a roster field along with the headers it is recognised by
+type column struct {
+	name     string
+	aliases  []string
+	required bool
+	set      func(row *Row, value string) error
+}
+
+// columns lists the roster fields. Headers are matched case-insensitively, ignoring spaces and
+// punctuation, against the column name and its aliases.
+var columns = []column{
+	{name: "enrollment_no", aliases: []string{"enrollment", "enrollmentnumber", "enrolmentno", "enrolmentnumber"}, required: true, set: setString(func(r *Row) *string { return &r.EnrollmentNo })},
+	{name: "name", aliases: []string{"studentname", "fullname"}, required: true, set: setString(func(r *Row) *string { return &r.Name })},
+	{name: "email", aliases: []string{"emailid", "emailaddress"}, required: true, set: setString(func(r *Row) *string { return &r.Email })},
+	{name: "phone", aliases: []string{"phoneno", "phonenumber", "mobile", "mobileno", "mobilenumber", "contactno"}, required: true, set: setString(func(r *Row) *string { return &r.Phone })},
+	{name: "gender", aliases: []string{"sex"}, required: true, set: setGender},
+	{name: "category", aliases: []string{"castecategory"}, required: true, set: setCategory},
+
+	{name: "branch", aliases: []string{"branchcode"}, required: true, set: setString(func(r *Row) *string { return &r.Branch })},
+	{name: "year_of_enrollment", aliases: []string{"enrollmentyear", "admissionyear"}, required: true, set: setInt(func(r *Row) *int { return &r.YearOfEnrollment })},
+	{name: "cgpa", set: setFloat(func(r *Row) *float32 { return &r.CGPA })},
+	{name: "previous_sem_sgpa", aliases: []string{"previoussemestersgpa", "sgpa"}, set: setFloat(func(r *Row) *float32 { return &r.PreviousSemSGPA })},
+	{name: "school_for_class_ten", aliases: []string{"classtenschool", "class10school", "tenthschool"}, required: true, set: setString(func(r *Row) *string { return &r.SchoolForClassTen })},
+	{name: "class_ten_percentage", aliases: []string{"class10percentage", "tenthpercentage"}, set: setFloat(func(r *Row) *float32 { return &r.ClassTenPercentage })},
+	{name: "school_for_class_twelve", aliases: []string{"classtwelveschool", "class12school", "twelfthschool"}, required: true, set: setString(func(r *Row) *string { return &r.SchoolForClassTwelve })},
+	{name: "class_twelve_percentage", aliases: []string{"class12percentage", "twelfthpercentage"}, set: setFloat(func(r *Row) *float32 { return &r.ClassTwelvePercentage })},
+
+	{name: "father_name", aliases: []string{"fathersname"}, required: true, set: setString(func(r *Row) *string { return &r.FatherName })},
+	{name: "father_qualification", aliases: []string{"fathersqualification"}, required: true, set: setString(func(r *Row) *string { return &r.FatherQualification })},
+	{name: "father_profession", aliases: []string{"fathersprofession", "fatheroccupation", "fathersoccupation"}, required: true, set: setString(func(r *Row) *string { return &r.FatherProfession })},
+	{name: "mother_name", aliases: []string{"mothersname"}, required: true, set: setString(func(r *Row) *string { return &r.MotherName })},
+	{name: "mother_qualification", aliases: []string{"mothersqualification"}, required: true, set: setString(func(r *Row) *string { return &r.MotherQualification })},
+	{name: "mother_profession", aliases: []string{"mothersprofession", "motheroccupation", "mothersoccupation"}, required: true, set: setString(func(r *Row) *string { return &r.MotherProfession })},
+	{name: "no_of_siblings", aliases: []string{"siblings", "numberofsiblings"}, set: setInt(func(r *Row) *int { return &r.NoOfSiblings })},
+	{name: "total_family_income", aliases: []string{"familyincome", "annualfamilyincome"}, required: true, set: setInt(func(r *Row) *int { return &r.TotalFamilyIncome })},
+
+	{name: "scholarship_name", aliases: []string{"scholarship"}, set: setString(func(r *Row) *string { return &r.ScholarshipName })},
+	{name: "scholarship_provided_by", aliases: []string{"providedby"}, set: setString(func(r *Row) *string { return &r.ScholarshipProvidedBy })},
+	{name: "scholarship_amount", aliases: []string{"amountreceived", "scholarshipamountreceived"}, set: setInt(func(r *Row) *int { return &r.ScholarshipAmount })},
+}
+
+// columnMapping maps the cell positions of a roster to its fields
+type columnMapping struct {
+	positions map[int]*column
+	ignored   []string
+}
+
+// mapHeader matches the header cells to the roster columns. Unknown headers are ignored, a
+// missing required column or a column given twice makes the whole file unusable.
+func mapHeader(header []string) (*columnMapping, map[string]any) {
+	byHeader := make(map[string]*column)
+	for i := range columns {
+		c := &columns[i]
+		byHeader[normalizeHeader(c.name)] = c
+		for _, alias := range c.aliases {
+			byHeader[alias] = c
+		}
+	}
+
+	mapping := &columnMapping{positions: make(map[int]*column)}
+	seen := make(map[string]bool)
+	var duplicated []string
+
+	for i, cell := range header {
+		c, ok := byHeader[normalizeHeader(cell)]
+		if !ok {
+			if cell != "" {
+				mapping.ignored = append(mapping.ignored, cell)
+			}
+			continue
+		}
+		if seen[c.name] {
+			duplicated = append(duplicated, c.name)
+			continue
+		}
+		seen[c.name] = true
+		mapping.positions[i] = c
+	}
+
+	var missing []string
+	for _, c := range columns {
+		if c.required && !seen[c.name] {
+			missing = append(missing, c.name)
+		}
+	}
+
+	if len(missing) > 0 || len(duplicated) > 0 {
+		details := map[string]any{}
+		if len(missing) > 0 {
+			details["missing_columns"] = missing
+		}
+		if len(duplicated) > 0 {
+			details["duplicated_columns"] = duplicated
+		}
+		return nil, details
+	}
+
+	return mapping, nil
+}
+
+// parse fills a row from its cells, reporting cells that can't be read
+func (m *columnMapping) parse(cells []string) (Row, []FieldError) {
+	var row Row
+	var errs []FieldError
+
+	for i, value := range cells {
+		c, ok := m.positions[i]
+		if !ok || value == "" {
+			continue
+		}
+		if err := c.set(&row, value); err != nil {
+			errs = append(errs, FieldError{Column: c.name, Value: value, Message: err.Error()})
+		}
+	}
+
+	return row, errs
+}
+
+// normalizeHeader lower cases a header and drops everything but letters and digits
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func setString(field func(*Row) *string) func(*Row, string) error {
+	return func(row *Row, value string) error {
+		*field(row) = value
+		return nil
+	}
+}
+
+// setInt accepts whole numbers, including thousands separators and a trailing ".0" left by spreadsheets
+func setInt(field func(*Row) *int) func(*Row, string) error {
+	return func(row *Row, value string) error {
+		number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		if err != nil || number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+			return errors.New("must be a whole number")
+		}
+		*field(row) = int(number)
+		return nil
+	}
+}
+
+// setFloat accepts decimal numbers, with an optional percent sign
+func setFloat(field func(*Row) *float32) func(*Row, string) error {
+	return func(row *Row, value string) error {
+		number, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 32)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return errors.New("must be a number")
+		}
+		*field(row) = float32(number)
+		return nil
+	}
+}
+
+// setGender accepts the constraint values as well as the spelled out genders
+func setGender(row *Row, value string) error {
+	switch strings.ToUpper(value) {
+	case student.GenderMale, "MALE":
+		row.Gender = student.GenderMale
+	case student.GenderFemale, "FEMALE":
+		row.Gender = student.GenderFemale
+	case student.GenderOther, "OTHER":
+		row.Gender = student.GenderOther
+	default:
+		return fmt.Errorf("must be one of %s, %s or %s", student.GenderMale, student.GenderFemale, student.GenderOther)
+	}
+	return nil
+}
+
+// setCategory accepts the constraint values in any case, and "General" for GEN
+func setCategory(row *Row, value string) error {
+	category := strings.ToUpper(value)
+	switch category {
+	case student.CategoryGeneral, student.CategoryEWS, student.CategoryOBC, student.CategorySC, student.CategoryST:
+		row.Category = category
+	case "GENERAL":
+		row.Category = student.CategoryGeneral
+	default:
+		return fmt.Errorf(
+			"must be one of %s, %s, %s, %s or %s",
+			student.CategoryGeneral, student.CategoryEWS, student.CategoryOBC, student.CategorySC, student.CategoryST,
+		)
+	}
+	return nil
+}
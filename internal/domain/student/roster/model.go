@@ -0,0 +1,126 @@
+package roster
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/platform_profile"
+)
+
+// MaxRows is the largest number of students accepted in one roster
+const MaxRows = platform_profile.MaxBulkItems
+
+// PreviewTTL is how long a previewed roster can be committed
+const PreviewTTL = 24 * time.Hour
+
+// ImportStatus tracks a roster from its preview to its commit
+type ImportStatus string
+
+const (
+	ImportPreviewed  ImportStatus = "previewed"
+	ImportCommitting ImportStatus = "committing"
+	ImportCommitted  ImportStatus = "committed"
+)
+
+// Delivery selects how imported students receive their sign-in details
+type Delivery string
+
+const (
+	// DeliveryInvitation mails a link to choose a password, then a link verifying the address
+	DeliveryInvitation Delivery = "invitation"
+	// DeliveryCredentials mails a temporary password that has to be changed on first login
+	DeliveryCredentials Delivery = "credentials"
+	// DeliveryNone sends nothing, students use the password reset flow
+	DeliveryNone Delivery = "none"
+)
+
+// SourceRow is a non-blank row of an uploaded spreadsheet, the first one holds the column headers
+type SourceRow struct {
+	Number int
+	Cells  []string
+}
+
+// Row is one student of a roster, with the spreadsheet columns mapped onto the fields of
+// EnrollmentMasterLookupTable and its detail tables
+type Row struct {
+	EnrollmentNo string `json:"enrollment_no"`
+
+	// Login and profile details
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Gender   string `json:"gender"`
+	Category string `json:"category"`
+
+	// Academic details
+	Branch                string  `json:"branch"`
+	YearOfEnrollment      int     `json:"year_of_enrollment"`
+	CGPA                  float32 `json:"cgpa"`
+	PreviousSemSGPA       float32 `json:"previous_sem_sgpa"`
+	SchoolForClassTen     string  `json:"school_for_class_ten"`
+	ClassTenPercentage    float32 `json:"class_ten_percentage"`
+	SchoolForClassTwelve  string  `json:"school_for_class_twelve"`
+	ClassTwelvePercentage float32 `json:"class_twelve_percentage"`
+
+	// Family details
+	FatherName          string `json:"father_name"`
+	FatherQualification string `json:"father_qualification"`
+	FatherProfession    string `json:"father_profession"`
+	MotherName          string `json:"mother_name"`
+	MotherQualification string `json:"mother_qualification"`
+	MotherProfession    string `json:"mother_profession"`
+	NoOfSiblings        int    `json:"no_of_siblings"`
+	TotalFamilyIncome   int    `json:"total_family_income"`
+
+	// Scholarship details, all empty when the student has none
+	ScholarshipName       string `json:"scholarship_name,omitempty"`
+	ScholarshipProvidedBy string `json:"scholarship_provided_by,omitempty"`
+	ScholarshipAmount     int    `json:"scholarship_amount,omitempty"`
+}
+
+// FieldError describes why a cell of a roster row was rejected
+type FieldError struct {
+	Column  string `json:"column"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// PreviewRow is a parsed roster row along with its validation errors
+type PreviewRow struct {
+	RowNumber int          `json:"row_number"`
+	Student   Row          `json:"student"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Valid reports whether the row will be imported on commit
+func (r *PreviewRow) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Import is an uploaded roster. It is kept as a preview until a coordinator commits its valid rows.
+type Import struct {
+	ID             uuid.UUID    `json:"id"`
+	FileName       string       `json:"file_name"`
+	Status         ImportStatus `json:"status"`
+	IgnoredColumns []string     `json:"ignored_columns"`
+	TotalRows      int          `json:"total_rows"`
+	ValidRows      int          `json:"valid_rows"`
+	InvalidRows    int          `json:"invalid_rows"`
+	Rows           []PreviewRow `json:"rows"`
+
+	// Result reports the outcome of every committed row, Index holding the spreadsheet row number
+	Result *platform_profile.BulkResult `json:"result,omitempty"`
+
+	CreatedBy   uuid.UUID  `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CommittedBy *uuid.UUID `json:"committed_by,omitempty"`
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+}
+
+// CommitRequest creates the students of a previewed roster
+type CommitRequest struct {
+	// Delivery defaults to DeliveryInvitation
+	Delivery Delivery `json:"delivery" binding:"omitempty,oneof=invitation credentials none"`
+}
@@ -0,0 +1,50 @@
+package roster
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/student/student"
+)
+
+// NewStudent is a roster row ready to be created: the platform profile the student signs in
+// with and the enrollment record along with its detail tables
+type NewStudent struct {
+	RowNumber int
+	Profile   *platform_profile.PlatformProfile
+	Record    *student.EnrollmentMasterLookupTable
+}
+
+// Repository defines the data access contract for roster imports
+type Repository interface {
+	// CreateImport stores a previewed roster
+	CreateImport(ctx context.Context, imp *Import) error
+
+	// GetImport retrieves a roster import, nil if it does not exist
+	GetImport(ctx context.Context, id uuid.UUID) (*Import, error)
+
+	// ClaimImport moves a previewed, unexpired import to committing. It returns false when the
+	// import is committed, being committed or expired, so it is never committed twice.
+	ClaimImport(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+
+	// ReleaseImport returns a claimed import to previewed after a failed commit
+	ReleaseImport(ctx context.Context, id uuid.UUID) error
+
+	// CompleteImport stores the outcome of a commit and marks the import committed
+	CompleteImport(ctx context.Context, id uuid.UUID, actorID uuid.UUID, result *platform_profile.BulkResult, committedAt time.Time) error
+
+	// DeleteExpiredImports removes previews that were never committed
+	DeleteExpiredImports(ctx context.Context, now time.Time) (int64, error)
+
+	// FindRegistered returns the enrollment numbers and emails that already belong to a student
+	// or a platform profile. Enrollment numbers are also checked against profile usernames.
+	FindRegistered(ctx context.Context, enrollmentNos []string, emails []string) (map[string]bool, map[string]bool, error)
+
+	// CreateStudents creates the platform profile and the student records of every row together,
+	// each row in its own savepoint. Rows whose enrollment number, username or email is taken are
+	// skipped. Every created profile is assigned the student role.
+	CreateStudents(ctx context.Context, students []*NewStudent) ([]platform_profile.BulkItemResult, error)
+}
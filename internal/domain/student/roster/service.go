@@ -0,0 +1,471 @@
+package roster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"server/internal/common/errors"
+	"server/internal/common/utils"
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/role"
+	"server/internal/domain/student/student"
+	"server/pkg/logger"
+)
+
+// noScholarship fills the scholarship record of students without one, the table requires a row
+const noScholarship = "None"
+
+// temporaryPasswordLength is the number of random bytes of a mailed temporary password
+const temporaryPasswordLength = 9
+
+// Service imports the enrollment lists the university sends as spreadsheets
+type Service interface {
+	// Preview maps and validates the rows of a roster and stores them until they are committed.
+	// The first row holds the column headers.
+	Preview(ctx context.Context, actorID uuid.UUID, fileName string, rows []SourceRow) (*Import, error)
+	// GetImport retrieves a roster import with its rows and, once committed, its result
+	GetImport(ctx context.Context, id uuid.UUID) (*Import, error)
+	// Commit creates the platform profiles and student records of the valid rows of a preview and
+	// sends the students their sign-in details
+	Commit(ctx context.Context, actorID uuid.UUID, id uuid.UUID, req CommitRequest) (*Import, error)
+}
+
+// Inviter sends the link to set the first password of a profile, or the link verifying the
+// address a temporary password was mailed to. Implemented by platform_profile.Service.
+type Inviter interface {
+	SendInvitation(ctx context.Context, profileID uuid.UUID) error
+	ResendVerificationEmail(ctx context.Context, req platform_profile.ResendVerificationRequest) error
+}
+
+// CredentialsMailer mails a temporary password.
+// Implemented by infrastructure/email.SMTPProvider.
+type CredentialsMailer interface {
+	SendCredentialsEmail(to, username, password string) error
+}
+
+type service struct {
+	repo           Repository
+	inviter        Inviter
+	mailer         CredentialsMailer
+	passwordHasher platform_profile.PasswordHasher
+	logger         logger.Logger
+}
+
+// NewService creates a new roster import service
+func NewService(
+	repo Repository,
+	inviter Inviter,
+	mailer CredentialsMailer,
+	passwordHasher platform_profile.PasswordHasher,
+	logger logger.Logger,
+) Service {
+	return &service{
+		repo:           repo,
+		inviter:        inviter,
+		mailer:         mailer,
+		passwordHasher: passwordHasher,
+		logger:         logger,
+	}
+}
+
+// Preview parses the rows, reporting for every row the cells that break the constraints of the
+// student tables, repeat an earlier row or are already registered
+func (s *service) Preview(ctx context.Context, actorID uuid.UUID, fileName string, rows []SourceRow) (*Import, error) {
+	if len(rows) < 2 {
+		return nil, errors.NewValidationError("the roster has no students, the first row must hold the column headers", map[string]any{"field": "file"})
+	}
+	if len(rows)-1 > MaxRows {
+		return nil, errors.NewValidationError(fmt.Sprintf("a roster can hold at most %d students", MaxRows), map[string]any{"field": "file"})
+	}
+
+	mapping, details := mapHeader(rows[0].Cells)
+	if details != nil {
+		return nil, errors.NewValidationError("the roster columns don't match the expected format", details)
+	}
+
+	access := role.AccessScopeFromContext(ctx)
+	previews := make([]PreviewRow, 0, len(rows)-1)
+	firstEnrollment := make(map[string]int)
+	firstEmail := make(map[string]int)
+
+	for _, source := range rows[1:] {
+		row, parseErrs := mapping.parse(source.Cells)
+		preview := PreviewRow{RowNumber: source.Number, Errors: parseErrs}
+		preview.Errors = appendFieldErrors(preview.Errors, validateRow(&row))
+		preview.Errors = appendFieldErrors(preview.Errors, validateScope(access, &row))
+		preview.Student = row
+
+		// Later rows repeating an enrollment number or email are rejected, the first one is kept
+		if row.EnrollmentNo != "" {
+			if first, ok := firstEnrollment[row.EnrollmentNo]; ok {
+				preview.Errors = appendFieldErrors(preview.Errors, []FieldError{{Column: "enrollment_no", Value: row.EnrollmentNo, Message: fmt.Sprintf("repeats row %d", first)}})
+			} else {
+				firstEnrollment[row.EnrollmentNo] = source.Number
+			}
+		}
+		if row.Email != "" {
+			if first, ok := firstEmail[row.Email]; ok {
+				preview.Errors = appendFieldErrors(preview.Errors, []FieldError{{Column: "email", Value: row.Email, Message: fmt.Sprintf("repeats row %d", first)}})
+			} else {
+				firstEmail[row.Email] = source.Number
+			}
+		}
+
+		previews = append(previews, preview)
+	}
+
+	if err := s.markRegistered(ctx, previews, firstEnrollment, firstEmail); err != nil {
+		return nil, err
+	}
+
+	if deleted, err := s.repo.DeleteExpiredImports(ctx, time.Now()); err != nil {
+		s.logger.Warn("Failed to delete expired roster imports", "error", err)
+	} else if deleted > 0 {
+		s.logger.Info("Deleted expired roster imports", "deleted", deleted)
+	}
+
+	now := time.Now()
+	imp := &Import{
+		ID:             uuid.New(),
+		FileName:       fileName,
+		Status:         ImportPreviewed,
+		IgnoredColumns: mapping.ignored,
+		TotalRows:      len(previews),
+		Rows:           previews,
+		CreatedBy:      actorID,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(PreviewTTL),
+	}
+	if imp.IgnoredColumns == nil {
+		imp.IgnoredColumns = []string{}
+	}
+	for i := range previews {
+		if previews[i].Valid() {
+			imp.ValidRows++
+		}
+	}
+	imp.InvalidRows = imp.TotalRows - imp.ValidRows
+
+	if err := s.repo.CreateImport(ctx, imp); err != nil {
+		s.logger.Error("Failed to store roster preview", "file_name", fileName, "actor_id", actorID, "error", err)
+		return nil, errors.NewDatabaseError("storing roster preview", err)
+	}
+
+	s.logger.Info(
+		"Roster previewed",
+		"import_id", imp.ID,
+		"file_name", fileName,
+		"actor_id", actorID,
+		"rows", imp.TotalRows,
+		"valid_rows", imp.ValidRows,
+	)
+	return imp, nil
+}
+
+// markRegistered reports the rows whose enrollment number or email is already taken
+func (s *service) markRegistered(ctx context.Context, previews []PreviewRow, enrollments map[string]int, emails map[string]int) error {
+	enrollmentNos := make([]string, 0, len(enrollments))
+	for enrollmentNo := range enrollments {
+		enrollmentNos = append(enrollmentNos, enrollmentNo)
+	}
+	addresses := make([]string, 0, len(emails))
+	for email := range emails {
+		addresses = append(addresses, email)
+	}
+
+	registeredEnrollments, registeredEmails, err := s.repo.FindRegistered(ctx, enrollmentNos, addresses)
+	if err != nil {
+		s.logger.Error("Failed to look up registered students", "error", err)
+		return errors.NewDatabaseError("looking up registered students", err)
+	}
+
+	for i := range previews {
+		row := &previews[i].Student
+		if registeredEnrollments[row.EnrollmentNo] {
+			previews[i].Errors = appendFieldErrors(previews[i].Errors, []FieldError{{Column: "enrollment_no", Value: row.EnrollmentNo, Message: "is already registered"}})
+		}
+		if registeredEmails[row.Email] {
+			previews[i].Errors = appendFieldErrors(previews[i].Errors, []FieldError{{Column: "email", Value: row.Email, Message: "is already registered"}})
+		}
+	}
+
+	return nil
+}
+
+// GetImport retrieves a roster import
+func (s *service) GetImport(ctx context.Context, id uuid.UUID) (*Import, error) {
+	imp, err := s.repo.GetImport(ctx, id)
+	if err != nil {
+		s.logger.Error("Failed to get roster import", "import_id", id, "error", err)
+		return nil, errors.NewDatabaseError("fetching roster import", err)
+	}
+	if imp == nil {
+		return nil, errors.NewNotFoundError("roster import", map[string]interface{}{"id": id})
+	}
+
+	return imp, nil
+}
+
+// Commit creates the students of the valid rows. Rows registered since the preview are skipped.
+// Sign-in details are mailed in the background once the students are saved.
+func (s *service) Commit(ctx context.Context, actorID uuid.UUID, id uuid.UUID, req CommitRequest) (*Import, error) {
+	delivery := req.Delivery
+	if delivery == "" {
+		delivery = DeliveryInvitation
+	}
+
+	imp, err := s.GetImport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if imp.Status != ImportPreviewed {
+		return nil, errors.NewBusinessError("IMPORT_ALREADY_COMMITTED", "the roster has already been committed", nil)
+	}
+	if !now.Before(imp.ExpiresAt) {
+		return nil, errors.NewBusinessError("IMPORT_EXPIRED", "the roster preview has expired, please upload the file again", nil)
+	}
+	if imp.ValidRows == 0 {
+		return nil, errors.NewValidationError("the roster has no valid rows to import", map[string]any{"invalid_rows": imp.InvalidRows})
+	}
+
+	// The preview may have been uploaded by someone with a wider scope
+	access := role.AccessScopeFromContext(ctx)
+	for i := range imp.Rows {
+		if imp.Rows[i].Valid() && len(validateScope(access, &imp.Rows[i].Student)) > 0 {
+			s.logger.Warn("Roster commit outside access scope", "import_id", id, "actor_id", actorID, "row", imp.Rows[i].RowNumber)
+			return nil, errors.NewForbiddenError(fmt.Sprintf("row %d is outside your access scope", imp.Rows[i].RowNumber))
+		}
+	}
+
+	claimed, err := s.repo.ClaimImport(ctx, id, now)
+	if err != nil {
+		s.logger.Error("Failed to claim roster import", "import_id", id, "error", err)
+		return nil, errors.NewDatabaseError("claiming roster import", err)
+	}
+	if !claimed {
+		return nil, errors.NewBusinessError("IMPORT_ALREADY_COMMITTED", "the roster has already been committed or has expired", nil)
+	}
+
+	s.logger.Info("Committing roster", "import_id", id, "actor_id", actorID, "rows", imp.ValidRows, "delivery", delivery)
+
+	students, passwords, err := s.newStudents(imp.Rows, delivery, now)
+	if err != nil {
+		s.releaseImport(ctx, id)
+		return nil, err
+	}
+
+	items, err := s.repo.CreateStudents(ctx, students)
+	if err != nil {
+		s.logger.Error("Failed to create roster students", "import_id", id, "error", err)
+		s.releaseImport(ctx, id)
+		return nil, errors.NewDatabaseError("creating students", err)
+	}
+
+	created := make([]*NewStudent, 0, len(items))
+	for i := range items {
+		items[i].Index = students[i].RowNumber
+		if items[i].Status == platform_profile.BulkItemCreated {
+			created = append(created, students[i])
+		}
+	}
+
+	result := summarize(items)
+	committedAt := time.Now()
+	if err := s.repo.CompleteImport(ctx, id, actorID, result, committedAt); err != nil {
+		// The students exist, the import stays claimed so it can't be committed again
+		s.logger.Error("Failed to complete roster import", "import_id", id, "error", err)
+		return nil, errors.NewDatabaseError("completing roster import", err)
+	}
+
+	imp.Status = ImportCommitted
+	imp.Result = result
+	imp.CommittedBy = &actorID
+	imp.CommittedAt = &committedAt
+
+	s.logger.Info(
+		"Roster committed",
+		"import_id", id,
+		"actor_id", actorID,
+		"succeeded", result.Succeeded,
+		"skipped", result.Skipped,
+		"failed", result.Failed,
+	)
+
+	if delivery != DeliveryNone && len(created) > 0 {
+		// Mail delivery retries can take minutes for a large roster, so it doesn't hold up the response
+		go s.notify(context.WithoutCancel(ctx), id, delivery, created, passwords)
+	}
+
+	return imp, nil
+}
+
+// newStudents prepares the profiles and records of the valid rows. Credentials are mailed as a
+// temporary password that must be changed, otherwise the profile gets an unusable random password
+// and stays pending until the student sets one through the invitation or a password reset.
+func (s *service) newStudents(rows []PreviewRow, delivery Delivery, now time.Time) ([]*NewStudent, map[uuid.UUID]string, error) {
+	students := make([]*NewStudent, 0, len(rows))
+	passwords := make(map[uuid.UUID]string)
+
+	for i := range rows {
+		if !rows[i].Valid() {
+			continue
+		}
+		row := &rows[i].Student
+
+		password, err := utils.GenerateToken(temporaryPasswordLength)
+		if err != nil {
+			s.logger.Error("Failed to generate password", "enrollment_no", row.EnrollmentNo, "error", err)
+			return nil, nil, errors.NewBusinessError("PASSWORD_HASHING_FAILED", "password hashing failed", nil)
+		}
+
+		passwordHash, err := s.passwordHasher.Hash(password)
+		if err != nil {
+			s.logger.Error("Failed to hash password", "enrollment_no", row.EnrollmentNo, "error", err)
+			return nil, nil, errors.NewBusinessError("PASSWORD_HASHING_FAILED", "password hashing failed", nil)
+		}
+
+		profile := &platform_profile.PlatformProfile{
+			ID:                uuid.New(),
+			Username:          row.EnrollmentNo,
+			Email:             row.Email,
+			PasswordHash:      passwordHash,
+			Status:            platform_profile.StatusPending,
+			CreatedAt:         now,
+			UpdatedByUserAt:   now,
+			UpdatedBySystemAt: now,
+		}
+		if delivery == DeliveryCredentials {
+			// The address is verified through the verification email sent along with the password
+			profile.Status = platform_profile.StatusActivated
+			profile.MustChangePassword = true
+			passwords[profile.ID] = password
+		}
+
+		students = append(students, &NewStudent{
+			RowNumber: rows[i].RowNumber,
+			Profile:   profile,
+			Record:    newRecord(row, passwordHash),
+		})
+	}
+
+	return students, passwords, nil
+}
+
+// newRecord maps a roster row onto the enrollment record and its detail tables
+func newRecord(row *Row, passwordHash string) *student.EnrollmentMasterLookupTable {
+	scholarship := student.StudentScholarshipDetailsTable{
+		ScholarshipName: row.ScholarshipName,
+		ProvidedBy:      row.ScholarshipProvidedBy,
+		AmountReceived:  row.ScholarshipAmount,
+	}
+	if scholarship.ScholarshipName == "" {
+		scholarship.ScholarshipName = noScholarship
+		scholarship.ProvidedBy = noScholarship
+	}
+
+	return &student.EnrollmentMasterLookupTable{
+		EnrollmentNo: row.EnrollmentNo,
+		StudentLogInDetailsTable: student.StudentLogInDetailsTable{
+			Email:    row.Email,
+			Password: passwordHash,
+			Phone:    row.Phone,
+		},
+		AcademicDetails: student.StudentAcademicDetailsTable{
+			Branch:                row.Branch,
+			YearOfEnrollment:      row.YearOfEnrollment,
+			CGPA:                  row.CGPA,
+			PreviousSemSGPA:       row.PreviousSemSGPA,
+			SchoolForClassTen:     row.SchoolForClassTen,
+			ClassTenPercentage:    row.ClassTenPercentage,
+			SchoolForClassTwelve:  row.SchoolForClassTwelve,
+			ClassTwelvePercentage: row.ClassTwelvePercentage,
+		},
+		FamilyDetails: student.StudentFamilyDetailsTable{
+			FatherName:          row.FatherName,
+			FatherQualification: row.FatherQualification,
+			FatherProfession:    row.FatherProfession,
+			MotherName:          row.MotherName,
+			MotherQualification: row.MotherQualification,
+			MotherProfession:    row.MotherProfession,
+			NoOfSiblings:        row.NoOfSiblings,
+			TotalFamilyIncome:   row.TotalFamilyIncome,
+		},
+		ProfileDetails: student.StudentProfileDetailsTable{
+			UserRole: student.UserRoleStudent,
+			Name:     row.Name,
+			Gender:   row.Gender,
+			Category: row.Category,
+		},
+		ScholarshipDetails: scholarship,
+	}
+}
+
+// notify mails the sign-in details of the created students. Failures are logged, administrators
+// can send a new invitation to a profile later.
+func (s *service) notify(ctx context.Context, importID uuid.UUID, delivery Delivery, students []*NewStudent, passwords map[uuid.UUID]string) {
+	failed := 0
+	for _, st := range students {
+		var err error
+		switch delivery {
+		case DeliveryCredentials:
+			err = s.mailer.SendCredentialsEmail(st.Profile.Email, st.Profile.Username, passwords[st.Profile.ID])
+			if err == nil {
+				err = s.inviter.ResendVerificationEmail(ctx, platform_profile.ResendVerificationRequest{Email: st.Profile.Email})
+			}
+		default:
+			err = s.inviter.SendInvitation(ctx, st.Profile.ID)
+		}
+
+		if err != nil {
+			failed++
+			s.logger.Error("Failed to send sign-in details", "import_id", importID, "profile_id", st.Profile.ID, "delivery", delivery, "error", err)
+		}
+	}
+
+	s.logger.Info("Roster sign-in details sent", "import_id", importID, "delivery", delivery, "sent", len(students)-failed, "failed", failed)
+}
+
+// releaseImport lets a failed commit be retried
+func (s *service) releaseImport(ctx context.Context, id uuid.UUID) {
+	if err := s.repo.ReleaseImport(ctx, id); err != nil {
+		s.logger.Error("Failed to release roster import", "import_id", id, "error", err)
+	}
+}
+
+// appendFieldErrors adds errors for columns that have none yet, so a cell is reported once
+func appendFieldErrors(errs []FieldError, more []FieldError) []FieldError {
+	for _, e := range more {
+		reported := false
+		for _, existing := range errs {
+			if existing.Column == e.Column {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// summarize counts the outcomes of the committed rows
+func summarize(items []platform_profile.BulkItemResult) *platform_profile.BulkResult {
+	result := &platform_profile.BulkResult{Total: len(items), Items: items}
+	for _, item := range items {
+		switch item.Status {
+		case platform_profile.BulkItemSkipped:
+			result.Skipped++
+		case platform_profile.BulkItemFailed:
+			result.Failed++
+		default:
+			result.Succeeded++
+		}
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package roster
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"server/internal/domain/role"
+	"server/internal/domain/student/student"
+)
+
+// Column lengths of the student tables
+const (
+	maxNameLength   = 100
+	maxEmailLength  = 255
+	maxPhoneLength  = 15
+	minPhoneLength  = 10
+	maxBranchLength = 7
+	maxSchoolLength = 255
+)
+
+// validateRow checks a parsed row against the constraints of the student tables. Required cells
+// are checked here since an empty cell leaves its field unset.
+func validateRow(row *Row) []FieldError {
+	var errs []FieldError
+	fail := func(column, value, message string) {
+		errs = append(errs, FieldError{Column: column, Value: value, Message: message})
+	}
+
+	row.EnrollmentNo = strings.ToUpper(row.EnrollmentNo)
+	switch {
+	case row.EnrollmentNo == "":
+		fail("enrollment_no", "", "is required")
+	case utf8.RuneCountInString(row.EnrollmentNo) != student.EnrollmentNoLength || !isAlphanumeric(row.EnrollmentNo):
+		fail("enrollment_no", row.EnrollmentNo, fmt.Sprintf("must be %d letters or digits", student.EnrollmentNoLength))
+	}
+
+	requireText := func(column, value string, maxLength int) {
+		switch {
+		case value == "":
+			fail(column, "", "is required")
+		case utf8.RuneCountInString(value) > maxLength:
+			fail(column, value, fmt.Sprintf("must be at most %d characters", maxLength))
+		}
+	}
+
+	requireText("name", row.Name, maxNameLength)
+
+	row.Email = strings.ToLower(row.Email)
+	requireText("email", row.Email, maxEmailLength)
+	if row.Email != "" {
+		if address, err := mail.ParseAddress(row.Email); err != nil || address.Address != row.Email {
+			fail("email", row.Email, "must be a valid email address")
+		}
+	}
+
+	row.Phone = normalizePhone(row.Phone)
+	switch {
+	case row.Phone == "":
+		fail("phone", "", "is required")
+	case len(row.Phone) < minPhoneLength || len(row.Phone) > maxPhoneLength:
+		fail("phone", row.Phone, fmt.Sprintf("must have between %d and %d digits", minPhoneLength, maxPhoneLength))
+	}
+
+	// Invalid enum values were already reported while parsing
+	if row.Gender == "" {
+		fail("gender", "", "is required")
+	}
+	if row.Category == "" {
+		fail("category", "", "is required")
+	}
+
+	row.Branch = strings.ToUpper(row.Branch)
+	requireText("branch", row.Branch, maxBranchLength)
+
+	if row.YearOfEnrollment < student.MinYearOfEnrollment || row.YearOfEnrollment > student.MaxYearOfEnrollment {
+		fail("year_of_enrollment", strconv.Itoa(row.YearOfEnrollment), fmt.Sprintf("must be between %d and %d", student.MinYearOfEnrollment, student.MaxYearOfEnrollment))
+	}
+
+	checkRange := func(column string, value float32, max float32) {
+		if value < 0 || value > max {
+			fail(column, strconv.FormatFloat(float64(value), 'f', -1, 32), fmt.Sprintf("must be between 0 and %v", max))
+		}
+	}
+	checkRange("cgpa", row.CGPA, student.MaxGradePoint)
+	checkRange("previous_sem_sgpa", row.PreviousSemSGPA, student.MaxGradePoint)
+	checkRange("class_ten_percentage", row.ClassTenPercentage, student.MaxPercentage)
+	checkRange("class_twelve_percentage", row.ClassTwelvePercentage, student.MaxPercentage)
+
+	requireText("school_for_class_ten", row.SchoolForClassTen, maxSchoolLength)
+	requireText("school_for_class_twelve", row.SchoolForClassTwelve, maxSchoolLength)
+
+	requireText("father_name", row.FatherName, maxNameLength)
+	requireText("father_qualification", row.FatherQualification, maxNameLength)
+	requireText("father_profession", row.FatherProfession, maxNameLength)
+	requireText("mother_name", row.MotherName, maxNameLength)
+	requireText("mother_qualification", row.MotherQualification, maxNameLength)
+	requireText("mother_profession", row.MotherProfession, maxNameLength)
+
+	if row.NoOfSiblings < 0 {
+		fail("no_of_siblings", strconv.Itoa(row.NoOfSiblings), "can't be negative")
+	}
+	if row.TotalFamilyIncome < 0 {
+		fail("total_family_income", strconv.Itoa(row.TotalFamilyIncome), "can't be negative")
+	}
+
+	// The scholarship is optional, but when given it needs a name and a provider
+	if row.ScholarshipName != "" || row.ScholarshipProvidedBy != "" || row.ScholarshipAmount != 0 {
+		requireText("scholarship_name", row.ScholarshipName, maxNameLength)
+		requireText("scholarship_provided_by", row.ScholarshipProvidedBy, maxNameLength)
+		if row.ScholarshipAmount < 0 {
+			fail("scholarship_amount", strconv.Itoa(row.ScholarshipAmount), "can't be negative")
+		}
+	}
+
+	return errs
+}
+
+// isAlphanumeric reports whether s only holds ASCII letters and digits
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizePhone drops the separators of a phone number, keeping a leading plus
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			// Leave letters in place so the number is rejected
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// validateScope rejects a row of a branch and admission year outside the caller's access scope
+func validateScope(access role.AccessScope, row *Row) []FieldError {
+	if access.Allows(row.Branch, strconv.Itoa(row.YearOfEnrollment)) {
+		return nil
+	}
+	return []FieldError{{Column: "branch", Value: row.Branch, Message: "is outside your access scope"}}
+}
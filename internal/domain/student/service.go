@@ -6,37 +6,57 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+
+	"server/internal/common/utils"
+	"server/internal/common/validator"
+	"server/internal/domain/role"
 )
 
 // Common errors
 var (
-	ErrStudentNotFound      = errors.New("student not found")
-	ErrEmailAlreadyExists   = errors.New("email already exists")
-	ErrEnrollmentIDExists   = errors.New("enrollment ID already exists")
-	ErrInvalidCredentials   = errors.New("invalid credentials")
-	ErrInvalidStatus        = errors.New("invalid status")
-	ErrInvalidEmail         = errors.New("invalid email format")
-	ErrInvalidEnrollmentID  = errors.New("invalid enrollment ID format")
-	ErrInvalidPasswordStrength = errors.New("password does not meet strength requirements")
+	ErrStudentNotFound             = errors.New("student not found")
+	ErrEmailAlreadyExists          = errors.New("email already exists")
+	ErrEnrollmentIDExists          = errors.New("enrollment ID already exists")
+	ErrInvalidCredentials          = errors.New("invalid credentials")
+	ErrInvalidStatus               = errors.New("invalid status")
+	ErrInvalidEmail                = errors.New("invalid email format")
+	ErrInvalidEnrollmentID         = errors.New("invalid enrollment ID format")
+	ErrInvalidPasswordStrength     = errors.New("password does not meet strength requirements")
+	ErrInvalidBatch                = errors.New("invalid batch")
+	ErrInvalidSortField            = errors.New("invalid sort field")
+	ErrContactNotFound             = errors.New("contact not found")
+	ErrInvalidDossierSection       = errors.New("invalid dossier section")
+	ErrInvalidAttendance           = errors.New("invalid attendance")
+	ErrSectionNotFound             = errors.New("section not found")
+	ErrStudentNotInSection         = errors.New("student not in section")
+	ErrInvalidContact              = errors.New("invalid contact")
+	ErrPrimaryContactRequired      = errors.New("primary emergency contact required")
+	ErrInvalidGuardianNotification = errors.New("invalid guardian notification")
 )
 
 // Service provides student-related operations
 type Service struct {
-	repo Repository
+	repo             Repository
+	passwordHasher   *utils.PasswordHasher
+	guardianMailer   GuardianMailer
+	attendancePolicy AttendancePolicy
 	// Add other necessary dependencies like event publisher, logger, etc.
 	// eventPublisher eventbus.Publisher
 	// logger         logger.Logger
 }
 
 // NewService creates a new instance of the student service
-func NewService(repo Repository) *Service {
+func NewService(repo Repository, passwordHasher *utils.PasswordHasher, guardianMailer GuardianMailer, attendancePolicy AttendancePolicy) *Service {
 	return &Service{
-		repo: repo,
+		repo:             repo,
+		passwordHasher:   passwordHasher,
+		guardianMailer:   guardianMailer,
+		attendancePolicy: attendancePolicy,
 	}
 }
 
@@ -70,7 +90,7 @@ func (s *Service) Register(ctx context.Context, student *Student, password strin
 	}
 
 	// Hash password
-	hashedPassword, err := hashPassword(password)
+	hashedPassword, err := s.passwordHasher.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -109,10 +129,18 @@ func (s *Service) Authenticate(ctx context.Context, email, password string) (*St
 	}
 
 	// Verify password
-	if err := verifyPassword(student.PasswordHash, password); err != nil {
+	match, needsRehash, err := s.passwordHasher.Verify(password, student.PasswordHash)
+	if err != nil || !match {
 		return nil, ErrInvalidCredentials
 	}
 
+	// Upgrade legacy bcrypt hashes and outdated Argon2 parameters along with the login time
+	if needsRehash {
+		if hashedPassword, err := s.passwordHasher.Hash(password); err == nil {
+			student.PasswordHash = hashedPassword
+		}
+	}
+
 	// Update last login time
 	now := time.Now()
 	student.LastLoginAt = &now
@@ -127,7 +155,7 @@ func (s *Service) Authenticate(ctx context.Context, email, password string) (*St
 // GetByID retrieves a student by ID
 func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Student, error) {
 	student, err := s.repo.GetByID(ctx, id)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
 		return nil, ErrStudentNotFound
 	}
 	return student, nil
@@ -136,7 +164,7 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Student, error) {
 // GetByEnrollmentID retrieves a student by enrollment ID
 func (s *Service) GetByEnrollmentID(ctx context.Context, enrollmentID string) (*Student, error) {
 	student, err := s.repo.GetByEnrollmentID(ctx, enrollmentID)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
 		return nil, ErrStudentNotFound
 	}
 	return student, nil
@@ -145,7 +173,11 @@ func (s *Service) GetByEnrollmentID(ctx context.Context, enrollmentID string) (*
 // Update updates a student's profile
 func (s *Service) Update(ctx context.Context, student *Student) (*Student, error) {
 	existingStudent, err := s.repo.GetByID(ctx, student.ID)
-	if err != nil {
+	if err != nil || !inScope(ctx, existingStudent) {
+		return nil, ErrStudentNotFound
+	}
+	// Students can't be moved out of the caller's scope either
+	if !inScope(ctx, student) {
 		return nil, ErrStudentNotFound
 	}
 
@@ -163,6 +195,15 @@ func (s *Service) Update(ctx context.Context, student *Student) (*Student, error
 	return student, nil
 }
 
+// GetByProfileID retrieves the student linked to the platform profile a student signs in with
+func (s *Service) GetByProfileID(ctx context.Context, profileID uuid.UUID) (*Student, error) {
+	student, err := s.repo.GetByProfileID(ctx, profileID)
+	if err != nil || !inScope(ctx, student) {
+		return nil, ErrStudentNotFound
+	}
+	return student, nil
+}
+
 // UpdateEmail updates a student's email
 func (s *Service) UpdateEmail(ctx context.Context, studentID uuid.UUID, newEmail string) error {
 	if !isValidEmail(newEmail) {
@@ -176,7 +217,7 @@ func (s *Service) UpdateEmail(ctx context.Context, studentID uuid.UUID, newEmail
 	}
 
 	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
 		return ErrStudentNotFound
 	}
 
@@ -196,12 +237,12 @@ func (s *Service) UpdateEmail(ctx context.Context, studentID uuid.UUID, newEmail
 // ChangePassword changes a student's password
 func (s *Service) ChangePassword(ctx context.Context, studentID uuid.UUID, currentPassword, newPassword string) error {
 	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
 		return ErrStudentNotFound
 	}
 
 	// Verify current password
-	if err := verifyPassword(student.PasswordHash, currentPassword); err != nil {
+	if match, _, err := s.passwordHasher.Verify(currentPassword, student.PasswordHash); err != nil || !match {
 		return ErrInvalidCredentials
 	}
 
@@ -211,7 +252,7 @@ func (s *Service) ChangePassword(ctx context.Context, studentID uuid.UUID, curre
 	}
 
 	// Hash new password
-	hashedPassword, err := hashPassword(newPassword)
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -232,7 +273,7 @@ func (s *Service) ChangePassword(ctx context.Context, studentID uuid.UUID, curre
 // UpdateStatus updates a student's status
 func (s *Service) UpdateStatus(ctx context.Context, studentID uuid.UUID, status StudentStatus) error {
 	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
 		return ErrStudentNotFound
 	}
 
@@ -259,117 +300,128 @@ func (s *Service) UpdateStatus(ctx context.Context, studentID uuid.UUID, status
 	return nil
 }
 
-// ListStudents retrieves a list of students with filtering and pagination
+// ListStudents retrieves a list of students with filtering and pagination.
+// Callers whose roles are scoped to a branch or batch only get those students.
 func (s *Service) ListStudents(ctx context.Context, filter StudentFilter, pagination Pagination) ([]*Student, int64, error) {
+	filter.Scope = role.AccessScopeFromContext(ctx)
 	return s.repo.List(ctx, filter, pagination)
 }
 
-// EnrollCourse enrolls a student in a course
-func (s *Service) EnrollCourse(ctx context.Context, studentID uuid.UUID, course Course) error {
-	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
-		return ErrStudentNotFound
+// GetStudentDossier assembles the complete record of a student, limited to the requested sections.
+// The family income and category are redacted unless the caller is an admin, see role.RoleNamesFromContext.
+func (s *Service) GetStudentDossier(ctx context.Context, enrollmentNo string, options DossierOptions) (*Dossier, error) {
+	for _, section := range options.Sections {
+		if !IsValidDossierSection(section) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDossierSection, section)
+		}
 	}
 
-	// Check if student is already enrolled in the course
-	for _, existingCourse := range student.Courses {
-		if existingCourse.CourseCode == course.CourseCode {
-			return errors.New("student already enrolled in this course")
-		}
+	dossier, err := s.repo.GetDossier(ctx, enrollmentNo, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dossier: %w", err)
+	}
+	if dossier == nil || !role.AccessScopeFromContext(ctx).Allows(dossier.Branch, dossier.Batch) {
+		return nil, ErrStudentNotFound
 	}
 
-	// Set default values
-	course.ID = uuid.New()
-	course.EnrolledAt = time.Now()
+	if !slices.Contains(role.RoleNamesFromContext(ctx), role.RoleAdmin) {
+		redactDossier(dossier)
+	}
 
-	return s.repo.AddCourse(ctx, studentID, course)
+	return dossier, nil
 }
 
-// UnenrollCourse removes a student from a course
-func (s *Service) UnenrollCourse(ctx context.Context, studentID, courseID uuid.UUID) error {
+// GetAttendance retrieves attendance records for a student
+func (s *Service) GetAttendance(ctx context.Context, studentID uuid.UUID, courseID *uuid.UUID, semester *int) ([]StudentAttendance, error) {
 	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+	if err != nil || !inScope(ctx, student) {
+		return nil, ErrStudentNotFound
+	}
+
+	return s.repo.GetAttendance(ctx, studentID, courseID, semester)
+}
+
+// RecordAttendance records attendance for a student. Records of dates already marked are replaced,
+// and the statistics are updated by the repository.
+func (s *Service) RecordAttendance(ctx context.Context, attendance *StudentAttendance) error {
+	student, err := s.repo.GetByID(ctx, attendance.StudentID)
+	if err != nil || !inScope(ctx, student) {
 		return ErrStudentNotFound
 	}
 
-	// Check if student is enrolled in the course
-	found := false
-	for _, course := range student.Courses {
-		if course.ID == courseID {
-			found = true
-			break
+	// Validate attendance record
+	for _, record := range attendance.Records {
+		if !IsValidAttendanceType(record.Status) || record.Date.IsZero() {
+			return fmt.Errorf("%w: every record needs a date and a status of present, absent or leave", ErrInvalidAttendance)
 		}
 	}
 
-	if !found {
-		return errors.New("student not enrolled in this course")
+	if attendance.ID == uuid.Nil {
+		attendance.ID = uuid.New()
 	}
+	attendance.CreatedAt = time.Now()
+	attendance.UpdatedAt = attendance.CreatedAt
 
-	return s.repo.RemoveCourse(ctx, studentID, courseID)
+	return s.repo.RecordAttendance(ctx, attendance, s.attendancePolicy)
 }
 
-// UpdateCourseGrade updates a student's grade for a specific course
-func (s *Service) UpdateCourseGrade(ctx context.Context, studentID, courseID uuid.UUID, grade string) error {
-	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
-		return ErrStudentNotFound
+// MarkSectionAttendance records one class for a whole section. Students without a mark get the
+// default status, present unless set. The marks can be submitted again safely, only changed
+// records are written. Students whose attendance falls below the shortage threshold are flagged
+// and a notification is queued for them.
+func (s *Service) MarkSectionAttendance(ctx context.Context, sheet SectionAttendance) (*SectionAttendanceResult, error) {
+	sheet.Branch = strings.ToUpper(strings.TrimSpace(sheet.Branch))
+	sheet.Section = strings.TrimSpace(sheet.Section)
+	if sheet.DefaultStatus == "" {
+		sheet.DefaultStatus = AttendancePresent
 	}
 
-	// Check if student is enrolled in the course
-	found := false
-	for _, course := range student.Courses {
-		if course.ID == courseID {
-			found = true
-			break
-		}
+	if sheet.CourseID == uuid.Nil || sheet.Semester < 1 || sheet.Semester > 10 || sheet.Date.IsZero() || sheet.Branch == "" {
+		return nil, fmt.Errorf("%w: course, semester between 1 and 10, date and branch are required", ErrInvalidAttendance)
 	}
-
-	if !found {
-		return errors.New("student not enrolled in this course")
+	if !IsValidAttendanceType(sheet.DefaultStatus) {
+		return nil, fmt.Errorf("%w: default status must be present, absent or leave", ErrInvalidAttendance)
 	}
 
-	// Validate grade format
-	if !isValidGrade(grade) {
-		return errors.New("invalid grade format")
+	marked := make(map[uuid.UUID]bool, len(sheet.Marks))
+	for _, mark := range sheet.Marks {
+		if !IsValidAttendanceType(mark.Status) {
+			return nil, fmt.Errorf("%w: status of student %s must be present, absent or leave", ErrInvalidAttendance, mark.StudentID)
+		}
+		if marked[mark.StudentID] {
+			return nil, fmt.Errorf("%w: student %s is marked more than once", ErrInvalidAttendance, mark.StudentID)
+		}
+		marked[mark.StudentID] = true
 	}
 
-	return s.repo.UpdateCourseGrade(ctx, studentID, courseID, grade)
-}
-
-// GetAttendance retrieves attendance records for a student
-func (s *Service) GetAttendance(ctx context.Context, studentID uuid.UUID, courseID *uuid.UUID, semester *int) ([]StudentAttendance, error) {
-	_, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
-		return nil, ErrStudentNotFound
+	// Sections outside the caller's scope are reported as not found
+	if !role.AccessScopeFromContext(ctx).Allows(sheet.Branch, sheet.Batch) {
+		return nil, ErrSectionNotFound
 	}
 
-	return s.repo.GetAttendance(ctx, studentID, courseID, semester)
+	return s.repo.MarkSectionAttendance(ctx, sheet, s.attendancePolicy)
 }
 
-// RecordAttendance records attendance for a student
-func (s *Service) RecordAttendance(ctx context.Context, attendance *StudentAttendance) error {
-	_, err := s.repo.GetByID(ctx, attendance.StudentID)
-	if err != nil {
-		return ErrStudentNotFound
+// GetAttendanceRate returns the overall attendance rate of a student as a percentage, across all
+// courses or those of a semester
+func (s *Service) GetAttendanceRate(ctx context.Context, studentID uuid.UUID, semester *int) (float64, error) {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil || !inScope(ctx, student) {
+		return 0, ErrStudentNotFound
 	}
 
-	// Validate attendance record
-	if attendance.ID == uuid.Nil {
-		attendance.ID = uuid.New()
+	stats, err := s.repo.GetAttendanceStatistics(ctx, studentID, semester)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get attendance statistics: %w", err)
 	}
-	attendance.CreatedAt = time.Now()
-	attendance.UpdatedAt = time.Now()
-
-	// Calculate statistics
-	calculateAttendanceStatistics(attendance)
 
-	return s.repo.RecordAttendance(ctx, attendance)
+	return stats.Rate(), nil
 }
 
 // GetPreferences retrieves preferences for a student
 func (s *Service) GetPreferences(ctx context.Context, studentID uuid.UUID) (*StudentPreferences, error) {
-	_, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil || !inScope(ctx, student) {
 		return nil, ErrStudentNotFound
 	}
 
@@ -378,8 +430,8 @@ func (s *Service) GetPreferences(ctx context.Context, studentID uuid.UUID) (*Stu
 
 // UpdatePreferences updates preferences for a student
 func (s *Service) UpdatePreferences(ctx context.Context, preferences *StudentPreferences) error {
-	_, err := s.repo.GetByID(ctx, preferences.StudentID)
-	if err != nil {
+	student, err := s.repo.GetByID(ctx, preferences.StudentID)
+	if err != nil || !inScope(ctx, student) {
 		return ErrStudentNotFound
 	}
 
@@ -387,80 +439,187 @@ func (s *Service) UpdatePreferences(ctx context.Context, preferences *StudentPre
 	return s.repo.UpdatePreferences(ctx, preferences)
 }
 
-// AddAchievement adds an achievement to a student
-func (s *Service) AddAchievement(ctx context.Context, studentID uuid.UUID, achievement Achievement) error {
-	_, err := s.repo.GetByID(ctx, studentID)
+// GetContacts retrieves the contacts of a student, the primary emergency contact first
+func (s *Service) GetContacts(ctx context.Context, studentID uuid.UUID) ([]StudentContact, error) {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil || !inScope(ctx, student) {
+		return nil, ErrStudentNotFound
+	}
+
+	return s.repo.GetContacts(ctx, studentID)
+}
+
+// AddContact adds a contact to a student. The first emergency contact of a student becomes its
+// primary contact, and a contact added as primary replaces the previous one.
+func (s *Service) AddContact(ctx context.Context, studentID uuid.UUID, req ContactRequest) (*StudentContact, error) {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil || !inScope(ctx, student) {
+		return nil, ErrStudentNotFound
+	}
+
+	contact, err := newContact(studentID, req)
 	if err != nil {
-		return ErrStudentNotFound
+		return nil, err
 	}
+	contact.ID = uuid.New()
+	contact.CreatedAt = time.Now()
+	contact.UpdatedAt = contact.CreatedAt
 
-	// Set default values
-	if achievement.ID == uuid.Nil {
-		achievement.ID = uuid.New()
+	if err := s.repo.AddContact(ctx, contact); err != nil {
+		return nil, err
 	}
-	achievement.CreatedAt = time.Now()
-	achievement.UpdatedAt = time.Now()
 
-	return s.repo.AddAchievement(ctx, studentID, achievement)
+	return contact, nil
 }
 
-// GetAchievements retrieves achievements for a student
-func (s *Service) GetAchievements(ctx context.Context, studentID uuid.UUID) ([]Achievement, error) {
-	_, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
+// UpdateContact replaces the details of a contact of a student. The primary contact can't be
+// unmarked while it is an emergency contact, another contact has to be marked primary instead.
+func (s *Service) UpdateContact(ctx context.Context, studentID, contactID uuid.UUID, req ContactRequest) (*StudentContact, error) {
+	student, err := s.repo.GetByID(ctx, studentID)
+	if err != nil || !inScope(ctx, student) {
 		return nil, ErrStudentNotFound
 	}
 
-	return s.repo.GetAchievements(ctx, studentID)
+	contact, err := newContact(studentID, req)
+	if err != nil {
+		return nil, err
+	}
+	contact.ID = contactID
+
+	if err := s.repo.UpdateContact(ctx, contact); err != nil {
+		return nil, err
+	}
+
+	return contact, nil
 }
 
-// CreateSession creates a new session for a student
-func (s *Service) CreateSession(ctx context.Context, studentID uuid.UUID, userAgent, ipAddress string) (*StudentSession, error) {
+// DeleteContact deletes a contact of a student. When it was the primary contact, the oldest
+// remaining emergency contact takes its place.
+func (s *Service) DeleteContact(ctx context.Context, studentID, contactID uuid.UUID) error {
 	student, err := s.repo.GetByID(ctx, studentID)
-	if err != nil {
-		return nil, ErrStudentNotFound
+	if err != nil || !inScope(ctx, student) {
+		return ErrStudentNotFound
 	}
 
-	if !student.IsActive() {
-		return nil, errors.New("inactive student cannot create session")
-	}
+	return s.repo.DeleteContact(ctx, studentID, contactID)
+}
 
-	// Generate tokens
-	token := generateToken(student.ID)
-	refreshToken := generateRefreshToken()
+// NotifyGuardians emails a message to the emergency contacts of the students, or only to their
+// primary contacts. Contacts without an email address are skipped. Students outside the caller's
+// scope are reported as not found, and a failed delivery doesn't stop the others.
+func (s *Service) NotifyGuardians(ctx context.Context, notification GuardianNotification) (*GuardianNotificationResult, error) {
+	notification.Subject = validator.SanitizeString(notification.Subject, validator.DefaultSanitizationOptions())
+	notification.Message = validator.SanitizeString(notification.Message, validator.DefaultSanitizationOptions())
 
-	// Create session
-	session := &StudentSession{
-		ID:           uuid.New(),
-		StudentID:    studentID,
-		Token:        token,
-		RefreshToken: refreshToken,
-		UserAgent:    userAgent,
-		IPAddress:    ipAddress,
-		ExpiresAt:    time.Now().Add(24 * time.Hour), // Token expires in 24 hours
-		CreatedAt:    time.Now(),
+	if notification.Subject == "" || notification.Message == "" {
+		return nil, fmt.Errorf("%w: subject and message are required", ErrInvalidGuardianNotification)
+	}
+	if len(notification.StudentIDs) == 0 || len(notification.StudentIDs) > MaxGuardianNotificationStudents {
+		return nil, fmt.Errorf("%w: between 1 and %d students can be notified at once", ErrInvalidGuardianNotification, MaxGuardianNotificationStudents)
 	}
 
-	if err := s.repo.CreateSession(ctx, session); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	recipients, err := s.repo.GetGuardianRecipients(ctx, notification.StudentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardian recipients: %w", err)
 	}
 
-	return session, nil
-}
+	access := role.AccessScopeFromContext(ctx)
+	byStudent := make(map[uuid.UUID]GuardianRecipients, len(recipients))
+	for _, r := range recipients {
+		if access.Allows(r.Branch, r.Batch) {
+			byStudent[r.StudentID] = r
+		}
+	}
+
+	result := &GuardianNotificationResult{Students: []GuardianNotificationOutcome{}}
+	seen := make(map[uuid.UUID]bool, len(notification.StudentIDs))
+	for _, studentID := range notification.StudentIDs {
+		if seen[studentID] {
+			continue
+		}
+		seen[studentID] = true
+
+		outcome := GuardianNotificationOutcome{StudentID: studentID}
+		r, ok := byStudent[studentID]
+		if !ok {
+			outcome.Status = GuardianNotificationNotFound
+			result.Students = append(result.Students, outcome)
+			continue
+		}
 
-// RevokeSession revokes a session
-func (s *Service) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
-	return s.repo.RevokeSession(ctx, sessionID)
+		var failures []string
+		for _, contact := range r.Contacts {
+			if contact.Email == "" || (notification.PrimaryOnly && !contact.IsPrimary) {
+				continue
+			}
+
+			if err := s.guardianMailer.SendGuardianNotificationEmail(contact.Email, contact.Name, r.StudentName, notification.Subject, notification.Message); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", contact.Email, err))
+				continue
+			}
+			outcome.Recipients++
+		}
+
+		switch {
+		case len(failures) > 0:
+			outcome.Status = GuardianNotificationFailed
+			outcome.Error = strings.Join(failures, "; ")
+			result.Failed++
+		case outcome.Recipients == 0:
+			outcome.Status = GuardianNotificationNoContact
+		default:
+			outcome.Status = GuardianNotificationSent
+			result.Sent++
+		}
+		result.Students = append(result.Students, outcome)
+	}
+
+	return result, nil
 }
 
 // Helper functions
 
+// inScope checks that the student is within the caller's access scope. Students outside of it are
+// reported as not found.
+func inScope(ctx context.Context, student *Student) bool {
+	return student != nil && role.AccessScopeFromContext(ctx).Allows(student.Branch, student.Batch)
+}
+
 // isValidEmail validates email format
 func isValidEmail(email string) bool {
 	// Simple validation, can be expanded with regex
 	return strings.Contains(email, "@") && strings.Contains(email, ".")
 }
 
+// newContact validates a contact request, normalizing the phone number and email address
+func newContact(studentID uuid.UUID, req ContactRequest) (*StudentContact, error) {
+	contact := &StudentContact{
+		StudentID:   studentID,
+		Name:        validator.SanitizeString(req.Name, validator.DefaultSanitizationOptions()),
+		Relation:    validator.SanitizeString(req.Relation, validator.DefaultSanitizationOptions()),
+		Phone:       validator.SanitizePhone(req.Phone),
+		IsEmergency: req.IsEmergency,
+		IsPrimary:   req.IsPrimary,
+	}
+
+	if contact.Name == "" || len(contact.Name) > 100 || contact.Relation == "" || len(contact.Relation) > 50 {
+		return nil, fmt.Errorf("%w: name of up to 100 and relation of up to 50 characters are required", ErrInvalidContact)
+	}
+	if contact.Phone == "" {
+		return nil, fmt.Errorf("%w: phone must have 10 to 15 digits", ErrInvalidContact)
+	}
+	if req.Email != "" {
+		contact.Email = validator.SanitizeEmail(req.Email)
+		if contact.Email == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidContact, ErrInvalidEmail)
+		}
+	}
+	if contact.IsPrimary && !contact.IsEmergency {
+		return nil, fmt.Errorf("%w: only an emergency contact can be primary", ErrInvalidContact)
+	}
+
+	return contact, nil
+}
 
 // isValidEnrollmentID validates enrollment ID format
 func isValidEnrollmentID(enrollmentID string) bool {
@@ -515,71 +674,14 @@ func isValidStatus(status StudentStatus) bool {
 	return false
 }
 
-// isValidGrade validates grade format
-func isValidGrade(grade string) bool {
-	validGrades := []string{"A", "A-", "B+", "B", "B-", "C+", "C", "C-", "D+", "D", "F", "I", "W"}
-	for _, g := range validGrades {
-		if grade == g {
-			return true
-		}
+// redactDossier withholds the fields only admins may see
+func redactDossier(dossier *Dossier) {
+	if dossier.Family != nil {
+		dossier.Family.TotalFamilyIncome = nil
+		dossier.Redacted = append(dossier.Redacted, RedactedFamilyIncome)
 	}
-	return false
-}
-
-// hashPassword hashes a password using bcrypt
-func hashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+	if dossier.Profile != nil {
+		dossier.Profile.Category = ""
+		dossier.Redacted = append(dossier.Redacted, RedactedCategory)
 	}
-	return string(hashedBytes), nil
-}
-
-// verifyPassword verifies a password against its hash
-func verifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
-
-// generateToken generates a JWT token for a student
-func generateToken(studentID uuid.UUID) string {
-	// This would typically use a JWT library to generate a proper token
-	// Placeholder implementation
-	return fmt.Sprintf("token_%s_%d", studentID.String(), time.Now().Unix())
-}
-
-// generateRefreshToken generates a refresh token
-func generateRefreshToken() string {
-	// This would typically generate a cryptographically secure random token
-	// Placeholder implementation
-	return fmt.Sprintf("refresh_%d", time.Now().UnixNano())
-}
-
-// calculateAttendanceStatistics calculates statistics for attendance records
-func calculateAttendanceStatistics(attendance *StudentAttendance) {
-	var present, absent, leave int
-	
-	for _, record := range attendance.Records {
-		switch record.Status {
-		case AttendancePresent:
-			present++
-		case AttendanceAbsent:
-			absent++
-		case AttendanceLeave:
-			leave++
-		}
-	}
-	
-	totalClasses := present + absent + leave
-	var attendanceRate float64
-	if totalClasses > 0 {
-		attendanceRate = float64(present) / float64(totalClasses) * 100
-	}
-	
-	attendance.Statistics = AttendanceStatistics{
-		TotalClasses:   totalClasses,
-		Present:        present,
-		Absent:         absent,
-		Leave:          leave,
-		AttendanceRate: attendanceRate,
-	}
-}
\ No newline at end of file
@@ -6,6 +6,11 @@
 // lookup tables.
 package student
 
+import "time"
+
+// EnrollmentNoLength is the length of every university enrollment number
+const EnrollmentNoLength = 12
+
 type EnrollmentMasterLookupTable struct {
 	EnrollmentNo         string `gorm:"type:varchar(12);size:12;primaryKey;check:char_length(enrollment_no) = 12" json:"enrollmentNo"` // Enrollment number (Primary Key)
 	LogInDetailsID       uint32 `gorm:"not null;unique" json:"-" bson:"-"`                                                             // Reference to login details table id for student (uint32)
@@ -14,6 +19,9 @@ type EnrollmentMasterLookupTable struct {
 	ProfileDetailsID     uint32 `gorm:"not null;unique" json:"-" bson:"-"`                                                             // Reference to profile details table id for student (uint32)
 	ScholarshipDetailsID uint32 `gorm:"not null;unique" json:"-" bson:"-"`                                                             // Reference to scholarship details table id for student (uint32)
 
+	// Set while the student's platform profile is in the recovery bin
+	DeletedAt *time.Time `gorm:"index" json:"deletedAt,omitempty"`
+
 	// Foreign keys to maintain referential integrity
 	StudentLogInDetailsTable StudentLogInDetailsTable       `gorm:"foreignKey:LogInDetailsID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
 	AcademicDetails          StudentAcademicDetailsTable    `gorm:"foreignKey:AcademicDetailsID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-" bson:"-"`
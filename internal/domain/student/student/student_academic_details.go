@@ -8,6 +8,14 @@ import (
 	"time"
 )
 
+// Bounds of the academic details check constraints
+const (
+	MinYearOfEnrollment = 1990
+	MaxYearOfEnrollment = 2100
+	MaxGradePoint       = 10
+	MaxPercentage       = 100
+)
+
 type StudentAcademicDetailsTable struct {
 	// ID = Primary Key (unique identifier for each student).
 	// To be used internally only. Not to be sent in responses.
@@ -7,6 +7,25 @@ import (
 	"time"
 )
 
+// Values allowed by the gender check constraint
+const (
+	GenderMale   = "M"
+	GenderFemale = "F"
+	GenderOther  = "O"
+)
+
+// Values allowed by the category check constraint
+const (
+	CategoryGeneral = "GEN"
+	CategoryEWS     = "EWS"
+	CategoryOBC     = "OBC"
+	CategorySC      = "SC"
+	CategoryST      = "ST"
+)
+
+// UserRoleStudent is the default user role of a student profile
+const UserRoleStudent = "STU"
+
 type StudentProfileDetailsTable struct {
 	// ID = Primary Key (unique identifier for each student).
 	// To be used internally only. Not to be sent in responses.
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"server/internal/common/utils"
+)
+
+const (
+	// apiKeyMarker starts every API key so leaked keys are easy to recognise and scan for
+	apiKeyMarker = "ing"
+
+	// apiKeyPrefixLength is the number of random bytes of the public lookup prefix
+	apiKeyPrefixLength = 6
+
+	// apiKeySecretLength is the number of random bytes of the secret part
+	apiKeySecretLength = 32
+)
+
+// APIKeyManager generates API keys of the form ing_<prefix>_<secret> and hashes them for storage.
+// The prefix is stored in clear to find the key, the key itself only as an HMAC, so the
+// database alone is not enough to verify a guessed key.
+// It implements platform_profile.APIKeyManager.
+type APIKeyManager struct {
+	key []byte
+}
+
+// NewAPIKeyManager creates an API key manager. The hashing key is derived from the application secret.
+func NewAPIKeyManager(secret string) (*APIKeyManager, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("API key secret must be at least %d bytes", minSecretLength)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("api-key"))
+
+	return &APIKeyManager{key: mac.Sum(nil)}, nil
+}
+
+// Generate creates a new API key and returns it with its lookup prefix and the hash to store
+func (m *APIKeyManager) Generate() (string, string, string, error) {
+	prefix, err := utils.GenerateToken(apiKeyPrefixLength)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key prefix: %w", err)
+	}
+
+	secret, err := utils.GenerateToken(apiKeySecretLength)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key secret: %w", err)
+	}
+
+	key := apiKeyMarker + "_" + prefix + "_" + secret
+	return key, prefix, m.hash(key), nil
+}
+
+// Parse returns the lookup prefix of a presented key and whether the key is well formed
+func (m *APIKeyManager) Parse(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyMarker {
+		return "", false
+	}
+
+	if len(parts[1]) != 2*apiKeyPrefixLength || len(parts[2]) != 2*apiKeySecretLength {
+		return "", false
+	}
+
+	return parts[1], true
+}
+
+// Verify checks a presented key against a stored hash in constant time
+func (m *APIKeyManager) Verify(key, keyHash string) bool {
+	return hmac.Equal([]byte(m.hash(key)), []byte(keyHash))
+}
+
+// hash returns the stored representation of a key
+func (m *APIKeyManager) hash(key string) string {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
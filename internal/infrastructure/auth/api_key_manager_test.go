@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAPIKeySecret = "test-api-key-secret-test-api-key"
+
+func newTestAPIKeyManager(t *testing.T, secret string) *APIKeyManager {
+	t.Helper()
+
+	manager, err := NewAPIKeyManager(secret)
+	if err != nil {
+		t.Fatalf("failed to create API key manager: %v", err)
+	}
+
+	return manager
+}
+
+func TestNewAPIKeyManagerRejectsShortSecrets(t *testing.T) {
+	if _, err := NewAPIKeyManager(testAPIKeySecret[:minSecretLength-1]); err == nil {
+		t.Fatalf("expected a secret shorter than %d bytes to be rejected", minSecretLength)
+	}
+}
+
+func TestAPIKeyManagerParse(t *testing.T) {
+	manager := newTestAPIKeyManager(t, testAPIKeySecret)
+	key, prefix, _, err := manager.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	parts := strings.Split(key, "_")
+
+	for _, tc := range []struct {
+		name       string
+		key        string
+		wantPrefix string
+		wantOK     bool
+	}{
+		{name: "generated key", key: key, wantPrefix: prefix, wantOK: true},
+		{name: "other marker", key: "sk_" + parts[1] + "_" + parts[2]},
+		{name: "missing secret", key: parts[0] + "_" + parts[1]},
+		{name: "extra part", key: key + "_extra"},
+		{name: "short prefix", key: parts[0] + "_" + parts[1][1:] + "_" + parts[2]},
+		{name: "short secret", key: parts[0] + "_" + parts[1] + "_" + parts[2][1:]},
+		{name: "long secret", key: key + "0"},
+		{name: "empty", key: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := manager.Parse(tc.key)
+			if ok != tc.wantOK || got != tc.wantPrefix {
+				t.Fatalf("expected prefix %q and %v, got %q and %v", tc.wantPrefix, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestAPIKeyManagerVerify(t *testing.T) {
+	manager := newTestAPIKeyManager(t, testAPIKeySecret)
+	key, _, keyHash, err := manager.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	other, _, _, err := manager.Generate()
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		manager *APIKeyManager
+		key     string
+		want    bool
+	}{
+		{name: "generated key", manager: manager, key: key, want: true},
+		{name: "other key", manager: manager, key: other},
+		{name: "altered key", manager: manager, key: key[:len(key)-1] + "x"},
+		{name: "other application secret", manager: newTestAPIKeyManager(t, strings.ToUpper(testAPIKeySecret)), key: key},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.manager.Verify(tc.key, keyHash); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if strings.Contains(keyHash, key) {
+		t.Fatalf("expected the stored hash not to contain the key")
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/internal/common/utils"
+	"server/internal/config"
+	"server/internal/domain/platform_profile"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// refreshTokenLength is the number of random bytes in an opaque refresh token
+const refreshTokenLength = 32
+
+// minSecretLength is the shortest HMAC secret accepted for signing access tokens
+const minSecretLength = 32
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// AccessTokenClaims is the JWT payload of an access token.
+// The subject holds the platform profile ID and "sid" the session the token was issued to.
+// Restricted tokens carry a "scope" instead of a session.
+type AccessTokenClaims struct {
+	SessionID string   `json:"sid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTProvider issues HS256 signed access tokens and opaque refresh tokens.
+// It implements platform_profile.TokenProvider.
+type JWTProvider struct {
+	secret          []byte
+	issuer          string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+// NewJWTProvider creates a token provider from the JWT secret and auth settings
+func NewJWTProvider(secret string, cfg config.AuthConfig) (*JWTProvider, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("JWT secret must be at least %d bytes", minSecretLength)
+	}
+
+	return &JWTProvider{
+		secret:          []byte(secret),
+		issuer:          cfg.TokenIssuer,
+		accessTokenTTL:  cfg.AccessTokenTTL,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+	}, nil
+}
+
+// GenerateAccessToken creates a signed access token carrying the profile ID, session ID and role names
+func (p *JWTProvider) GenerateAccessToken(profileID uuid.UUID, sessionID uuid.UUID, roles []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(p.accessTokenTTL)
+
+	claims := AccessTokenClaims{
+		SessionID: sessionID.String(),
+		Roles:     roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   profileID.String(),
+			Issuer:    p.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	return p.sign(claims, expiresAt)
+}
+
+// GenerateRestrictedToken creates a signed token without a session that is only valid for the given scope
+func (p *JWTProvider) GenerateRestrictedToken(profileID uuid.UUID, scope string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(p.accessTokenTTL)
+
+	claims := AccessTokenClaims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   profileID.String(),
+			Issuer:    p.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	return p.sign(claims, expiresAt)
+}
+
+// sign creates the HS256 signed JWT for the claims
+func (p *JWTProvider) sign(claims AccessTokenClaims, expiresAt time.Time) (string, time.Time, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(p.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature, issuer and expiry of an access token
+func (p *JWTProvider) ParseAccessToken(tokenString string) (*platform_profile.AccessClaims, error) {
+	claims := &AccessTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			return p.secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	profileID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Only restricted tokens may come without a session
+	sessionID := uuid.Nil
+	if claims.Scope == "" {
+		sessionID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	accessClaims := &platform_profile.AccessClaims{
+		ProfileID: profileID,
+		SessionID: sessionID,
+		Roles:     claims.Roles,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Scope:     claims.Scope,
+	}
+	if claims.IssuedAt != nil {
+		accessClaims.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return accessClaims, nil
+}
+
+// GenerateRefreshToken creates an opaque refresh token and the hash that should be stored
+func (p *JWTProvider) GenerateRefreshToken() (string, string, time.Time, error) {
+	token, err := utils.GenerateToken(refreshTokenLength)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return token, p.HashRefreshToken(token), time.Now().Add(p.refreshTokenTTL), nil
+}
+
+// HashRefreshToken hashes a refresh token for storage and lookup.
+// Refresh tokens are long random values, so a fast hash is sufficient.
+func (p *JWTProvider) HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
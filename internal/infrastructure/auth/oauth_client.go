@@ -0,0 +1,378 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// defaultJWKSMinRefreshInterval limits how often an unknown key ID can force a JWKS download
+	defaultJWKSMinRefreshInterval = 30 * time.Second
+
+	// idTokenLeeway tolerates clock skew between us and the issuer
+	idTokenLeeway = time.Minute
+
+	// maxOIDCResponseSize caps the discovery, JWKS and token responses read from the issuer
+	maxOIDCResponseSize = 1 << 20
+)
+
+var (
+	ErrOIDCDiscovery     = errors.New("failed to discover OpenID configuration")
+	ErrOIDCExchange      = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken    = errors.New("invalid ID token")
+	ErrUnknownSigningKey = errors.New("ID token signed with unknown key")
+)
+
+// OIDCConfig describes our client registration with an OpenID Connect issuer
+type OIDCConfig struct {
+	// IssuerURL is where /.well-known/openid-configuration is served
+	IssuerURL string
+	// AcceptedIssuers are the "iss" values accepted in ID tokens, defaults to IssuerURL
+	AcceptedIssuers []string
+
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AuthParams are added to the authorization URL, e.g. "hd" or "prompt"
+	AuthParams map[string]string
+
+	// JWKSCacheTTL is used when the JWKS response carries no max-age
+	JWKSCacheTTL time.Duration
+	// JWKSMinRefreshInterval is the least time between two downloads triggered by unknown key IDs
+	JWKSMinRefreshInterval time.Duration
+}
+
+// IDTokenClaims are the ID token claims used for login
+type IDTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	HostedDomain  string `json:"hd"`
+	jwt.RegisteredClaims
+}
+
+// oidcDiscovery is the subset of the OpenID provider metadata we rely on
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCClient runs the authorization code flow with PKCE against an OpenID Connect issuer
+// and verifies the returned ID tokens against the issuer's cached JWKS.
+// Discovery happens on first use, so an unreachable issuer does not block startup.
+type OIDCClient struct {
+	cfg        OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]*rsa.PublicKey
+	keysExpireAt  time.Time
+	keysFetchedAt time.Time
+}
+
+// NewOIDCClient creates a client for the given registration. A nil httpClient selects one with a 10s timeout.
+func NewOIDCClient(cfg OIDCConfig, httpClient *http.Client) (*OIDCClient, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("OIDC issuer URL, client ID, client secret and redirect URL are required")
+	}
+	if len(cfg.AcceptedIssuers) == 0 {
+		cfg.AcceptedIssuers = []string{cfg.IssuerURL}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if cfg.JWKSCacheTTL <= 0 {
+		cfg.JWKSCacheTTL = time.Hour
+	}
+	if cfg.JWKSMinRefreshInterval <= 0 {
+		cfg.JWKSMinRefreshInterval = defaultJWKSMinRefreshInterval
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OIDCClient{cfg: cfg, httpClient: httpClient}, nil
+}
+
+// AuthCodeURL returns the URL the user is sent to for login. The PKCE challenge is derived from codeVerifier.
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, state, codeVerifier, nonce string) (string, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", c.cfg.ClientID)
+	params.Set("redirect_uri", c.cfg.RedirectURL)
+	params.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", PKCEChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+	for key, value := range c.cfg.AuthParams {
+		params.Set(key, value)
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified claims of its ID token
+func (c *OIDCClient) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*IDTokenClaims, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseSize)).Decode(&tokenResponse); err != nil {
+		return nil, fmt.Errorf("%w: status %d: %v", ErrOIDCExchange, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		return nil, fmt.Errorf("%w: status %d: %s %s", ErrOIDCExchange, resp.StatusCode, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token in response", ErrOIDCExchange)
+	}
+
+	return c.VerifyIDToken(ctx, tokenResponse.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature, issuer, audience, lifetime and nonce of an ID token
+func (c *OIDCClient) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(
+		rawIDToken,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			keyID, _ := token.Header["kid"].(string)
+			return c.getSigningKey(ctx, keyID)
+		},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		if errors.Is(err, ErrUnknownSigningKey) || errors.Is(err, ErrOIDCDiscovery) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if !slices.Contains(c.cfg.AcceptedIssuers, claims.Issuer) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// getDiscovery fetches the provider metadata once and caches it
+func (c *OIDCClient) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	discovery := &oidcDiscovery{}
+	wellKnown := strings.TrimSuffix(c.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if _, err := c.getJSON(ctx, wellKnown, discovery); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCDiscovery, err)
+	}
+
+	if discovery.Issuer != c.cfg.IssuerURL {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrOIDCDiscovery, discovery.Issuer, c.cfg.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete provider metadata", ErrOIDCDiscovery)
+	}
+
+	c.discovery = discovery
+	return discovery, nil
+}
+
+// getSigningKey returns a key from the cached JWKS, downloading it again when it expired
+// or when the key ID is unknown, e.g. after the issuer rotated its keys
+func (c *OIDCClient) getSigningKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if key, ok := c.keys[keyID]; ok && now.Before(c.keysExpireAt) {
+		return key, nil
+	}
+
+	// Unknown key IDs must not let callers hammer the issuer
+	if c.keys != nil && now.Before(c.keysExpireAt) && now.Sub(c.keysFetchedAt) < c.cfg.JWKSMinRefreshInterval {
+		return nil, ErrUnknownSigningKey
+	}
+
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	header, err := c.getJSON(ctx, discovery.JWKSURI, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAPublicKey(jwk.N, jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	c.keys = keys
+	c.keysFetchedAt = now
+	c.keysExpireAt = now.Add(cacheMaxAge(header, c.cfg.JWKSCacheTTL))
+
+	key, ok := keys[keyID]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	return key, nil
+}
+
+// getJSON decodes the JSON response of a GET request and returns its headers
+func (c *OIDCClient) getJSON(ctx context.Context, endpoint string, target interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, endpoint)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseSize)).Decode(target); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+
+	return resp.Header, nil
+}
+
+// cacheMaxAge reads max-age from a Cache-Control header, falling back to the given TTL
+func cacheMaxAge(header http.Header, fallback time.Duration) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return fallback
+}
+
+// parseRSAPublicKey builds an RSA key from the base64url encoded modulus and exponent of a JWK
+func parseRSAPublicKey(modulus, exponent string) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(modulus)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(exponent)
+	if err != nil {
+		return nil, err
+	}
+
+	publicExponent := new(big.Int).SetBytes(e)
+	if !publicExponent.IsInt64() || publicExponent.Int64() < 3 || publicExponent.Int64() > 1<<31-1 {
+		return nil, errors.New("unsupported RSA exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(publicExponent.Int64())}, nil
+}
+
+// PKCEChallenge derives the S256 code challenge of a PKCE code verifier
+func PKCEChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 appendix B test vectors
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func providerAt(now time.Time) *TOTPProvider {
+	provider := NewTOTPProvider("test")
+	provider.now = func() time.Time { return now }
+
+	return provider
+}
+
+func TestGenerateTOTPMatchesRFC6238(t *testing.T) {
+	// The RFC lists eight digit codes, the last six of which are the six digit codes
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	} {
+		key, _ := totpEncoding.DecodeString(rfcSecret)
+		if got := generateTOTP(key, tc.unix/30); got != tc.want {
+			t.Fatalf("expected code %s at %d, got %s", tc.want, tc.unix, got)
+		}
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / 30
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+
+	for _, tc := range []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: rfcSecret, code: generateTOTP(key, current), wantStep: current, wantOK: true},
+		{name: "previous step", secret: rfcSecret, code: generateTOTP(key, current-1), wantStep: current - 1, wantOK: true},
+		{name: "next step", secret: rfcSecret, code: generateTOTP(key, current+1), wantStep: current + 1, wantOK: true},
+		{name: "two steps behind", secret: rfcSecret, code: generateTOTP(key, current-2)},
+		{name: "two steps ahead", secret: rfcSecret, code: generateTOTP(key, current+2)},
+		{name: "surrounding spaces", secret: rfcSecret, code: " " + generateTOTP(key, current) + " ", wantStep: current, wantOK: true},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: generateTOTP(key, current), wantStep: current, wantOK: true},
+		{name: "too short", secret: rfcSecret, code: generateTOTP(key, current)[:5]},
+		{name: "too long", secret: rfcSecret, code: generateTOTP(key, current) + "0"},
+		{name: "invalid secret", secret: "not base32!", code: generateTOTP(key, current)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := providerAt(now).Validate(tc.secret, tc.code)
+			if ok != tc.wantOK || step != tc.wantStep {
+				t.Fatalf("expected step %d and %v, got %d and %v", tc.wantStep, tc.wantOK, step, ok)
+			}
+		})
+	}
+}
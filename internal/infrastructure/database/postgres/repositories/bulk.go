@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"server/internal/domain/platform_profile"
+	"server/pkg/logger"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// bulkBatchSize is the number of rows a bulk operation writes per transaction
+const bulkBatchSize = 500
+
+// bulkRowFunc processes one row of a bulk operation inside its savepoint
+type bulkRowFunc func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult
+
+// runBulk processes count rows in transactions of bulkBatchSize rows. Every row runs in its own
+// savepoint, so a failing row is rolled back and reported without undoing the rest of its batch.
+// A dry run rolls every batch back after processing it, which reports the same outcomes, including
+// duplicates, without saving anything. If a batch can't be committed all its rows are reported failed.
+func runBulk(ctx context.Context, pool *pgxpool.Pool, log *logger.Logger, operation string, count int, dryRun bool, fn bulkRowFunc) ([]platform_profile.BulkItemResult, error) {
+	results := make([]platform_profile.BulkItemResult, count)
+
+	for start := 0; start < count; start += bulkBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := min(start+bulkBatchSize, count)
+		batch := results[start:end]
+
+		if err := runBulkBatch(ctx, pool, start, dryRun, batch, fn); err != nil {
+			log.Error("Bulk batch failed", "operation", operation, "first_index", start, "rows", len(batch), "error", err)
+			for i := range batch {
+				batch[i] = platform_profile.BulkItemResult{
+					Index:     start + i,
+					ProfileID: batch[i].ProfileID,
+					Username:  batch[i].Username,
+					Status:    platform_profile.BulkItemFailed,
+					Reason:    "batch could not be saved",
+				}
+			}
+		}
+	}
+
+	log.Info("Bulk operation processed", "operation", operation, "rows", count, "dry_run", dryRun)
+	return results, nil
+}
+
+// runBulkBatch processes one batch of a bulk operation in a transaction, filling in the results of its rows
+func runBulkBatch(ctx context.Context, pool *pgxpool.Pool, start int, dryRun bool, results []platform_profile.BulkItemResult, fn bulkRowFunc) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range results {
+		index := start + i
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		result := fn(ctx, savepoint, index)
+		result.Index = index
+
+		if result.Status == platform_profile.BulkItemFailed || result.Status == platform_profile.BulkItemSkipped {
+			if err := savepoint.Rollback(ctx); err != nil {
+				return fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+		} else if err := savepoint.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		results[i] = result
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// bulkFailure reports a failed row, logging the underlying error
+func bulkFailure(log *logger.Logger, operation string, result platform_profile.BulkItemResult, reason string, err error) platform_profile.BulkItemResult {
+	log.Warn("Bulk row failed", "operation", operation, "profile_id", result.ProfileID, "username", result.Username, "error", err)
+
+	result.Status = platform_profile.BulkItemFailed
+	result.Reason = reason
+	return result
+}
@@ -16,9 +16,11 @@ import (
 	"errors"
 	"fmt"
 	"server/internal/domain/platform_profile"
+	"server/internal/domain/role"
 	"server/pkg/logger"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -27,14 +29,19 @@ import (
 )
 
 var (
-	ErrProfileNotFound       = errors.New("profile not found")
-	ErrUsernameAlreadyExists = errors.New("username already exists")
-	ErrEmailAlreadyExists    = errors.New("email already exists")
-	ErrResetTokenNotFound    = errors.New("reset token not found")
-	ErrResetTokenExpired     = errors.New("reset token expired")
-	ErrResetTokenUsed        = errors.New("reset token already used")
-	ErrRoleNotFound          = errors.New("role not found")
-	ErrRoleNotAssigned       = errors.New("role not assigned or already removed")
+	ErrProfileNotFound           = errors.New("profile not found")
+	ErrUsernameAlreadyExists     = errors.New("username already exists")
+	ErrEmailAlreadyExists        = errors.New("email already exists")
+	ErrResetTokenNotFound        = errors.New("reset token not found")
+	ErrResetTokenExpired         = errors.New("reset token expired")
+	ErrResetTokenUsed            = errors.New("reset token already used")
+	ErrRoleNotFound              = errors.New("role not found")
+	ErrRoleNotAssigned           = errors.New("role not assigned or already removed")
+	ErrRefreshTokenNotFound      = errors.New("refresh token not found")
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrMFANotFound               = errors.New("MFA not configured")
+	ErrMFAChallengeNotFound      = errors.New("MFA challenge not found")
+	ErrVerificationTokenNotFound = errors.New("verification token not found")
 )
 
 // PostgresProfileRepository implements the platform_profile.Repository interface
@@ -165,7 +172,7 @@ func (r *PostgresProfileRepository) GetProfileByID(ctx context.Context, id uuid.
 	SELECT 
 		id, username, email, password_hash, status, verified_at, 
 		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at
+		updated_by_system_at, must_change_password
 	FROM platform_profiles 
 	WHERE id = $1`
 
@@ -182,6 +189,7 @@ func (r *PostgresProfileRepository) GetProfileByID(ctx context.Context, id uuid.
 		&profile.CreatedAt,
 		&profile.UpdatedByUserAt,
 		&profile.UpdatedBySystemAt,
+		&profile.MustChangePassword,
 	)
 
 	if err != nil {
@@ -201,7 +209,7 @@ func (r *PostgresProfileRepository) GetProfileByEmail(ctx context.Context, email
 	SELECT 
 		id, username, email, password_hash, status, verified_at, 
 		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at
+		updated_by_system_at, must_change_password
 	FROM platform_profiles 
 	WHERE email = $1`
 
@@ -218,6 +226,7 @@ func (r *PostgresProfileRepository) GetProfileByEmail(ctx context.Context, email
 		&profile.CreatedAt,
 		&profile.UpdatedByUserAt,
 		&profile.UpdatedBySystemAt,
+		&profile.MustChangePassword,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -236,7 +245,7 @@ func (r *PostgresProfileRepository) GetProfileByUsername(ctx context.Context, us
 	SELECT 
 		id, username, email, password_hash, status, verified_at, 
 		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at
+		updated_by_system_at, must_change_password
 	FROM platform_profiles 
 	WHERE username = $1`
 
@@ -253,6 +262,7 @@ func (r *PostgresProfileRepository) GetProfileByUsername(ctx context.Context, us
 		&profile.CreatedAt,
 		&profile.UpdatedByUserAt,
 		&profile.UpdatedBySystemAt,
+		&profile.MustChangePassword,
 	)
 
 	if err != nil {
@@ -277,16 +287,18 @@ func (r *PostgresProfileRepository) UpdateProfile(ctx context.Context, profile *
 	query := `
 	   UPDATE platform_profiles SET
 			username = $1,
-			email = $2,			
-			updated_by_user_at = $3,
-			updated_by_system_at = $4
-	   WHERE id = $5`
+			email = $2,
+			verified_at = $3,
+			updated_by_user_at = $4,
+			updated_by_system_at = $5
+	   WHERE id = $6`
 
 	commandTag, err := r.pool.Exec(
 		ctx,
 		query,
 		profile.Username,
 		profile.Email,
+		profile.VerifiedAt,
 		profile.UpdatedByUserAt,
 		profile.UpdatedBySystemAt,
 		profile.ID,
@@ -321,9 +333,10 @@ func (r *PostgresProfileRepository) UpdateProfile(ctx context.Context, profile *
 
 // TODO: 2, 3
 
-// SoftDeleteProfile archives the profile to deleted_profiles and removes it from platform_profiles
-func (r *PostgresProfileRepository) SoftDeleteProfile(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Starting profile soft delete process", "id", id)
+// SoftDeleteProfile archives the profile to deleted_profiles and removes it from platform_profiles.
+// The linked student records are soft deleted along with it and the profile's sessions are revoked.
+func (r *PostgresProfileRepository) SoftDeleteProfile(ctx context.Context, id uuid.UUID, deletedBy uuid.UUID, reason string) error {
+	r.logger.Debug("Starting profile soft delete process", "id", id, "deleted_by", deletedBy)
 
 	// Begin a transaction to ensure atomicity
 	tx, err := r.pool.Begin(ctx)
@@ -337,71 +350,81 @@ func (r *PostgresProfileRepository) SoftDeleteProfile(ctx context.Context, id uu
 	}
 	defer tx.Rollback(ctx)
 
-	// Archive the profile to the deleted_profiles table
+	if _, err := softDeleteProfileTx(ctx, tx, id, deletedBy, reason); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Profile not found for soft delete", "id", id)
+			return ErrProfileNotFound
+		}
+		r.logger.Error("Failed to soft delete profile", "id", id, "error", err)
+		return err
+	}
+
+	// Commit the transaction after successful archiving and deletion
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction for soft delete", "id", id, "error", err)
+		return fmt.Errorf("failed to commit transaction for soft delete: %w", err)
+	}
+
+	r.logger.Info("Profile archived and removed from platform_profiles successfully", "id", id)
+	return nil
+}
+
+// softDeleteProfileTx moves a profile to deleted_profiles inside a transaction, soft deletes the
+// student records linked to it and revokes its sessions. It returns the username of the
+// profile, pgx.ErrNoRows when the profile doesn't exist.
+func softDeleteProfileTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedBy uuid.UUID, reason string) (string, error) {
 	archiveQuery := `
 	INSERT INTO profile_schema.deleted_profiles (
-		id, username, email, password_hash, status, verified_at, 
+		id, username, email, password_hash, status, verified_at,
 		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at, deleted_at
+		updated_by_system_at, must_change_password, deleted_at, deleted_by, reason
 	)
-	SELECT 
-		id, username, email, password_hash, status, verified_at, 
+	SELECT
+		id, username, email, password_hash, status, verified_at,
 		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at, NOW()
-	FROM platform_profiles
+		updated_by_system_at, must_change_password, NOW(), $2, NULLIF($3, '')
+	FROM profile_schema.platform_profiles
 	WHERE id = $1
-	`
-
-	commandTag, err := tx.Exec(ctx, archiveQuery, id)
-	if err != nil {
-		r.logger.Error(
-			"Failed to archive profile before soft delete",
-			"id", id,
-			"error", err,
-		)
-		return fmt.Errorf("failed to archive profile before soft delete: %w", err)
-	}
+	RETURNING username`
 
-	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile not found for soft delete", "id", id)
-		return ErrProfileNotFound
+	var username string
+	if err := tx.QueryRow(ctx, archiveQuery, id, deletedBy, reason).Scan(&username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to archive profile before soft delete: %w", err)
 	}
 
-	r.logger.Info("Profile archived successfully", "id", id)
-
 	// Permanently remove the profile from platform_profiles
-	deleteQuery := `
-	DELETE FROM platform_profiles WHERE id = $1
-	`
-
-	commandTag, err = tx.Exec(ctx, deleteQuery, id)
-	if err != nil {
-		r.logger.Error("Failed to delete profile after archiving", "id", id, "error", err)
-		return fmt.Errorf("failed to delete profile after archiving: %w", err)
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.platform_profiles WHERE id = $1`, id); err != nil {
+		return "", fmt.Errorf("failed to delete profile after archiving: %w", err)
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile already deleted or not found", "id", id)
-		return ErrProfileNotFound
+	// The student records linked to the profile go to the recovery bin with it
+	if _, err := tx.Exec(ctx, `
+	UPDATE public.enrollment_master_lookup_table SET
+		DeletedAt = NOW()
+	WHERE ProfileID = $1 AND DeletedAt IS NULL`,
+		id,
+	); err != nil {
+		return "", fmt.Errorf("failed to soft delete student records: %w", err)
 	}
 
-	// Commit the transaction after successful archiving and deletion
-	if err = tx.Commit(ctx); err != nil {
-		r.logger.Error("Failed to commit transaction for soft delete", "id", id, "error", err)
-		return fmt.Errorf("failed to commit transaction for soft delete: %w", err)
+	if err := revokeProfileSessionsTx(ctx, tx, id); err != nil {
+		return "", err
 	}
 
-	r.logger.Info("Profile archived and removed from platform_profiles successfully", "id", id)
-	return nil
+	return username, nil
 }
 
-// VerifyProfile marks a profile as verified by setting VerifiedAt timestamp
+// VerifyProfile marks a profile as verified by setting VerifiedAt timestamp.
+// Pending profiles are activated, other statuses such as suspended are kept.
 func (r *PostgresProfileRepository) VerifyProfile(ctx context.Context, id uuid.UUID) error {
 	r.logger.Debug("Starting profile verification process", "id", id)
 
 	query := `
 	UPDATE platform_profiles SET
-		status = 'activated',
+		status = CASE WHEN status = 'pending' THEN 'activated' ELSE status END,
 		verified_at = $1,
 		updated_by_user_at = $1,
 		updated_by_system_at = $1
@@ -434,6 +457,116 @@ func (r *PostgresProfileRepository) VerifyProfile(ctx context.Context, id uuid.U
 	return nil
 }
 
+// CreateEmailVerificationToken stores a sent email verification token
+func (r *PostgresProfileRepository) CreateEmailVerificationToken(ctx context.Context, token *platform_profile.EmailVerificationToken) error {
+	r.logger.Debug("Starting to create email verification token", "profile_id", token.ProfileID)
+
+	query := `
+	INSERT INTO profile_schema.email_verification_tokens (
+		id, profile_id, token_hash, expires_at, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5
+	)`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		token.ID,
+		token.ProfileID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create email verification token", "profile_id", token.ProfileID, "error", err)
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+
+	r.logger.Info("Email verification token created successfully", "profile_id", token.ProfileID, "expires_at", token.ExpiresAt)
+	return nil
+}
+
+// GetEmailVerificationTokenByHash retrieves an email verification token by the hash of its value
+func (r *PostgresProfileRepository) GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (*platform_profile.EmailVerificationToken, error) {
+	r.logger.Debug("Fetching email verification token")
+
+	query := `
+	SELECT id, profile_id, token_hash, expires_at, used_at, created_at
+	FROM profile_schema.email_verification_tokens
+	WHERE token_hash = $1`
+
+	token := &platform_profile.EmailVerificationToken{}
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.ProfileID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Email verification token not found")
+			return nil, ErrVerificationTokenNotFound
+		}
+		r.logger.Error("Failed to fetch email verification token", "error", err)
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ConsumeEmailVerificationToken marks a verification token as used.
+// Returns false if the token was already used, so each link verifies only once.
+func (r *PostgresProfileRepository) ConsumeEmailVerificationToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.logger.Debug("Consuming email verification token", "token_id", id)
+
+	query := `
+	UPDATE profile_schema.email_verification_tokens
+	SET used_at = $1
+	WHERE id = $2 AND used_at IS NULL`
+
+	commandTag, err := r.pool.Exec(ctx, query, time.Now(), id)
+	if err != nil {
+		r.logger.Error("Failed to consume email verification token", "token_id", id, "error", err)
+		return false, fmt.Errorf("failed to consume email verification token: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// CountEmailVerificationTokensSince counts the verification tokens sent to a profile since the given time
+func (r *PostgresProfileRepository) CountEmailVerificationTokensSince(ctx context.Context, profileID uuid.UUID, since time.Time) (int, error) {
+	query := `
+	SELECT COUNT(*)
+	FROM profile_schema.email_verification_tokens
+	WHERE profile_id = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, profileID, since).Scan(&count); err != nil {
+		r.logger.Error("Failed to count email verification tokens", "profile_id", profileID, "error", err)
+		return 0, fmt.Errorf("failed to count email verification tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteEmailVerificationTokens deletes all verification tokens of a profile
+func (r *PostgresProfileRepository) DeleteEmailVerificationTokens(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Deleting email verification tokens", "profile_id", profileID)
+
+	query := `DELETE FROM profile_schema.email_verification_tokens WHERE profile_id = $1`
+
+	commandTag, err := r.pool.Exec(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to delete email verification tokens", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to delete email verification tokens: %w", err)
+	}
+
+	r.logger.Info("Email verification tokens deleted", "profile_id", profileID, "deleted", commandTag.RowsAffected())
+	return nil
+}
+
 // UpdateStatus updates the status of a profile
 func (r *PostgresProfileRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status platform_profile.Status) error {
 	r.logger.Debug("Updating profile status", "id", id, "status", status)
@@ -471,7 +604,7 @@ func (r *PostgresProfileRepository) CreatePasswordResetToken(ctx context.Context
 
 	query := `
 	INSERT INTO profile_schema.password_reset_tokens (
-		profile_id, token, expires_at, is_used, created_at
+		profile_id, token_hash, expires_at, is_used, created_at
 	) VALUES (
 		$1, $2, $3, $4, $5
 	)`
@@ -481,7 +614,7 @@ func (r *PostgresProfileRepository) CreatePasswordResetToken(ctx context.Context
 		ctx,
 		query,
 		resetToken.ProfileID,
-		resetToken.Token,
+		resetToken.TokenHash,
 		resetToken.ExpiresAt,
 		resetToken.IsUsed,
 		resetToken.CreatedAt,
@@ -504,20 +637,20 @@ func (r *PostgresProfileRepository) CreatePasswordResetToken(ctx context.Context
 	return nil
 }
 
-// GetPasswordResetToken retrieves a password reset token
-func (r *PostgresProfileRepository) GetPasswordResetToken(ctx context.Context, token string) (*platform_profile.PasswordResetToken, error) {
-	r.logger.Debug("Fetching password reset token", "token", token)
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of its value
+func (r *PostgresProfileRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*platform_profile.PasswordResetToken, error) {
+	r.logger.Debug("Fetching password reset token")
 
 	query := `
 	SELECT 
-		profile_id, token, expires_at, is_used, created_at
+		profile_id, token_hash, expires_at, is_used, created_at
 	FROM profile_schema.password_reset_tokens
-	WHERE token = $1`
+	WHERE token_hash = $1`
 
 	resetToken := &platform_profile.PasswordResetToken{}
-	err := r.pool.QueryRow(ctx, query, token).Scan(
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
 		&resetToken.ProfileID,
-		&resetToken.Token,
+		&resetToken.TokenHash,
 		&resetToken.ExpiresAt,
 		&resetToken.IsUsed,
 		&resetToken.CreatedAt,
@@ -525,50 +658,116 @@ func (r *PostgresProfileRepository) GetPasswordResetToken(ctx context.Context, t
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			r.logger.Warn("Password reset token not found", "token", token)
+			r.logger.Warn("Password reset token not found")
 			return nil, ErrResetTokenNotFound
 		}
-		r.logger.Error("Failed to fetch password reset token", "token", token, "error", err)
+		r.logger.Error("Failed to fetch password reset token", "error", err)
 		return nil, fmt.Errorf("failed to get password reset token: %w", err)
 	}
 
-	r.logger.Info("Password reset token fetched successfully", "token", token, "profile_id", resetToken.ProfileID)
+	r.logger.Debug("Password reset token fetched successfully", "profile_id", resetToken.ProfileID)
 	return resetToken, nil
 }
 
-// MarkPasswordResetTokenUsed marks a token as used
-func (r *PostgresProfileRepository) MarkPasswordResetTokenUsed(ctx context.Context, token string) error {
-	r.logger.Debug("Marking password reset token as used", "token", token)
+// MarkPasswordResetTokenUsed marks the token with the given hash as used
+func (r *PostgresProfileRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenHash string) error {
+	r.logger.Debug("Marking password reset token as used")
 
 	query := `
 	UPDATE profile_schema.password_reset_tokens SET
 		is_used = true,
 		updated_at = NOW()
-	WHERE token = $1 AND is_used = false`
+	WHERE token_hash = $1 AND is_used = false`
 
-	commandTag, err := r.pool.Exec(ctx, query, token)
+	commandTag, err := r.pool.Exec(ctx, query, tokenHash)
 	if err != nil {
-		r.logger.Error("Failed to mark password reset token as used", "token", token, "error", err)
+		r.logger.Error("Failed to mark password reset token as used", "error", err)
 		return fmt.Errorf("failed to mark password reset token as used: %w", err)
 	}
 
 	// Check if any rows were affected
 	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Password reset token not found or already used", "token", token)
+		r.logger.Warn("Password reset token not found or already used")
 		return ErrResetTokenNotFound
 	}
 
-	r.logger.Info("Password reset token marked as used successfully", "token", token)
+	r.logger.Info("Password reset token marked as used successfully")
+	return nil
+}
+
+// ExpirePasswordResetTokens expires all unused reset tokens of a profile
+func (r *PostgresProfileRepository) ExpirePasswordResetTokens(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Expiring password reset tokens", "profile_id", profileID)
+
+	query := `
+	UPDATE profile_schema.password_reset_tokens SET
+		expires_at = $1,
+		updated_at = $1
+	WHERE profile_id = $2 AND is_used = false AND expires_at > $1`
+
+	commandTag, err := r.pool.Exec(ctx, query, time.Now(), profileID)
+	if err != nil {
+		r.logger.Error("Failed to expire password reset tokens", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to expire password reset tokens: %w", err)
+	}
+
+	r.logger.Info("Password reset tokens expired", "profile_id", profileID, "expired", commandTag.RowsAffected())
+	return nil
+}
+
+// DeleteExpiredPasswordResetTokens deletes all reset tokens that are expired or used
+// and returns the number of deleted tokens
+func (r *PostgresProfileRepository) DeleteExpiredPasswordResetTokens(ctx context.Context) (int64, error) {
+	r.logger.Debug("Deleting expired password reset tokens")
+
+	query := `
+	DELETE FROM profile_schema.password_reset_tokens
+	WHERE expires_at <= $1 OR is_used = true`
+
+	commandTag, err := r.pool.Exec(ctx, query, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to delete expired password reset tokens", "error", err)
+		return 0, fmt.Errorf("failed to delete expired password reset tokens: %w", err)
+	}
+
+	r.logger.Info("Expired password reset tokens deleted", "deleted", commandTag.RowsAffected())
+	return commandTag.RowsAffected(), nil
+}
+
+// SetMustChangePassword sets or clears the forced password change flag of a profile
+func (r *PostgresProfileRepository) SetMustChangePassword(ctx context.Context, id uuid.UUID, mustChange bool) error {
+	r.logger.Debug("Setting forced password change", "id", id, "must_change_password", mustChange)
+
+	query := `
+	UPDATE platform_profiles SET
+		must_change_password = $1,
+		updated_by_system_at = $2
+	WHERE id = $3`
+
+	commandTag, err := r.pool.Exec(ctx, query, mustChange, time.Now(), id)
+	if err != nil {
+		r.logger.Error("Failed to set forced password change", "id", id, "error", err)
+		return fmt.Errorf("failed to set forced password change: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Profile not found while setting forced password change", "id", id)
+		return ErrProfileNotFound
+	}
+
+	r.logger.Info("Forced password change updated", "id", id, "must_change_password", mustChange)
 	return nil
 }
 
-// UpdatePassword updates a profile's password hash
+// UpdatePassword updates a profile's password hash and clears a forced password change
 func (r *PostgresProfileRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
 	r.logger.Debug("Starting password update process", "id", id)
 
+	// A new password satisfies a forced password change
 	query := `
 	UPDATE platform_profiles SET
 		password_hash = $1,
+		must_change_password = false,
 		updated_by_user_at = $2,
 		updated_by_system_at = $2
 	WHERE id = $3
@@ -600,6 +799,109 @@ func (r *PostgresProfileRepository) UpdatePassword(ctx context.Context, id uuid.
 	return nil
 }
 
+// ReplacePasswordHash swaps the stored hash of an unchanged password, e.g. after re-hashing it
+// with newer parameters. Unlike UpdatePassword it leaves the forced password change flag alone.
+func (r *PostgresProfileRepository) ReplacePasswordHash(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	r.logger.Debug("Replacing password hash", "id", id)
+
+	query := `
+	UPDATE platform_profiles SET
+		password_hash = $1,
+		updated_by_system_at = $2
+	WHERE id = $3`
+
+	commandTag, err := r.pool.Exec(ctx, query, passwordHash, time.Now(), id)
+	if err != nil {
+		r.logger.Error("Failed to replace password hash", "id", id, "error", err)
+		return fmt.Errorf("failed to replace password hash: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Profile not found while replacing password hash", "id", id)
+		return ErrProfileNotFound
+	}
+
+	r.logger.Info("Password hash replaced", "id", id)
+	return nil
+}
+
+// AddPasswordHistory records a password hash of a profile and prunes everything but the newest keep entries
+func (r *PostgresProfileRepository) AddPasswordHistory(ctx context.Context, profileID uuid.UUID, passwordHash string, keep int) error {
+	r.logger.Debug("Recording password history", "profile_id", profileID, "keep", keep)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin password history transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+	INSERT INTO profile_schema.password_history (id, profile_id, password_hash, created_at)
+	VALUES ($1, $2, $3, $4)`
+
+	if _, err := tx.Exec(ctx, insertQuery, uuid.New(), profileID, passwordHash, time.Now()); err != nil {
+		r.logger.Error("Failed to insert password history", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to insert password history: %w", err)
+	}
+
+	pruneQuery := `
+	DELETE FROM profile_schema.password_history
+	WHERE profile_id = $1 AND id NOT IN (
+		SELECT id FROM profile_schema.password_history
+		WHERE profile_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	)`
+
+	if _, err := tx.Exec(ctx, pruneQuery, profileID, keep); err != nil {
+		r.logger.Error("Failed to prune password history", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit password history", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Password history recorded", "profile_id", profileID)
+	return nil
+}
+
+// GetPasswordHistory returns the newest password hashes of a profile, newest first
+func (r *PostgresProfileRepository) GetPasswordHistory(ctx context.Context, profileID uuid.UUID, limit int) ([]string, error) {
+	r.logger.Debug("Fetching password history", "profile_id", profileID, "limit", limit)
+
+	query := `
+	SELECT password_hash FROM profile_schema.password_history
+	WHERE profile_id = $1
+	ORDER BY created_at DESC
+	LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, profileID, limit)
+	if err != nil {
+		r.logger.Error("Failed to fetch password history", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to fetch password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			r.logger.Error("Failed to scan password history", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating password history", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating password history: %w", err)
+	}
+
+	return hashes, nil
+}
+
 // DeleteOtherResetTokens deletes all other reset tokens for the profile except the used one
 func (r *PostgresProfileRepository) DeleteOtherPasswordResetTokens(ctx context.Context, profileID uuid.UUID) error {
 	r.logger.Debug("Starting to delete other password reset tokens", "profile_id", profileID)
@@ -628,918 +930,3077 @@ func (r *PostgresProfileRepository) DeleteOtherPasswordResetTokens(ctx context.C
 	return nil
 }
 
-// GetSoftDeletedProfileByEmail retrieves a soft-deleted profile by email
-func (r *PostgresProfileRepository) GetSoftDeletedProfileByEmail(ctx context.Context, email string) (*platform_profile.PlatformProfile, error) {
-	r.logger.Debug("Fetching soft-deleted profile by email", "email", email)
+// insertRefreshTokenQuery stores a refresh token, shared by CreateRefreshToken and CreateSession
+const insertRefreshTokenQuery = `
+	INSERT INTO profile_schema.refresh_tokens (
+		id, profile_id, family_id, token_hash, expires_at, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)`
 
-	query := `
-	SELECT id, username, email, password_hash, status, verified_at, 
-	       last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-	       updated_by_system_at
-	FROM profile_schema.deleted_profiles
-	WHERE email = $1
-	`
+// maxUserAgentLength is the size of the user_agent columns of sessions and MFA challenges
+const maxUserAgentLength = 512
 
-	var profile platform_profile.PlatformProfile
-	err := r.pool.QueryRow(ctx, query, email).Scan(
-		&profile.ID,
-		&profile.Username,
-		&profile.Email,
-		&profile.PasswordHash,
-		&profile.Status,
-		&profile.VerifiedAt,
-		&profile.LastLoginAt,
-		&profile.FailedLoginAttempts,
-		&profile.CreatedAt,
-		&profile.UpdatedByUserAt,
-		&profile.UpdatedBySystemAt,
+// truncateUserAgent shortens a User-Agent header to fit its column without splitting a character
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	cut := maxUserAgentLength
+	for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+		cut--
+	}
+	return userAgent[:cut]
+}
+
+// CreateRefreshToken stores the hash of a newly issued refresh token
+func (r *PostgresProfileRepository) CreateRefreshToken(ctx context.Context, token *platform_profile.RefreshToken) error {
+	r.logger.Debug(
+		"Starting to create refresh token",
+		"profile_id", token.ProfileID,
+		"family_id", token.FamilyID,
+	)
+
+	_, err := r.pool.Exec(
+		ctx,
+		insertRefreshTokenQuery,
+		token.ID,
+		token.ProfileID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			r.logger.Warn("No soft-deleted profile found with the given email", "email", email)
-			return nil, ErrProfileNotFound
-		}
-		r.logger.Error("Failed to fetch soft-deleted profile by email", "email", email, "error", err)
-		return nil, ErrProfileNotFound
+		r.logger.Error(
+			"Failed to create refresh token",
+			"profile_id", token.ProfileID,
+			"family_id", token.FamilyID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to create refresh token: %w", err)
 	}
 
-	r.logger.Info("Soft-deleted profile fetched successfully by email", "email", email)
-	return &profile, nil
+	r.logger.Info(
+		"Refresh token created successfully",
+		"profile_id", token.ProfileID,
+		"family_id", token.FamilyID,
+		"expires_at", token.ExpiresAt,
+	)
+	return nil
 }
 
-// GetSoftDeletedProfileByUsername retrieves a soft-deleted profile by username
-func (r *PostgresProfileRepository) GetSoftDeletedProfileByUsername(ctx context.Context, username string) (*platform_profile.PlatformProfile, error) {
-	r.logger.Debug("Fetching soft-deleted profile by username", "username", username)
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+func (r *PostgresProfileRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*platform_profile.RefreshToken, error) {
+	r.logger.Debug("Fetching refresh token by hash")
 
 	query := `
-	SELECT id, username, email, password_hash, status, verified_at, 
-	       last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-	       updated_by_system_at
-	FROM profile_schema.deleted_profiles
-	WHERE username = $1
-	`
-
-	var profile platform_profile.PlatformProfile
-	err := r.pool.QueryRow(ctx, query, username).Scan(
-		&profile.ID,
-		&profile.Username,
-		&profile.Email,
-		&profile.PasswordHash,
-		&profile.Status,
-		&profile.VerifiedAt,
-		&profile.LastLoginAt,
-		&profile.FailedLoginAttempts,
-		&profile.CreatedAt,
-		&profile.UpdatedByUserAt,
-		&profile.UpdatedBySystemAt,
+	SELECT
+		id, profile_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+	FROM profile_schema.refresh_tokens
+	WHERE token_hash = $1`
+
+	token := &platform_profile.RefreshToken{}
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.ProfileID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			r.logger.Warn("No soft-deleted profile found with the given username", "username", username)
-			return nil, ErrProfileNotFound
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Refresh token not found")
+			return nil, ErrRefreshTokenNotFound
 		}
-		r.logger.Error("Failed to fetch soft-deleted profile by username", "username", username, "error", err)
-		return nil, ErrProfileNotFound
+		r.logger.Error("Failed to fetch refresh token", "error", err)
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
 	}
 
-	r.logger.Info("Soft-deleted profile fetched successfully by username", "username", username)
-	return &profile, nil
+	r.logger.Info("Refresh token fetched successfully", "id", token.ID, "profile_id", token.ProfileID)
+	return token, nil
 }
 
-// RestoreSoftDeletedProfile restores a soft-deleted profile by id
-func (r *PostgresProfileRepository) RestoreSoftDeletedProfile(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Starting profile restoration process", "id", id)
+// ConsumeRefreshToken marks a refresh token as used.
+// It returns false when the token was already used or revoked, so that two
+// concurrent refreshes with the same token cannot both succeed.
+func (r *PostgresProfileRepository) ConsumeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.logger.Debug("Consuming refresh token", "id", id)
 
-	// Begin a transaction
-	tx, err := r.pool.Begin(ctx)
+	query := `
+	UPDATE profile_schema.refresh_tokens SET
+		used_at = NOW()
+	WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+
+	commandTag, err := r.pool.Exec(ctx, query, id)
 	if err != nil {
-		r.logger.Error(
-			"Failed to start transaction for profile restoration",
-			"id", id,
-			"error", err,
-		)
-		return fmt.Errorf("failed to start transaction for profile restoration: %w", err)
+		r.logger.Error("Failed to consume refresh token", "id", id, "error", err)
+		return false, fmt.Errorf("failed to consume refresh token: %w", err)
 	}
-	defer tx.Rollback(ctx)
 
-	// Restore profile from deleted_profiles to platform_profiles
-	restoreQuery := `
-	INSERT INTO platform_profiles (
-		id, username, email, password_hash, status, verified_at, 
-		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at
-	)
-	SELECT 
-		id, username, email, password_hash, status, verified_at, 
-		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
-		updated_by_system_at
-	FROM profile_schema.deleted_profiles
-	WHERE id = $1
-	ON CONFLICT (id) DO NOTHING
-	RETURNING id
-	`
-
-	var restoredID uuid.UUID
-	err = tx.QueryRow(ctx, restoreQuery, id).Scan(&restoredID)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			r.logger.Warn("No soft-deleted profile found for restoration", "id", id)
-			return ErrProfileNotFound
-		}
-		r.logger.Error("Failed to restore profile from deleted_profiles", "id", id, "error", err)
-		return fmt.Errorf("failed to restore profile: %w", err)
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Refresh token already used or revoked", "id", id)
+		return false, nil
 	}
 
-	r.logger.Info("Profile restored successfully", "id", id, "restoredID", restoredID)
-
-	// Delete the restored profile from deleted_profiles
-	deleteQuery := `
-	DELETE FROM profile_schema.deleted_profiles WHERE id = $1
-	`
+	r.logger.Info("Refresh token consumed successfully", "id", id)
+	return true, nil
+}
 
-	commandTag, err := tx.Exec(ctx, deleteQuery, id)
-	if err != nil {
-		r.logger.Error(
-			"Failed to delete restored profile from deleted_profiles",
-			"id", id,
-			"error", err,
-		)
-		return fmt.Errorf("failed to delete restored profile from deleted_profiles: %w", err)
-	}
+// RevokeRefreshTokenFamily revokes every refresh token that descends from the same login
+func (r *PostgresProfileRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.logger.Debug("Revoking refresh token family", "family_id", familyID)
 
-	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile was not found or already restored", "id", id)
-		return ErrProfileNotFound
-	}
+	query := `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE family_id = $1 AND revoked_at IS NULL`
 
-	// Commit transaction after successful restoration and deletion
-	if err = tx.Commit(ctx); err != nil {
-		r.logger.Error(
-			"Failed to commit transaction for profile restoration",
-			"id", id,
-			"error", err,
-		)
-		return fmt.Errorf("failed to commit transaction for profile restoration: %w", err)
+	commandTag, err := r.pool.Exec(ctx, query, familyID)
+	if err != nil {
+		r.logger.Error("Failed to revoke refresh token family", "family_id", familyID, "error", err)
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
 	}
 
-	r.logger.Info("Profile restoration completed successfully", "id", id)
+	r.logger.Info(
+		"Refresh token family revoked successfully",
+		"family_id", familyID,
+		"tokens_revoked", commandTag.RowsAffected(),
+	)
 	return nil
 }
 
-// HardDeleteProfile removes a profile from the deleted_profiles table in profile_schema
-func (r *PostgresProfileRepository) HardDeleteProfile(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Starting hard delete process for profile", "id", id)
+// RevokeAllRefreshTokens revokes every active refresh token of a profile
+func (r *PostgresProfileRepository) RevokeAllRefreshTokens(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Revoking all refresh tokens", "profile_id", profileID)
 
-	// Delete query to remove the profile from deleted_profiles table
-	query := `DELETE FROM profile_schema.deleted_profiles WHERE id = $1`
+	query := `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND revoked_at IS NULL`
 
-	commandTag, err := r.pool.Exec(ctx, query, id)
+	commandTag, err := r.pool.Exec(ctx, query, profileID)
 	if err != nil {
-		r.logger.Error("Failed to delete profile from deleted_profiles", "id", id, "error", err)
-		return fmt.Errorf("failed to delete profile from deleted_profiles: %w", err)
-	}
-
-	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile not found in deleted_profiles for hard delete", "id", id)
-		return ErrProfileNotFound
+		r.logger.Error("Failed to revoke refresh tokens", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
 	}
 
-	r.logger.Info("Profile successfully deleted from deleted_profiles", "id", id)
+	r.logger.Info(
+		"Refresh tokens revoked successfully",
+		"profile_id", profileID,
+		"tokens_revoked", commandTag.RowsAffected(),
+	)
 	return nil
 }
 
-// AssignRoleToProfile assigns a role to a profile in the profile_roles table
-func (r *PostgresProfileRepository) AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
+// CreateSession stores a new login session together with the first refresh token of its family,
+// so a login never leaves a session without a token or a token without a session
+func (r *PostgresProfileRepository) CreateSession(ctx context.Context, session *platform_profile.Session, token *platform_profile.RefreshToken) error {
+	r.logger.Debug("Starting to create session", "profile_id", session.ProfileID, "session_id", session.ID)
 
-	r.logger.Debug(
-		"Starting to assign role to profile",
-		"profile_id",
-		profileID,
-		"role_id",
-		roleID,
-	)
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin session transaction", "profile_id", session.ProfileID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	query := `
-	INSERT INTO profile_schema.profile_roles (
-		profile_id, role_id, created_at
+	INSERT INTO profile_schema.sessions (
+		id, profile_id, user_agent, ip_address, device_info,
+		expires_at, last_seen_at, created_at
 	) VALUES (
-		$1, $2, $3
-	) ON CONFLICT (profile_id, role_id) DO NOTHING
-	`
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)`
 
-	// Execute the query to assign the role
-	_, err := r.pool.Exec(ctx, query, profileID, roleID, time.Now())
+	_, err = tx.Exec(
+		ctx,
+		query,
+		session.ID,
+		session.ProfileID,
+		truncateUserAgent(session.UserAgent),
+		session.IPAddress,
+		session.DeviceInfo,
+		session.ExpiresAt,
+		session.LastSeenAt,
+		session.CreatedAt,
+	)
 	if err != nil {
 		r.logger.Error(
-			"Failed to assign role to profile",
-			"profile_id", profileID,
-			"role_id", roleID,
+			"Failed to create session",
+			"profile_id", session.ProfileID,
+			"session_id", session.ID,
 			"error", err,
 		)
-		return fmt.Errorf("failed to assign role to profile: %w", err)
+		return fmt.Errorf("failed to create session: %w", err)
 	}
 
-	r.logger.Info("Role assigned to profile successfully", "profile_id", profileID, "role_id", roleID)
+	_, err = tx.Exec(
+		ctx,
+		insertRefreshTokenQuery,
+		token.ID,
+		token.ProfileID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create session refresh token", "session_id", session.ID, "error", err)
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit session", "session_id", session.ID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Session created successfully", "profile_id", session.ProfileID, "session_id", session.ID)
 	return nil
 }
 
-// GetProfileRoles retrieves all roles assigned to a profile
-func (r *PostgresProfileRepository) GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]uuid.UUID, error) {
-	r.logger.Debug("Fetching roles assigned to profile", "profile_id", profileID)
+// GetSession retrieves a session by ID, including revoked and expired sessions
+func (r *PostgresProfileRepository) GetSession(ctx context.Context, id uuid.UUID) (*platform_profile.Session, error) {
+	r.logger.Debug("Fetching session", "session_id", id)
 
 	query := `
-	SELECT role_id
-	FROM profile_schema.profile_roles
-	WHERE profile_id = $1`
+	SELECT
+		id, profile_id, user_agent, ip_address, device_info,
+		expires_at, last_seen_at, created_at, revoked_at
+	FROM profile_schema.sessions
+	WHERE id = $1`
+
+	session := &platform_profile.Session{}
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&session.ID,
+		&session.ProfileID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.DeviceInfo,
+		&session.ExpiresAt,
+		&session.LastSeenAt,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Session not found", "session_id", id)
+			return nil, ErrSessionNotFound
+		}
+		r.logger.Error("Failed to fetch session", "session_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	r.logger.Info("Session fetched successfully", "session_id", id)
+	return session, nil
+}
+
+// ListActiveSessions retrieves the sessions of a profile that are neither revoked nor expired
+func (r *PostgresProfileRepository) ListActiveSessions(ctx context.Context, profileID uuid.UUID) ([]*platform_profile.Session, error) {
+	r.logger.Debug("Listing active sessions", "profile_id", profileID)
+
+	query := `
+	SELECT
+		id, profile_id, user_agent, ip_address, device_info,
+		expires_at, last_seen_at, created_at, revoked_at
+	FROM profile_schema.sessions
+	WHERE profile_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+	ORDER BY last_seen_at DESC`
 
-	// Query to get roles
 	rows, err := r.pool.Query(ctx, query, profileID)
 	if err != nil {
-		r.logger.Error("Failed to query profile roles", "profile_id", profileID, "error", err)
-		return nil, fmt.Errorf("failed to get profile roles: %w", err)
+		r.logger.Error("Failed to query sessions", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
 	}
 	defer rows.Close()
 
-	var roleIDs []uuid.UUID
-
-	// Iterate over the result set
+	var sessions []*platform_profile.Session
 	for rows.Next() {
-		var roleID uuid.UUID
-		if err := rows.Scan(&roleID); err != nil {
-			r.logger.Error("Failed to scan role ID", "profile_id", profileID, "error", err)
-			return nil, fmt.Errorf("failed to scan role ID: %w", err)
+		session := &platform_profile.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			&session.ProfileID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.DeviceInfo,
+			&session.ExpiresAt,
+			&session.LastSeenAt,
+			&session.CreatedAt,
+			&session.RevokedAt,
+		); err != nil {
+			r.logger.Error("Failed to scan session", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-		roleIDs = append(roleIDs, roleID)
+		sessions = append(sessions, session)
 	}
 
-	// Check for errors after iteration
 	if err := rows.Err(); err != nil {
-		r.logger.Error("Error iterating over role rows", "profile_id", profileID, "error", err)
-		return nil, fmt.Errorf("error iterating over role rows: %w", err)
-	}
-
-	// Log if no roles are found
-	if len(roleIDs) == 0 {
-		r.logger.Warn("No roles found for profile", "profile_id", profileID)
+		r.logger.Error("Error iterating over session rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over session rows: %w", err)
 	}
 
-	r.logger.Info("Roles retrieved successfully for profile", "profile_id", profileID, "role_count", len(roleIDs))
-	return roleIDs, nil
+	r.logger.Info("Active sessions listed successfully", "profile_id", profileID, "session_count", len(sessions))
+	return sessions, nil
 }
 
-// RemoveRoleFromProfile removes a role from a profile
-func (r *PostgresProfileRepository) RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
-	r.logger.Debug("Starting to remove role from profile", "profile_id", profileID, "role_id", roleID)
+// TouchSession records activity on a session and extends it to the given expiry
+func (r *PostgresProfileRepository) TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	r.logger.Debug("Touching session", "session_id", id)
 
 	query := `
-	DELETE FROM profile_schema.profile_roles
-	WHERE profile_id = $1 AND role_id = $2`
+	UPDATE profile_schema.sessions SET
+		last_seen_at = NOW(),
+		expires_at = $2
+	WHERE id = $1 AND revoked_at IS NULL`
 
-	// Execute the query to remove the role
-	commandTag, err := r.pool.Exec(ctx, query, profileID, roleID)
+	commandTag, err := r.pool.Exec(ctx, query, id, expiresAt)
 	if err != nil {
-		r.logger.Error(
-			"Failed to remove role from profile",
-			"profile_id", profileID,
-			"role_id", roleID,
-			"error", err,
-		)
-		return fmt.Errorf("failed to remove role from profile: %w", err)
+		r.logger.Error("Failed to touch session", "session_id", id, "error", err)
+		return fmt.Errorf("failed to touch session: %w", err)
 	}
 
-	// Check if any rows were affected
 	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn(
-			"Role not found for profile or already removed",
-			"profile_id", profileID,
-			"role_id", roleID,
-		)
-		return ErrRoleNotAssigned
+		r.logger.Warn("Session not found or already revoked", "session_id", id)
+		return ErrSessionNotFound
 	}
 
-	r.logger.Info("Role removed successfully from profile", "profile_id", profileID, "role_id", roleID)
+	r.logger.Debug("Session touched successfully", "session_id", id)
 	return nil
 }
 
-// TODO: 5
-// SavePreferences saves profile preferences
-func (r *PostgresProfileRepository) SavePreferences(ctx context.Context, prefs *platform_profile.ProfilePreference) error {
+// RevokeSession revokes a session of the given profile together with its refresh tokens
+func (r *PostgresProfileRepository) RevokeSession(ctx context.Context, profileID, sessionID uuid.UUID) error {
+	r.logger.Debug("Starting to revoke session", "profile_id", profileID, "session_id", sessionID)
 
-	r.logger.Debug(
-		"Saving profile preferences",
-		"profileID", prefs.ProfileID,
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx, `
+	UPDATE profile_schema.sessions SET
+		revoked_at = NOW()
+	WHERE id = $1 AND profile_id = $2 AND revoked_at IS NULL`,
+		sessionID, profileID,
 	)
+	if err != nil {
+		r.logger.Error("Failed to revoke session", "session_id", sessionID, "error", err)
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
 
-	// Check if preference already exists
-	existingPrefs, err := r.GetPreferences(ctx, prefs.ProfileID)
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Session not found or already revoked", "profile_id", profileID, "session_id", sessionID)
+		return ErrSessionNotFound
+	}
 
-	if err != nil && !errors.Is(err, ErrProfileNotFound) {
-		r.logger.Error(
-			"Failed to check existing preferences",
-			"profileID", prefs.ProfileID,
-			"error", err,
-		)
-		return err
+	// The session ID is the refresh token family ID
+	_, err = tx.Exec(ctx, `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE family_id = $1 AND revoked_at IS NULL`,
+		sessionID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to revoke session refresh tokens", "session_id", sessionID, "error", err)
+		return fmt.Errorf("failed to revoke session refresh tokens: %w", err)
 	}
 
-	var query string
-	if existingPrefs != nil {
-		// Update existing preferences
-		query = `
-		UPDATE profile_schema.profile_preferences SET 
-			notifications_email = $1, 			
-			updated_at = $2 
-		WHERE profile_id = $3`
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "session_id", sessionID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
-		_, err = r.pool.Exec(
-			ctx,
-			query,
-			prefs.NotificationsEmail,
-			time.Now(),
-			prefs.ProfileID,
-		)
-	} else {
-		// Insert new preferences
-		query = `
-		INSERT INTO profile_schema.profile_preferences ( 
-			id, profile_id, notifications_email, created_at, updated_at 
-		) VALUES ( 
-			$1, $2, $3, $4, $5
-		)`
+	r.logger.Info("Session revoked successfully", "profile_id", profileID, "session_id", sessionID)
+	return nil
+}
 
-		now := time.Now()
+// RevokeOtherSessions revokes every session of the profile except the given one
+// and returns the number of sessions revoked
+func (r *PostgresProfileRepository) RevokeOtherSessions(ctx context.Context, profileID, keepSessionID uuid.UUID) (int64, error) {
+	r.logger.Debug("Starting to revoke other sessions", "profile_id", profileID, "keep_session_id", keepSessionID)
 
-		// Generate a new UUID if ID is not provided
-		if prefs.ID == uuid.Nil {
-			prefs.ID = uuid.New()
-		}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-		_, err = r.pool.Exec(
-			ctx,
-			query,
-			prefs.ID,
-			prefs.ProfileID,
-			prefs.NotificationsEmail,
-			now,
-			now,
-		)
+	commandTag, err := tx.Exec(ctx, `
+	UPDATE profile_schema.sessions SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND id <> $2 AND revoked_at IS NULL`,
+		profileID, keepSessionID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to revoke other sessions", "profile_id", profileID, "error", err)
+		return 0, fmt.Errorf("failed to revoke other sessions: %w", err)
 	}
 
+	_, err = tx.Exec(ctx, `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND family_id <> $2 AND revoked_at IS NULL`,
+		profileID, keepSessionID,
+	)
 	if err != nil {
-		r.logger.Error(
-			"Failed to save profile preferences",
-			"profileID", prefs.ProfileID,
-			"error", err,
-		)
-		return fmt.Errorf("failed to save profile preferences: %w", err)
+		r.logger.Error("Failed to revoke refresh tokens of other sessions", "profile_id", profileID, "error", err)
+		return 0, fmt.Errorf("failed to revoke refresh tokens of other sessions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	r.logger.Info(
-		"Profile preferences saved successfully",
-		"profileID", prefs.ProfileID,
+		"Other sessions revoked successfully",
+		"profile_id", profileID,
+		"sessions_revoked", commandTag.RowsAffected(),
 	)
-
-	return nil
+	return commandTag.RowsAffected(), nil
 }
 
-// TODO: 5
-// GetPreferences retrieves profile preferences
-func (r *PostgresProfileRepository) GetPreferences(ctx context.Context, profileID uuid.UUID) (*platform_profile.ProfilePreference, error) {
-
-	r.logger.Debug(
-		"Getting profile preferences",
-		"profileID", profileID,
-	)
+// RevokeAllSessions revokes every session and refresh token of a profile
+func (r *PostgresProfileRepository) RevokeAllSessions(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Starting to revoke all sessions", "profile_id", profileID)
 
-	query := `
-	SELECT  
-		id,
-		profile_id, 
-		notifications_email,
-		created_at, 
-		updated_at 
-	FROM profile_schema.profile_preferences  
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx, `
+	UPDATE profile_schema.sessions SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND revoked_at IS NULL`,
+		profileID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to revoke sessions", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND revoked_at IS NULL`,
+		profileID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to revoke refresh tokens", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info(
+		"All sessions revoked successfully",
+		"profile_id", profileID,
+		"sessions_revoked", commandTag.RowsAffected(),
+	)
+	return nil
+}
+
+// IsMFAEnabled checks if a profile has confirmed MFA enrollment
+func (r *PostgresProfileRepository) IsMFAEnabled(ctx context.Context, profileID uuid.UUID) (bool, error) {
+	r.logger.Debug("Checking if MFA is enabled", "profile_id", profileID)
+
+	query := "SELECT EXISTS(SELECT 1 FROM profile_schema.profile_mfa WHERE profile_id = $1 AND enabled = true)"
+	var enabled bool
+	err := r.pool.QueryRow(ctx, query, profileID).Scan(&enabled)
+	if err != nil {
+		r.logger.Error("Failed to check if MFA is enabled", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to check MFA status: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// GetMFA retrieves the MFA settings of a profile
+func (r *PostgresProfileRepository) GetMFA(ctx context.Context, profileID uuid.UUID) (*platform_profile.ProfileMFA, error) {
+	r.logger.Debug("Fetching MFA settings", "profile_id", profileID)
+
+	query := `
+	SELECT
+		profile_id, secret, enabled, last_used_step, confirmed_at, created_at, updated_at
+	FROM profile_schema.profile_mfa
 	WHERE profile_id = $1`
 
-	prefs := &platform_profile.ProfilePreference{}
+	mfa := &platform_profile.ProfileMFA{}
 	err := r.pool.QueryRow(ctx, query, profileID).Scan(
-		&prefs.ID,
-		&prefs.ProfileID,
-		&prefs.NotificationsEmail,
-		&prefs.CreatedAt,
-		&prefs.UpdatedAt,
+		&mfa.ProfileID,
+		&mfa.Secret,
+		&mfa.Enabled,
+		&mfa.LastUsedStep,
+		&mfa.ConfirmedAt,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
 	)
-
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			r.logger.Debug(
-				"Profile preferences not found",
-				"profileID", profileID,
-			)
-			return nil, ErrProfileNotFound
+			r.logger.Debug("MFA settings not found", "profile_id", profileID)
+			return nil, ErrMFANotFound
 		}
-		r.logger.Error(
-			"Failed to get profile preferences",
-			"profileID", profileID,
-			"error", err,
-		)
-		return nil, fmt.Errorf("failed to get profile preferences: %w", err)
+		r.logger.Error("Failed to fetch MFA settings", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get MFA settings: %w", err)
 	}
 
-	r.logger.Debug(
-		"Profile preferences retrieved successfully",
-		"profileID", profileID,
+	return mfa, nil
+}
+
+// SaveMFA creates or replaces the MFA settings of a profile
+func (r *PostgresProfileRepository) SaveMFA(ctx context.Context, mfa *platform_profile.ProfileMFA) error {
+	r.logger.Debug("Saving MFA settings", "profile_id", mfa.ProfileID)
+
+	query := `
+	INSERT INTO profile_schema.profile_mfa (
+		profile_id, secret, enabled, last_used_step, confirmed_at, created_at, updated_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
 	)
+	ON CONFLICT (profile_id) DO UPDATE SET
+		secret = EXCLUDED.secret,
+		enabled = EXCLUDED.enabled,
+		last_used_step = EXCLUDED.last_used_step,
+		confirmed_at = EXCLUDED.confirmed_at,
+		updated_at = EXCLUDED.updated_at`
 
-	return prefs, nil
-}
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		mfa.ProfileID,
+		mfa.Secret,
+		mfa.Enabled,
+		mfa.LastUsedStep,
+		mfa.ConfirmedAt,
+		mfa.CreatedAt,
+		mfa.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to save MFA settings", "profile_id", mfa.ProfileID, "error", err)
+		return fmt.Errorf("failed to save MFA settings: %w", err)
+	}
 
-// GetProfiles retrieves profiles with pagination and filtering
-func (r *PostgresProfileRepository) GetProfiles(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*platform_profile.PlatformProfile, int, error) {
-	r.logger.Debug("Fetching profiles with pagination and filters", "offset", offset, "limit", limit, "filters", filters)
+	r.logger.Info("MFA settings saved successfully", "profile_id", mfa.ProfileID, "enabled", mfa.Enabled)
+	return nil
+}
 
-	// Base queries for profiles and count
-	baseQuery := `
-	SELECT 
-		id, username, email, password_hash, first_name, last_name, 
-		display_name, status, created_at, updated_by_user_at, 
-		last_login_at, failed_login_attempts, verified_at
-	FROM profile_schema.platform_profiles`
+// EnableMFA marks the pending MFA enrollment of a profile as confirmed and stores its recovery codes
+func (r *PostgresProfileRepository) EnableMFA(ctx context.Context, profileID uuid.UUID, recoveryCodes []*platform_profile.MFARecoveryCode) error {
+	r.logger.Debug("Starting to enable MFA", "profile_id", profileID)
 
-	countQuery := `SELECT COUNT(*) FROM profile_schema.platform_profiles`
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	// Build WHERE clause for filters
-	whereClause := ""
-	args := []interface{}{}
-	paramIndex := 1
+	commandTag, err := tx.Exec(ctx, `
+	UPDATE profile_schema.profile_mfa SET
+		enabled = true,
+		confirmed_at = NOW(),
+		updated_at = NOW()
+	WHERE profile_id = $1 AND enabled = false`,
+		profileID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to enable MFA", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to enable MFA: %w", err)
+	}
 
-	if len(filters) > 0 {
-		whereClause = " WHERE "
-		conditions := []string{}
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("No pending MFA enrollment found", "profile_id", profileID)
+		return ErrMFANotFound
+	}
 
-		for key, value := range filters {
-			switch key {
-			case "status":
-				conditions = append(conditions, fmt.Sprintf("status = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "verified":
-				conditions = append(conditions, fmt.Sprintf("verified_at IS NOT NULL AND verified_at <= $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "search":
-				search := fmt.Sprintf("%%%s%%", value)
-				conditions = append(conditions, fmt.Sprintf("(username ILIKE $%d OR email ILIKE $%d OR first_name ILIKE $%d OR last_name ILIKE $%d)",
-					paramIndex, paramIndex+1, paramIndex+2, paramIndex+3))
-				args = append(args, search, search, search, search)
-				paramIndex += 4
-			case "created_after":
-				conditions = append(conditions, fmt.Sprintf("created_at > $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "created_before":
-				conditions = append(conditions, fmt.Sprintf("created_at < $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			}
-		}
+	if err := r.replaceRecoveryCodes(ctx, tx, profileID, recoveryCodes); err != nil {
+		return err
+	}
 
-		whereClause += strings.Join(conditions, " AND ")
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Build final queries
-	dataQuery := baseQuery + whereClause + fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
-	countQueryFinal := countQuery + whereClause
+	r.logger.Info("MFA enabled successfully", "profile_id", profileID)
+	return nil
+}
 
-	// Add pagination parameters
-	args = append(args, limit, offset)
+// UpdateMFALastUsedStep records the time step of an accepted TOTP code.
+// It returns false when a code of the same or a later step was already accepted.
+func (r *PostgresProfileRepository) UpdateMFALastUsedStep(ctx context.Context, profileID uuid.UUID, step int64) (bool, error) {
+	query := `
+	UPDATE profile_schema.profile_mfa SET
+		last_used_step = $2,
+		updated_at = NOW()
+	WHERE profile_id = $1 AND last_used_step < $2`
 
-	// Get total count of records
-	var total int
-	err := r.pool.QueryRow(ctx, countQueryFinal, args[:len(args)-2]...).Scan(&total)
+	commandTag, err := r.pool.Exec(ctx, query, profileID, step)
 	if err != nil {
-		r.logger.Error("Failed to get profiles count", "error", err)
-		return nil, 0, fmt.Errorf("failed to get profiles count: %w", err)
+		r.logger.Error("Failed to update MFA last used step", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to update MFA last used step: %w", err)
 	}
 
-	// Execute data query
-	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// DeleteMFA removes the MFA settings, recovery codes and pending challenges of a profile
+func (r *PostgresProfileRepository) DeleteMFA(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Starting to delete MFA", "profile_id", profileID)
+
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		r.logger.Error("Failed to get profiles", "error", err)
-		return nil, 0, fmt.Errorf("failed to get profiles: %w", err)
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer rows.Close()
+	defer tx.Rollback(ctx)
 
-	// Process query results
-	profiles := []*platform_profile.PlatformProfile{}
-	for rows.Next() {
-		profile := &platform_profile.PlatformProfile{}
-		err := rows.Scan(
-			&profile.ID,
-			&profile.Username,
-			&profile.Email,
-			&profile.PasswordHash,
-			&profile.Status,
-			&profile.VerifiedAt,
-			&profile.LastLoginAt,
-			&profile.FailedLoginAttempts,
-			&profile.CreatedAt,
-			&profile.UpdatedByUserAt,
-			&profile.UpdatedBySystemAt,
-		)
-		if err != nil {
-			r.logger.Error("Failed to scan profile", "error", err)
-			return nil, 0, fmt.Errorf("failed to scan profile: %w", err)
-		}
-		profiles = append(profiles, profile)
+	if err := r.deleteMFA(ctx, tx, profileID); err != nil {
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		r.logger.Error("Error iterating over profile rows", "error", err)
-		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.logger.Info("Profiles fetched successfully", "total_profiles", len(profiles))
-	return profiles, total, nil
+	r.logger.Info("MFA deleted successfully", "profile_id", profileID)
+	return nil
 }
 
-// GetProfilesByRole retrieves profiles assigned to a specific role with pagination
-func (r *PostgresProfileRepository) GetProfilesByRole(ctx context.Context, roleID uuid.UUID, page, pageSize int) ([]*platform_profile.PlatformProfile, int, error) {
-	r.logger.Debug("Fetching profiles by role", "role_id", roleID, "page", page, "page_size", pageSize)
+// ResetMFA removes the MFA of a profile on behalf of an administrator and records the audit entry
+// in the same transaction, so a reset can never happen without being audited
+func (r *PostgresProfileRepository) ResetMFA(ctx context.Context, profileID uuid.UUID, audit *platform_profile.AuditLog) error {
+	r.logger.Debug("Starting to reset MFA", "profile_id", profileID, "actor_profile_id", audit.ActorProfileID)
 
-	// Calculate offset based on page and pageSize
-	offset := (page - 1) * pageSize
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	// Base query to get profiles by role
-	baseQuery := `
-	SELECT 
-		p.id, p.username, p.email, p.password_hash, p.first_name, 
-		p.last_name, p.display_name, p.status, p.created_at, 
-		p.updated_by_user_at, p.last_login_at, p.failed_login_attempts, p.verified_at
-	FROM profile_schema.platform_profiles AS p
-	JOIN profile_schema.profile_roles AS pr ON p.id = pr.profile_id
-	WHERE pr.role_id = $1
-	ORDER BY p.created_at DESC
-	LIMIT $2 OFFSET $3`
-
-	// Query to count total profiles with the given role
-	countQuery := `
-	SELECT COUNT(*)
-	FROM profile_schema.platform_profiles AS p
-	JOIN profile_schema.profile_roles AS pr ON p.id = pr.profile_id
-	WHERE pr.role_id = $1`
+	if err := r.deleteMFA(ctx, tx, profileID); err != nil {
+		return err
+	}
 
-	// Get the total count of profiles with the given role
-	var total int
-	err := r.pool.QueryRow(ctx, countQuery, roleID).Scan(&total)
+	if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("MFA reset successfully", "profile_id", profileID, "actor_profile_id", audit.ActorProfileID)
+	return nil
+}
+
+// ReplaceRecoveryCodes discards all recovery codes of a profile and stores new ones
+func (r *PostgresProfileRepository) ReplaceRecoveryCodes(ctx context.Context, profileID uuid.UUID, recoveryCodes []*platform_profile.MFARecoveryCode) error {
+	r.logger.Debug("Starting to replace recovery codes", "profile_id", profileID)
+
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		r.logger.Error("Failed to get profile count by role", "role_id", roleID, "error", err)
-		return nil, 0, fmt.Errorf("failed to get profile count by role: %w", err)
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.replaceRecoveryCodes(ctx, tx, profileID, recoveryCodes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Fetch profiles with the specified role
-	rows, err := r.pool.Query(ctx, baseQuery, roleID, pageSize, offset)
+	r.logger.Info("Recovery codes replaced successfully", "profile_id", profileID, "code_count", len(recoveryCodes))
+	return nil
+}
+
+// GetUnusedRecoveryCodes retrieves the recovery codes of a profile that have not been used yet
+func (r *PostgresProfileRepository) GetUnusedRecoveryCodes(ctx context.Context, profileID uuid.UUID) ([]*platform_profile.MFARecoveryCode, error) {
+	r.logger.Debug("Fetching unused recovery codes", "profile_id", profileID)
+
+	query := `
+	SELECT id, profile_id, code_hash, used_at, created_at
+	FROM profile_schema.mfa_recovery_codes
+	WHERE profile_id = $1 AND used_at IS NULL`
+
+	rows, err := r.pool.Query(ctx, query, profileID)
 	if err != nil {
-		r.logger.Error("Failed to get profiles by role", "role_id", roleID, "error", err)
-		return nil, 0, fmt.Errorf("failed to get profiles by role: %w", err)
+		r.logger.Error("Failed to query recovery codes", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get recovery codes: %w", err)
 	}
 	defer rows.Close()
 
-	// Process the query results
-	profiles := []*platform_profile.PlatformProfile{}
+	var codes []*platform_profile.MFARecoveryCode
 	for rows.Next() {
-		profile := &platform_profile.PlatformProfile{}
-		err := rows.Scan(
-			&profile.ID,
-			&profile.Username,
-			&profile.Email,
-			&profile.PasswordHash,
-			&profile.Status,
-			&profile.VerifiedAt,
-			&profile.LastLoginAt,
-			&profile.FailedLoginAttempts,
-			&profile.CreatedAt,
-			&profile.UpdatedByUserAt,
-			&profile.UpdatedBySystemAt,
-		)
-		if err != nil {
-			r.logger.Error("Failed to scan profile by role", "role_id", roleID, "error", err)
-			return nil, 0, fmt.Errorf("failed to scan profile by role: %w", err)
+		code := &platform_profile.MFARecoveryCode{}
+		if err := rows.Scan(&code.ID, &code.ProfileID, &code.CodeHash, &code.UsedAt, &code.CreatedAt); err != nil {
+			r.logger.Error("Failed to scan recovery code", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan recovery code: %w", err)
 		}
-		profiles = append(profiles, profile)
+		codes = append(codes, code)
 	}
 
-	// Check for iteration errors
 	if err := rows.Err(); err != nil {
-		r.logger.Error("Error iterating over profile rows by role", "role_id", roleID, "error", err)
-		return nil, 0, fmt.Errorf("error iterating over profile rows by role: %w", err)
+		r.logger.Error("Error iterating over recovery code rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over recovery code rows: %w", err)
 	}
 
-	r.logger.Info("Profiles fetched successfully by role", "role_id", roleID, "total_profiles", len(profiles))
-	return profiles, total, nil
+	return codes, nil
 }
 
-// GetProfilesByPreferences retrieves profiles that match specific preferences with pagination
-func (r *PostgresProfileRepository) GetProfilesByPreferences(ctx context.Context, preferences map[string]interface{}, page, pageSize int) ([]*platform_profile.PlatformProfile, int, error) {
-	r.logger.Debug("Fetching profiles by preferences", "preferences", preferences, "page", page, "page_size", pageSize)
+// MarkRecoveryCodeUsed marks a recovery code as used. It returns false if the code was already used.
+func (r *PostgresProfileRepository) MarkRecoveryCodeUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+	UPDATE profile_schema.mfa_recovery_codes SET
+		used_at = NOW()
+	WHERE id = $1 AND used_at IS NULL`
 
-	// Calculate offset for pagination
-	offset := (page - 1) * pageSize
+	commandTag, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Failed to mark recovery code as used", "id", id, "error", err)
+		return false, fmt.Errorf("failed to mark recovery code as used: %w", err)
+	}
 
-	// Base query to get profiles matching preferences
-	baseQuery := `
-	SELECT 
-		p.id, p.username, p.email, p.password_hash, p.first_name, p.last_name,
-		p.display_name, p.status, p.created_at, p.updated_by_user_at, 
-		p.last_login_at, p.failed_login_attempts, p.verified_at
-	FROM profile_schema.platform_profiles p
-	JOIN profile_schema.profile_preferences pref ON p.id = pref.profile_id`
+	return commandTag.RowsAffected() > 0, nil
+}
 
-	// Count query to get the total number of matching profiles
-	countQuery := `
-	SELECT COUNT(*)
-	FROM profile_schema.platform_profiles p
-	JOIN profile_schema.profile_preferences pref ON p.id = pref.profile_id`
+// CreateMFAChallenge stores a pending MFA login challenge
+func (r *PostgresProfileRepository) CreateMFAChallenge(ctx context.Context, challenge *platform_profile.MFAChallenge) error {
+	r.logger.Debug("Creating MFA challenge", "profile_id", challenge.ProfileID)
 
-	// Prepare WHERE clause for preferences
-	whereClause := ""
+	query := `
+	INSERT INTO profile_schema.mfa_challenges (
+		id, profile_id, token_hash, user_agent, ip_address, attempts, expires_at, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		challenge.ID,
+		challenge.ProfileID,
+		challenge.TokenHash,
+		truncateUserAgent(challenge.UserAgent),
+		challenge.IPAddress,
+		challenge.Attempts,
+		challenge.ExpiresAt,
+		challenge.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create MFA challenge", "profile_id", challenge.ProfileID, "error", err)
+		return fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+
+	r.logger.Info("MFA challenge created successfully", "profile_id", challenge.ProfileID)
+	return nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token
+func (r *PostgresProfileRepository) GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*platform_profile.MFAChallenge, error) {
+	query := `
+	SELECT
+		id, profile_id, token_hash, user_agent, ip_address, attempts,
+		expires_at, consumed_at, created_at
+	FROM profile_schema.mfa_challenges
+	WHERE token_hash = $1`
+
+	challenge := &platform_profile.MFAChallenge{}
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&challenge.ID,
+		&challenge.ProfileID,
+		&challenge.TokenHash,
+		&challenge.UserAgent,
+		&challenge.IPAddress,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.ConsumedAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("MFA challenge not found")
+			return nil, ErrMFAChallengeNotFound
+		}
+		r.logger.Error("Failed to fetch MFA challenge", "error", err)
+		return nil, fmt.Errorf("failed to get MFA challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// IncrementMFAChallengeAttempts records a wrong code entered for an MFA challenge
+func (r *PostgresProfileRepository) IncrementMFAChallengeAttempts(ctx context.Context, id uuid.UUID) error {
+	query := `
+	UPDATE profile_schema.mfa_challenges SET
+		attempts = attempts + 1
+	WHERE id = $1`
+
+	if _, err := r.pool.Exec(ctx, query, id); err != nil {
+		r.logger.Error("Failed to increment MFA challenge attempts", "id", id, "error", err)
+		return fmt.Errorf("failed to increment MFA challenge attempts: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeMFAChallenge marks an MFA challenge as completed. It returns false if it was already consumed.
+func (r *PostgresProfileRepository) ConsumeMFAChallenge(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+	UPDATE profile_schema.mfa_challenges SET
+		consumed_at = NOW()
+	WHERE id = $1 AND consumed_at IS NULL`
+
+	commandTag, err := r.pool.Exec(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Failed to consume MFA challenge", "id", id, "error", err)
+		return false, fmt.Errorf("failed to consume MFA challenge: %w", err)
+	}
+
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// CreateAuditLog records an administrative action
+func (r *PostgresProfileRepository) CreateAuditLog(ctx context.Context, audit *platform_profile.AuditLog) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes deletes the recovery codes of a profile and inserts new ones within a transaction
+func (r *PostgresProfileRepository) replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, profileID uuid.UUID, recoveryCodes []*platform_profile.MFARecoveryCode) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.mfa_recovery_codes WHERE profile_id = $1`, profileID); err != nil {
+		r.logger.Error("Failed to delete recovery codes", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for _, code := range recoveryCodes {
+		batch.Queue(`
+		INSERT INTO profile_schema.mfa_recovery_codes (
+			id, profile_id, code_hash, created_at
+		) VALUES (
+			$1, $2, $3, $4
+		)`,
+			code.ID, profileID, code.CodeHash, code.CreatedAt,
+		)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		r.logger.Error("Failed to insert recovery codes", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to insert recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// deleteMFA removes the MFA settings, recovery codes and pending challenges of a profile within a transaction
+func (r *PostgresProfileRepository) deleteMFA(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error {
+	queries := []string{
+		`DELETE FROM profile_schema.mfa_challenges WHERE profile_id = $1`,
+		`DELETE FROM profile_schema.mfa_recovery_codes WHERE profile_id = $1`,
+		`DELETE FROM profile_schema.profile_mfa WHERE profile_id = $1`,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, profileID); err != nil {
+			r.logger.Error("Failed to delete MFA data", "profile_id", profileID, "error", err)
+			return fmt.Errorf("failed to delete MFA data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insertAuditLog inserts an audit log entry within a transaction
+func (r *PostgresProfileRepository) insertAuditLog(ctx context.Context, tx pgx.Tx, audit *platform_profile.AuditLog) error {
+	query := `
+	INSERT INTO profile_schema.audit_logs (
+		id, actor_profile_id, target_profile_id, action, details, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		audit.ID,
+		audit.ActorProfileID,
+		audit.TargetProfileID,
+		audit.Action,
+		audit.Details,
+		audit.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error(
+			"Failed to create audit log",
+			"action", audit.Action,
+			"target_profile_id", audit.TargetProfileID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	r.logger.Info(
+		"Audit log created",
+		"action", audit.Action,
+		"actor_profile_id", audit.ActorProfileID,
+		"target_profile_id", audit.TargetProfileID,
+	)
+	return nil
+}
+
+// deletedProfileColumns are the columns scanned by scanDeletedProfile, in order
+const deletedProfileColumns = `id, username, email, password_hash, status, verified_at,
+	last_login_at, failed_login_attempts, created_at, updated_by_user_at,
+	updated_by_system_at, must_change_password, deleted_at, deleted_by, COALESCE(reason, '')`
+
+// GetSoftDeletedProfileByEmail retrieves a soft-deleted profile by email
+func (r *PostgresProfileRepository) GetSoftDeletedProfileByEmail(ctx context.Context, email string) (*platform_profile.DeletedProfile, error) {
+	r.logger.Debug("Fetching soft-deleted profile by email", "email", email)
+
+	query := `SELECT ` + deletedProfileColumns + ` FROM profile_schema.deleted_profiles WHERE email = $1`
+
+	profile, err := scanDeletedProfile(r.pool.QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("No soft-deleted profile found with the given email", "email", email)
+			return nil, ErrProfileNotFound
+		}
+		r.logger.Error("Failed to fetch soft-deleted profile by email", "email", email, "error", err)
+		return nil, fmt.Errorf("failed to fetch soft-deleted profile: %w", err)
+	}
+
+	r.logger.Info("Soft-deleted profile fetched successfully by email", "email", email)
+	return profile, nil
+}
+
+// GetSoftDeletedProfileByUsername retrieves a soft-deleted profile by username
+func (r *PostgresProfileRepository) GetSoftDeletedProfileByUsername(ctx context.Context, username string) (*platform_profile.DeletedProfile, error) {
+	r.logger.Debug("Fetching soft-deleted profile by username", "username", username)
+
+	query := `SELECT ` + deletedProfileColumns + ` FROM profile_schema.deleted_profiles WHERE username = $1`
+
+	profile, err := scanDeletedProfile(r.pool.QueryRow(ctx, query, username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("No soft-deleted profile found with the given username", "username", username)
+			return nil, ErrProfileNotFound
+		}
+		r.logger.Error("Failed to fetch soft-deleted profile by username", "username", username, "error", err)
+		return nil, fmt.Errorf("failed to fetch soft-deleted profile: %w", err)
+	}
+
+	r.logger.Info("Soft-deleted profile fetched successfully by username", "username", username)
+	return profile, nil
+}
+
+// GetSoftDeletedProfile retrieves a soft-deleted profile by id. It returns nil if the profile is not in the recovery bin.
+func (r *PostgresProfileRepository) GetSoftDeletedProfile(ctx context.Context, id uuid.UUID) (*platform_profile.DeletedProfile, error) {
+	query := `SELECT ` + deletedProfileColumns + ` FROM profile_schema.deleted_profiles WHERE id = $1`
+
+	profile, err := scanDeletedProfile(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to fetch soft-deleted profile", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to fetch soft-deleted profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// ListSoftDeletedProfiles retrieves the recovery bin, most recently deleted first. A non-empty search
+// matches part of the username or the email.
+func (r *PostgresProfileRepository) ListSoftDeletedProfiles(ctx context.Context, search string, access role.AccessScope, offset, limit int) ([]*platform_profile.DeletedProfile, int, error) {
+	r.logger.Debug("Listing soft-deleted profiles", "search", search, "offset", offset, "limit", limit)
+
+	conditions := []string{}
 	args := []interface{}{}
-	paramIndex := 1
+	if search != "" {
+		conditions = append(conditions, `(username ILIKE $1 OR email ILIKE $1)`)
+		args = append(args, fmt.Sprintf("%%%s%%", search))
+	}
+	if condition, scopeArgs := studentScopeCondition("username", access, len(args)+1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, scopeArgs...)
+	}
 
-	if len(preferences) > 0 {
-		whereClause = " WHERE "
-		conditions := []string{}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
 
-		for key, value := range preferences {
-			switch key {
-			case "notifications_email":
-				conditions = append(conditions, fmt.Sprintf("pref.notifications_email = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "notifications_push":
-				conditions = append(conditions, fmt.Sprintf("pref.notifications_push = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "notifications_sms":
-				conditions = append(conditions, fmt.Sprintf("pref.notifications_sms = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "language":
-				conditions = append(conditions, fmt.Sprintf("pref.language ILIKE $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "dark_mode_on":
-				conditions = append(conditions, fmt.Sprintf("pref.dark_mode_on = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "status":
-				conditions = append(conditions, fmt.Sprintf("p.status = $%d", paramIndex))
-				args = append(args, value)
-				paramIndex++
-			case "verified":
-				notStr := ""
-				if value.(bool) {
-					notStr = "NOT"
-				}
-				conditions = append(conditions, fmt.Sprintf("p.verified_at IS %s NULL", notStr))
-			case "search":
-				search := fmt.Sprintf("%%%s%%", value)
-				conditions = append(conditions, fmt.Sprintf("(p.username ILIKE $%d OR p.email ILIKE $%d OR p.first_name ILIKE $%d OR p.last_name ILIKE $%d)",
-					paramIndex, paramIndex+1, paramIndex+2, paramIndex+3))
-				args = append(args, search, search, search, search)
-				paramIndex += 4
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM profile_schema.deleted_profiles`+whereClause, args...).Scan(&total); err != nil {
+		r.logger.Error("Failed to count soft-deleted profiles", "error", err)
+		return nil, 0, fmt.Errorf("failed to count soft-deleted profiles: %w", err)
+	}
+
+	query := `SELECT ` + deletedProfileColumns + ` FROM profile_schema.deleted_profiles` + whereClause +
+		fmt.Sprintf(` ORDER BY deleted_at DESC, id LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := r.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		r.logger.Error("Failed to list soft-deleted profiles", "error", err)
+		return nil, 0, fmt.Errorf("failed to list soft-deleted profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []*platform_profile.DeletedProfile{}
+	for rows.Next() {
+		profile, err := scanDeletedProfile(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan soft-deleted profile", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan soft-deleted profile: %w", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over soft-deleted profile rows", "error", err)
+		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return profiles, total, nil
+}
+
+// RestoreSoftDeletedProfile restores a soft-deleted profile by id along with its student records
+func (r *PostgresProfileRepository) RestoreSoftDeletedProfile(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debug("Starting profile restoration process", "id", id)
+
+	// Begin a transaction
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error(
+			"Failed to start transaction for profile restoration",
+			"id", id,
+			"error", err,
+		)
+		return fmt.Errorf("failed to start transaction for profile restoration: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Restore profile from deleted_profiles to platform_profiles
+	restoreQuery := `
+	INSERT INTO profile_schema.platform_profiles (
+		id, username, email, password_hash, status, verified_at,
+		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
+		updated_by_system_at, must_change_password
+	)
+	SELECT
+		id, username, email, password_hash, status, verified_at,
+		last_login_at, failed_login_attempts, created_at, updated_by_user_at,
+		NOW(), must_change_password
+	FROM profile_schema.deleted_profiles
+	WHERE id = $1
+	ON CONFLICT (id) DO NOTHING
+	RETURNING username
+	`
+
+	var username string
+	err = tx.QueryRow(ctx, restoreQuery, id).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("No soft-deleted profile found for restoration", "id", id)
+			return ErrProfileNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			r.logger.Warn("Username or email of the soft-deleted profile is taken", "id", id, "constraint", pgErr.ConstraintName)
+			if strings.Contains(pgErr.ConstraintName, "email") {
+				return ErrEmailAlreadyExists
 			}
+			return ErrUsernameAlreadyExists
 		}
+		r.logger.Error("Failed to restore profile from deleted_profiles", "id", id, "error", err)
+		return fmt.Errorf("failed to restore profile: %w", err)
+	}
+
+	// Delete the restored profile from deleted_profiles
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.deleted_profiles WHERE id = $1`, id); err != nil {
+		r.logger.Error(
+			"Failed to delete restored profile from deleted_profiles",
+			"id", id,
+			"error", err,
+		)
+		return fmt.Errorf("failed to delete restored profile from deleted_profiles: %w", err)
+	}
+
+	// Bring back the student records soft deleted with the profile
+	if _, err := tx.Exec(ctx, `
+	UPDATE public.enrollment_master_lookup_table SET
+		DeletedAt = NULL
+	WHERE ProfileID = $1`,
+		id,
+	); err != nil {
+		r.logger.Error("Failed to restore student records", "id", id, "error", err)
+		return fmt.Errorf("failed to restore student records: %w", err)
+	}
+
+	// Commit transaction after successful restoration and deletion
+	if err = tx.Commit(ctx); err != nil {
+		r.logger.Error(
+			"Failed to commit transaction for profile restoration",
+			"id", id,
+			"error", err,
+		)
+		return fmt.Errorf("failed to commit transaction for profile restoration: %w", err)
+	}
+
+	r.logger.Info("Profile restoration completed successfully", "id", id, "username", username)
+	return nil
+}
+
+// HardDeleteProfile removes a profile from the deleted_profiles table in profile_schema,
+// along with its student records and everything else kept for it
+func (r *PostgresProfileRepository) HardDeleteProfile(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debug("Starting hard delete process for profile", "id", id)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to start transaction for hard delete", "id", id, "error", err)
+		return fmt.Errorf("failed to start transaction for hard delete: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+	err = tx.QueryRow(ctx, `DELETE FROM profile_schema.deleted_profiles WHERE id = $1 RETURNING username`, id).Scan(&username)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Profile not found in deleted_profiles for hard delete", "id", id)
+			return ErrProfileNotFound
+		}
+		r.logger.Error("Failed to delete profile from deleted_profiles", "id", id, "error", err)
+		return fmt.Errorf("failed to delete profile from deleted_profiles: %w", err)
+	}
+
+	if err := purgeProfileDataTx(ctx, tx, id, username); err != nil {
+		r.logger.Error("Failed to purge profile data", "id", id, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction for hard delete", "id", id, "error", err)
+		return fmt.Errorf("failed to commit transaction for hard delete: %w", err)
+	}
+
+	r.logger.Info("Profile successfully deleted from deleted_profiles", "id", id)
+	return nil
+}
+
+// PurgeSoftDeletedProfiles permanently deletes up to limit profiles that entered the recovery bin
+// before deletedBefore. Each purge is recorded in the audit log first, in the same transaction.
+// Rows locked by a concurrent purge are skipped.
+func (r *PostgresProfileRepository) PurgeSoftDeletedProfiles(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	r.logger.Debug("Purging soft-deleted profiles", "deleted_before", deletedBefore, "limit", limit)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to start transaction for purge", "error", err)
+		return 0, fmt.Errorf("failed to start transaction for purge: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + deletedProfileColumns + `
+	FROM profile_schema.deleted_profiles
+	WHERE deleted_at < $1
+	ORDER BY deleted_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(ctx, query, deletedBefore, limit)
+	if err != nil {
+		r.logger.Error("Failed to select soft-deleted profiles for purge", "error", err)
+		return 0, fmt.Errorf("failed to select soft-deleted profiles: %w", err)
+	}
+
+	var expired []*platform_profile.DeletedProfile
+	for rows.Next() {
+		profile, err := scanDeletedProfile(rows)
+		if err != nil {
+			rows.Close()
+			r.logger.Error("Failed to scan soft-deleted profile", "error", err)
+			return 0, fmt.Errorf("failed to scan soft-deleted profile: %w", err)
+		}
+		expired = append(expired, profile)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over soft-deleted profile rows", "error", err)
+		return 0, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	now := time.Now()
+	for _, profile := range expired {
+		audit := &platform_profile.AuditLog{
+			ID:              uuid.New(),
+			ActorProfileID:  platform_profile.SystemActorID,
+			TargetProfileID: profile.ID,
+			Action:          platform_profile.AuditActionProfilePurge,
+			Details: map[string]interface{}{
+				"username":   profile.Username,
+				"email":      profile.Email,
+				"deleted_at": profile.DeletedAt,
+				"deleted_by": profile.DeletedBy,
+				"reason":     profile.Reason,
+			},
+			CreatedAt: now,
+		}
+		if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+			return 0, err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.deleted_profiles WHERE id = $1`, profile.ID); err != nil {
+			r.logger.Error("Failed to purge soft-deleted profile", "id", profile.ID, "error", err)
+			return 0, fmt.Errorf("failed to purge soft-deleted profile: %w", err)
+		}
+
+		if err := purgeProfileDataTx(ctx, tx, profile.ID, profile.Username); err != nil {
+			r.logger.Error("Failed to purge profile data", "id", profile.ID, "error", err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction for purge", "error", err)
+		return 0, fmt.Errorf("failed to commit transaction for purge: %w", err)
+	}
+
+	if len(expired) > 0 {
+		r.logger.Info("Soft-deleted profiles purged", "purged", len(expired), "deleted_before", deletedBefore)
+	}
+	return len(expired), nil
+}
+
+// profileDataTables hold the rows kept for a profile by profile_id. Their rows survive a soft delete
+// so a restored profile keeps its roles, MFA and API keys, and go away when the profile is purged.
+var profileDataTables = []string{
+	"profile_schema.refresh_tokens",
+	"profile_schema.sessions",
+	"profile_schema.mfa_recovery_codes",
+	"profile_schema.mfa_challenges",
+	"profile_schema.profile_mfa",
+	"profile_schema.account_lockouts",
+	"profile_schema.email_verification_tokens",
+	"profile_schema.password_reset_tokens",
+	"profile_schema.password_history",
+	"profile_schema.linked_identities",
+	"profile_schema.api_keys",
+	"profile_schema.profile_roles",
+	"profile_schema.profile_preferences",
+}
+
+// purgeProfileDataTx permanently deletes the data kept for a profile and the student records linked
+// to its username, inside a transaction
+func purgeProfileDataTx(ctx context.Context, tx pgx.Tx, profileID uuid.UUID, username string) error {
+	for _, table := range profileDataTables {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE profile_id = $1`, profileID); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+	}
+
+	return deleteStudentRecordsTx(ctx, tx, username)
+}
+
+// scanDeletedProfile scans a row selected with deletedProfileColumns
+func scanDeletedProfile(row pgx.Row) (*platform_profile.DeletedProfile, error) {
+	profile := &platform_profile.DeletedProfile{}
+	err := row.Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.Email,
+		&profile.PasswordHash,
+		&profile.Status,
+		&profile.VerifiedAt,
+		&profile.LastLoginAt,
+		&profile.FailedLoginAttempts,
+		&profile.CreatedAt,
+		&profile.UpdatedByUserAt,
+		&profile.UpdatedBySystemAt,
+		&profile.MustChangePassword,
+		&profile.DeletedAt,
+		&profile.DeletedBy,
+		&profile.Reason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// AssignRoleToProfile assigns a role to a profile in the profile_roles table
+func (r *PostgresProfileRepository) AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
+
+	r.logger.Debug(
+		"Starting to assign role to profile",
+		"profile_id",
+		profileID,
+		"role_id",
+		roleID,
+	)
+
+	query := `
+	INSERT INTO profile_schema.profile_roles (
+		profile_id, role_id, created_at
+	) VALUES (
+		$1, $2, $3
+	) ON CONFLICT (profile_id, role_id, scope_branch, scope_batch) DO NOTHING
+	`
+
+	// Execute the query to assign the role
+	_, err := r.pool.Exec(ctx, query, profileID, roleID, time.Now())
+	if err != nil {
+		r.logger.Error(
+			"Failed to assign role to profile",
+			"profile_id", profileID,
+			"role_id", roleID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to assign role to profile: %w", err)
+	}
+
+	r.logger.Info("Role assigned to profile successfully", "profile_id", profileID, "role_id", roleID)
+	return nil
+}
+
+// GetProfileRoles retrieves all roles assigned to a profile
+func (r *PostgresProfileRepository) GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]uuid.UUID, error) {
+	r.logger.Debug("Fetching roles assigned to profile", "profile_id", profileID)
+
+	query := `
+	SELECT role_id
+	FROM profile_schema.profile_roles
+	WHERE profile_id = $1`
+
+	// Query to get roles
+	rows, err := r.pool.Query(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to query profile roles", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get profile roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roleIDs []uuid.UUID
+
+	// Iterate over the result set
+	for rows.Next() {
+		var roleID uuid.UUID
+		if err := rows.Scan(&roleID); err != nil {
+			r.logger.Error("Failed to scan role ID", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan role ID: %w", err)
+		}
+		roleIDs = append(roleIDs, roleID)
+	}
+
+	// Check for errors after iteration
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over role rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over role rows: %w", err)
+	}
+
+	// Log if no roles are found
+	if len(roleIDs) == 0 {
+		r.logger.Warn("No roles found for profile", "profile_id", profileID)
+	}
+
+	r.logger.Info("Roles retrieved successfully for profile", "profile_id", profileID, "role_count", len(roleIDs))
+	return roleIDs, nil
+}
+
+// GetProfileRoleNames retrieves the names of all roles assigned to a profile
+func (r *PostgresProfileRepository) GetProfileRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error) {
+	r.logger.Debug("Fetching role names assigned to profile", "profile_id", profileID)
+
+	query := `
+	SELECT r.name
+	FROM profile_schema.profile_roles pr
+	JOIN profile_schema.roles r ON r.id = pr.role_id
+	WHERE pr.profile_id = $1
+	ORDER BY r.name`
+
+	rows, err := r.pool.Query(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to query profile role names", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get profile role names: %w", err)
+	}
+	defer rows.Close()
+
+	var roleNames []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			r.logger.Error("Failed to scan role name", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan role name: %w", err)
+		}
+		roleNames = append(roleNames, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over role name rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over role name rows: %w", err)
+	}
+
+	r.logger.Info("Role names retrieved successfully for profile", "profile_id", profileID, "role_count", len(roleNames))
+	return roleNames, nil
+}
+
+// RemoveRoleFromProfile removes a role from a profile
+func (r *PostgresProfileRepository) RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
+	r.logger.Debug("Starting to remove role from profile", "profile_id", profileID, "role_id", roleID)
+
+	query := `
+	DELETE FROM profile_schema.profile_roles
+	WHERE profile_id = $1 AND role_id = $2`
+
+	// Execute the query to remove the role
+	commandTag, err := r.pool.Exec(ctx, query, profileID, roleID)
+	if err != nil {
+		r.logger.Error(
+			"Failed to remove role from profile",
+			"profile_id", profileID,
+			"role_id", roleID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to remove role from profile: %w", err)
+	}
+
+	// Check if any rows were affected
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn(
+			"Role not found for profile or already removed",
+			"profile_id", profileID,
+			"role_id", roleID,
+		)
+		return ErrRoleNotAssigned
+	}
+
+	r.logger.Info("Role removed successfully from profile", "profile_id", profileID, "role_id", roleID)
+	return nil
+}
+
+// TODO: 5
+// SavePreferences saves profile preferences
+func (r *PostgresProfileRepository) SavePreferences(ctx context.Context, prefs *platform_profile.ProfilePreference) error {
+
+	r.logger.Debug(
+		"Saving profile preferences",
+		"profileID", prefs.ProfileID,
+	)
+
+	// Check if preference already exists
+	existingPrefs, err := r.GetPreferences(ctx, prefs.ProfileID)
+
+	if err != nil && !errors.Is(err, ErrProfileNotFound) {
+		r.logger.Error(
+			"Failed to check existing preferences",
+			"profileID", prefs.ProfileID,
+			"error", err,
+		)
+		return err
+	}
+
+	var query string
+	if existingPrefs != nil {
+		// Update existing preferences
+		query = `
+		UPDATE profile_schema.profile_preferences SET 
+			notifications_email = $1, 			
+			updated_at = $2 
+		WHERE profile_id = $3`
+
+		_, err = r.pool.Exec(
+			ctx,
+			query,
+			prefs.NotificationsEmail,
+			time.Now(),
+			prefs.ProfileID,
+		)
+	} else {
+		// Insert new preferences
+		query = `
+		INSERT INTO profile_schema.profile_preferences ( 
+			id, profile_id, notifications_email, created_at, updated_at 
+		) VALUES ( 
+			$1, $2, $3, $4, $5
+		)`
+
+		now := time.Now()
+
+		// Generate a new UUID if ID is not provided
+		if prefs.ID == uuid.Nil {
+			prefs.ID = uuid.New()
+		}
+
+		_, err = r.pool.Exec(
+			ctx,
+			query,
+			prefs.ID,
+			prefs.ProfileID,
+			prefs.NotificationsEmail,
+			now,
+			now,
+		)
+	}
+
+	if err != nil {
+		r.logger.Error(
+			"Failed to save profile preferences",
+			"profileID", prefs.ProfileID,
+			"error", err,
+		)
+		return fmt.Errorf("failed to save profile preferences: %w", err)
+	}
+
+	r.logger.Info(
+		"Profile preferences saved successfully",
+		"profileID", prefs.ProfileID,
+	)
+
+	return nil
+}
+
+// TODO: 5
+// GetPreferences retrieves profile preferences
+func (r *PostgresProfileRepository) GetPreferences(ctx context.Context, profileID uuid.UUID) (*platform_profile.ProfilePreference, error) {
+
+	r.logger.Debug(
+		"Getting profile preferences",
+		"profileID", profileID,
+	)
+
+	query := `
+	SELECT  
+		id,
+		profile_id, 
+		notifications_email,
+		created_at, 
+		updated_at 
+	FROM profile_schema.profile_preferences  
+	WHERE profile_id = $1`
+
+	prefs := &platform_profile.ProfilePreference{}
+	err := r.pool.QueryRow(ctx, query, profileID).Scan(
+		&prefs.ID,
+		&prefs.ProfileID,
+		&prefs.NotificationsEmail,
+		&prefs.CreatedAt,
+		&prefs.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Debug(
+				"Profile preferences not found",
+				"profileID", profileID,
+			)
+			return nil, ErrProfileNotFound
+		}
+		r.logger.Error(
+			"Failed to get profile preferences",
+			"profileID", profileID,
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed to get profile preferences: %w", err)
+	}
+
+	r.logger.Debug(
+		"Profile preferences retrieved successfully",
+		"profileID", profileID,
+	)
+
+	return prefs, nil
+}
+
+// profileColumns are the columns scanned by scanProfile, in order
+const profileColumns = `id, username, email, password_hash, status, verified_at,
+	last_login_at, failed_login_attempts, created_at, updated_by_user_at,
+	updated_by_system_at, must_change_password`
+
+// GetProfiles retrieves profiles with pagination and filtering. The "scope" filter takes a
+// role.AccessScope and limits the profiles to those of the students within it.
+func (r *PostgresProfileRepository) GetProfiles(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*platform_profile.PlatformProfile, int, error) {
+	r.logger.Debug("Fetching profiles with pagination and filters", "offset", offset, "limit", limit, "filters", filters)
+
+	// Base queries for profiles and count
+	baseQuery := `SELECT ` + profileColumns + ` FROM profile_schema.platform_profiles`
+	countQuery := `SELECT COUNT(*) FROM profile_schema.platform_profiles`
+
+	// Build WHERE clause for filters
+	whereClause := ""
+	args := []interface{}{}
+	paramIndex := 1
+
+	if len(filters) > 0 {
+		conditions := []string{}
+
+		for key, value := range filters {
+			switch key {
+			case "status":
+				conditions = append(conditions, fmt.Sprintf("status = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "verified":
+				conditions = append(conditions, fmt.Sprintf("verified_at IS NOT NULL AND verified_at <= $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "search":
+				conditions = append(conditions, fmt.Sprintf("(username ILIKE $%d OR email ILIKE $%d)", paramIndex, paramIndex))
+				args = append(args, fmt.Sprintf("%%%s%%", value))
+				paramIndex++
+			case "role_id":
+				conditions = append(conditions, fmt.Sprintf("id IN (SELECT profile_id FROM profile_schema.profile_roles WHERE role_id = $%d)", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "scope":
+				access, ok := value.(role.AccessScope)
+				if !ok {
+					return nil, 0, fmt.Errorf("invalid scope filter %T", value)
+				}
+				if condition, scopeArgs := studentScopeCondition("username", access, paramIndex); condition != "" {
+					conditions = append(conditions, condition)
+					args = append(args, scopeArgs...)
+					paramIndex += len(scopeArgs)
+				}
+			case "created_after":
+				conditions = append(conditions, fmt.Sprintf("created_at > $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "created_before":
+				conditions = append(conditions, fmt.Sprintf("created_at < $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			}
+		}
+
+		if len(conditions) > 0 {
+			whereClause = " WHERE " + strings.Join(conditions, " AND ")
+		}
+	}
+
+	// Build final queries
+	dataQuery := baseQuery + whereClause + fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
+	countQueryFinal := countQuery + whereClause
+
+	// Get total count of records
+	var total int
+	err := r.pool.QueryRow(ctx, countQueryFinal, args...).Scan(&total)
+	if err != nil {
+		r.logger.Error("Failed to get profiles count", "error", err)
+		return nil, 0, fmt.Errorf("failed to get profiles count: %w", err)
+	}
+
+	// Execute data query
+	rows, err := r.pool.Query(ctx, dataQuery, append(args, limit, offset)...)
+	if err != nil {
+		r.logger.Error("Failed to get profiles", "error", err)
+		return nil, 0, fmt.Errorf("failed to get profiles: %w", err)
+	}
+	defer rows.Close()
+
+	// Process query results
+	profiles := []*platform_profile.PlatformProfile{}
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan profile", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan profile: %w", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over profile rows", "error", err)
+		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	r.logger.Info("Profiles fetched successfully", "total_profiles", len(profiles))
+	return profiles, total, nil
+}
+
+// GetProfilesByRole retrieves profiles assigned to a specific role with pagination
+func (r *PostgresProfileRepository) GetProfilesByRole(ctx context.Context, roleID uuid.UUID, page, pageSize int) ([]*platform_profile.PlatformProfile, int, error) {
+	r.logger.Debug("Fetching profiles by role", "role_id", roleID, "page", page, "page_size", pageSize)
+
+	return r.GetProfiles(ctx, (page-1)*pageSize, pageSize, map[string]interface{}{"role_id": roleID})
+}
+
+// ProfilesInScope returns the profiles among profileIDs that belong to students within the access scope.
+// Profiles in the recovery bin are included.
+func (r *PostgresProfileRepository) ProfilesInScope(ctx context.Context, profileIDs []uuid.UUID, access role.AccessScope) ([]uuid.UUID, error) {
+	if access.Unrestricted {
+		return profileIDs, nil
+	}
+
+	condition, scopeArgs := studentScopeCondition("p.username", access, 2)
+	query := `
+	SELECT p.id
+	FROM (
+		SELECT id, username FROM profile_schema.platform_profiles
+		UNION ALL
+		SELECT id, username FROM profile_schema.deleted_profiles
+	) p
+	WHERE p.id = ANY($1) AND ` + condition
+
+	rows, err := r.pool.Query(ctx, query, append([]interface{}{profileIDs}, scopeArgs...)...)
+	if err != nil {
+		r.logger.Error("Failed to check profile scope", "error", err)
+		return nil, fmt.Errorf("failed to check profile scope: %w", err)
+	}
+	defer rows.Close()
+
+	inScope := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			r.logger.Error("Failed to scan profile ID", "error", err)
+			return nil, fmt.Errorf("failed to scan profile ID: %w", err)
+		}
+		inScope = append(inScope, id)
+	}
+
+	return inScope, rows.Err()
+}
+
+// scanProfile scans a row selected with profileColumns
+func scanProfile(row pgx.Row) (*platform_profile.PlatformProfile, error) {
+	profile := &platform_profile.PlatformProfile{}
+	err := row.Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.Email,
+		&profile.PasswordHash,
+		&profile.Status,
+		&profile.VerifiedAt,
+		&profile.LastLoginAt,
+		&profile.FailedLoginAttempts,
+		&profile.CreatedAt,
+		&profile.UpdatedByUserAt,
+		&profile.UpdatedBySystemAt,
+		&profile.MustChangePassword,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// GetProfilesByPreferences retrieves profiles that match specific preferences with pagination
+func (r *PostgresProfileRepository) GetProfilesByPreferences(ctx context.Context, preferences map[string]interface{}, page, pageSize int) ([]*platform_profile.PlatformProfile, int, error) {
+	r.logger.Debug("Fetching profiles by preferences", "preferences", preferences, "page", page, "page_size", pageSize)
+
+	// Calculate offset for pagination
+	offset := (page - 1) * pageSize
+
+	// Base query to get profiles matching preferences
+	baseQuery := `
+	SELECT 
+		p.id, p.username, p.email, p.password_hash, p.first_name, p.last_name,
+		p.display_name, p.status, p.created_at, p.updated_by_user_at, 
+		p.last_login_at, p.failed_login_attempts, p.verified_at
+	FROM profile_schema.platform_profiles p
+	JOIN profile_schema.profile_preferences pref ON p.id = pref.profile_id`
+
+	// Count query to get the total number of matching profiles
+	countQuery := `
+	SELECT COUNT(*)
+	FROM profile_schema.platform_profiles p
+	JOIN profile_schema.profile_preferences pref ON p.id = pref.profile_id`
+
+	// Prepare WHERE clause for preferences
+	whereClause := ""
+	args := []interface{}{}
+	paramIndex := 1
+
+	if len(preferences) > 0 {
+		whereClause = " WHERE "
+		conditions := []string{}
+
+		for key, value := range preferences {
+			switch key {
+			case "notifications_email":
+				conditions = append(conditions, fmt.Sprintf("pref.notifications_email = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "notifications_push":
+				conditions = append(conditions, fmt.Sprintf("pref.notifications_push = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "notifications_sms":
+				conditions = append(conditions, fmt.Sprintf("pref.notifications_sms = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "language":
+				conditions = append(conditions, fmt.Sprintf("pref.language ILIKE $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "dark_mode_on":
+				conditions = append(conditions, fmt.Sprintf("pref.dark_mode_on = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "status":
+				conditions = append(conditions, fmt.Sprintf("p.status = $%d", paramIndex))
+				args = append(args, value)
+				paramIndex++
+			case "verified":
+				notStr := ""
+				if value.(bool) {
+					notStr = "NOT"
+				}
+				conditions = append(conditions, fmt.Sprintf("p.verified_at IS %s NULL", notStr))
+			case "search":
+				search := fmt.Sprintf("%%%s%%", value)
+				conditions = append(conditions, fmt.Sprintf("(p.username ILIKE $%d OR p.email ILIKE $%d OR p.first_name ILIKE $%d OR p.last_name ILIKE $%d)",
+					paramIndex, paramIndex+1, paramIndex+2, paramIndex+3))
+				args = append(args, search, search, search, search)
+				paramIndex += 4
+			}
+		}
+
+		whereClause += strings.Join(conditions, " AND ")
+	}
+
+	// Complete data and count queries
+	dataQuery := baseQuery + whereClause + fmt.Sprintf(" ORDER BY p.created_at DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
+	countQueryFinal := countQuery + whereClause
+
+	// Add pagination parameters
+	args = append(args, pageSize, offset)
+
+	// Get total count of matching profiles
+	var total int
+	err := r.pool.QueryRow(ctx, countQueryFinal, args[:len(args)-2]...).Scan(&total)
+	if err != nil {
+		r.logger.Error("Failed to get profile count by preferences", "preferences", preferences, "error", err)
+		return nil, 0, fmt.Errorf("failed to get profile count by preferences: %w", err)
+	}
+
+	// Execute query to fetch profiles
+	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	if err != nil {
+		r.logger.Error("Failed to get profiles by preferences", "preferences", preferences, "error", err)
+		return nil, 0, fmt.Errorf("failed to get profiles by preferences: %w", err)
+	}
+	defer rows.Close()
+
+	// Process query results
+	profiles := []*platform_profile.PlatformProfile{}
+	for rows.Next() {
+		profile := &platform_profile.PlatformProfile{}
+		err := rows.Scan(
+			&profile.ID,
+			&profile.Username,
+			&profile.Email,
+			&profile.PasswordHash,
+			&profile.Status,
+			&profile.VerifiedAt,
+			&profile.LastLoginAt,
+			&profile.FailedLoginAttempts,
+			&profile.CreatedAt,
+			&profile.UpdatedByUserAt,
+			&profile.UpdatedBySystemAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan profile by preferences", "preferences", preferences, "error", err)
+			return nil, 0, fmt.Errorf("failed to scan profile by preferences: %w", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	// Check for iteration errors
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over profile rows by preferences", "preferences", preferences, "error", err)
+		return nil, 0, fmt.Errorf("error iterating over profile rows by preferences: %w", err)
+	}
+
+	r.logger.Info("Profiles fetched successfully by preferences", "total_profiles", len(profiles))
+	return profiles, total, nil
+}
+
+// DeleteProfiles deletes multiple profiles either as a hard delete or a soft delete
+func (r *PostgresProfileRepository) DeleteProfiles(ctx context.Context, profileIDs []uuid.UUID, hardDelete bool) error {
+	r.logger.Debug("Starting profile deletion process", "hard_delete", hardDelete, "profile_ids", profileIDs)
+
+	if len(profileIDs) == 0 {
+		r.logger.Warn("No profile IDs provided for deletion")
+		return nil
+	}
+
+	// Prepare query placeholders for IN clause
+	placeholders := make([]string, len(profileIDs))
+	args := make([]interface{}, len(profileIDs))
+	for i, id := range profileIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	idList := strings.Join(placeholders, ", ")
+
+	var query string
+	var err error
+
+	if hardDelete {
+		// Hard delete: Permanently remove profiles and related records
+		query = fmt.Sprintf(`
+		DELETE FROM profile_schema.platform_profiles
+		WHERE id IN (%s)`, idList)
+
+		_, err = r.pool.Exec(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("Failed to hard delete profiles", "error", err, "profile_ids", profileIDs)
+			return fmt.Errorf("failed to hard delete profiles: %w", err)
+		}
+		r.logger.Info("Profiles permanently deleted successfully", "profile_ids", profileIDs)
+	} else {
+		// Soft delete: Mark profiles as deleted and store them in deleted_profiles
+		tx, err := r.pool.Begin(ctx)
+		if err != nil {
+			r.logger.Error("Failed to start transaction for soft delete", "error", err)
+			return fmt.Errorf("failed to start transaction for soft delete: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				_ = tx.Rollback(ctx)
+			}
+		}()
+
+		// Move profiles to deleted_profiles table before marking them as deleted
+		moveQuery := fmt.Sprintf(`
+		INSERT INTO profile_schema.deleted_profiles (
+			id, username, email, password_hash, first_name, last_name, 
+			display_name, status, created_at, updated_by_user_at, 
+			last_login_at, failed_login_attempts, verified_at, 
+			deleted_at
+		)
+		SELECT 
+			id, username, email, password_hash, first_name, last_name, 
+			display_name, status, created_at, updated_by_user_at, 
+			last_login_at, failed_login_attempts, verified_at, 
+			NOW()
+		FROM profile_schema.platform_profiles
+		WHERE id IN (%s)`, idList)
+
+		_, err = tx.Exec(ctx, moveQuery, args...)
+		if err != nil {
+			r.logger.Error("Failed to move profiles to deleted_profiles for soft delete", "error", err, "profile_ids", profileIDs)
+			return fmt.Errorf("failed to move profiles to deleted_profiles: %w", err)
+		}
+
+		// Commit the transaction
+		err = tx.Commit(ctx)
+		if err != nil {
+			r.logger.Error("Failed to commit transaction for soft delete", "error", err)
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		r.logger.Info("Profiles soft deleted successfully and moved to deleted_profiles", "profile_ids", profileIDs)
+	}
+
+	return nil
+}
+
+// profileExistsTx reports whether a profile exists, inside a transaction
+func profileExistsTx(ctx context.Context, tx pgx.Tx, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM profile_schema.platform_profiles WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// BulkCreateProfiles creates many profiles along with their password history.
+// Profiles whose username or email is taken are skipped as duplicates.
+func (r *PostgresProfileRepository) BulkCreateProfiles(ctx context.Context, profiles []*platform_profile.PlatformProfile, dryRun bool) ([]platform_profile.BulkItemResult, error) {
+	r.logger.Debug("Starting bulk profile creation", "rows", len(profiles), "dry_run", dryRun)
+
+	profileQuery := `
+	INSERT INTO profile_schema.platform_profiles (
+		id, username, email, password_hash, status, verified_at,
+		failed_login_attempts, created_at, updated_by_user_at,
+		updated_by_system_at, must_change_password
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	)`
+
+	historyQuery := `
+	INSERT INTO profile_schema.password_history (id, profile_id, password_hash, created_at)
+	VALUES ($1, $2, $3, $4)`
+
+	return runBulk(ctx, r.pool, r.logger, "create_profiles", len(profiles), dryRun, func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult {
+		profile := profiles[index]
+		result := platform_profile.BulkItemResult{ProfileID: &profile.ID, Username: profile.Username}
+
+		_, err := tx.Exec(
+			ctx,
+			profileQuery,
+			profile.ID,
+			profile.Username,
+			profile.Email,
+			profile.PasswordHash,
+			profile.Status,
+			profile.VerifiedAt,
+			profile.FailedLoginAttempts,
+			profile.CreatedAt,
+			profile.UpdatedByUserAt,
+			profile.UpdatedBySystemAt,
+			profile.MustChangePassword,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				result.ProfileID = nil
+				result.Status = platform_profile.BulkItemSkipped
+				switch pgErr.ConstraintName {
+				case "platform_profiles_username_key":
+					result.Reason = "username already exists"
+				case "platform_profiles_email_key":
+					result.Reason = "email already exists"
+				default:
+					result.Reason = "profile already exists"
+				}
+				return result
+			}
+			return bulkFailure(r.logger, "create_profiles", result, "failed to create profile", err)
+		}
+
+		if _, err := tx.Exec(ctx, historyQuery, uuid.New(), profile.ID, profile.PasswordHash, profile.CreatedAt); err != nil {
+			return bulkFailure(r.logger, "create_profiles", result, "failed to record password history", err)
+		}
+
+		result.Status = platform_profile.BulkItemCreated
+		return result
+	})
+}
+
+// BulkUpdateStatus changes the status of many profiles. Suspending or locking a profile ends its
+// sessions, a lock set this way has no expiry and lasts until an administrator lifts it.
+// Profiles already in the status are skipped.
+func (r *PostgresProfileRepository) BulkUpdateStatus(ctx context.Context, profileIDs []uuid.UUID, status platform_profile.Status, dryRun bool) ([]platform_profile.BulkItemResult, error) {
+	r.logger.Debug("Starting bulk status change", "rows", len(profileIDs), "status", status, "dry_run", dryRun)
+
+	statusQuery := `
+	UPDATE profile_schema.platform_profiles SET
+		status = $1,
+		updated_by_user_at = $2,
+		updated_by_system_at = $2
+	WHERE id = $3 AND status <> $1`
+
+	lockoutQuery := `
+	INSERT INTO profile_schema.account_lockouts (
+		profile_id, lock_count, locked_until, last_locked_at, updated_at
+	) VALUES (
+		$1, 0, NULL, $2, $2
+	)
+	ON CONFLICT (profile_id) DO UPDATE SET
+		locked_until = NULL,
+		last_locked_at = EXCLUDED.last_locked_at,
+		updated_at = EXCLUDED.updated_at`
+
+	return runBulk(ctx, r.pool, r.logger, "update_status", len(profileIDs), dryRun, func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult {
+		id := profileIDs[index]
+		result := platform_profile.BulkItemResult{ProfileID: &id}
+		now := time.Now()
+
+		commandTag, err := tx.Exec(ctx, statusQuery, status, now, id)
+		if err != nil {
+			return bulkFailure(r.logger, "update_status", result, "failed to update status", err)
+		}
+
+		if commandTag.RowsAffected() == 0 {
+			exists, err := profileExistsTx(ctx, tx, id)
+			if err != nil {
+				return bulkFailure(r.logger, "update_status", result, "failed to update status", err)
+			}
+			if !exists {
+				result.Status = platform_profile.BulkItemFailed
+				result.Reason = "profile not found"
+				return result
+			}
+			result.Status = platform_profile.BulkItemSkipped
+			result.Reason = fmt.Sprintf("profile is already %s", status)
+			return result
+		}
+
+		switch status {
+		case platform_profile.StatusLocked:
+			if _, err := tx.Exec(ctx, lockoutQuery, id, now); err != nil {
+				return bulkFailure(r.logger, "update_status", result, "failed to store account lockout", err)
+			}
+			fallthrough
+		case platform_profile.StatusSuspended:
+			if err := revokeProfileSessionsTx(ctx, tx, id); err != nil {
+				return bulkFailure(r.logger, "update_status", result, "failed to end sessions", err)
+			}
+		case platform_profile.StatusActivated:
+			if err := r.deleteAccountLockout(ctx, tx, id); err != nil {
+				return bulkFailure(r.logger, "update_status", result, "failed to clear account lockout", err)
+			}
+		}
+
+		result.Status = platform_profile.BulkItemUpdated
+		return result
+	})
+}
+
+// BulkAssignRole assigns a role to many profiles. Profiles that already have the role are skipped.
+func (r *PostgresProfileRepository) BulkAssignRole(ctx context.Context, profileIDs []uuid.UUID, roleID uuid.UUID, scope role.Scope, dryRun bool) ([]platform_profile.BulkItemResult, error) {
+	r.logger.Debug("Starting bulk role assignment", "rows", len(profileIDs), "role_id", roleID, "scope", scope, "dry_run", dryRun)
+
+	query := `
+	INSERT INTO profile_schema.profile_roles (
+		profile_id, role_id, scope_branch, scope_batch, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) ON CONFLICT (profile_id, role_id, scope_branch, scope_batch) DO NOTHING`
+
+	return runBulk(ctx, r.pool, r.logger, "assign_role", len(profileIDs), dryRun, func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult {
+		id := profileIDs[index]
+		result := platform_profile.BulkItemResult{ProfileID: &id}
+
+		exists, err := profileExistsTx(ctx, tx, id)
+		if err != nil {
+			return bulkFailure(r.logger, "assign_role", result, "failed to assign role", err)
+		}
+		if !exists {
+			result.Status = platform_profile.BulkItemFailed
+			result.Reason = "profile not found"
+			return result
+		}
+
+		commandTag, err := tx.Exec(ctx, query, id, roleID, scope.Branch, scope.Batch, time.Now())
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				result.Status = platform_profile.BulkItemFailed
+				result.Reason = "role not found"
+				return result
+			}
+			return bulkFailure(r.logger, "assign_role", result, "failed to assign role", err)
+		}
+
+		if commandTag.RowsAffected() == 0 {
+			result.Status = platform_profile.BulkItemSkipped
+			result.Reason = "role already assigned"
+			return result
+		}
+
+		result.Status = platform_profile.BulkItemUpdated
+		return result
+	})
+}
+
+// BulkDeleteProfiles deletes many profiles. Soft deleted profiles are moved to the recovery bin
+// like SoftDeleteProfile does, hard deleted ones are removed for good along with their student records.
+func (r *PostgresProfileRepository) BulkDeleteProfiles(ctx context.Context, profileIDs []uuid.UUID, hardDelete bool, deletedBy uuid.UUID, reason string, dryRun bool) ([]platform_profile.BulkItemResult, error) {
+	r.logger.Debug("Starting bulk profile deletion", "rows", len(profileIDs), "hard_delete", hardDelete, "dry_run", dryRun)
+
+	deleteQuery := `DELETE FROM profile_schema.platform_profiles WHERE id = $1 RETURNING username`
+
+	return runBulk(ctx, r.pool, r.logger, "delete_profiles", len(profileIDs), dryRun, func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult {
+		id := profileIDs[index]
+		result := platform_profile.BulkItemResult{ProfileID: &id}
+
+		var err error
+		if hardDelete {
+			if err = tx.QueryRow(ctx, deleteQuery, id).Scan(&result.Username); err == nil {
+				err = purgeProfileDataTx(ctx, tx, id, result.Username)
+			}
+		} else {
+			result.Username, err = softDeleteProfileTx(ctx, tx, id, deletedBy, reason)
+		}
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				result.Status = platform_profile.BulkItemFailed
+				result.Reason = "profile not found"
+				return result
+			}
+			return bulkFailure(r.logger, "delete_profiles", result, "failed to delete profile", err)
+		}
+
+		result.Status = platform_profile.BulkItemDeleted
+		return result
+	})
+}
+
+// revokeProfileSessionsTx revokes all sessions and refresh tokens of a profile inside a transaction
+func revokeProfileSessionsTx(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `
+	UPDATE profile_schema.sessions SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND revoked_at IS NULL`,
+		profileID,
+	); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+	UPDATE profile_schema.refresh_tokens SET
+		revoked_at = NOW()
+	WHERE profile_id = $1 AND revoked_at IS NULL`,
+		profileID,
+	); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RecordLogin updates the last login timestamp and resets failed login attempts
+func (r *PostgresProfileRepository) RecordLogin(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debug("Recording login for profile", "profile_id", id)
+
+	query := `
+	UPDATE profile_schema.platform_profiles
+	SET
+		last_login_at = $1,
+		updated_by_system_at = $1,
+		failed_login_attempts = 0
+	WHERE id = $2
+	`
+
+	now := time.Now()
+
+	// Execute the query to update login information
+	commandTag, err := r.pool.Exec(ctx, query, now, id)
+	if err != nil {
+		r.logger.Error("Failed to record login", "profile_id", id, "error", err)
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	// Check if any rows were affected (handle profile not found case)
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Profile not found or already deleted", "profile_id", id)
+		return ErrProfileNotFound
+	}
+
+	r.logger.Info("Login recorded successfully", "profile_id", id, "last_login_at", now)
+	return nil
+}
+
+// IncrementFailedLoginAttempts increments the failed login attempts counter for a profile
+// and returns the new count
+func (r *PostgresProfileRepository) IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	r.logger.Debug("Incrementing failed login attempts", "profile_id", id)
+
+	query := `
+	UPDATE profile_schema.platform_profiles
+	SET
+		failed_login_attempts = failed_login_attempts + 1,
+		updated_by_system_at = $1
+	WHERE id = $2
+	RETURNING failed_login_attempts`
+
+	now := time.Now()
+
+	// Execute the query to increment failed login attempts
+	var attempts int
+	err := r.pool.QueryRow(ctx, query, now, id).Scan(&attempts)
+	if err != nil {
+		// No row means the profile was not found or deleted
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("Profile not found or already deleted, failed attempts not incremented", "profile_id", id)
+			return 0, ErrProfileNotFound
+		}
+		r.logger.Error("Failed to increment failed login attempts", "profile_id", id, "error", err)
+		return 0, fmt.Errorf("failed to increment failed login attempts: %w", err)
+	}
+
+	r.logger.Info("Failed login attempts incremented successfully", "profile_id", id, "failed_login_attempts", attempts)
+	return attempts, nil
+}
+
+// ResetFailedLoginAttempts resets the failed login attempts counter for a profile
+func (r *PostgresProfileRepository) ResetFailedLoginAttempts(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debug("Resetting failed login attempts", "profile_id", id)
+
+	query := `
+	UPDATE profile_schema.platform_profiles
+	SET
+		failed_login_attempts = 0,
+		updated_by_system_at = $1
+	WHERE id = $2
+	`
+
+	now := time.Now()
+
+	// Execute the query to reset failed login attempts
+	commandTag, err := r.pool.Exec(ctx, query, now, id)
+	if err != nil {
+		r.logger.Error("Failed to reset failed login attempts", "profile_id", id, "error", err)
+		return fmt.Errorf("failed to reset failed login attempts: %w", err)
+	}
+
+	// Check if any rows were affected (profile not found or deleted)
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Profile not found or already deleted, failed attempts not reset", "profile_id", id)
+		return ErrProfileNotFound
+	}
+
+	r.logger.Info("Failed login attempts reset successfully", "profile_id", id)
+	return nil
+}
+
+// GetAccountLockout retrieves the lockout state of a profile.
+// A profile that was never locked gets an empty state rather than an error.
+func (r *PostgresProfileRepository) GetAccountLockout(ctx context.Context, profileID uuid.UUID) (*platform_profile.AccountLockout, error) {
+	r.logger.Debug("Fetching account lockout", "profile_id", profileID)
+
+	query := `
+	SELECT profile_id, lock_count, locked_until, last_locked_at
+	FROM profile_schema.account_lockouts
+	WHERE profile_id = $1`
+
+	lockout := &platform_profile.AccountLockout{}
+	err := r.pool.QueryRow(ctx, query, profileID).Scan(
+		&lockout.ProfileID,
+		&lockout.LockCount,
+		&lockout.LockedUntil,
+		&lockout.LastLockedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &platform_profile.AccountLockout{ProfileID: profileID}, nil
+		}
+		r.logger.Error("Failed to fetch account lockout", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get account lockout: %w", err)
+	}
+
+	return lockout, nil
+}
+
+// LockProfile sets the status of a profile to locked until the given time and counts the lock
+func (r *PostgresProfileRepository) LockProfile(ctx context.Context, profileID uuid.UUID, lockedUntil time.Time) error {
+	r.logger.Debug("Starting to lock profile", "profile_id", profileID, "locked_until", lockedUntil)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+
+	statusQuery := `
+	UPDATE profile_schema.platform_profiles
+	SET
+		status = $1,
+		updated_by_system_at = $2
+	WHERE id = $3`
+
+	commandTag, err := tx.Exec(ctx, statusQuery, platform_profile.StatusLocked, now, profileID)
+	if err != nil {
+		r.logger.Error("Failed to lock profile", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to lock profile: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Warn("Profile not found while locking", "profile_id", profileID)
+		return ErrProfileNotFound
+	}
+
+	lockoutQuery := `
+	INSERT INTO profile_schema.account_lockouts (
+		profile_id, lock_count, locked_until, last_locked_at, updated_at
+	) VALUES (
+		$1, 1, $2, $3, $3
+	)
+	ON CONFLICT (profile_id) DO UPDATE SET
+		lock_count = account_lockouts.lock_count + 1,
+		locked_until = EXCLUDED.locked_until,
+		last_locked_at = EXCLUDED.last_locked_at,
+		updated_at = EXCLUDED.updated_at`
+
+	if _, err := tx.Exec(ctx, lockoutQuery, profileID, lockedUntil, now); err != nil {
+		r.logger.Error("Failed to store account lockout", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to store account lockout: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Profile locked successfully", "profile_id", profileID, "locked_until", lockedUntil)
+	return nil
+}
+
+// UnlockProfile restores a locked profile to pending or activated and resets its failed login attempts.
+// The lock count is kept for the backoff of later locks unless clearHistory is set.
+// An audit entry is written in the same transaction when given.
+// Returns false if the profile was not locked.
+func (r *PostgresProfileRepository) UnlockProfile(ctx context.Context, profileID uuid.UUID, clearHistory bool, audit *platform_profile.AuditLog) (bool, error) {
+	r.logger.Debug("Starting to unlock profile", "profile_id", profileID, "clear_history", clearHistory)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Profiles that have not verified their email yet go back to pending
+	statusQuery := `
+	UPDATE profile_schema.platform_profiles
+	SET
+		status = CASE WHEN verified_at IS NULL THEN $1 ELSE $2 END,
+		failed_login_attempts = 0,
+		updated_by_system_at = $3
+	WHERE id = $4 AND status = $5`
+
+	commandTag, err := tx.Exec(
+		ctx,
+		statusQuery,
+		platform_profile.StatusPending,
+		platform_profile.StatusActivated,
+		time.Now(),
+		profileID,
+		platform_profile.StatusLocked,
+	)
+	if err != nil {
+		r.logger.Error("Failed to unlock profile", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to unlock profile: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		r.logger.Debug("Profile was not locked", "profile_id", profileID)
+		return false, nil
+	}
+
+	if clearHistory {
+		if err := r.deleteAccountLockout(ctx, tx, profileID); err != nil {
+			return false, err
+		}
+	} else {
+		lockoutQuery := `
+		UPDATE profile_schema.account_lockouts
+		SET locked_until = NULL, updated_at = $1
+		WHERE profile_id = $2`
+
+		if _, err := tx.Exec(ctx, lockoutQuery, time.Now(), profileID); err != nil {
+			r.logger.Error("Failed to clear lock expiry", "profile_id", profileID, "error", err)
+			return false, fmt.Errorf("failed to clear lock expiry: %w", err)
+		}
+	}
+
+	if audit != nil {
+		if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Profile unlocked successfully", "profile_id", profileID)
+	return true, nil
+}
+
+// ClearAccountLockout removes the lockout history of a profile, resetting the lock backoff
+func (r *PostgresProfileRepository) ClearAccountLockout(ctx context.Context, profileID uuid.UUID) error {
+	r.logger.Debug("Clearing account lockout", "profile_id", profileID)
+
+	query := `DELETE FROM profile_schema.account_lockouts WHERE profile_id = $1`
+
+	if _, err := r.pool.Exec(ctx, query, profileID); err != nil {
+		r.logger.Error("Failed to clear account lockout", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to clear account lockout: %w", err)
+	}
+
+	return nil
+}
+
+// deleteAccountLockout removes the lockout history of a profile inside a transaction
+func (r *PostgresProfileRepository) deleteAccountLockout(ctx context.Context, tx pgx.Tx, profileID uuid.UUID) error {
+	query := `DELETE FROM profile_schema.account_lockouts WHERE profile_id = $1`
+
+	if _, err := tx.Exec(ctx, query, profileID); err != nil {
+		r.logger.Error("Failed to delete account lockout", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to delete account lockout: %w", err)
+	}
+
+	return nil
+}
+
+// RecordIPLoginFailure counts a failed login from an IP address and returns the number of
+// failures within the current window. A new window starts once the previous one has passed.
+func (r *PostgresProfileRepository) RecordIPLoginFailure(ctx context.Context, ipAddress string, window time.Duration) (int, error) {
+	r.logger.Debug("Recording failed login for IP", "ip_address", ipAddress)
+
+	query := `
+	INSERT INTO profile_schema.login_ip_failures (
+		ip_address, failure_count, window_started_at, updated_at
+	) VALUES (
+		$1, 1, $2, $2
+	)
+	ON CONFLICT (ip_address) DO UPDATE SET
+		failure_count = CASE
+			WHEN login_ip_failures.window_started_at < $3 THEN 1
+			ELSE login_ip_failures.failure_count + 1
+		END,
+		window_started_at = CASE
+			WHEN login_ip_failures.window_started_at < $3 THEN EXCLUDED.window_started_at
+			ELSE login_ip_failures.window_started_at
+		END,
+		updated_at = EXCLUDED.updated_at
+	RETURNING failure_count`
+
+	now := time.Now()
+
+	var failures int
+	if err := r.pool.QueryRow(ctx, query, ipAddress, now, now.Add(-window)).Scan(&failures); err != nil {
+		r.logger.Error("Failed to record failed login for IP", "ip_address", ipAddress, "error", err)
+		return 0, fmt.Errorf("failed to record failed login for IP: %w", err)
+	}
+
+	return failures, nil
+}
+
+// BlockIP rejects logins from an IP address until the given time
+func (r *PostgresProfileRepository) BlockIP(ctx context.Context, ipAddress string, blockedUntil time.Time) error {
+	r.logger.Debug("Blocking IP", "ip_address", ipAddress, "blocked_until", blockedUntil)
+
+	query := `
+	UPDATE profile_schema.login_ip_failures
+	SET blocked_until = $1, updated_at = $2
+	WHERE ip_address = $3`
+
+	if _, err := r.pool.Exec(ctx, query, blockedUntil, time.Now(), ipAddress); err != nil {
+		r.logger.Error("Failed to block IP", "ip_address", ipAddress, "error", err)
+		return fmt.Errorf("failed to block IP: %w", err)
+	}
+
+	r.logger.Info("IP blocked successfully", "ip_address", ipAddress, "blocked_until", blockedUntil)
+	return nil
+}
+
+// GetIPBlockedUntil returns the end of the active block of an IP address, or nil if it is not blocked
+func (r *PostgresProfileRepository) GetIPBlockedUntil(ctx context.Context, ipAddress string) (*time.Time, error) {
+	query := `
+	SELECT blocked_until
+	FROM profile_schema.login_ip_failures
+	WHERE ip_address = $1 AND blocked_until > $2`
+
+	var blockedUntil time.Time
+	err := r.pool.QueryRow(ctx, query, ipAddress, time.Now()).Scan(&blockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to check IP block", "ip_address", ipAddress, "error", err)
+		return nil, fmt.Errorf("failed to check IP block: %w", err)
+	}
+
+	return &blockedUntil, nil
+}
+
+// CreateOAuthState stores a pending external login
+func (r *PostgresProfileRepository) CreateOAuthState(ctx context.Context, state *platform_profile.OAuthState) error {
+	r.logger.Debug("Creating OAuth state", "provider", state.Provider)
+
+	query := `
+	INSERT INTO profile_schema.oauth_states (
+		state_hash, provider, code_verifier, nonce, expires_at, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		state.StateHash,
+		state.Provider,
+		state.CodeVerifier,
+		state.Nonce,
+		state.ExpiresAt,
+		state.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create OAuth state", "provider", state.Provider, "error", err)
+		return fmt.Errorf("failed to create OAuth state: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeOAuthState deletes a pending external login and returns it, so a state can only be used once.
+// Returns nil without an error when no such state exists.
+func (r *PostgresProfileRepository) ConsumeOAuthState(ctx context.Context, stateHash string) (*platform_profile.OAuthState, error) {
+	r.logger.Debug("Consuming OAuth state")
+
+	query := `
+	DELETE FROM profile_schema.oauth_states
+	WHERE state_hash = $1
+	RETURNING state_hash, provider, code_verifier, nonce, expires_at, created_at`
+
+	state := &platform_profile.OAuthState{}
+	err := r.pool.QueryRow(ctx, query, stateHash).Scan(
+		&state.StateHash,
+		&state.Provider,
+		&state.CodeVerifier,
+		&state.Nonce,
+		&state.ExpiresAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Warn("OAuth state not found")
+			return nil, nil
+		}
+		r.logger.Error("Failed to consume OAuth state", "error", err)
+		return nil, fmt.Errorf("failed to consume OAuth state: %w", err)
+	}
+
+	return state, nil
+}
+
+// DeleteExpiredOAuthStates removes abandoned external logins
+func (r *PostgresProfileRepository) DeleteExpiredOAuthStates(ctx context.Context) (int64, error) {
+	r.logger.Debug("Deleting expired OAuth states")
+
+	query := `DELETE FROM profile_schema.oauth_states WHERE expires_at < $1`
+
+	commandTag, err := r.pool.Exec(ctx, query, time.Now())
+	if err != nil {
+		r.logger.Error("Failed to delete expired OAuth states", "error", err)
+		return 0, fmt.Errorf("failed to delete expired OAuth states: %w", err)
+	}
+
+	r.logger.Info("Expired OAuth states deleted", "deleted", commandTag.RowsAffected())
+	return commandTag.RowsAffected(), nil
+}
+
+// CreateLinkedIdentity links an external identity to a profile
+func (r *PostgresProfileRepository) CreateLinkedIdentity(ctx context.Context, identity *platform_profile.LinkedIdentity) error {
+	r.logger.Debug("Linking external identity", "profile_id", identity.ProfileID, "provider", identity.Provider)
+
+	query := `
+	INSERT INTO profile_schema.linked_identities (
+		id, profile_id, provider, subject, email, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		identity.ID,
+		identity.ProfileID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to link external identity", "profile_id", identity.ProfileID, "provider", identity.Provider, "error", err)
+		return fmt.Errorf("failed to create linked identity: %w", err)
+	}
+
+	r.logger.Info("External identity linked", "profile_id", identity.ProfileID, "provider", identity.Provider)
+	return nil
+}
+
+// GetLinkedIdentity retrieves the identity linked for a provider account.
+// Returns nil without an error when the account is not linked.
+func (r *PostgresProfileRepository) GetLinkedIdentity(ctx context.Context, provider string, subject string) (*platform_profile.LinkedIdentity, error) {
+	r.logger.Debug("Fetching linked identity", "provider", provider)
 
-		whereClause += strings.Join(conditions, " AND ")
+	query := `
+	SELECT id, profile_id, provider, subject, email, created_at, last_login_at
+	FROM profile_schema.linked_identities
+	WHERE provider = $1 AND subject = $2`
+
+	identity := &platform_profile.LinkedIdentity{}
+	err := r.pool.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.ProfileID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to fetch linked identity", "provider", provider, "error", err)
+		return nil, fmt.Errorf("failed to get linked identity: %w", err)
 	}
 
-	// Complete data and count queries
-	dataQuery := baseQuery + whereClause + fmt.Sprintf(" ORDER BY p.created_at DESC LIMIT $%d OFFSET $%d", paramIndex, paramIndex+1)
-	countQueryFinal := countQuery + whereClause
+	return identity, nil
+}
 
-	// Add pagination parameters
-	args = append(args, pageSize, offset)
+// ListLinkedIdentities retrieves all external identities linked to a profile
+func (r *PostgresProfileRepository) ListLinkedIdentities(ctx context.Context, profileID uuid.UUID) ([]*platform_profile.LinkedIdentity, error) {
+	r.logger.Debug("Listing linked identities", "profile_id", profileID)
 
-	// Get total count of matching profiles
-	var total int
-	err := r.pool.QueryRow(ctx, countQueryFinal, args[:len(args)-2]...).Scan(&total)
-	if err != nil {
-		r.logger.Error("Failed to get profile count by preferences", "preferences", preferences, "error", err)
-		return nil, 0, fmt.Errorf("failed to get profile count by preferences: %w", err)
-	}
+	query := `
+	SELECT id, profile_id, provider, subject, email, created_at, last_login_at
+	FROM profile_schema.linked_identities
+	WHERE profile_id = $1
+	ORDER BY created_at`
 
-	// Execute query to fetch profiles
-	rows, err := r.pool.Query(ctx, dataQuery, args...)
+	rows, err := r.pool.Query(ctx, query, profileID)
 	if err != nil {
-		r.logger.Error("Failed to get profiles by preferences", "preferences", preferences, "error", err)
-		return nil, 0, fmt.Errorf("failed to get profiles by preferences: %w", err)
+		r.logger.Error("Failed to list linked identities", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to list linked identities: %w", err)
 	}
 	defer rows.Close()
 
-	// Process query results
-	profiles := []*platform_profile.PlatformProfile{}
+	var identities []*platform_profile.LinkedIdentity
 	for rows.Next() {
-		profile := &platform_profile.PlatformProfile{}
-		err := rows.Scan(
-			&profile.ID,
-			&profile.Username,
-			&profile.Email,
-			&profile.PasswordHash,
-			&profile.Status,
-			&profile.VerifiedAt,
-			&profile.LastLoginAt,
-			&profile.FailedLoginAttempts,
-			&profile.CreatedAt,
-			&profile.UpdatedByUserAt,
-			&profile.UpdatedBySystemAt,
-		)
-		if err != nil {
-			r.logger.Error("Failed to scan profile by preferences", "preferences", preferences, "error", err)
-			return nil, 0, fmt.Errorf("failed to scan profile by preferences: %w", err)
+		identity := &platform_profile.LinkedIdentity{}
+		if err := rows.Scan(
+			&identity.ID,
+			&identity.ProfileID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.LastLoginAt,
+		); err != nil {
+			r.logger.Error("Failed to scan linked identity", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan linked identity: %w", err)
 		}
-		profiles = append(profiles, profile)
+		identities = append(identities, identity)
 	}
-
-	// Check for iteration errors
 	if err := rows.Err(); err != nil {
-		r.logger.Error("Error iterating over profile rows by preferences", "preferences", preferences, "error", err)
-		return nil, 0, fmt.Errorf("error iterating over profile rows by preferences: %w", err)
+		r.logger.Error("Error iterating linked identities", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating linked identities: %w", err)
 	}
 
-	r.logger.Info("Profiles fetched successfully by preferences", "total_profiles", len(profiles))
-	return profiles, total, nil
+	return identities, nil
 }
 
-// DeleteProfiles deletes multiple profiles either as a hard delete or a soft delete
-func (r *PostgresProfileRepository) DeleteProfiles(ctx context.Context, profileIDs []uuid.UUID, hardDelete bool) error {
-	r.logger.Debug("Starting profile deletion process", "hard_delete", hardDelete, "profile_ids", profileIDs)
+// DeleteLinkedIdentity unlinks an external identity of a profile, reporting whether it existed
+func (r *PostgresProfileRepository) DeleteLinkedIdentity(ctx context.Context, profileID uuid.UUID, id uuid.UUID) (bool, error) {
+	r.logger.Debug("Unlinking external identity", "profile_id", profileID, "id", id)
 
-	if len(profileIDs) == 0 {
-		r.logger.Warn("No profile IDs provided for deletion")
-		return nil
+	query := `DELETE FROM profile_schema.linked_identities WHERE id = $1 AND profile_id = $2`
+
+	commandTag, err := r.pool.Exec(ctx, query, id, profileID)
+	if err != nil {
+		r.logger.Error("Failed to unlink external identity", "profile_id", profileID, "id", id, "error", err)
+		return false, fmt.Errorf("failed to delete linked identity: %w", err)
 	}
 
-	// Prepare query placeholders for IN clause
-	placeholders := make([]string, len(profileIDs))
-	args := make([]interface{}, len(profileIDs))
-	for i, id := range profileIDs {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = id
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// RecordIdentityLogin updates the last login time of a linked identity
+func (r *PostgresProfileRepository) RecordIdentityLogin(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE profile_schema.linked_identities SET last_login_at = $1 WHERE id = $2`
+
+	if _, err := r.pool.Exec(ctx, query, time.Now(), id); err != nil {
+		r.logger.Error("Failed to record identity login", "id", id, "error", err)
+		return fmt.Errorf("failed to record identity login: %w", err)
 	}
-	idList := strings.Join(placeholders, ", ")
 
-	var query string
-	var err error
+	return nil
+}
 
-	if hardDelete {
-		// Hard delete: Permanently remove profiles and related records
-		query = fmt.Sprintf(`
-		DELETE FROM profile_schema.platform_profiles
-		WHERE id IN (%s)`, idList)
+// apiKeyColumns are the columns scanned by scanAPIKey, in order
+const apiKeyColumns = `id, profile_id, name, prefix, key_hash, scopes, allowed_ips, expires_at,
+	last_used_at, last_used_ip, revoked_at, rotated_from, created_by, created_at`
 
-		_, err = r.pool.Exec(ctx, query, args...)
-		if err != nil {
-			r.logger.Error("Failed to hard delete profiles", "error", err, "profile_ids", profileIDs)
-			return fmt.Errorf("failed to hard delete profiles: %w", err)
+// CreateAPIKey stores a new API key and records its creation in the audit log
+func (r *PostgresProfileRepository) CreateAPIKey(ctx context.Context, key *platform_profile.APIKey, audit *platform_profile.AuditLog) error {
+	r.logger.Debug("Creating API key", "profile_id", key.ProfileID, "prefix", key.Prefix)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "profile_id", key.ProfileID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.insertAPIKey(ctx, tx, key); err != nil {
+		return err
+	}
+
+	if audit != nil {
+		if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+			return err
 		}
-		r.logger.Info("Profiles permanently deleted successfully", "profile_ids", profileIDs)
-	} else {
-		// Soft delete: Mark profiles as deleted and store them in deleted_profiles
-		tx, err := r.pool.Begin(ctx)
-		if err != nil {
-			r.logger.Error("Failed to start transaction for soft delete", "error", err)
-			return fmt.Errorf("failed to start transaction for soft delete: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", key.ProfileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("API key created", "profile_id", key.ProfileID, "id", key.ID)
+	return nil
+}
+
+// GetAPIKey retrieves an API key of a profile.
+// Returns nil without an error when the profile has no such key.
+func (r *PostgresProfileRepository) GetAPIKey(ctx context.Context, profileID uuid.UUID, id uuid.UUID) (*platform_profile.APIKey, error) {
+	r.logger.Debug("Fetching API key", "profile_id", profileID, "id", id)
+
+	query := `SELECT ` + apiKeyColumns + ` FROM profile_schema.api_keys WHERE id = $1 AND profile_id = $2`
+
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, query, id, profileID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
 		}
-		defer func() {
-			if err != nil {
-				_ = tx.Rollback(ctx)
-			}
-		}()
+		r.logger.Error("Failed to fetch API key", "profile_id", profileID, "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
 
-		// Move profiles to deleted_profiles table before marking them as deleted
-		moveQuery := fmt.Sprintf(`
-		INSERT INTO profile_schema.deleted_profiles (
-			id, username, email, password_hash, first_name, last_name, 
-			display_name, status, created_at, updated_by_user_at, 
-			last_login_at, failed_login_attempts, verified_at, 
-			deleted_at
-		)
-		SELECT 
-			id, username, email, password_hash, first_name, last_name, 
-			display_name, status, created_at, updated_by_user_at, 
-			last_login_at, failed_login_attempts, verified_at, 
-			NOW()
-		FROM profile_schema.platform_profiles
-		WHERE id IN (%s)`, idList)
+	return key, nil
+}
 
-		_, err = tx.Exec(ctx, moveQuery, args...)
-		if err != nil {
-			r.logger.Error("Failed to move profiles to deleted_profiles for soft delete", "error", err, "profile_ids", profileIDs)
-			return fmt.Errorf("failed to move profiles to deleted_profiles: %w", err)
+// GetAPIKeyByPrefix retrieves the API key with the lookup prefix.
+// Returns nil without an error when no key has the prefix.
+func (r *PostgresProfileRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*platform_profile.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM profile_schema.api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
 		}
+		r.logger.Error("Failed to fetch API key by prefix", "prefix", prefix, "error", err)
+		return nil, fmt.Errorf("failed to get API key by prefix: %w", err)
+	}
 
-		// Commit the transaction
-		err = tx.Commit(ctx)
+	return key, nil
+}
+
+// ListAPIKeys retrieves all API keys of a profile, including expired and revoked ones
+func (r *PostgresProfileRepository) ListAPIKeys(ctx context.Context, profileID uuid.UUID) ([]*platform_profile.APIKey, error) {
+	r.logger.Debug("Listing API keys", "profile_id", profileID)
+
+	query := `SELECT ` + apiKeyColumns + ` FROM profile_schema.api_keys WHERE profile_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to list API keys", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*platform_profile.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
 		if err != nil {
-			r.logger.Error("Failed to commit transaction for soft delete", "error", err)
-			return fmt.Errorf("failed to commit transaction: %w", err)
+			r.logger.Error("Failed to scan API key", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
 		}
-		r.logger.Info("Profiles soft deleted successfully and moved to deleted_profiles", "profile_ids", profileIDs)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating API keys", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
 	}
 
-	return nil
+	return keys, nil
 }
 
-// RecordLogin updates the last login timestamp and resets failed login attempts
-func (r *PostgresProfileRepository) RecordLogin(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Recording login for profile", "profile_id", id)
+// RotateAPIKey stores the replacement of an API key and shortens the expiry of the old key to the
+// end of the overlap window, in one transaction with the audit log entry.
+// Reports false when the old key was revoked or already rotated.
+func (r *PostgresProfileRepository) RotateAPIKey(ctx context.Context, oldKeyID uuid.UUID, oldKeyExpiresAt time.Time, newKey *platform_profile.APIKey, audit *platform_profile.AuditLog) (bool, error) {
+	r.logger.Debug("Rotating API key", "profile_id", newKey.ProfileID, "old_id", oldKeyID)
 
-	query := `
-	UPDATE profile_schema.platform_profiles
-	SET
-		last_login_at = $1,
-		updated_by_system_at = $1,
-		failed_login_attempts = 0
-	WHERE id = $2
-	`
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "profile_id", newKey.ProfileID, "error", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	now := time.Now()
+	// Never extends the old key, and fails if it was revoked or rotated concurrently
+	query := `
+	UPDATE profile_schema.api_keys
+	SET expires_at = LEAST(expires_at, $1)
+	WHERE id = $2 AND revoked_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM profile_schema.api_keys WHERE rotated_from = $2)`
 
-	// Execute the query to update login information
-	commandTag, err := r.pool.Exec(ctx, query, now, id)
+	commandTag, err := tx.Exec(ctx, query, oldKeyExpiresAt, oldKeyID)
 	if err != nil {
-		r.logger.Error("Failed to record login", "profile_id", id, "error", err)
-		return fmt.Errorf("failed to record login: %w", err)
+		r.logger.Error("Failed to expire rotated API key", "id", oldKeyID, "error", err)
+		return false, fmt.Errorf("failed to expire rotated API key: %w", err)
 	}
-
-	// Check if any rows were affected (handle profile not found case)
 	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile not found or already deleted", "profile_id", id)
-		return ErrProfileNotFound
+		r.logger.Warn("API key was revoked or rotated concurrently", "id", oldKeyID)
+		return false, nil
 	}
 
-	r.logger.Info("Login recorded successfully", "profile_id", id, "last_login_at", now)
-	return nil
-}
+	if err := r.insertAPIKey(ctx, tx, newKey); err != nil {
+		return false, err
+	}
 
-// IncrementFailedLoginAttempts increments the failed login attempts counter for a profile
-func (r *PostgresProfileRepository) IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Incrementing failed login attempts", "profile_id", id)
+	if audit != nil {
+		if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+			return false, err
+		}
+	}
 
-	query := `
-	UPDATE profile_schema.platform_profiles
-	SET
-		failed_login_attempts = failed_login_attempts + 1,
-		updated_by_system_at = $1
-	WHERE id = $2 `
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", newKey.ProfileID, "error", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
-	now := time.Now()
+	r.logger.Info("API key rotated", "profile_id", newKey.ProfileID, "old_id", oldKeyID, "new_id", newKey.ID)
+	return true, nil
+}
 
-	// Execute the query to increment failed login attempts
-	commandTag, err := r.pool.Exec(ctx, query, now, id)
+// RevokeAPIKey revokes an API key of a profile immediately, reporting whether an active key was revoked
+func (r *PostgresProfileRepository) RevokeAPIKey(ctx context.Context, profileID uuid.UUID, id uuid.UUID, audit *platform_profile.AuditLog) (bool, error) {
+	r.logger.Debug("Revoking API key", "profile_id", profileID, "id", id)
+
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		r.logger.Error("Failed to increment failed login attempts", "profile_id", id, "error", err)
-		return fmt.Errorf("failed to increment failed login attempts: %w", err)
+		r.logger.Error("Failed to begin transaction", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Check if any rows were affected (profile not found or deleted)
+	query := `
+	UPDATE profile_schema.api_keys
+	SET revoked_at = $1
+	WHERE id = $2 AND profile_id = $3 AND revoked_at IS NULL`
+
+	commandTag, err := tx.Exec(ctx, query, time.Now(), id, profileID)
+	if err != nil {
+		r.logger.Error("Failed to revoke API key", "profile_id", profileID, "id", id, "error", err)
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
 	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile not found or already deleted, failed attempts not incremented", "profile_id", id)
-		return ErrProfileNotFound
+		return false, nil
 	}
 
-	r.logger.Info("Failed login attempts incremented successfully", "profile_id", id)
-	return nil
+	if audit != nil {
+		if err := r.insertAuditLog(ctx, tx, audit); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("API key revoked", "profile_id", profileID, "id", id)
+	return true, nil
 }
 
-// ResetFailedLoginAttempts resets the failed login attempts counter for a profile
-func (r *PostgresProfileRepository) ResetFailedLoginAttempts(ctx context.Context, id uuid.UUID) error {
-	r.logger.Debug("Resetting failed login attempts", "profile_id", id)
+// RecordAPIKeyUse updates when and from where an API key was last used.
+// Writes at most once a minute per key, so busy keys don't update the row on every request.
+func (r *PostgresProfileRepository) RecordAPIKeyUse(ctx context.Context, id uuid.UUID, ipAddress string) error {
+	now := time.Now()
 
 	query := `
-	UPDATE profile_schema.platform_profiles
-	SET
-		failed_login_attempts = 0,
-		updated_by_system_at = $1
-	WHERE id = $2
-	`
+	UPDATE profile_schema.api_keys
+	SET last_used_at = $1, last_used_ip = $2
+	WHERE id = $3 AND (last_used_at IS NULL OR last_used_at < $4 OR last_used_ip IS DISTINCT FROM $2)`
 
-	now := time.Now()
+	if _, err := r.pool.Exec(ctx, query, now, ipAddress, id, now.Add(-time.Minute)); err != nil {
+		r.logger.Error("Failed to record API key use", "id", id, "error", err)
+		return fmt.Errorf("failed to record API key use: %w", err)
+	}
 
-	// Execute the query to reset failed login attempts
-	commandTag, err := r.pool.Exec(ctx, query, now, id)
-	if err != nil {
-		r.logger.Error("Failed to reset failed login attempts", "profile_id", id, "error", err)
-		return fmt.Errorf("failed to reset failed login attempts: %w", err)
+	return nil
+}
+
+// insertAPIKey stores an API key inside a transaction
+func (r *PostgresProfileRepository) insertAPIKey(ctx context.Context, tx pgx.Tx, key *platform_profile.APIKey) error {
+	query := `
+	INSERT INTO profile_schema.api_keys (
+		id, profile_id, name, prefix, key_hash, scopes, allowed_ips,
+		expires_at, rotated_from, created_by, created_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	)`
+
+	allowedIPs := key.AllowedIPs
+	if allowedIPs == nil {
+		allowedIPs = []string{}
 	}
 
-	// Check if any rows were affected (profile not found or deleted)
-	if commandTag.RowsAffected() == 0 {
-		r.logger.Warn("Profile not found or already deleted, failed attempts not reset", "profile_id", id)
-		return ErrProfileNotFound
+	_, err := tx.Exec(
+		ctx,
+		query,
+		key.ID,
+		key.ProfileID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		allowedIPs,
+		key.ExpiresAt,
+		key.RotatedFrom,
+		key.CreatedBy,
+		key.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create API key", "profile_id", key.ProfileID, "error", err)
+		return fmt.Errorf("failed to create API key: %w", err)
 	}
 
-	r.logger.Info("Failed login attempts reset successfully", "profile_id", id)
 	return nil
 }
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row) (*platform_profile.APIKey, error) {
+	key := &platform_profile.APIKey{}
+	err := row.Scan(
+		&key.ID,
+		&key.ProfileID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.AllowedIPs,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.LastUsedIP,
+		&key.RevokedAt,
+		&key.RotatedFrom,
+		&key.CreatedBy,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
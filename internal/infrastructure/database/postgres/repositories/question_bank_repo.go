@@ -0,0 +1,357 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"server/internal/domain/quiz"
+	questionpkg "server/internal/domain/quiz/question"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// questionBankColumns are the columns read by scanQuestionBank, the question count is aggregated
+// from the questions
+const questionBankColumns = `
+	b.id, b.name, b.description, b.domain_id, b.sub_domain_id, b.difficulty_level_id,
+	(SELECT COUNT(*) FROM quiz_schema.bank_questions bq WHERE bq.bank_id = b.id),
+	b.created_by, b.created_at, b.updated_at`
+
+// bankQuestionColumns are the columns read by scanBankQuestion
+const bankQuestionColumns = `id, bank_id, prompt, content, explanation, created_at, updated_at`
+
+// CreateQuestionBank creates a question bank and sets its ID
+func (r *PostgresQuizRepository) CreateQuestionBank(ctx context.Context, bank *quiz.QuestionBank) error {
+	err := r.pool.QueryRow(ctx, `
+	INSERT INTO quiz_schema.question_banks (
+		name, description, domain_id, sub_domain_id, difficulty_level_id, created_by, created_at, updated_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)
+	RETURNING id`,
+		bank.Name,
+		bank.Description,
+		bank.DomainID,
+		bank.SubDomainID,
+		bank.DifficultyLevelID,
+		bank.CreatedBy,
+		bank.CreatedAt,
+		bank.UpdatedAt,
+	).Scan(&bank.ID)
+	if err != nil {
+		r.logger.Error("Failed to create question bank", "name", bank.Name, "error", err)
+		return fmt.Errorf("failed to create question bank: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuestionBank retrieves a question bank.
+// Returns nil without an error when the bank does not exist.
+func (r *PostgresQuizRepository) GetQuestionBank(ctx context.Context, id int64) (*quiz.QuestionBank, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+questionBankColumns+` FROM quiz_schema.question_banks b WHERE b.id = $1`, id)
+
+	bank, err := scanQuestionBank(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get question bank", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get question bank: %w", err)
+	}
+
+	return bank, nil
+}
+
+// ListQuestionBanks retrieves the question banks matching the filters, by name
+func (r *PostgresQuizRepository) ListQuestionBanks(ctx context.Context, req quiz.ListQuestionBanksRequest) ([]*quiz.QuestionBank, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	paramIndex := 1
+
+	if req.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(b.name ILIKE $%d OR b.description ILIKE $%d)", paramIndex, paramIndex))
+		args = append(args, fmt.Sprintf("%%%s%%", req.Search))
+		paramIndex++
+	}
+	if req.DomainID != nil {
+		conditions = append(conditions, fmt.Sprintf("b.domain_id = $%d", paramIndex))
+		args = append(args, *req.DomainID)
+		paramIndex++
+	}
+	if req.SubDomainID != nil {
+		conditions = append(conditions, fmt.Sprintf("b.sub_domain_id = $%d", paramIndex))
+		args = append(args, *req.SubDomainID)
+		paramIndex++
+	}
+	if req.DifficultyLevelID != nil {
+		conditions = append(conditions, fmt.Sprintf("b.difficulty_level_id = $%d", paramIndex))
+		args = append(args, *req.DifficultyLevelID)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT `+questionBankColumns+` FROM quiz_schema.question_banks b`+whereClause+` ORDER BY b.name, b.id`, args...)
+	if err != nil {
+		r.logger.Error("Failed to list question banks", "error", err)
+		return nil, fmt.Errorf("failed to list question banks: %w", err)
+	}
+
+	banks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*quiz.QuestionBank, error) {
+		return scanQuestionBank(row)
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan question banks", "error", err)
+		return nil, fmt.Errorf("failed to scan question banks: %w", err)
+	}
+
+	return banks, nil
+}
+
+// UpdateQuestionBank updates the details of a question bank
+func (r *PostgresQuizRepository) UpdateQuestionBank(ctx context.Context, bank *quiz.QuestionBank) error {
+	tag, err := r.pool.Exec(ctx, `
+	UPDATE quiz_schema.question_banks SET
+		name = $2,
+		description = $3,
+		domain_id = $4,
+		sub_domain_id = $5,
+		difficulty_level_id = $6,
+		updated_at = $7
+	WHERE id = $1`,
+		bank.ID,
+		bank.Name,
+		bank.Description,
+		bank.DomainID,
+		bank.SubDomainID,
+		bank.DifficultyLevelID,
+		bank.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to update question bank", "id", bank.ID, "error", err)
+		return fmt.Errorf("failed to update question bank: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quiz.ErrBankNotFound
+	}
+
+	return nil
+}
+
+// DeleteQuestionBank deletes a question bank, its questions go with it
+func (r *PostgresQuizRepository) DeleteQuestionBank(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM quiz_schema.question_banks WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete question bank", "id", id, "error", err)
+		return fmt.Errorf("failed to delete question bank: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quiz.ErrBankNotFound
+	}
+
+	return nil
+}
+
+// GetBankQuestions retrieves the questions of a bank in the order they were added
+func (r *PostgresQuizRepository) GetBankQuestions(ctx context.Context, bankID int64) ([]quiz.BankQuestion, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+bankQuestionColumns+`
+	FROM quiz_schema.bank_questions
+	WHERE bank_id = $1
+	ORDER BY id`,
+		bankID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get bank questions", "bank_id", bankID, "error", err)
+		return nil, fmt.Errorf("failed to get bank questions: %w", err)
+	}
+
+	questions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (quiz.BankQuestion, error) {
+		return scanBankQuestion(row)
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan bank questions", "bank_id", bankID, "error", err)
+		return nil, fmt.Errorf("failed to scan bank questions: %w", err)
+	}
+
+	return questions, nil
+}
+
+// GetBankQuestionsByID retrieves bank questions by ID, skipping those that don't exist
+func (r *PostgresQuizRepository) GetBankQuestionsByID(ctx context.Context, ids []int64) ([]quiz.BankQuestion, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+bankQuestionColumns+`
+	FROM quiz_schema.bank_questions
+	WHERE id = ANY($1)`,
+		ids,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get bank questions", "count", len(ids), "error", err)
+		return nil, fmt.Errorf("failed to get bank questions: %w", err)
+	}
+
+	questions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (quiz.BankQuestion, error) {
+		return scanBankQuestion(row)
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan bank questions", "error", err)
+		return nil, fmt.Errorf("failed to scan bank questions: %w", err)
+	}
+
+	return questions, nil
+}
+
+// AddBankQuestion adds a question to a bank and sets its ID
+func (r *PostgresQuizRepository) AddBankQuestion(ctx context.Context, question *quiz.BankQuestion) error {
+	content, err := questionpkg.Marshal(question.Content)
+	if err != nil {
+		return err
+	}
+
+	err = r.pool.QueryRow(ctx, `
+	INSERT INTO quiz_schema.bank_questions (
+		bank_id, prompt, content, explanation, created_at, updated_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)
+	RETURNING id`,
+		question.BankID,
+		question.Prompt,
+		content,
+		question.Explanation,
+		question.CreatedAt,
+		question.UpdatedAt,
+	).Scan(&question.ID)
+	if err != nil {
+		r.logger.Error("Failed to add bank question", "bank_id", question.BankID, "error", err)
+		return fmt.Errorf("failed to add bank question: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBankQuestion replaces a question of a bank
+func (r *PostgresQuizRepository) UpdateBankQuestion(ctx context.Context, question *quiz.BankQuestion) error {
+	content, err := questionpkg.Marshal(question.Content)
+	if err != nil {
+		return err
+	}
+
+	err = r.pool.QueryRow(ctx, `
+	UPDATE quiz_schema.bank_questions SET
+		prompt = $3,
+		content = $4,
+		explanation = $5,
+		updated_at = $6
+	WHERE id = $1 AND bank_id = $2
+	RETURNING created_at`,
+		question.ID,
+		question.BankID,
+		question.Prompt,
+		content,
+		question.Explanation,
+		question.UpdatedAt,
+	).Scan(&question.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return quiz.ErrQuestionNotFound
+		}
+		r.logger.Error("Failed to update bank question", "id", question.ID, "error", err)
+		return fmt.Errorf("failed to update bank question: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBankQuestion deletes a question of a bank
+func (r *PostgresQuizRepository) DeleteBankQuestion(ctx context.Context, bankID, questionID int64) error {
+	tag, err := r.pool.Exec(ctx, `
+	DELETE FROM quiz_schema.bank_questions WHERE id = $1 AND bank_id = $2`,
+		questionID, bankID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to delete bank question", "id", questionID, "error", err)
+		return fmt.Errorf("failed to delete bank question: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quiz.ErrQuestionNotFound
+	}
+
+	return nil
+}
+
+// ListPoolQuestionIDs retrieves the IDs of the questions of the banks tagged with the domain and
+// difficulty level of the rule and, when the rule has one, its sub-domain
+func (r *PostgresQuizRepository) ListPoolQuestionIDs(ctx context.Context, rule quiz.BlueprintRule) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT bq.id
+	FROM quiz_schema.bank_questions bq
+	JOIN quiz_schema.question_banks b ON b.id = bq.bank_id
+	WHERE b.domain_id = $1 AND b.difficulty_level_id = $2 AND ($3 = 0 OR b.sub_domain_id = $3)
+	ORDER BY bq.id`,
+		rule.DomainID, rule.DifficultyLevelID, rule.SubDomainID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to list pool questions", "domain_id", rule.DomainID, "difficulty_level_id", rule.DifficultyLevelID, "error", err)
+		return nil, fmt.Errorf("failed to list pool questions: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		r.logger.Error("Failed to scan pool questions", "error", err)
+		return nil, fmt.Errorf("failed to scan pool questions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// scanQuestionBank scans a row selected with questionBankColumns
+func scanQuestionBank(row pgx.Row) (*quiz.QuestionBank, error) {
+	var bank quiz.QuestionBank
+	err := row.Scan(
+		&bank.ID,
+		&bank.Name,
+		&bank.Description,
+		&bank.DomainID,
+		&bank.SubDomainID,
+		&bank.DifficultyLevelID,
+		&bank.QuestionCount,
+		&bank.CreatedBy,
+		&bank.CreatedAt,
+		&bank.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// scanBankQuestion scans a row selected with bankQuestionColumns
+func scanBankQuestion(row pgx.Row) (quiz.BankQuestion, error) {
+	var question quiz.BankQuestion
+	var content []byte
+	err := row.Scan(
+		&question.ID,
+		&question.BankID,
+		&question.Prompt,
+		&content,
+		&question.Explanation,
+		&question.CreatedAt,
+		&question.UpdatedAt,
+	)
+	if err != nil {
+		return question, err
+	}
+
+	question.Content, err = questionpkg.Unmarshal(content)
+	if err != nil {
+		return question, err
+	}
+	question.Type = question.Content.Type()
+	return question, nil
+}
@@ -1 +1,1089 @@
 package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server/internal/domain/quiz"
+	questionpkg "server/internal/domain/quiz/question"
+	"server/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// quizColumns are the columns read by scanQuiz, the question count and total points are
+// aggregated from the questions
+const quizColumns = `
+	q.id, q.title, q.description, q.domain_id, q.sub_domain_id, q.difficulty_level_id,
+	q.time_limit_minutes, q.passing_score, q.max_concurrent_attempts, q.blueprint, q.published, q.published_at,
+	(SELECT COUNT(*) FROM quiz_schema.questions qs WHERE qs.quiz_id = q.id),
+	(SELECT COALESCE(SUM(qs.points), 0) FROM quiz_schema.questions qs WHERE qs.quiz_id = q.id),
+	q.created_by, q.created_at, q.updated_at`
+
+// questionColumns are the columns read by scanQuestion
+const questionColumns = `id, quiz_id, position, prompt, content, explanation, points, created_at, updated_at`
+
+// attemptColumns are the columns read by scanAttempt
+const attemptColumns = `id, quiz_id, student_id, status, start_time, deadline, end_time, auto_submitted, score, max_score, passed, paper_seed`
+
+// PostgresQuizRepository stores quizzes, their questions, the question banks and the attempts of
+// students
+type PostgresQuizRepository struct {
+	pool   *pgxpool.Pool
+	logger *logger.Logger
+}
+
+// NewPostgresQuizRepository creates a new PostgreSQL-backed quiz repository
+func NewPostgresQuizRepository(pool *pgxpool.Pool, logger *logger.Logger) quiz.Repository {
+	return &PostgresQuizRepository{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// CreateQuiz creates a quiz and sets its ID
+func (r *PostgresQuizRepository) CreateQuiz(ctx context.Context, q *quiz.Quiz) error {
+	blueprint, err := encodeBlueprint(q.Blueprint)
+	if err != nil {
+		return err
+	}
+
+	err = r.pool.QueryRow(ctx, `
+	INSERT INTO quiz_schema.quizzes (
+		title, description, domain_id, sub_domain_id, difficulty_level_id, time_limit_minutes,
+		passing_score, max_concurrent_attempts, blueprint, published, published_at, created_by,
+		created_at, updated_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+	)
+	RETURNING id`,
+		q.Title,
+		q.Description,
+		q.DomainID,
+		q.SubDomainID,
+		q.DifficultyLevelID,
+		q.TimeLimitMinutes,
+		q.PassingScore,
+		q.MaxConcurrentAttempts,
+		blueprint,
+		q.Published,
+		q.PublishedAt,
+		q.CreatedBy,
+		q.CreatedAt,
+		q.UpdatedAt,
+	).Scan(&q.ID)
+	if err != nil {
+		r.logger.Error("Failed to create quiz", "title", q.Title, "error", err)
+		return fmt.Errorf("failed to create quiz: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuiz retrieves a quiz.
+// Returns nil without an error when the quiz does not exist.
+func (r *PostgresQuizRepository) GetQuiz(ctx context.Context, id int64) (*quiz.Quiz, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+quizColumns+` FROM quiz_schema.quizzes q WHERE q.id = $1`, id)
+
+	q, err := scanQuiz(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get quiz", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get quiz: %w", err)
+	}
+
+	return q, nil
+}
+
+// ListQuizzes retrieves a page of quizzes, the latest first
+func (r *PostgresQuizRepository) ListQuizzes(ctx context.Context, req quiz.ListQuizzesRequest) ([]*quiz.Quiz, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	paramIndex := 1
+
+	if req.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(q.title ILIKE $%d OR q.description ILIKE $%d)", paramIndex, paramIndex))
+		args = append(args, fmt.Sprintf("%%%s%%", req.Search))
+		paramIndex++
+	}
+	if req.DomainID != nil {
+		conditions = append(conditions, fmt.Sprintf("q.domain_id = $%d", paramIndex))
+		args = append(args, *req.DomainID)
+		paramIndex++
+	}
+	if req.SubDomainID != nil {
+		conditions = append(conditions, fmt.Sprintf("q.sub_domain_id = $%d", paramIndex))
+		args = append(args, *req.SubDomainID)
+		paramIndex++
+	}
+	if req.DifficultyLevelID != nil {
+		conditions = append(conditions, fmt.Sprintf("q.difficulty_level_id = $%d", paramIndex))
+		args = append(args, *req.DifficultyLevelID)
+		paramIndex++
+	}
+	if req.Published != nil {
+		conditions = append(conditions, fmt.Sprintf("q.published = $%d", paramIndex))
+		args = append(args, *req.Published)
+		paramIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM quiz_schema.quizzes q`+whereClause, args...).Scan(&total); err != nil {
+		r.logger.Error("Failed to count quizzes", "error", err)
+		return nil, 0, fmt.Errorf("failed to count quizzes: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM quiz_schema.quizzes q%s ORDER BY q.created_at DESC, q.id DESC LIMIT $%d OFFSET $%d`,
+		quizColumns, whereClause, paramIndex, paramIndex+1,
+	)
+	args = append(args, req.PageSize, (req.Page-1)*req.PageSize)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list quizzes", "error", err)
+		return nil, 0, fmt.Errorf("failed to list quizzes: %w", err)
+	}
+	defer rows.Close()
+
+	quizzes := []*quiz.Quiz{}
+	for rows.Next() {
+		q, err := scanQuiz(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan quiz", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan quiz: %w", err)
+		}
+		quizzes = append(quizzes, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over quiz rows", "error", err)
+		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return quizzes, total, nil
+}
+
+// UpdateQuiz updates the details and published state of a quiz
+func (r *PostgresQuizRepository) UpdateQuiz(ctx context.Context, q *quiz.Quiz) error {
+	blueprint, err := encodeBlueprint(q.Blueprint)
+	if err != nil {
+		return err
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+	UPDATE quiz_schema.quizzes SET
+		title = $2,
+		description = $3,
+		domain_id = $4,
+		sub_domain_id = $5,
+		difficulty_level_id = $6,
+		time_limit_minutes = $7,
+		passing_score = $8,
+		max_concurrent_attempts = $9,
+		blueprint = $10,
+		published = $11,
+		published_at = $12,
+		updated_at = $13
+	WHERE id = $1`,
+		q.ID,
+		q.Title,
+		q.Description,
+		q.DomainID,
+		q.SubDomainID,
+		q.DifficultyLevelID,
+		q.TimeLimitMinutes,
+		q.PassingScore,
+		q.MaxConcurrentAttempts,
+		blueprint,
+		q.Published,
+		q.PublishedAt,
+		q.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to update quiz", "id", q.ID, "error", err)
+		return fmt.Errorf("failed to update quiz: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quiz.ErrQuizNotFound
+	}
+
+	return nil
+}
+
+// DeleteQuiz deletes a quiz, its questions go with it
+func (r *PostgresQuizRepository) DeleteQuiz(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM quiz_schema.quizzes WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return quiz.ErrQuizHasAttempts
+		}
+		r.logger.Error("Failed to delete quiz", "id", id, "error", err)
+		return fmt.Errorf("failed to delete quiz: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return quiz.ErrQuizNotFound
+	}
+
+	return nil
+}
+
+// GetQuestions retrieves the questions of a quiz in order
+func (r *PostgresQuizRepository) GetQuestions(ctx context.Context, quizID int64) ([]quiz.Question, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+questionColumns+`
+	FROM quiz_schema.questions
+	WHERE quiz_id = $1
+	ORDER BY position`,
+		quizID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get questions", "quiz_id", quizID, "error", err)
+		return nil, fmt.Errorf("failed to get questions: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []quiz.Question{}
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan question", "error", err)
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		questions = append(questions, question)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over question rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return questions, nil
+}
+
+// AddQuestion inserts a question at its position, or appends it when it has none
+func (r *PostgresQuizRepository) AddQuestion(ctx context.Context, question *quiz.Question) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := lockQuizQuestionsTx(ctx, tx, question.QuizID)
+	if err != nil {
+		return err
+	}
+
+	content, err := questionpkg.Marshal(question.Content)
+	if err != nil {
+		return err
+	}
+
+	if question.Position < 1 || question.Position > count+1 {
+		question.Position = count + 1
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE quiz_schema.questions SET position = position + 1 WHERE quiz_id = $1 AND position >= $2`,
+		question.QuizID, question.Position,
+	)
+	if err != nil {
+		r.logger.Error("Failed to make room for question", "quiz_id", question.QuizID, "error", err)
+		return fmt.Errorf("failed to move questions: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO quiz_schema.questions (
+		quiz_id, position, prompt, content, explanation, points, created_at, updated_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)
+	RETURNING id`,
+		question.QuizID,
+		question.Position,
+		question.Prompt,
+		content,
+		question.Explanation,
+		question.Points,
+		question.CreatedAt,
+		question.UpdatedAt,
+	).Scan(&question.ID)
+	if err != nil {
+		r.logger.Error("Failed to add question", "quiz_id", question.QuizID, "error", err)
+		return fmt.Errorf("failed to add question: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit question", "quiz_id", question.QuizID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateQuestion replaces a question, moving the questions between its old and new position
+func (r *PostgresQuizRepository) UpdateQuestion(ctx context.Context, question *quiz.Question) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := lockQuizQuestionsTx(ctx, tx, question.QuizID)
+	if err != nil {
+		return err
+	}
+
+	content, err := questionpkg.Marshal(question.Content)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = tx.QueryRow(ctx, `
+	SELECT position FROM quiz_schema.questions WHERE id = $1 AND quiz_id = $2`,
+		question.ID, question.QuizID,
+	).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return quiz.ErrQuestionNotFound
+		}
+		r.logger.Error("Failed to get question", "id", question.ID, "error", err)
+		return fmt.Errorf("failed to get question: %w", err)
+	}
+
+	if question.Position < 1 {
+		question.Position = current
+	}
+	if question.Position > count {
+		question.Position = count
+	}
+
+	// The questions in between shift by one towards the place the question leaves
+	if question.Position != current {
+		_, err = tx.Exec(ctx, `
+		UPDATE quiz_schema.questions SET
+			position = position + CASE WHEN $2 < $3 THEN 1 ELSE -1 END
+		WHERE quiz_id = $1 AND position BETWEEN LEAST($2, $3) AND GREATEST($2, $3) AND position <> $3`,
+			question.QuizID, question.Position, current,
+		)
+		if err != nil {
+			r.logger.Error("Failed to move questions", "quiz_id", question.QuizID, "error", err)
+			return fmt.Errorf("failed to move questions: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+	UPDATE quiz_schema.questions SET
+		position = $3,
+		prompt = $4,
+		content = $5,
+		explanation = $6,
+		points = $7,
+		updated_at = $8
+	WHERE id = $1 AND quiz_id = $2
+	RETURNING created_at`,
+		question.ID,
+		question.QuizID,
+		question.Position,
+		question.Prompt,
+		content,
+		question.Explanation,
+		question.Points,
+		question.UpdatedAt,
+	).Scan(&question.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to update question", "id", question.ID, "error", err)
+		return fmt.Errorf("failed to update question: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit question", "id", question.ID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteQuestion deletes a question and moves the following questions up
+func (r *PostgresQuizRepository) DeleteQuestion(ctx context.Context, quizID, questionID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockQuizQuestionsTx(ctx, tx, quizID); err != nil {
+		return err
+	}
+
+	var position int
+	err = tx.QueryRow(ctx, `
+	DELETE FROM quiz_schema.questions WHERE id = $1 AND quiz_id = $2 RETURNING position`,
+		questionID, quizID,
+	).Scan(&position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return quiz.ErrQuestionNotFound
+		}
+		r.logger.Error("Failed to delete question", "id", questionID, "error", err)
+		return fmt.Errorf("failed to delete question: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE quiz_schema.questions SET position = position - 1 WHERE quiz_id = $1 AND position > $2`,
+		quizID, position,
+	)
+	if err != nil {
+		r.logger.Error("Failed to move questions", "quiz_id", quizID, "error", err)
+		return fmt.Errorf("failed to move questions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit question deletion", "id", questionID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderQuestions numbers the questions in the order of questionIDs
+func (r *PostgresQuizRepository) ReorderQuestions(ctx context.Context, quizID int64, questionIDs []int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := lockQuizQuestionsTx(ctx, tx, quizID)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+	UPDATE quiz_schema.questions q SET
+		position = o.position
+	FROM unnest($2::bigint[]) WITH ORDINALITY AS o (id, position)
+	WHERE q.id = o.id AND q.quiz_id = $1`,
+		quizID, questionIDs,
+	)
+	if err != nil {
+		r.logger.Error("Failed to reorder questions", "quiz_id", quizID, "error", err)
+		return fmt.Errorf("failed to reorder questions: %w", err)
+	}
+	if int(tag.RowsAffected()) != len(questionIDs) || len(questionIDs) != count {
+		return fmt.Errorf("%w: every question of the quiz must be listed once", quiz.ErrInvalidQuestionOrder)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit question order", "quiz_id", quizID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateAttempt starts an attempt with its paper and sets its ID, unless the student already has
+// maxConcurrent attempts of the quiz running
+func (r *PostgresQuizRepository) CreateAttempt(ctx context.Context, attempt *quiz.Attempt, paper []quiz.PaperQuestion, maxConcurrent int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Attempts of the same student and quiz start one at a time, so that concurrent starts can't
+	// both pass the limit
+	_, err = tx.Exec(ctx, `
+	SELECT pg_advisory_xact_lock(hashtextextended($1::text || ':' || $2::text, 0))`,
+		attempt.QuizID, attempt.StudentID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to lock attempts", "quiz_id", attempt.QuizID, "student_id", attempt.StudentID, "error", err)
+		return fmt.Errorf("failed to lock attempts: %w", err)
+	}
+
+	var running int
+	err = tx.QueryRow(ctx, `
+	SELECT COUNT(*)
+	FROM quiz_schema.attempts
+	WHERE quiz_id = $1 AND student_id = $2 AND status = $3 AND deadline > $4`,
+		attempt.QuizID, attempt.StudentID, quiz.AttemptInProgress, attempt.StartTime,
+	).Scan(&running)
+	if err != nil {
+		r.logger.Error("Failed to count running attempts", "quiz_id", attempt.QuizID, "student_id", attempt.StudentID, "error", err)
+		return fmt.Errorf("failed to count running attempts: %w", err)
+	}
+	if running >= maxConcurrent {
+		return quiz.ErrAttemptLimitReached
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO quiz_schema.attempts (quiz_id, student_id, status, start_time, deadline, max_score, paper_seed)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id`,
+		attempt.QuizID,
+		attempt.StudentID,
+		attempt.Status,
+		attempt.StartTime,
+		attempt.Deadline,
+		attempt.MaxScore,
+		attempt.PaperSeed,
+	).Scan(&attempt.ID)
+	if err != nil {
+		r.logger.Error("Failed to create attempt", "quiz_id", attempt.QuizID, "student_id", attempt.StudentID, "error", err)
+		return fmt.Errorf("failed to create attempt: %w", err)
+	}
+
+	questionIDs := make([]int64, len(paper))
+	positions := make([]int32, len(paper))
+	prompts := make([]string, len(paper))
+	contents := make([]string, len(paper)) // JSON documents, cast to JSONB by the query
+	explanations := make([]string, len(paper))
+	points := make([]float64, len(paper))
+	for i, q := range paper {
+		content, err := questionpkg.Marshal(q.Content)
+		if err != nil {
+			return err
+		}
+
+		questionIDs[i] = q.ID
+		positions[i] = int32(q.Position)
+		prompts[i] = q.Prompt
+		contents[i] = string(content)
+		explanations[i] = q.Explanation
+		points[i] = q.Points
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO quiz_schema.attempt_questions (attempt_id, question_id, position, prompt, content, explanation, points)
+	SELECT $1, p.question_id, p.position, p.prompt, p.content::jsonb, p.explanation, p.points
+	FROM unnest($2::bigint[], $3::int[], $4::text[], $5::text[], $6::text[], $7::real[])
+		AS p (question_id, position, prompt, content, explanation, points)`,
+		attempt.ID, questionIDs, positions, prompts, contents, explanations, points,
+	)
+	if err != nil {
+		r.logger.Error("Failed to store attempt paper", "attempt_id", attempt.ID, "error", err)
+		return fmt.Errorf("failed to store attempt paper: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit attempt", "quiz_id", attempt.QuizID, "student_id", attempt.StudentID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttempt retrieves an attempt.
+// Returns nil without an error when the attempt does not exist.
+func (r *PostgresQuizRepository) GetAttempt(ctx context.Context, id int64) (*quiz.Attempt, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+attemptColumns+` FROM quiz_schema.attempts WHERE id = $1`, id)
+
+	attempt, err := scanAttempt(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get attempt", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get attempt: %w", err)
+	}
+
+	return attempt, nil
+}
+
+// GetPaper retrieves the questions of an attempt in order
+func (r *PostgresQuizRepository) GetPaper(ctx context.Context, attemptID int64) ([]quiz.PaperQuestion, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT question_id, position, prompt, content, explanation, points
+	FROM quiz_schema.attempt_questions
+	WHERE attempt_id = $1
+	ORDER BY position`,
+		attemptID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get attempt paper", "attempt_id", attemptID, "error", err)
+		return nil, fmt.Errorf("failed to get attempt paper: %w", err)
+	}
+
+	paper, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (quiz.PaperQuestion, error) {
+		var q quiz.PaperQuestion
+		var content []byte
+		if err := row.Scan(&q.ID, &q.Position, &q.Prompt, &content, &q.Explanation, &q.Points); err != nil {
+			return q, err
+		}
+
+		q.Content, err = questionpkg.Unmarshal(content)
+		if err != nil {
+			return q, err
+		}
+		q.Type = q.Content.Type()
+		return q, nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan attempt paper", "attempt_id", attemptID, "error", err)
+		return nil, fmt.Errorf("failed to scan attempt paper: %w", err)
+	}
+
+	return paper, nil
+}
+
+// ListAttempts retrieves the attempts of a student, the latest first
+func (r *PostgresQuizRepository) ListAttempts(ctx context.Context, studentID uuid.UUID) ([]*quiz.Attempt, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+attemptColumns+`
+	FROM quiz_schema.attempts
+	WHERE student_id = $1
+	ORDER BY start_time DESC`,
+		studentID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to list attempts", "student_id", studentID, "error", err)
+		return nil, fmt.Errorf("failed to list attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []*quiz.Attempt{}
+	for rows.Next() {
+		attempt, err := scanAttempt(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan attempt", "error", err)
+			return nil, fmt.Errorf("failed to scan attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over attempt rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ListExpiredAttempts retrieves attempts in progress whose deadline is before the time and that
+// are not postponed past now
+func (r *PostgresQuizRepository) ListExpiredAttempts(ctx context.Context, before, now time.Time, limit int) ([]*quiz.Attempt, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+attemptColumns+`
+	FROM quiz_schema.attempts
+	WHERE status = $1 AND deadline < $2 AND (next_sweep_at IS NULL OR next_sweep_at <= $3)
+	ORDER BY deadline
+	LIMIT $4`,
+		quiz.AttemptInProgress, before, now, limit,
+	)
+	if err != nil {
+		r.logger.Error("Failed to list expired attempts", "error", err)
+		return nil, fmt.Errorf("failed to list expired attempts: %w", err)
+	}
+
+	attempts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*quiz.Attempt, error) {
+		return scanAttempt(row)
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan expired attempts", "error", err)
+		return nil, fmt.Errorf("failed to scan expired attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// ListAttemptsToRegrade retrieves submitted attempts with answers flagged to be graded again
+func (r *PostgresQuizRepository) ListAttemptsToRegrade(ctx context.Context, now time.Time, limit int) ([]*quiz.Attempt, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+attemptColumns+`
+	FROM quiz_schema.attempts a
+	WHERE status = $1 AND (next_sweep_at IS NULL OR next_sweep_at <= $2)
+		AND EXISTS (SELECT 1 FROM quiz_schema.attempt_answers aa WHERE aa.attempt_id = a.id AND aa.needs_regrade)
+	ORDER BY end_time
+	LIMIT $3`,
+		quiz.AttemptSubmitted, now, limit,
+	)
+	if err != nil {
+		r.logger.Error("Failed to list attempts to regrade", "error", err)
+		return nil, fmt.Errorf("failed to list attempts to regrade: %w", err)
+	}
+
+	attempts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*quiz.Attempt, error) {
+		return scanAttempt(row)
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan attempts to regrade", "error", err)
+		return nil, fmt.Errorf("failed to scan attempts to regrade: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// PostponeSweep counts a failure to submit or regrade an attempt and postpones its next sweep. The
+// delay doubles with every failure, the exponent is capped to keep the interval in range.
+// Submitting an attempt resets the count, so regrading starts over with the shortest delay.
+func (r *PostgresQuizRepository) PostponeSweep(ctx context.Context, attemptID int64, now time.Time, delay, maxDelay time.Duration) error {
+	_, err := r.pool.Exec(ctx, `
+	UPDATE quiz_schema.attempts SET
+		sweep_failures = sweep_failures + 1,
+		next_sweep_at = $2 + LEAST(
+			make_interval(secs => $3::float8 * power(2, LEAST(sweep_failures, 30))),
+			make_interval(secs => $4::float8)
+		)
+	WHERE id = $1`,
+		attemptID, now, delay.Seconds(), maxDelay.Seconds(),
+	)
+	if err != nil {
+		r.logger.Error("Failed to postpone attempt sweep", "attempt_id", attemptID, "error", err)
+		return fmt.Errorf("failed to postpone attempt sweep: %w", err)
+	}
+
+	return nil
+}
+
+// SaveAnswer stores or replaces the answer to a question while the attempt is open. The attempt
+// is locked so that an answer can't slip in while the attempt is being submitted.
+func (r *PostgresQuizRepository) SaveAnswer(ctx context.Context, attemptID, questionID int64, answer json.RawMessage, savedAt, openAfter time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+	INSERT INTO quiz_schema.attempt_answers (attempt_id, question_id, answer, saved_at)
+	SELECT a.id, q.question_id, $3::jsonb, $4
+	FROM quiz_schema.attempts a
+	JOIN quiz_schema.attempt_questions q ON q.attempt_id = a.id AND q.question_id = $2
+	WHERE a.id = $1 AND a.status = $5 AND a.deadline > $6
+	FOR SHARE OF a
+	ON CONFLICT (attempt_id, question_id) DO UPDATE SET
+		answer = EXCLUDED.answer,
+		saved_at = EXCLUDED.saved_at`,
+		attemptID, questionID, string(answer), savedAt, quiz.AttemptInProgress, openAfter,
+	)
+	if err != nil {
+		r.logger.Error("Failed to save answer", "attempt_id", attemptID, "question_id", questionID, "error", err)
+		return false, fmt.Errorf("failed to save answer: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetAttemptAnswers retrieves the answers of an attempt in the order of its paper
+func (r *PostgresQuizRepository) GetAttemptAnswers(ctx context.Context, attemptID int64) ([]quiz.GradedAnswer, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT a.question_id, a.answer, a.is_correct, a.points_awarded, a.test_results, a.needs_regrade
+	FROM quiz_schema.attempt_answers a
+	JOIN quiz_schema.attempt_questions q ON q.attempt_id = a.attempt_id AND q.question_id = a.question_id
+	WHERE a.attempt_id = $1
+	ORDER BY q.position`,
+		attemptID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get attempt answers", "attempt_id", attemptID, "error", err)
+		return nil, fmt.Errorf("failed to get attempt answers: %w", err)
+	}
+
+	answers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (quiz.GradedAnswer, error) {
+		var answer quiz.GradedAnswer
+		err := row.Scan(&answer.QuestionID, &answer.Answer, &answer.IsCorrect, &answer.PointsAwarded, &answer.Tests, &answer.NeedsRegrade)
+		return answer, err
+	})
+	if err != nil {
+		r.logger.Error("Failed to scan attempt answers", "attempt_id", attemptID, "error", err)
+		return nil, fmt.Errorf("failed to scan attempt answers: %w", err)
+	}
+
+	return answers, nil
+}
+
+// SubmitAttempt stores the graded answers and the score of an attempt in progress
+func (r *PostgresQuizRepository) SubmitAttempt(ctx context.Context, attempt *quiz.Attempt, answers []quiz.GradedAnswer) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Only one submission of the attempt can move it out of progress
+	tag, err := tx.Exec(ctx, `
+	UPDATE quiz_schema.attempts SET
+		status = $2,
+		end_time = $3,
+		auto_submitted = $4,
+		score = $5,
+		passed = $6,
+		sweep_failures = 0,
+		next_sweep_at = NULL
+	WHERE id = $1 AND status = $7`,
+		attempt.ID,
+		attempt.Status,
+		attempt.EndTime,
+		attempt.AutoSubmitted,
+		attempt.Score,
+		attempt.Passed,
+		quiz.AttemptInProgress,
+	)
+	if err != nil {
+		r.logger.Error("Failed to submit attempt", "id", attempt.ID, "error", err)
+		return false, fmt.Errorf("failed to submit attempt: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	columns, err := newGradedAnswerColumns(answers)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO quiz_schema.attempt_answers (attempt_id, question_id, answer, is_correct, points_awarded, test_results, needs_regrade)
+	SELECT $1, a.question_id, a.answer::jsonb, a.is_correct, a.points_awarded, a.test_results::jsonb, a.needs_regrade
+	FROM unnest($2::bigint[], $3::text[], $4::boolean[], $5::real[], $6::text[], $7::boolean[])
+		AS a (question_id, answer, is_correct, points_awarded, test_results, needs_regrade)
+	ON CONFLICT (attempt_id, question_id) DO UPDATE SET
+		answer = EXCLUDED.answer,
+		is_correct = EXCLUDED.is_correct,
+		points_awarded = EXCLUDED.points_awarded,
+		test_results = EXCLUDED.test_results,
+		needs_regrade = EXCLUDED.needs_regrade`,
+		attempt.ID, columns.questionIDs, columns.texts, columns.correct, columns.points, columns.tests, columns.regrade,
+	)
+	if err != nil {
+		r.logger.Error("Failed to store attempt answers", "id", attempt.ID, "error", err)
+		return false, fmt.Errorf("failed to store attempt answers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit attempt", "id", attempt.ID, "error", err)
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// RegradeAttempt stores the answers of a submitted attempt graded again and its new score. The
+// attempt is locked first, so that concurrent regrades of it are applied one after the other.
+func (r *PostgresQuizRepository) RegradeAttempt(ctx context.Context, attempt *quiz.Attempt, answers []quiz.GradedAnswer) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+	UPDATE quiz_schema.attempts SET
+		score = $2,
+		passed = $3
+	WHERE id = $1 AND status = $4`,
+		attempt.ID,
+		attempt.Score,
+		attempt.Passed,
+		quiz.AttemptSubmitted,
+	)
+	if err != nil {
+		r.logger.Error("Failed to update score of regraded attempt", "id", attempt.ID, "error", err)
+		return fmt.Errorf("failed to update attempt score: %w", err)
+	}
+
+	columns, err := newGradedAnswerColumns(answers)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE quiz_schema.attempt_answers aa SET
+		is_correct = a.is_correct,
+		points_awarded = a.points_awarded,
+		test_results = a.test_results::jsonb,
+		needs_regrade = a.needs_regrade
+	FROM unnest($2::bigint[], $3::boolean[], $4::real[], $5::text[], $6::boolean[])
+		AS a (question_id, is_correct, points_awarded, test_results, needs_regrade)
+	WHERE aa.attempt_id = $1 AND aa.question_id = a.question_id AND aa.needs_regrade`,
+		attempt.ID, columns.questionIDs, columns.correct, columns.points, columns.tests, columns.regrade,
+	)
+	if err != nil {
+		r.logger.Error("Failed to store regraded answers", "id", attempt.ID, "error", err)
+		return fmt.Errorf("failed to store regraded answers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit regraded attempt", "id", attempt.ID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// gradedAnswerColumns holds graded answers column by column, to be unnested by a query
+type gradedAnswerColumns struct {
+	questionIDs []int64
+	texts       []string // JSON documents, cast to JSONB by the query
+	correct     []bool
+	points      []float64
+	tests       []*string // Test results of code questions, NULL for other questions
+	regrade     []bool
+}
+
+// newGradedAnswerColumns splits graded answers into columns, encoding the test results
+func newGradedAnswerColumns(answers []quiz.GradedAnswer) (*gradedAnswerColumns, error) {
+	columns := &gradedAnswerColumns{
+		questionIDs: make([]int64, len(answers)),
+		texts:       make([]string, len(answers)),
+		correct:     make([]bool, len(answers)),
+		points:      make([]float64, len(answers)),
+		tests:       make([]*string, len(answers)),
+		regrade:     make([]bool, len(answers)),
+	}
+	for i, answer := range answers {
+		columns.questionIDs[i] = answer.QuestionID
+		columns.texts[i] = string(answer.Answer)
+		columns.correct[i] = answer.IsCorrect
+		columns.points[i] = answer.PointsAwarded
+		columns.regrade[i] = answer.NeedsRegrade
+
+		if len(answer.Tests) > 0 {
+			encoded, err := json.Marshal(answer.Tests)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode test results: %w", err)
+			}
+			results := string(encoded)
+			columns.tests[i] = &results
+		}
+	}
+
+	return columns, nil
+}
+
+// lockQuizQuestionsTx locks a quiz until the transaction ends, so that concurrent changes to its
+// questions keep their positions contiguous, and returns its number of questions
+func lockQuizQuestionsTx(ctx context.Context, tx pgx.Tx, quizID int64) (int, error) {
+	var count int
+	err := tx.QueryRow(ctx, `
+	SELECT (SELECT COUNT(*) FROM quiz_schema.questions WHERE quiz_id = q.id)
+	FROM quiz_schema.quizzes q
+	WHERE q.id = $1
+	FOR UPDATE`,
+		quizID,
+	).Scan(&count)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, quiz.ErrQuizNotFound
+		}
+		return 0, fmt.Errorf("failed to lock quiz: %w", err)
+	}
+
+	return count, nil
+}
+
+// scanQuiz scans a row selected with quizColumns
+func scanQuiz(row pgx.Row) (*quiz.Quiz, error) {
+	var q quiz.Quiz
+	err := row.Scan(
+		&q.ID,
+		&q.Title,
+		&q.Description,
+		&q.DomainID,
+		&q.SubDomainID,
+		&q.DifficultyLevelID,
+		&q.TimeLimitMinutes,
+		&q.PassingScore,
+		&q.MaxConcurrentAttempts,
+		&q.Blueprint,
+		&q.Published,
+		&q.PublishedAt,
+		&q.QuestionCount,
+		&q.TotalPoints,
+		&q.CreatedBy,
+		&q.CreatedAt,
+		&q.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quizzes with a blueprint have no questions of their own, every paper has the questions the
+	// blueprint draws
+	if len(q.Blueprint) > 0 {
+		q.QuestionCount = q.Blueprint.QuestionCount()
+		q.TotalPoints = q.Blueprint.TotalPoints()
+	}
+	return &q, nil
+}
+
+// encodeBlueprint encodes the blueprint of a quiz, quizzes without one store an empty list
+func encodeBlueprint(blueprint quiz.Blueprint) (string, error) {
+	if len(blueprint) == 0 {
+		return "[]", nil
+	}
+
+	encoded, err := json.Marshal(blueprint)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode blueprint: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// scanQuestion scans a row selected with questionColumns
+func scanQuestion(row pgx.Row) (quiz.Question, error) {
+	var question quiz.Question
+	var content []byte
+	err := row.Scan(
+		&question.ID,
+		&question.QuizID,
+		&question.Position,
+		&question.Prompt,
+		&content,
+		&question.Explanation,
+		&question.Points,
+		&question.CreatedAt,
+		&question.UpdatedAt,
+	)
+	if err != nil {
+		return question, err
+	}
+
+	question.Content, err = questionpkg.Unmarshal(content)
+	if err != nil {
+		return question, err
+	}
+	question.Type = question.Content.Type()
+	return question, nil
+}
+
+// scanAttempt scans a row selected with attemptColumns
+func scanAttempt(row pgx.Row) (*quiz.Attempt, error) {
+	var attempt quiz.Attempt
+	err := row.Scan(
+		&attempt.ID,
+		&attempt.QuizID,
+		&attempt.StudentID,
+		&attempt.Status,
+		&attempt.StartTime,
+		&attempt.Deadline,
+		&attempt.EndTime,
+		&attempt.AutoSubmitted,
+		&attempt.Score,
+		&attempt.MaxScore,
+		&attempt.Passed,
+		&attempt.PaperSeed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
@@ -0,0 +1,703 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/internal/domain/role"
+	"server/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresRoleRepository implements the role.Repository interface
+type PostgresRoleRepository struct {
+	pool   *pgxpool.Pool
+	logger *logger.Logger
+}
+
+// NewPostgresRoleRepository creates a new PostgreSQL-backed role repository
+func NewPostgresRoleRepository(pool *pgxpool.Pool, logger *logger.Logger) role.Repository {
+	return &PostgresRoleRepository{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// roleColumns are the columns scanned by scanRole, in order
+const roleColumns = `id, name, COALESCE(description, ''), created_at, updated_at, inherits_from`
+
+// permissionColumns are the columns scanned by scanPermission, in order
+const permissionColumns = `p.id, p.resource, p.action, COALESCE(p.description, ''), p.created_at, p.updated_at`
+
+// CreateRole stores a new role. Its permissions are assigned separately.
+func (r *PostgresRoleRepository) CreateRole(ctx context.Context, rl *role.Role) error {
+	r.logger.Debug("Creating role", "name", rl.Name)
+
+	query := `
+	INSERT INTO profile_schema.roles (id, name, description, created_at, updated_at, inherits_from)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if _, err := r.pool.Exec(ctx, query, rl.ID, rl.Name, rl.Description, rl.CreatedAt, rl.UpdatedAt, rl.InheritsFrom); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return role.ErrDuplicateRole
+		}
+		r.logger.Error("Failed to create role", "name", rl.Name, "error", err)
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+
+	r.logger.Info("Role created", "role_id", rl.ID, "name", rl.Name)
+	return nil
+}
+
+// GetRoleByID retrieves a role along with its permissions. It returns nil if the role does not exist.
+func (r *PostgresRoleRepository) GetRoleByID(ctx context.Context, id uuid.UUID) (*role.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM profile_schema.roles WHERE id = $1`
+	return r.getRole(ctx, query, id)
+}
+
+// GetRoleByName retrieves a role along with its permissions. It returns nil if the role does not exist.
+func (r *PostgresRoleRepository) GetRoleByName(ctx context.Context, name string) (*role.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM profile_schema.roles WHERE name = $1`
+	return r.getRole(ctx, query, name)
+}
+
+// getRole scans the role selected by the query and loads its permissions
+func (r *PostgresRoleRepository) getRole(ctx context.Context, query string, arg interface{}) (*role.Role, error) {
+	rl, err := scanRole(r.pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get role", "role", arg, "error", err)
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	rl.Permissions, err = r.GetRolePermissions(ctx, rl.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// ListRoles retrieves every role along with its permissions
+func (r *PostgresRoleRepository) ListRoles(ctx context.Context) ([]role.Role, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+roleColumns+` FROM profile_schema.roles ORDER BY name`)
+	if err != nil {
+		r.logger.Error("Failed to list roles", "error", err)
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []role.Role{}
+	positions := make(map[uuid.UUID]int)
+	for rows.Next() {
+		rl, err := scanRole(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan role", "error", err)
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		rl.Permissions = []role.Permission{}
+		positions[rl.ID] = len(roles)
+		roles = append(roles, *rl)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over role rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	// The permissions of every role in one query
+	permissionRows, err := r.pool.Query(ctx, `
+	SELECT rp.role_id, `+permissionColumns+`
+	FROM profile_schema.role_permissions rp
+	JOIN profile_schema.permissions p ON p.id = rp.permission_id
+	ORDER BY p.resource, p.action`)
+	if err != nil {
+		r.logger.Error("Failed to list role permissions", "error", err)
+		return nil, fmt.Errorf("failed to list role permissions: %w", err)
+	}
+	defer permissionRows.Close()
+
+	for permissionRows.Next() {
+		var roleID uuid.UUID
+		var permission role.Permission
+		if err := permissionRows.Scan(
+			&roleID,
+			&permission.ID,
+			&permission.Resource,
+			&permission.Action,
+			&permission.Description,
+			&permission.CreatedAt,
+			&permission.UpdatedAt,
+		); err != nil {
+			r.logger.Error("Failed to scan role permission", "error", err)
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		if i, ok := positions[roleID]; ok {
+			roles[i].Permissions = append(roles[i].Permissions, permission)
+		}
+	}
+	if err := permissionRows.Err(); err != nil {
+		r.logger.Error("Error iterating over role permission rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// UpdateRole updates the name, description and inherited role of a role
+func (r *PostgresRoleRepository) UpdateRole(ctx context.Context, rl *role.Role) error {
+	query := `
+	UPDATE profile_schema.roles SET
+		name = $2,
+		description = $3,
+		updated_at = $4,
+		inherits_from = $5
+	WHERE id = $1`
+
+	commandTag, err := r.pool.Exec(ctx, query, rl.ID, rl.Name, rl.Description, rl.UpdatedAt, rl.InheritsFrom)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return role.ErrDuplicateRole
+		}
+		r.logger.Error("Failed to update role", "role_id", rl.ID, "error", err)
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrRoleNotFound
+	}
+
+	r.logger.Info("Role updated", "role_id", rl.ID, "name", rl.Name)
+	return nil
+}
+
+// DeleteRole deletes a role along with its permission and profile assignments
+func (r *PostgresRoleRepository) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "role_id", id, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.profile_roles WHERE role_id = $1`, id); err != nil {
+		r.logger.Error("Failed to remove role from profiles", "role_id", id, "error", err)
+		return fmt.Errorf("failed to remove role from profiles: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.role_permissions WHERE role_id = $1`, id); err != nil {
+		r.logger.Error("Failed to remove role permissions", "role_id", id, "error", err)
+		return fmt.Errorf("failed to remove role permissions: %w", err)
+	}
+
+	// Roles inheriting from the deleted role keep only their own permissions
+	if _, err := tx.Exec(ctx, `UPDATE profile_schema.roles SET inherits_from = NULL WHERE inherits_from = $1`, id); err != nil {
+		r.logger.Error("Failed to detach inheriting roles", "role_id", id, "error", err)
+		return fmt.Errorf("failed to detach inheriting roles: %w", err)
+	}
+
+	commandTag, err := tx.Exec(ctx, `DELETE FROM profile_schema.roles WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete role", "role_id", id, "error", err)
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrRoleNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "role_id", id, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Role deleted", "role_id", id)
+	return nil
+}
+
+// CreatePermission stores a new permission. A resource and action pair exists only once.
+func (r *PostgresRoleRepository) CreatePermission(ctx context.Context, permission *role.Permission) error {
+	query := `
+	INSERT INTO profile_schema.permissions (id, resource, action, description, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if _, err := r.pool.Exec(
+		ctx,
+		query,
+		permission.ID,
+		permission.Resource,
+		permission.Action,
+		permission.Description,
+		permission.CreatedAt,
+		permission.UpdatedAt,
+	); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return role.ErrDuplicatePermission
+		}
+		r.logger.Error("Failed to create permission", "resource", permission.Resource, "action", permission.Action, "error", err)
+		return fmt.Errorf("failed to create permission: %w", err)
+	}
+
+	r.logger.Info("Permission created", "permission_id", permission.ID, "resource", permission.Resource, "action", permission.Action)
+	return nil
+}
+
+// GetPermissionByID retrieves a permission. It returns nil if the permission does not exist.
+func (r *PostgresRoleRepository) GetPermissionByID(ctx context.Context, id uuid.UUID) (*role.Permission, error) {
+	query := `SELECT ` + permissionColumns + ` FROM profile_schema.permissions p WHERE p.id = $1`
+
+	permission, err := scanPermission(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get permission", "permission_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+
+	return permission, nil
+}
+
+// ListPermissions retrieves every permission
+func (r *PostgresRoleRepository) ListPermissions(ctx context.Context) ([]role.Permission, error) {
+	query := `SELECT ` + permissionColumns + ` FROM profile_schema.permissions p ORDER BY p.resource, p.action`
+	return r.queryPermissions(ctx, query)
+}
+
+// UpdatePermission updates the resource, action and description of a permission
+func (r *PostgresRoleRepository) UpdatePermission(ctx context.Context, permission *role.Permission) error {
+	query := `
+	UPDATE profile_schema.permissions SET
+		resource = $2,
+		action = $3,
+		description = $4,
+		updated_at = $5
+	WHERE id = $1`
+
+	commandTag, err := r.pool.Exec(
+		ctx,
+		query,
+		permission.ID,
+		permission.Resource,
+		permission.Action,
+		permission.Description,
+		permission.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return role.ErrDuplicatePermission
+		}
+		r.logger.Error("Failed to update permission", "permission_id", permission.ID, "error", err)
+		return fmt.Errorf("failed to update permission: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrPermissionNotFound
+	}
+
+	return nil
+}
+
+// DeletePermission deletes a permission and removes it from every role
+func (r *PostgresRoleRepository) DeletePermission(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "permission_id", id, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.role_permissions WHERE permission_id = $1`, id); err != nil {
+		r.logger.Error("Failed to remove permission from roles", "permission_id", id, "error", err)
+		return fmt.Errorf("failed to remove permission from roles: %w", err)
+	}
+
+	commandTag, err := tx.Exec(ctx, `DELETE FROM profile_schema.permissions WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete permission", "permission_id", id, "error", err)
+		return fmt.Errorf("failed to delete permission: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrPermissionNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "permission_id", id, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Permission deleted", "permission_id", id)
+	return nil
+}
+
+// AssignPermissionToRole grants a permission to a role. Granting it again is a no-op.
+func (r *PostgresRoleRepository) AssignPermissionToRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
+	query := `
+	INSERT INTO profile_schema.role_permissions (role_id, permission_id, created_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (role_id, permission_id) DO NOTHING`
+
+	if _, err := r.pool.Exec(ctx, query, roleID, permissionID, time.Now()); err != nil {
+		r.logger.Error("Failed to assign permission to role", "role_id", roleID, "permission_id", permissionID, "error", err)
+		return fmt.Errorf("failed to assign permission to role: %w", err)
+	}
+
+	return nil
+}
+
+// RemovePermissionFromRole revokes a permission from a role
+func (r *PostgresRoleRepository) RemovePermissionFromRole(ctx context.Context, roleID, permissionID uuid.UUID) error {
+	query := `DELETE FROM profile_schema.role_permissions WHERE role_id = $1 AND permission_id = $2`
+
+	commandTag, err := r.pool.Exec(ctx, query, roleID, permissionID)
+	if err != nil {
+		r.logger.Error("Failed to remove permission from role", "role_id", roleID, "permission_id", permissionID, "error", err)
+		return fmt.Errorf("failed to remove permission from role: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrPermissionNotFound
+	}
+
+	return nil
+}
+
+// GetRolePermissions retrieves the permissions granted to a role
+func (r *PostgresRoleRepository) GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]role.Permission, error) {
+	query := `
+	SELECT ` + permissionColumns + `
+	FROM profile_schema.role_permissions rp
+	JOIN profile_schema.permissions p ON p.id = rp.permission_id
+	WHERE rp.role_id = $1
+	ORDER BY p.resource, p.action`
+
+	return r.queryPermissions(ctx, query, roleID)
+}
+
+// SyncRolePermissions replaces the permissions of a role in one transaction
+func (r *PostgresRoleRepository) SyncRolePermissions(ctx context.Context, roleID uuid.UUID, permissionIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM profile_schema.role_permissions WHERE role_id = $1 AND NOT (permission_id = ANY($2))`, roleID, permissionIDs); err != nil {
+		r.logger.Error("Failed to remove role permissions", "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to remove role permissions: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+	INSERT INTO profile_schema.role_permissions (role_id, permission_id, created_at)
+	SELECT $1, permission_id, $3 FROM unnest($2::uuid[]) AS permission_id
+	ON CONFLICT (role_id, permission_id) DO NOTHING`,
+		roleID, permissionIDs, time.Now(),
+	); err != nil {
+		r.logger.Error("Failed to add role permissions", "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to add role permissions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Role permissions synced", "role_id", roleID, "permissions", len(permissionIDs))
+	return nil
+}
+
+// AssignRoleToProfile assigns a role to a profile in a scope. Assigning it again is a no-op.
+func (r *PostgresRoleRepository) AssignRoleToProfile(ctx context.Context, profileID, roleID uuid.UUID, scope role.Scope) error {
+	query := `
+	INSERT INTO profile_schema.profile_roles (profile_id, role_id, scope_branch, scope_batch, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (profile_id, role_id, scope_branch, scope_batch) DO NOTHING`
+
+	if _, err := r.pool.Exec(ctx, query, profileID, roleID, scope.Branch, scope.Batch, time.Now()); err != nil {
+		r.logger.Error("Failed to assign role to profile", "profile_id", profileID, "role_id", roleID, "scope", scope, "error", err)
+		return fmt.Errorf("failed to assign role to profile: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveRoleFromProfile removes a role from a profile, in every scope
+func (r *PostgresRoleRepository) RemoveRoleFromProfile(ctx context.Context, profileID, roleID uuid.UUID) error {
+	query := `DELETE FROM profile_schema.profile_roles WHERE profile_id = $1 AND role_id = $2`
+
+	commandTag, err := r.pool.Exec(ctx, query, profileID, roleID)
+	if err != nil {
+		r.logger.Error("Failed to remove role from profile", "profile_id", profileID, "role_id", roleID, "error", err)
+		return fmt.Errorf("failed to remove role from profile: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return role.ErrRoleNotFound
+	}
+
+	return nil
+}
+
+// GetProfileRoles retrieves the roles assigned to a profile along with their scopes, without their permissions
+func (r *PostgresRoleRepository) GetProfileRoles(ctx context.Context, profileID uuid.UUID) ([]role.AssignedRole, error) {
+	query := `
+	SELECT r.id, r.name, COALESCE(r.description, ''), r.created_at, r.updated_at, r.inherits_from,
+		pr.scope_branch, pr.scope_batch
+	FROM profile_schema.profile_roles pr
+	JOIN profile_schema.roles r ON r.id = pr.role_id
+	WHERE pr.profile_id = $1
+	ORDER BY r.name, pr.scope_branch, pr.scope_batch`
+
+	rows, err := r.pool.Query(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to get profile roles", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get profile roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []role.AssignedRole{}
+	for rows.Next() {
+		var assigned role.AssignedRole
+		if err := rows.Scan(
+			&assigned.ID,
+			&assigned.Name,
+			&assigned.Description,
+			&assigned.CreatedAt,
+			&assigned.UpdatedAt,
+			&assigned.InheritsFrom,
+			&assigned.Scope.Branch,
+			&assigned.Scope.Batch,
+		); err != nil {
+			r.logger.Error("Failed to scan role", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		roles = append(roles, assigned)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over role rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetProfilesWithRole returns the IDs of the profiles holding a role
+func (r *PostgresRoleRepository) GetProfilesWithRole(ctx context.Context, roleID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT profile_id FROM profile_schema.profile_roles WHERE role_id = $1`, roleID)
+	if err != nil {
+		r.logger.Error("Failed to get profiles with role", "role_id", roleID, "error", err)
+		return nil, fmt.Errorf("failed to get profiles with role: %w", err)
+	}
+	defer rows.Close()
+
+	profileIDs := []uuid.UUID{}
+	for rows.Next() {
+		var profileID uuid.UUID
+		if err := rows.Scan(&profileID); err != nil {
+			r.logger.Error("Failed to scan profile ID", "role_id", roleID, "error", err)
+			return nil, fmt.Errorf("failed to scan profile ID: %w", err)
+		}
+		profileIDs = append(profileIDs, profileID)
+	}
+
+	return profileIDs, rows.Err()
+}
+
+// SyncProfileRoles replaces the role assignments of a profile in one transaction
+func (r *PostgresRoleRepository) SyncProfileRoles(ctx context.Context, profileID uuid.UUID, assignments []role.ProfileRole) error {
+	roleIDs := make([]uuid.UUID, len(assignments))
+	branches := make([]string, len(assignments))
+	batches := make([]string, len(assignments))
+	for i, assignment := range assignments {
+		roleIDs[i] = assignment.RoleID
+		branches[i] = assignment.Scope.Branch
+		batches[i] = assignment.Scope.Batch
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+	DELETE FROM profile_schema.profile_roles
+	WHERE profile_id = $1 AND (role_id, scope_branch, scope_batch) NOT IN (
+		SELECT * FROM unnest($2::uuid[], $3::text[], $4::text[])
+	)`,
+		profileID, roleIDs, branches, batches,
+	); err != nil {
+		r.logger.Error("Failed to remove profile roles", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to remove profile roles: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+	INSERT INTO profile_schema.profile_roles (profile_id, role_id, scope_branch, scope_batch, created_at)
+	SELECT $1, role_id, scope_branch, scope_batch, $5
+	FROM unnest($2::uuid[], $3::text[], $4::text[]) AS assignment (role_id, scope_branch, scope_batch)
+	ON CONFLICT (profile_id, role_id, scope_branch, scope_batch) DO NOTHING`,
+		profileID, roleIDs, branches, batches, time.Now(),
+	); err != nil {
+		r.logger.Error("Failed to add profile roles", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to add profile roles: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit transaction", "profile_id", profileID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Profile roles synced", "profile_id", profileID, "roles", len(assignments))
+	return nil
+}
+
+// effectiveRolesCTE expands the role assignments of the profile $1 with the roles they inherit
+// from, carrying the scope of the assignment along. The path stops cycles.
+const effectiveRolesCTE = `
+	WITH RECURSIVE effective_roles (role_id, scope_branch, scope_batch, path) AS (
+		SELECT pr.role_id, pr.scope_branch, pr.scope_batch, ARRAY[pr.role_id]
+		FROM profile_schema.profile_roles pr
+		WHERE pr.profile_id = $1
+		UNION ALL
+		SELECT r.inherits_from, er.scope_branch, er.scope_batch, er.path || r.inherits_from
+		FROM effective_roles er
+		JOIN profile_schema.roles r ON r.id = er.role_id
+		WHERE r.inherits_from IS NOT NULL AND NOT r.inherits_from = ANY(er.path)
+	)`
+
+// GetProfilePermissions returns the distinct permissions of every role assigned to the profile and
+// of the roles they inherit from, each with the scope of the assignment granting it
+func (r *PostgresRoleRepository) GetProfilePermissions(ctx context.Context, profileID uuid.UUID) ([]role.ScopedPermission, error) {
+	query := effectiveRolesCTE + `
+	SELECT DISTINCT ` + permissionColumns + `, er.scope_branch, er.scope_batch
+	FROM effective_roles er
+	JOIN profile_schema.role_permissions rp ON rp.role_id = er.role_id
+	JOIN profile_schema.permissions p ON p.id = rp.permission_id`
+
+	rows, err := r.pool.Query(ctx, query, profileID)
+	if err != nil {
+		r.logger.Error("Failed to get profile permissions", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("failed to get profile permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []role.ScopedPermission{}
+	for rows.Next() {
+		var permission role.ScopedPermission
+		if err := rows.Scan(
+			&permission.ID,
+			&permission.Resource,
+			&permission.Action,
+			&permission.Description,
+			&permission.CreatedAt,
+			&permission.UpdatedAt,
+			&permission.Scope.Branch,
+			&permission.Scope.Batch,
+		); err != nil {
+			r.logger.Error("Failed to scan profile permission", "profile_id", profileID, "error", err)
+			return nil, fmt.Errorf("failed to scan profile permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over permission rows", "profile_id", profileID, "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// HasPermission checks the permissions of every role assigned to the platform profile, in any scope,
+// including inherited ones. ActionManage on the resource grants every action.
+func (r *PostgresRoleRepository) HasPermission(ctx context.Context, profileID uuid.UUID, resource, action string) (bool, error) {
+	query := effectiveRolesCTE + `
+	SELECT EXISTS (
+		SELECT 1
+		FROM effective_roles er
+		JOIN profile_schema.role_permissions rp ON rp.role_id = er.role_id
+		JOIN profile_schema.permissions p ON p.id = rp.permission_id
+		WHERE p.resource = $2 AND p.action IN ($3, $4)
+	)`
+
+	var allowed bool
+	if err := r.pool.QueryRow(ctx, query, profileID, resource, action, role.ActionManage).Scan(&allowed); err != nil {
+		r.logger.Error("Failed to check permission", "profile_id", profileID, "resource", resource, "action", action, "error", err)
+		return false, fmt.Errorf("failed to check permission: %w", err)
+	}
+
+	return allowed, nil
+}
+
+// queryPermissions runs a query selecting permissionColumns
+func (r *PostgresRoleRepository) queryPermissions(ctx context.Context, query string, args ...interface{}) ([]role.Permission, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to query permissions", "error", err)
+		return nil, fmt.Errorf("failed to query permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []role.Permission{}
+	for rows.Next() {
+		permission, err := scanPermission(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan permission", "error", err)
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, *permission)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over permission rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// scanRole scans a row selected with roleColumns
+func scanRole(row pgx.Row) (*role.Role, error) {
+	rl := &role.Role{}
+	err := row.Scan(
+		&rl.ID,
+		&rl.Name,
+		&rl.Description,
+		&rl.CreatedAt,
+		&rl.UpdatedAt,
+		&rl.InheritsFrom,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// scanPermission scans a row selected with permissionColumns
+func scanPermission(row pgx.Row) (*role.Permission, error) {
+	permission := &role.Permission{}
+	err := row.Scan(
+		&permission.ID,
+		&permission.Resource,
+		&permission.Action,
+		&permission.Description,
+		&permission.CreatedAt,
+		&permission.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
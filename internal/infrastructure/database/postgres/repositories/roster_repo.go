@@ -0,0 +1,448 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"server/internal/domain/platform_profile"
+	"server/internal/domain/role"
+	"server/internal/domain/student/roster"
+	"server/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Placeholder documents are created for the marksheets, photograph and resume the student tables
+// require, the student uploads the files later
+const (
+	pendingDocumentStore = "pending"
+
+	documentClassTenMarksheet    = "class_ten_marksheet"
+	documentClassTwelveMarksheet = "class_twelve_marksheet"
+	documentPhotograph           = "photograph"
+	documentResume               = "resume"
+)
+
+// PostgresRosterRepository stores roster imports and creates the students they contain
+type PostgresRosterRepository struct {
+	pool   *pgxpool.Pool
+	logger *logger.Logger
+}
+
+// NewPostgresRosterRepository creates a new PostgreSQL-backed roster import repository
+func NewPostgresRosterRepository(pool *pgxpool.Pool, logger *logger.Logger) roster.Repository {
+	return &PostgresRosterRepository{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// CreateImport stores a previewed roster, its rows are kept as JSON
+func (r *PostgresRosterRepository) CreateImport(ctx context.Context, imp *roster.Import) error {
+	r.logger.Debug("Storing roster import", "id", imp.ID, "rows", imp.TotalRows)
+
+	query := `
+	INSERT INTO student_schema.roster_imports (
+		id, file_name, status, ignored_columns, total_rows, valid_rows,
+		rows, created_by, created_at, expires_at
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	)`
+
+	_, err := r.pool.Exec(
+		ctx,
+		query,
+		imp.ID,
+		imp.FileName,
+		imp.Status,
+		imp.IgnoredColumns,
+		imp.TotalRows,
+		imp.ValidRows,
+		imp.Rows,
+		imp.CreatedBy,
+		imp.CreatedAt,
+		imp.ExpiresAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to store roster import", "id", imp.ID, "error", err)
+		return fmt.Errorf("failed to create roster import: %w", err)
+	}
+
+	return nil
+}
+
+// GetImport retrieves a roster import.
+// Returns nil without an error when the import does not exist.
+func (r *PostgresRosterRepository) GetImport(ctx context.Context, id uuid.UUID) (*roster.Import, error) {
+	query := `
+	SELECT id, file_name, status, ignored_columns, total_rows, valid_rows, rows,
+		result, created_by, created_at, expires_at, committed_by, committed_at
+	FROM student_schema.roster_imports
+	WHERE id = $1`
+
+	imp := &roster.Import{}
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&imp.ID,
+		&imp.FileName,
+		&imp.Status,
+		&imp.IgnoredColumns,
+		&imp.TotalRows,
+		&imp.ValidRows,
+		&imp.Rows,
+		&imp.Result,
+		&imp.CreatedBy,
+		&imp.CreatedAt,
+		&imp.ExpiresAt,
+		&imp.CommittedBy,
+		&imp.CommittedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to fetch roster import", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get roster import: %w", err)
+	}
+
+	imp.InvalidRows = imp.TotalRows - imp.ValidRows
+	return imp, nil
+}
+
+// ClaimImport moves a previewed, unexpired import to committing
+func (r *PostgresRosterRepository) ClaimImport(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	query := `
+	UPDATE student_schema.roster_imports SET status = $1
+	WHERE id = $2 AND status = $3 AND expires_at > $4`
+
+	commandTag, err := r.pool.Exec(ctx, query, roster.ImportCommitting, id, roster.ImportPreviewed, now)
+	if err != nil {
+		r.logger.Error("Failed to claim roster import", "id", id, "error", err)
+		return false, fmt.Errorf("failed to claim roster import: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// ReleaseImport returns a claimed import to previewed
+func (r *PostgresRosterRepository) ReleaseImport(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE student_schema.roster_imports SET status = $1 WHERE id = $2 AND status = $3`
+
+	if _, err := r.pool.Exec(ctx, query, roster.ImportPreviewed, id, roster.ImportCommitting); err != nil {
+		r.logger.Error("Failed to release roster import", "id", id, "error", err)
+		return fmt.Errorf("failed to release roster import: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteImport stores the outcome of a commit and marks the import committed
+func (r *PostgresRosterRepository) CompleteImport(ctx context.Context, id uuid.UUID, actorID uuid.UUID, result *platform_profile.BulkResult, committedAt time.Time) error {
+	query := `
+	UPDATE student_schema.roster_imports SET
+		status = $1,
+		result = $2,
+		committed_by = $3,
+		committed_at = $4
+	WHERE id = $5`
+
+	commandTag, err := r.pool.Exec(ctx, query, roster.ImportCommitted, result, actorID, committedAt, id)
+	if err != nil {
+		r.logger.Error("Failed to complete roster import", "id", id, "error", err)
+		return fmt.Errorf("failed to complete roster import: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to complete roster import: import %s not found", id)
+	}
+
+	r.logger.Info("Roster import completed", "id", id, "succeeded", result.Succeeded, "skipped", result.Skipped, "failed", result.Failed)
+	return nil
+}
+
+// DeleteExpiredImports removes previews that were never committed
+func (r *PostgresRosterRepository) DeleteExpiredImports(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM student_schema.roster_imports WHERE status = $1 AND expires_at <= $2`
+
+	commandTag, err := r.pool.Exec(ctx, query, roster.ImportPreviewed, now)
+	if err != nil {
+		r.logger.Error("Failed to delete expired roster imports", "error", err)
+		return 0, fmt.Errorf("failed to delete expired roster imports: %w", err)
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+// FindRegistered returns the enrollment numbers and emails that are taken
+func (r *PostgresRosterRepository) FindRegistered(ctx context.Context, enrollmentNos []string, emails []string) (map[string]bool, map[string]bool, error) {
+	enrollmentQuery := `
+	SELECT EnrollmentNo FROM public.enrollment_master_lookup_table WHERE EnrollmentNo = ANY($1)
+	UNION
+	SELECT upper(username) FROM profile_schema.platform_profiles WHERE upper(username) = ANY($1)`
+
+	emailQuery := `
+	SELECT lower(Email) FROM student_schema.student_login_details_table WHERE lower(Email) = ANY($1)
+	UNION
+	SELECT lower(email) FROM profile_schema.platform_profiles WHERE lower(email) = ANY($1)`
+
+	registeredEnrollments, err := r.collectStrings(ctx, enrollmentQuery, enrollmentNos)
+	if err != nil {
+		r.logger.Error("Failed to look up registered enrollment numbers", "error", err)
+		return nil, nil, fmt.Errorf("failed to find registered enrollment numbers: %w", err)
+	}
+
+	registeredEmails, err := r.collectStrings(ctx, emailQuery, emails)
+	if err != nil {
+		r.logger.Error("Failed to look up registered emails", "error", err)
+		return nil, nil, fmt.Errorf("failed to find registered emails: %w", err)
+	}
+
+	return registeredEnrollments, registeredEmails, nil
+}
+
+// collectStrings runs a query returning a single text column for a list argument and collects the values
+func (r *PostgresRosterRepository) collectStrings(ctx context.Context, query string, values []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(values) == 0 {
+		return found, nil
+	}
+
+	rows, err := r.pool.Query(ctx, query, values)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		found[value] = true
+	}
+
+	return found, rows.Err()
+}
+
+// CreateStudents creates the platform profile and the student records of every row. The enrollment
+// record references its detail tables, which are inserted first, the required documents as placeholders.
+func (r *PostgresRosterRepository) CreateStudents(ctx context.Context, students []*roster.NewStudent) ([]platform_profile.BulkItemResult, error) {
+	r.logger.Debug("Starting roster student creation", "rows", len(students))
+
+	profileQuery := `
+	INSERT INTO profile_schema.platform_profiles (
+		id, username, email, password_hash, status,
+		failed_login_attempts, created_at, updated_by_user_at,
+		updated_by_system_at, must_change_password
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	)`
+
+	historyQuery := `
+	INSERT INTO profile_schema.password_history (id, profile_id, password_hash, created_at)
+	VALUES ($1, $2, $3, $4)`
+
+	roleQuery := `
+	INSERT INTO profile_schema.profile_roles (profile_id, role_id, created_at)
+	SELECT $1, id, $3 FROM profile_schema.roles WHERE name = $2`
+
+	documentQuery := `
+	INSERT INTO student_schema.student_documents_table (StoredIn, DocumentType, URL)
+	VALUES ($1, $2, '')
+	RETURNING DocumentID`
+
+	loginQuery := `
+	INSERT INTO student_schema.student_login_details_table (Email, Password, Phone)
+	VALUES ($1, $2, $3)
+	RETURNING ID`
+
+	academicQuery := `
+	INSERT INTO student_schema.student_academic_details_table (
+		Branch, YearOfEnrollment, CGPA, PreviousSemSGPA,
+		SchoolForClassTen, ClassTenPercentage, ClassTenMarksheetID,
+		SchoolForClassTwelve, ClassTwelvePercentage, ClassTwelveMarksheetID
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	)
+	RETURNING ID`
+
+	familyQuery := `
+	INSERT INTO student_schema.student_family_details_table (
+		FatherName, FatherQualification, FatherProfession,
+		MotherName, MotherQualification, MotherProfession,
+		NoOfSiblings, TotalFamilyIncome
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)
+	RETURNING ID`
+
+	profileDetailsQuery := `
+	INSERT INTO student_schema.student_profile_details_table (
+		UserRole, Name, Gender, Category, PhotographID, ResumeID
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)
+	RETURNING ID`
+
+	scholarshipQuery := `
+	INSERT INTO student_schema.student_scholarship_details_table (ScholarshipName, ProvidedBy, AmountReceived)
+	VALUES ($1, $2, $3)
+	RETURNING ID`
+
+	masterQuery := `
+	INSERT INTO public.enrollment_master_lookup_table (
+		EnrollmentNo, LogInDetailsID, AcademicDetailsID,
+		FamilyDetailsID, ProfileDetailsID, ScholarshipDetailsID, ProfileID
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	)`
+
+	return runBulk(ctx, r.pool, r.logger, "import_roster", len(students), false, func(ctx context.Context, tx pgx.Tx, index int) platform_profile.BulkItemResult {
+		st := students[index]
+		profile, record := st.Profile, st.Record
+		result := platform_profile.BulkItemResult{ProfileID: &profile.ID, Username: profile.Username}
+
+		skipped := func(reason string) platform_profile.BulkItemResult {
+			result.ProfileID = nil
+			result.Status = platform_profile.BulkItemSkipped
+			result.Reason = reason
+			return result
+		}
+
+		_, err := tx.Exec(
+			ctx,
+			profileQuery,
+			profile.ID,
+			profile.Username,
+			profile.Email,
+			profile.PasswordHash,
+			profile.Status,
+			profile.FailedLoginAttempts,
+			profile.CreatedAt,
+			profile.UpdatedByUserAt,
+			profile.UpdatedBySystemAt,
+			profile.MustChangePassword,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				switch pgErr.ConstraintName {
+				case "platform_profiles_username_key":
+					return skipped("enrollment number already registered")
+				case "platform_profiles_email_key":
+					return skipped("email already registered")
+				default:
+					return skipped("profile already exists")
+				}
+			}
+			return bulkFailure(r.logger, "import_roster", result, "failed to create profile", err)
+		}
+
+		if _, err := tx.Exec(ctx, historyQuery, uuid.New(), profile.ID, profile.PasswordHash, profile.CreatedAt); err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to record password history", err)
+		}
+
+		if _, err := tx.Exec(ctx, roleQuery, profile.ID, role.RoleStudent, profile.CreatedAt); err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to assign student role", err)
+		}
+
+		documentIDs := make(map[string]uint32, 4)
+		for _, documentType := range []string{documentClassTenMarksheet, documentClassTwelveMarksheet, documentPhotograph, documentResume} {
+			var documentID uint32
+			if err := tx.QueryRow(ctx, documentQuery, pendingDocumentStore, documentType).Scan(&documentID); err != nil {
+				return bulkFailure(r.logger, "import_roster", result, "failed to create document placeholders", err)
+			}
+			documentIDs[documentType] = documentID
+		}
+
+		login := &record.StudentLogInDetailsTable
+		if err := tx.QueryRow(ctx, loginQuery, login.Email, login.Password, login.Phone).Scan(&record.LogInDetailsID); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return skipped("email already registered")
+			}
+			return bulkFailure(r.logger, "import_roster", result, "failed to create login details", err)
+		}
+
+		academic := &record.AcademicDetails
+		err = tx.QueryRow(
+			ctx,
+			academicQuery,
+			academic.Branch,
+			academic.YearOfEnrollment,
+			academic.CGPA,
+			academic.PreviousSemSGPA,
+			academic.SchoolForClassTen,
+			academic.ClassTenPercentage,
+			documentIDs[documentClassTenMarksheet],
+			academic.SchoolForClassTwelve,
+			academic.ClassTwelvePercentage,
+			documentIDs[documentClassTwelveMarksheet],
+		).Scan(&record.AcademicDetailsID)
+		if err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to create academic details", err)
+		}
+
+		family := &record.FamilyDetails
+		err = tx.QueryRow(
+			ctx,
+			familyQuery,
+			family.FatherName,
+			family.FatherQualification,
+			family.FatherProfession,
+			family.MotherName,
+			family.MotherQualification,
+			family.MotherProfession,
+			family.NoOfSiblings,
+			family.TotalFamilyIncome,
+		).Scan(&record.FamilyDetailsID)
+		if err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to create family details", err)
+		}
+
+		details := &record.ProfileDetails
+		err = tx.QueryRow(
+			ctx,
+			profileDetailsQuery,
+			details.UserRole,
+			details.Name,
+			details.Gender,
+			details.Category,
+			documentIDs[documentPhotograph],
+			documentIDs[documentResume],
+		).Scan(&record.ProfileDetailsID)
+		if err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to create profile details", err)
+		}
+
+		scholarship := &record.ScholarshipDetails
+		err = tx.QueryRow(ctx, scholarshipQuery, scholarship.ScholarshipName, scholarship.ProvidedBy, scholarship.AmountReceived).Scan(&record.ScholarshipDetailsID)
+		if err != nil {
+			return bulkFailure(r.logger, "import_roster", result, "failed to create scholarship details", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			masterQuery,
+			record.EnrollmentNo,
+			record.LogInDetailsID,
+			record.AcademicDetailsID,
+			record.FamilyDetailsID,
+			record.ProfileDetailsID,
+			record.ScholarshipDetailsID,
+			profile.ID,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return skipped("enrollment number already registered")
+			}
+			return bulkFailure(r.logger, "import_roster", result, "failed to create enrollment record", err)
+		}
+
+		result.Status = platform_profile.BulkItemCreated
+		return result
+	})
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"fmt"
+
+	"server/internal/domain/role"
+)
+
+// studentScopeCondition returns the SQL condition limiting rows to the students within the access
+// scope, along with its two arguments. enrollmentColumn holds the enrollment number of the student,
+// the username of a student's platform profile. The placeholders start at paramIndex.
+// Unrestricted access scopes need no condition, an empty one is returned.
+func studentScopeCondition(enrollmentColumn string, access role.AccessScope, paramIndex int) (string, []interface{}) {
+	if access.Unrestricted {
+		return "", nil
+	}
+
+	branches := make([]string, len(access.Scopes))
+	batches := make([]string, len(access.Scopes))
+	for i, scope := range access.Scopes {
+		branches[i] = scope.Branch
+		batches[i] = scope.Batch
+	}
+
+	condition := fmt.Sprintf(`EXISTS (
+		SELECT 1
+		FROM public.enrollment_master_lookup_table scope_e
+		JOIN student_schema.student_academic_details_table scope_a ON scope_a.ID = scope_e.AcademicDetailsID
+		JOIN unnest($%d::text[], $%d::text[]) AS scope (branch, batch)
+			ON (scope.branch = '' OR scope.branch = scope_a.Branch)
+			AND (scope.batch = '' OR scope.batch = scope_a.YearOfEnrollment::text)
+		WHERE scope_e.EnrollmentNo = %s
+	)`, paramIndex, paramIndex+1, enrollmentColumn)
+
+	return condition, []interface{}{branches, batches}
+}
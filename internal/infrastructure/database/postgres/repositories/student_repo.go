@@ -1 +1,1979 @@
 package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/domain/student"
+	"server/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Page sizes of student lists
+const (
+	defaultStudentPageSize = 20
+	maxStudentPageSize     = 100
+)
+
+// studentSortColumns whitelists the Pagination.SortBy values, keyed by the JSON field names of a student.
+// Only these columns are ever written into the ORDER BY clause.
+var studentSortColumns = map[string]string{
+	"enrollment_id": "e.EnrollmentNo",
+	"first_name":    "p.Name",
+	"email":         "l.Email",
+	"program":       "a.Program",
+	"branch":        "a.Branch",
+	"batch":         "a.YearOfEnrollment",
+	"semester":      "a.PresentSemester",
+	"status":        "e.Status",
+	"created_at":    "e.CreatedAt",
+	"updated_at":    "e.UpdatedAt",
+}
+
+// studentTables joins the enrollment record with the detail tables a student is assembled from
+const studentTables = `
+	public.enrollment_master_lookup_table e
+	JOIN student_schema.student_login_details_table l ON l.ID = e.LogInDetailsID
+	JOIN student_schema.student_academic_details_table a ON a.ID = e.AcademicDetailsID
+	JOIN student_schema.student_profile_details_table p ON p.ID = e.ProfileDetailsID
+	LEFT JOIN student_schema.student_documents_table d ON d.DocumentID = p.PhotographID`
+
+// studentColumns are the columns scanned by scanStudent, selected from studentTables.
+// The roles are those of the platform profile linked to the student.
+const studentColumns = `
+	e.ID, e.EnrollmentNo, p.Name, l.Email, l.Phone, l.Password, l.LastLoginAt,
+	p.DateOfBirth, p.Gender, p.Category, p.PresentAddress, p.PermanentAddress,
+	a.Program, a.Branch, a.YearOfEnrollment, a.YearOfGraduation, a.PresentSemester, a.Section,
+	e.Status, COALESCE(d.URL, ''),
+	ARRAY(
+		SELECT DISTINCT pr.role_id
+		FROM profile_schema.profile_roles pr
+		WHERE pr.profile_id = e.ProfileID
+	),
+	e.CreatedAt, e.UpdatedAt, e.DeactivatedAt`
+
+// PostgresStudentRepository stores students across the enrollment record and its detail tables
+type PostgresStudentRepository struct {
+	pool   *pgxpool.Pool
+	logger *logger.Logger
+}
+
+// NewPostgresStudentRepository creates a new PostgreSQL-backed student repository
+func NewPostgresStudentRepository(pool *pgxpool.Pool, logger *logger.Logger) student.Repository {
+	return &PostgresStudentRepository{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// Create stores a new student. The enrollment record references its detail tables, which are
+// inserted first in the same transaction. The documents and the family and scholarship details the
+// tables require are created as placeholders, the student fills them in later.
+func (r *PostgresStudentRepository) Create(ctx context.Context, st *student.Student) error {
+	r.logger.Debug("Creating student", "enrollment_id", st.EnrollmentID)
+
+	yearOfEnrollment, yearOfGraduation, err := parseBatch(st.Batch)
+	if err != nil {
+		return err
+	}
+
+	if st.ID == uuid.Nil {
+		st.ID = uuid.New()
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	documentIDs := make(map[string]uint32, 4)
+	for _, documentType := range []string{documentClassTenMarksheet, documentClassTwelveMarksheet, documentPhotograph, documentResume} {
+		url := ""
+		if documentType == documentPhotograph {
+			url = st.ProfileImageUrl
+		}
+
+		var documentID uint32
+		err := tx.QueryRow(ctx, `
+		INSERT INTO student_schema.student_documents_table (StoredIn, DocumentType, URL)
+		VALUES ($1, $2, $3)
+		RETURNING DocumentID`,
+			pendingDocumentStore, documentType, url,
+		).Scan(&documentID)
+		if err != nil {
+			r.logger.Error("Failed to create document placeholders", "enrollment_id", st.EnrollmentID, "error", err)
+			return fmt.Errorf("failed to create document placeholders: %w", err)
+		}
+		documentIDs[documentType] = documentID
+	}
+
+	var loginID, academicID, familyID, profileDetailsID, scholarshipID uint32
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO student_schema.student_login_details_table (Email, Password, Phone, LastLoginAt)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ID`,
+		st.Email, st.PasswordHash, st.PhoneNumber, st.LastLoginAt,
+	).Scan(&loginID)
+	if err != nil {
+		return r.studentWriteError("create login details", st.EnrollmentID, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO student_schema.student_academic_details_table (
+		Branch, YearOfEnrollment, YearOfGraduation, Program, PresentSemester, Section,
+		SchoolForClassTen, ClassTenMarksheetID, SchoolForClassTwelve, ClassTwelveMarksheetID
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, '', $7, '', $8
+	)
+	RETURNING ID`,
+		st.Branch,
+		yearOfEnrollment,
+		yearOfGraduation,
+		st.Program,
+		st.PresentSemester,
+		st.Section,
+		documentIDs[documentClassTenMarksheet],
+		documentIDs[documentClassTwelveMarksheet],
+	).Scan(&academicID)
+	if err != nil {
+		return r.studentWriteError("create academic details", st.EnrollmentID, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO student_schema.student_family_details_table (
+		FatherName, FatherQualification, FatherProfession,
+		MotherName, MotherQualification, MotherProfession,
+		NoOfSiblings, TotalFamilyIncome
+	) VALUES (
+		'', '', '', '', '', '', 0, 0
+	)
+	RETURNING ID`,
+	).Scan(&familyID)
+	if err != nil {
+		return r.studentWriteError("create family details", st.EnrollmentID, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO student_schema.student_profile_details_table (
+		Name, Gender, Category, DateOfBirth, PresentAddress, PermanentAddress, PhotographID, ResumeID
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	)
+	RETURNING ID`,
+		st.FullName(),
+		st.Gender,
+		st.Category,
+		nullableDate(st.DateOfBirth),
+		st.PresentAddress,
+		st.PermanentAddress,
+		documentIDs[documentPhotograph],
+		documentIDs[documentResume],
+	).Scan(&profileDetailsID)
+	if err != nil {
+		return r.studentWriteError("create profile details", st.EnrollmentID, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+	INSERT INTO student_schema.student_scholarship_details_table (ScholarshipName, ProvidedBy, AmountReceived)
+	VALUES ('', '', 0)
+	RETURNING ID`,
+	).Scan(&scholarshipID)
+	if err != nil {
+		return r.studentWriteError("create scholarship details", st.EnrollmentID, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO public.enrollment_master_lookup_table (
+		ID, EnrollmentNo, LogInDetailsID, AcademicDetailsID, FamilyDetailsID,
+		ProfileDetailsID, ScholarshipDetailsID, Status, CreatedAt, UpdatedAt, DeactivatedAt
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	)`,
+		st.ID,
+		st.EnrollmentID,
+		loginID,
+		academicID,
+		familyID,
+		profileDetailsID,
+		scholarshipID,
+		st.Status,
+		st.CreatedAt,
+		st.UpdatedAt,
+		st.DeactivatedAt,
+	)
+	if err != nil {
+		return r.studentWriteError("create enrollment record", st.EnrollmentID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit student creation", "enrollment_id", st.EnrollmentID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Student created successfully", "id", st.ID, "enrollment_id", st.EnrollmentID)
+	return nil
+}
+
+// GetByID retrieves a student by ID.
+// Returns nil without an error when the student does not exist.
+func (r *PostgresStudentRepository) GetByID(ctx context.Context, id uuid.UUID) (*student.Student, error) {
+	return r.getStudent(ctx, "e.ID = $1", id)
+}
+
+// GetByEnrollmentID retrieves a student by enrollment number.
+// Returns nil without an error when the student does not exist.
+func (r *PostgresStudentRepository) GetByEnrollmentID(ctx context.Context, enrollmentID string) (*student.Student, error) {
+	return r.getStudent(ctx, "e.EnrollmentNo = $1", enrollmentID)
+}
+
+// GetByProfileID retrieves the student linked to a platform profile.
+// Returns nil without an error when the profile has no student.
+func (r *PostgresStudentRepository) GetByProfileID(ctx context.Context, profileID uuid.UUID) (*student.Student, error) {
+	return r.getStudent(ctx, "e.ProfileID = $1", profileID)
+}
+
+// GetByEmail retrieves a student by login email.
+// Returns nil without an error when the student does not exist.
+func (r *PostgresStudentRepository) GetByEmail(ctx context.Context, email string) (*student.Student, error) {
+	return r.getStudent(ctx, "l.Email = $1", email)
+}
+
+// getStudent retrieves the student matching condition, students whose profile is in the recovery bin are excluded
+func (r *PostgresStudentRepository) getStudent(ctx context.Context, condition string, arg interface{}) (*student.Student, error) {
+	query := `SELECT ` + studentColumns + ` FROM ` + studentTables + `
+	WHERE ` + condition + ` AND e.DeletedAt IS NULL`
+
+	st, err := scanStudent(r.pool.QueryRow(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get student", "error", err)
+		return nil, fmt.Errorf("failed to get student: %w", err)
+	}
+
+	return st, nil
+}
+
+// Update updates the student's enrollment record and detail tables in a single transaction
+func (r *PostgresStudentRepository) Update(ctx context.Context, st *student.Student) error {
+	r.logger.Debug("Updating student", "id", st.ID)
+
+	yearOfEnrollment, yearOfGraduation, err := parseBatch(st.Batch)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var loginID, academicID, profileDetailsID uint32
+	err = tx.QueryRow(ctx, `
+	SELECT LogInDetailsID, AcademicDetailsID, ProfileDetailsID
+	FROM public.enrollment_master_lookup_table
+	WHERE ID = $1 AND DeletedAt IS NULL
+	FOR UPDATE`,
+		st.ID,
+	).Scan(&loginID, &academicID, &profileDetailsID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return student.ErrStudentNotFound
+		}
+		r.logger.Error("Failed to lock student", "id", st.ID, "error", err)
+		return fmt.Errorf("failed to lock student: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE student_schema.student_login_details_table SET
+		Email = $2,
+		Password = $3,
+		Phone = $4,
+		LastLoginAt = $5
+	WHERE ID = $1`,
+		loginID, st.Email, st.PasswordHash, st.PhoneNumber, st.LastLoginAt,
+	)
+	if err != nil {
+		return r.studentWriteError("update login details", st.EnrollmentID, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE student_schema.student_academic_details_table SET
+		Branch = $2,
+		YearOfEnrollment = $3,
+		YearOfGraduation = $4,
+		Program = $5,
+		PresentSemester = $6,
+		Section = $7,
+		UpdatedAt = $8
+	WHERE ID = $1`,
+		academicID,
+		st.Branch,
+		yearOfEnrollment,
+		yearOfGraduation,
+		st.Program,
+		st.PresentSemester,
+		st.Section,
+		st.UpdatedAt,
+	)
+	if err != nil {
+		return r.studentWriteError("update academic details", st.EnrollmentID, err)
+	}
+
+	var photographID uint32
+	err = tx.QueryRow(ctx, `
+	UPDATE student_schema.student_profile_details_table SET
+		Name = $2,
+		Gender = $3,
+		Category = $4,
+		DateOfBirth = $5,
+		PresentAddress = $6,
+		PermanentAddress = $7,
+		UpdatedAt = $8
+	WHERE ID = $1
+	RETURNING PhotographID`,
+		profileDetailsID,
+		st.FullName(),
+		st.Gender,
+		st.Category,
+		nullableDate(st.DateOfBirth),
+		st.PresentAddress,
+		st.PermanentAddress,
+		st.UpdatedAt,
+	).Scan(&photographID)
+	if err != nil {
+		return r.studentWriteError("update profile details", st.EnrollmentID, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE student_schema.student_documents_table SET
+		URL = $2,
+		UpdatedAt = $3
+	WHERE DocumentID = $1 AND URL IS DISTINCT FROM $2`,
+		photographID, st.ProfileImageUrl, st.UpdatedAt,
+	)
+	if err != nil {
+		return r.studentWriteError("update photograph", st.EnrollmentID, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE public.enrollment_master_lookup_table SET
+		Status = $2,
+		UpdatedAt = $3,
+		DeactivatedAt = $4
+	WHERE ID = $1`,
+		st.ID, st.Status, st.UpdatedAt, st.DeactivatedAt,
+	)
+	if err != nil {
+		return r.studentWriteError("update enrollment record", st.EnrollmentID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit student update", "id", st.ID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Student updated successfully", "id", st.ID)
+	return nil
+}
+
+// Delete permanently deletes the student's enrollment record along with its detail tables and documents
+func (r *PostgresStudentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debug("Deleting student", "id", id)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var enrollmentNo string
+	err = tx.QueryRow(ctx, `
+	SELECT EnrollmentNo FROM public.enrollment_master_lookup_table
+	WHERE ID = $1
+	FOR UPDATE`,
+		id,
+	).Scan(&enrollmentNo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return student.ErrStudentNotFound
+		}
+		r.logger.Error("Failed to lock student", "id", id, "error", err)
+		return fmt.Errorf("failed to lock student: %w", err)
+	}
+
+	if err := deleteStudentRecordsTx(ctx, tx, enrollmentNo); err != nil {
+		r.logger.Error("Failed to delete student", "id", id, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit student deletion", "id", id, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Info("Student deleted successfully", "id", id, "enrollment_id", enrollmentNo)
+	return nil
+}
+
+// List retrieves the students matching the filter, one page at a time, along with their total count.
+// Pagination.SortBy must be one of the whitelisted sort fields, students are ordered by creation
+// time when it is empty.
+func (r *PostgresStudentRepository) List(ctx context.Context, filter student.StudentFilter, pagination student.Pagination) ([]*student.Student, int64, error) {
+	r.logger.Debug("Listing students", "page", pagination.Page, "page_size", pagination.PageSize, "sort_by", pagination.SortBy)
+
+	sortColumn := studentSortColumns["created_at"]
+	if pagination.SortBy != "" {
+		column, ok := studentSortColumns[pagination.SortBy]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: %q", student.ErrInvalidSortField, pagination.SortBy)
+		}
+		sortColumn = column
+	}
+	direction := "ASC"
+	if pagination.SortDesc {
+		direction = "DESC"
+	}
+
+	page, pageSize := pagination.Page, pagination.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultStudentPageSize
+	}
+	if pageSize > maxStudentPageSize {
+		pageSize = maxStudentPageSize
+	}
+
+	conditions := []string{"e.DeletedAt IS NULL"}
+	args := []interface{}{}
+	paramIndex := 1
+
+	if filter.Status != nil {
+		conditions = append(conditions, fmt.Sprintf("e.Status = $%d", paramIndex))
+		args = append(args, *filter.Status)
+		paramIndex++
+	}
+	if filter.Program != nil {
+		conditions = append(conditions, fmt.Sprintf("a.Program = $%d", paramIndex))
+		args = append(args, *filter.Program)
+		paramIndex++
+	}
+	if filter.Batch != nil {
+		// Batches are matched on their admission year, "2023" matches the "2023-2027" batch
+		conditions = append(conditions, fmt.Sprintf("a.YearOfEnrollment::text = split_part($%d, '-', 1)", paramIndex))
+		args = append(args, *filter.Batch)
+		paramIndex++
+	}
+	if filter.Semester != nil {
+		conditions = append(conditions, fmt.Sprintf("a.PresentSemester = $%d", paramIndex))
+		args = append(args, *filter.Semester)
+		paramIndex++
+	}
+	if filter.Branch != nil {
+		conditions = append(conditions, fmt.Sprintf("a.Branch = upper($%d)", paramIndex))
+		args = append(args, *filter.Branch)
+		paramIndex++
+	}
+	if filter.SearchQuery != nil && *filter.SearchQuery != "" {
+		conditions = append(conditions, fmt.Sprintf("(p.Name ILIKE $%d OR l.Email ILIKE $%d OR e.EnrollmentNo ILIKE $%d)", paramIndex, paramIndex, paramIndex))
+		args = append(args, fmt.Sprintf("%%%s%%", *filter.SearchQuery))
+		paramIndex++
+	}
+	if condition, scopeArgs := studentScopeCondition("e.EnrollmentNo", filter.Scope, paramIndex); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, scopeArgs...)
+		paramIndex += len(scopeArgs)
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM `+studentTables+whereClause, args...).Scan(&total); err != nil {
+		r.logger.Error("Failed to count students", "error", err)
+		return nil, 0, fmt.Errorf("failed to count students: %w", err)
+	}
+
+	// The ID breaks ties so that pages do not overlap
+	dataQuery := `SELECT ` + studentColumns + ` FROM ` + studentTables + whereClause +
+		fmt.Sprintf(" ORDER BY %s %s, e.ID LIMIT $%d OFFSET $%d", sortColumn, direction, paramIndex, paramIndex+1)
+
+	rows, err := r.pool.Query(ctx, dataQuery, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		r.logger.Error("Failed to list students", "error", err)
+		return nil, 0, fmt.Errorf("failed to list students: %w", err)
+	}
+	defer rows.Close()
+
+	students := []*student.Student{}
+	for rows.Next() {
+		st, err := scanStudent(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan student", "error", err)
+			return nil, 0, fmt.Errorf("failed to scan student: %w", err)
+		}
+		students = append(students, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over student rows", "error", err)
+		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return students, total, nil
+}
+
+// GetAttendance retrieves the attendance sheets of a student with their records, optionally limited
+// to a course and a semester
+func (r *PostgresStudentRepository) GetAttendance(ctx context.Context, studentID uuid.UUID, courseID *uuid.UUID, semester *int) ([]student.StudentAttendance, error) {
+	query := `
+	SELECT ID, StudentID, CourseID, Semester, TotalClasses, PresentCount, AbsentCount, LeaveCount, CreatedAt, UpdatedAt
+	FROM student_schema.student_attendance_table
+	WHERE StudentID = $1
+		AND ($2::uuid IS NULL OR CourseID = $2)
+		AND ($3::int IS NULL OR Semester = $3)
+	ORDER BY Semester, CourseID`
+
+	rows, err := r.pool.Query(ctx, query, studentID, courseID, semester)
+	if err != nil {
+		r.logger.Error("Failed to get attendance", "student_id", studentID, "error", err)
+		return nil, fmt.Errorf("failed to get attendance: %w", err)
+	}
+	defer rows.Close()
+
+	attendance := []student.StudentAttendance{}
+	positions := make(map[uuid.UUID]int)
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var sheet student.StudentAttendance
+		err := rows.Scan(
+			&sheet.ID,
+			&sheet.StudentID,
+			&sheet.CourseID,
+			&sheet.Semester,
+			&sheet.Statistics.TotalClasses,
+			&sheet.Statistics.Present,
+			&sheet.Statistics.Absent,
+			&sheet.Statistics.Leave,
+			&sheet.CreatedAt,
+			&sheet.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan attendance", "error", err)
+			return nil, fmt.Errorf("failed to scan attendance: %w", err)
+		}
+		sheet.Statistics.AttendanceRate = sheet.Statistics.Rate()
+		sheet.Records = []student.AttendanceRecord{}
+
+		positions[sheet.ID] = len(attendance)
+		ids = append(ids, sheet.ID)
+		attendance = append(attendance, sheet)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over attendance rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return attendance, nil
+	}
+
+	recordRows, err := r.pool.Query(ctx, `
+	SELECT AttendanceID, Date, Status, Remarks, RecordedBy
+	FROM student_schema.student_attendance_records_table
+	WHERE AttendanceID = ANY($1)
+	ORDER BY Date`,
+		ids,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get attendance records", "student_id", studentID, "error", err)
+		return nil, fmt.Errorf("failed to get attendance records: %w", err)
+	}
+	defer recordRows.Close()
+
+	for recordRows.Next() {
+		var attendanceID uuid.UUID
+		var record student.AttendanceRecord
+		if err := recordRows.Scan(&attendanceID, &record.Date, &record.Status, &record.Remarks, &record.RecordedBy); err != nil {
+			r.logger.Error("Failed to scan attendance record", "error", err)
+			return nil, fmt.Errorf("failed to scan attendance record: %w", err)
+		}
+		sheet := &attendance[positions[attendanceID]]
+		sheet.Records = append(sheet.Records, record)
+	}
+
+	if err := recordRows.Err(); err != nil {
+		r.logger.Error("Error iterating over attendance record rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return attendance, nil
+}
+
+// RecordAttendance stores the attendance records of a student for a course and semester. The sheet is
+// created on first use and a record replaces the one of the same date, see markAttendanceTx.
+func (r *PostgresStudentRepository) RecordAttendance(ctx context.Context, attendance *student.StudentAttendance, policy student.AttendancePolicy) error {
+	r.logger.Debug("Recording attendance", "student_id", attendance.StudentID, "course_id", attendance.CourseID, "records", len(attendance.Records))
+
+	marks := make([]attendanceMark, len(attendance.Records))
+	for i, record := range attendance.Records {
+		marks[i] = attendanceMark{
+			studentID:  attendance.StudentID,
+			date:       record.Date,
+			status:     record.Status,
+			remarks:    record.Remarks,
+			recordedBy: record.RecordedBy,
+		}
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The sheet is created even without records so that it can be listed
+	sheets, err := upsertAttendanceSheetsTx(ctx, tx, attendance.CourseID, attendance.Semester, []uuid.UUID{attendance.StudentID}, attendance.UpdatedAt, attendance.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return student.ErrStudentNotFound
+		}
+		r.logger.Error("Failed to store attendance", "student_id", attendance.StudentID, "error", err)
+		return err
+	}
+
+	summaries, _, err := markAttendanceTx(ctx, tx, sheets, marks, policy, attendance.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to record attendance", "student_id", attendance.StudentID, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit attendance", "student_id", attendance.StudentID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	summary := summaries[0]
+	attendance.ID = summary.AttendanceID
+	attendance.Statistics = summary.Statistics
+	if summary.ShortageAlerted {
+		r.logger.Info("Attendance shortage alert queued", "student_id", attendance.StudentID, "course_id", attendance.CourseID, "attendance_rate", summary.Statistics.AttendanceRate)
+	}
+
+	return nil
+}
+
+// MarkSectionAttendance records one class for every active student of the section, see markAttendanceTx.
+// Every mark must belong to a student of the section.
+func (r *PostgresStudentRepository) MarkSectionAttendance(ctx context.Context, sheet student.SectionAttendance, policy student.AttendancePolicy) (*student.SectionAttendanceResult, error) {
+	r.logger.Debug("Marking section attendance", "branch", sheet.Branch, "batch", sheet.Batch, "section", sheet.Section, "course_id", sheet.CourseID, "date", sheet.Date)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+	SELECT e.ID
+	FROM public.enrollment_master_lookup_table e
+	JOIN student_schema.student_academic_details_table a ON a.ID = e.AcademicDetailsID
+	WHERE a.Branch = $1
+		AND a.YearOfEnrollment::text = split_part($2, '-', 1)
+		AND a.Section = $3
+		AND e.Status = $4
+		AND e.DeletedAt IS NULL
+	ORDER BY e.EnrollmentNo`,
+		sheet.Branch, sheet.Batch, sheet.Section, student.StatusActive,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get section students", "error", err)
+		return nil, fmt.Errorf("failed to get section students: %w", err)
+	}
+	studentIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("Failed to scan section students", "error", err)
+		return nil, fmt.Errorf("failed to scan section students: %w", err)
+	}
+	if len(studentIDs) == 0 {
+		return nil, student.ErrSectionNotFound
+	}
+
+	exceptions := make(map[uuid.UUID]student.AttendanceMark, len(sheet.Marks))
+	for _, mark := range sheet.Marks {
+		exceptions[mark.StudentID] = mark
+	}
+
+	marks := make([]attendanceMark, len(studentIDs))
+	for i, studentID := range studentIDs {
+		mark, ok := exceptions[studentID]
+		if !ok {
+			mark = student.AttendanceMark{StudentID: studentID, Status: sheet.DefaultStatus}
+		}
+		delete(exceptions, studentID)
+
+		marks[i] = attendanceMark{
+			studentID:  studentID,
+			date:       sheet.Date,
+			status:     mark.Status,
+			remarks:    mark.Remarks,
+			recordedBy: sheet.RecordedBy,
+		}
+	}
+	for studentID := range exceptions {
+		return nil, fmt.Errorf("%w: %s", student.ErrStudentNotInSection, studentID)
+	}
+
+	now := time.Now()
+	sheets, err := upsertAttendanceSheetsTx(ctx, tx, sheet.CourseID, sheet.Semester, studentIDs, now, uuid.Nil)
+	if err != nil {
+		r.logger.Error("Failed to store attendance sheets", "error", err)
+		return nil, err
+	}
+
+	summaries, marked, err := markAttendanceTx(ctx, tx, sheets, marks, policy, now)
+	if err != nil {
+		r.logger.Error("Failed to mark section attendance", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit section attendance", "error", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result := &student.SectionAttendanceResult{
+		Marked:    marked,
+		Unchanged: len(marks) - marked,
+		Students:  summaries,
+	}
+
+	r.logger.Info(
+		"Section attendance marked",
+		"branch", sheet.Branch,
+		"batch", sheet.Batch,
+		"section", sheet.Section,
+		"course_id", sheet.CourseID,
+		"marked", result.Marked,
+		"unchanged", result.Unchanged,
+	)
+	return result, nil
+}
+
+// GetAttendanceStatistics sums the attendance counters of a student's sheets, optionally for a semester
+func (r *PostgresStudentRepository) GetAttendanceStatistics(ctx context.Context, studentID uuid.UUID, semester *int) (student.AttendanceStatistics, error) {
+	var stats student.AttendanceStatistics
+	err := r.pool.QueryRow(ctx, `
+	SELECT
+		COALESCE(SUM(TotalClasses), 0), COALESCE(SUM(PresentCount), 0),
+		COALESCE(SUM(AbsentCount), 0), COALESCE(SUM(LeaveCount), 0)
+	FROM student_schema.student_attendance_table
+	WHERE StudentID = $1 AND ($2::int IS NULL OR Semester = $2)`,
+		studentID, semester,
+	).Scan(&stats.TotalClasses, &stats.Present, &stats.Absent, &stats.Leave)
+	if err != nil {
+		r.logger.Error("Failed to get attendance statistics", "student_id", studentID, "error", err)
+		return stats, fmt.Errorf("failed to get attendance statistics: %w", err)
+	}
+
+	stats.AttendanceRate = stats.Rate()
+	return stats, nil
+}
+
+// ListPendingShortageAlerts retrieves the oldest alerts still to be sent, with the name and email of the student
+func (r *PostgresStudentRepository) ListPendingShortageAlerts(ctx context.Context, maxAttempts, limit int) ([]student.AttendanceShortageAlert, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT
+		sa.ID, sa.StudentID, sa.AttendanceID, sa.CourseID, sa.Semester, sa.AttendanceRate, sa.Threshold,
+		sa.Attempts, sa.LastError, sa.CreatedAt, sa.SentAt, sa.ResolvedAt, p.Name, l.Email
+	FROM student_schema.attendance_shortage_alerts sa
+	JOIN public.enrollment_master_lookup_table e ON e.ID = sa.StudentID
+	JOIN student_schema.student_login_details_table l ON l.ID = e.LogInDetailsID
+	JOIN student_schema.student_profile_details_table p ON p.ID = e.ProfileDetailsID
+	WHERE sa.SentAt IS NULL AND sa.ResolvedAt IS NULL AND sa.Attempts < $1 AND e.DeletedAt IS NULL
+	ORDER BY sa.CreatedAt
+	LIMIT $2`,
+		maxAttempts, limit,
+	)
+	if err != nil {
+		r.logger.Error("Failed to list pending shortage alerts", "error", err)
+		return nil, fmt.Errorf("failed to list pending shortage alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []student.AttendanceShortageAlert{}
+	for rows.Next() {
+		var alert student.AttendanceShortageAlert
+		err := rows.Scan(
+			&alert.ID,
+			&alert.StudentID,
+			&alert.AttendanceID,
+			&alert.CourseID,
+			&alert.Semester,
+			&alert.AttendanceRate,
+			&alert.Threshold,
+			&alert.Attempts,
+			&alert.LastError,
+			&alert.CreatedAt,
+			&alert.SentAt,
+			&alert.ResolvedAt,
+			&alert.StudentName,
+			&alert.StudentEmail,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan shortage alert", "error", err)
+			return nil, fmt.Errorf("failed to scan shortage alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over shortage alert rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// MarkShortageAlertSent records the delivery of a shortage alert
+func (r *PostgresStudentRepository) MarkShortageAlertSent(ctx context.Context, alertID uuid.UUID, sentAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+	UPDATE student_schema.attendance_shortage_alerts SET
+		SentAt = $2,
+		Attempts = Attempts + 1,
+		LastError = ''
+	WHERE ID = $1`,
+		alertID, sentAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to mark shortage alert sent", "id", alertID, "error", err)
+		return fmt.Errorf("failed to mark shortage alert sent: %w", err)
+	}
+
+	return nil
+}
+
+// RecordShortageAlertFailure counts a failed delivery attempt, keeping the reason
+func (r *PostgresStudentRepository) RecordShortageAlertFailure(ctx context.Context, alertID uuid.UUID, reason string) error {
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+
+	_, err := r.pool.Exec(ctx, `
+	UPDATE student_schema.attendance_shortage_alerts SET
+		Attempts = Attempts + 1,
+		LastError = $2
+	WHERE ID = $1`,
+		alertID, reason,
+	)
+	if err != nil {
+		r.logger.Error("Failed to record shortage alert failure", "id", alertID, "error", err)
+		return fmt.Errorf("failed to record shortage alert failure: %w", err)
+	}
+
+	return nil
+}
+
+// GetPreferences retrieves the preferences of a student.
+// Returns nil without an error when the student has not saved any.
+func (r *PostgresStudentRepository) GetPreferences(ctx context.Context, studentID uuid.UUID) (*student.StudentPreferences, error) {
+	preferences := &student.StudentPreferences{}
+	err := r.pool.QueryRow(ctx, `
+	SELECT ID, StudentID, CalendarEnabled, CalendarProvider, CalendarExternalID, CreatedAt, UpdatedAt
+	FROM student_schema.student_preferences_table
+	WHERE StudentID = $1`,
+		studentID,
+	).Scan(
+		&preferences.ID,
+		&preferences.StudentID,
+		&preferences.Calendar.Enabled,
+		&preferences.Calendar.Provider,
+		&preferences.Calendar.ExternalID,
+		&preferences.CreatedAt,
+		&preferences.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get preferences", "student_id", studentID, "error", err)
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences saves the preferences of a student, creating them on first use
+func (r *PostgresStudentRepository) UpdatePreferences(ctx context.Context, preferences *student.StudentPreferences) error {
+	if preferences.ID == uuid.Nil {
+		preferences.ID = uuid.New()
+	}
+	if preferences.CreatedAt.IsZero() {
+		preferences.CreatedAt = preferences.UpdatedAt
+	}
+
+	err := r.pool.QueryRow(ctx, `
+	INSERT INTO student_schema.student_preferences_table (
+		ID, StudentID, CalendarEnabled, CalendarProvider, CalendarExternalID, CreatedAt, UpdatedAt
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	)
+	ON CONFLICT (StudentID) DO UPDATE SET
+		CalendarEnabled = EXCLUDED.CalendarEnabled,
+		CalendarProvider = EXCLUDED.CalendarProvider,
+		CalendarExternalID = EXCLUDED.CalendarExternalID,
+		UpdatedAt = EXCLUDED.UpdatedAt
+	RETURNING ID, CreatedAt`,
+		preferences.ID,
+		preferences.StudentID,
+		preferences.Calendar.Enabled,
+		preferences.Calendar.Provider,
+		preferences.Calendar.ExternalID,
+		preferences.CreatedAt,
+		preferences.UpdatedAt,
+	).Scan(&preferences.ID, &preferences.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return student.ErrStudentNotFound
+		}
+		r.logger.Error("Failed to update preferences", "student_id", preferences.StudentID, "error", err)
+		return fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	return nil
+}
+
+// contactColumns are the columns read by scanContact
+const contactColumns = `ID, StudentID, Name, Relation, Phone, Email, IsEmergency, IsPrimary, CreatedAt, UpdatedAt`
+
+// GetContacts retrieves the contacts of a student, the primary contact first and then the other
+// emergency contacts
+func (r *PostgresStudentRepository) GetContacts(ctx context.Context, studentID uuid.UUID) ([]student.StudentContact, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT `+contactColumns+`
+	FROM student_schema.student_contacts_table
+	WHERE StudentID = $1
+	ORDER BY IsPrimary DESC, IsEmergency DESC, CreatedAt`,
+		studentID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get contacts", "student_id", studentID, "error", err)
+		return nil, fmt.Errorf("failed to get contacts: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := []student.StudentContact{}
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan contact", "error", err)
+			return nil, fmt.Errorf("failed to scan contact: %w", err)
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over contact rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return contacts, nil
+}
+
+// AddContact adds a contact to a student. A primary contact replaces the previous one, and the
+// contact becomes primary when it is the first emergency contact of the student.
+func (r *PostgresStudentRepository) AddContact(ctx context.Context, contact *student.StudentContact) error {
+	if contact.ID == uuid.Nil {
+		contact.ID = uuid.New()
+	}
+	if contact.CreatedAt.IsZero() {
+		contact.CreatedAt = time.Now()
+		contact.UpdatedAt = contact.CreatedAt
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockStudentContactsTx(ctx, tx, contact.StudentID); err != nil {
+		return err
+	}
+
+	if contact.IsPrimary {
+		if err := demotePrimaryContactTx(ctx, tx, contact.StudentID); err != nil {
+			r.logger.Error("Failed to demote primary contact", "student_id", contact.StudentID, "error", err)
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO student_schema.student_contacts_table (
+		ID, StudentID, Name, Relation, Phone, Email, IsEmergency, IsPrimary, CreatedAt, UpdatedAt
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	)`,
+		contact.ID,
+		contact.StudentID,
+		contact.Name,
+		contact.Relation,
+		contact.Phone,
+		contact.Email,
+		contact.IsEmergency,
+		contact.IsPrimary,
+		contact.CreatedAt,
+		contact.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to add contact", "student_id", contact.StudentID, "error", err)
+		return fmt.Errorf("failed to add contact: %w", err)
+	}
+
+	promotedID, err := ensurePrimaryContactTx(ctx, tx, contact.StudentID)
+	if err != nil {
+		r.logger.Error("Failed to ensure primary contact", "student_id", contact.StudentID, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit contact", "student_id", contact.StudentID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if promotedID == contact.ID {
+		contact.IsPrimary = true
+	}
+	return nil
+}
+
+// UpdateContact updates a contact of a student. The primary contact stays primary while it is an
+// emergency contact, another contact must be marked primary to replace it.
+func (r *PostgresStudentRepository) UpdateContact(ctx context.Context, contact *student.StudentContact) error {
+	contact.UpdatedAt = time.Now()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockStudentContactsTx(ctx, tx, contact.StudentID); err != nil {
+		return err
+	}
+
+	var wasPrimary bool
+	err = tx.QueryRow(ctx, `
+	SELECT IsPrimary FROM student_schema.student_contacts_table WHERE ID = $1 AND StudentID = $2`,
+		contact.ID, contact.StudentID,
+	).Scan(&wasPrimary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return student.ErrContactNotFound
+		}
+		r.logger.Error("Failed to get contact", "id", contact.ID, "error", err)
+		return fmt.Errorf("failed to get contact: %w", err)
+	}
+
+	if wasPrimary && contact.IsEmergency && !contact.IsPrimary {
+		return student.ErrPrimaryContactRequired
+	}
+
+	if contact.IsPrimary && !wasPrimary {
+		if err := demotePrimaryContactTx(ctx, tx, contact.StudentID); err != nil {
+			r.logger.Error("Failed to demote primary contact", "student_id", contact.StudentID, "error", err)
+			return err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+	UPDATE student_schema.student_contacts_table SET
+		Name = $3,
+		Relation = $4,
+		Phone = $5,
+		Email = $6,
+		IsEmergency = $7,
+		IsPrimary = $8,
+		UpdatedAt = $9
+	WHERE ID = $1 AND StudentID = $2
+	RETURNING CreatedAt`,
+		contact.ID,
+		contact.StudentID,
+		contact.Name,
+		contact.Relation,
+		contact.Phone,
+		contact.Email,
+		contact.IsEmergency,
+		contact.IsPrimary,
+		contact.UpdatedAt,
+	).Scan(&contact.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to update contact", "id", contact.ID, "error", err)
+		return fmt.Errorf("failed to update contact: %w", err)
+	}
+
+	promotedID, err := ensurePrimaryContactTx(ctx, tx, contact.StudentID)
+	if err != nil {
+		r.logger.Error("Failed to ensure primary contact", "student_id", contact.StudentID, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit contact", "id", contact.ID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if promotedID == contact.ID {
+		contact.IsPrimary = true
+	}
+	return nil
+}
+
+// DeleteContact deletes a contact of a student. When the primary contact is deleted, the oldest
+// remaining emergency contact becomes primary.
+func (r *PostgresStudentRepository) DeleteContact(ctx context.Context, studentID, contactID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockStudentContactsTx(ctx, tx, studentID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+	DELETE FROM student_schema.student_contacts_table WHERE ID = $1 AND StudentID = $2`,
+		contactID, studentID,
+	)
+	if err != nil {
+		r.logger.Error("Failed to delete contact", "id", contactID, "error", err)
+		return fmt.Errorf("failed to delete contact: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return student.ErrContactNotFound
+	}
+
+	if _, err := ensurePrimaryContactTx(ctx, tx, studentID); err != nil {
+		r.logger.Error("Failed to ensure primary contact", "student_id", studentID, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit contact deletion", "id", contactID, "error", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetGuardianRecipients retrieves the emergency contacts of the students, along with what identifies
+// each student to the guardian. Students that don't exist or are deleted are left out, students
+// without emergency contacts have none.
+func (r *PostgresStudentRepository) GetGuardianRecipients(ctx context.Context, studentIDs []uuid.UUID) ([]student.GuardianRecipients, error) {
+	rows, err := r.pool.Query(ctx, `
+	SELECT
+		e.ID, e.EnrollmentNo, p.Name, a.Branch, a.YearOfEnrollment, a.YearOfGraduation,
+		c.ID, c.Name, c.Relation, c.Phone, c.Email, c.IsPrimary
+	FROM public.enrollment_master_lookup_table e
+	JOIN student_schema.student_profile_details_table p ON p.ID = e.ProfileDetailsID
+	JOIN student_schema.student_academic_details_table a ON a.ID = e.AcademicDetailsID
+	LEFT JOIN student_schema.student_contacts_table c ON c.StudentID = e.ID AND c.IsEmergency
+	WHERE e.ID = ANY($1) AND e.DeletedAt IS NULL
+	ORDER BY e.EnrollmentNo, c.IsPrimary DESC, c.CreatedAt`,
+		studentIDs,
+	)
+	if err != nil {
+		r.logger.Error("Failed to get guardian recipients", "error", err)
+		return nil, fmt.Errorf("failed to get guardian recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := []student.GuardianRecipients{}
+	for rows.Next() {
+		var (
+			studentID        uuid.UUID
+			enrollmentNo     string
+			studentName      string
+			branch           string
+			yearOfEnrollment int
+			yearOfGraduation *int
+			contactID        *uuid.UUID
+			name             *string
+			relation         *string
+			phone            *string
+			email            *string
+			isPrimary        *bool
+		)
+		err := rows.Scan(
+			&studentID, &enrollmentNo, &studentName, &branch, &yearOfEnrollment, &yearOfGraduation,
+			&contactID, &name, &relation, &phone, &email, &isPrimary,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan guardian recipient", "error", err)
+			return nil, fmt.Errorf("failed to scan guardian recipient: %w", err)
+		}
+
+		// Rows of a student are adjacent
+		if len(recipients) == 0 || recipients[len(recipients)-1].StudentID != studentID {
+			recipients = append(recipients, student.GuardianRecipients{
+				StudentID:    studentID,
+				EnrollmentNo: enrollmentNo,
+				StudentName:  studentName,
+				Branch:       branch,
+				Batch:        formatBatch(yearOfEnrollment, yearOfGraduation),
+				Contacts:     []student.StudentContact{},
+			})
+		}
+		if contactID == nil {
+			continue
+		}
+
+		current := &recipients[len(recipients)-1]
+		current.Contacts = append(current.Contacts, student.StudentContact{
+			ID:          *contactID,
+			StudentID:   studentID,
+			Name:        *name,
+			Relation:    *relation,
+			Phone:       *phone,
+			Email:       *email,
+			IsEmergency: true,
+			IsPrimary:   *isPrimary,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating over guardian recipient rows", "error", err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// GetDossier assembles the dossier of a student in a single round trip: the enrollment record joined
+// with its detail tables, and a batched query for each requested list section.
+// Returns nil without an error when the student does not exist.
+func (r *PostgresStudentRepository) GetDossier(ctx context.Context, enrollmentNo string, options student.DossierOptions) (*student.Dossier, error) {
+	r.logger.Debug("Fetching student dossier", "enrollment_id", enrollmentNo, "sections", options.Sections)
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+	SELECT
+		e.ID, e.EnrollmentNo, e.Status,
+		l.Email, l.Phone, l.LastLoginAt,
+		a.Program, a.Branch, a.YearOfEnrollment, a.YearOfGraduation, a.PresentSemester, a.Section,
+		a.CGPA, a.PreviousSemSGPA, a.SchoolForClassTen, a.ClassTenPercentage,
+		a.SchoolForClassTwelve, a.ClassTwelvePercentage,
+		p.Name, p.Gender, p.Category, p.DateOfBirth, p.PresentAddress, p.PermanentAddress,
+		COALESCE(photo.URL, ''), COALESCE(resume.URL, ''),
+		f.FatherName, f.FatherQualification, f.FatherProfession,
+		f.MotherName, f.MotherQualification, f.MotherProfession,
+		f.NoOfSiblings, f.TotalFamilyIncome,
+		s.ScholarshipName, s.ProvidedBy, s.AmountReceived
+	FROM public.enrollment_master_lookup_table e
+	JOIN student_schema.student_login_details_table l ON l.ID = e.LogInDetailsID
+	JOIN student_schema.student_academic_details_table a ON a.ID = e.AcademicDetailsID
+	JOIN student_schema.student_profile_details_table p ON p.ID = e.ProfileDetailsID
+	JOIN student_schema.student_family_details_table f ON f.ID = e.FamilyDetailsID
+	JOIN student_schema.student_scholarship_details_table s ON s.ID = e.ScholarshipDetailsID
+	LEFT JOIN student_schema.student_documents_table photo ON photo.DocumentID = p.PhotographID
+	LEFT JOIN student_schema.student_documents_table resume ON resume.DocumentID = p.ResumeID
+	WHERE e.EnrollmentNo = $1 AND e.DeletedAt IS NULL`,
+		enrollmentNo,
+	)
+
+	if options.Includes(student.DossierDocuments) {
+		batch.Queue(`
+		SELECT COALESCE(d.DocumentType, ''), COALESCE(d.URL, ''), d.UpdatedAt
+		FROM public.enrollment_master_lookup_table e
+		JOIN student_schema.student_academic_details_table a ON a.ID = e.AcademicDetailsID
+		JOIN student_schema.student_profile_details_table p ON p.ID = e.ProfileDetailsID
+		JOIN student_schema.student_documents_table d ON d.DocumentID IN (
+			a.ClassTenMarksheetID, a.ClassTwelveMarksheetID, p.PhotographID, p.ResumeID
+		)
+		WHERE e.EnrollmentNo = $1
+		ORDER BY d.DocumentID`,
+			enrollmentNo,
+		)
+	}
+	if options.Includes(student.DossierCertifications) {
+		batch.Queue(`
+		SELECT c.CertificationName, c.IssuingAuthority, c.IssuingDate, COALESCE(d.URL, '')
+		FROM student_schema.student_certification_lookup_table lookup
+		JOIN student_schema.student_certification_and_achievements_details_table c ON c.ID = lookup.StudentCertificationDetailsID
+		LEFT JOIN student_schema.student_documents_table d ON d.DocumentID = c.DocumentID
+		WHERE lookup.EnrollmentNo = $1
+		ORDER BY c.IssuingDate DESC, c.ID`,
+			enrollmentNo,
+		)
+	}
+	if options.Includes(student.DossierLeaderboard) {
+		batch.Queue(`
+		SELECT lr.Rank, lr.Score, lr.Domain, lr.SubDomain, lr.TimePeriod, lr.LastUpdated
+		FROM student_schema.student_leaderboard_lookup_table lookup
+		JOIN student_schema.student_leaderboard_records_table lr ON lr.LeaderboardRecordID = lookup.LeaderboardRecordID
+		WHERE lookup.EnrollmentNo = $1
+		ORDER BY lr.LastUpdated DESC`,
+			enrollmentNo,
+		)
+	}
+	if options.Includes(student.DossierPracticeSessions) {
+		batch.Queue(`
+		SELECT
+			ps.PracticeSessionID, ps.DomainID, ps.SubDomainID, ps.DifficultyLevelID,
+			ps.QuestionsAttempted, ps.QuestionsCorrect, ps.ScoreEarned,
+			ps.StartTime, ps.EndTime, COALESCE(ps.Feedbacks, ''), lookup.Status
+		FROM student_schema.student_practice_session_lookup_table lookup
+		JOIN student_schema.student_practice_session_records ps ON ps.PracticeSessionID = lookup.PracticeSessionID
+		WHERE lookup.EnrollmentNo = $1
+		ORDER BY ps.StartTime DESC`,
+			enrollmentNo,
+		)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	dossier, err := scanDossier(results.QueryRow(), options)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		r.logger.Error("Failed to get student dossier", "enrollment_id", enrollmentNo, "error", err)
+		return nil, fmt.Errorf("failed to get student dossier: %w", err)
+	}
+
+	if options.Includes(student.DossierDocuments) {
+		dossier.Documents, err = collectDossierRows(results, func(row pgx.Row, document *student.DossierDocument) error {
+			return row.Scan(&document.DocumentType, &document.URL, &document.UpdatedAt)
+		})
+		if err != nil {
+			r.logger.Error("Failed to get student documents", "enrollment_id", enrollmentNo, "error", err)
+			return nil, fmt.Errorf("failed to get student documents: %w", err)
+		}
+	}
+	if options.Includes(student.DossierCertifications) {
+		dossier.Certifications, err = collectDossierRows(results, func(row pgx.Row, certification *student.DossierCertification) error {
+			return row.Scan(
+				&certification.CertificationName,
+				&certification.IssuingAuthority,
+				&certification.IssuingDate,
+				&certification.DocumentURL,
+			)
+		})
+		if err != nil {
+			r.logger.Error("Failed to get student certifications", "enrollment_id", enrollmentNo, "error", err)
+			return nil, fmt.Errorf("failed to get student certifications: %w", err)
+		}
+	}
+	if options.Includes(student.DossierLeaderboard) {
+		dossier.Leaderboard, err = collectDossierRows(results, func(row pgx.Row, record *student.DossierLeaderboardRecord) error {
+			return row.Scan(&record.Rank, &record.Score, &record.Domain, &record.SubDomain, &record.TimePeriod, &record.LastUpdated)
+		})
+		if err != nil {
+			r.logger.Error("Failed to get student leaderboard records", "enrollment_id", enrollmentNo, "error", err)
+			return nil, fmt.Errorf("failed to get student leaderboard records: %w", err)
+		}
+	}
+	if options.Includes(student.DossierPracticeSessions) {
+		dossier.PracticeSessions, err = collectDossierRows(results, func(row pgx.Row, session *student.DossierPracticeSession) error {
+			return row.Scan(
+				&session.SessionID,
+				&session.DomainID,
+				&session.SubDomainID,
+				&session.DifficultyLevelID,
+				&session.QuestionsAttempted,
+				&session.QuestionsCorrect,
+				&session.ScoreEarned,
+				&session.StartTime,
+				&session.EndTime,
+				&session.Feedbacks,
+				&session.Status,
+			)
+		})
+		if err != nil {
+			r.logger.Error("Failed to get student practice sessions", "enrollment_id", enrollmentNo, "error", err)
+			return nil, fmt.Errorf("failed to get student practice sessions: %w", err)
+		}
+	}
+
+	return dossier, nil
+}
+
+// studentWriteError maps unique violations of a student write to the student domain errors
+func (r *PostgresStudentRepository) studentWriteError(operation, enrollmentID string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		switch pgErr.ConstraintName {
+		case "student_login_details_table_email_key":
+			return student.ErrEmailAlreadyExists
+		case "enrollment_master_lookup_table_pkey":
+			return student.ErrEnrollmentIDExists
+		}
+	}
+
+	r.logger.Error("Failed to "+operation, "enrollment_id", enrollmentID, "error", err)
+	return fmt.Errorf("failed to %s: %w", operation, err)
+}
+
+// deleteStudentRecordsTx deletes the enrollment record of a student along with its detail tables,
+// certifications, leaderboard records, practice sessions and documents. The enrollment record
+// references its detail tables, which reference the documents. Every statement of the query sees
+// the same rows, so the details are found through the deleted enrollment record.
+func deleteStudentRecordsTx(ctx context.Context, tx pgx.Tx, enrollmentNo string) error {
+	_, err := tx.Exec(ctx, `
+	WITH master AS (
+		DELETE FROM public.enrollment_master_lookup_table
+		WHERE EnrollmentNo = $1
+		RETURNING LogInDetailsID, AcademicDetailsID, FamilyDetailsID, ProfileDetailsID, ScholarshipDetailsID
+	), academic AS (
+		DELETE FROM student_schema.student_academic_details_table
+		WHERE ID IN (SELECT AcademicDetailsID FROM master)
+		RETURNING ClassTenMarksheetID, ClassTwelveMarksheetID
+	), details AS (
+		DELETE FROM student_schema.student_profile_details_table
+		WHERE ID IN (SELECT ProfileDetailsID FROM master)
+		RETURNING PhotographID, ResumeID
+	), login AS (
+		DELETE FROM student_schema.student_login_details_table
+		WHERE ID IN (SELECT LogInDetailsID FROM master)
+	), family AS (
+		DELETE FROM student_schema.student_family_details_table
+		WHERE ID IN (SELECT FamilyDetailsID FROM master)
+	), scholarship AS (
+		DELETE FROM student_schema.student_scholarship_details_table
+		WHERE ID IN (SELECT ScholarshipDetailsID FROM master)
+	), certification_lookup AS (
+		DELETE FROM student_schema.student_certification_lookup_table
+		WHERE EnrollmentNo = $1
+		RETURNING StudentCertificationDetailsID
+	), certifications AS (
+		DELETE FROM student_schema.student_certification_and_achievements_details_table
+		WHERE ID IN (SELECT StudentCertificationDetailsID FROM certification_lookup)
+		RETURNING DocumentID
+	), leaderboard_lookup AS (
+		DELETE FROM student_schema.student_leaderboard_lookup_table
+		WHERE EnrollmentNo = $1
+		RETURNING LeaderboardRecordID
+	), leaderboard AS (
+		DELETE FROM student_schema.student_leaderboard_records_table
+		WHERE LeaderboardRecordID IN (SELECT LeaderboardRecordID FROM leaderboard_lookup)
+	), practice_session_lookup AS (
+		DELETE FROM student_schema.student_practice_session_lookup_table
+		WHERE EnrollmentNo = $1
+		RETURNING PracticeSessionID
+	), practice_sessions AS (
+		DELETE FROM student_schema.student_practice_session_records
+		WHERE PracticeSessionID IN (SELECT PracticeSessionID FROM practice_session_lookup)
+	)
+	DELETE FROM student_schema.student_documents_table
+	WHERE DocumentID IN (
+		SELECT ClassTenMarksheetID FROM academic
+		UNION ALL SELECT ClassTwelveMarksheetID FROM academic
+		UNION ALL SELECT PhotographID FROM details
+		UNION ALL SELECT ResumeID FROM details
+		UNION ALL SELECT DocumentID FROM certifications
+	)`,
+		enrollmentNo,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete student records: %w", err)
+	}
+
+	return nil
+}
+
+// lockStudentContactsTx locks the enrollment record of a student until the transaction ends, so
+// that concurrent changes to the contacts of the student can't leave it with two primary contacts
+// or none
+func lockStudentContactsTx(ctx context.Context, tx pgx.Tx, studentID uuid.UUID) error {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+	SELECT ID FROM public.enrollment_master_lookup_table WHERE ID = $1 AND DeletedAt IS NULL FOR UPDATE`,
+		studentID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return student.ErrStudentNotFound
+		}
+		return fmt.Errorf("failed to lock student: %w", err)
+	}
+
+	return nil
+}
+
+// demotePrimaryContactTx clears the primary flag of the current primary contact of a student
+func demotePrimaryContactTx(ctx context.Context, tx pgx.Tx, studentID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+	UPDATE student_schema.student_contacts_table SET
+		IsPrimary = FALSE
+	WHERE StudentID = $1 AND IsPrimary`,
+		studentID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to demote primary contact: %w", err)
+	}
+
+	return nil
+}
+
+// ensurePrimaryContactTx makes the oldest emergency contact of a student primary when it has none,
+// and returns the ID of the promoted contact, uuid.Nil if none was
+func ensurePrimaryContactTx(ctx context.Context, tx pgx.Tx, studentID uuid.UUID) (uuid.UUID, error) {
+	var promotedID uuid.UUID
+	err := tx.QueryRow(ctx, `
+	UPDATE student_schema.student_contacts_table SET
+		IsPrimary = TRUE
+	WHERE ID = (
+		SELECT ID FROM student_schema.student_contacts_table
+		WHERE StudentID = $1 AND IsEmergency
+		ORDER BY CreatedAt, ID
+		LIMIT 1
+	) AND NOT EXISTS (
+		SELECT 1 FROM student_schema.student_contacts_table WHERE StudentID = $1 AND IsPrimary
+	)
+	RETURNING ID`,
+		studentID,
+	).Scan(&promotedID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("failed to promote primary contact: %w", err)
+	}
+
+	return promotedID, nil
+}
+
+// attendanceMark is one attendance record to store
+type attendanceMark struct {
+	studentID  uuid.UUID
+	date       time.Time
+	status     student.AttendanceType
+	remarks    string
+	recordedBy uuid.UUID
+}
+
+// upsertAttendanceSheetsTx creates the attendance sheets of the students for the course and semester
+// where missing, and returns the sheet ID of every student. Existing sheets are locked until the
+// transaction ends, so that concurrent markings of a sheet are serialized and its counters stay
+// exact. sheetID is used for the sheet of a single student when set.
+func upsertAttendanceSheetsTx(ctx context.Context, tx pgx.Tx, courseID uuid.UUID, semester int, studentIDs []uuid.UUID, now time.Time, sheetID uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, `
+	INSERT INTO student_schema.student_attendance_table (ID, StudentID, CourseID, Semester, CreatedAt, UpdatedAt)
+	SELECT COALESCE($5, gen_random_uuid()), s.student_id, $2, $3, $4, $4
+	FROM unnest($1::uuid[]) AS s (student_id)
+	ON CONFLICT (StudentID, CourseID, Semester) DO UPDATE SET
+		UpdatedAt = EXCLUDED.UpdatedAt
+	RETURNING StudentID, ID`,
+		studentIDs, courseID, semester, now, nullableUUID(sheetID),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store attendance sheets: %w", err)
+	}
+	defer rows.Close()
+
+	sheets := make(map[uuid.UUID]uuid.UUID, len(studentIDs))
+	for rows.Next() {
+		var studentID, attendanceID uuid.UUID
+		if err := rows.Scan(&studentID, &attendanceID); err != nil {
+			return nil, fmt.Errorf("failed to scan attendance sheet: %w", err)
+		}
+		sheets[studentID] = attendanceID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to store attendance sheets: %w", err)
+	}
+
+	return sheets, nil
+}
+
+// markAttendanceTx stores the marks on the sheets of their students and returns the attendance of
+// every sheet, along with the number of records written. A mark replaces the record of the same
+// date, a mark equal to the stored record is skipped, so submitting the same marks again changes
+// nothing. The counters of a sheet are adjusted by the difference each written record makes instead
+// of being recounted. A shortage alert is queued for the sheets that fall below the policy's
+// threshold, and the open alerts of the sheets that recovered are resolved.
+func markAttendanceTx(ctx context.Context, tx pgx.Tx, sheets map[uuid.UUID]uuid.UUID, marks []attendanceMark, policy student.AttendancePolicy, now time.Time) ([]student.StudentAttendanceSummary, int, error) {
+	// A date may only be written once per statement, the last mark of a date wins
+	type markKey struct {
+		attendanceID uuid.UUID
+		date         string
+	}
+	positions := make(map[markKey]int, len(marks))
+	attendanceIDs := []uuid.UUID{}
+	dates := []time.Time{}
+	statuses := []string{}
+	remarks := []string{}
+	recordedBy := []uuid.UUID{}
+	for _, mark := range marks {
+		attendanceID := sheets[mark.studentID]
+		key := markKey{attendanceID, mark.date.Format(time.DateOnly)}
+		if i, ok := positions[key]; ok {
+			statuses[i], remarks[i], recordedBy[i] = string(mark.status), mark.remarks, mark.recordedBy
+			continue
+		}
+		positions[key] = len(attendanceIDs)
+		attendanceIDs = append(attendanceIDs, attendanceID)
+		dates = append(dates, mark.date)
+		statuses = append(statuses, string(mark.status))
+		remarks = append(remarks, mark.remarks)
+		recordedBy = append(recordedBy, mark.recordedBy)
+	}
+
+	// Every sub-statement sees the records as they were before the upsert, previous holds the
+	// statuses that are being replaced
+	var marked int
+	err := tx.QueryRow(ctx, `
+	WITH input AS (
+		SELECT *
+		FROM unnest($1::uuid[], $2::date[], $3::text[], $4::text[], $5::uuid[])
+			AS i (attendance_id, date, status, remarks, recorded_by)
+	), previous AS (
+		SELECT r.AttendanceID, r.Date, r.Status
+		FROM student_schema.student_attendance_records_table r
+		JOIN input i ON i.attendance_id = r.AttendanceID AND i.date = r.Date
+	), upserted AS (
+		INSERT INTO student_schema.student_attendance_records_table AS r (
+			AttendanceID, Date, Status, Remarks, RecordedBy, RecordedAt
+		)
+		SELECT attendance_id, date, status, remarks, recorded_by, $6
+		FROM input
+		ON CONFLICT (AttendanceID, Date) DO UPDATE SET
+			Status = EXCLUDED.Status,
+			Remarks = EXCLUDED.Remarks,
+			RecordedBy = EXCLUDED.RecordedBy,
+			RecordedAt = EXCLUDED.RecordedAt
+		WHERE (r.Status, r.Remarks) IS DISTINCT FROM (EXCLUDED.Status, EXCLUDED.Remarks)
+		RETURNING AttendanceID, Date, Status
+	), deltas AS (
+		SELECT
+			u.AttendanceID,
+			COUNT(*) FILTER (WHERE p.Status IS NULL) AS total,
+			SUM((u.Status = 'present')::int - (p.Status IS NOT DISTINCT FROM 'present')::int) AS present,
+			SUM((u.Status = 'absent')::int - (p.Status IS NOT DISTINCT FROM 'absent')::int) AS absent,
+			SUM((u.Status = 'leave')::int - (p.Status IS NOT DISTINCT FROM 'leave')::int) AS on_leave
+		FROM upserted u
+		LEFT JOIN previous p ON p.AttendanceID = u.AttendanceID AND p.Date = u.Date
+		GROUP BY u.AttendanceID
+	), updated AS (
+		UPDATE student_schema.student_attendance_table t SET
+			TotalClasses = t.TotalClasses + d.total,
+			PresentCount = t.PresentCount + d.present,
+			AbsentCount = t.AbsentCount + d.absent,
+			LeaveCount = t.LeaveCount + d.on_leave
+		FROM deltas d
+		WHERE t.ID = d.AttendanceID
+	)
+	SELECT COUNT(*) FROM upserted`,
+		attendanceIDs, dates, statuses, remarks, recordedBy, now,
+	).Scan(&marked)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to store attendance records: %w", err)
+	}
+
+	sheetIDs := make([]uuid.UUID, 0, len(sheets))
+	for _, attendanceID := range sheets {
+		sheetIDs = append(sheetIDs, attendanceID)
+	}
+
+	// The rate is computed as in AttendanceStatistics.Rate
+	rows, err := tx.Query(ctx, `
+	WITH sheets AS (
+		SELECT ID, StudentID, CourseID, Semester, TotalClasses, PresentCount, AbsentCount, LeaveCount,
+			CASE WHEN TotalClasses = 0 THEN 0 ELSE PresentCount * 100.0 / TotalClasses END AS rate
+		FROM student_schema.student_attendance_table
+		WHERE ID = ANY($1)
+	), resolved AS (
+		UPDATE student_schema.attendance_shortage_alerts sa SET
+			ResolvedAt = $4
+		FROM sheets s
+		WHERE sa.AttendanceID = s.ID AND sa.ResolvedAt IS NULL AND s.rate >= $2
+	), queued AS (
+		INSERT INTO student_schema.attendance_shortage_alerts (
+			ID, StudentID, AttendanceID, CourseID, Semester, AttendanceRate, Threshold, CreatedAt
+		)
+		SELECT gen_random_uuid(), StudentID, ID, CourseID, Semester, rate, $2, $4
+		FROM sheets
+		WHERE TotalClasses >= $3 AND rate < $2
+		ON CONFLICT (AttendanceID) WHERE ResolvedAt IS NULL DO NOTHING
+		RETURNING AttendanceID
+	)
+	SELECT s.StudentID, s.ID, s.TotalClasses, s.PresentCount, s.AbsentCount, s.LeaveCount,
+		s.ID IN (SELECT AttendanceID FROM queued)
+	FROM sheets s`,
+		sheetIDs, policy.ShortageThreshold, policy.MinimumClasses, now,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to update attendance alerts: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := make(map[uuid.UUID]student.StudentAttendanceSummary, len(sheets))
+	for rows.Next() {
+		var summary student.StudentAttendanceSummary
+		stats := &summary.Statistics
+		err := rows.Scan(
+			&summary.StudentID,
+			&summary.AttendanceID,
+			&stats.TotalClasses,
+			&stats.Present,
+			&stats.Absent,
+			&stats.Leave,
+			&summary.ShortageAlerted,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan attendance statistics: %w", err)
+		}
+		stats.AttendanceRate = stats.Rate()
+		summaries[summary.StudentID] = summary
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to update attendance alerts: %w", err)
+	}
+
+	// Summaries follow the order of the marks
+	ordered := make([]student.StudentAttendanceSummary, 0, len(summaries))
+	for _, mark := range marks {
+		if summary, ok := summaries[mark.studentID]; ok {
+			ordered = append(ordered, summary)
+			delete(summaries, mark.studentID)
+		}
+	}
+	for _, summary := range summaries {
+		ordered = append(ordered, summary)
+	}
+
+	return ordered, marked, nil
+}
+
+// scanContact scans a row selected with contactColumns
+func scanContact(row pgx.Row) (student.StudentContact, error) {
+	var contact student.StudentContact
+	err := row.Scan(
+		&contact.ID,
+		&contact.StudentID,
+		&contact.Name,
+		&contact.Relation,
+		&contact.Phone,
+		&contact.Email,
+		&contact.IsEmergency,
+		&contact.IsPrimary,
+		&contact.CreatedAt,
+		&contact.UpdatedAt,
+	)
+	return contact, err
+}
+
+// scanStudent scans a row selected with studentColumns
+func scanStudent(row pgx.Row) (*student.Student, error) {
+	st := &student.Student{}
+	var name string
+	var dateOfBirth *time.Time
+	var yearOfEnrollment int
+	var yearOfGraduation *int
+
+	err := row.Scan(
+		&st.ID,
+		&st.EnrollmentID,
+		&name,
+		&st.Email,
+		&st.PhoneNumber,
+		&st.PasswordHash,
+		&st.LastLoginAt,
+		&dateOfBirth,
+		&st.Gender,
+		&st.Category,
+		&st.PresentAddress,
+		&st.PermanentAddress,
+		&st.Program,
+		&st.Branch,
+		&yearOfEnrollment,
+		&yearOfGraduation,
+		&st.PresentSemester,
+		&st.Section,
+		&st.Status,
+		&st.ProfileImageUrl,
+		&st.RoleIDs,
+		&st.CreatedAt,
+		&st.UpdatedAt,
+		&st.DeactivatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The profile details keep the full name, the first name ends at the first space
+	st.FirstName, st.LastName, _ = strings.Cut(name, " ")
+	if dateOfBirth != nil {
+		st.DateOfBirth = *dateOfBirth
+	}
+	st.Batch = formatBatch(yearOfEnrollment, yearOfGraduation)
+
+	return st, nil
+}
+
+// scanDossier scans the enrollment record of a dossier along with its detail tables, keeping the
+// sections the options include
+func scanDossier(row pgx.Row, options student.DossierOptions) (*student.Dossier, error) {
+	dossier := &student.Dossier{}
+	contact := &student.DossierContactDetails{}
+	academic := &student.DossierAcademicSummary{}
+	profile := &student.DossierProfileDetails{}
+	family := &student.DossierFamilyDetails{}
+	scholarship := &student.DossierScholarshipDetails{}
+	var familyIncome int
+
+	err := row.Scan(
+		&dossier.StudentID,
+		&dossier.EnrollmentNo,
+		&dossier.Status,
+		&contact.Email,
+		&contact.Phone,
+		&contact.LastLoginAt,
+		&academic.Program,
+		&academic.Branch,
+		&academic.YearOfEnrollment,
+		&academic.YearOfGraduation,
+		&academic.PresentSemester,
+		&academic.Section,
+		&academic.CGPA,
+		&academic.PreviousSemSGPA,
+		&academic.SchoolForClassTen,
+		&academic.ClassTenPercentage,
+		&academic.SchoolForClassTwelve,
+		&academic.ClassTwelvePercentage,
+		&profile.Name,
+		&profile.Gender,
+		&profile.Category,
+		&profile.DateOfBirth,
+		&profile.PresentAddress,
+		&profile.PermanentAddress,
+		&profile.PhotographURL,
+		&profile.ResumeURL,
+		&family.FatherName,
+		&family.FatherQualification,
+		&family.FatherProfession,
+		&family.MotherName,
+		&family.MotherQualification,
+		&family.MotherProfession,
+		&family.NoOfSiblings,
+		&familyIncome,
+		&scholarship.ScholarshipName,
+		&scholarship.ProvidedBy,
+		&scholarship.AmountReceived,
+	)
+	if err != nil {
+		return nil, err
+	}
+	family.TotalFamilyIncome = &familyIncome
+
+	// The branch and batch are always returned, the access scope of the viewer is checked against them
+	dossier.Branch = academic.Branch
+	dossier.Batch = formatBatch(academic.YearOfEnrollment, academic.YearOfGraduation)
+
+	if options.Includes(student.DossierContact) {
+		dossier.Contact = contact
+	}
+	if options.Includes(student.DossierAcademic) {
+		dossier.Academic = academic
+	}
+	if options.Includes(student.DossierProfile) {
+		dossier.Profile = profile
+	}
+	if options.Includes(student.DossierFamily) {
+		dossier.Family = family
+	}
+	if options.Includes(student.DossierScholarship) {
+		dossier.Scholarship = scholarship
+	}
+
+	return dossier, nil
+}
+
+// collectDossierRows reads the next result of a dossier batch, scanning every row with scan
+func collectDossierRows[T any](results pgx.BatchResults, scan func(row pgx.Row, item *T) error) ([]T, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		var item T
+		if err := scan(rows, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// parseBatch splits a batch such as "2023-2027" into its admission and graduation years.
+// The graduation year is optional.
+func parseBatch(batch string) (int, *int, error) {
+	admission, graduation, hasGraduation := strings.Cut(strings.TrimSpace(batch), "-")
+
+	yearOfEnrollment, err := strconv.Atoi(admission)
+	if err != nil || len(admission) != 4 {
+		return 0, nil, fmt.Errorf("%w: %q", student.ErrInvalidBatch, batch)
+	}
+	if !hasGraduation {
+		return yearOfEnrollment, nil, nil
+	}
+
+	yearOfGraduation, err := strconv.Atoi(graduation)
+	if err != nil || len(graduation) != 4 || yearOfGraduation < yearOfEnrollment {
+		return 0, nil, fmt.Errorf("%w: %q", student.ErrInvalidBatch, batch)
+	}
+
+	return yearOfEnrollment, &yearOfGraduation, nil
+}
+
+// formatBatch joins the admission and graduation years into a batch such as "2023-2027"
+func formatBatch(yearOfEnrollment int, yearOfGraduation *int) string {
+	batch := strconv.Itoa(yearOfEnrollment)
+	if yearOfGraduation != nil {
+		batch += "-" + strconv.Itoa(*yearOfGraduation)
+	}
+	return batch
+}
+
+// nullableUUID stores unset IDs as NULL
+func nullableUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+// nullableDate stores unset dates as NULL
+func nullableDate(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,174 @@
+package email
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/internal/config"
+	"server/pkg/logger"
+)
+
+// SMTPProvider sends account emails through an SMTP server.
+// It implements platform_profile.Mailer, roster.CredentialsMailer, student.ShortageAlertMailer and
+// student.GuardianMailer.
+type SMTPProvider struct {
+	cfg    config.EmailConfig
+	logger *logger.Logger
+}
+
+// NewSMTPProvider creates a new SMTP mail provider
+func NewSMTPProvider(cfg config.EmailConfig, log *logger.Logger) *SMTPProvider {
+	return &SMTPProvider{
+		cfg:    cfg,
+		logger: log,
+	}
+}
+
+// SendVerificationEmail sends the email verification link to a newly registered address
+func (p *SMTPProvider) SendVerificationEmail(to, token string) error {
+	return p.sendLink(to, verificationTemplate, p.cfg.VerificationURL, token)
+}
+
+// SendPasswordResetEmail sends the password reset link
+func (p *SMTPProvider) SendPasswordResetEmail(to, token string) error {
+	return p.sendLink(to, passwordResetTemplate, p.cfg.PasswordResetURL, token)
+}
+
+// SendInvitationEmail sends the username of an account created on someone's behalf along with
+// the link to set its first password
+func (p *SMTPProvider) SendInvitationEmail(to, username, token string) error {
+	link, err := withToken(p.cfg.InvitationURL, token)
+	if err != nil {
+		return err
+	}
+
+	msg, err := render(invitationTemplate, templateData{Link: link, Username: username})
+	if err != nil {
+		return err
+	}
+
+	return p.send(to, msg)
+}
+
+// SendCredentialsEmail sends the username and temporary password of an account created on someone's behalf
+func (p *SMTPProvider) SendCredentialsEmail(to, username, password string) error {
+	msg, err := render(credentialsTemplate, templateData{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+
+	return p.send(to, msg)
+}
+
+// SendAttendanceShortageEmail tells a student that their attendance rate in a course of the semester
+// fell below the threshold
+func (p *SMTPProvider) SendAttendanceShortageEmail(to, name string, semester int, rate, threshold float64) error {
+	msg, err := render(attendanceShortageTemplate, templateData{Name: name, Semester: semester, Rate: rate, Threshold: threshold})
+	if err != nil {
+		return err
+	}
+
+	return p.send(to, msg)
+}
+
+// SendGuardianNotificationEmail sends a coordinator's message to an emergency contact of a student
+func (p *SMTPProvider) SendGuardianNotificationEmail(to, guardianName, studentName, subject, message string) error {
+	msg, err := render(guardianNotificationTemplate, templateData{Name: guardianName, StudentName: studentName, Message: message})
+	if err != nil {
+		return err
+	}
+	if subject != "" {
+		msg.Subject = subject
+	}
+
+	return p.send(to, msg)
+}
+
+// sendLink renders a template with the token appended to the base URL and sends it
+func (p *SMTPProvider) sendLink(to string, tmpl emailTemplate, baseURL, token string) error {
+	link, err := withToken(baseURL, token)
+	if err != nil {
+		return err
+	}
+
+	msg, err := render(tmpl, templateData{Link: link})
+	if err != nil {
+		return err
+	}
+
+	return p.send(to, msg)
+}
+
+// send delivers a message, retrying transient failures as configured
+func (p *SMTPProvider) send(to string, msg *Message) error {
+	// Delivery is switched off in development, the message is only logged
+	if !p.cfg.Enabled {
+		p.logger.Info("Email delivery disabled, message not sent", "to", to, "subject", msg.Subject)
+		return nil
+	}
+
+	addr := net.JoinHostPort(p.cfg.SMTPHost, strconv.Itoa(p.cfg.SMTPPort))
+
+	var auth smtp.Auth
+	if p.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", p.cfg.SMTPUsername, p.cfg.SMTPPassword, p.cfg.SMTPHost)
+	}
+
+	data := p.compose(to, msg)
+
+	var err error
+	for attempt := 0; attempt <= p.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(p.cfg.RetryInterval)
+		}
+
+		if err = smtp.SendMail(addr, auth, p.cfg.SenderEmail, []string{to}, data); err == nil {
+			p.logger.Info("Email sent", "to", to, "subject", msg.Subject)
+			return nil
+		}
+
+		p.logger.Warn("Failed to send email", "to", to, "subject", msg.Subject, "attempt", attempt+1, "error", err)
+	}
+
+	return fmt.Errorf("failed to send email: %w", err)
+}
+
+// compose builds the RFC 5322 message with headers
+func (p *SMTPProvider) compose(to string, msg *Message) []byte {
+	from := p.cfg.SenderEmail
+	if p.cfg.SenderName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", p.cfg.SenderName), p.cfg.SenderEmail)
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// withToken appends the token as the "token" query parameter of the base URL
+func withToken(baseURL, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid email link URL %q: %w", baseURL, err)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
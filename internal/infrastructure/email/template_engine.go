@@ -0,0 +1,122 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Message is a rendered email ready to be sent
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// templateData is passed to every email template
+type templateData struct {
+	Link     string
+	Username string
+	Password string
+
+	// Attendance shortage alerts
+	Name      string
+	Semester  int
+	Rate      float64
+	Threshold float64
+
+	// Guardian notifications
+	StudentName string
+	Message     string
+}
+
+// emailTemplate pairs the subject of an email with its plain text body template
+type emailTemplate struct {
+	subject string
+	body    *template.Template
+}
+
+var verificationTemplate = emailTemplate{
+	subject: "Verify your email address",
+	body: template.Must(template.New("verification").Parse(`Hello,
+
+Please confirm your email address by opening the link below:
+
+{{.Link}}
+
+If you did not create an account, you can ignore this email.
+`)),
+}
+
+var passwordResetTemplate = emailTemplate{
+	subject: "Reset your password",
+	body: template.Must(template.New("password_reset").Parse(`Hello,
+
+A password reset was requested for your account. Open the link below to choose a new password:
+
+{{.Link}}
+
+If you did not request a password reset, you can ignore this email.
+`)),
+}
+
+var invitationTemplate = emailTemplate{
+	subject: "Your account has been created",
+	body: template.Must(template.New("invitation").Parse(`Hello,
+
+An account has been created for you. Your username is:
+
+{{.Username}}
+
+Open the link below to choose your password and sign in:
+
+{{.Link}}
+
+The link can be used once. If it has expired, ask your coordinator for a new invitation.
+`)),
+}
+
+var credentialsTemplate = emailTemplate{
+	subject: "Your account has been created",
+	body: template.Must(template.New("credentials").Parse(`Hello,
+
+An account has been created for you. Sign in with these details:
+
+Username: {{.Username}}
+Temporary password: {{.Password}}
+
+You will be asked to choose a new password when you first sign in.
+`)),
+}
+
+var attendanceShortageTemplate = emailTemplate{
+	subject: "Attendance shortage",
+	body: template.Must(template.New("attendance_shortage").Parse(`Hello {{.Name}},
+
+Your attendance in a course of semester {{.Semester}} has dropped to {{printf "%.1f" .Rate}}%, below the required {{printf "%.1f" .Threshold}}%.
+
+Please attend the upcoming classes regularly, or contact your coordinator if you were absent for a valid reason.
+`)),
+}
+
+// The subject of guardian notifications is set by the coordinator sending them
+var guardianNotificationTemplate = emailTemplate{
+	subject: "Message from the training and placement cell",
+	body: template.Must(template.New("guardian_notification").Parse(`Dear {{.Name}},
+
+You are receiving this message as an emergency contact of {{.StudentName}}.
+
+{{.Message}}
+
+Please contact the training and placement cell if you have any questions.
+`)),
+}
+
+// render executes a template with the given data
+func render(tmpl emailTemplate, data templateData) (*Message, error) {
+	var body bytes.Buffer
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", tmpl.body.Name(), err)
+	}
+
+	return &Message{Subject: tmpl.subject, Body: body.String()}, nil
+}
@@ -0,0 +1,78 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"server/internal/config"
+	"server/internal/domain/platform_profile"
+	"server/internal/infrastructure/auth"
+)
+
+// Issuer is the OpenID Connect issuer of Google accounts
+const Issuer = "https://accounts.google.com"
+
+// AuthProvider logs users in with their Google account.
+// It implements platform_profile.IdentityProvider.
+type AuthProvider struct {
+	client       *auth.OIDCClient
+	hostedDomain string
+}
+
+// NewAuthProvider creates the Google login provider. A nil httpClient selects the default one.
+func NewAuthProvider(cfg config.GoogleOAuthConfig, httpClient *http.Client) (*AuthProvider, error) {
+	issuers := []string{cfg.IssuerURL}
+	if cfg.IssuerURL == Issuer {
+		// Google signs some ID tokens with the issuer without scheme
+		issuers = append(issuers, strings.TrimPrefix(Issuer, "https://"))
+	}
+
+	authParams := map[string]string{"prompt": "select_account"}
+	if cfg.HostedDomain != "" {
+		// Only preselects the domain on the account chooser, Exchange enforces it
+		authParams["hd"] = cfg.HostedDomain
+	}
+
+	client, err := auth.NewOIDCClient(auth.OIDCConfig{
+		IssuerURL:       cfg.IssuerURL,
+		AcceptedIssuers: issuers,
+		ClientID:        cfg.ClientID,
+		ClientSecret:    cfg.ClientSecret,
+		RedirectURL:     cfg.RedirectURL,
+		Scopes:          []string{"openid", "email", "profile"},
+		AuthParams:      authParams,
+		JWKSCacheTTL:    cfg.JWKSCacheTTL,
+	}, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Google OIDC client: %w", err)
+	}
+
+	return &AuthProvider{client: client, hostedDomain: cfg.HostedDomain}, nil
+}
+
+// AuthCodeURL returns the Google login URL
+func (p *AuthProvider) AuthCodeURL(ctx context.Context, state, codeVerifier, nonce string) (string, error) {
+	return p.client.AuthCodeURL(ctx, state, codeVerifier, nonce)
+}
+
+// Exchange redeems an authorization code and returns the Google account it was issued for
+func (p *AuthProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*platform_profile.ExternalIdentity, error) {
+	claims, err := p.client.Exchange(ctx, code, codeVerifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.hostedDomain != "" && !strings.EqualFold(claims.HostedDomain, p.hostedDomain) {
+		return nil, fmt.Errorf("%w: account does not belong to %s", auth.ErrInvalidIDToken, p.hostedDomain)
+	}
+
+	return &platform_profile.ExternalIdentity{
+		Provider:      platform_profile.IdentityProviderGoogle,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
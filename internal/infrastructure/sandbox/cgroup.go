@@ -0,0 +1,76 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// cgroupRemoveAttempts bounds how long removing a cgroup waits for its killed processes to exit
+const cgroupRemoveAttempts = 50
+
+// cgroup is the cgroup v2 a single command of a submission runs in. It caps the memory of all the
+// processes of the command together, whatever their language, and kills them all at once.
+type cgroup struct {
+	dir  string
+	file *os.File
+}
+
+// newCgroup creates a cgroup under parent with the memory and process limits
+func newCgroup(parent string, memoryBytes int64, maxProcesses int) (*cgroup, error) {
+	dir, err := os.MkdirTemp(parent, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	cg := &cgroup{dir: dir}
+	limits := []struct {
+		file  string
+		value string
+	}{
+		{"memory.max", strconv.FormatInt(memoryBytes, 10)},
+		{"memory.swap.max", "0"},
+		{"pids.max", strconv.Itoa(maxProcesses)},
+	}
+	for _, limit := range limits {
+		err := os.WriteFile(filepath.Join(dir, limit.file), []byte(limit.value), 0)
+		// Without swap accounting there is no swap to cap
+		if err != nil && !(limit.file == "memory.swap.max" && errors.Is(err, os.ErrNotExist)) {
+			_ = cg.remove()
+			return nil, fmt.Errorf("failed to set %s of cgroup: %w", limit.file, err)
+		}
+	}
+
+	if cg.file, err = os.Open(dir); err != nil {
+		_ = cg.remove()
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	return cg, nil
+}
+
+// remove kills whatever still runs in the cgroup, then removes it
+func (cg *cgroup) remove() error {
+	if cg.file != nil {
+		cg.file.Close()
+	}
+
+	if err := os.WriteFile(filepath.Join(cg.dir, "cgroup.kill"), []byte("1"), 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to kill cgroup: %w", err)
+	}
+
+	var err error
+	for i := 0; i < cgroupRemoveAttempts; i++ {
+		if err = os.Remove(cg.dir); err == nil || !errors.Is(err, syscall.EBUSY) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove cgroup: %w", err)
+	}
+	return nil
+}
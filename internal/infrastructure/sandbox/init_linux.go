@@ -0,0 +1,165 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+)
+
+// initName is the name the server binary is re-executed under to become the init process of a
+// sandbox
+const initName = "quiz-sandbox-init"
+
+// initErrorFD is the descriptor the init process reports setup failures on. It is closed when the
+// command starts, so the runner tells the two apart by whether anything was written to it.
+const initErrorFD = 3
+
+// Constants missing from the syscall package
+const (
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+	rlimitNProc          = 6
+
+	stNoSUID     = 0x2
+	stNoDev      = 0x4
+	stNoExec     = 0x8
+	stNoATime    = 0x400
+	stNoDirATime = 0x800
+	stRelATime   = 0x1000
+)
+
+// init turns the process into the init process of a sandbox when the runner re-executed the server
+// binary as one. Nothing else in the binary gets to run.
+func init() {
+	if len(os.Args) < 5 || os.Args[0] != initName {
+		return
+	}
+
+	// Dropping privileges has to happen on the thread that runs the command
+	runtime.LockOSThread()
+
+	errs := os.NewFile(initErrorFD, "sandbox-init")
+	syscall.CloseOnExec(initErrorFD)
+
+	err := initSandbox(os.Args[1], os.Args[2], os.Args[3])
+	if err == nil {
+		err = syscall.Exec(os.Args[4], os.Args[4:], os.Environ())
+	}
+
+	fmt.Fprint(errs, err.Error())
+	os.Exit(1)
+}
+
+// initSandbox runs in the new namespaces of a sandbox. It bind mounts the scratch directory at
+// /sandbox of the root, makes everything else read-only, pivots into the root and detaches the
+// host's filesystem. Then it becomes the sandbox user for good, limited to maxProcesses processes.
+func initSandbox(root, dir, maxProcesses string) error {
+	processes, err := strconv.ParseUint(maxProcesses, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid process limit: %w", err)
+	}
+
+	// Mounts made from here on stay within the mount namespace of the sandbox
+	if err := syscall.Mount("", "/", "", syscall.MS_PRIVATE|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	if err := syscall.Mount(root, root, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to mount root: %w", err)
+	}
+
+	work := filepath.Join(root, "sandbox")
+	if err := syscall.Mount(dir, work, "", syscall.MS_BIND, ""); err != nil {
+		return fmt.Errorf("failed to mount scratch directory: %w", err)
+	}
+	if err := remount(work, syscall.MS_NOSUID|syscall.MS_NODEV); err != nil {
+		return fmt.Errorf("failed to remount scratch directory: %w", err)
+	}
+
+	// A proc of its own only shows the processes of the sandbox
+	proc := filepath.Join(root, "proc")
+	if err := syscall.Mount("proc", proc, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("failed to mount proc: %w", err)
+	}
+
+	if err := remount(root, syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV); err != nil {
+		return fmt.Errorf("failed to remount root read-only: %w", err)
+	}
+
+	// Stacking the old root under the new one, then detaching it, leaves nothing of the host
+	if err := syscall.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter root: %w", err)
+	}
+	if err := syscall.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("failed to pivot root: %w", err)
+	}
+	if err := syscall.Unmount(".", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to detach host filesystem: %w", err)
+	}
+	// The process limit of the user is checked on every fork, threads included
+	if err := syscall.Setrlimit(rlimitNProc, &syscall.Rlimit{Cur: processes, Max: processes}); err != nil {
+		return fmt.Errorf("failed to limit processes: %w", err)
+	}
+
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("failed to drop supplementary groups: %w", err)
+	}
+	if err := syscall.Setgid(nobody); err != nil {
+		return fmt.Errorf("failed to change group: %w", err)
+	}
+	if err := syscall.Setuid(nobody); err != nil {
+		return fmt.Errorf("failed to change user: %w", err)
+	}
+
+	// The scratch directory belongs to the sandbox user
+	if err := syscall.Chdir("/sandbox"); err != nil {
+		return fmt.Errorf("failed to enter scratch directory: %w", err)
+	}
+
+	// Without ambient capabilities, the capabilities of the namespace go with the exec, and nothing
+	// the command runs may gain any. Changing users cleared the parent death signal, which is set
+	// again.
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0); errno != 0 {
+		return fmt.Errorf("failed to clear ambient capabilities: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_PDEATHSIG, uintptr(syscall.SIGKILL), 0); errno != 0 {
+		return fmt.Errorf("failed to set parent death signal: %w", errno)
+	}
+
+	return nil
+}
+
+// remount remounts a bind mount with the flags added. The flags the mount already has are kept,
+// the kernel refuses to clear them within a user namespace.
+func remount(path string, flags uintptr) error {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return err
+	}
+
+	for _, flag := range []struct {
+		st    int64
+		mount uintptr
+	}{
+		{stNoSUID, syscall.MS_NOSUID},
+		{stNoDev, syscall.MS_NODEV},
+		{stNoExec, syscall.MS_NOEXEC},
+		{stNoATime, syscall.MS_NOATIME},
+		{stNoDirATime, syscall.MS_NODIRATIME},
+		{stRelATime, syscall.MS_RELATIME},
+	} {
+		if int64(fs.Flags)&flag.st != 0 {
+			flags |= flag.mount
+		}
+	}
+
+	return syscall.Mount("", path, "", syscall.MS_BIND|syscall.MS_REMOUNT|flags, "")
+}
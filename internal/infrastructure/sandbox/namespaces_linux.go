@@ -0,0 +1,112 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"server/internal/config"
+)
+
+// sandboxSupported reports whether programs can be isolated on this platform
+const sandboxSupported = true
+
+// nobody is the user and group the programs run as within their user namespace. They are mapped
+// to the host user and group reserved for the sandbox, the server's own are not mapped at all.
+const nobody = 65534
+
+// Capabilities the init process keeps across its exec, within the user namespace only
+const (
+	capSetGID   = 6
+	capSetUID   = 7
+	capSysAdmin = 21
+)
+
+// sandboxCommand re-executes the server binary as the init process of the sandbox, which enters
+// the root of the sandbox before it runs the command, see initSandbox
+func sandboxCommand(ctx context.Context, command []string, cfg config.SandboxConfig, dir string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "/proc/self/exe", append([]string{cfg.RootFS, dir, strconv.Itoa(cfg.MaxProcesses)}, command...)...)
+	cmd.Args[0] = initName
+	return cmd
+}
+
+// sandboxAttributes starts the process in its own process group and cgroup, and in new user,
+// mount, PID, network, IPC and UTS namespaces. The new network namespace only has a loopback
+// interface, which is down.
+func sandboxAttributes(cfg config.SandboxConfig, cgroup *cgroup) *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: nobody, HostID: cfg.UID, Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: nobody, HostID: cfg.GID, Size: 1},
+		},
+		// Lets the init process drop the supplementary groups inherited from the server
+		GidMappingsEnableSetgroups: true,
+		// The init process isn't root within the namespace, so it would lose the capabilities it
+		// needs to set up the mounts and change users to the exec otherwise
+		AmbientCaps: []uintptr{capSetGID, capSetUID, capSysAdmin},
+		UseCgroupFD: true,
+		CgroupFD:    int(cgroup.file.Fd()),
+	}
+}
+
+// killProcessGroup kills the process and everything it started
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// handOver gives a scratch directory and what's in it to the sandbox user
+func handOver(cfg config.SandboxConfig, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Lchown(filepath.Join(dir, entry.Name()), cfg.UID, cfg.GID); err != nil {
+			return err
+		}
+	}
+	return os.Chown(dir, cfg.UID, cfg.GID)
+}
+
+// reclaim gives a scratch directory back to the server so that it can be removed, whatever
+// permissions the program left on the directories it created. Only directories need to change
+// hands, removing a file takes no more than write access to its directory.
+func reclaim(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return err
+	}
+	if err := os.Lchown(dir, os.Getuid(), os.Getgid()); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := reclaim(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
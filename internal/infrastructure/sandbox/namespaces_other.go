@@ -0,0 +1,43 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+
+	"server/internal/config"
+)
+
+// sandboxSupported reports whether programs can be isolated on this platform. Without Linux
+// namespaces there is no way to keep programs off the network, so they are not run at all.
+const sandboxSupported = false
+
+// sandboxCommand is never used, programs are not run on this platform
+func sandboxCommand(ctx context.Context, command []string, cfg config.SandboxConfig, dir string) *exec.Cmd {
+	return exec.CommandContext(ctx, command[0], command[1:]...)
+}
+
+// sandboxAttributes is never used, programs are not run on this platform
+func sandboxAttributes(cfg config.SandboxConfig, cgroup *cgroup) *syscall.SysProcAttr {
+	return nil
+}
+
+// killProcessGroup kills the process
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// handOver is never used, programs are not run on this platform
+func handOver(cfg config.SandboxConfig, dir string) error {
+	return nil
+}
+
+// reclaim is never used, programs are not run on this platform
+func reclaim(dir string) error {
+	return nil
+}
@@ -0,0 +1,291 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"server/internal/config"
+	"server/internal/domain/quiz/question"
+	"server/pkg/logger"
+)
+
+// language describes how programs of a language are written to disk, compiled and run. The
+// commands are run in the scratch directory of the submission.
+type language struct {
+	file    string
+	compile []string
+	run     func(limits question.Limits) []string
+
+	// Every command runs under the memory limit of its cgroup. Runtimes that reserve far more
+	// address space than they use are only told the limit through their own heap settings, the
+	// others also get an address space limit, so that allocations fail instead of being killed.
+	capAddressSpace bool
+}
+
+// languages supported by the runner, matching question.Languages
+var languages = map[string]language{
+	"c": {
+		file:            "main.c",
+		compile:         []string{"gcc", "-O2", "-std=c17", "-o", "main", "main.c", "-lm"},
+		run:             func(question.Limits) []string { return []string{"./main"} },
+		capAddressSpace: true,
+	},
+	"cpp": {
+		file:            "main.cpp",
+		compile:         []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+		run:             func(question.Limits) []string { return []string{"./main"} },
+		capAddressSpace: true,
+	},
+	"java": {
+		file:    "Main.java",
+		compile: []string{"javac", "-encoding", "UTF-8", "Main.java"},
+		run: func(limits question.Limits) []string {
+			return []string{"java", "-Xmx" + megabytes(limits.MemoryBytes) + "m", "-XX:+UseSerialGC", "-cp", ".", "Main"}
+		},
+	},
+	"javascript": {
+		file: "main.js",
+		run: func(limits question.Limits) []string {
+			return []string{"node", "--max-old-space-size=" + megabytes(limits.MemoryBytes), "main.js"}
+		},
+	},
+	"python": {
+		file:            "main.py",
+		run:             func(question.Limits) []string { return []string{"python3", "-I", "-B", "main.py"} },
+		capAddressSpace: true,
+	},
+}
+
+// maxOutputFileBlocks caps the files a program may write, in 1 KB blocks
+const maxOutputFileBlocks = 1024
+
+// SubprocessRunner runs programs as subprocesses of the server, each submission in its own scratch
+// directory. Every run gets a time limit, a cgroup capping its memory and processes, and new user,
+// mount, PID and network namespaces: it runs as the sandbox user, sees only the read-only root of
+// the sandbox and its scratch directory, can't see or signal the server, and has no network
+// access. It implements question.Runner.
+type SubprocessRunner struct {
+	cfg    config.SandboxConfig
+	slots  chan struct{}
+	logger *logger.Logger
+}
+
+// NewSubprocessRunner creates a new subprocess runner
+func NewSubprocessRunner(cfg config.SandboxConfig, log *logger.Logger) *SubprocessRunner {
+	return &SubprocessRunner{
+		cfg:    cfg,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		logger: log,
+	}
+}
+
+// Run compiles the program when its language needs it, then runs it once per input. A program
+// that doesn't compile gets a compile error for every input.
+func (r *SubprocessRunner) Run(ctx context.Context, program question.Program, inputs []string, limits question.Limits) ([]question.Execution, error) {
+	lang, ok := languages[program.Language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q", program.Language)
+	}
+	if !sandboxSupported {
+		return nil, errors.New("sandboxed execution requires Linux namespaces")
+	}
+	if !r.cfg.Configured() {
+		return nil, errors.New("sandbox user, root and cgroup are not configured")
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := os.MkdirAll(r.cfg.WorkDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(r.cfg.WorkDir, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer r.removeScratch(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, lang.file), []byte(program.Source), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write program: %w", err)
+	}
+	if err := handOver(r.cfg, dir); err != nil {
+		return nil, fmt.Errorf("failed to hand scratch directory to the sandbox user: %w", err)
+	}
+
+	executions := make([]question.Execution, len(inputs))
+
+	if len(lang.compile) > 0 {
+		// The compiler is trusted but the source is not, so compilation is isolated and bounded in
+		// time as well
+		compileLimits := question.Limits{Timeout: r.cfg.CompileTimeout, MemoryBytes: r.cfg.CompileMemoryBytes}
+		compilation, err := r.exec(ctx, dir, lang.compile, "", compileLimits, false)
+		if err != nil {
+			return nil, err
+		}
+		if compilation.Status != question.RunCompleted {
+			for i := range executions {
+				executions[i] = question.Execution{Status: question.RunCompileError, Stderr: compilation.Stderr}
+			}
+			return executions, nil
+		}
+	}
+
+	for i, input := range inputs {
+		execution, err := r.exec(ctx, dir, lang.run(limits), input, limits, lang.capAddressSpace)
+		if err != nil {
+			return nil, err
+		}
+		executions[i] = *execution
+	}
+
+	return executions, nil
+}
+
+// exec runs a command of a submission under the limits. Only failures of the runner itself, or
+// the cancellation of ctx, are returned as errors.
+func (r *SubprocessRunner) exec(ctx context.Context, dir string, command []string, stdin string, limits question.Limits, capAddressSpace bool) (*question.Execution, error) {
+	runCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	// Resource limits are applied by the shell right before it replaces itself with the command.
+	// The CPU time limit backs up the timeout for programs that detach from the process group.
+	script := "ulimit -f " + strconv.Itoa(maxOutputFileBlocks) +
+		" && ulimit -t " + strconv.Itoa(int(limits.Timeout/time.Second)+1)
+	if capAddressSpace && limits.MemoryBytes > 0 {
+		script += " && ulimit -v " + strconv.FormatInt(limits.MemoryBytes/1024, 10)
+	}
+	script += ` && exec "$@"`
+
+	// Questions without a memory limit of their own get the one of compilation
+	memoryBytes := limits.MemoryBytes
+	if memoryBytes <= 0 {
+		memoryBytes = r.cfg.CompileMemoryBytes
+	}
+	cg, err := newCgroup(r.cfg.CgroupDir, memoryBytes, r.cfg.MaxProcesses)
+	if err != nil {
+		r.logger.Error("Failed to create sandbox cgroup", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if err := cg.remove(); err != nil {
+			r.logger.Warn("Failed to remove sandbox cgroup", "cgroup", cg.dir, "error", err)
+		}
+	}()
+
+	// Setup failures of the init process of the sandbox come back through a pipe of their own, so
+	// that they are not mistaken for the program failing
+	initErrors, initErrorsWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox pipe: %w", err)
+	}
+	defer initErrors.Close()
+
+	cmd := sandboxCommand(runCtx, append([]string{"/bin/sh", "-c", script, "sandbox"}, command...), r.cfg, dir)
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/sandbox", "TMPDIR=/sandbox", "LANG=C.UTF-8"}
+	cmd.Stdin = bytes.NewBufferString(stdin)
+	stdout := &limitedBuffer{limit: r.cfg.OutputLimitBytes}
+	stderr := &limitedBuffer{limit: r.cfg.OutputLimitBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{initErrorsWriter}
+	cmd.SysProcAttr = sandboxAttributes(r.cfg, cg)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Start()
+	initErrorsWriter.Close()
+	if err != nil {
+		r.logger.Error("Failed to start sandboxed process", "command", command[0], "error", err)
+		return nil, fmt.Errorf("failed to start sandboxed process: %w", err)
+	}
+
+	// The pipe closes once the command starts, or once the init process gave up
+	initFailure, _ := io.ReadAll(initErrors)
+	err = cmd.Wait()
+	runtime := time.Since(start)
+
+	// Stray processes left behind by the program go with its process group, and with its cgroup
+	// when it's removed
+	_ = killProcessGroup(cmd)
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if len(initFailure) > 0 {
+		r.logger.Error("Failed to set up sandbox", "command", command[0], "error", string(initFailure))
+		return nil, fmt.Errorf("failed to set up sandbox: %s", initFailure)
+	}
+
+	execution := &question.Execution{
+		Status:  question.RunCompleted,
+		Stdout:  stdout.String(),
+		Stderr:  stderr.String(),
+		Runtime: runtime,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		execution.Status = question.RunTimedOut
+		execution.Runtime = limits.Timeout
+	case errors.As(err, &exitErr):
+		execution.Status = question.RunRuntimeError
+	case err != nil:
+		return nil, fmt.Errorf("failed to run sandboxed process: %w", err)
+	}
+
+	return execution, nil
+}
+
+// removeScratch removes a scratch directory along with what the program left in it
+func (r *SubprocessRunner) removeScratch(dir string) {
+	err := reclaim(dir)
+	if err == nil {
+		err = os.RemoveAll(dir)
+	}
+	if err != nil {
+		r.logger.Warn("Failed to remove scratch directory", "dir", dir, "error", err)
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// Write keeps what fits and reports everything as written, so that the program is not interrupted
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// String returns what was kept
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// megabytes formats a number of bytes in whole megabytes
+func megabytes(bytes int64) string {
+	return strconv.FormatInt(bytes/(1024*1024), 10)
+}
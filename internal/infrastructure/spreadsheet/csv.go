@@ -0,0 +1,43 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// utf8BOM is written at the start of CSV files saved by Excel
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// readCSV reads the rows of a comma separated file. Rows may have differing numbers of cells.
+func readCSV(r io.Reader, maxRows int) ([]Row, error) {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if rows, err = appendRow(rows, line, record, maxRows); err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,59 @@
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format, upload a .csv or .xlsx file")
+	// ErrInvalidFile is returned when a file can't be parsed as the format its name claims
+	ErrInvalidFile = errors.New("invalid spreadsheet file")
+	// ErrTooManyRows is returned when a file holds more rows than the caller accepts
+	ErrTooManyRows = errors.New("spreadsheet has too many rows")
+)
+
+// Row is a non-blank row of a spreadsheet
+type Row struct {
+	// Number is the row number shown by spreadsheet programs, starting at 1
+	Number int
+	Cells  []string
+}
+
+// Read parses a CSV or XLSX file, chosen by the extension of fileName, into its non-blank rows.
+// Only the first sheet of a workbook is read. Cell values are trimmed. At most maxRows rows are
+// accepted, including the header.
+func Read(fileName string, r io.ReaderAt, size int64, maxRows int) ([]Row, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return readCSV(io.NewSectionReader(r, 0, size), maxRows)
+	case ".xlsx":
+		return readXLSX(r, size, maxRows)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// appendRow trims the cells of a row and appends it unless it is blank
+func appendRow(rows []Row, number int, cells []string, maxRows int) ([]Row, error) {
+	blank := true
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+		if cells[i] != "" {
+			blank = false
+		}
+	}
+	if blank {
+		return rows, nil
+	}
+
+	if len(rows) >= maxRows {
+		return nil, fmt.Errorf("%w: at most %d rows are accepted", ErrTooManyRows, maxRows)
+	}
+
+	return append(rows, Row{Number: number, Cells: cells}), nil
+}
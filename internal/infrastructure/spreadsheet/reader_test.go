@@ -0,0 +1,235 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// workbook builds an XLSX file whose first sheet holds the given sheetData rows
+func workbook(t *testing.T, sharedStrings []string, sheetData string) []byte {
+	t.Helper()
+
+	var shared strings.Builder
+	for _, s := range sharedStrings {
+		shared.WriteString("<si><t>" + s + "</t></si>")
+	}
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Students" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       "<sst>" + shared.String() + "</sst>",
+		"xl/worksheets/sheet1.xml":   "<worksheet><sheetData>" + sheetData + "</sheetData></worksheet>",
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close workbook: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func read(fileName string, content []byte, maxRows int) ([]Row, error) {
+	return Read(fileName, bytes.NewReader(content), int64(len(content)), maxRows)
+}
+
+func TestReadCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		maxRows int
+		want    []Row
+		wantErr error
+	}{
+		{
+			name:    "trims cells and skips blank rows",
+			content: "enrollment, name\n\n , \n0101CS211001,  Asha \n",
+			maxRows: 10,
+			want: []Row{
+				{Number: 1, Cells: []string{"enrollment", "name"}},
+				{Number: 4, Cells: []string{"0101CS211001", "Asha"}},
+			},
+		},
+		{
+			name:    "strips the byte order mark of Excel",
+			content: "\xEF\xBB\xBFenrollment\n0101CS211001\n",
+			maxRows: 10,
+			want: []Row{
+				{Number: 1, Cells: []string{"enrollment"}},
+				{Number: 2, Cells: []string{"0101CS211001"}},
+			},
+		},
+		{
+			name:    "accepts rows of differing lengths",
+			content: "a,b,c\nd\n",
+			maxRows: 10,
+			want: []Row{
+				{Number: 1, Cells: []string{"a", "b", "c"}},
+				{Number: 2, Cells: []string{"d"}},
+			},
+		},
+		{
+			name:    "counts the header against the row limit",
+			content: "enrollment\n1\n2\n",
+			maxRows: 2,
+			wantErr: ErrTooManyRows,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := read("students.CSV", []byte(tc.content), tc.maxRows)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, rows)
+			}
+		})
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		sheetData string
+		maxRows   int
+		want      []Row
+		wantErr   error
+	}{
+		{
+			name: "resolves shared, inline, boolean and numeric cells",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+				`<row r="2"><c r="A2" t="inlineStr"><is><t> Asha </t></is></c><c r="B2" t="b"><v>1</v></c>` +
+				`<c r="C2"><v>1.23456789012E+11</v></c></row>`,
+			maxRows: 10,
+			want: []Row{
+				{Number: 1, Cells: []string{"name", "active"}},
+				{Number: 2, Cells: []string{"Asha", "TRUE", "123456789012"}},
+			},
+		},
+		{
+			name:      "places cells at their column when empty cells are omitted",
+			sheetData: `<row r="3"><c r="C3" t="s"><v>0</v></c></row>`,
+			maxRows:   10,
+			want:      []Row{{Number: 3, Cells: []string{"", "", "name"}}},
+		},
+		{
+			name:      "skips rows without values",
+			sheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t> </t></is></c></row>`,
+			maxRows:   10,
+			want:      nil,
+		},
+		{
+			name:      "rejects out of range shared strings",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>7</v></c></row>`,
+			maxRows:   10,
+			wantErr:   ErrInvalidFile,
+		},
+		{
+			name:      "rejects columns past the last one of a sheet",
+			sheetData: `<row r="1"><c r="XFE1"><v>1</v></c></row>`,
+			maxRows:   10,
+			wantErr:   ErrInvalidFile,
+		},
+		{
+			name:      "rejects references too long to be a column",
+			sheetData: `<row r="1"><c r="` + strings.Repeat("Z", 20) + `1"><v>1</v></c></row>`,
+			maxRows:   10,
+			wantErr:   ErrInvalidFile,
+		},
+		{
+			name:      "enforces the row limit",
+			sheetData: `<row r="1"><c r="A1"><v>1</v></c></row><row r="2"><c r="A2"><v>2</v></c></row>`,
+			maxRows:   1,
+			wantErr:   ErrTooManyRows,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			content := workbook(t, []string{"name", "active"}, tc.sheetData)
+
+			rows, err := read("students.xlsx", content, tc.maxRows)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, rows)
+			}
+		})
+	}
+}
+
+func TestReadRejectsUnknownFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fileName string
+		content  []byte
+		wantErr  error
+	}{
+		{name: "legacy excel", fileName: "students.xls", content: []byte("data"), wantErr: ErrUnsupportedFormat},
+		{name: "no extension", fileName: "students", content: []byte("data"), wantErr: ErrUnsupportedFormat},
+		{name: "not a zip archive", fileName: "students.xlsx", content: []byte("enrollment\n"), wantErr: ErrInvalidFile},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := read(tc.fileName, tc.content, 10); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for _, tc := range []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{ref: "A1", want: 0},
+		{ref: "Z9", want: 25},
+		{ref: "AA10", want: 26},
+		{ref: "XFD1048576", want: 16383},
+		{ref: "XFE1", wantErr: true},
+		{ref: "AAAA1", wantErr: true},
+		{ref: strings.Repeat("Z", 64) + "1", wantErr: true},
+		{ref: "12", wantErr: true},
+		{ref: "", wantErr: true},
+	} {
+		t.Run(tc.ref, func(t *testing.T) {
+			got, err := columnIndex(tc.ref)
+			if tc.wantErr {
+				if !errors.Is(err, ErrInvalidFile) {
+					t.Fatalf("expected %q to be rejected, got %d, %v", tc.ref, got, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("expected column %d, got %d, %v", tc.want, got, err)
+			}
+		})
+	}
+}
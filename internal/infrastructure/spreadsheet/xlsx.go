@@ -0,0 +1,250 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartSize caps the uncompressed size of a workbook part, guarding against zip bombs
+const maxXLSXPartSize = 64 << 20
+
+// maxXLSXColumns is the number of columns of a sheet, the last one being XFD
+const maxXLSXColumns = 16384
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a shared or inline string, either plain or made of rich text runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string    `xml:"r,attr"`
+			T      string    `xml:"t,attr"`
+			V      string    `xml:"v"`
+			Inline *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the rows of the first sheet of an Office Open XML workbook
+func readXLSX(r io.ReaderAt, size int64, maxRows int) ([]Row, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	sheetPart, err := firstSheetPart(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(f, &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := parts[sheetPart]
+	if !ok {
+		return nil, fmt.Errorf("%w: sheet %s is missing", ErrInvalidFile, sheetPart)
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for i, row := range sheet.Rows {
+		number := row.R
+		if number == 0 {
+			number = i + 1
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.R != "" {
+				if column, err = columnIndex(cell.R); err != nil {
+					return nil, err
+				}
+			}
+
+			value, err := cellValue(cell.T, cell.V, cell.Inline, sharedStrings.Items)
+			if err != nil {
+				return nil, err
+			}
+
+			// Empty cells are omitted from the sheet, so the slice grows to the cell's column
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			cells[column] = value
+		}
+
+		if rows, err = appendRow(rows, number, cells, maxRows); err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
+
+// firstSheetPart resolves the part name of the first sheet through the workbook relationships
+func firstSheetPart(parts map[string]*zip.File) (string, error) {
+	workbookFile, ok := parts["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: workbook is missing", ErrInvalidFile)
+	}
+	relsFile, ok := parts["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return "", fmt.Errorf("%w: workbook relationships are missing", ErrInvalidFile)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodePart(workbookFile, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrInvalidFile)
+	}
+
+	var rels xlsxRelationships
+	if err := decodePart(relsFile, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		// Targets are relative to the xl folder unless absolute
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", fmt.Errorf("%w: sheet %q has no part", ErrInvalidFile, workbook.Sheets[0].Name)
+}
+
+// decodePart unmarshals an XML part of the archive
+func decodePart(f *zip.File, v any) error {
+	if f.UncompressedSize64 > maxXLSXPartSize {
+		return fmt.Errorf("%w: %s is too large", ErrInvalidFile, f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer rc.Close()
+
+	// The declared size can't be trusted, so the read is capped as well
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	return nil
+}
+
+// cellValue returns the text of a cell according to its type
+func cellValue(cellType, value string, inline *xlsxText, sharedStrings []xlsxText) (string, error) {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= len(sharedStrings) {
+			return "", fmt.Errorf("%w: invalid shared string reference %q", ErrInvalidFile, value)
+		}
+		return sharedStrings[index].String(), nil
+	case "inlineStr":
+		if inline == nil {
+			return "", nil
+		}
+		return inline.String(), nil
+	case "b":
+		if value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "", "n":
+		// Large numbers are stored in exponent notation, spell them out like the spreadsheet shows them
+		if strings.ContainsAny(value, "eE") {
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				return strconv.FormatFloat(number, 'f', -1, 64), nil
+			}
+		}
+		return value, nil
+	default:
+		// Formula strings, errors and dates stored as text
+		return value, nil
+	}
+}
+
+// columnIndex returns the zero based column of a cell reference such as "AB12"
+func columnIndex(ref string) (int, error) {
+	column := 0
+	letters := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		column = column*26 + int(c-'A') + 1
+		letters++
+
+		// Checked on every letter, a long run of letters would overflow the column otherwise
+		if column > maxXLSXColumns {
+			return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidFile, ref)
+		}
+	}
+
+	if letters == 0 {
+		return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidFile, ref)
+	}
+	return column - 1, nil
+}
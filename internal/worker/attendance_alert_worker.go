@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"server/internal/config"
+	"server/internal/domain/student"
+	"server/internal/infrastructure/database/postgres/repositories"
+	"server/internal/infrastructure/email"
+	"server/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// attendanceAlertBatchSize is the number of shortage alerts sent per run
+const attendanceAlertBatchSize = 100
+
+// AttendanceAlertWorker periodically emails the attendance shortage alerts queued when attendance is
+// marked. Failed deliveries are retried on the following runs until the attempts are exhausted.
+type AttendanceAlertWorker struct {
+	repo        student.Repository
+	mailer      student.ShortageAlertMailer
+	interval    time.Duration
+	maxAttempts int
+	logger      *logger.Logger
+}
+
+// NewAttendanceAlertWorker creates a new AttendanceAlertWorker
+func NewAttendanceAlertWorker(db *pgxpool.Pool, cfg *config.Config, log *logger.Logger) *AttendanceAlertWorker {
+	return &AttendanceAlertWorker{
+		repo:        repositories.NewPostgresStudentRepository(db, log),
+		mailer:      email.NewSMTPProvider(cfg.Integration.Email, log),
+		interval:    cfg.Student.AttendanceAlertInterval,
+		maxAttempts: cfg.Student.AttendanceAlertMaxAttempts,
+		logger:      log,
+	}
+}
+
+// Name identifies the worker in logs
+func (w *AttendanceAlertWorker) Name() string {
+	return "attendance_shortage_alerts"
+}
+
+// Start sends the pending alerts immediately and then once per interval until the context is cancelled
+func (w *AttendanceAlertWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.send(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// send delivers one batch of pending alerts, recording the outcome of each
+func (w *AttendanceAlertWorker) send(ctx context.Context) {
+	alerts, err := w.repo.ListPendingShortageAlerts(ctx, w.maxAttempts, attendanceAlertBatchSize)
+	if err != nil {
+		w.logger.Error("Failed to list pending attendance shortage alerts", "error", err)
+		return
+	}
+
+	sent := 0
+	for _, alert := range alerts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := w.mailer.SendAttendanceShortageEmail(alert.StudentEmail, alert.StudentName, alert.Semester, alert.AttendanceRate, alert.Threshold); err != nil {
+			w.logger.Warn("Failed to send attendance shortage alert", "alert_id", alert.ID, "attempt", alert.Attempts+1, "error", err)
+			if err := w.repo.RecordShortageAlertFailure(ctx, alert.ID, err.Error()); err != nil {
+				w.logger.Error("Failed to record attendance shortage alert failure", "alert_id", alert.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := w.repo.MarkShortageAlertSent(ctx, alert.ID, time.Now()); err != nil {
+			w.logger.Error("Failed to mark attendance shortage alert sent", "alert_id", alert.ID, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	w.logger.Debug("Attendance shortage alerts sent", "pending", len(alerts), "sent", sent)
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"server/internal/config"
+	"server/internal/domain/quiz"
+	"server/internal/infrastructure/database/postgres/repositories"
+	"server/internal/infrastructure/sandbox"
+	"server/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// QuizAttemptWorker periodically submits the quiz attempts left in progress past their deadline,
+// grading them on the answers saved before it, and grades again the answers of submitted attempts
+// that the sandbox failed to grade
+type QuizAttemptWorker struct {
+	attemptService quiz.AttemptService
+	interval       time.Duration
+	batchSize      int
+	logger         *logger.Logger
+}
+
+// NewQuizAttemptWorker creates a new QuizAttemptWorker
+func NewQuizAttemptWorker(db *pgxpool.Pool, cfg *config.Config, log *logger.Logger) *QuizAttemptWorker {
+	return &QuizAttemptWorker{
+		attemptService: quiz.NewAttemptService(
+			repositories.NewPostgresQuizRepository(db, log),
+			sandbox.NewSubprocessRunner(cfg.Quiz.Sandbox, log),
+			quiz.AttemptPolicy{GracePeriod: cfg.Quiz.AttemptGracePeriod},
+			*log,
+		),
+		interval:  cfg.Quiz.AttemptSweepInterval,
+		batchSize: cfg.Quiz.AttemptSweepBatchSize,
+		logger:    log,
+	}
+}
+
+// Name identifies the worker in logs
+func (w *QuizAttemptWorker) Name() string {
+	return "quiz_attempt_sweeper"
+}
+
+// Start submits the expired attempts immediately and then once per interval until the context is cancelled
+func (w *QuizAttemptWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep submits expired attempts, then regrades submitted ones, batch by batch until a batch comes
+// back short
+func (w *QuizAttemptWorker) sweep(ctx context.Context) {
+	w.submitExpired(ctx)
+	w.regrade(ctx)
+}
+
+// submitExpired submits expired attempts batch by batch
+func (w *QuizAttemptWorker) submitExpired(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		submitted, err := w.attemptService.SubmitExpiredAttempts(ctx, w.batchSize)
+		if err != nil {
+			w.logger.Error("Failed to submit expired quiz attempts", "error", err)
+			return
+		}
+
+		total += submitted
+		if submitted < w.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		w.logger.Info("Expired quiz attempts submitted", "count", total)
+	}
+}
+
+// regrade grades again the flagged answers of submitted attempts batch by batch
+func (w *QuizAttemptWorker) regrade(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		regraded, err := w.attemptService.RegradeAttempts(ctx, w.batchSize)
+		if err != nil {
+			w.logger.Error("Failed to regrade quiz attempts", "error", err)
+			return
+		}
+
+		total += regraded
+		if regraded < w.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		w.logger.Info("Quiz attempts regraded", "count", total)
+	}
+}
@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"server/internal/config"
+	"server/internal/domain/platform_profile"
+	"server/internal/infrastructure/database/postgres/repositories"
+	"server/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// recoveryBinPurgeBatchSize is the number of profiles purged per transaction
+const recoveryBinPurgeBatchSize = 100
+
+// RecoveryBinWorker periodically purges soft deleted profiles that have been in the recovery bin
+// longer than the retention period. Every purge is recorded in the audit log.
+type RecoveryBinWorker struct {
+	repo      platform_profile.Repository
+	retention time.Duration
+	interval  time.Duration
+	logger    *logger.Logger
+}
+
+// NewRecoveryBinWorker creates a new RecoveryBinWorker
+func NewRecoveryBinWorker(db *pgxpool.Pool, cfg *config.Config, log *logger.Logger) *RecoveryBinWorker {
+	return &RecoveryBinWorker{
+		repo:      repositories.NewPostgresProfileRepository(db, log),
+		retention: cfg.Auth.DeletedProfileRetention,
+		interval:  cfg.Auth.DeletedProfilePurgeInterval,
+		logger:    log,
+	}
+}
+
+// Name identifies the worker in logs
+func (w *RecoveryBinWorker) Name() string {
+	return "recovery_bin_purge"
+}
+
+// Start runs a purge immediately and then once per interval until the context is cancelled
+func (w *RecoveryBinWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge deletes the expired profiles in batches until none are left. Failures are logged and
+// retried on the next run.
+func (w *RecoveryBinWorker) purge(ctx context.Context) {
+	deletedBefore := time.Now().Add(-w.retention)
+
+	total := 0
+	for ctx.Err() == nil {
+		purged, err := w.repo.PurgeSoftDeletedProfiles(ctx, deletedBefore, recoveryBinPurgeBatchSize)
+		if err != nil {
+			w.logger.Error("Failed to purge soft-deleted profiles", "error", err)
+			break
+		}
+
+		total += purged
+		if purged < recoveryBinPurgeBatchSize {
+			break
+		}
+	}
+
+	w.logger.Debug("Recovery bin purge finished", "purged", total, "deleted_before", deletedBefore)
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"server/internal/config"
+	"server/internal/domain/platform_profile"
+	"server/internal/infrastructure/database/postgres/repositories"
+	"server/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenCleanupWorker periodically deletes password reset tokens that are expired or used,
+// and abandoned external logins
+type TokenCleanupWorker struct {
+	repo     platform_profile.Repository
+	interval time.Duration
+	logger   *logger.Logger
+}
+
+// NewTokenCleanupWorker creates a new TokenCleanupWorker
+func NewTokenCleanupWorker(db *pgxpool.Pool, cfg *config.Config, log *logger.Logger) *TokenCleanupWorker {
+	return &TokenCleanupWorker{
+		repo:     repositories.NewPostgresProfileRepository(db, log),
+		interval: cfg.Auth.ResetTokenCleanupInterval,
+		logger:   log,
+	}
+}
+
+// Name identifies the worker in logs
+func (w *TokenCleanupWorker) Name() string {
+	return "token_cleanup"
+}
+
+// Start runs a cleanup immediately and then once per interval until the context is cancelled
+func (w *TokenCleanupWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanup deletes the expired tokens. Failures are logged and retried on the next run.
+func (w *TokenCleanupWorker) cleanup(ctx context.Context) {
+	deleted, err := w.repo.DeleteExpiredPasswordResetTokens(ctx)
+	if err != nil {
+		w.logger.Error("Failed to delete expired password reset tokens", "error", err)
+	} else {
+		w.logger.Debug("Password reset token cleanup finished", "deleted", deleted)
+	}
+
+	deleted, err = w.repo.DeleteExpiredOAuthStates(ctx)
+	if err != nil {
+		w.logger.Error("Failed to delete expired OAuth states", "error", err)
+	} else {
+		w.logger.Debug("OAuth state cleanup finished", "deleted", deleted)
+	}
+}
@@ -0,0 +1,11 @@
+package worker
+
+import "context"
+
+// Worker is a background job run by the worker process
+type Worker interface {
+	// Name identifies the worker in logs
+	Name() string
+	// Start runs the worker until the context is cancelled
+	Start(ctx context.Context) error
+}
@@ -0,0 +1,15 @@
+CREATE SCHEMA IF NOT EXISTS profile_schema;
+
+CREATE TABLE profile_schema.refresh_tokens (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	family_id UUID NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON profile_schema.refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_profile_id ON profile_schema.refresh_tokens (profile_id);
@@ -0,0 +1,13 @@
+CREATE TABLE profile_schema.sessions (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	user_agent VARCHAR(512) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	device_info VARCHAR(255) NOT NULL DEFAULT '',
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_sessions_profile_id ON profile_schema.sessions (profile_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS profile_schema.audit_logs CASCADE;
+DROP TABLE IF EXISTS profile_schema.mfa_challenges CASCADE;
+DROP TABLE IF EXISTS profile_schema.mfa_recovery_codes CASCADE;
+DROP TABLE IF EXISTS profile_schema.profile_mfa CASCADE;
@@ -0,0 +1,44 @@
+CREATE TABLE profile_schema.profile_mfa (
+	profile_id UUID PRIMARY KEY,
+	secret VARCHAR(64) NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	confirmed_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE profile_schema.mfa_recovery_codes (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	code_hash VARCHAR(255) NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_mfa_recovery_codes_profile_id ON profile_schema.mfa_recovery_codes (profile_id);
+
+CREATE TABLE profile_schema.mfa_challenges (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	user_agent VARCHAR(512) NOT NULL DEFAULT '',
+	ip_address VARCHAR(45) NOT NULL DEFAULT '',
+	attempts INT NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	consumed_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_mfa_challenges_profile_id ON profile_schema.mfa_challenges (profile_id);
+
+CREATE TABLE profile_schema.audit_logs (
+	id UUID PRIMARY KEY,
+	actor_profile_id UUID NOT NULL,
+	target_profile_id UUID NOT NULL,
+	action VARCHAR(64) NOT NULL,
+	details JSONB,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_audit_logs_target_profile_id ON profile_schema.audit_logs (target_profile_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_schema.login_ip_failures CASCADE;
+DROP TABLE IF EXISTS profile_schema.account_lockouts CASCADE;
@@ -0,0 +1,17 @@
+CREATE TABLE profile_schema.account_lockouts (
+	profile_id UUID PRIMARY KEY,
+	lock_count INT NOT NULL DEFAULT 0,
+	locked_until TIMESTAMP WITH TIME ZONE,
+	last_locked_at TIMESTAMP WITH TIME ZONE,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE profile_schema.login_ip_failures (
+	ip_address VARCHAR(45) PRIMARY KEY,
+	failure_count INT NOT NULL DEFAULT 0,
+	window_started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	blocked_until TIMESTAMP WITH TIME ZONE,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_login_ip_failures_updated_at ON profile_schema.login_ip_failures (updated_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS profile_schema.email_verification_tokens CASCADE;
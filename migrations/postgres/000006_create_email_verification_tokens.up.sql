@@ -0,0 +1,10 @@
+CREATE TABLE profile_schema.email_verification_tokens (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	token_hash VARCHAR(64) UNIQUE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_email_verification_tokens_profile_id ON profile_schema.email_verification_tokens (profile_id, created_at);
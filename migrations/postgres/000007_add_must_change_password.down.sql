@@ -0,0 +1,2 @@
+ALTER TABLE profile_schema.platform_profiles
+	DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,2 @@
+ALTER TABLE profile_schema.platform_profiles
+	ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
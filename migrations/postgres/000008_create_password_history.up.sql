@@ -0,0 +1,8 @@
+CREATE TABLE profile_schema.password_history (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_password_history_profile_id ON profile_schema.password_history (profile_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_schema.oauth_states CASCADE;
+DROP TABLE IF EXISTS profile_schema.linked_identities CASCADE;
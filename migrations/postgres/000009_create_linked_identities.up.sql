@@ -0,0 +1,23 @@
+CREATE TABLE profile_schema.linked_identities (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	provider VARCHAR(50) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	last_login_at TIMESTAMP WITH TIME ZONE,
+	UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_linked_identities_profile_id ON profile_schema.linked_identities (profile_id);
+
+CREATE TABLE profile_schema.oauth_states (
+	state_hash VARCHAR(64) PRIMARY KEY,
+	provider VARCHAR(50) NOT NULL,
+	code_verifier VARCHAR(128) NOT NULL,
+	nonce VARCHAR(128) NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_oauth_states_expires_at ON profile_schema.oauth_states (expires_at);
@@ -0,0 +1,18 @@
+CREATE TABLE profile_schema.api_keys (
+	id UUID PRIMARY KEY,
+	profile_id UUID NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	prefix VARCHAR(32) NOT NULL UNIQUE,
+	key_hash VARCHAR(64) NOT NULL,
+	scopes JSONB NOT NULL DEFAULT '[]'::jsonb,
+	allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_used_at TIMESTAMP WITH TIME ZONE,
+	last_used_ip VARCHAR(45),
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	rotated_from UUID REFERENCES profile_schema.api_keys (id) ON DELETE SET NULL,
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_api_keys_profile_id ON profile_schema.api_keys (profile_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.enrollment_master_lookup_table CASCADE;
+DROP TABLE IF EXISTS student_schema.student_scholarship_details_table CASCADE;
+DROP TABLE IF EXISTS student_schema.student_profile_details_table CASCADE;
+DROP TABLE IF EXISTS student_schema.student_family_details_table CASCADE;
+DROP TABLE IF EXISTS student_schema.student_documents_table CASCADE;
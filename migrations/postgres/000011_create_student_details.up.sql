@@ -0,0 +1,54 @@
+CREATE TABLE student_schema.student_documents_table (
+	DocumentID SERIAL PRIMARY KEY,
+	StoredIn VARCHAR(255) NOT NULL,
+	CreatedAt TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UpdatedAt TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	DocumentType VARCHAR(255),
+	URL VARCHAR(255)
+);
+
+ALTER TABLE student_schema.student_academic_details_table
+	ADD FOREIGN KEY (ClassTenMarksheetID) REFERENCES student_schema.student_documents_table (DocumentID) ON UPDATE CASCADE ON DELETE CASCADE,
+	ADD FOREIGN KEY (ClassTwelveMarksheetID) REFERENCES student_schema.student_documents_table (DocumentID) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE TABLE student_schema.student_family_details_table (
+	ID SERIAL PRIMARY KEY,
+	FatherName VARCHAR(100) NOT NULL,
+	FatherQualification VARCHAR(100) NOT NULL,
+	FatherProfession VARCHAR(100) NOT NULL,
+	MotherName VARCHAR(100) NOT NULL,
+	MotherQualification VARCHAR(100) NOT NULL,
+	MotherProfession VARCHAR(100) NOT NULL,
+	NoOfSiblings INT NOT NULL,
+	TotalFamilyIncome INT NOT NULL,
+	UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE student_schema.student_profile_details_table (
+	ID SERIAL PRIMARY KEY,
+	UserRole VARCHAR(3) DEFAULT 'STU' CHECK (UserRole IN ('STU', 'VOL', 'COR', 'ADM')) NOT NULL,
+	Name VARCHAR(100) NOT NULL,
+	Gender VARCHAR(1) CHECK (Gender IN ('M', 'F', 'O')) NOT NULL,
+	Category VARCHAR(3) CHECK (Category IN ('GEN', 'EWS', 'OBC', 'SC', 'ST')) NOT NULL,
+	PhotographID INT NOT NULL UNIQUE REFERENCES student_schema.student_documents_table (DocumentID) ON UPDATE CASCADE ON DELETE CASCADE,
+	ResumeID INT NOT NULL UNIQUE REFERENCES student_schema.student_documents_table (DocumentID) ON UPDATE CASCADE ON DELETE CASCADE,
+	CreatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE student_schema.student_scholarship_details_table (
+	ID SERIAL PRIMARY KEY,
+	ScholarshipName VARCHAR(100) NOT NULL,
+	ProvidedBy VARCHAR(100) NOT NULL,
+	AmountReceived INT NOT NULL,
+	UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE public.enrollment_master_lookup_table (
+	EnrollmentNo VARCHAR(12) PRIMARY KEY CHECK (char_length(EnrollmentNo) = 12),
+	LogInDetailsID INT NOT NULL UNIQUE REFERENCES student_schema.student_login_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE,
+	AcademicDetailsID INT NOT NULL UNIQUE REFERENCES student_schema.student_academic_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE,
+	FamilyDetailsID INT NOT NULL UNIQUE REFERENCES student_schema.student_family_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE,
+	ProfileDetailsID INT NOT NULL UNIQUE REFERENCES student_schema.student_profile_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE,
+	ScholarshipDetailsID INT NOT NULL UNIQUE REFERENCES student_schema.student_scholarship_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+CREATE TABLE student_schema.roster_imports (
+	id UUID PRIMARY KEY,
+	file_name VARCHAR(255) NOT NULL,
+	status VARCHAR(20) DEFAULT 'previewed' CHECK (status IN ('previewed', 'committing', 'committed')) NOT NULL,
+	ignored_columns JSONB NOT NULL DEFAULT '[]'::jsonb,
+	total_rows INT NOT NULL,
+	valid_rows INT NOT NULL,
+	rows JSONB NOT NULL,
+	result JSONB,
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	committed_by UUID,
+	committed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_roster_imports_expires_at ON student_schema.roster_imports (expires_at) WHERE status = 'previewed';
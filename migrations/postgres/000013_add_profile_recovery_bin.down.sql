@@ -0,0 +1,9 @@
+ALTER TABLE public.enrollment_master_lookup_table
+	DROP COLUMN IF EXISTS DeletedAt;
+
+DROP INDEX IF EXISTS profile_schema.idx_deleted_profiles_deleted_at;
+
+ALTER TABLE profile_schema.deleted_profiles
+	DROP COLUMN IF EXISTS reason,
+	DROP COLUMN IF EXISTS deleted_by,
+	DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,9 @@
+ALTER TABLE profile_schema.deleted_profiles
+	ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN IF NOT EXISTS deleted_by UUID,
+	ADD COLUMN IF NOT EXISTS reason VARCHAR(500);
+
+CREATE INDEX IF NOT EXISTS idx_deleted_profiles_deleted_at ON profile_schema.deleted_profiles (deleted_at);
+
+ALTER TABLE public.enrollment_master_lookup_table
+	ADD COLUMN DeletedAt TIMESTAMP WITH TIME ZONE;
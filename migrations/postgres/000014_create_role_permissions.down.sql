@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS profile_schema.role_permissions CASCADE;
+DROP TABLE IF EXISTS profile_schema.permissions CASCADE;
+DROP INDEX IF EXISTS profile_schema.idx_profile_roles_role_id;
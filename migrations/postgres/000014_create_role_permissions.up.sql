@@ -0,0 +1,80 @@
+-- Roles and profile roles predate the migrations, they are only created on fresh databases
+CREATE TABLE IF NOT EXISTS profile_schema.roles (
+	id UUID PRIMARY KEY,
+	name VARCHAR(50) NOT NULL UNIQUE,
+	description VARCHAR(255),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE profile_schema.roles
+	ADD COLUMN IF NOT EXISTS description VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS profile_schema.profile_roles (
+	profile_id UUID NOT NULL,
+	role_id UUID NOT NULL REFERENCES profile_schema.roles (id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	UNIQUE (profile_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_roles_role_id ON profile_schema.profile_roles (role_id);
+
+CREATE TABLE profile_schema.permissions (
+	id UUID PRIMARY KEY,
+	resource VARCHAR(50) NOT NULL,
+	action VARCHAR(20) NOT NULL,
+	description VARCHAR(255),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	UNIQUE (resource, action)
+);
+
+CREATE TABLE profile_schema.role_permissions (
+	role_id UUID NOT NULL REFERENCES profile_schema.roles (id) ON DELETE CASCADE,
+	permission_id UUID NOT NULL REFERENCES profile_schema.permissions (id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE INDEX idx_role_permissions_permission_id ON profile_schema.role_permissions (permission_id);
+
+INSERT INTO profile_schema.roles (id, name, description)
+VALUES
+	(gen_random_uuid(), 'admin', 'Full access to the platform'),
+	(gen_random_uuid(), 'coordinator', 'Manages students and quizzes'),
+	(gen_random_uuid(), 'student', 'Takes quizzes'),
+	(gen_random_uuid(), 'faculty', 'Creates and grades quizzes'),
+	(gen_random_uuid(), 'guest', 'Default role of new profiles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO profile_schema.permissions (id, resource, action, description)
+VALUES
+	(gen_random_uuid(), 'profile', 'manage', 'Every action on profiles'),
+	(gen_random_uuid(), 'student', 'manage', 'Every action on students'),
+	(gen_random_uuid(), 'quiz', 'manage', 'Every action on quizzes');
+
+INSERT INTO profile_schema.role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM profile_schema.roles r
+CROSS JOIN profile_schema.permissions p
+WHERE r.name = 'admin' AND p.action = 'manage';
+
+-- Fine-grained permissions for the roles that don't manage a resource outright
+INSERT INTO profile_schema.permissions (id, resource, action, description)
+VALUES
+	(gen_random_uuid(), 'student', 'read', 'View students'),
+	(gen_random_uuid(), 'quiz', 'read', 'View and attempt published quizzes');
+
+-- coordinator manages students and quizzes, faculty manages quizzes and views students, student
+-- attempts quizzes
+INSERT INTO profile_schema.role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM profile_schema.roles r
+JOIN profile_schema.permissions p ON (r.name, p.resource, p.action) IN (
+	('coordinator', 'student', 'manage'),
+	('coordinator', 'quiz', 'manage'),
+	('faculty', 'quiz', 'manage'),
+	('faculty', 'student', 'read'),
+	('student', 'quiz', 'read')
+);
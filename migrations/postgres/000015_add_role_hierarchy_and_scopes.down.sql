@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS student_schema.idx_student_academic_details_branch_year;
+
+DELETE FROM profile_schema.profile_roles WHERE scope_branch <> '' OR scope_batch <> '';
+
+ALTER TABLE profile_schema.profile_roles
+	DROP CONSTRAINT IF EXISTS profile_roles_profile_id_role_id_scope_key,
+	DROP COLUMN IF EXISTS scope_branch,
+	DROP COLUMN IF EXISTS scope_batch,
+	ADD CONSTRAINT profile_roles_profile_id_role_id_key UNIQUE (profile_id, role_id);
+
+DELETE FROM profile_schema.role_permissions rp
+USING profile_schema.roles r
+WHERE rp.role_id = r.id AND r.name = 'volunteer';
+
+ALTER TABLE profile_schema.roles DROP COLUMN IF EXISTS inherits_from;
@@ -0,0 +1,30 @@
+ALTER TABLE profile_schema.roles
+	ADD COLUMN inherits_from UUID REFERENCES profile_schema.roles (id) ON DELETE SET NULL;
+
+-- A profile may hold a role in several scopes, an empty branch or batch matches every one
+ALTER TABLE profile_schema.profile_roles
+	ADD COLUMN scope_branch VARCHAR(7) NOT NULL DEFAULT '',
+	ADD COLUMN scope_batch VARCHAR(4) NOT NULL DEFAULT '',
+	DROP CONSTRAINT IF EXISTS profile_roles_profile_id_role_id_key,
+	ADD CONSTRAINT profile_roles_profile_id_role_id_scope_key UNIQUE (profile_id, role_id, scope_branch, scope_batch);
+
+INSERT INTO profile_schema.roles (id, name, description)
+VALUES (gen_random_uuid(), 'volunteer', 'Helps coordinators with their students')
+ON CONFLICT (name) DO NOTHING;
+
+-- admin inherits from coordinator, which inherits from volunteer
+UPDATE profile_schema.roles r SET inherits_from = parent.id
+FROM profile_schema.roles parent
+WHERE (r.name, parent.name) IN (('admin', 'coordinator'), ('coordinator', 'volunteer'));
+
+-- volunteer views students, the roles above it inherit that
+INSERT INTO profile_schema.role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM profile_schema.roles r
+JOIN profile_schema.permissions p ON p.resource = 'student' AND p.action = 'read'
+WHERE r.name = 'volunteer'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- Scoped list queries look students up by branch and admission year
+CREATE INDEX IF NOT EXISTS idx_student_academic_details_branch_year
+	ON student_schema.student_academic_details_table (Branch, YearOfEnrollment);
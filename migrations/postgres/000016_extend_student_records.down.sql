@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS student_schema.student_contacts_table;
+DROP TABLE IF EXISTS student_schema.student_preferences_table;
+DROP TABLE IF EXISTS student_schema.student_attendance_records_table;
+DROP TABLE IF EXISTS student_schema.student_attendance_table;
+
+ALTER TABLE student_schema.student_academic_details_table
+	DROP COLUMN IF EXISTS Program,
+	DROP COLUMN IF EXISTS YearOfGraduation,
+	DROP COLUMN IF EXISTS PresentSemester,
+	DROP COLUMN IF EXISTS Section;
+
+ALTER TABLE student_schema.student_profile_details_table
+	DROP COLUMN IF EXISTS DateOfBirth,
+	DROP COLUMN IF EXISTS PresentAddress,
+	DROP COLUMN IF EXISTS PermanentAddress;
+
+ALTER TABLE student_schema.student_login_details_table
+	DROP COLUMN IF EXISTS LastLoginAt;
+
+ALTER TABLE public.enrollment_master_lookup_table
+	DROP CONSTRAINT IF EXISTS enrollment_master_lookup_table_id_key,
+	DROP COLUMN IF EXISTS ID,
+	DROP COLUMN IF EXISTS Status,
+	DROP COLUMN IF EXISTS CreatedAt,
+	DROP COLUMN IF EXISTS UpdatedAt,
+	DROP COLUMN IF EXISTS DeactivatedAt;
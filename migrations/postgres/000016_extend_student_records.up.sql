@@ -0,0 +1,72 @@
+-- Students are referenced by a stable UUID, the enrollment number remains the natural key
+ALTER TABLE public.enrollment_master_lookup_table
+	ADD COLUMN ID UUID NOT NULL DEFAULT gen_random_uuid(),
+	ADD COLUMN Status VARCHAR(20) NOT NULL DEFAULT 'active'
+		CHECK (Status IN ('active', 'on_leave', 'graduated', 'suspended', 'deactivated', 'provisional')),
+	ADD COLUMN CreatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD COLUMN UpdatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ADD COLUMN DeactivatedAt TIMESTAMP WITH TIME ZONE,
+	ADD CONSTRAINT enrollment_master_lookup_table_id_key UNIQUE (ID);
+
+ALTER TABLE student_schema.student_login_details_table
+	ADD COLUMN LastLoginAt TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE student_schema.student_profile_details_table
+	ADD COLUMN DateOfBirth DATE,
+	ADD COLUMN PresentAddress JSONB NOT NULL DEFAULT '{}',
+	ADD COLUMN PermanentAddress JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE student_schema.student_academic_details_table
+	ADD COLUMN Program VARCHAR(100) NOT NULL DEFAULT '',
+	ADD COLUMN YearOfGraduation INT CHECK (YearOfGraduation BETWEEN 1990 AND 2100),
+	ADD COLUMN PresentSemester INT NOT NULL DEFAULT 1 CHECK (PresentSemester BETWEEN 1 AND 10),
+	ADD COLUMN Section VARCHAR(5) NOT NULL DEFAULT '';
+
+-- One attendance sheet per student, course and semester, the statistics are kept in sync with its records
+CREATE TABLE student_schema.student_attendance_table (
+	ID UUID PRIMARY KEY,
+	StudentID UUID NOT NULL REFERENCES public.enrollment_master_lookup_table (ID) ON DELETE CASCADE,
+	CourseID UUID NOT NULL,
+	Semester INT NOT NULL CHECK (Semester BETWEEN 1 AND 10),
+	TotalClasses INT NOT NULL DEFAULT 0,
+	PresentCount INT NOT NULL DEFAULT 0,
+	AbsentCount INT NOT NULL DEFAULT 0,
+	LeaveCount INT NOT NULL DEFAULT 0,
+	CreatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UpdatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (StudentID, CourseID, Semester)
+);
+
+CREATE TABLE student_schema.student_attendance_records_table (
+	AttendanceID UUID NOT NULL REFERENCES student_schema.student_attendance_table (ID) ON DELETE CASCADE,
+	Date DATE NOT NULL,
+	Status VARCHAR(10) NOT NULL CHECK (Status IN ('present', 'absent', 'leave')),
+	Remarks VARCHAR(255) NOT NULL DEFAULT '',
+	RecordedBy UUID NOT NULL,
+	RecordedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (AttendanceID, Date)
+);
+
+CREATE TABLE student_schema.student_preferences_table (
+	ID UUID PRIMARY KEY,
+	StudentID UUID NOT NULL UNIQUE REFERENCES public.enrollment_master_lookup_table (ID) ON DELETE CASCADE,
+	CalendarEnabled BOOLEAN NOT NULL DEFAULT FALSE,
+	CalendarProvider VARCHAR(50) NOT NULL DEFAULT '',
+	CalendarExternalID VARCHAR(255) NOT NULL DEFAULT '',
+	CreatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UpdatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE student_schema.student_contacts_table (
+	ID UUID PRIMARY KEY,
+	StudentID UUID NOT NULL REFERENCES public.enrollment_master_lookup_table (ID) ON DELETE CASCADE,
+	Name VARCHAR(100) NOT NULL,
+	Relation VARCHAR(50) NOT NULL,
+	Phone VARCHAR(15) NOT NULL,
+	Email VARCHAR(255) NOT NULL DEFAULT '',
+	IsEmergency BOOLEAN NOT NULL DEFAULT FALSE,
+	CreatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UpdatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_student_contacts_student_id ON student_schema.student_contacts_table (StudentID);
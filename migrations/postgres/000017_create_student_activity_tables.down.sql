@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS student_schema.student_practice_session_lookup_table;
+DROP TABLE IF EXISTS student_schema.student_practice_session_records;
+DROP TABLE IF EXISTS student_schema.student_leaderboard_lookup_table;
+DROP TABLE IF EXISTS student_schema.student_certification_lookup_table;
+DROP TABLE IF EXISTS student_schema.student_certification_and_achievements_details_table;
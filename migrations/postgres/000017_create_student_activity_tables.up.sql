@@ -0,0 +1,42 @@
+CREATE TABLE student_schema.student_certification_and_achievements_details_table (
+	ID SERIAL PRIMARY KEY,
+	CertificationName VARCHAR(255) NOT NULL,
+	IssuingAuthority VARCHAR(255) NOT NULL,
+	IssuingDate VARCHAR(10) NOT NULL,
+	DocumentID INT NOT NULL UNIQUE REFERENCES student_schema.student_documents_table (DocumentID) ON UPDATE CASCADE ON DELETE CASCADE,
+	UpdatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE student_schema.student_certification_lookup_table (
+	EnrollmentNo VARCHAR(12) NOT NULL REFERENCES public.enrollment_master_lookup_table (EnrollmentNo) ON UPDATE CASCADE ON DELETE CASCADE,
+	StudentCertificationDetailsID INT PRIMARY KEY REFERENCES student_schema.student_certification_and_achievements_details_table (ID) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE student_schema.student_leaderboard_lookup_table (
+	EnrollmentNo VARCHAR(12) NOT NULL REFERENCES public.enrollment_master_lookup_table (EnrollmentNo) ON UPDATE CASCADE ON DELETE CASCADE,
+	LeaderboardRecordID INT PRIMARY KEY REFERENCES student_schema.student_leaderboard_records_table (LeaderboardRecordID) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE student_schema.student_practice_session_records (
+	PracticeSessionID SERIAL PRIMARY KEY,
+	DomainID INT NOT NULL,
+	SubDomainID INT NOT NULL,
+	DifficultyLevelID INT NOT NULL,
+	QuestionsAttempted INT NOT NULL,
+	QuestionsCorrect INT NOT NULL,
+	ScoreEarned FLOAT NOT NULL,
+	StartTime TIMESTAMP WITH TIME ZONE NOT NULL,
+	EndTime TIMESTAMP WITH TIME ZONE NOT NULL,
+	Feedbacks VARCHAR(255) DEFAULT ''
+);
+
+CREATE TABLE student_schema.student_practice_session_lookup_table (
+	EnrollmentNo VARCHAR(12) NOT NULL REFERENCES public.enrollment_master_lookup_table (EnrollmentNo) ON UPDATE CASCADE ON DELETE CASCADE,
+	PracticeSessionID INT PRIMARY KEY REFERENCES student_schema.student_practice_session_records (PracticeSessionID) ON UPDATE CASCADE ON DELETE CASCADE,
+	Status VARCHAR(9) NOT NULL DEFAULT 'Active' CHECK (Status IN ('Submitted', 'Active', 'Force End'))
+);
+
+-- The dossier of a student reads every lookup table by enrollment number
+CREATE INDEX idx_student_certification_lookup_enrollment ON student_schema.student_certification_lookup_table (EnrollmentNo);
+CREATE INDEX idx_student_leaderboard_lookup_enrollment ON student_schema.student_leaderboard_lookup_table (EnrollmentNo);
+CREATE INDEX idx_student_practice_session_lookup_enrollment ON student_schema.student_practice_session_lookup_table (EnrollmentNo);
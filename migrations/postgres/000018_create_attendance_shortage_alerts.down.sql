@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS student_schema.idx_student_academic_details_section;
+DROP TABLE IF EXISTS student_schema.attendance_shortage_alerts;
@@ -0,0 +1,30 @@
+-- Queued when a student's attendance in a course falls below the shortage threshold, and sent by the
+-- attendance alert worker. An alert is resolved once the attendance recovers, so that a later
+-- shortage is alerted again.
+CREATE TABLE student_schema.attendance_shortage_alerts (
+	ID UUID PRIMARY KEY,
+	StudentID UUID NOT NULL REFERENCES public.enrollment_master_lookup_table (ID) ON DELETE CASCADE,
+	AttendanceID UUID NOT NULL REFERENCES student_schema.student_attendance_table (ID) ON DELETE CASCADE,
+	CourseID UUID NOT NULL,
+	Semester INT NOT NULL,
+	AttendanceRate REAL NOT NULL,
+	Threshold REAL NOT NULL,
+	Attempts INT NOT NULL DEFAULT 0,
+	LastError VARCHAR(500) NOT NULL DEFAULT '',
+	CreatedAt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	SentAt TIMESTAMP WITH TIME ZONE,
+	ResolvedAt TIMESTAMP WITH TIME ZONE
+);
+
+-- At most one open alert per attendance sheet
+CREATE UNIQUE INDEX idx_attendance_shortage_alerts_open
+	ON student_schema.attendance_shortage_alerts (AttendanceID)
+	WHERE ResolvedAt IS NULL;
+
+CREATE INDEX idx_attendance_shortage_alerts_pending
+	ON student_schema.attendance_shortage_alerts (CreatedAt)
+	WHERE SentAt IS NULL AND ResolvedAt IS NULL;
+
+-- Sections are marked together, their students are looked up by branch, admission year and section
+CREATE INDEX idx_student_academic_details_section
+	ON student_schema.student_academic_details_table (Branch, YearOfEnrollment, Section);
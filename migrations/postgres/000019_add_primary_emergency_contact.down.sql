@@ -0,0 +1,9 @@
+ALTER TABLE public.enrollment_master_lookup_table
+	DROP CONSTRAINT IF EXISTS enrollment_master_lookup_table_profile_id_key,
+	DROP COLUMN IF EXISTS ProfileID;
+
+DROP INDEX IF EXISTS student_schema.idx_student_contacts_primary;
+
+ALTER TABLE student_schema.student_contacts_table
+	DROP CONSTRAINT IF EXISTS student_contacts_primary_is_emergency,
+	DROP COLUMN IF EXISTS IsPrimary;
@@ -0,0 +1,30 @@
+-- A student has exactly one primary emergency contact once any emergency contact exists
+ALTER TABLE student_schema.student_contacts_table
+	ADD COLUMN IsPrimary BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD CONSTRAINT student_contacts_primary_is_emergency CHECK (NOT IsPrimary OR IsEmergency);
+
+-- The oldest emergency contact of every student becomes the primary one
+UPDATE student_schema.student_contacts_table c SET
+	IsPrimary = TRUE
+FROM (
+	SELECT DISTINCT ON (StudentID) ID
+	FROM student_schema.student_contacts_table
+	WHERE IsEmergency
+	ORDER BY StudentID, CreatedAt, ID
+) first_emergency
+WHERE c.ID = first_emergency.ID;
+
+CREATE UNIQUE INDEX idx_student_contacts_primary ON student_schema.student_contacts_table (StudentID) WHERE IsPrimary;
+
+-- The platform profile a student signs in with. Usernames can be changed, so ownership of a
+-- student record is never derived from them.
+ALTER TABLE public.enrollment_master_lookup_table
+	ADD COLUMN ProfileID UUID,
+	ADD CONSTRAINT enrollment_master_lookup_table_profile_id_key UNIQUE (ProfileID);
+
+-- Profiles created for a student got the enrollment number as username and the student's email
+UPDATE public.enrollment_master_lookup_table e SET ProfileID = pp.id
+FROM student_schema.student_login_details_table l, profile_schema.platform_profiles pp
+WHERE l.ID = e.LogInDetailsID
+	AND pp.username = e.EnrollmentNo
+	AND LOWER(pp.email) = LOWER(l.Email);
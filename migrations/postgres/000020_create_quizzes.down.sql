@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS quiz_schema.attempt_answers;
+DROP TABLE IF EXISTS quiz_schema.attempts;
+DROP TABLE IF EXISTS quiz_schema.questions;
+DROP TABLE IF EXISTS quiz_schema.quizzes;
+DROP SCHEMA IF EXISTS quiz_schema;
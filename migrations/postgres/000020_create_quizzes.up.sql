@@ -0,0 +1,61 @@
+CREATE SCHEMA IF NOT EXISTS quiz_schema;
+
+-- Domain, sub-domain and difficulty level use the IDs of practice sessions
+CREATE TABLE quiz_schema.quizzes (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	title VARCHAR(200) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	domain_id INT NOT NULL,
+	sub_domain_id INT NOT NULL DEFAULT 0,
+	difficulty_level_id INT NOT NULL,
+	time_limit_minutes INT NOT NULL CHECK (time_limit_minutes > 0),
+	passing_score REAL NOT NULL DEFAULT 0 CHECK (passing_score BETWEEN 0 AND 100),
+	published BOOLEAN NOT NULL DEFAULT FALSE,
+	published_at TIMESTAMP WITH TIME ZONE,
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_quizzes_domain ON quiz_schema.quizzes (domain_id, sub_domain_id, difficulty_level_id);
+
+-- Positions are checked at the end of the transaction, so that questions can be moved around
+CREATE TABLE quiz_schema.questions (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	quiz_id BIGINT NOT NULL REFERENCES quiz_schema.quizzes (id) ON DELETE CASCADE,
+	position INT NOT NULL CHECK (position > 0),
+	type VARCHAR(20) NOT NULL CHECK (type IN ('mcq', 'true_false', 'fill_blank')),
+	prompt TEXT NOT NULL,
+	options JSONB NOT NULL DEFAULT '[]'::jsonb,
+	answer TEXT NOT NULL,
+	explanation TEXT NOT NULL DEFAULT '',
+	points REAL NOT NULL CHECK (points > 0),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT questions_quiz_position_key UNIQUE (quiz_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Attempted quizzes can't be deleted, the attempts keep the scores of the students
+CREATE TABLE quiz_schema.attempts (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	quiz_id BIGINT NOT NULL REFERENCES quiz_schema.quizzes (id) ON DELETE RESTRICT,
+	student_id UUID NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'submitted')),
+	start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+	end_time TIMESTAMP WITH TIME ZONE,
+	score REAL NOT NULL DEFAULT 0,
+	max_score REAL NOT NULL DEFAULT 0,
+	passed BOOLEAN
+);
+
+CREATE INDEX idx_attempts_student_id ON quiz_schema.attempts (student_id, start_time DESC);
+CREATE INDEX idx_attempts_quiz_id ON quiz_schema.attempts (quiz_id);
+
+CREATE TABLE quiz_schema.attempt_answers (
+	attempt_id BIGINT NOT NULL REFERENCES quiz_schema.attempts (id) ON DELETE CASCADE,
+	question_id BIGINT NOT NULL REFERENCES quiz_schema.questions (id) ON DELETE CASCADE,
+	answer TEXT NOT NULL DEFAULT '',
+	is_correct BOOLEAN NOT NULL DEFAULT FALSE,
+	points_awarded REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (attempt_id, question_id)
+);
@@ -0,0 +1,56 @@
+-- Multi-select questions have no equivalent before the question documents and are dropped along
+-- with their answers
+DELETE FROM quiz_schema.questions WHERE content->>'type' = 'multi_select';
+
+ALTER TABLE quiz_schema.questions
+	ADD COLUMN type VARCHAR(20),
+	ADD COLUMN options JSONB NOT NULL DEFAULT '[]'::jsonb,
+	ADD COLUMN answer TEXT;
+
+UPDATE quiz_schema.questions q SET
+	type = q.content->>'type',
+	options = COALESCE(
+		(
+			SELECT jsonb_agg(o.value->'text' ORDER BY o.ordinality)
+			FROM jsonb_array_elements(q.content->'options') WITH ORDINALITY AS o (value, ordinality)
+		),
+		'[]'::jsonb
+	),
+	answer = CASE q.content->>'type'
+		WHEN 'mcq' THEN (
+			SELECT o.value->>'text'
+			FROM jsonb_array_elements(q.content->'options') AS o (value)
+			WHERE o.value->>'id' = q.content->>'answer'
+		)
+		WHEN 'true_false' THEN q.content->>'answer'
+		ELSE q.content#>>'{blanks,0,answers,0}'
+	END;
+
+ALTER TABLE quiz_schema.attempt_answers ADD COLUMN answer_text TEXT NOT NULL DEFAULT '';
+
+-- Multiple choice answers go back from option IDs to option texts
+UPDATE quiz_schema.attempt_answers a SET answer_text = COALESCE(
+	CASE
+		WHEN q.content->>'type' = 'mcq' THEN (
+			SELECT o.value->>'text'
+			FROM jsonb_array_elements(q.content->'options') AS o (value)
+			WHERE o.value->>'id' = a.answer#>>'{}'
+		)
+		WHEN jsonb_typeof(a.answer) = 'array' THEN a.answer->>0
+		WHEN jsonb_typeof(a.answer) = 'null' THEN ''
+		ELSE a.answer#>>'{}'
+	END,
+	''
+)
+FROM quiz_schema.questions q
+WHERE q.id = a.question_id;
+
+ALTER TABLE quiz_schema.attempt_answers DROP COLUMN answer;
+ALTER TABLE quiz_schema.attempt_answers RENAME COLUMN answer_text TO answer;
+
+ALTER TABLE quiz_schema.questions
+	DROP CONSTRAINT questions_content_type_check,
+	DROP COLUMN content,
+	ALTER COLUMN type SET NOT NULL,
+	ALTER COLUMN answer SET NOT NULL,
+	ADD CONSTRAINT questions_type_check CHECK (type IN ('mcq', 'true_false', 'fill_blank'));
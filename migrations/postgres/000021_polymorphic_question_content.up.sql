@@ -0,0 +1,59 @@
+-- Questions keep their type-specific content, answers included, in a single document whose
+-- "type" field tells the question types apart. Options of choice questions get IDs, numbered
+-- from 1, and answers refer to them.
+ALTER TABLE quiz_schema.questions ADD COLUMN content JSONB;
+
+UPDATE quiz_schema.questions q SET content = CASE q.type
+	WHEN 'mcq' THEN jsonb_build_object(
+		'type', 'mcq',
+		'options', (
+			SELECT jsonb_agg(jsonb_build_object('id', o.ordinality::text, 'text', o.value) ORDER BY o.ordinality)
+			FROM jsonb_array_elements_text(q.options) WITH ORDINALITY AS o (value, ordinality)
+		),
+		'answer', (
+			SELECT o.ordinality::text
+			FROM jsonb_array_elements_text(q.options) WITH ORDINALITY AS o (value, ordinality)
+			WHERE o.value = q.answer
+			LIMIT 1
+		)
+	)
+	WHEN 'true_false' THEN jsonb_build_object('type', 'true_false', 'answer', q.answer = 'true')
+	ELSE jsonb_build_object(
+		'type', 'fill_blank',
+		'blanks', jsonb_build_array(jsonb_build_object('answers', jsonb_build_array(q.answer)))
+	)
+END;
+
+-- Answers become documents shaped by the type of their question
+ALTER TABLE quiz_schema.attempt_answers ADD COLUMN answer_content JSONB NOT NULL DEFAULT 'null'::jsonb;
+
+UPDATE quiz_schema.attempt_answers a SET answer_content = CASE q.type
+	WHEN 'mcq' THEN COALESCE(
+		(
+			SELECT to_jsonb(o.ordinality::text)
+			FROM jsonb_array_elements_text(q.options) WITH ORDINALITY AS o (value, ordinality)
+			WHERE o.value = a.answer
+			LIMIT 1
+		),
+		to_jsonb(a.answer)
+	)
+	WHEN 'true_false' THEN CASE lower(trim(a.answer))
+		WHEN 'true' THEN 'true'::jsonb
+		WHEN 'false' THEN 'false'::jsonb
+		ELSE 'null'::jsonb
+	END
+	ELSE jsonb_build_array(a.answer)
+END
+FROM quiz_schema.questions q
+WHERE q.id = a.question_id;
+
+ALTER TABLE quiz_schema.attempt_answers DROP COLUMN answer;
+ALTER TABLE quiz_schema.attempt_answers RENAME COLUMN answer_content TO answer;
+
+ALTER TABLE quiz_schema.questions
+	ALTER COLUMN content SET NOT NULL,
+	ADD CONSTRAINT questions_content_type_check
+		CHECK (content->>'type' IN ('mcq', 'multi_select', 'true_false', 'fill_blank')),
+	DROP COLUMN type,
+	DROP COLUMN options,
+	DROP COLUMN answer;
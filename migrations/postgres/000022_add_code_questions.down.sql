@@ -0,0 +1,8 @@
+ALTER TABLE quiz_schema.attempt_answers DROP COLUMN IF EXISTS test_results;
+
+DELETE FROM quiz_schema.questions WHERE content->>'type' = 'code';
+
+ALTER TABLE quiz_schema.questions
+	DROP CONSTRAINT questions_content_type_check,
+	ADD CONSTRAINT questions_content_type_check
+		CHECK (content->>'type' IN ('mcq', 'multi_select', 'true_false', 'fill_blank'));
@@ -0,0 +1,7 @@
+ALTER TABLE quiz_schema.questions
+	DROP CONSTRAINT questions_content_type_check,
+	ADD CONSTRAINT questions_content_type_check
+		CHECK (content->>'type' IN ('mcq', 'multi_select', 'true_false', 'fill_blank', 'code'));
+
+-- Outcome of every test case run for the answers to code questions
+ALTER TABLE quiz_schema.attempt_answers ADD COLUMN test_results JSONB;
@@ -0,0 +1,18 @@
+ALTER TABLE quiz_schema.attempts
+	DROP COLUMN IF EXISTS next_sweep_at,
+	DROP COLUMN IF EXISTS sweep_failures;
+
+DROP INDEX IF EXISTS quiz_schema.idx_attempt_answers_needs_regrade;
+
+ALTER TABLE quiz_schema.attempt_answers DROP COLUMN IF EXISTS needs_regrade;
+
+ALTER TABLE quiz_schema.attempt_answers DROP COLUMN IF EXISTS saved_at;
+
+DROP INDEX IF EXISTS quiz_schema.idx_attempts_in_progress_student;
+DROP INDEX IF EXISTS quiz_schema.idx_attempts_in_progress_deadline;
+
+ALTER TABLE quiz_schema.attempts
+	DROP COLUMN IF EXISTS auto_submitted,
+	DROP COLUMN IF EXISTS deadline;
+
+ALTER TABLE quiz_schema.quizzes DROP COLUMN IF EXISTS max_concurrent_attempts;
@@ -0,0 +1,34 @@
+ALTER TABLE quiz_schema.quizzes
+	ADD COLUMN max_concurrent_attempts INT NOT NULL DEFAULT 1 CHECK (max_concurrent_attempts > 0);
+
+-- Deadlines are set when attempts start, attempts already running get the time limit of their quiz
+ALTER TABLE quiz_schema.attempts
+	ADD COLUMN deadline TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN auto_submitted BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE quiz_schema.attempts a SET deadline = a.start_time + make_interval(mins => q.time_limit_minutes)
+FROM quiz_schema.quizzes q
+WHERE q.id = a.quiz_id;
+
+ALTER TABLE quiz_schema.attempts ALTER COLUMN deadline SET NOT NULL;
+
+-- The sweeper looks for attempts in progress past their deadline, starting an attempt counts the
+-- ones the student already has running
+CREATE INDEX idx_attempts_in_progress_deadline ON quiz_schema.attempts (deadline) WHERE status = 'in_progress';
+CREATE INDEX idx_attempts_in_progress_student ON quiz_schema.attempts (quiz_id, student_id) WHERE status = 'in_progress';
+
+-- Answers are saved one question at a time while the attempt runs and graded on submission
+ALTER TABLE quiz_schema.attempt_answers
+	ADD COLUMN saved_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL;
+
+-- Answers the sandbox failed to grade are submitted with no points and flagged to be graded again
+ALTER TABLE quiz_schema.attempt_answers
+	ADD COLUMN needs_regrade BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_attempt_answers_needs_regrade ON quiz_schema.attempt_answers (attempt_id) WHERE needs_regrade;
+
+-- Expired attempts that fail to be submitted are retried later and later, so that they don't hold
+-- up the ones behind them
+ALTER TABLE quiz_schema.attempts
+	ADD COLUMN sweep_failures INT NOT NULL DEFAULT 0,
+	ADD COLUMN next_sweep_at TIMESTAMP WITH TIME ZONE;
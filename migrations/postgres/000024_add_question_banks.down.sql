@@ -0,0 +1,19 @@
+-- Answers to questions drawn from the banks, or deleted from their quiz since, have no question of
+-- the quiz to go back to
+DELETE FROM quiz_schema.attempt_answers aa
+USING quiz_schema.attempts a
+WHERE a.id = aa.attempt_id
+	AND (a.paper_seed IS NOT NULL OR NOT EXISTS (SELECT 1 FROM quiz_schema.questions q WHERE q.id = aa.question_id));
+
+ALTER TABLE quiz_schema.attempt_answers
+	DROP CONSTRAINT IF EXISTS attempt_answers_paper_question_fkey,
+	ADD CONSTRAINT attempt_answers_question_id_fkey FOREIGN KEY (question_id)
+		REFERENCES quiz_schema.questions (id) ON DELETE CASCADE;
+
+DROP TABLE IF EXISTS quiz_schema.attempt_questions;
+
+ALTER TABLE quiz_schema.attempts DROP COLUMN IF EXISTS paper_seed;
+ALTER TABLE quiz_schema.quizzes DROP COLUMN IF EXISTS blueprint;
+
+DROP TABLE IF EXISTS quiz_schema.bank_questions;
+DROP TABLE IF EXISTS quiz_schema.question_banks;
@@ -0,0 +1,62 @@
+-- Question banks are tagged with the domain, sub-domain and difficulty level IDs of practice
+-- sessions, sub-domain 0 holds the questions of the domain as a whole
+CREATE TABLE quiz_schema.question_banks (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	name VARCHAR(200) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	domain_id INT NOT NULL,
+	sub_domain_id INT NOT NULL DEFAULT 0,
+	difficulty_level_id INT NOT NULL,
+	created_by UUID NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_question_banks_tags ON quiz_schema.question_banks (domain_id, difficulty_level_id, sub_domain_id);
+
+-- Bank questions have no points, the blueprint rule drawing them sets them
+CREATE TABLE quiz_schema.bank_questions (
+	id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+	bank_id BIGINT NOT NULL REFERENCES quiz_schema.question_banks (id) ON DELETE CASCADE,
+	prompt TEXT NOT NULL,
+	content JSONB NOT NULL CONSTRAINT bank_questions_content_type_check
+		CHECK (content->>'type' IN ('mcq', 'multi_select', 'true_false', 'fill_blank', 'code')),
+	explanation TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_bank_questions_bank_id ON quiz_schema.bank_questions (bank_id);
+
+-- Rules drawing the questions of the quiz from the banks, empty for quizzes with their own questions
+ALTER TABLE quiz_schema.quizzes ADD COLUMN blueprint JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Seed of the questions drawn for attempts of quizzes with a blueprint
+ALTER TABLE quiz_schema.attempts ADD COLUMN paper_seed BIGINT;
+
+-- Every attempt keeps a copy of the questions it was given, in the order it was given them.
+-- Question IDs refer to the questions of the quiz or, for quizzes with a blueprint, of the banks,
+-- which may have changed or gone since.
+CREATE TABLE quiz_schema.attempt_questions (
+	attempt_id BIGINT NOT NULL REFERENCES quiz_schema.attempts (id) ON DELETE CASCADE,
+	question_id BIGINT NOT NULL,
+	position INT NOT NULL CHECK (position > 0),
+	prompt TEXT NOT NULL,
+	content JSONB NOT NULL,
+	explanation TEXT NOT NULL DEFAULT '',
+	points REAL NOT NULL CHECK (points > 0),
+	PRIMARY KEY (attempt_id, question_id),
+	CONSTRAINT attempt_questions_attempt_position_key UNIQUE (attempt_id, position)
+);
+
+-- Attempts started before papers get the current questions of their quiz
+INSERT INTO quiz_schema.attempt_questions (attempt_id, question_id, position, prompt, content, explanation, points)
+SELECT a.id, q.id, q.position, q.prompt, q.content, q.explanation, q.points
+FROM quiz_schema.attempts a
+JOIN quiz_schema.questions q ON q.quiz_id = a.quiz_id;
+
+-- Answers belong to the paper of their attempt rather than to the questions of the quiz
+ALTER TABLE quiz_schema.attempt_answers
+	DROP CONSTRAINT attempt_answers_question_id_fkey,
+	ADD CONSTRAINT attempt_answers_paper_question_fkey FOREIGN KEY (attempt_id, question_id)
+		REFERENCES quiz_schema.attempt_questions (attempt_id, question_id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Tokens can't be recovered from their hashes, pending reset links have to be requested again
+DELETE FROM profile_schema.password_reset_tokens;
+
+ALTER TABLE profile_schema.password_reset_tokens RENAME COLUMN token_hash TO token;
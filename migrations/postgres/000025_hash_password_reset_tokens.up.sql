@@ -0,0 +1,4 @@
+-- Reset links were stored as sent, so anyone able to read the table could reset any password.
+-- Only the SHA-256 hash of a link's token is kept from now on, links already sent keep working.
+ALTER TABLE profile_schema.password_reset_tokens RENAME COLUMN token TO token_hash;
+ALTER TABLE profile_schema.password_reset_tokens ALTER COLUMN token_hash TYPE VARCHAR(64) USING encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
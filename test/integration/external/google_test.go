@@ -0,0 +1,350 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	domainerrors "server/internal/common/errors"
+	"server/internal/config"
+	"server/internal/domain/platform_profile"
+	"server/internal/infrastructure/auth"
+	"server/internal/infrastructure/integration/google"
+	"server/pkg/logger"
+	"server/test/integration/external/mock_services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testClientID     = "test-client.apps.googleusercontent.com"
+	testClientSecret = "test-client-secret"
+	testRedirectURL  = "http://localhost:3000/auth/google/callback"
+)
+
+func newFakeGoogle(t *testing.T) *mock_services.FakeOIDCIssuer {
+	t.Helper()
+
+	issuer, err := mock_services.NewFakeOIDCIssuer(testClientID, testClientSecret)
+	if err != nil {
+		t.Fatalf("failed to start fake issuer: %v", err)
+	}
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+func newGoogleProvider(t *testing.T, issuer *mock_services.FakeOIDCIssuer, hostedDomain string) *google.AuthProvider {
+	t.Helper()
+
+	provider, err := google.NewAuthProvider(config.GoogleOAuthConfig{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		IssuerURL:    issuer.URL(),
+		HostedDomain: hostedDomain,
+		JWKSCacheTTL: time.Hour,
+	}, issuer.Client())
+	if err != nil {
+		t.Fatalf("failed to create Google provider: %v", err)
+	}
+
+	return provider
+}
+
+var student = mock_services.FakeUser{
+	Subject:       "108234567890123456789",
+	Email:         "Student@RGPV.ac.in",
+	EmailVerified: true,
+	Name:          "Test Student",
+	HostedDomain:  "rgpv.ac.in",
+}
+
+func TestGoogleLoginWithPKCE(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	provider := newGoogleProvider(t, issuer, "")
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "verifier-verifier-verifier-verifier-verifier", "nonce-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("state") != "state-1" || query.Get("redirect_uri") != testRedirectURL {
+		t.Fatalf("authorization URL misses state or redirect URI: %s", authURL)
+	}
+	if query.Get("code_challenge") != auth.PKCEChallenge("verifier-verifier-verifier-verifier-verifier") {
+		t.Fatalf("authorization URL carries wrong PKCE challenge: %s", authURL)
+	}
+
+	code, err := issuer.Authorize(authURL, student)
+	if err != nil {
+		t.Fatalf("fake consent failed: %v", err)
+	}
+
+	identity, err := provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	if identity.Provider != "google" || identity.Subject != student.Subject {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+	if identity.Email != "student@rgpv.ac.in" || !identity.EmailVerified {
+		t.Errorf("expected verified, lower cased email, got %q verified=%v", identity.Email, identity.EmailVerified)
+	}
+}
+
+func TestGoogleLoginRejectsWrongCodeVerifier(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	provider := newGoogleProvider(t, issuer, "")
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "the-real-verifier-the-real-verifier-0000", "nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	code, err := issuer.Authorize(authURL, student)
+	if err != nil {
+		t.Fatalf("fake consent failed: %v", err)
+	}
+
+	_, err = provider.Exchange(ctx, code, "an-intercepted-code-without-verifier-0000", "nonce")
+	if !errors.Is(err, auth.ErrOIDCExchange) {
+		t.Fatalf("expected exchange to fail, got %v", err)
+	}
+}
+
+func TestGoogleLoginRejectsNonceMismatch(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	provider := newGoogleProvider(t, issuer, "")
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "verifier-verifier-verifier-verifier-verifier", "nonce-sent")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	code, err := issuer.Authorize(authURL, student)
+	if err != nil {
+		t.Fatalf("fake consent failed: %v", err)
+	}
+
+	_, err = provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce-expected")
+	if !errors.Is(err, auth.ErrInvalidIDToken) {
+		t.Fatalf("expected nonce mismatch to be rejected, got %v", err)
+	}
+}
+
+func TestGoogleLoginRejectsExpiredIDToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	issuer.TokenTTL = -10 * time.Minute
+	provider := newGoogleProvider(t, issuer, "")
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "verifier-verifier-verifier-verifier-verifier", "nonce")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	code, err := issuer.Authorize(authURL, student)
+	if err != nil {
+		t.Fatalf("fake consent failed: %v", err)
+	}
+
+	_, err = provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+	if !errors.Is(err, auth.ErrExpiredToken) {
+		t.Fatalf("expected expired ID token to be rejected, got %v", err)
+	}
+}
+
+func TestGoogleLoginEnforcesHostedDomain(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	provider := newGoogleProvider(t, issuer, "rgpv.ac.in")
+
+	outsider := student
+	outsider.Email = "someone@gmail.com"
+	outsider.HostedDomain = ""
+
+	for _, tc := range []struct {
+		name    string
+		user    mock_services.FakeUser
+		allowed bool
+	}{
+		{name: "domain account", user: student, allowed: true},
+		{name: "consumer account", user: outsider, allowed: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			authURL, err := provider.AuthCodeURL(ctx, "state", "verifier-verifier-verifier-verifier-verifier", "nonce")
+			if err != nil {
+				t.Fatalf("AuthCodeURL failed: %v", err)
+			}
+			code, err := issuer.Authorize(authURL, tc.user)
+			if err != nil {
+				t.Fatalf("fake consent failed: %v", err)
+			}
+
+			_, err = provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+			if tc.allowed && err != nil {
+				t.Fatalf("expected login to succeed, got %v", err)
+			}
+			if !tc.allowed && !errors.Is(err, auth.ErrInvalidIDToken) {
+				t.Fatalf("expected login to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOIDCClientCachesJWKSAndFollowsKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+
+	client, err := auth.NewOIDCClient(auth.OIDCConfig{
+		IssuerURL:              issuer.URL(),
+		ClientID:               testClientID,
+		ClientSecret:           testClientSecret,
+		RedirectURL:            testRedirectURL,
+		JWKSMinRefreshInterval: time.Millisecond,
+	}, issuer.Client())
+	if err != nil {
+		t.Fatalf("failed to create OIDC client: %v", err)
+	}
+
+	login := func() error {
+		authURL, err := client.AuthCodeURL(ctx, "state", "verifier-verifier-verifier-verifier-verifier", "nonce")
+		if err != nil {
+			return err
+		}
+		code, err := issuer.Authorize(authURL, student)
+		if err != nil {
+			return err
+		}
+		_, err = client.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := login(); err != nil {
+			t.Fatalf("login %d failed: %v", i+1, err)
+		}
+	}
+	if requests := issuer.JWKSRequests(); requests != 1 {
+		t.Fatalf("expected the JWKS to be fetched once, got %d requests", requests)
+	}
+
+	if err := issuer.RotateKey(); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if err := login(); err != nil {
+		t.Fatalf("login after key rotation failed: %v", err)
+	}
+	if requests := issuer.JWKSRequests(); requests != 2 {
+		t.Fatalf("expected the JWKS to be fetched again after rotation, got %d requests", requests)
+	}
+}
+
+func newProfileService(t *testing.T, repo platform_profile.Repository, provider platform_profile.IdentityProvider) platform_profile.Service {
+	t.Helper()
+
+	tokens, err := auth.NewJWTProvider("test-jwt-secret-test-jwt-secret-0000", config.AuthConfig{
+		TokenIssuer:     "test",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("failed to create token provider: %v", err)
+	}
+
+	return platform_profile.NewService(
+		repo,
+		nil,
+		tokens,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		map[string]platform_profile.IdentityProvider{platform_profile.IdentityProviderGoogle: provider},
+		platform_profile.SecuritySettings{OAuthStateTTL: time.Minute},
+		*logger.NewLogger(),
+	)
+}
+
+func TestGoogleLoginLinksOnlyVerifiedProfiles(t *testing.T) {
+	ctx := context.Background()
+	issuer := newFakeGoogle(t)
+	provider := newGoogleProvider(t, issuer, "")
+	verifiedAt := time.Now().Add(-24 * time.Hour)
+
+	for _, tc := range []struct {
+		name       string
+		verifiedAt *time.Time
+		linked     bool
+	}{
+		// Anyone can register a profile with someone else's address and wait for its owner
+		// to log in with Google, so the profile has to prove it owns the address first
+		{name: "unverified profile", verifiedAt: nil, linked: false},
+		{name: "verified profile", verifiedAt: &verifiedAt, linked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &platform_profile.PlatformProfile{
+				ID:           uuid.New(),
+				Username:     "student",
+				Email:        "student@rgpv.ac.in",
+				PasswordHash: "hash-of-a-password-set-by-whoever-registered",
+				Status:       platform_profile.StatusActivated,
+				VerifiedAt:   tc.verifiedAt,
+			}
+			if tc.verifiedAt == nil {
+				profile.Status = platform_profile.StatusPending
+			}
+			repo := mock_services.NewFakeProfileRepository(profile)
+			service := newProfileService(t, repo, provider)
+
+			authorization, err := service.BeginOAuthLogin(ctx, platform_profile.IdentityProviderGoogle)
+			if err != nil {
+				t.Fatalf("BeginOAuthLogin failed: %v", err)
+			}
+			code, err := issuer.Authorize(authorization.AuthorizationURL, student)
+			if err != nil {
+				t.Fatalf("fake consent failed: %v", err)
+			}
+
+			response, err := service.CompleteOAuthLogin(ctx, platform_profile.OAuthCallbackRequest{
+				Provider: platform_profile.IdentityProviderGoogle,
+				Code:     code,
+				State:    authorization.State,
+			})
+
+			identities := repo.LinkedIdentities()
+			if !tc.linked {
+				var domainErr *domainerrors.DomainError
+				if !errors.As(err, &domainErr) || domainErr.Code != "PROFILE_EMAIL_NOT_VERIFIED" {
+					t.Fatalf("expected login to be refused, got %v", err)
+				}
+				if len(identities) != 0 || len(repo.Sessions()) != 0 {
+					t.Fatalf("refused login linked %d identities and created %d sessions", len(identities), len(repo.Sessions()))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected login to succeed, got %v", err)
+			}
+			if response.Profile.ID != profile.ID || response.Tokens == nil {
+				t.Fatalf("unexpected login response %+v", response)
+			}
+			if len(identities) != 1 || identities[0].ProfileID != profile.ID || identities[0].Subject != student.Subject {
+				t.Fatalf("expected the identity to be linked to the profile, got %+v", identities)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package mock_services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FakeUser is the Google account that consents on the fake authorization page
+type FakeUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	HostedDomain  string
+}
+
+// pendingCode is an authorization code waiting to be redeemed at the token endpoint
+type pendingCode struct {
+	user          FakeUser
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+}
+
+// FakeOIDCIssuer is a local OpenID Connect issuer behaving like accounts.google.com for the
+// authorization code flow with PKCE. It serves discovery, token and JWKS endpoints; the
+// authorization page is replaced by Authorize, which consents on behalf of a user.
+type FakeOIDCIssuer struct {
+	server *httptest.Server
+
+	clientID     string
+	clientSecret string
+
+	// TokenTTL is the lifetime of issued ID tokens, negative values issue expired tokens
+	TokenTTL time.Duration
+
+	mu           sync.Mutex
+	key          *rsa.PrivateKey
+	keyID        string
+	keyVersion   int
+	codes        map[string]pendingCode
+	jwksRequests int
+}
+
+// NewFakeOIDCIssuer starts a fake issuer accepting the given client registration
+func NewFakeOIDCIssuer(clientID, clientSecret string) (*FakeOIDCIssuer, error) {
+	issuer := &FakeOIDCIssuer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		TokenTTL:     5 * time.Minute,
+		codes:        make(map[string]pendingCode),
+	}
+	if err := issuer.RotateKey(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.handleDiscovery)
+	mux.HandleFunc("/token", issuer.handleToken)
+	mux.HandleFunc("/oauth2/v3/certs", issuer.handleJWKS)
+	issuer.server = httptest.NewServer(mux)
+
+	return issuer, nil
+}
+
+// URL is the issuer URL
+func (f *FakeOIDCIssuer) URL() string {
+	return f.server.URL
+}
+
+// Client returns an HTTP client for talking to the issuer
+func (f *FakeOIDCIssuer) Client() *http.Client {
+	return f.server.Client()
+}
+
+// Close shuts the issuer down
+func (f *FakeOIDCIssuer) Close() {
+	f.server.Close()
+}
+
+// RotateKey replaces the signing key, the old key is no longer published
+func (f *FakeOIDCIssuer) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.keyVersion++
+	f.key = key
+	f.keyID = fmt.Sprintf("fake-key-%d", f.keyVersion)
+	return nil
+}
+
+// JWKSRequests counts the JWKS downloads so far
+func (f *FakeOIDCIssuer) JWKSRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.jwksRequests
+}
+
+// Authorize validates an authorization URL the way Google's consent page does and returns
+// the code the user would be redirected back with
+func (f *FakeOIDCIssuer) Authorize(authorizationURL string, user FakeUser) (string, error) {
+	parsed, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+
+	switch {
+	case query.Get("client_id") != f.clientID:
+		return "", errors.New("unknown client_id")
+	case query.Get("response_type") != "code":
+		return "", errors.New("unsupported response_type")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", errors.New("PKCE with S256 is required")
+	case query.Get("state") == "" || query.Get("nonce") == "":
+		return "", errors.New("state and nonce are required")
+	}
+
+	code := rand.Text()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.codes[code] = pendingCode{
+		user:          user,
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+	}
+	return code, nil
+}
+
+func (f *FakeOIDCIssuer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                f.URL(),
+		"authorization_endpoint":                f.URL() + "/o/oauth2/v2/auth",
+		"token_endpoint":                        f.URL() + "/token",
+		"jwks_uri":                              f.URL() + "/oauth2/v3/certs",
+		"response_types_supported":              []string{"code"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (f *FakeOIDCIssuer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.jwksRequests++
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": f.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.PublicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func (f *FakeOIDCIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if r.PostForm.Get("client_id") != f.clientID || r.PostForm.Get("client_secret") != f.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Codes are single use, even when the exchange fails
+	code := r.PostForm.Get("code")
+	pending, ok := f.codes[code]
+	delete(f.codes, code)
+
+	verifierSum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		pending.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(verifierSum[:]) != pending.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            f.URL(),
+		"aud":            f.clientID,
+		"azp":            f.clientID,
+		"sub":            pending.user.Subject,
+		"email":          pending.user.Email,
+		"email_verified": pending.user.EmailVerified,
+		"name":           pending.user.Name,
+		"nonce":          pending.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(f.TokenTTL).Unix(),
+	}
+	if pending.user.HostedDomain != "" {
+		claims["hd"] = pending.user.HostedDomain
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.keyID
+	idToken, err := token.SignedString(f.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3599,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,173 @@
+package mock_services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"server/internal/domain/platform_profile"
+
+	"github.com/google/uuid"
+)
+
+// FakeProfileRepository keeps the profiles, OAuth states, linked identities and sessions of the
+// external login flow in memory. Every other repository method panics through the embedded nil
+// interface, so a test notices when the flow starts depending on more of the repository.
+type FakeProfileRepository struct {
+	platform_profile.Repository
+
+	mu         sync.Mutex
+	profiles   map[uuid.UUID]*platform_profile.PlatformProfile
+	states     map[string]*platform_profile.OAuthState
+	identities []*platform_profile.LinkedIdentity
+	sessions   []*platform_profile.Session
+}
+
+// NewFakeProfileRepository creates a repository holding the given profiles
+func NewFakeProfileRepository(profiles ...*platform_profile.PlatformProfile) *FakeProfileRepository {
+	repo := &FakeProfileRepository{
+		profiles: make(map[uuid.UUID]*platform_profile.PlatformProfile),
+		states:   make(map[string]*platform_profile.OAuthState),
+	}
+	for _, profile := range profiles {
+		repo.profiles[profile.ID] = profile
+	}
+
+	return repo
+}
+
+// LinkedIdentities returns the identities linked so far
+func (r *FakeProfileRepository) LinkedIdentities() []*platform_profile.LinkedIdentity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*platform_profile.LinkedIdentity(nil), r.identities...)
+}
+
+// Sessions returns the sessions created so far
+func (r *FakeProfileRepository) Sessions() []*platform_profile.Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*platform_profile.Session(nil), r.sessions...)
+}
+
+func (r *FakeProfileRepository) findByEmail(email string) *platform_profile.PlatformProfile {
+	for _, profile := range r.profiles {
+		if strings.EqualFold(profile.Email, email) {
+			return profile
+		}
+	}
+
+	return nil
+}
+
+func (r *FakeProfileRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.findByEmail(email) != nil, nil
+}
+
+func (r *FakeProfileRepository) GetProfileByEmail(ctx context.Context, email string) (*platform_profile.PlatformProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	profile := r.findByEmail(email)
+	if profile == nil {
+		return nil, nil
+	}
+	copied := *profile
+
+	return &copied, nil
+}
+
+func (r *FakeProfileRepository) GetProfileByID(ctx context.Context, id uuid.UUID) (*platform_profile.PlatformProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	profile, ok := r.profiles[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *profile
+
+	return &copied, nil
+}
+
+func (r *FakeProfileRepository) CreateOAuthState(ctx context.Context, state *platform_profile.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *FakeProfileRepository) ConsumeOAuthState(ctx context.Context, stateHash string) (*platform_profile.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.states, stateHash)
+
+	return state, nil
+}
+
+func (r *FakeProfileRepository) CreateLinkedIdentity(ctx context.Context, identity *platform_profile.LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *FakeProfileRepository) GetLinkedIdentity(ctx context.Context, provider string, subject string) (*platform_profile.LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *FakeProfileRepository) RecordIdentityLogin(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, identity := range r.identities {
+		if identity.ID == id {
+			identity.LastLoginAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (r *FakeProfileRepository) IsMFAEnabled(ctx context.Context, profileID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *FakeProfileRepository) RecordLogin(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (r *FakeProfileRepository) GetProfileRoleNames(ctx context.Context, profileID uuid.UUID) ([]string, error) {
+	return []string{"student"}, nil
+}
+
+func (r *FakeProfileRepository) CreateSession(ctx context.Context, session *platform_profile.Session, token *platform_profile.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions = append(r.sessions, session)
+	return nil
+}